@@ -0,0 +1,215 @@
+/*
+Package adif writes QSOs and decodes as records in the ADIF 3 format (ADI files), which is understood by virtually
+every logging software.
+*/
+package adif
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// Version is the version of the ADIF specification that the records comply to.
+const Version = "3.1.4"
+
+// DefaultProgramID is the program ID that is written to the header of a log file.
+const DefaultProgramID = "digimodes"
+
+const (
+	dateLayout      = "20060102"
+	timeLayout      = "150405"
+	timestampLayout = "20060102 150405"
+)
+
+// modes maps the names of the modes of this library to the ADIF mode and submode.
+var modes = map[string][2]string{
+	"cw":        {"CW", ""},
+	"psk31":     {"PSK", "PSK31"},
+	"psk63":     {"PSK", "PSK63"},
+	"psk125":    {"PSK", "PSK125"},
+	"psk250":    {"PSK", "PSK250"},
+	"rtty":      {"RTTY", ""},
+	"olivia":    {"OLIVIA", ""},
+	"contestia": {"CONTESTI", ""},
+	"rttym":     {"RTTYM", ""},
+	"wspr":      {"WSPR", ""},
+	"ft8":       {"FT8", ""},
+	"ft4":       {"MFSK", "FT4"},
+	"js8":       {"MFSK", "JS8"},
+	"sstv":      {"SSTV", ""},
+	"packet":    {"PKT", ""},
+}
+
+// Mode returns the ADIF mode and submode of the mode with the given name, e.g. "PSK" and "PSK31" for "psk31".
+// Unknown modes are returned in upper case without submode.
+func Mode(name string) (mode, submode string) {
+	m, ok := modes[strings.ToLower(name)]
+	if !ok {
+		return strings.ToUpper(name), ""
+	}
+	return m[0], m[1]
+}
+
+// Record is a QSO or a decode that is logged.
+type Record struct {
+	Call string
+	// Mode is the name of the mode, e.g. "psk31". It is converted with Mode.
+	Mode string
+	// Frequency is the RF frequency in Hz, 0 if unknown.
+	Frequency float64
+	Start     time.Time
+	// End is the end of the QSO, it is omitted if zero.
+	End          time.Time
+	RSTSent      string
+	RSTReceived  string
+	Gridsquare   string
+	MyCall       string
+	MyGridsquare string
+	Comment      string
+	// Fields contains additional fields, e.g. "TX_PWR" or "CONTEST_ID". The names are case insensitive.
+	Fields map[string]string
+}
+
+// RecordFromQSO returns the record of the given QSO that was completed by the sequencer on the given RF frequency
+// in Hz.
+func RecordFromQSO(qso sequencer.QSO, myCall, myGridsquare string, frequency float64) Record {
+	return Record{
+		Call:         qso.Call,
+		Mode:         qso.Mode,
+		Frequency:    frequency,
+		Start:        qso.Start,
+		End:          qso.End,
+		RSTSent:      sequencer.FormatReport(qso.SentReport),
+		RSTReceived:  sequencer.FormatReport(qso.ReceivedReport),
+		Gridsquare:   qso.Grid,
+		MyCall:       strings.ToUpper(myCall),
+		MyGridsquare: myGridsquare,
+	}
+}
+
+// RecordFromDecode returns the record of a decode of the station with the given callsign. Since it is no QSO, the
+// record is marked as SWL report, the decoded text is kept in the comment.
+func RecordFromDecode(record digimodes.DecodeRecord, call string, myCall string) Record {
+	return Record{
+		Call:        strings.ToUpper(call),
+		Mode:        record.Mode,
+		Frequency:   record.RFFrequency,
+		Start:       record.Time,
+		RSTReceived: sequencer.FormatReport(int(record.SNR)),
+		MyCall:      strings.ToUpper(myCall),
+		Comment:     record.Text,
+		Fields:      map[string]string{"SWL": "Y"},
+	}
+}
+
+// String returns the record in the ADI format, terminated by <EOR> and a line break.
+func (r Record) String() string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "<%s:%d>%s ", name, len(value), value)
+	}
+
+	mode, submode := Mode(r.Mode)
+	field("CALL", strings.ToUpper(r.Call))
+	field("MODE", mode)
+	field("SUBMODE", submode)
+	if r.Frequency != 0 {
+		field("FREQ", fmt.Sprintf("%.6f", r.Frequency/1000000))
+		field("BAND", band(r.Frequency))
+	}
+	if !r.Start.IsZero() {
+		start := r.Start.UTC()
+		field("QSO_DATE", start.Format(dateLayout))
+		field("TIME_ON", start.Format(timeLayout))
+	}
+	if !r.End.IsZero() {
+		end := r.End.UTC()
+		field("QSO_DATE_OFF", end.Format(dateLayout))
+		field("TIME_OFF", end.Format(timeLayout))
+	}
+	field("RST_SENT", r.RSTSent)
+	field("RST_RCVD", r.RSTReceived)
+	field("GRIDSQUARE", strings.ToUpper(r.Gridsquare))
+	field("STATION_CALLSIGN", strings.ToUpper(r.MyCall))
+	field("MY_GRIDSQUARE", strings.ToUpper(r.MyGridsquare))
+	field("COMMENT", singleLine(r.Comment))
+
+	names := make([]string, 0, len(r.Fields))
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field(strings.ToUpper(name), r.Fields[name])
+	}
+
+	b.WriteString("<EOR>\n")
+	return b.String()
+}
+
+// singleLine replaces all line breaks in the given text by spaces.
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func band(frequency float64) string {
+	for _, region := range []bandplan.Region{bandplan.Region1, bandplan.Region2, bandplan.Region3} {
+		b, ok := bandplan.BandOf(region, bandplan.Frequency(frequency))
+		if ok {
+			return string(b.Name)
+		}
+	}
+	return ""
+}
+
+// Header returns the header of an ADI file that was created by the given program at the given time.
+func Header(programID string, created time.Time) string {
+	return fmt.Sprintf("ADIF export of %s\n<ADIF_VER:%d>%s <PROGRAMID:%d>%s <CREATED_TIMESTAMP:%d>%s <EOH>\n",
+		programID,
+		len(Version), Version,
+		len(programID), programID,
+		len(timestampLayout), created.UTC().Format(timestampLayout))
+}
+
+// Write writes the given records to the given writer.
+func Write(w io.Writer, records ...Record) error {
+	for _, record := range records {
+		_, err := io.WriteString(w, record.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Append appends the given records to the log file with the given name. If the file does not exist or is empty,
+// it is created with a header.
+func Append(filename string, records ...Record) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		_, err = io.WriteString(file, Header(DefaultProgramID, time.Now()))
+	}
+	if err == nil {
+		err = Write(file, records...)
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,87 @@
+package adif
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+func TestMode(t *testing.T) {
+	testCases := []struct {
+		name, mode, submode string
+	}{
+		{"psk31", "PSK", "PSK31"},
+		{"CW", "CW", ""},
+		{"contestia", "CONTESTI", ""},
+		{"ft4", "MFSK", "FT4"},
+		{"hell", "HELL", ""},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			mode, submode := Mode(tC.name)
+			assert.Equal(t, tC.mode, mode)
+			assert.Equal(t, tC.submode, submode)
+		})
+	}
+}
+
+func TestRecordFromQSO(t *testing.T) {
+	qso := sequencer.QSO{
+		Mode:           "FT8",
+		Call:           "W1AW",
+		Grid:           "FN31",
+		SentReport:     -10,
+		ReceivedReport: 3,
+		Start:          time.Date(2020, 5, 1, 12, 34, 15, 0, time.UTC),
+		End:            time.Date(2020, 5, 1, 12, 36, 0, 0, time.UTC),
+	}
+	record := RecordFromQSO(qso, "dl1abc", "JO31", 14075512)
+
+	assert.Equal(t, "<CALL:4>W1AW <MODE:3>FT8 <FREQ:9>14.075512 <BAND:3>20m <QSO_DATE:8>20200501 <TIME_ON:6>123415 "+
+		"<QSO_DATE_OFF:8>20200501 <TIME_OFF:6>123600 <RST_SENT:3>-10 <RST_RCVD:3>+03 <GRIDSQUARE:4>FN31 "+
+		"<STATION_CALLSIGN:6>DL1ABC <MY_GRIDSQUARE:4>JO31 <EOR>\n", record.String())
+}
+
+func TestRecordFromDecode(t *testing.T) {
+	decode := digimodes.DecodeRecord{
+		Time:        time.Date(2020, 5, 1, 8, 0, 0, 0, time.UTC),
+		Mode:        "psk31",
+		RFFrequency: 7040500,
+		Text:        "CQ CQ de dl2xyz\r\npse k",
+		SNR:         12.4,
+	}
+	record := RecordFromDecode(decode, "dl2xyz", "DL1ABC")
+	record.Fields["tx_pwr"] = "5"
+
+	assert.Equal(t, "<CALL:6>DL2XYZ <MODE:3>PSK <SUBMODE:5>PSK31 <FREQ:8>7.040500 <BAND:3>40m <QSO_DATE:8>20200501 "+
+		"<TIME_ON:6>080000 <RST_RCVD:3>+12 <STATION_CALLSIGN:6>DL1ABC <COMMENT:21>CQ CQ de dl2xyz pse k <SWL:1>Y "+
+		"<TX_PWR:1>5 <EOR>\n", record.String())
+}
+
+func TestHeader(t *testing.T) {
+	header := Header("test", time.Date(2020, 5, 1, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, "ADIF export of test\n<ADIF_VER:5>3.1.4 <PROGRAMID:4>test <CREATED_TIMESTAMP:15>20200501 080000 <EOH>\n", header)
+}
+
+func TestAppend(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "log.adi")
+	require.NoError(t, Append(filename, Record{Call: "W1AW", Mode: "cw"}))
+	require.NoError(t, Append(filename, Record{Call: "K1A", Mode: "cw"}, Record{Call: "N1MM", Mode: "rtty"}))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasSuffix(lines[1], "<EOH>"))
+	assert.Equal(t, "<CALL:4>W1AW <MODE:2>CW <EOR>", lines[2])
+	assert.Equal(t, "<CALL:3>K1A <MODE:2>CW <EOR>", lines[3])
+	assert.Equal(t, "<CALL:4>N1MM <MODE:4>RTTY <EOR>", lines[4])
+}
@@ -0,0 +1,95 @@
+/*
+Package afsk implements the transmission of AX.25 packets with Bell 202 AFSK at 1200 baud, as used for APRS.
+*/
+package afsk
+
+import (
+	"io"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+const (
+	// Baud is the symbol rate of AFSK1200.
+	Baud = 1200.0
+	// MarkFrequency is the frequency of the mark tone in Hz.
+	MarkFrequency = 1200.0
+	// SpaceFrequency is the frequency of the space tone in Hz.
+	SpaceFrequency = 2200.0
+
+	// DefaultTXDelay is the common number of flags sent before a frame, to let the receivers synchronize.
+	DefaultTXDelay = 32
+	// txTail is the number of flags sent after a frame.
+	txTail = 3
+
+	// window is the time in seconds to ramp the amplitude up and down at the start and the end of a transmission.
+	window = 0.002
+)
+
+// Modulator generates the Bell 202 AFSK signal of an AX.25 frame. The transmission starts with the first call of
+// Modulate.
+type Modulator struct {
+	tones    []bool
+	duration float64
+	started  bool
+	start    float64
+	ended    bool
+	done     chan struct{}
+}
+
+// NewModulator returns a new Modulator that transmits the given frame after the given number of flags.
+func NewModulator(frame Frame, txDelay int) *Modulator {
+	tones := nrzi(hdlcBits(frame.Bytes(), txDelay, txTail))
+	return &Modulator{
+		tones:    tones,
+		duration: float64(len(tones)) / Baud,
+		done:     make(chan struct{}),
+	}
+}
+
+// Duration returns the duration of the whole transmission in seconds.
+func (m *Modulator) Duration() float64 {
+	return m.duration
+}
+
+// Done is closed when the transmission is complete.
+func (m *Modulator) Done() <-chan struct{} {
+	return m.done
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends with the transmission of the frame.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.Done())
+}
+
+// Modulate returns the amplitude and the frequency of the transmission at the given time in seconds. The phase
+// stays continuous between the tones.
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if !m.started {
+		m.started = true
+		m.start = t
+	}
+	elapsed := t - m.start
+	bit := int(elapsed * Baud)
+	if bit >= len(m.tones) {
+		if !m.ended {
+			m.ended = true
+			close(m.done)
+		}
+		return 0, f, p
+	}
+
+	frequency = SpaceFrequency
+	if m.tones[bit] {
+		frequency = MarkFrequency
+	}
+	amplitude = 1
+	if elapsed < window {
+		amplitude = elapsed / window
+	}
+	if remaining := m.duration - elapsed; remaining < window {
+		amplitude = remaining / window
+	}
+	return amplitude, frequency, p
+}
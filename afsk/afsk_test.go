@@ -0,0 +1,61 @@
+package afsk
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModulatorFollowsTones(t *testing.T) {
+	frame, err := NewFrame("DL1ABC", "APRS", nil, []byte("hello"))
+	assert.NoError(t, err)
+	m := NewModulator(frame, 4)
+	tones := nrzi(hdlcBits(frame.Bytes(), 4, txTail))
+	assert.InDelta(t, float64(len(tones))/Baud, m.Duration(), 1e-9)
+
+	m.Modulate(5, 0, 0, 0)
+	for i, mark := range tones {
+		amplitude, frequency, _ := m.Modulate(5+(float64(i)+0.5)/Baud, 0, 0, 0)
+		assert.True(t, amplitude > 0, "bit %d", i)
+		if mark {
+			assert.Equal(t, MarkFrequency, frequency, "bit %d", i)
+		} else {
+			assert.Equal(t, SpaceFrequency, frequency, "bit %d", i)
+		}
+	}
+	select {
+	case <-m.Done():
+		assert.Fail(t, "done too early")
+	default:
+	}
+
+	amplitude, _, _ := m.Modulate(5+m.Duration(), 0, 0, 0)
+	assert.Equal(t, 0.0, amplitude)
+	<-m.Done()
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	frame, err := NewFrame("DL1ABC", "APRS", nil, []byte("hello"))
+	assert.NoError(t, err)
+	m := NewModulator(frame, DefaultTXDelay)
+	var a, f, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, f, p = m.Modulate(float64(n)/48000, a, f, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestSamplesReader(t *testing.T) {
+	frame, err := NewFrame("DL1ABC", "APRS", nil, []byte("hello"))
+	assert.NoError(t, err)
+	m := NewModulator(frame, 4)
+
+	pcm, err := io.ReadAll(m.SamplesReader(48000))
+	assert.NoError(t, err)
+	samples := len(pcm) / 2
+	assert.GreaterOrEqual(t, samples, int(m.Duration()*48000))
+	assert.Less(t, samples, int(m.Duration()*48000)+4096, "the reader ends with the frame")
+}
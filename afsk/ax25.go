@@ -0,0 +1,150 @@
+package afsk
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	// controlUI is the control field of an unnumbered information frame.
+	controlUI = 0x03
+	// pidNoLayer3 is the protocol identifier for frames without layer 3 protocol, as used by APRS.
+	pidNoLayer3 = 0xF0
+
+	// maxDigipeaters is the maximum number of digipeaters in the path of a frame.
+	maxDigipeaters = 8
+	// addressLength is the length of an encoded address in bytes.
+	addressLength = 7
+)
+
+// Errors of the AX.25 frame encoding.
+var (
+	ErrInvalidCallsign    = errors.New("afsk: invalid callsign")
+	ErrTooManyDigipeaters = errors.New("afsk: too many digipeaters")
+)
+
+// Address is an AX.25 address: a callsign with up to 6 characters and an SSID between 0 and 15.
+type Address struct {
+	Callsign string
+	SSID     int
+}
+
+// ParseAddress parses an address in the common notation CALL-SSID, e.g. DL1ABC-9 or WIDE2-1.
+func ParseAddress(s string) (Address, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	callsign, ssid := s, 0
+	if i := strings.Index(s, "-"); i >= 0 {
+		var err error
+		callsign = s[:i]
+		ssid, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Address{}, ErrInvalidCallsign
+		}
+	}
+	result := Address{Callsign: callsign, SSID: ssid}
+	if !result.valid() {
+		return Address{}, ErrInvalidCallsign
+	}
+	return result, nil
+}
+
+func (a Address) valid() bool {
+	if len(a.Callsign) == 0 || len(a.Callsign) > 6 || a.SSID < 0 || a.SSID > 15 {
+		return false
+	}
+	for _, c := range a.Callsign {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Address) String() string {
+	if a.SSID == 0 {
+		return a.Callsign
+	}
+	return a.Callsign + "-" + strconv.Itoa(a.SSID)
+}
+
+// encode returns the 7 bytes of the address field. The characters are shifted left by one bit, the lowest bit of
+// the last byte marks the last address of the header.
+func (a Address) encode(flags byte, last bool) []byte {
+	result := make([]byte, addressLength)
+	for i := 0; i < 6; i++ {
+		c := byte(' ')
+		if i < len(a.Callsign) {
+			c = a.Callsign[i]
+		}
+		result[i] = c << 1
+	}
+	result[6] = flags | byte(a.SSID)<<1
+	if last {
+		result[6] |= 0x01
+	}
+	return result
+}
+
+// Frame is an AX.25 unnumbered information frame.
+type Frame struct {
+	Destination Address
+	Source      Address
+	Path        []Address
+	Payload     []byte
+}
+
+// NewFrame returns a new UI frame from the given source to the given destination via the given digipeater path.
+// The addresses are in the notation CALL-SSID.
+func NewFrame(source string, destination string, path []string, payload []byte) (Frame, error) {
+	if len(path) > maxDigipeaters {
+		return Frame{}, ErrTooManyDigipeaters
+	}
+	src, err := ParseAddress(source)
+	if err != nil {
+		return Frame{}, err
+	}
+	dst, err := ParseAddress(destination)
+	if err != nil {
+		return Frame{}, err
+	}
+	digis := make([]Address, len(path))
+	for i, p := range path {
+		digis[i], err = ParseAddress(p)
+		if err != nil {
+			return Frame{}, err
+		}
+	}
+	return Frame{Destination: dst, Source: src, Path: digis, Payload: payload}, nil
+}
+
+// Bytes returns the content of the frame including the frame check sequence, without the flags.
+func (f Frame) Bytes() []byte {
+	result := make([]byte, 0, (2+len(f.Path))*addressLength+2+len(f.Payload)+2)
+	// command frame: C bit set in the destination, cleared in the source
+	result = append(result, f.Destination.encode(0xE0, false)...)
+	result = append(result, f.Source.encode(0x60, len(f.Path) == 0)...)
+	for i, digi := range f.Path {
+		result = append(result, digi.encode(0x60, i == len(f.Path)-1)...)
+	}
+	result = append(result, controlUI, pidNoLayer3)
+	result = append(result, f.Payload...)
+	fcs := CRC(result)
+	return append(result, byte(fcs), byte(fcs>>8))
+}
+
+// CRC returns the frame check sequence of the given data: CRC-16/X.25 as used by HDLC.
+func CRC(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
@@ -0,0 +1,75 @@
+package afsk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCRC(t *testing.T) {
+	assert.Equal(t, uint16(0x906E), CRC([]byte("123456789")))
+}
+
+func TestParseAddress(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected Address
+		invalid  bool
+	}{
+		{value: "DL1ABC", expected: Address{Callsign: "DL1ABC"}},
+		{value: "dl1abc-9", expected: Address{Callsign: "DL1ABC", SSID: 9}},
+		{value: "WIDE2-1", expected: Address{Callsign: "WIDE2", SSID: 1}},
+		{value: "", invalid: true},
+		{value: "DL1ABCD", invalid: true},
+		{value: "DL1ABC-16", invalid: true},
+		{value: "DL1ABC-X", invalid: true},
+		{value: "DL/ABC", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			actual, err := ParseAddress(tC.value)
+			if tC.invalid {
+				assert.Equal(t, ErrInvalidCallsign, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+			assert.Equal(t, tC.expected.String(), actual.String())
+		})
+	}
+}
+
+func TestFrameBytes(t *testing.T) {
+	frame, err := NewFrame("DL1ABC-9", "APRS", []string{"WIDE1-1"}, []byte(">test"))
+	assert.NoError(t, err)
+
+	actual := frame.Bytes()
+
+	expected := []byte{
+		'A' << 1, 'P' << 1, 'R' << 1, 'S' << 1, ' ' << 1, ' ' << 1, 0xE0,
+		'D' << 1, 'L' << 1, '1' << 1, 'A' << 1, 'B' << 1, 'C' << 1, 0x60 | 9<<1,
+		'W' << 1, 'I' << 1, 'D' << 1, 'E' << 1, '1' << 1, ' ' << 1, 0x60 | 1<<1 | 1,
+		controlUI, pidNoLayer3, '>', 't', 'e', 's', 't',
+	}
+	assert.Equal(t, expected, actual[:len(actual)-2])
+	fcs := CRC(expected)
+	assert.Equal(t, []byte{byte(fcs), byte(fcs >> 8)}, actual[len(actual)-2:])
+}
+
+func TestFrameWithoutPathMarksSourceAsLast(t *testing.T) {
+	frame, err := NewFrame("DL1ABC", "APRS", nil, nil)
+	assert.NoError(t, err)
+
+	actual := frame.Bytes()
+
+	assert.Equal(t, byte(0xE0), actual[6])
+	assert.Equal(t, byte(0x61), actual[13])
+}
+
+func TestNewFrameInvalid(t *testing.T) {
+	_, err := NewFrame("DL1ABC", "APRS", make([]string, maxDigipeaters+1), nil)
+	assert.Equal(t, ErrTooManyDigipeaters, err)
+
+	_, err = NewFrame("DL1ABC", "APRS", []string{"WIDE1-1", "WIDE2-X"}, nil)
+	assert.Equal(t, ErrInvalidCallsign, err)
+}
@@ -0,0 +1,54 @@
+package afsk
+
+const (
+	// flag separates the HDLC frames.
+	flag = 0x7E
+	// maxOnes is the number of consecutive one bits after which a zero bit is stuffed.
+	maxOnes = 5
+)
+
+// hdlcBits returns the bits of the given frame content between the given number of leading and trailing flags.
+// The bits are sent LSB first, a zero is stuffed after five consecutive ones within the frame content.
+func hdlcBits(content []byte, leadingFlags int, trailingFlags int) []bool {
+	result := make([]bool, 0, (leadingFlags+trailingFlags+len(content))*8+len(content)*8/maxOnes)
+	addFlags := func(n int) {
+		for i := 0; i < n; i++ {
+			for bit := 0; bit < 8; bit++ {
+				result = append(result, (flag>>uint(bit))&1 == 1)
+			}
+		}
+	}
+
+	addFlags(leadingFlags)
+	ones := 0
+	for _, b := range content {
+		for bit := 0; bit < 8; bit++ {
+			one := (b>>uint(bit))&1 == 1
+			result = append(result, one)
+			if !one {
+				ones = 0
+				continue
+			}
+			ones++
+			if ones == maxOnes {
+				result = append(result, false)
+				ones = 0
+			}
+		}
+	}
+	addFlags(trailingFlags)
+	return result
+}
+
+// nrzi encodes the given bits in place with NRZI: a zero bit changes the tone, a one bit keeps it. The result is
+// true for the mark tone. The line starts with the mark tone.
+func nrzi(bits []bool) []bool {
+	mark := true
+	for i, bit := range bits {
+		if !bit {
+			mark = !mark
+		}
+		bits[i] = mark
+	}
+	return bits
+}
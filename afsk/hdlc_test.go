@@ -0,0 +1,43 @@
+package afsk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHDLCBits(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		content  []byte
+		expected string
+	}{
+		{"no stuffing", []byte{0x0F}, "11110000"},
+		{"stuffing", []byte{0xFF}, "111110111"},
+		{"stuffing across bytes", []byte{0xF8, 0x03}, "00011111" + "0" + "11000000"},
+		{"ones reset by zero", []byte{0xEF}, "11110111"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, "01111110"+tC.expected+"01111110", bitString(hdlcBits(tC.content, 1, 1)))
+		})
+	}
+}
+
+func TestNRZI(t *testing.T) {
+	actual := nrzi([]bool{true, false, false, true, true, false})
+
+	assert.Equal(t, []bool{true, false, true, true, true, false}, actual)
+}
+
+func bitString(bits []bool) string {
+	result := make([]byte, len(bits))
+	for i, bit := range bits {
+		if bit {
+			result[i] = '1'
+		} else {
+			result[i] = '0'
+		}
+	}
+	return string(result)
+}
@@ -0,0 +1,59 @@
+/*
+Package aprs builds APRS packets: uncompressed and compressed position reports, status reports, telemetry and Mic-E
+frames. The frames can be transmitted with the afsk package.
+*/
+package aprs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ftl/digimodes/afsk"
+)
+
+// Destination is the destination address of the frames built by this package, the experimental tocall APZ with an
+// identifier of this library.
+const Destination = "APZDGM"
+
+// ErrInvalidPosition is returned when the latitude or the longitude are out of range.
+var ErrInvalidPosition = errors.New("aprs: invalid position")
+
+// Symbol selects the icon of a station: the symbol table ('/' primary, '\' alternate) and the symbol code.
+type Symbol struct {
+	Table byte
+	Code  byte
+}
+
+// Some common symbols.
+var (
+	House   = Symbol{'/', '-'}
+	Car     = Symbol{'/', '>'}
+	Jogger  = Symbol{'/', '['}
+	Bicycle = Symbol{'/', 'b'}
+	WX      = Symbol{'/', '_'}
+	Digi    = Symbol{'/', '#'}
+)
+
+// NewFrame returns a new AX.25 frame with the given information field, sent from the given source via the given
+// digipeater path.
+func NewFrame(source string, path []string, information string) (afsk.Frame, error) {
+	return afsk.NewFrame(source, Destination, path, []byte(information))
+}
+
+// Status returns the information field of a status report with the given text.
+func Status(text string) string {
+	return ">" + text
+}
+
+// Telemetry returns the information field of a telemetry report with the given sequence number, the five 8 bit
+// analog values and the eight digital bits. The digital bits are sent starting with the highest bit.
+func Telemetry(sequence int, analog [5]uint8, digital uint8) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "T#%03d", sequence%1000)
+	for _, value := range analog {
+		fmt.Fprintf(&result, ",%03d", value)
+	}
+	fmt.Fprintf(&result, ",%08b", digital)
+	return result.String()
+}
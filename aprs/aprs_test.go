@@ -0,0 +1,26 @@
+package aprs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, ">on the air", Status("on the air"))
+}
+
+func TestTelemetry(t *testing.T) {
+	assert.Equal(t, "T#005,199,000,255,073,123,01101001", Telemetry(5, [5]uint8{199, 0, 255, 73, 123}, 0x69))
+	assert.Equal(t, "T#001,000,000,000,000,000,00000000", Telemetry(1001, [5]uint8{}, 0))
+}
+
+func TestNewFrame(t *testing.T) {
+	frame, err := NewFrame("DL1ABC-9", []string{"WIDE1-1"}, Status("test"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "DL1ABC-9", frame.Source.String())
+	assert.Equal(t, Destination, frame.Destination.String())
+	assert.Equal(t, "WIDE1-1", frame.Path[0].String())
+	assert.Equal(t, []byte(">test"), frame.Payload)
+}
@@ -0,0 +1,93 @@
+package aprs
+
+import (
+	"math"
+
+	"github.com/ftl/digimodes/afsk"
+)
+
+// MicEMessage is the standard message of a Mic-E position report.
+type MicEMessage int
+
+// The standard Mic-E messages, the values are the message bits A, B and C.
+const (
+	MicEEmergency MicEMessage = iota
+	MicEPriority
+	MicESpecial
+	MicECommitted
+	MicEReturning
+	MicEInService
+	MicEEnRoute
+	MicEOffDuty
+)
+
+// MicE is the content of a Mic-E position report. Mic-E encodes the latitude and the message in the destination
+// address and the longitude, course and speed in the information field.
+type MicE struct {
+	Position
+	Message MicEMessage
+}
+
+// Encode returns the destination address and the information field of the Mic-E report.
+func (m MicE) Encode() (destination string, information string, err error) {
+	if !m.valid() {
+		return "", "", ErrInvalidPosition
+	}
+	latDegrees, latMinutes := hundredthMinutes(m.Latitude)
+	lonDegrees, lonMinutes := hundredthMinutes(m.Longitude)
+	longitudeOffset := lonDegrees < 10 || lonDegrees >= 100
+
+	digits := [6]int{latDegrees / 10, latDegrees % 10, latMinutes / 1000, latMinutes / 100 % 10, latMinutes / 10 % 10, latMinutes % 10}
+	flags := [6]bool{
+		m.Message&4 != 0,
+		m.Message&2 != 0,
+		m.Message&1 != 0,
+		m.Latitude >= 0,
+		longitudeOffset,
+		m.Longitude < 0,
+	}
+	dst := make([]byte, len(digits))
+	for i, digit := range digits {
+		if flags[i] {
+			dst[i] = byte('P' + digit)
+		} else {
+			dst[i] = byte('0' + digit)
+		}
+	}
+
+	d := lonDegrees
+	switch {
+	case lonDegrees < 10:
+		d += 90
+	case lonDegrees < 100:
+	case lonDegrees < 110:
+		d -= 20
+	default:
+		d -= 100
+	}
+	minutes := lonMinutes / 100
+	if minutes < 10 {
+		minutes += 60
+	}
+	speed := int(math.Round(m.Speed))
+	course := m.Course % 360
+
+	info := make([]byte, 0, 9+len(m.Comment))
+	info = append(info, '`',
+		byte(d+28), byte(minutes+28), byte(lonMinutes%100+28),
+		byte(speed/10+28), byte(speed%10*10+course/100+28), byte(course%100+28),
+		m.Symbol.Code, m.Symbol.Table,
+	)
+	info = append(info, m.Comment...)
+	return string(dst), string(info), nil
+}
+
+// NewMicEFrame returns a new AX.25 frame with the given Mic-E report, sent from the given source via the given
+// digipeater path.
+func NewMicEFrame(source string, path []string, report MicE) (afsk.Frame, error) {
+	destination, information, err := report.Encode()
+	if err != nil {
+		return afsk.Frame{}, err
+	}
+	return afsk.NewFrame(source, destination, path, []byte(information))
+}
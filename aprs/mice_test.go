@@ -0,0 +1,52 @@
+package aprs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMicE(t *testing.T) {
+	testCases := []struct {
+		desc                string
+		report              MicE
+		expectedDestination string
+		expectedInformation string
+	}{
+		{
+			desc:                "specification example",
+			report:              MicE{Position: Position{Latitude: 33 + 25.64/60, Longitude: -(72 + 45.0/60), Symbol: Car}, Message: MicEReturning},
+			expectedDestination: "S32U6T",
+			expectedInformation: "`d" + "I\x1c" + "\x1c\x1c\x1c" + ">/",
+		},
+		{
+			desc:                "east with longitude offset",
+			report:              MicE{Position: Position{Latitude: -(12 + 5.5/60), Longitude: 105 + 3.27/60, Symbol: Jogger, Course: 251, Speed: 23, Comment: "hi"}, Message: MicEOffDuty},
+			expectedDestination: "QRP5U0",
+			expectedInformation: "`" + string([]byte{85 + 28, 63 + 28, 27 + 28, 2 + 28, 32 + 28, 51 + 28}) + "[/hi",
+		},
+		{
+			desc:                "below 10 degrees",
+			report:              MicE{Position: Position{Latitude: 1, Longitude: -7.5, Symbol: House}, Message: MicEEmergency},
+			expectedDestination: "010PPP",
+			expectedInformation: "`" + string([]byte{97 + 28, 30 + 28, 0 + 28, 28, 28, 28}) + "-/",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			destination, information, err := tC.report.Encode()
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedDestination, destination)
+			assert.Equal(t, tC.expectedInformation, information)
+		})
+	}
+}
+
+func TestNewMicEFrame(t *testing.T) {
+	frame, err := NewMicEFrame("DL1ABC-9", nil, MicE{Position: Position{Latitude: 33 + 25.64/60, Longitude: -(72 + 45.0/60), Symbol: Car}, Message: MicEReturning})
+	assert.NoError(t, err)
+	assert.Equal(t, "S32U6T", frame.Destination.String())
+
+	_, err = NewMicEFrame("DL1ABC-9", nil, MicE{Position: Position{Latitude: 95}})
+	assert.Equal(t, ErrInvalidPosition, err)
+}
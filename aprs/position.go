@@ -0,0 +1,104 @@
+package aprs
+
+import (
+	"fmt"
+	"math"
+)
+
+// Position is the content of a position report. Course and speed are optional and only sent if one of them is
+// not zero.
+type Position struct {
+	Latitude  float64
+	Longitude float64
+	Symbol    Symbol
+	// Course in degrees, 1-360.
+	Course int
+	// Speed in knots.
+	Speed float64
+	// Messaging marks a station that is capable of receiving messages.
+	Messaging bool
+	Comment   string
+}
+
+// compressedType is the compression type byte for a current fix with course and speed from software, see the APRS
+// specification, chapter 9.
+const compressedType = '!' + 0x3A
+
+func (p Position) valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+func (p Position) hasCourseSpeed() bool {
+	return p.Course != 0 || p.Speed != 0
+}
+
+func (p Position) dataType() byte {
+	if p.Messaging {
+		return '='
+	}
+	return '!'
+}
+
+// Uncompressed returns the information field of an uncompressed position report without timestamp, e.g.
+// !4903.50N/07201.75W-comment.
+func (p Position) Uncompressed() (string, error) {
+	if !p.valid() {
+		return "", ErrInvalidPosition
+	}
+	latDegrees, latMinutes := hundredthMinutes(p.Latitude)
+	lonDegrees, lonMinutes := hundredthMinutes(p.Longitude)
+	result := fmt.Sprintf("%c%02d%02d.%02d%c%c%03d%02d.%02d%c%c",
+		p.dataType(),
+		latDegrees, latMinutes/100, latMinutes%100, hemisphere(p.Latitude, 'N', 'S'),
+		p.Symbol.Table,
+		lonDegrees, lonMinutes/100, lonMinutes%100, hemisphere(p.Longitude, 'E', 'W'),
+		p.Symbol.Code,
+	)
+	if p.hasCourseSpeed() {
+		result += fmt.Sprintf("%03d/%03d", p.Course%361, int(math.Round(p.Speed)))
+	}
+	return result + p.Comment, nil
+}
+
+// Compressed returns the information field of a compressed position report without timestamp, e.g.
+// !/5L!!<*e7>7P[comment.
+func (p Position) Compressed() (string, error) {
+	if !p.valid() {
+		return "", ErrInvalidPosition
+	}
+	result := make([]byte, 0, 14+len(p.Comment))
+	result = append(result, p.dataType(), p.Symbol.Table)
+	result = appendBase91(result, int(380926*(90-p.Latitude)))
+	result = appendBase91(result, int(190463*(180+p.Longitude)))
+	result = append(result, p.Symbol.Code)
+	if p.hasCourseSpeed() {
+		speed := int(math.Round(math.Log(p.Speed+1) / math.Log(1.08)))
+		result = append(result, byte('!'+(p.Course%360)/4), byte('!'+speed), compressedType)
+	} else {
+		result = append(result, ' ', ' ', '!')
+	}
+	return string(append(result, p.Comment...)), nil
+}
+
+// hundredthMinutes splits the given angle into whole degrees and hundredths of minutes.
+func hundredthMinutes(angle float64) (degrees int, minutes int) {
+	total := int(math.Round(math.Abs(angle) * 6000))
+	return total / 6000, total % 6000
+}
+
+func hemisphere(angle float64, positive byte, negative byte) byte {
+	if angle < 0 {
+		return negative
+	}
+	return positive
+}
+
+// appendBase91 appends the given value as four base 91 digits.
+func appendBase91(b []byte, value int) []byte {
+	var digits [4]byte
+	for i := 3; i >= 0; i-- {
+		digits[i] = byte('!' + value%91)
+		value /= 91
+	}
+	return append(b, digits[:]...)
+}
@@ -0,0 +1,73 @@
+package aprs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUncompressed(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		position Position
+		expected string
+	}{
+		{
+			desc:     "north west",
+			position: Position{Latitude: 49 + 3.5/60, Longitude: -(72 + 1.75/60), Symbol: House},
+			expected: "!4903.50N/07201.75W-",
+		},
+		{
+			desc:     "south east with messaging",
+			position: Position{Latitude: -(33 + 52.12/60), Longitude: 151 + 12.5/60, Symbol: Car, Messaging: true, Comment: "mobile"},
+			expected: "=3352.12S/15112.50E>mobile",
+		},
+		{
+			desc:     "course and speed",
+			position: Position{Latitude: 50.5, Longitude: 8.25, Symbol: Car, Course: 88, Speed: 36.2},
+			expected: "!5030.00N/00815.00E>088/036",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual, err := tC.position.Uncompressed()
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		position Position
+		expected string
+	}{
+		{
+			desc:     "specification example",
+			position: Position{Latitude: 49.5, Longitude: -72.75, Symbol: Car, Course: 88, Speed: 36.2, Messaging: true},
+			expected: "=/5L!!<*e7>7P[",
+		},
+		{
+			desc:     "without course and speed",
+			position: Position{Latitude: 49.5, Longitude: -72.75, Symbol: House, Comment: "home"},
+			expected: "!/5L!!<*e7-  !home",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual, err := tC.position.Compressed()
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestInvalidPosition(t *testing.T) {
+	for _, position := range []Position{{Latitude: 91}, {Latitude: -91}, {Longitude: 181}, {Longitude: -181}} {
+		_, err := position.Uncompressed()
+		assert.Equal(t, ErrInvalidPosition, err)
+		_, err = position.Compressed()
+		assert.Equal(t, ErrInvalidPosition, err)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Package aprsis implements a client for the APRS internet system (APRS-IS). It logs in with a callsign and passcode,
+sets filter strings and sends and receives packets in TNC2 format. Together with the AX.25/APRS layer, it can be
+used to build iGates and trackers.
+*/
+package aprsis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// DefaultServer is the address of the APRS-IS server pool with user defined filters.
+const DefaultServer = "rotate.aprs2.net:14580"
+
+// ReceiveOnlyPasscode is the passcode for receive only connections.
+const ReceiveOnlyPasscode = -1
+
+var (
+	// ErrUnverified is returned when a packet is sent through an unverified connection.
+	ErrUnverified = errors.New("aprsis: connection not verified")
+	// ErrLoginFailed is returned when the server does not respond to the login.
+	ErrLoginFailed = errors.New("aprsis: login failed")
+)
+
+// Passcode returns the APRS-IS passcode of the given callsign. The SSID is ignored.
+func Passcode(callsign string) int {
+	call, _, _ := strings.Cut(strings.ToUpper(callsign), "-")
+	hash := 0x73E2
+	for i := 0; i < len(call); i += 2 {
+		hash ^= int(call[i]) << 8
+		if i+1 < len(call) {
+			hash ^= int(call[i+1])
+		}
+	}
+	return hash & 0x7FFF
+}
+
+// Config of the login.
+type Config struct {
+	// Callsign with optional SSID.
+	Callsign string
+	// Passcode of the callsign, or ReceiveOnlyPasscode.
+	Passcode int
+	// Filter is the server side filter, e.g. "r/50.0/10.0/100".
+	Filter string
+	// Software is the name of the client software.
+	Software string
+	// Version is the version of the client software.
+	Version string
+}
+
+// Client is a connection to an APRS-IS server.
+type Client struct {
+	conn     io.ReadWriteCloser
+	reader   *bufio.Reader
+	callsign string
+	verified bool
+	server   string
+
+	writeLock sync.Mutex
+}
+
+// Dial connects to the APRS-IS server with the given address and logs in.
+func Dial(ctx context.Context, address string, config Config) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	result, err := NewClient(conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// NewClient logs in through the given connection.
+func NewClient(conn io.ReadWriteCloser, config Config) (*Client, error) {
+	result := &Client{
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		callsign: config.Callsign,
+	}
+	software := config.Software
+	if software == "" {
+		software = "digimodes"
+	}
+	version := config.Version
+	if version == "" {
+		version = "0"
+	}
+	login := fmt.Sprintf("user %s pass %d vers %s %s", config.Callsign, config.Passcode, software, version)
+	if config.Filter != "" {
+		login += " filter " + config.Filter
+	}
+	err := result.writeLine(login)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := result.readLine()
+		if err != nil {
+			if err == io.EOF {
+				return nil, ErrLoginFailed
+			}
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "# logresp ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "# logresp "))
+		if len(fields) < 2 || !strings.EqualFold(fields[0], config.Callsign) {
+			return nil, ErrLoginFailed
+		}
+		result.verified = strings.TrimSuffix(fields[1], ",") == "verified"
+		if len(fields) >= 4 && fields[2] == "server" {
+			result.server = fields[3]
+		}
+		return result, nil
+	}
+}
+
+// Verified indicates if the server verified the passcode. Only verified connections can send packets.
+func (c *Client) Verified() bool {
+	return c.verified
+}
+
+// Server returns the name of the server, as reported in the login response.
+func (c *Client) Server() string {
+	return c.server
+}
+
+// SetFilter replaces the server side filter.
+func (c *Client) SetFilter(filter string) error {
+	return c.writeLine("#filter " + filter)
+}
+
+// Send sends the given packet.
+func (c *Client) Send(packet Packet) error {
+	if !c.verified {
+		return ErrUnverified
+	}
+	return c.writeLine(packet.String())
+}
+
+// Receive returns the next packet from the server. Comments of the server and invalid packets are skipped.
+func (c *Client) Receive() (Packet, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return Packet{}, err
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		packet, err := ParsePacket(line)
+		if err != nil {
+			continue
+		}
+		return packet, nil
+	}
+}
+
+// Run receives packets and passes them to the given handler until the context is done or the connection fails.
+// The connection is closed when Run returns.
+func (c *Client) Run(ctx context.Context, handler func(Packet)) error {
+	defer c.conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		packet, err := c.Receive()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		handler(packet)
+	}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) writeLine(line string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := io.WriteString(c.conn, line+"\r\n")
+	return err
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil && (line == "" || err != io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,141 @@
+package aprsis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasscode(t *testing.T) {
+	assert.Equal(t, 13023, Passcode("N0CALL"))
+	assert.Equal(t, 13023, Passcode("n0call-10"))
+}
+
+func TestParsePacket(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected Packet
+		invalid  bool
+	}{
+		{
+			line:     "DL1ABC-9>APRS,WIDE1-1,qAR,DB0XYZ:!5030.00N/01000.00E>test\r\n",
+			expected: Packet{Source: "DL1ABC-9", Destination: "APRS", Path: []string{"WIDE1-1", "qAR", "DB0XYZ"}, Information: "!5030.00N/01000.00E>test"},
+		},
+		{
+			line:     "DL1ABC>APRS::DL2XYZ   :hello: world",
+			expected: Packet{Source: "DL1ABC", Destination: "APRS", Information: ":DL2XYZ   :hello: world"},
+		},
+		{line: "DL1ABC APRS test", invalid: true},
+		{line: ">APRS:test", invalid: true},
+		{line: "DL1ABC>:test", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.line, func(t *testing.T) {
+			actual, err := ParsePacket(tC.line)
+			if tC.invalid {
+				assert.Equal(t, ErrInvalidPacket, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+			assert.Equal(t, strings.TrimRight(tC.line, "\r\n"), actual.String())
+		})
+	}
+}
+
+// fakeServer plays the server side of an APRS-IS connection and returns the lines received from the client.
+func fakeServer(t *testing.T, conn net.Conn, logresp string, packets ...string) <-chan string {
+	received := make(chan string, 10)
+	// net.Pipe is unbuffered, the server writes asynchronously like through a TCP connection
+	writes := make(chan string, 10)
+	go func() {
+		for line := range writes {
+			conn.Write([]byte(line + "\r\n"))
+		}
+	}()
+	go func() {
+		defer close(received)
+		defer close(writes)
+		reader := bufio.NewReader(conn)
+		writes <- "# aprsc 2.1.4"
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			received <- line
+			if strings.HasPrefix(line, "user ") {
+				writes <- logresp
+				for _, packet := range packets {
+					writes <- packet
+				}
+			}
+		}
+	}()
+	return received
+}
+
+func TestClient(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	received := fakeServer(t, serverConn,
+		"# logresp DL1ABC-10 verified, server T2TEST",
+		"# aprsc 2.1.4 11 Apr 2020 12:00:00 GMT T2TEST",
+		"invalid",
+		"DL2XYZ>APRS,TCPIP*,qAC,T2TEST:>status",
+	)
+
+	client, err := NewClient(clientConn, Config{Callsign: "DL1ABC-10", Passcode: Passcode("DL1ABC"), Filter: "r/50/10/100", Software: "test", Version: "1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "user DL1ABC-10 pass 17580 vers test 1.0 filter r/50/10/100", <-received)
+	assert.True(t, client.Verified())
+	assert.Equal(t, "T2TEST", client.Server())
+
+	packet, err := client.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "DL2XYZ", packet.Source)
+	assert.Equal(t, ">status", packet.Information)
+
+	go func() {
+		assert.NoError(t, client.SetFilter("m/50"))
+		assert.NoError(t, client.Send(Packet{Source: "DL1ABC-10", Destination: "APRS", Path: []string{"TCPIP*"}, Information: ">hello"}))
+		client.Close()
+	}()
+	assert.Equal(t, "#filter m/50", <-received)
+	assert.Equal(t, "DL1ABC-10>APRS,TCPIP*:>hello", <-received)
+}
+
+func TestClientUnverified(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	fakeServer(t, serverConn, "# logresp DL1ABC unverified, server T2TEST")
+
+	client, err := NewClient(clientConn, Config{Callsign: "DL1ABC", Passcode: ReceiveOnlyPasscode})
+	require.NoError(t, err)
+	assert.False(t, client.Verified())
+	assert.Equal(t, ErrUnverified, client.Send(Packet{Source: "DL1ABC", Destination: "APRS", Information: ">hello"}))
+	client.Close()
+}
+
+func TestClientRun(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	fakeServer(t, serverConn, "# logresp DL1ABC verified, server T2TEST", "DL2XYZ>APRS:>one", "DL2XYZ>APRS:>two")
+
+	client, err := NewClient(clientConn, Config{Callsign: "DL1ABC", Passcode: Passcode("DL1ABC")})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var informations []string
+	err = client.Run(ctx, func(packet Packet) {
+		informations = append(informations, packet.Information)
+		if len(informations) == 2 {
+			cancel()
+		}
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []string{">one", ">two"}, informations)
+}
@@ -0,0 +1,58 @@
+package aprsis
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidPacket is returned when a line is not a valid packet in TNC2 format.
+var ErrInvalidPacket = errors.New("aprsis: invalid packet")
+
+// Packet is an APRS packet in TNC2 format: SOURCE>DESTINATION,PATH1,PATH2:information
+type Packet struct {
+	Source      string
+	Destination string
+	Path        []string
+	Information string
+}
+
+// ParsePacket parses the given line in TNC2 format.
+func ParsePacket(line string) (Packet, error) {
+	line = strings.TrimRight(line, "\r\n")
+	header, information, found := strings.Cut(line, ":")
+	if !found {
+		return Packet{}, ErrInvalidPacket
+	}
+	source, route, found := strings.Cut(header, ">")
+	if !found || source == "" {
+		return Packet{}, ErrInvalidPacket
+	}
+	parts := strings.Split(route, ",")
+	if parts[0] == "" {
+		return Packet{}, ErrInvalidPacket
+	}
+	result := Packet{
+		Source:      source,
+		Destination: parts[0],
+		Information: information,
+	}
+	if len(parts) > 1 {
+		result.Path = parts[1:]
+	}
+	return result, nil
+}
+
+// String returns the packet in TNC2 format.
+func (p Packet) String() string {
+	var b strings.Builder
+	b.WriteString(p.Source)
+	b.WriteString(">")
+	b.WriteString(p.Destination)
+	for _, hop := range p.Path {
+		b.WriteString(",")
+		b.WriteString(hop)
+	}
+	b.WriteString(":")
+	b.WriteString(p.Information)
+	return b.String()
+}
@@ -0,0 +1,126 @@
+/*
+Package arecord provides a portable capture driver that runs the ALSA arecord utility and reads the raw samples
+from its standard output. It needs no cgo and works with every device that arecord can open, including the
+PulseAudio and pipewire plugins.
+*/
+package arecord
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// DefaultCommand is the name of the arecord executable.
+const DefaultCommand = "arecord"
+
+// Driver is an audio.CaptureDriver that uses arecord.
+type Driver struct {
+	// Command is the arecord executable, DefaultCommand if empty.
+	Command string
+}
+
+// InputDevices returns the capture devices that are listed by "arecord -L".
+func (d Driver) InputDevices() ([]audio.Device, error) {
+	output, err := exec.Command(d.command(), "-L").Output()
+	if err != nil {
+		return nil, fmt.Errorf("arecord: cannot list devices: %w", err)
+	}
+	return parseDevices(bytes.NewReader(output)), nil
+}
+
+// OpenInput starts arecord to capture mono samples from the device with the given ID.
+func (d Driver) OpenInput(id string, sampleRate int, format audio.SampleFormat) (audio.InputStream, error) {
+	args, err := arguments(id, sampleRate, format)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(d.command(), args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("arecord: cannot start capture: %w", err)
+	}
+	return &Stream{
+		ReaderSource: audio.NewReaderSource(stdout, sampleRate, format),
+		cmd:          cmd,
+	}, nil
+}
+
+func (d Driver) command() string {
+	if d.Command == "" {
+		return DefaultCommand
+	}
+	return d.Command
+}
+
+// Stream is a running arecord process. It implements audio.InputStream.
+type Stream struct {
+	*audio.ReaderSource
+	cmd       *exec.Cmd
+	closeOnce sync.Once
+}
+
+// Close stops the arecord process.
+func (s *Stream) Close() error {
+	s.closeOnce.Do(func() {
+		_ = s.cmd.Process.Kill()
+		_ = s.cmd.Wait()
+	})
+	return nil
+}
+
+func arguments(id string, sampleRate int, format audio.SampleFormat) ([]string, error) {
+	var formatName string
+	switch format {
+	case audio.Int16:
+		formatName = "S16_LE"
+	case audio.Float32:
+		formatName = "FLOAT_LE"
+	case audio.Float64:
+		formatName = "FLOAT64_LE"
+	default:
+		return nil, fmt.Errorf("arecord: unsupported sample format %v", format)
+	}
+	result := []string{"-q", "-t", "raw", "-c", "1", "-r", strconv.Itoa(sampleRate), "-f", formatName}
+	if id != "" {
+		result = append(result, "-D", id)
+	}
+	return result, nil
+}
+
+// parseDevices parses the output of "arecord -L": each device name starts at the beginning of a line and is
+// followed by indented lines of description.
+func parseDevices(r io.Reader) []audio.Device {
+	result := make([]audio.Device, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			result = append(result, audio.Device{ID: line})
+			continue
+		}
+		if len(result) == 0 {
+			continue
+		}
+		last := &result[len(result)-1]
+		if last.Description != "" {
+			last.Description += ", "
+		}
+		last.Description += strings.TrimSpace(line)
+	}
+	return result
+}
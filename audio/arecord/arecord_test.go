@@ -0,0 +1,71 @@
+package arecord
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+func TestParseDevices(t *testing.T) {
+	output := `null
+    Discard all samples (playback) or generate zero samples (capture)
+default
+    Default ALSA Output (currently PipeWire Media Server)
+hw:CARD=PCH,DEV=0
+    HDA Intel PCH, ALC892 Analog
+    Direct hardware device without any conversions
+`
+	devices := parseDevices(strings.NewReader(output))
+	assert.Equal(t, []audio.Device{
+		{ID: "null", Description: "Discard all samples (playback) or generate zero samples (capture)"},
+		{ID: "default", Description: "Default ALSA Output (currently PipeWire Media Server)"},
+		{ID: "hw:CARD=PCH,DEV=0", Description: "HDA Intel PCH, ALC892 Analog, Direct hardware device without any conversions"},
+	}, devices)
+}
+
+func TestArguments(t *testing.T) {
+	args, err := arguments("hw:1", 12000, audio.Int16)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-q", "-t", "raw", "-c", "1", "-r", "12000", "-f", "S16_LE", "-D", "hw:1"}, args)
+
+	args, err = arguments("", 48000, audio.Float32)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-q", "-t", "raw", "-c", "1", "-r", "48000", "-f", "FLOAT_LE"}, args)
+
+	_, err = arguments("", 48000, audio.SampleFormat(42))
+	assert.Error(t, err)
+}
+
+func TestOpenInput(t *testing.T) {
+	// a fake arecord that provides four int16 samples with the value 0x4000
+	command := filepath.Join(t.TempDir(), "arecord")
+	err := os.WriteFile(command, []byte("#!/bin/sh\nprintf '\\000\\100\\000\\100\\000\\100\\000\\100'\n"), 0o755)
+	require.NoError(t, err)
+
+	stream, err := Driver{Command: command}.OpenInput("default", 12000, audio.Int16)
+	require.NoError(t, err)
+	defer stream.Close()
+	assert.Equal(t, 12000, stream.SampleRate())
+
+	samples := make([]float64, 8)
+	read := 0
+	for {
+		n, err := stream.ReadSamples(samples[read:])
+		read += n
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, read)
+	for _, sample := range samples[:read] {
+		assert.InDelta(t, 0.5, sample, 0.001)
+	}
+}
@@ -0,0 +1,23 @@
+/*
+Package audio provides the interfaces to move audio samples between the modes and audio devices.
+
+The samples are normalized to the range [-1.0, 1.0].
+*/
+package audio
+
+// Sink is a playback device that consumes audio samples.
+type Sink interface {
+	// SampleRate returns the sample rate of the sink in Hz.
+	SampleRate() int
+	// WriteSamples writes the given samples to the sink. It blocks until all samples are consumed or an error occurs.
+	WriteSamples(samples []float64) (int, error)
+}
+
+// Source is a capture device that provides audio samples.
+type Source interface {
+	// SampleRate returns the sample rate of the source in Hz.
+	SampleRate() int
+	// ReadSamples reads up to len(samples) samples from the source. It blocks until at least one sample is available
+	// or an error occurs. It returns io.EOF when the source is exhausted.
+	ReadSamples(samples []float64) (int, error)
+}
@@ -0,0 +1,70 @@
+/*
+Package audioout plays the signal of a modulator on the default soundcard, so small tools can transmit without
+writing their own audio loop:
+
+	m := psk31.NewModulator(1000)
+	go func() {
+		m.Write([]byte("cq cq de dl1abc pse k"))
+		m.End()
+		cancel()
+	}()
+	err := audioout.Play(ctx, m, nil)
+
+The output uses PortAudio, it requires cgo and the PortAudio development files. It is only built with the build
+tag "portaudio", without it Open and Play return ErrNotSupported.
+*/
+package audioout
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// DefaultSampleRate is the sample rate that is used by Play.
+const DefaultSampleRate = 48000
+
+// blockSize is the number of samples that are rendered and written at once.
+const blockSize = 1024
+
+// ErrNotSupported is returned by Open if the package was built without PortAudio support.
+var ErrNotSupported = errors.New("audioout: not supported, build with tag \"portaudio\"")
+
+// Play opens the default output device with the default sample rate and plays the given modulator, see
+// Output.Play.
+func Play(ctx context.Context, modulator audio.Modulator, done <-chan struct{}) error {
+	output, err := Open(DefaultSampleRate)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	return output.Play(ctx, modulator, done)
+}
+
+// Play renders the given modulator and writes the samples to the output until the given context is done or the
+// given channel is closed, e.g. the Done channel of an AFSK or SSTV modulator. If done is nil, the modulator is
+// played until the context is done. It returns nil when done is closed, otherwise the error of the context or the
+// output.
+func (o *Output) Play(ctx context.Context, modulator audio.Modulator, done <-chan struct{}) error {
+	return play(ctx, o, modulator, done)
+}
+
+func play(ctx context.Context, sink audio.Sink, modulator audio.Modulator, done <-chan struct{}) error {
+	renderer := audio.NewRenderer(modulator, sink.SampleRate())
+	block := make([]float64, blockSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return nil
+		default:
+		}
+		renderer.Render(block)
+		_, err := sink.WriteSamples(block)
+		if err != nil {
+			return err
+		}
+	}
+}
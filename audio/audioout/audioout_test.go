@@ -0,0 +1,71 @@
+package audioout
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+type toneModulator struct{}
+
+func (toneModulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	return 1, 1000, 0
+}
+
+// countingSink counts the written samples and calls a function after each write.
+type countingSink struct {
+	samples int
+	written func(samples int) error
+}
+
+func (s *countingSink) SampleRate() int {
+	return 8000
+}
+
+func (s *countingSink) WriteSamples(samples []float64) (int, error) {
+	s.samples += len(samples)
+	return len(samples), s.written(s.samples)
+}
+
+func TestPlayUntilDone(t *testing.T) {
+	done := make(chan struct{})
+	sink := &countingSink{written: func(samples int) error {
+		if samples >= 8000 {
+			close(done)
+		}
+		return nil
+	}}
+	var _ audio.Sink = sink
+
+	err := play(context.Background(), sink, toneModulator{}, done)
+	assert.NoError(t, err)
+	assert.Equal(t, 8192, sink.samples)
+}
+
+func TestPlayUntilContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &countingSink{written: func(samples int) error {
+		if samples >= 4000 {
+			cancel()
+		}
+		return nil
+	}}
+
+	err := play(ctx, sink, toneModulator{}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 4096, sink.samples)
+}
+
+func TestPlayOutputError(t *testing.T) {
+	failure := errors.New("failure")
+	sink := &countingSink{written: func(int) error {
+		return failure
+	}}
+
+	err := play(context.Background(), sink, toneModulator{}, nil)
+	assert.Equal(t, failure, err)
+}
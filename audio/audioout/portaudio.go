@@ -0,0 +1,100 @@
+//go:build portaudio
+
+package audioout
+
+/*
+#cgo pkg-config: portaudio-2.0
+#include <portaudio.h>
+
+static PaError dm_open(PaStream **stream, double sampleRate, unsigned long framesPerBuffer) {
+	return Pa_OpenDefaultStream(stream, 0, 1, paFloat32, sampleRate, framesPerBuffer, NULL, NULL);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// Output is the default output device of the soundcard. It implements audio.Sink.
+type Output struct {
+	sampleRate int
+
+	mu     sync.Mutex
+	stream unsafe.Pointer
+	buffer []float32
+}
+
+// Open opens the default output device of the soundcard with the given sample rate.
+func Open(sampleRate int) (*Output, error) {
+	err := C.Pa_Initialize()
+	if err != C.paNoError {
+		return nil, paError("cannot initialize", err)
+	}
+	var stream unsafe.Pointer
+	err = C.dm_open(&stream, C.double(sampleRate), C.ulong(blockSize))
+	if err != C.paNoError {
+		C.Pa_Terminate()
+		return nil, paError("cannot open the default output", err)
+	}
+	err = C.Pa_StartStream(stream)
+	if err != C.paNoError {
+		C.Pa_CloseStream(stream)
+		C.Pa_Terminate()
+		return nil, paError("cannot start the output", err)
+	}
+	return &Output{
+		sampleRate: sampleRate,
+		stream:     stream,
+	}, nil
+}
+
+func paError(message string, err C.PaError) error {
+	return fmt.Errorf("audioout: %s: %s", message, C.GoString(C.Pa_GetErrorText(err)))
+}
+
+// SampleRate returns the sample rate of the output in Hz.
+func (o *Output) SampleRate() int {
+	return o.sampleRate
+}
+
+// WriteSamples plays the given samples. It blocks until the samples are buffered by the device.
+func (o *Output) WriteSamples(samples []float64) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.stream == nil {
+		return 0, audio.ErrClosed
+	}
+	if len(samples) == 0 {
+		return 0, nil
+	}
+	if cap(o.buffer) < len(samples) {
+		o.buffer = make([]float32, len(samples))
+	}
+	buffer := o.buffer[:len(samples)]
+	audio.Convert(buffer, samples)
+
+	err := C.Pa_WriteStream(o.stream, unsafe.Pointer(&buffer[0]), C.ulong(len(buffer)))
+	if err != C.paNoError && err != C.paOutputUnderflowed {
+		return 0, paError("cannot write", err)
+	}
+	return len(samples), nil
+}
+
+// Close stops the output after the buffered samples are played and releases the device.
+func (o *Output) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.stream == nil {
+		return nil
+	}
+	C.Pa_StopStream(o.stream)
+	C.Pa_CloseStream(o.stream)
+	C.Pa_Terminate()
+	o.stream = nil
+	return nil
+}
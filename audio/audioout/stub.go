@@ -0,0 +1,26 @@
+//go:build !portaudio
+
+package audioout
+
+// Output is the default output device of the soundcard. Without PortAudio support, no output can be opened.
+type Output struct{}
+
+// Open returns ErrNotSupported, because the package was built without PortAudio support.
+func Open(sampleRate int) (*Output, error) {
+	return nil, ErrNotSupported
+}
+
+// SampleRate returns the sample rate of the output in Hz.
+func (o *Output) SampleRate() int {
+	return 0
+}
+
+// WriteSamples returns ErrNotSupported.
+func (o *Output) WriteSamples(samples []float64) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Close closes the output.
+func (o *Output) Close() error {
+	return nil
+}
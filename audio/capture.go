@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Device describes an audio input device of a capture driver.
+type Device struct {
+	// ID identifies the device when it is opened.
+	ID string
+	// Description is a human readable description of the device.
+	Description string
+}
+
+// InputStream is an open capture stream of an input device.
+type InputStream interface {
+	Source
+	// Close stops the capture and releases the device. A pending ReadSamples returns.
+	Close() error
+}
+
+// CaptureDriver is the interface of audio capture backends.
+type CaptureDriver interface {
+	// InputDevices returns the available input devices.
+	InputDevices() ([]Device, error)
+	// OpenInput opens a mono capture stream on the device with the given ID at the given rate and format.
+	OpenInput(id string, sampleRate int, format SampleFormat) (InputStream, error)
+}
+
+// Block is a block of captured samples.
+type Block struct {
+	// Time is the UTC time of the first sample of the block.
+	Time time.Time
+	// Samples of the block.
+	Samples []float64
+	// Dropped is the number of samples that were dropped right before this block because the consumer was too slow.
+	Dropped int
+}
+
+// Capture reads blocks of samples from a source in the background and delivers them through a buffered channel.
+// If the consumer does not keep up and the buffer is full, blocks are dropped and accounted as overflows. The
+// timestamps are derived from the number of captured samples, including the dropped ones.
+type Capture struct {
+	source     Source
+	start      time.Time
+	blockSize  int
+	blocks     chan Block
+	sampleRate int
+
+	mu        sync.Mutex
+	overflows int
+	dropped   int
+	err       error
+}
+
+// StartCapture starts to capture blocks of the given size from the given source. The first sample is taken at
+// the given start time. Up to queueLength blocks are buffered. The capture ends when the source returns an error,
+// e.g. because the underlying stream was closed.
+func StartCapture(source Source, start time.Time, blockSize int, queueLength int) *Capture {
+	result := &Capture{
+		source:     source,
+		start:      start.UTC(),
+		blockSize:  blockSize,
+		blocks:     make(chan Block, queueLength),
+		sampleRate: source.SampleRate(),
+	}
+	go result.run()
+	return result
+}
+
+// Blocks returns the channel of captured blocks. It is closed when the capture ends.
+func (c *Capture) Blocks() <-chan Block {
+	return c.blocks
+}
+
+// Overflows returns the number of dropped blocks.
+func (c *Capture) Overflows() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.overflows
+}
+
+// Dropped returns the total number of dropped samples.
+func (c *Capture) Dropped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropped
+}
+
+// Err returns the error that ended the capture, or nil if the capture is still running or the source was exhausted.
+func (c *Capture) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Capture) run() {
+	defer close(c.blocks)
+
+	var count int64
+	pendingDrop := 0
+	for {
+		samples := make([]float64, c.blockSize)
+		n, err := c.readBlock(samples)
+		if n > 0 {
+			block := Block{
+				Time:    c.start.Add(time.Duration(count) * time.Second / time.Duration(c.sampleRate)),
+				Samples: samples[:n],
+				Dropped: pendingDrop,
+			}
+			count += int64(n)
+			select {
+			case c.blocks <- block:
+				pendingDrop = 0
+			default:
+				pendingDrop += n
+				c.mu.Lock()
+				c.overflows++
+				c.dropped += n
+				c.mu.Unlock()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				c.mu.Lock()
+				c.err = err
+				c.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+// readBlock reads from the source until the block is full or an error occurs.
+func (c *Capture) readBlock(samples []float64) (int, error) {
+	read := 0
+	for read < len(samples) {
+		n, err := c.source.ReadSamples(samples[read:])
+		read += n
+		if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
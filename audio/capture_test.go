@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSource struct {
+	samples int
+	err     error
+}
+
+func (s *failingSource) SampleRate() int {
+	return 1000
+}
+
+func (s *failingSource) ReadSamples(samples []float64) (int, error) {
+	if s.samples == 0 {
+		return 0, s.err
+	}
+	n := minInt(len(samples), s.samples)
+	s.samples -= n
+	return n, nil
+}
+
+func TestCaptureBlocks(t *testing.T) {
+	loopback := NewLoopback(1000, 0, 0)
+	start := time.Date(2020, 6, 21, 12, 0, 0, 0, time.UTC)
+	capture := StartCapture(loopback, start, 100, 10)
+
+	samples := make([]float64, 250)
+	for i := range samples {
+		samples[i] = float64(i)
+	}
+	_, err := loopback.WriteSamples(samples)
+	require.NoError(t, err)
+	loopback.Close()
+
+	blocks := make([]Block, 0)
+	for block := range capture.Blocks() {
+		blocks = append(blocks, block)
+	}
+	require.Len(t, blocks, 3)
+	assert.Equal(t, start, blocks[0].Time)
+	assert.Equal(t, start.Add(100*time.Millisecond), blocks[1].Time)
+	assert.Equal(t, start.Add(200*time.Millisecond), blocks[2].Time)
+	assert.Len(t, blocks[2].Samples, 50)
+	assert.Equal(t, 100.0, blocks[1].Samples[0])
+	assert.Equal(t, 0, capture.Overflows())
+	assert.NoError(t, capture.Err())
+}
+
+func TestCaptureOverflow(t *testing.T) {
+	source := &failingSource{samples: 1000, err: errors.New("device gone")}
+	capture := StartCapture(source, time.Time{}, 100, 1)
+
+	// the consumer starts after the source ended, only the first block fits into the queue
+	for capture.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	blocks := make([]Block, 0)
+	for block := range capture.Blocks() {
+		blocks = append(blocks, block)
+	}
+	require.Len(t, blocks, 1)
+	assert.Equal(t, 9, capture.Overflows())
+	assert.Equal(t, 900, capture.Dropped())
+	assert.EqualError(t, capture.Err(), "device gone")
+}
+
+func TestCaptureDroppedBeforeBlock(t *testing.T) {
+	loopback := NewLoopback(1000, 0, 0)
+	capture := StartCapture(loopback, time.Time{}, 10, 1)
+
+	// the loopback holds back the last sample for interpolation
+	_, err := loopback.WriteSamples(make([]float64, 31))
+	require.NoError(t, err)
+	for capture.Overflows() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	first := <-capture.Blocks()
+	assert.Equal(t, 0, first.Dropped)
+
+	_, err = loopback.WriteSamples(make([]float64, 10))
+	require.NoError(t, err)
+	second := <-capture.Blocks()
+	assert.Equal(t, 20, second.Dropped)
+	assert.Equal(t, 30*time.Millisecond, second.Time.Sub(first.Time))
+	loopback.Close()
+}
@@ -0,0 +1,223 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SampleFormat describes the binary encoding of a single sample. All formats are little endian.
+type SampleFormat int
+
+// The supported sample formats.
+const (
+	Float64 SampleFormat = iota
+	Float32
+	Int16
+)
+
+// Size returns the size of a single sample in bytes.
+func (f SampleFormat) Size() int {
+	switch f {
+	case Float64:
+		return 8
+	case Float32:
+		return 4
+	case Int16:
+		return 2
+	default:
+		panic(fmt.Sprintf("unknown sample format %d", f))
+	}
+}
+
+func (f SampleFormat) String() string {
+	switch f {
+	case Float64:
+		return "float64"
+	case Float32:
+		return "float32"
+	case Int16:
+		return "int16"
+	default:
+		return fmt.Sprintf("SampleFormat(%d)", int(f))
+	}
+}
+
+// Sample is the constraint for all supported sample types. Floating point samples are normalized to [-1.0, 1.0],
+// integer samples use their full range.
+type Sample interface {
+	~int16 | ~float32 | ~float64
+}
+
+// ToFloat64 converts the given sample into a normalized float64 sample.
+func ToFloat64[T Sample](sample T) float64 {
+	switch s := any(sample).(type) {
+	case int16:
+		return float64(s) / math.MaxInt16
+	default:
+		return float64(sample)
+	}
+}
+
+// FromFloat64 converts the given normalized float64 sample into a sample of type T.
+// Integer samples are clipped to their range.
+func FromFloat64[T Sample](sample float64) T {
+	var result T
+	switch any(result).(type) {
+	case int16:
+		clipped := math.Max(-1, math.Min(1, sample))
+		return T(math.Round(clipped * math.MaxInt16))
+	default:
+		return T(sample)
+	}
+}
+
+// Convert converts the samples of src into the sample type of dst. It returns the number of converted samples,
+// which is the minimum of len(dst) and len(src).
+func Convert[From, To Sample](dst []To, src []From) int {
+	n := minInt(len(dst), len(src))
+	for i := 0; i < n; i++ {
+		dst[i] = FromFloat64[To](ToFloat64(src[i]))
+	}
+	return n
+}
+
+// Encode encodes the given samples into dst using the given format. It returns the number of encoded samples.
+func Encode(format SampleFormat, dst []byte, src []float64) int {
+	size := format.Size()
+	n := minInt(len(dst)/size, len(src))
+	for i := 0; i < n; i++ {
+		b := dst[i*size:]
+		switch format {
+		case Float64:
+			binary.LittleEndian.PutUint64(b, math.Float64bits(src[i]))
+		case Float32:
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(src[i])))
+		case Int16:
+			binary.LittleEndian.PutUint16(b, uint16(FromFloat64[int16](src[i])))
+		}
+	}
+	return n
+}
+
+// Decode decodes the samples from src using the given format into dst. It returns the number of decoded samples.
+func Decode(format SampleFormat, dst []float64, src []byte) int {
+	size := format.Size()
+	n := minInt(len(dst), len(src)/size)
+	for i := 0; i < n; i++ {
+		b := src[i*size:]
+		switch format {
+		case Float64:
+			dst[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case Float32:
+			dst[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case Int16:
+			dst[i] = ToFloat64(int16(binary.LittleEndian.Uint16(b)))
+		}
+	}
+	return n
+}
+
+// WriterSink is a Sink that writes the encoded samples to an io.Writer.
+type WriterSink struct {
+	w          io.Writer
+	sampleRate int
+	format     SampleFormat
+	buffer     []byte
+}
+
+// NewWriterSink returns a new WriterSink that writes samples with the given rate and format to the given writer.
+func NewWriterSink(w io.Writer, sampleRate int, format SampleFormat) *WriterSink {
+	return &WriterSink{
+		w:          w,
+		sampleRate: sampleRate,
+		format:     format,
+	}
+}
+
+// SampleRate returns the sample rate of the sink in Hz.
+func (s *WriterSink) SampleRate() int {
+	return s.sampleRate
+}
+
+// Format returns the sample format of the sink.
+func (s *WriterSink) Format() SampleFormat {
+	return s.format
+}
+
+// WriteSamples encodes the given samples and writes them to the underlying writer.
+func (s *WriterSink) WriteSamples(samples []float64) (int, error) {
+	size := len(samples) * s.format.Size()
+	if cap(s.buffer) < size {
+		s.buffer = make([]byte, size)
+	}
+	s.buffer = s.buffer[:size]
+	Encode(s.format, s.buffer, samples)
+	n, err := s.w.Write(s.buffer)
+	return n / s.format.Size(), err
+}
+
+// ReaderSource is a Source that reads the encoded samples from an io.Reader.
+type ReaderSource struct {
+	r          io.Reader
+	sampleRate int
+	format     SampleFormat
+	buffer     []byte
+	pending    int
+}
+
+// NewReaderSource returns a new ReaderSource that reads samples with the given rate and format from the given reader.
+func NewReaderSource(r io.Reader, sampleRate int, format SampleFormat) *ReaderSource {
+	return &ReaderSource{
+		r:          r,
+		sampleRate: sampleRate,
+		format:     format,
+	}
+}
+
+// SampleRate returns the sample rate of the source in Hz.
+func (s *ReaderSource) SampleRate() int {
+	return s.sampleRate
+}
+
+// Format returns the sample format of the source.
+func (s *ReaderSource) Format() SampleFormat {
+	return s.format
+}
+
+// ReadSamples reads and decodes samples from the underlying reader. Incomplete samples at the end of the
+// stream are discarded.
+func (s *ReaderSource) ReadSamples(samples []float64) (int, error) {
+	sampleSize := s.format.Size()
+	size := len(samples) * sampleSize
+	if size < sampleSize {
+		return 0, nil
+	}
+	if cap(s.buffer) < size {
+		buffer := make([]byte, size)
+		copy(buffer, s.buffer[:s.pending])
+		s.buffer = buffer
+	}
+	s.buffer = s.buffer[:size]
+
+	n, err := io.ReadAtLeast(s.r, s.buffer[s.pending:], sampleSize-s.pending)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	available := s.pending + n
+	complete := available / sampleSize * sampleSize
+	result := Decode(s.format, samples, s.buffer[:complete])
+	s.pending = copy(s.buffer, s.buffer[complete:available])
+	if result > 0 && err == io.EOF {
+		err = nil
+	}
+	return result, err
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
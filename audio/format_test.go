@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleConversion(t *testing.T) {
+	assert.Equal(t, int16(math.MaxInt16), FromFloat64[int16](1))
+	assert.Equal(t, int16(-math.MaxInt16), FromFloat64[int16](-2), "clipped")
+	assert.Equal(t, int16(0), FromFloat64[int16](0))
+	assert.Equal(t, float32(0.5), FromFloat64[float32](0.5))
+	assert.Equal(t, 1.0, ToFloat64(int16(math.MaxInt16)))
+	assert.Equal(t, 0.25, ToFloat64(float32(0.25)))
+
+	ints := make([]int16, 3)
+	n := Convert(ints, []float32{-1, 0, 1, 0.5})
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int16{-math.MaxInt16, 0, math.MaxInt16}, ints)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	samples := []float64{-1, -0.5, 0, 0.25, 1}
+	for _, format := range []SampleFormat{Float64, Float32, Int16} {
+		t.Run(format.String(), func(t *testing.T) {
+			encoded := make([]byte, len(samples)*format.Size())
+			assert.Equal(t, len(samples), Encode(format, encoded, samples))
+
+			decoded := make([]float64, len(samples))
+			assert.Equal(t, len(samples), Decode(format, decoded, encoded))
+			assert.InDeltaSlice(t, samples, decoded, 1e-4)
+		})
+	}
+}
+
+func TestWriterSinkAndReaderSource(t *testing.T) {
+	samples := []float64{-1, -0.5, 0, 0.25, 1}
+	buf := new(bytes.Buffer)
+	sink := NewWriterSink(buf, 8000, Int16)
+
+	n, err := sink.WriteSamples(samples)
+	require.NoError(t, err)
+	assert.Equal(t, len(samples), n)
+	assert.Equal(t, 2*len(samples), buf.Len())
+
+	source := NewReaderSource(iotest.OneByteReader(buf), 8000, Int16)
+	actual := make([]float64, 0, len(samples))
+	block := make([]float64, 2)
+	for {
+		n, err := source.ReadSamples(block)
+		actual = append(actual, block[:n]...)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.InDeltaSlice(t, samples, actual, 1e-4)
+}
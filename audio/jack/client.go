@@ -0,0 +1,256 @@
+//go:build jack
+
+package jack
+
+/*
+#cgo pkg-config: jack
+#include <stdlib.h>
+#include <string.h>
+#include <jack/jack.h>
+#include <jack/ringbuffer.h>
+
+typedef struct {
+	jack_port_t *port;
+	jack_ringbuffer_t *buffer;
+	int output;
+} dm_port;
+
+typedef struct {
+	jack_client_t *client;
+	dm_port *ports;
+	int count;
+} dm_client;
+
+static int dm_process(jack_nframes_t nframes, void *arg) {
+	dm_client *c = arg;
+	size_t size = nframes * sizeof(float);
+	for (int i = 0; i < c->count; i++) {
+		dm_port *p = &c->ports[i];
+		char *samples = jack_port_get_buffer(p->port, nframes);
+		if (p->output) {
+			size_t n = jack_ringbuffer_read(p->buffer, samples, size);
+			memset(samples + n, 0, size - n);
+		} else {
+			jack_ringbuffer_write(p->buffer, samples, size);
+		}
+	}
+	return 0;
+}
+
+static dm_client *dm_open(const char *name, int count, jack_status_t *status) {
+	jack_client_t *client = jack_client_open(name, JackNullOption, status);
+	if (client == NULL) {
+		return NULL;
+	}
+	dm_client *c = calloc(1, sizeof(dm_client));
+	c->client = client;
+	c->ports = calloc(count, sizeof(dm_port));
+	c->count = count;
+	return c;
+}
+
+static int dm_register_port(dm_client *c, int index, const char *name, int output, size_t bufferSize) {
+	unsigned long flags = output ? JackPortIsOutput : JackPortIsInput;
+	jack_port_t *port = jack_port_register(c->client, name, JACK_DEFAULT_AUDIO_TYPE, flags, 0);
+	if (port == NULL) {
+		return -1;
+	}
+	c->ports[index].port = port;
+	c->ports[index].buffer = jack_ringbuffer_create(bufferSize);
+	c->ports[index].output = output;
+	return 0;
+}
+
+static jack_ringbuffer_t *dm_ring(dm_client *c, int index) {
+	return c->ports[index].buffer;
+}
+
+static int dm_activate(dm_client *c) {
+	jack_set_process_callback(c->client, dm_process, c);
+	return jack_activate(c->client);
+}
+
+static void dm_close(dm_client *c) {
+	jack_deactivate(c->client);
+	jack_client_close(c->client);
+	for (int i = 0; i < c->count; i++) {
+		if (c->ports[i].buffer != NULL) {
+			jack_ringbuffer_free(c->ports[i].buffer);
+		}
+	}
+	free(c->ports);
+	free(c);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// bufferDuration is the duration in seconds of the buffer of each port.
+const bufferDuration = 1
+
+const sampleSize = C.size_t(unsafe.Sizeof(C.float(0)))
+
+// Client is a JACK client with named input and output ports.
+type Client struct {
+	c          *C.dm_client
+	sampleRate int
+	inputs     map[string]*Input
+	outputs    map[string]*Output
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	// lock protects the C client against Close while the ring buffers are used
+	lock sync.RWMutex
+}
+
+// Open connects to the JACK server as a client with the given name and registers the given input and output ports.
+func Open(name string, inputs, outputs []string) (*Client, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var status C.jack_status_t
+	c := C.dm_open(cName, C.int(len(inputs)+len(outputs)), &status)
+	if c == nil {
+		return nil, fmt.Errorf("jack: cannot open client %q, status 0x%x", name, int(status))
+	}
+
+	result := &Client{
+		c:          c,
+		sampleRate: int(C.jack_get_sample_rate(c.client)),
+		inputs:     make(map[string]*Input),
+		outputs:    make(map[string]*Output),
+		closed:     make(chan struct{}),
+	}
+	bufferSize := C.size_t(bufferDuration*result.sampleRate) * sampleSize
+
+	index := 0
+	register := func(portName string, output bool) (ring, error) {
+		cPortName := C.CString(portName)
+		defer C.free(unsafe.Pointer(cPortName))
+		var cOutput C.int
+		if output {
+			cOutput = 1
+		}
+		if C.dm_register_port(c, C.int(index), cPortName, cOutput, bufferSize) != 0 {
+			return nil, fmt.Errorf("jack: cannot register port %q", portName)
+		}
+		r := &ringBuffer{client: result, buffer: C.dm_ring(c, C.int(index))}
+		index++
+		return r, nil
+	}
+	for _, portName := range inputs {
+		r, err := register(portName, false)
+		if err != nil {
+			C.dm_close(c)
+			return nil, err
+		}
+		result.inputs[portName] = &Input{name: portName, sampleRate: result.sampleRate, ring: r, closed: result.closed}
+	}
+	for _, portName := range outputs {
+		r, err := register(portName, true)
+		if err != nil {
+			C.dm_close(c)
+			return nil, err
+		}
+		result.outputs[portName] = &Output{name: portName, sampleRate: result.sampleRate, ring: r, closed: result.closed}
+	}
+
+	if C.dm_activate(c) != 0 {
+		C.dm_close(c)
+		return nil, fmt.Errorf("jack: cannot activate client %q", name)
+	}
+	return result, nil
+}
+
+// SampleRate returns the sample rate of the JACK server in Hz.
+func (c *Client) SampleRate() int {
+	return c.sampleRate
+}
+
+// Input returns the input port with the given name.
+func (c *Client) Input(name string) (*Input, bool) {
+	result, ok := c.inputs[name]
+	return result, ok
+}
+
+// Output returns the output port with the given name.
+func (c *Client) Output(name string) (*Output, bool) {
+	result, ok := c.outputs[name]
+	return result, ok
+}
+
+// Connect connects the given ports. The names are full port names, e.g. "digimodes:out" or "system:playback_1".
+func (c *Client) Connect(source, destination string) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.c == nil {
+		return audio.ErrClosed
+	}
+	cSource := C.CString(source)
+	defer C.free(unsafe.Pointer(cSource))
+	cDestination := C.CString(destination)
+	defer C.free(unsafe.Pointer(cDestination))
+	if C.jack_connect(c.c.client, cSource, cDestination) != 0 {
+		return fmt.Errorf("jack: cannot connect %q to %q", source, destination)
+	}
+	return nil
+}
+
+// Close deactivates the client and disconnects it from the JACK server. Blocked reads and writes on the ports
+// return audio.ErrClosed.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		C.dm_close(c.c)
+		c.c = nil
+	})
+	return nil
+}
+
+type ringBuffer struct {
+	client *Client
+	buffer *C.jack_ringbuffer_t
+}
+
+func (r *ringBuffer) write(samples []float32) int {
+	r.client.lock.RLock()
+	defer r.client.lock.RUnlock()
+	if r.client.c == nil || len(samples) == 0 {
+		return 0
+	}
+	space := C.jack_ringbuffer_write_space(r.buffer) / sampleSize
+	n := C.size_t(len(samples))
+	if n > space {
+		n = space
+	}
+	if n == 0 {
+		return 0
+	}
+	return int(C.jack_ringbuffer_write(r.buffer, (*C.char)(unsafe.Pointer(&samples[0])), n*sampleSize) / sampleSize)
+}
+
+func (r *ringBuffer) read(samples []float32) int {
+	r.client.lock.RLock()
+	defer r.client.lock.RUnlock()
+	if r.client.c == nil || len(samples) == 0 {
+		return 0
+	}
+	available := C.jack_ringbuffer_read_space(r.buffer) / sampleSize
+	n := C.size_t(len(samples))
+	if n > available {
+		n = available
+	}
+	if n == 0 {
+		return 0
+	}
+	return int(C.jack_ringbuffer_read(r.buffer, (*C.char)(unsafe.Pointer(&samples[0])), n*sampleSize) / sampleSize)
+}
@@ -0,0 +1,110 @@
+/*
+Package jack provides a JACK client with named input and output ports as audio sources and sinks. It allows to
+route the modulators and decoders through existing JACK or pipewire audio graphs.
+
+The JACK client requires cgo and the JACK development files. It is only built with the build tag "jack", without
+it Open returns ErrNotSupported.
+*/
+package jack
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// ErrNotSupported is returned by Open if the package was built without JACK support.
+var ErrNotSupported = errors.New("jack: not supported, build with tag \"jack\"")
+
+// pollInterval is the time to wait for the JACK process cycle to free space or to provide samples.
+const pollInterval = 5 * time.Millisecond
+
+// ring is the realtime safe buffer between the JACK process cycle and a port.
+type ring interface {
+	write(samples []float32) int
+	read(samples []float32) int
+}
+
+// Output is an output port of the client. It implements audio.Sink.
+type Output struct {
+	name       string
+	sampleRate int
+	ring       ring
+	closed     <-chan struct{}
+	buffer     []float32
+}
+
+// Name returns the short name of the port.
+func (o *Output) Name() string {
+	return o.name
+}
+
+// SampleRate returns the sample rate of the JACK server in Hz.
+func (o *Output) SampleRate() int {
+	return o.sampleRate
+}
+
+// WriteSamples writes the given samples to the port. It blocks until all samples are buffered or the client
+// is closed.
+func (o *Output) WriteSamples(samples []float64) (int, error) {
+	if cap(o.buffer) < len(samples) {
+		o.buffer = make([]float32, len(samples))
+	}
+	buffer := o.buffer[:len(samples)]
+	audio.Convert(buffer, samples)
+
+	written := 0
+	for written < len(buffer) {
+		n := o.ring.write(buffer[written:])
+		written += n
+		if n > 0 {
+			continue
+		}
+		select {
+		case <-o.closed:
+			return written, audio.ErrClosed
+		case <-time.After(pollInterval):
+		}
+	}
+	return written, nil
+}
+
+// Input is an input port of the client. It implements audio.Source.
+type Input struct {
+	name       string
+	sampleRate int
+	ring       ring
+	closed     <-chan struct{}
+	buffer     []float32
+}
+
+// Name returns the short name of the port.
+func (i *Input) Name() string {
+	return i.name
+}
+
+// SampleRate returns the sample rate of the JACK server in Hz.
+func (i *Input) SampleRate() int {
+	return i.sampleRate
+}
+
+// ReadSamples reads up to len(samples) samples from the port. It blocks until at least one sample is available
+// or the client is closed.
+func (i *Input) ReadSamples(samples []float64) (int, error) {
+	if cap(i.buffer) < len(samples) {
+		i.buffer = make([]float32, len(samples))
+	}
+	buffer := i.buffer[:len(samples)]
+	for {
+		n := i.ring.read(buffer)
+		if n > 0 || len(buffer) == 0 {
+			return audio.Convert(samples, buffer[:n]), nil
+		}
+		select {
+		case <-i.closed:
+			return 0, audio.ErrClosed
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package jack
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// testRing is a ring with a fixed capacity, the test plays the role of the JACK process cycle.
+type testRing struct {
+	mu       sync.Mutex
+	samples  []float32
+	capacity int
+}
+
+func (r *testRing) write(samples []float32) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.capacity - len(r.samples)
+	if n > len(samples) {
+		n = len(samples)
+	}
+	r.samples = append(r.samples, samples[:n]...)
+	return n
+}
+
+func (r *testRing) read(samples []float32) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := copy(samples, r.samples)
+	r.samples = r.samples[n:]
+	return n
+}
+
+func TestOutput(t *testing.T) {
+	r := &testRing{capacity: 4}
+	closed := make(chan struct{})
+	output := &Output{name: "out", sampleRate: 48000, ring: r, closed: closed}
+	var _ audio.Sink = output
+
+	n, err := output.WriteSamples([]float64{0.25, 0.5, -0.5})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []float32{0.25, 0.5, -0.5}, r.samples)
+
+	go func() {
+		time.Sleep(2 * pollInterval)
+		close(closed)
+	}()
+	n, err = output.WriteSamples([]float64{1, 1})
+	assert.Equal(t, audio.ErrClosed, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestInput(t *testing.T) {
+	r := &testRing{capacity: 4}
+	closed := make(chan struct{})
+	input := &Input{name: "in", sampleRate: 48000, ring: r, closed: closed}
+	var _ audio.Source = input
+
+	go func() {
+		time.Sleep(2 * pollInterval)
+		r.write([]float32{0.25, -0.25})
+	}()
+	samples := make([]float64, 4)
+	n, err := input.ReadSamples(samples)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.25, -0.25}, samples[:n])
+
+	close(closed)
+	_, err = input.ReadSamples(samples)
+	assert.Equal(t, audio.ErrClosed, err)
+}
+
+func TestOpenWithoutJACK(t *testing.T) {
+	_, err := Open("digimodes", []string{"in"}, []string{"out"})
+	if err != ErrNotSupported {
+		t.Skip("built with JACK support")
+	}
+	assert.Equal(t, ErrNotSupported, err)
+}
@@ -0,0 +1,36 @@
+//go:build !jack
+
+package jack
+
+// Client is a JACK client with named input and output ports. Without JACK support, no client can be opened.
+type Client struct{}
+
+// Open returns ErrNotSupported, because the package was built without JACK support.
+func Open(name string, inputs, outputs []string) (*Client, error) {
+	return nil, ErrNotSupported
+}
+
+// SampleRate returns the sample rate of the JACK server in Hz.
+func (c *Client) SampleRate() int {
+	return 0
+}
+
+// Input returns the input port with the given name.
+func (c *Client) Input(name string) (*Input, bool) {
+	return nil, false
+}
+
+// Output returns the output port with the given name.
+func (c *Client) Output(name string) (*Output, bool) {
+	return nil, false
+}
+
+// Connect connects the given ports.
+func (c *Client) Connect(source, destination string) error {
+	return ErrNotSupported
+}
+
+// Close disconnects the client from the JACK server.
+func (c *Client) Close() error {
+	return nil
+}
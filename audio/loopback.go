@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when writing to a closed device.
+var ErrClosed = errors.New("audio: device closed")
+
+// Loopback is an in-memory duplex audio device. All samples written to its playback side are provided on its
+// capture side, delayed by a configurable latency and resampled by a configurable drift between the two sides.
+type Loopback struct {
+	sampleRate int
+	step       float64
+
+	mu       sync.Mutex
+	readable *sync.Cond
+	buffer   []float64
+	position float64
+	closed   bool
+}
+
+// NewLoopback returns a new Loopback with the given sample rate, latency and drift. The drift is given in ppm,
+// a positive drift means that the capture side runs faster than the playback side.
+func NewLoopback(sampleRate int, latency time.Duration, drift float64) *Loopback {
+	result := &Loopback{
+		sampleRate: sampleRate,
+		step:       1.0 / (1.0 + drift/1e6),
+		buffer:     make([]float64, int(latency.Seconds()*float64(sampleRate))),
+	}
+	result.readable = sync.NewCond(&result.mu)
+	return result
+}
+
+// SampleRate returns the sample rate of the loopback device.
+func (l *Loopback) SampleRate() int {
+	return l.sampleRate
+}
+
+// WriteSamples writes the given samples to the playback side.
+func (l *Loopback) WriteSamples(samples []float64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	l.buffer = append(l.buffer, samples...)
+	l.readable.Broadcast()
+	return len(samples), nil
+}
+
+// ReadSamples reads samples from the capture side. It blocks until at least one sample is available. When
+// the device is closed, the remaining samples can still be read, after that ReadSamples returns io.EOF.
+func (l *Loopback) ReadSamples(samples []float64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.available() == 0 && !l.closed {
+		l.readable.Wait()
+	}
+	if l.available() == 0 {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(samples) && l.available() > 0 {
+		i := int(l.position)
+		fraction := l.position - float64(i)
+		samples[n] = l.sample(i)*(1-fraction) + l.sample(i+1)*fraction
+		l.position += l.step
+		n++
+	}
+
+	consumed := int(l.position)
+	l.buffer = append(l.buffer[:0], l.buffer[consumed:]...)
+	l.position -= float64(consumed)
+
+	return n, nil
+}
+
+// Available returns the number of samples that can be read from the capture side without blocking.
+func (l *Loopback) Available() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.available()
+}
+
+func (l *Loopback) available() int {
+	limit := len(l.buffer)
+	if !l.closed {
+		// the interpolation needs the sample after the current position
+		limit--
+	}
+	remaining := float64(limit) - l.position
+	if remaining <= 0 {
+		return 0
+	}
+	return int(math.Ceil(remaining / l.step))
+}
+
+func (l *Loopback) sample(i int) float64 {
+	if i >= len(l.buffer) {
+		return 0
+	}
+	return l.buffer[i]
+}
+
+// Close closes the playback side of the device.
+func (l *Loopback) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	l.readable.Broadcast()
+	return nil
+}
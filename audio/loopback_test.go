@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopbackPassThrough(t *testing.T) {
+	l := NewLoopback(1000, 0, 0)
+
+	n, err := l.WriteSamples([]float64{0.1, 0.2, 0.3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 2, l.Available())
+
+	l.Close()
+	_, err = l.WriteSamples([]float64{0.4})
+	assert.Equal(t, ErrClosed, err)
+
+	actual := readAll(t, l)
+	assert.Equal(t, []float64{0.1, 0.2, 0.3}, actual)
+}
+
+func TestLoopbackLatency(t *testing.T) {
+	l := NewLoopback(1000, 5*time.Millisecond, 0)
+
+	l.WriteSamples([]float64{1, 1})
+	l.Close()
+
+	actual := readAll(t, l)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, 1, 1}, actual)
+}
+
+func TestLoopbackDrift(t *testing.T) {
+	l := NewLoopback(1000, 0, 1000)
+	input := make([]float64, 10000)
+	for i := range input {
+		input[i] = float64(i)
+	}
+
+	l.WriteSamples(input)
+	l.Close()
+
+	actual := readAll(t, l)
+	assert.InDelta(t, 10010, len(actual), 1)
+	assert.InDelta(t, 5000, actual[5005], 0.01)
+}
+
+func TestLoopbackBlockingRead(t *testing.T) {
+	l := NewLoopback(1000, 0, 0)
+	read := make(chan int)
+	go func() {
+		buf := make([]float64, 10)
+		n, _ := l.ReadSamples(buf)
+		read <- n
+	}()
+
+	l.WriteSamples([]float64{1, 2, 3})
+	select {
+	case n := <-read:
+		assert.Equal(t, 2, n)
+	case <-time.After(time.Second):
+		t.Fatal("read does not return")
+	}
+}
+
+func readAll(t *testing.T, source Source) []float64 {
+	t.Helper()
+	result := make([]float64, 0)
+	buf := make([]float64, 3)
+	for {
+		n, err := source.ReadSamples(buf)
+		result = append(result, buf[:n]...)
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+	}
+}
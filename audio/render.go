@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// Modulator is the interface of the modulators that can be rendered. Modulate is called for each sample with the
+// time t in seconds and the amplitude a, frequency f and phase p of the previous sample. It returns the amplitude,
+// frequency and phase of the current sample.
+type Modulator interface {
+	Modulate(t, a, f, p float64) (amplitude, frequency, phase float64)
+}
+
+// Renderer drives a Modulator at a fixed sample rate and produces the audio samples of its signal. The oscillator
+// keeps a continuous phase when the modulator changes the frequency. Renderer implements Source.
+type Renderer struct {
+	modulator  Modulator
+	sampleRate int
+	level      float64
+	samples    []float64
+	pcm        []byte
+
+	n       int64
+	a, f, p float64
+	phase   float64
+}
+
+// NewRenderer returns a new Renderer for the given modulator at the given sample rate with full level.
+func NewRenderer(modulator Modulator, sampleRate int) *Renderer {
+	return &Renderer{
+		modulator:  modulator,
+		sampleRate: sampleRate,
+		level:      1,
+	}
+}
+
+// SampleRate returns the sample rate in Hz.
+func (r *Renderer) SampleRate() int {
+	return r.sampleRate
+}
+
+// SetLevel sets the output level in the range [0.0, 1.0].
+func (r *Renderer) SetLevel(level float64) {
+	r.level = level
+}
+
+// Samples returns the number of samples rendered so far.
+func (r *Renderer) Samples() int64 {
+	return r.n
+}
+
+// Time returns the time of the next sample in seconds.
+func (r *Renderer) Time() float64 {
+	return float64(r.n) / float64(r.sampleRate)
+}
+
+// Render fills the given slice with the next samples.
+func (r *Renderer) Render(samples []float64) {
+	for i := range samples {
+		r.a, r.f, r.p = r.modulator.Modulate(r.Time(), r.a, r.f, r.p)
+		samples[i] = r.level * r.a * math.Sin(r.phase+r.p)
+
+		r.phase += 2 * math.Pi * r.f / float64(r.sampleRate)
+		if r.phase > 2*math.Pi {
+			r.phase -= 2 * math.Pi
+		}
+		r.n++
+	}
+}
+
+// ReadSamples renders len(samples) samples. It never returns an error.
+func (r *Renderer) ReadSamples(samples []float64) (int, error) {
+	r.Render(samples)
+	return len(samples), nil
+}
+
+// WritePCM renders the given number of samples and writes them in the given format to the given writer.
+func (r *Renderer) WritePCM(w io.Writer, format SampleFormat, count int) error {
+	if cap(r.samples) < count {
+		r.samples = make([]float64, count)
+	}
+	r.samples = r.samples[:count]
+	size := count * format.Size()
+	if cap(r.pcm) < size {
+		r.pcm = make([]byte, size)
+	}
+	r.pcm = r.pcm[:size]
+
+	r.Render(r.samples)
+	Encode(format, r.pcm, r.samples)
+	_, err := w.Write(r.pcm)
+	return err
+}
+
+// Queuer is implemented by the modulators whose transmissions can be rendered with RenderText.
+type Queuer interface {
+	// Queue queues the given text and the end of the transmission without blocking. The returned channel is closed
+	// when the transmission is sent completely.
+	Queue(text string) (<-chan struct{}, error)
+}
+
+// ErrRenderTimeout is returned by RenderText when the transmission does not end within the timeout.
+var ErrRenderTimeout = errors.New("audio: transmission timeout")
+
+// textBlockSize is the number of samples that RenderText renders at once.
+const textBlockSize = 256
+
+// RenderText queues the given text in the modulator of the renderer and renders the signal as fast as possible until
+// the transmission is complete, followed by the given tail. The modulator must implement Queuer. The whole text is
+// queued before the first sample is rendered, so the rendered signal does not depend on the scheduling of
+// goroutines. The samples are passed to the given handler in blocks, the handler must not keep the block.
+// RenderText returns the error of the modulator or the handler, or ErrRenderTimeout if the rendered transmission
+// exceeds the given timeout.
+func (r *Renderer) RenderText(text string, tail, timeout time.Duration, handler func(block []float64) error) error {
+	queuer, ok := r.modulator.(Queuer)
+	if !ok {
+		return errors.New("audio: the modulator does not implement Queuer")
+	}
+	done, err := queuer.Queue(text)
+	if err != nil {
+		return err
+	}
+
+	block := make([]float64, textBlockSize)
+	maxSamples := r.n + int64(timeout.Seconds()*float64(r.sampleRate))
+	end := int64(-1)
+	for end == -1 || r.n < end {
+		// after the end of the transmission, the modulator is silent, so the tail is rendered as well
+		r.Render(block)
+		if err := handler(block); err != nil {
+			return err
+		}
+		if end != -1 {
+			continue
+		}
+		if err := modulatorError(r.modulator); err != nil {
+			return err
+		}
+		if isClosed(done) {
+			end = r.n + int64(tail.Seconds()*float64(r.sampleRate))
+		} else if r.n > maxSamples {
+			return ErrRenderTimeout
+		}
+	}
+	return nil
+}
+
+// modulatorError returns the internal error that made the given modulator stop, if the modulator reports it.
+func modulatorError(modulator Modulator) error {
+	if errorer, ok := modulator.(interface{ Err() error }); ok {
+		return errorer.Err()
+	}
+	return nil
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxReadSamples limits the number of samples that a PCMReader renders at once, so a large read does not render
+// far beyond the end of the transmission.
+const maxReadSamples = 4096
+
+// PCMReader is an io.Reader that renders the signal of a Modulator as encoded samples, e.g. to pipe it into
+// aplay, an audio stream or a network socket. The rendering is paced by the consumer of the reader.
+type PCMReader struct {
+	renderer *Renderer
+	format   SampleFormat
+	done     <-chan struct{}
+	samples  []float64
+	buffer   []byte
+	pending  []byte
+}
+
+// NewPCMReader returns a new PCMReader that renders the given modulator at the given sample rate and encodes the
+// samples with the given format. The reader returns io.EOF after the given channel is closed, e.g. when the
+// transmission is complete. If done is nil, the reader never ends.
+func NewPCMReader(modulator Modulator, sampleRate int, format SampleFormat, done <-chan struct{}) *PCMReader {
+	return &PCMReader{
+		renderer: NewRenderer(modulator, sampleRate),
+		format:   format,
+		done:     done,
+	}
+}
+
+// Renderer returns the renderer of the reader, e.g. to set the level.
+func (r *PCMReader) Renderer() *Renderer {
+	return r.renderer
+}
+
+// Read renders the next samples into the given buffer.
+func (r *PCMReader) Read(p []byte) (int, error) {
+	if len(r.pending) > 0 {
+		n := copy(p, r.pending)
+		r.pending = r.pending[n:]
+		return n, nil
+	}
+	select {
+	case <-r.done:
+		return 0, io.EOF
+	default:
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	size := r.format.Size()
+	count := minInt(len(p)/size, maxReadSamples)
+	if count == 0 {
+		// the buffer is smaller than a sample, the rest of the sample is returned with the next read
+		count = 1
+	}
+	if cap(r.samples) < count {
+		r.samples = make([]float64, count)
+		r.buffer = make([]byte, count*size)
+	}
+	r.samples = r.samples[:count]
+	r.renderer.Render(r.samples)
+	Encode(r.format, r.buffer[:count*size], r.samples)
+	n := copy(p, r.buffer[:count*size])
+	r.pending = r.buffer[n : count*size]
+	return n, nil
+}
@@ -0,0 +1,179 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toneModulator generates a constant tone that changes its frequency at t=switchTime.
+type toneModulator struct {
+	frequency  float64
+	switched   float64
+	switchTime float64
+}
+
+func (m *toneModulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if t >= m.switchTime {
+		return 1, m.switched, 0
+	}
+	return 1, m.frequency, 0
+}
+
+func TestRenderer(t *testing.T) {
+	m := &toneModulator{frequency: 1000, switched: 1500, switchTime: 0.5}
+	r := NewRenderer(m, 8000)
+	r.SetLevel(0.5)
+
+	samples := make([]float64, 8000)
+	r.Render(samples)
+	assert.Equal(t, int64(8000), r.Samples())
+	assert.Equal(t, 1.0, r.Time())
+
+	for i := 1; i < len(samples); i++ {
+		assert.LessOrEqual(t, math.Abs(samples[i]), 0.5)
+		assert.Less(t, math.Abs(samples[i]-samples[i-1]), 0.5*2*math.Pi*1500/8000+1e-9, "continuous phase at sample %d", i)
+	}
+	assert.Equal(t, 0.0, samples[0])
+	assert.InDelta(t, 0.5*math.Sin(2*math.Pi*1000/8000), samples[1], 1e-9)
+}
+
+func TestRendererWritePCM(t *testing.T) {
+	m := &toneModulator{frequency: 1000, switched: 1000}
+	r := NewRenderer(m, 8000)
+	buf := new(bytes.Buffer)
+
+	err := r.WritePCM(buf, Int16, 8)
+	require.NoError(t, err)
+	require.Equal(t, 16, buf.Len())
+	assert.Equal(t, int16(0), int16(binary.LittleEndian.Uint16(buf.Bytes()[0:])))
+	assert.Equal(t, int16(math.MaxInt16), int16(binary.LittleEndian.Uint16(buf.Bytes()[4:])))
+
+	buf.Reset()
+	err = r.WritePCM(buf, Float32, 4)
+	require.NoError(t, err)
+	require.Equal(t, 16, buf.Len())
+	assert.InDelta(t, 0.0, math.Float32frombits(binary.LittleEndian.Uint32(buf.Bytes()[0:])), 1e-6, "the phase continues")
+	assert.Equal(t, int64(12), r.Samples())
+}
+
+func TestPCMReader(t *testing.T) {
+	m := &toneModulator{frequency: 1000, switched: 1000, switchTime: 1}
+	done := make(chan struct{})
+	r := NewPCMReader(m, 8000, Int16, done)
+	r.Renderer().SetLevel(0.5)
+
+	buffer := make([]byte, 1001)
+	n, err := r.Read(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, n, "only complete samples")
+
+	// a buffer smaller than a sample gets the sample byte by byte
+	n, err = r.Read(buffer[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = r.Read(buffer[1:2])
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(501), r.Renderer().Samples())
+
+	expected := make([]float64, 501)
+	NewRenderer(m, 8000).Render(expected)
+	sample := int16(binary.LittleEndian.Uint16(buffer[:2]))
+	assert.Equal(t, FromFloat64[int16](0.5*expected[500]), sample)
+
+	close(done)
+	n, err = r.Read(buffer)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err)
+}
+
+// textModulator sends each queued character as a tone of charSamples samples and closes the token of the
+// transmission when all characters are sent. It implements Queuer.
+type textModulator struct {
+	queue   []byte
+	current int
+	token   chan struct{}
+	err     error
+}
+
+const charSamples = 1000
+
+func (m *textModulator) Queue(text string) (<-chan struct{}, error) {
+	m.queue = append(m.queue, text...)
+	m.token = make(chan struct{})
+	return m.token, nil
+}
+
+func (m *textModulator) Err() error {
+	return m.err
+}
+
+func (m *textModulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if m.current == 0 && len(m.queue) > 0 {
+		m.queue = m.queue[1:]
+		m.current = charSamples
+	}
+	if m.current == 0 {
+		return 0, 1000, math.Pi / 8
+	}
+	m.current--
+	if m.current == 0 && len(m.queue) == 0 {
+		close(m.token)
+	}
+	return 1, 1000, math.Pi / 8
+}
+
+func TestRenderText(t *testing.T) {
+	r := NewRenderer(&textModulator{}, 8000)
+	var samples []float64
+	err := r.RenderText("hello", 100*time.Millisecond, time.Minute, func(block []float64) error {
+		samples = append(samples, block...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	first, last, count := -1, -1, 0
+	for i, x := range samples {
+		if x == 0 {
+			continue
+		}
+		if first == -1 {
+			first = i
+		}
+		last = i
+		count++
+	}
+	assert.Equal(t, 0, first)
+	assert.Equal(t, 5*charSamples, count)
+	assert.Equal(t, 5*charSamples, last-first+1, "no gaps between the characters")
+	assert.GreaterOrEqual(t, len(samples)-last-1, 800, "tail")
+}
+
+func TestRenderTextTimeout(t *testing.T) {
+	r := NewRenderer(&textModulator{}, 8000)
+	err := r.RenderText("hello", 0, 10*time.Millisecond, func([]float64) error { return nil })
+	assert.Equal(t, ErrRenderTimeout, err)
+}
+
+func TestRenderTextModulatorError(t *testing.T) {
+	failure := errors.New("failure")
+	m := &textModulator{err: failure}
+	r := NewRenderer(m, 8000)
+	err := r.RenderText("hello", 0, time.Minute, func([]float64) error { return nil })
+	assert.Equal(t, failure, err)
+	assert.Equal(t, int64(textBlockSize), r.Samples())
+}
+
+func TestRenderTextNoWriter(t *testing.T) {
+	r := NewRenderer(&toneModulator{}, 8000)
+	err := r.RenderText("hello", 0, time.Second, func([]float64) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"strings"
+	"sync"
+)
+
+// Route selects the audio that is played on one channel of a stereo output.
+type Route int
+
+// All routes.
+const (
+	// Mute plays silence.
+	Mute Route = iota
+	// Transmit plays the transmit audio.
+	Transmit
+	// Sidetone plays the local sidetone or monitor audio.
+	Sidetone
+	// Mix plays the sum of transmit and sidetone audio, clipped to [-1.0, 1.0].
+	Mix
+)
+
+// Routing defines the routes of the left and the right channel.
+type Routing struct {
+	Left  Route
+	Right Route
+}
+
+// DefaultRouting plays the transmit audio on the left channel (to the rig) and the sidetone on the right channel
+// (to the headphones).
+var DefaultRouting = Routing{Left: Transmit, Right: Sidetone}
+
+// SidetoneFunc generates the sidetone for the given transmit audio into sidetone. Both slices have the same length.
+type SidetoneFunc func(sidetone []float64, transmit []float64)
+
+// Monitor returns a SidetoneFunc that plays the transmit audio at the given level.
+func Monitor(level float64) SidetoneFunc {
+	return func(sidetone []float64, transmit []float64) {
+		for i, sample := range transmit {
+			sidetone[i] = sample * level
+		}
+	}
+}
+
+// Router is a Sink for transmit audio that writes interleaved stereo frames (left, right) to an output sink.
+// The routing of both channels can be configured per mode. The sample rate of the output is the frame rate.
+type Router struct {
+	output Sink
+
+	mu       sync.Mutex
+	routings map[string]Routing
+	mode     string
+	sidetone SidetoneFunc
+	buffer   []float64
+	frames   []float64
+}
+
+// NewRouter returns a new Router that writes to the given stereo output, using the DefaultRouting for all modes
+// and the transmit audio at full level as sidetone.
+func NewRouter(output Sink) *Router {
+	return &Router{
+		output:   output,
+		routings: make(map[string]Routing),
+		sidetone: Monitor(1),
+	}
+}
+
+// SampleRate returns the frame rate of the output in Hz.
+func (r *Router) SampleRate() int {
+	return r.output.SampleRate()
+}
+
+// SetRouting sets the routing for the given mode. The empty mode sets the routing for all modes without their
+// own routing.
+func (r *Router) SetRouting(mode string, routing Routing) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routings[strings.ToLower(mode)] = routing
+}
+
+// SetMode selects the routing of the given mode.
+func (r *Router) SetMode(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mode = strings.ToLower(mode)
+}
+
+// Routing returns the routing of the current mode.
+func (r *Router) Routing() Routing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.routing()
+}
+
+func (r *Router) routing() Routing {
+	if routing, ok := r.routings[r.mode]; ok {
+		return routing
+	}
+	if routing, ok := r.routings[""]; ok {
+		return routing
+	}
+	return DefaultRouting
+}
+
+// SetSidetone sets the function that generates the sidetone from the transmit audio.
+func (r *Router) SetSidetone(sidetone SidetoneFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sidetone = sidetone
+}
+
+// WriteSamples routes the given transmit samples and writes the resulting frames to the output. It returns the
+// number of transmit samples that were written.
+func (r *Router) WriteSamples(samples []float64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	routing := r.routing()
+	if cap(r.buffer) < len(samples) {
+		r.buffer = make([]float64, len(samples))
+		r.frames = make([]float64, 2*len(samples))
+	}
+	sidetone := r.buffer[:len(samples)]
+	frames := r.frames[:2*len(samples)]
+	if routing.Left >= Sidetone || routing.Right >= Sidetone {
+		r.sidetone(sidetone, samples)
+	}
+
+	for i, sample := range samples {
+		frames[2*i] = route(routing.Left, sample, sidetone[i])
+		frames[2*i+1] = route(routing.Right, sample, sidetone[i])
+	}
+	n, err := r.output.WriteSamples(frames)
+	return n / 2, err
+}
+
+func route(route Route, transmit, sidetone float64) float64 {
+	switch route {
+	case Transmit:
+		return transmit
+	case Sidetone:
+		return sidetone
+	case Mix:
+		sum := transmit + sidetone
+		if sum > 1 {
+			return 1
+		}
+		if sum < -1 {
+			return -1
+		}
+		return sum
+	default:
+		return 0
+	}
+}
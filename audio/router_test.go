@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bufferSink struct {
+	samples []float64
+}
+
+func (s *bufferSink) SampleRate() int {
+	return 48000
+}
+
+func (s *bufferSink) WriteSamples(samples []float64) (int, error) {
+	s.samples = append(s.samples, samples...)
+	return len(samples), nil
+}
+
+func TestRouterRoutes(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		routing  Routing
+		expected []float64
+	}{
+		{"default", DefaultRouting, []float64{0.8, 0.4, -0.8, -0.4}},
+		{"swapped", Routing{Left: Sidetone, Right: Transmit}, []float64{0.4, 0.8, -0.4, -0.8}},
+		{"duplicate", Routing{Left: Transmit, Right: Transmit}, []float64{0.8, 0.8, -0.8, -0.8}},
+		{"mute", Routing{Left: Transmit, Right: Mute}, []float64{0.8, 0, -0.8, 0}},
+		{"mix", Routing{Left: Mix, Right: Mute}, []float64{1, 0, -1, 0}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			output := &bufferSink{}
+			router := NewRouter(output)
+			router.SetSidetone(Monitor(0.5))
+			router.SetRouting("", tC.routing)
+
+			n, err := router.WriteSamples([]float64{0.8, -0.8})
+			require.NoError(t, err)
+			assert.Equal(t, 2, n)
+			assert.InDeltaSlice(t, tC.expected, output.samples, 1e-9)
+		})
+	}
+}
+
+func TestRouterPerMode(t *testing.T) {
+	output := &bufferSink{}
+	router := NewRouter(output)
+	router.SetRouting("CW", Routing{Left: Mute, Right: Sidetone})
+	assert.Equal(t, 48000, router.SampleRate())
+
+	router.SetMode("psk31")
+	assert.Equal(t, DefaultRouting, router.Routing())
+	router.SetMode("cw")
+	assert.Equal(t, Routing{Left: Mute, Right: Sidetone}, router.Routing())
+
+	_, err := router.WriteSamples([]float64{0.5})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 0.5}, output.samples)
+}
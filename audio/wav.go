@@ -0,0 +1,247 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	wavHeaderSize   = 44
+	wavFormatPCM    = 1
+	wavFormatFloat  = 3
+	wavRIFFSizeOff  = 4
+	wavDataSizeOff  = 40
+	wavMaxDataBytes = 0xFFFFFFFF - wavHeaderSize
+)
+
+// Errors of the WAV files.
+var (
+	// ErrWAVTooLarge is returned when the data of a WAV file would exceed the 4 GiB limit of the RIFF format.
+	ErrWAVTooLarge = errors.New("audio: WAV file too large")
+	// ErrWAVFormat is returned when reading a file that is not a mono WAV file in one of the supported formats.
+	ErrWAVFormat = errors.New("audio: unsupported WAV format")
+)
+
+// WAVWriter is a Sink that writes mono samples into a RIFF/WAVE file. The sizes in the header are updated when
+// the writer is closed.
+type WAVWriter struct {
+	w          io.WriteSeeker
+	sampleRate int
+	format     SampleFormat
+	buffer     []byte
+	dataBytes  int64
+}
+
+// NewWAVWriter writes the header of a WAV file with the given sample rate and format and returns a new WAVWriter.
+func NewWAVWriter(w io.WriteSeeker, sampleRate int, format SampleFormat) (*WAVWriter, error) {
+	result := &WAVWriter{
+		w:          w,
+		sampleRate: sampleRate,
+		format:     format,
+	}
+	_, err := w.Write(wavHeader(sampleRate, format, 0))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SampleRate returns the sample rate of the WAV file in Hz.
+func (w *WAVWriter) SampleRate() int {
+	return w.sampleRate
+}
+
+// Samples returns the number of samples written so far.
+func (w *WAVWriter) Samples() int64 {
+	return w.dataBytes / int64(w.format.Size())
+}
+
+// WriteSamples writes the given samples to the WAV file.
+func (w *WAVWriter) WriteSamples(samples []float64) (int, error) {
+	size := len(samples) * w.format.Size()
+	if w.dataBytes+int64(size) > wavMaxDataBytes {
+		return 0, ErrWAVTooLarge
+	}
+	if cap(w.buffer) < size {
+		w.buffer = make([]byte, size)
+	}
+	w.buffer = w.buffer[:size]
+	Encode(w.format, w.buffer, samples)
+	n, err := w.w.Write(w.buffer)
+	w.dataBytes += int64(n)
+	return n / w.format.Size(), err
+}
+
+// Close updates the sizes in the header of the WAV file. It does not close the underlying writer.
+func (w *WAVWriter) Close() error {
+	end, err := w.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	header := wavHeader(w.sampleRate, w.format, w.dataBytes)
+	_, err = w.w.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Seek(end, io.SeekStart)
+	return err
+}
+
+// wavBlockSize is the number of samples that WriteWAV renders at once.
+const wavBlockSize = 4096
+
+// WriteWAV renders the signal of the given modulator for the given duration at the given sample rate and writes
+// it as 16 bit PCM RIFF/WAVE file to the given writer. Since the length is known in advance, the writer does not
+// need to support seeking.
+func WriteWAV(w io.Writer, mod Modulator, duration time.Duration, sampleRate int) error {
+	samples := int64(duration.Seconds() * float64(sampleRate))
+	dataBytes := samples * int64(Int16.Size())
+	if dataBytes > wavMaxDataBytes {
+		return ErrWAVTooLarge
+	}
+
+	_, err := w.Write(wavHeader(sampleRate, Int16, dataBytes))
+	if err != nil {
+		return err
+	}
+	renderer := NewRenderer(mod, sampleRate)
+	for remaining := samples; remaining > 0; remaining -= wavBlockSize {
+		count := wavBlockSize
+		if remaining < wavBlockSize {
+			count = int(remaining)
+		}
+		err := renderer.WritePCM(w, Int16, count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteWAVSamples writes the given samples as 16 bit PCM RIFF/WAVE file to the given writer. Like WriteWAV, it
+// does not need to seek.
+func WriteWAVSamples(w io.Writer, samples []float64, sampleRate int) error {
+	dataBytes := int64(len(samples)) * int64(Int16.Size())
+	if dataBytes > wavMaxDataBytes {
+		return ErrWAVTooLarge
+	}
+
+	_, err := w.Write(wavHeader(sampleRate, Int16, dataBytes))
+	if err != nil {
+		return err
+	}
+	pcm := make([]byte, wavBlockSize*Int16.Size())
+	for len(samples) > 0 {
+		count := minInt(len(samples), wavBlockSize)
+		n := Encode(Int16, pcm, samples[:count])
+		_, err := w.Write(pcm[:n*Int16.Size()])
+		if err != nil {
+			return err
+		}
+		samples = samples[count:]
+	}
+	return nil
+}
+
+// WAVReader is a Source that reads the mono samples of a RIFF/WAVE file in one of the supported sample formats.
+type WAVReader struct {
+	*ReaderSource
+}
+
+// NewWAVReader reads the header of a WAV file and returns a new WAVReader for its samples. Chunks other than the
+// format and the data are skipped.
+func NewWAVReader(r io.Reader) (*WAVReader, error) {
+	var riff [12]byte
+	_, err := io.ReadFull(r, riff[:])
+	if err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrWAVFormat
+	}
+
+	var format SampleFormat
+	sampleRate := 0
+	for {
+		var chunk [8]byte
+		_, err := io.ReadFull(r, chunk[:])
+		if err != nil {
+			return nil, err
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if size < 16 {
+				return nil, ErrWAVFormat
+			}
+			fmtChunk := make([]byte, size+size%2)
+			_, err := io.ReadFull(r, fmtChunk)
+			if err != nil {
+				return nil, err
+			}
+			format, err = wavFormat(binary.LittleEndian.Uint16(fmtChunk[0:]), binary.LittleEndian.Uint16(fmtChunk[14:]))
+			if err != nil {
+				return nil, err
+			}
+			if binary.LittleEndian.Uint16(fmtChunk[2:]) != 1 {
+				return nil, ErrWAVFormat
+			}
+			sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:]))
+		case "data":
+			if sampleRate == 0 {
+				return nil, ErrWAVFormat
+			}
+			return &WAVReader{NewReaderSource(io.LimitReader(r, size), sampleRate, format)}, nil
+		default:
+			// chunks are padded to an even size
+			_, err := io.CopyN(io.Discard, r, size+size%2)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// wavFormat returns the sample format for the given format tag and bits per sample.
+func wavFormat(formatTag uint16, bits uint16) (SampleFormat, error) {
+	switch {
+	case formatTag == wavFormatPCM && bits == 16:
+		return Int16, nil
+	case formatTag == wavFormatFloat && bits == 32:
+		return Float32, nil
+	case formatTag == wavFormatFloat && bits == 64:
+		return Float64, nil
+	default:
+		return 0, ErrWAVFormat
+	}
+}
+
+func wavHeader(sampleRate int, format SampleFormat, dataBytes int64) []byte {
+	formatTag := uint16(wavFormatPCM)
+	if format != Int16 {
+		formatTag = wavFormatFloat
+	}
+	sampleSize := format.Size()
+
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[wavRIFFSizeOff:], uint32(wavHeaderSize-8+dataBytes))
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], formatTag)
+	binary.LittleEndian.PutUint16(header[22:], 1) // mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*sampleSize))
+	binary.LittleEndian.PutUint16(header[32:], uint16(sampleSize))
+	binary.LittleEndian.PutUint16(header[34:], uint16(8*sampleSize))
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[wavDataSizeOff:], uint32(dataBytes))
+	return header
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAVWriter(t *testing.T) {
+	testCases := []struct {
+		format    SampleFormat
+		formatTag uint16
+	}{
+		{Int16, wavFormatPCM},
+		{Float32, wavFormatFloat},
+		{Float64, wavFormatFloat},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.format.String(), func(t *testing.T) {
+			f, err := os.Create(filepath.Join(t.TempDir(), "test.wav"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			w, err := NewWAVWriter(f, 8000, tC.format)
+			require.NoError(t, err)
+			n, err := w.WriteSamples([]float64{0, 0.5, -0.5})
+			require.NoError(t, err)
+			assert.Equal(t, 3, n)
+			assert.Equal(t, int64(3), w.Samples())
+			require.NoError(t, w.Close())
+
+			content, err := os.ReadFile(f.Name())
+			require.NoError(t, err)
+			dataBytes := 3 * tC.format.Size()
+			require.Equal(t, wavHeaderSize+dataBytes, len(content))
+			assert.Equal(t, "RIFF", string(content[0:4]))
+			assert.Equal(t, uint32(wavHeaderSize-8+dataBytes), binary.LittleEndian.Uint32(content[4:]))
+			assert.Equal(t, "WAVE", string(content[8:12]))
+			assert.Equal(t, tC.formatTag, binary.LittleEndian.Uint16(content[20:]))
+			assert.Equal(t, uint32(8000), binary.LittleEndian.Uint32(content[24:]))
+			assert.Equal(t, uint32(dataBytes), binary.LittleEndian.Uint32(content[40:]))
+
+			samples := make([]float64, 3)
+			Decode(tC.format, samples, content[wavHeaderSize:])
+			assert.InDeltaSlice(t, []float64{0, 0.5, -0.5}, samples, 1e-4)
+		})
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	buf := new(bytes.Buffer)
+	m := &toneModulator{frequency: 1000, switched: 1000}
+
+	err := WriteWAV(buf, m, 1500*time.Millisecond, 8000)
+	require.NoError(t, err)
+
+	content := buf.Bytes()
+	dataBytes := 12000 * Int16.Size()
+	require.Equal(t, wavHeaderSize+dataBytes, len(content))
+	assert.Equal(t, uint32(wavHeaderSize-8+dataBytes), binary.LittleEndian.Uint32(content[4:]))
+	assert.Equal(t, uint16(wavFormatPCM), binary.LittleEndian.Uint16(content[20:]))
+	assert.Equal(t, uint32(8000), binary.LittleEndian.Uint32(content[24:]))
+	assert.Equal(t, uint32(dataBytes), binary.LittleEndian.Uint32(content[40:]))
+
+	samples := make([]float64, 12000)
+	Decode(Int16, samples, content[wavHeaderSize:])
+	expected := make([]float64, 12000)
+	NewRenderer(&toneModulator{frequency: 1000, switched: 1000}, 8000).Render(expected)
+	assert.InDeltaSlice(t, expected, samples, 1e-4)
+}
+
+func TestWAVReader(t *testing.T) {
+	for _, format := range []SampleFormat{Int16, Float32, Float64} {
+		t.Run(format.String(), func(t *testing.T) {
+			f, err := os.Create(filepath.Join(t.TempDir(), "test.wav"))
+			require.NoError(t, err)
+			defer f.Close()
+			w, err := NewWAVWriter(f, 8000, format)
+			require.NoError(t, err)
+			_, err = w.WriteSamples([]float64{0, 0.5, -0.5})
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			content, err := os.ReadFile(f.Name())
+			require.NoError(t, err)
+			// an additional chunk before the data
+			content = append(content[:36:36], append([]byte{'L', 'I', 'S', 'T', 3, 0, 0, 0, 1, 2, 3, 0}, content[36:]...)...)
+
+			r, err := NewWAVReader(bytes.NewReader(append(content, 42)))
+			require.NoError(t, err)
+			assert.Equal(t, 8000, r.SampleRate())
+			assert.Equal(t, format, r.Format())
+			samples := make([]float64, 10)
+			n, err := r.ReadSamples(samples)
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, []float64{0, 0.5, -0.5}, samples[:n], 1e-4, "the data ends with the data chunk")
+			_, err = r.ReadSamples(samples)
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestWAVReaderUnsupported(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteWAV(buf, &toneModulator{}, time.Second, 8000))
+	stereo := append([]byte{}, buf.Bytes()...)
+	binary.LittleEndian.PutUint16(stereo[22:], 2)
+	_, err := NewWAVReader(bytes.NewReader(stereo))
+	assert.Equal(t, ErrWAVFormat, err)
+
+	_, err = NewWAVReader(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00AVI LIST")))
+	assert.Equal(t, ErrWAVFormat, err)
+}
+
+func TestWriteWAVSamples(t *testing.T) {
+	buf := new(bytes.Buffer)
+	samples := make([]float64, 5000)
+	NewRenderer(&toneModulator{frequency: 1000, switched: 1000}, 8000).Render(samples)
+
+	require.NoError(t, WriteWAVSamples(buf, samples, 8000))
+
+	r, err := NewWAVReader(buf)
+	require.NoError(t, err)
+	assert.Equal(t, 8000, r.SampleRate())
+	read := make([]float64, 0, len(samples))
+	block := make([]float64, 1024)
+	for {
+		n, err := r.ReadSamples(block)
+		read = append(read, block[:n]...)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.InDeltaSlice(t, samples, read, 1e-4)
+}
@@ -0,0 +1,203 @@
+/*
+Package bandplan provides the amateur radio band edges of the three IARU regions and the conventional
+segments and dial frequencies of the digital modes.
+
+The data is meant for sanity checks and sensible defaults, it does not replace the license conditions
+of your country.
+*/
+package bandplan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Frequency in Hz.
+type Frequency float64
+
+// kHz returns the frequency for the given value in kHz.
+func kHz(f float64) Frequency {
+	return Frequency(f * 1000)
+}
+
+func (f Frequency) String() string {
+	return fmt.Sprintf("%.3fkHz", float64(f)/1000)
+}
+
+// Region is one of the three IARU regions.
+type Region int
+
+// All IARU regions.
+const (
+	Region1 Region = 1
+	Region2 Region = 2
+	Region3 Region = 3
+)
+
+// BandName is the name of an amateur radio band, e.g. "20m".
+type BandName string
+
+// Band is an amateur radio band with its edges.
+type Band struct {
+	Name BandName
+	From Frequency
+	To   Frequency
+}
+
+// Contains indicates if the given frequency is within the band.
+func (b Band) Contains(f Frequency) bool {
+	return b.From <= f && f <= b.To
+}
+
+// Width returns the width of the band.
+func (b Band) Width() Frequency {
+	return b.To - b.From
+}
+
+// Segment is a part of a band that is conventionally used by a mode.
+type Segment struct {
+	Band BandName
+	Mode string
+	From Frequency
+	To   Frequency
+}
+
+// Contains indicates if the given frequency is within the segment.
+func (s Segment) Contains(f Frequency) bool {
+	return s.From <= f && f <= s.To
+}
+
+// Dial is the conventional (USB) dial frequency of a mode on a band.
+type Dial struct {
+	Band      BandName
+	Mode      string
+	Frequency Frequency
+}
+
+// Bands returns all bands of the given region, ordered by frequency.
+func Bands(region Region) []Band {
+	bands := bandsByRegion[region]
+	result := make([]Band, len(bands))
+	copy(result, bands)
+	return result
+}
+
+// BandByName returns the band with the given name in the given region.
+func BandByName(region Region, name BandName) (Band, bool) {
+	for _, band := range bandsByRegion[region] {
+		if band.Name == name {
+			return band, true
+		}
+	}
+	return Band{}, false
+}
+
+// BandOf returns the band that contains the given frequency in the given region.
+func BandOf(region Region, f Frequency) (Band, bool) {
+	for _, band := range bandsByRegion[region] {
+		if band.Contains(f) {
+			return band, true
+		}
+	}
+	return Band{}, false
+}
+
+// Modes returns the names of all modes with segments or dial frequencies.
+func Modes() []string {
+	modes := make(map[string]bool)
+	for _, segments := range segmentsByRegion {
+		for _, segment := range segments {
+			modes[segment.Mode] = true
+		}
+	}
+	for _, dial := range dials {
+		modes[dial.Mode] = true
+	}
+	result := make([]string, 0, len(modes))
+	for mode := range modes {
+		result = append(result, mode)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Segments returns all segments of the given mode in the given region, ordered by frequency.
+func Segments(region Region, mode string) []Segment {
+	mode = strings.ToLower(mode)
+	result := make([]Segment, 0)
+	for _, segment := range segmentsByRegion[region] {
+		if segment.Mode == mode {
+			result = append(result, segment)
+		}
+	}
+	return result
+}
+
+// SegmentOf returns the segment of the given mode in the given region that contains the given frequency.
+func SegmentOf(region Region, mode string, f Frequency) (Segment, bool) {
+	for _, segment := range Segments(region, mode) {
+		if segment.Contains(f) {
+			return segment, true
+		}
+	}
+	return Segment{}, false
+}
+
+// Dials returns all dial frequencies of the given mode in the given region, ordered by frequency.
+// Dial frequencies that are not within a band of the region are omitted.
+func Dials(region Region, mode string) []Dial {
+	mode = strings.ToLower(mode)
+	result := make([]Dial, 0)
+	for _, dial := range dials {
+		if dial.Mode != mode {
+			continue
+		}
+		if override, ok := regionalDials[region][dialKey{dial.Band, dial.Mode}]; ok {
+			dial.Frequency = override
+		}
+		band, ok := BandByName(region, dial.Band)
+		if !ok || !band.Contains(dial.Frequency) {
+			continue
+		}
+		result = append(result, dial)
+	}
+	return result
+}
+
+// DialFrequency returns the dial frequency of the given mode on the given band in the given region.
+func DialFrequency(region Region, mode string, band BandName) (Frequency, bool) {
+	for _, dial := range Dials(region, mode) {
+		if dial.Band == band {
+			return dial.Frequency, true
+		}
+	}
+	return 0, false
+}
+
+// CheckTransmitFrequency checks if a signal of the given mode on the given RF frequency with the given bandwidth
+// is within an amateur radio band of the given region. If the mode has conventional segments in this band, the signal
+// must also be within one of these segments.
+func CheckTransmitFrequency(region Region, mode string, f Frequency, bandwidth Frequency) error {
+	lower := f - bandwidth/2
+	upper := f + bandwidth/2
+	band, ok := BandOf(region, f)
+	if !ok || !band.Contains(lower) || !band.Contains(upper) {
+		return fmt.Errorf("bandplan: %v is outside of the amateur radio bands of region %d", f, region)
+	}
+
+	hasSegments := false
+	for _, segment := range Segments(region, mode) {
+		if segment.Band != band.Name {
+			continue
+		}
+		hasSegments = true
+		if segment.Contains(lower) && segment.Contains(upper) {
+			return nil
+		}
+	}
+	if hasSegments {
+		return fmt.Errorf("bandplan: %v is outside of the %s segments on %s", f, mode, band.Name)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package bandplan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandOf(t *testing.T) {
+	testCases := []struct {
+		region   Region
+		f        Frequency
+		expected BandName
+		valid    bool
+	}{
+		{Region1, kHz(14074), "20m", true},
+		{Region1, kHz(7250), "", false},
+		{Region2, kHz(7250), "40m", true},
+		{Region1, kHz(1805), "", false},
+		{Region2, kHz(1805), "160m", true},
+		{Region3, kHz(50100), "6m", true},
+		{Region1, kHz(11000), "", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.f.String(), func(t *testing.T) {
+			band, ok := BandOf(tC.region, tC.f)
+			assert.Equal(t, tC.valid, ok)
+			assert.Equal(t, tC.expected, band.Name)
+		})
+	}
+}
+
+func TestDialFrequency(t *testing.T) {
+	f, ok := DialFrequency(Region1, "FT8", "20m")
+	assert.True(t, ok)
+	assert.Equal(t, kHz(14074), f)
+
+	f, ok = DialFrequency(Region2, "psk31", "40m")
+	assert.True(t, ok)
+	assert.Equal(t, kHz(7070.15), f)
+
+	_, ok = DialFrequency(Region1, "ft4", "160m")
+	assert.False(t, ok)
+}
+
+func TestAllDialsAreWithinTheBands(t *testing.T) {
+	for _, region := range []Region{Region1, Region2, Region3} {
+		for _, mode := range Modes() {
+			for _, dial := range Dials(region, mode) {
+				band, ok := BandOf(region, dial.Frequency)
+				assert.True(t, ok, "%d %s %v", region, mode, dial.Frequency)
+				assert.Equal(t, dial.Band, band.Name)
+			}
+		}
+	}
+}
+
+func TestAllSegmentsAreWithinTheBands(t *testing.T) {
+	for region, segments := range segmentsByRegion {
+		for _, segment := range segments {
+			band, ok := BandByName(region, segment.Band)
+			assert.True(t, ok)
+			assert.True(t, band.Contains(segment.From) && band.Contains(segment.To), "%d %v", region, segment)
+		}
+	}
+}
+
+func TestCheckTransmitFrequency(t *testing.T) {
+	assert.NoError(t, CheckTransmitFrequency(Region1, "wspr", kHz(14095.6)+1500, 6))
+	assert.Error(t, CheckTransmitFrequency(Region1, "wspr", kHz(14095.6)+1700, 6))
+	assert.NoError(t, CheckTransmitFrequency(Region1, "cw", kHz(14030), 100))
+	assert.Error(t, CheckTransmitFrequency(Region1, "cw", kHz(14100), 100))
+	assert.NoError(t, CheckTransmitFrequency(Region1, "psk31", kHz(14070.15)+1000, 31.25))
+	assert.Error(t, CheckTransmitFrequency(Region1, "psk31", kHz(14000), 31.25), "signal crosses the band edge")
+	assert.Error(t, CheckTransmitFrequency(Region1, "psk31", kHz(7250), 31.25))
+}
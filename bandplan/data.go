@@ -0,0 +1,202 @@
+package bandplan
+
+var bandsByRegion = map[Region][]Band{
+	Region1: {
+		{"2200m", kHz(135.7), kHz(137.8)},
+		{"630m", kHz(472), kHz(479)},
+		{"160m", kHz(1810), kHz(2000)},
+		{"80m", kHz(3500), kHz(3800)},
+		{"60m", kHz(5351.5), kHz(5366.5)},
+		{"40m", kHz(7000), kHz(7200)},
+		{"30m", kHz(10100), kHz(10150)},
+		{"20m", kHz(14000), kHz(14350)},
+		{"17m", kHz(18068), kHz(18168)},
+		{"15m", kHz(21000), kHz(21450)},
+		{"12m", kHz(24890), kHz(24990)},
+		{"10m", kHz(28000), kHz(29700)},
+		{"6m", kHz(50000), kHz(52000)},
+		{"2m", kHz(144000), kHz(146000)},
+	},
+	Region2: {
+		{"2200m", kHz(135.7), kHz(137.8)},
+		{"630m", kHz(472), kHz(479)},
+		{"160m", kHz(1800), kHz(2000)},
+		{"80m", kHz(3500), kHz(4000)},
+		{"60m", kHz(5330.5), kHz(5406.5)},
+		{"40m", kHz(7000), kHz(7300)},
+		{"30m", kHz(10100), kHz(10150)},
+		{"20m", kHz(14000), kHz(14350)},
+		{"17m", kHz(18068), kHz(18168)},
+		{"15m", kHz(21000), kHz(21450)},
+		{"12m", kHz(24890), kHz(24990)},
+		{"10m", kHz(28000), kHz(29700)},
+		{"6m", kHz(50000), kHz(54000)},
+		{"2m", kHz(144000), kHz(148000)},
+	},
+	Region3: {
+		{"2200m", kHz(135.7), kHz(137.8)},
+		{"630m", kHz(472), kHz(479)},
+		{"160m", kHz(1800), kHz(2000)},
+		{"80m", kHz(3500), kHz(3900)},
+		{"60m", kHz(5351.5), kHz(5366.5)},
+		{"40m", kHz(7000), kHz(7300)},
+		{"30m", kHz(10100), kHz(10150)},
+		{"20m", kHz(14000), kHz(14350)},
+		{"17m", kHz(18068), kHz(18168)},
+		{"15m", kHz(21000), kHz(21450)},
+		{"12m", kHz(24890), kHz(24990)},
+		{"10m", kHz(28000), kHz(29700)},
+		{"6m", kHz(50000), kHz(54000)},
+		{"2m", kHz(144000), kHz(148000)},
+	},
+}
+
+var segmentsByRegion = map[Region][]Segment{
+	Region1: {
+		{"160m", "cw", kHz(1810), kHz(1838)},
+		{"80m", "cw", kHz(3500), kHz(3570)},
+		{"80m", "rtty", kHz(3570), kHz(3600)},
+		{"40m", "cw", kHz(7000), kHz(7040)},
+		{"40m", "rtty", kHz(7040), kHz(7050)},
+		{"30m", "cw", kHz(10100), kHz(10130)},
+		{"20m", "cw", kHz(14000), kHz(14070)},
+		{"20m", "rtty", kHz(14080), kHz(14099)},
+		{"17m", "cw", kHz(18068), kHz(18095)},
+		{"15m", "cw", kHz(21000), kHz(21070)},
+		{"15m", "rtty", kHz(21080), kHz(21120)},
+		{"12m", "cw", kHz(24890), kHz(24915)},
+		{"10m", "cw", kHz(28000), kHz(28070)},
+		{"10m", "rtty", kHz(28070), kHz(28150)},
+	},
+	Region2: {
+		{"160m", "cw", kHz(1800), kHz(1840)},
+		{"80m", "cw", kHz(3500), kHz(3570)},
+		{"80m", "rtty", kHz(3570), kHz(3600)},
+		{"40m", "cw", kHz(7000), kHz(7040)},
+		{"40m", "rtty", kHz(7025), kHz(7100)},
+		{"30m", "cw", kHz(10100), kHz(10130)},
+		{"20m", "cw", kHz(14000), kHz(14070)},
+		{"20m", "rtty", kHz(14080), kHz(14100)},
+		{"17m", "cw", kHz(18068), kHz(18095)},
+		{"15m", "cw", kHz(21000), kHz(21070)},
+		{"15m", "rtty", kHz(21080), kHz(21150)},
+		{"12m", "cw", kHz(24890), kHz(24915)},
+		{"10m", "cw", kHz(28000), kHz(28070)},
+		{"10m", "rtty", kHz(28080), kHz(28150)},
+	},
+	Region3: {
+		{"160m", "cw", kHz(1800), kHz(1840)},
+		{"80m", "cw", kHz(3500), kHz(3570)},
+		{"80m", "rtty", kHz(3570), kHz(3600)},
+		{"40m", "cw", kHz(7000), kHz(7040)},
+		{"40m", "rtty", kHz(7025), kHz(7060)},
+		{"30m", "cw", kHz(10100), kHz(10130)},
+		{"20m", "cw", kHz(14000), kHz(14070)},
+		{"20m", "rtty", kHz(14080), kHz(14099)},
+		{"17m", "cw", kHz(18068), kHz(18095)},
+		{"15m", "cw", kHz(21000), kHz(21070)},
+		{"15m", "rtty", kHz(21080), kHz(21120)},
+		{"12m", "cw", kHz(24890), kHz(24915)},
+		{"10m", "cw", kHz(28000), kHz(28070)},
+		{"10m", "rtty", kHz(28070), kHz(28150)},
+	},
+}
+
+var dials = []Dial{
+	// WSPR
+	{"2200m", "wspr", kHz(136.0)},
+	{"630m", "wspr", kHz(474.2)},
+	{"160m", "wspr", kHz(1836.6)},
+	{"80m", "wspr", kHz(3568.6)},
+	{"60m", "wspr", kHz(5364.7)},
+	{"40m", "wspr", kHz(7038.6)},
+	{"30m", "wspr", kHz(10138.7)},
+	{"20m", "wspr", kHz(14095.6)},
+	{"17m", "wspr", kHz(18104.6)},
+	{"15m", "wspr", kHz(21094.6)},
+	{"12m", "wspr", kHz(24924.6)},
+	{"10m", "wspr", kHz(28124.6)},
+	{"6m", "wspr", kHz(50293.0)},
+	{"2m", "wspr", kHz(144489.0)},
+
+	// FT8
+	{"160m", "ft8", kHz(1840)},
+	{"80m", "ft8", kHz(3573)},
+	{"60m", "ft8", kHz(5357)},
+	{"40m", "ft8", kHz(7074)},
+	{"30m", "ft8", kHz(10136)},
+	{"20m", "ft8", kHz(14074)},
+	{"17m", "ft8", kHz(18100)},
+	{"15m", "ft8", kHz(21074)},
+	{"12m", "ft8", kHz(24915)},
+	{"10m", "ft8", kHz(28074)},
+	{"6m", "ft8", kHz(50313)},
+	{"2m", "ft8", kHz(144174)},
+
+	// FT4
+	{"80m", "ft4", kHz(3575)},
+	{"40m", "ft4", kHz(7047.5)},
+	{"30m", "ft4", kHz(10140)},
+	{"20m", "ft4", kHz(14080)},
+	{"17m", "ft4", kHz(18104)},
+	{"15m", "ft4", kHz(21140)},
+	{"12m", "ft4", kHz(24919)},
+	{"10m", "ft4", kHz(28180)},
+	{"6m", "ft4", kHz(50318)},
+	{"2m", "ft4", kHz(144170)},
+
+	// JS8
+	{"160m", "js8", kHz(1842)},
+	{"80m", "js8", kHz(3578)},
+	{"40m", "js8", kHz(7078)},
+	{"30m", "js8", kHz(10130)},
+	{"20m", "js8", kHz(14078)},
+	{"17m", "js8", kHz(18104)},
+	{"15m", "js8", kHz(21078)},
+	{"12m", "js8", kHz(24922)},
+	{"10m", "js8", kHz(28078)},
+	{"6m", "js8", kHz(50318)},
+
+	// PSK31 calling frequencies
+	{"160m", "psk31", kHz(1838.15)},
+	{"80m", "psk31", kHz(3580.15)},
+	{"40m", "psk31", kHz(7040.15)},
+	{"30m", "psk31", kHz(10142.15)},
+	{"20m", "psk31", kHz(14070.15)},
+	{"17m", "psk31", kHz(18100.15)},
+	{"15m", "psk31", kHz(21080.15)},
+	{"12m", "psk31", kHz(24920.15)},
+	{"10m", "psk31", kHz(28120.15)},
+	{"6m", "psk31", kHz(50290.15)},
+}
+
+type dialKey struct {
+	band BandName
+	mode string
+}
+
+var regionalDials = map[Region]map[dialKey]Frequency{
+	Region2: {
+		{"60m", "wspr"}:  kHz(5346.5),
+		{"40m", "psk31"}: kHz(7070.15),
+	},
+}
+
+// WSPR transmissions are within 1400-1600Hz above the dial frequency.
+const (
+	wsprLowerOffset = 1400
+	wsprUpperOffset = 1600
+)
+
+func init() {
+	for region := range bandsByRegion {
+		for _, dial := range Dials(region, "wspr") {
+			segmentsByRegion[region] = append(segmentsByRegion[region], Segment{
+				Band: dial.Band,
+				Mode: "wspr",
+				From: dial.Frequency + wsprLowerOffset,
+				To:   dial.Frequency + wsprUpperOffset,
+			})
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package bandplan
+
+// Sideband of the receiver or transmitter.
+type Sideband int
+
+// All sidebands.
+const (
+	USB Sideband = iota
+	LSB
+)
+
+func (s Sideband) String() string {
+	switch s {
+	case USB:
+		return "USB"
+	case LSB:
+		return "LSB"
+	default:
+		return "unknown"
+	}
+}
+
+// ConventionalSideband returns the sideband that is conventionally used for voice on the given frequency: LSB below
+// 10MHz, except on 60m, USB otherwise. The digital modes always use USB.
+func ConventionalSideband(f Frequency) Sideband {
+	if f >= kHz(10000) || (f >= kHz(5000) && f < kHz(5500)) {
+		return USB
+	}
+	return LSB
+}
+
+// Tuning relates the dial frequency of the radio, the audio offset of a signal and its RF frequency. The methods
+// return a modified copy, so that the three values are always consistent.
+type Tuning struct {
+	// Dial is the dial frequency (suppressed carrier) of the radio.
+	Dial Frequency
+	// Offset is the audio frequency of the signal.
+	Offset Frequency
+	// Sideband of the radio.
+	Sideband Sideband
+}
+
+// NewTuning returns a new Tuning with the given dial frequency and audio offset in USB.
+func NewTuning(dial, offset Frequency) Tuning {
+	return Tuning{
+		Dial:   dial,
+		Offset: offset,
+	}
+}
+
+// RF returns the RF frequency of the signal.
+func (t Tuning) RF() Frequency {
+	return t.Dial + t.rfOffset(t.Offset)
+}
+
+// RFOf returns the RF frequency of a signal with the given audio offset.
+func (t Tuning) RFOf(offset Frequency) Frequency {
+	return t.Dial + t.rfOffset(offset)
+}
+
+// OffsetOf returns the audio offset of a signal with the given RF frequency. The offset is negative if the signal
+// is on the other sideband.
+func (t Tuning) OffsetOf(rf Frequency) Frequency {
+	return t.rfOffset(rf - t.Dial)
+}
+
+// InPassband indicates if a signal with the given RF frequency is within the given audio passband.
+func (t Tuning) InPassband(rf Frequency, low, high Frequency) bool {
+	offset := t.OffsetOf(rf)
+	return offset >= low && offset <= high
+}
+
+// WithOffset moves the signal to the given audio offset, the dial frequency is kept.
+func (t Tuning) WithOffset(offset Frequency) Tuning {
+	t.Offset = offset
+	return t
+}
+
+// WithRF moves the signal to the given RF frequency by changing the audio offset, the dial frequency is kept.
+func (t Tuning) WithRF(rf Frequency) Tuning {
+	t.Offset = t.OffsetOf(rf)
+	return t
+}
+
+// WithDial changes the dial frequency and keeps the audio offset, the RF frequency moves with the dial.
+func (t Tuning) WithDial(dial Frequency) Tuning {
+	t.Dial = dial
+	return t
+}
+
+// Retune changes the dial frequency and keeps the RF frequency of the signal by changing the audio offset.
+func (t Tuning) Retune(dial Frequency) Tuning {
+	rf := t.RF()
+	t.Dial = dial
+	return t.WithRF(rf)
+}
+
+// WithSideband switches to the given sideband and keeps the RF frequency of the signal by changing the audio
+// offset.
+func (t Tuning) WithSideband(sideband Sideband) Tuning {
+	rf := t.RF()
+	t.Sideband = sideband
+	return t.WithRF(rf)
+}
+
+// rfOffset converts between audio offset and RF offset, the conversion is symmetric.
+func (t Tuning) rfOffset(offset Frequency) Frequency {
+	if t.Sideband == LSB {
+		return -offset
+	}
+	return offset
+}
@@ -0,0 +1,67 @@
+package bandplan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTuning(t *testing.T) {
+	usb := NewTuning(kHz(14074), 1500)
+	assert.Equal(t, kHz(14075.5), usb.RF())
+	assert.Equal(t, kHz(14074.7), usb.RFOf(700))
+	assert.Equal(t, Frequency(2000), usb.OffsetOf(kHz(14076)))
+	assert.Equal(t, Frequency(-1000), usb.OffsetOf(kHz(14073)))
+	assert.True(t, usb.InPassband(kHz(14076), 200, 3000))
+	assert.False(t, usb.InPassband(kHz(14073), 200, 3000))
+
+	lsb := Tuning{Dial: kHz(3580), Offset: 1000, Sideband: LSB}
+	assert.Equal(t, kHz(3579), lsb.RF())
+	assert.Equal(t, Frequency(500), lsb.OffsetOf(kHz(3579.5)))
+	assert.True(t, lsb.InPassband(kHz(3579.5), 200, 3000))
+}
+
+func TestTuningChanges(t *testing.T) {
+	tuning := NewTuning(kHz(14074), 1500)
+
+	moved := tuning.WithRF(kHz(14075))
+	assert.Equal(t, kHz(14074), moved.Dial)
+	assert.Equal(t, Frequency(1000), moved.Offset)
+
+	followed := tuning.WithDial(kHz(14080))
+	assert.Equal(t, Frequency(1500), followed.Offset)
+	assert.Equal(t, kHz(14081.5), followed.RF())
+
+	retuned := tuning.Retune(kHz(14075))
+	assert.Equal(t, Frequency(500), retuned.Offset)
+	assert.Equal(t, tuning.RF(), retuned.RF())
+
+	switched := tuning.WithSideband(LSB)
+	assert.Equal(t, LSB, switched.Sideband)
+	assert.Equal(t, Frequency(-1500), switched.Offset, "the signal is on the other side of the dial")
+	assert.Equal(t, tuning.RF(), switched.RF())
+
+	assert.Equal(t, Frequency(1500), tuning.Offset, "the original is unchanged")
+	assert.Equal(t, Frequency(700), tuning.WithOffset(700).Offset)
+}
+
+func TestConventionalSideband(t *testing.T) {
+	testCases := []struct {
+		f        Frequency
+		expected Sideband
+	}{
+		{kHz(1840), LSB},
+		{kHz(3573), LSB},
+		{kHz(5357), USB},
+		{kHz(7074), LSB},
+		{kHz(10136), USB},
+		{kHz(14074), USB},
+		{kHz(50313), USB},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.f.String(), func(t *testing.T) {
+			assert.Equal(t, tC.expected, ConventionalSideband(tC.f))
+			assert.Equal(t, tC.expected.String(), ConventionalSideband(tC.f).String())
+		})
+	}
+}
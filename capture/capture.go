@@ -0,0 +1,246 @@
+/*
+Package capture records the output of a modulator, the amplitude, frequency and phase of each sample, and replays
+it later. A capture can be used as reference in regression tests or to render a transmission ahead of time and
+send it later.
+
+The file format is compact and little endian: the magic "DMCP", the format version (1 byte) and the sample rate
+(uint32), followed by runs of identical samples. Each run is the number of samples (uvarint) and the amplitude,
+frequency and phase of the samples (3 x float32).
+*/
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+const (
+	magic   = "DMCP"
+	version = 1
+)
+
+// ErrInvalidFormat is returned when reading data that is not a capture.
+var ErrInvalidFormat = errors.New("capture: invalid format")
+
+// Sample is the output of a modulator for one sample.
+type Sample struct {
+	Amplitude float64
+	Frequency float64
+	Phase     float64
+}
+
+type run struct {
+	start  int64
+	count  int64
+	sample Sample
+}
+
+// Recorder records the output of a modulator. It implements audio.Modulator and passes the output of the recorded
+// modulator through, so it can be rendered as usual. Each call of Modulate is recorded as one sample.
+type Recorder struct {
+	modulator audio.Modulator
+	w         *bufio.Writer
+	err       error
+
+	current Sample
+	count   int64
+	samples int64
+	buffer  [binary.MaxVarintLen64 + 12]byte
+}
+
+// NewRecorder returns a new Recorder that records the output of the given modulator, rendered at the given sample
+// rate, to the given writer. Close must be called to write the last samples.
+func NewRecorder(w io.Writer, modulator audio.Modulator, sampleRate int) (*Recorder, error) {
+	result := &Recorder{
+		modulator: modulator,
+		w:         bufio.NewWriter(w),
+	}
+	header := make([]byte, len(magic)+5)
+	copy(header, magic)
+	header[len(magic)] = version
+	binary.LittleEndian.PutUint32(header[len(magic)+1:], uint32(sampleRate))
+	if _, err := result.w.Write(header); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Modulate calls the recorded modulator and records its output.
+func (r *Recorder) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	amplitude, frequency, phase = r.modulator.Modulate(t, a, f, p)
+	sample := quantize(Sample{amplitude, frequency, phase})
+	if r.count > 0 && sample != r.current {
+		r.writeRun()
+	}
+	r.current = sample
+	r.count++
+	r.samples++
+	return amplitude, frequency, phase
+}
+
+// quantize reduces the given sample to the precision of the file format.
+func quantize(s Sample) Sample {
+	return Sample{
+		Amplitude: float64(float32(s.Amplitude)),
+		Frequency: float64(float32(s.Frequency)),
+		Phase:     float64(float32(s.Phase)),
+	}
+}
+
+func (r *Recorder) writeRun() {
+	if r.err != nil || r.count == 0 {
+		return
+	}
+	n := binary.PutUvarint(r.buffer[:], uint64(r.count))
+	binary.LittleEndian.PutUint32(r.buffer[n:], math.Float32bits(float32(r.current.Amplitude)))
+	binary.LittleEndian.PutUint32(r.buffer[n+4:], math.Float32bits(float32(r.current.Frequency)))
+	binary.LittleEndian.PutUint32(r.buffer[n+8:], math.Float32bits(float32(r.current.Phase)))
+	_, r.err = r.w.Write(r.buffer[:n+12])
+	r.count = 0
+}
+
+// Samples returns the number of recorded samples.
+func (r *Recorder) Samples() int64 {
+	return r.samples
+}
+
+// Err returns the first error that occurred while writing the capture.
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// Close writes the pending samples and flushes the writer. It does not close the underlying writer.
+func (r *Recorder) Close() error {
+	r.writeRun()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// Capture is a recorded modulator output.
+type Capture struct {
+	sampleRate int
+	runs       []run
+	samples    int64
+}
+
+// Read reads a capture from the given reader.
+func Read(r io.Reader) (*Capture, error) {
+	in := bufio.NewReader(r)
+	header := make([]byte, len(magic)+5)
+	if _, err := io.ReadFull(in, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: wrong magic", ErrInvalidFormat)
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, header[len(magic)])
+	}
+	result := &Capture{
+		sampleRate: int(binary.LittleEndian.Uint32(header[len(magic)+1:])),
+	}
+	if result.sampleRate <= 0 {
+		return nil, fmt.Errorf("%w: invalid sample rate", ErrInvalidFormat)
+	}
+
+	values := make([]byte, 12)
+	for {
+		count, err := binary.ReadUvarint(in)
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+		if _, err := io.ReadFull(in, values); err != nil {
+			return nil, fmt.Errorf("%w: truncated run: %v", ErrInvalidFormat, err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: empty run", ErrInvalidFormat)
+		}
+		result.runs = append(result.runs, run{
+			start: result.samples,
+			count: int64(count),
+			sample: Sample{
+				Amplitude: float64(math.Float32frombits(binary.LittleEndian.Uint32(values))),
+				Frequency: float64(math.Float32frombits(binary.LittleEndian.Uint32(values[4:]))),
+				Phase:     float64(math.Float32frombits(binary.LittleEndian.Uint32(values[8:]))),
+			},
+		})
+		result.samples += int64(count)
+	}
+}
+
+// SampleRate returns the sample rate of the capture in Hz.
+func (c *Capture) SampleRate() int {
+	return c.sampleRate
+}
+
+// Samples returns the number of samples in the capture.
+func (c *Capture) Samples() int64 {
+	return c.samples
+}
+
+// Duration returns the duration of the capture in seconds.
+func (c *Capture) Duration() float64 {
+	return float64(c.samples) / float64(c.sampleRate)
+}
+
+// At returns the sample with the given index. Outside of the capture, the amplitude is 0.
+func (c *Capture) At(index int64) Sample {
+	if index < 0 || index >= c.samples {
+		return Sample{}
+	}
+	return c.runs[c.search(index)].sample
+}
+
+// search returns the index of the run that contains the sample with the given index.
+func (c *Capture) search(index int64) int {
+	return sort.Search(len(c.runs), func(i int) bool {
+		return c.runs[i].start+c.runs[i].count > index
+	})
+}
+
+// Player returns a new Player that replays this capture.
+func (c *Capture) Player() *Player {
+	return &Player{capture: c}
+}
+
+// Player replays a capture. It implements audio.Modulator, the samples are picked by the time t and the sample
+// rate of the capture. After the end of the capture, the amplitude is 0.
+type Player struct {
+	capture *Capture
+	run     int
+}
+
+// Modulate returns the recorded output of the modulator at the given time.
+func (p *Player) Modulate(t, a, f, phase float64) (amplitude, frequency, outPhase float64) {
+	index := int64(math.Round(t * float64(p.capture.sampleRate)))
+	runs := p.capture.runs
+	if p.run >= len(runs) || index < runs[p.run].start {
+		p.run = p.capture.search(index)
+	}
+	for p.run < len(runs) && index >= runs[p.run].start+runs[p.run].count {
+		p.run++
+	}
+	if index < 0 || p.run >= len(runs) {
+		return 0, f, phase
+	}
+	sample := runs[p.run].sample
+	return sample.Amplitude, sample.Frequency, sample.Phase
+}
+
+// Done indicates if the player reached the end of the capture at the given time.
+func (p *Player) Done(t float64) bool {
+	return t*float64(p.capture.sampleRate) >= float64(p.capture.samples)
+}
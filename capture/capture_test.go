@@ -0,0 +1,111 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+)
+
+type stepModulator struct{}
+
+func (stepModulator) Modulate(t, a, f, p float64) (float64, float64, float64) {
+	if t < 0.5 {
+		return 0, 700, 0
+	}
+	return 1, 700, 0.25
+}
+
+func TestRecordAndRead(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	recorder, err := NewRecorder(buffer, stepModulator{}, 100)
+	require.NoError(t, err)
+	audio.NewRenderer(recorder, 100).Render(make([]float64, 100))
+	require.NoError(t, recorder.Close())
+	assert.Equal(t, int64(100), recorder.Samples())
+	assert.Equal(t, 9+2*13, buffer.Len(), "two runs")
+
+	capture, err := Read(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, 100, capture.SampleRate())
+	assert.Equal(t, int64(100), capture.Samples())
+	assert.Equal(t, 1.0, capture.Duration())
+	assert.Equal(t, Sample{0, 700, 0}, capture.At(49))
+	assert.Equal(t, Sample{1, 700, 0.25}, capture.At(50))
+	assert.Equal(t, Sample{}, capture.At(100))
+
+	player := capture.Player()
+	a, f, p := player.Modulate(0.75, 0, 0, 0)
+	assert.Equal(t, []float64{1, 700, 0.25}, []float64{a, f, p})
+	a, _, _ = player.Modulate(0.25, 0, 0, 0)
+	assert.Equal(t, 0.0, a)
+	assert.False(t, player.Done(0.99))
+	assert.True(t, player.Done(1))
+	a, _, _ = player.Modulate(1.5, 0, 0, 0)
+	assert.Equal(t, 0.0, a)
+}
+
+func TestReplayCW(t *testing.T) {
+	const sampleRate = 8000
+	m := cw.NewModulator(700, 30)
+	defer m.Close()
+	buffer := &bytes.Buffer{}
+	recorder, err := NewRecorder(buffer, m, sampleRate)
+	require.NoError(t, err)
+
+	written := make(chan struct{})
+	go func() {
+		m.Write([]byte("test"))
+		close(written)
+	}()
+	renderer := audio.NewRenderer(recorder, sampleRate)
+	var original []float64
+	block := make([]float64, 80)
+	for done := false; !done; {
+		renderer.Render(block)
+		original = append(original, block...)
+		runtime.Gosched()
+		select {
+		case <-written:
+			done = true
+		default:
+		}
+	}
+	require.NoError(t, recorder.Close())
+	assert.Less(t, buffer.Len(), 13*len(original)/4, "compact")
+
+	capture, err := Read(buffer)
+	require.NoError(t, err)
+	replayed := make([]float64, len(original))
+	audio.NewRenderer(capture.Player(), sampleRate).Render(replayed)
+
+	for i := range original {
+		require.InDelta(t, original[i], replayed[i], 1e-4, "sample %d", i)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	testCases := []struct {
+		desc string
+		data []byte
+	}{
+		{"empty", nil},
+		{"wrong magic", []byte("WAVE\x01\x40\x1f\x00\x00")},
+		{"wrong version", []byte("DMCP\x02\x40\x1f\x00\x00")},
+		{"no sample rate", []byte("DMCP\x01\x00\x00\x00\x00")},
+		{"truncated run", []byte("DMCP\x01\x40\x1f\x00\x00\x05\x00\x00")},
+		{"empty run", append([]byte("DMCP\x01\x40\x1f\x00\x00\x00"), make([]byte, 12)...)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tC.data))
+			assert.True(t, errors.Is(err, ErrInvalidFormat), "%v", err)
+		})
+	}
+}
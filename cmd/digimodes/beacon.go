@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/wspr"
+)
+
+type beaconConfig struct {
+	Mode       string  `json:"mode"`
+	Output     string  `json:"output"`
+	SampleRate int     `json:"sample_rate"`
+	Frequency  float64 `json:"frequency"`
+	Level      float64 `json:"level"`
+
+	// CW
+	Text     string `json:"text"`
+	WPM      int    `json:"wpm"`
+	Interval string `json:"interval"`
+
+	// WSPR
+	Callsign string `json:"callsign"`
+	Locator  string `json:"locator"`
+	Power    int    `json:"power"`
+}
+
+func runBeacon(args []string) error {
+	config := beaconConfig{
+		Mode:       "cw",
+		Output:     "-",
+		SampleRate: 48000,
+		Level:      0.5,
+		WPM:        20,
+		Interval:   "1m",
+		Power:      10,
+	}
+	flags := flag.NewFlagSet("beacon", flag.ExitOnError)
+	configFile := flags.String("config", "", "read the beacon configuration from the given JSON file, flags override the configuration")
+	flags.StringVar(&config.Mode, "mode", config.Mode, "the mode: cw or wspr")
+	flags.StringVar(&config.Output, "o", config.Output, "write the audio as signed 16 bit little endian mono PCM to the given file, - for stdout")
+	flags.IntVar(&config.SampleRate, "rate", config.SampleRate, "the sample rate in Hz")
+	flags.Float64Var(&config.Frequency, "frequency", config.Frequency, "the audio frequency in Hz, 0 for the default of the mode")
+	flags.Float64Var(&config.Level, "level", config.Level, "the output level (0.0-1.0)")
+	flags.IntVar(&config.WPM, "wpm", config.WPM, "the CW speed in WpM")
+	flags.StringVar(&config.Interval, "interval", config.Interval, "the interval between two CW transmissions")
+	flags.StringVar(&config.Callsign, "call", config.Callsign, "the WSPR callsign")
+	flags.StringVar(&config.Locator, "locator", config.Locator, "the WSPR locator")
+	flags.IntVar(&config.Power, "power", config.Power, "the WSPR power in dBm")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: digimodes beacon [flags] [<text>]")
+		fmt.Fprintln(flags.Output(), "example: digimodes beacon -mode cw -wpm 18 vvv de dl0abc | aplay -f S16_LE -r 48000")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *configFile != "" {
+		err := loadBeaconConfig(*configFile, &config)
+		if err != nil {
+			return err
+		}
+		// the flags override the configuration file
+		flags.Parse(args)
+	}
+	if text := strings.Join(flags.Args(), " "); text != "" {
+		config.Text = text
+	}
+	if config.Frequency == 0 {
+		config.Frequency = defaultFrequencies[config.Mode]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+		cancel()
+	}()
+
+	switch config.Mode {
+	case "cw":
+		return runCWBeacon(ctx, config)
+	case "wspr":
+		return runWSPRBeacon(ctx, config)
+	default:
+		return fmt.Errorf("unknown mode %q", config.Mode)
+	}
+}
+
+func loadBeaconConfig(filename string, config *beaconConfig) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(config)
+	if err != nil {
+		return fmt.Errorf("cannot read configuration %s: %v", filename, err)
+	}
+	return nil
+}
+
+func openOutput(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return os.Stdout, nil
+	}
+	return os.Create(name)
+}
+
+func runCWBeacon(ctx context.Context, config beaconConfig) error {
+	if config.Text == "" {
+		return errors.New("no text given")
+	}
+	interval, err := time.ParseDuration(config.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid interval: %v", err)
+	}
+	out, err := openOutput(config.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	m := cw.NewModulator(config.Frequency, config.WPM)
+	m.AbortWhenDone(ctx.Done())
+
+	go func() {
+		for {
+			log.Printf("transmitting %q", config.Text)
+			_, err := m.Write([]byte(config.Text))
+			if err != nil {
+				return
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return writePCM(out, newRenderer(m, config.SampleRate, config.Level), ctx.Done())
+}
+
+func runWSPRBeacon(ctx context.Context, config beaconConfig) error {
+	transmission, err := wspr.ToTransmission(config.Callsign, config.Locator, config.Power)
+	if err != nil {
+		return err
+	}
+	out, err := openOutput(config.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	m := wspr.NewModulator(config.Frequency)
+	m.AbortWhenDone(ctx.Done())
+	scheduler := wspr.DefaultScheduler()
+	go func() {
+		for {
+			start, ok := scheduler.WaitForStart(ctx)
+			if !ok {
+				return
+			}
+			log.Printf("transmitting %s %s %d at %s", config.Callsign, config.Locator, config.Power, start.Format("15:04:05"))
+			err := m.Transmit(transmission)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return writePCM(out, newRenderer(m, config.SampleRate, config.Level), ctx.Done())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/psk31"
+	"github.com/ftl/digimodes/wspr"
+)
+
+const (
+	// decodeBlockSize is the number of samples that are fed into the decoder at once.
+	decodeBlockSize = 4096
+	// flushDuration is the silence in seconds after the end of the file to let the decoder finish the last record.
+	flushDuration = 4
+)
+
+var pskRates = map[string]float64{
+	"psk31":  psk31.PSK31,
+	"psk63":  psk31.PSK63,
+	"psk125": psk31.PSK125,
+	"psk250": psk31.PSK250,
+}
+
+// decodeConfig contains the parameters to decode a WAV file.
+type decodeConfig struct {
+	Mode      string
+	Frequency float64
+	WPM       int
+	Start     time.Time
+}
+
+func runDecode(args []string) error {
+	config := decodeConfig{}
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	flags.StringVar(&config.Mode, "mode", "cw", "the mode: cw, psk31, psk63, psk125, psk250 or wspr")
+	flags.Float64Var(&config.Frequency, "frequency", 0, "the audio frequency in Hz, 0 for the default of the mode")
+	flags.IntVar(&config.WPM, "wpm", 20, "the initial CW speed in WpM")
+	start := flags.String("start", "", "the time of the first sample in RFC 3339 format, WSPR cycles are aligned to this time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: digimodes decode [flags] <WAV file>")
+		fmt.Fprintln(flags.Output(), "use - to read the WAV file from stdin")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("no WAV file given")
+	}
+	if *start != "" {
+		var err error
+		config.Start, err = time.Parse(time.RFC3339, *start)
+		if err != nil {
+			return fmt.Errorf("invalid start time: %v", err)
+		}
+	}
+
+	var in io.Reader
+	if flags.Arg(0) == "-" {
+		in = os.Stdin
+	} else {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	wav, err := audio.NewWAVReader(in)
+	if err != nil {
+		return err
+	}
+
+	records, err := decode(context.Background(), config, wav)
+	for _, record := range records {
+		fmt.Println(formatRecord(record))
+	}
+	return err
+}
+
+// decode feeds the samples of the given source into a decoder for the given configuration and returns the decoded
+// records.
+func decode(ctx context.Context, config decodeConfig, source audio.Source) ([]digimodes.DecodeRecord, error) {
+	decoder, err := newDecoder(config, source.SampleRate())
+	if err != nil {
+		return nil, err
+	}
+	var result []digimodes.DecodeRecord
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for record := range decoder.Records() {
+			result = append(result, record)
+		}
+	}()
+
+	err = feed(ctx, decoder, source)
+	decoder.Close()
+	<-collected
+	return result, err
+}
+
+func feed(ctx context.Context, decoder digimodes.Decoder, source audio.Source) error {
+	samples := make([]float64, decodeBlockSize)
+	for {
+		n, err := source.ReadSamples(samples)
+		if n > 0 {
+			if err := decoder.Feed(ctx, samples[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// the decoders finish a record after some silence
+	return decoder.Feed(ctx, make([]float64, flushDuration*source.SampleRate()))
+}
+
+// newDecoder returns the decoder for the given configuration.
+func newDecoder(config decodeConfig, sampleRate int) (digimodes.Decoder, error) {
+	if config.Frequency == 0 {
+		config.Frequency = defaultFrequencies[config.Mode]
+	}
+	if baud, ok := pskRates[config.Mode]; ok {
+		return psk31.NewRecordDecoder(config.Frequency, baud, sampleRate, config.Start), nil
+	}
+	switch config.Mode {
+	case "cw":
+		return cw.NewRecordDecoder(config.Frequency, sampleRate, config.WPM, config.Start), nil
+	case "wspr":
+		return wspr.NewRecordDecoder(config.Frequency, sampleRate, config.Start), nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q", config.Mode)
+	}
+}
+
+func formatRecord(record digimodes.DecodeRecord) string {
+	return fmt.Sprintf("%s %4.0f dB %6.1f Hz %-6s %s", record.Time.UTC().Format("15:04:05.0"), record.SNR, record.AudioFrequency, record.Mode, record.Text)
+}
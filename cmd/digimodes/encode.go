@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/afsk"
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/remote"
+	"github.com/ftl/digimodes/sstv"
+	"github.com/ftl/digimodes/wspr"
+)
+
+const (
+	// leadIn is the silence in seconds before the transmission.
+	leadIn = 0.5
+	// leadOut is the silence in seconds after the transmission.
+	leadOut = 0.5
+	// encodeTimeout is the maximum duration of an encoded text transmission.
+	encodeTimeout = 10 * time.Minute
+)
+
+var defaultFrequencies = map[string]float64{
+	"cw":        700,
+	"psk31":     1000,
+	"psk63":     1000,
+	"psk125":    1000,
+	"psk250":    1000,
+	"rtty":      1500,
+	"olivia":    1500,
+	"contestia": 1500,
+	"rttym":     1500,
+	"wspr":      1500,
+}
+
+var sstvModes = map[string]sstv.Mode{
+	"m1": sstv.MartinM1,
+	"m2": sstv.MartinM2,
+	"s1": sstv.ScottieS1,
+	"s2": sstv.ScottieS2,
+}
+
+// encodeConfig contains the parameters to encode a transmission.
+type encodeConfig struct {
+	Mode       string
+	SampleRate int
+	Frequency  float64
+	Level      float64
+
+	// CW
+	WPM int
+	// Olivia, Contestia, RTTYM
+	Tones     int
+	Bandwidth float64
+	// AFSK
+	Source      string
+	Destination string
+	Path        string
+	// SSTV
+	SSTVMode string
+}
+
+func runEncode(args []string) error {
+	config := encodeConfig{}
+	flags := flag.NewFlagSet("encode", flag.ExitOnError)
+	flags.StringVar(&config.Mode, "mode", "cw", "the mode: cw, psk31, psk63, psk125, psk250, rtty, olivia, contestia, rttym, wspr, afsk or sstv")
+	output := flags.String("o", "out.wav", "the output WAV file, - for stdout")
+	flags.IntVar(&config.SampleRate, "rate", 12000, "the sample rate in Hz")
+	flags.Float64Var(&config.Frequency, "frequency", 0, "the audio frequency in Hz, 0 for the default of the mode")
+	flags.Float64Var(&config.Level, "level", 0.5, "the output level (0.0-1.0)")
+	flags.IntVar(&config.WPM, "wpm", 20, "the CW speed in WpM")
+	flags.IntVar(&config.Tones, "tones", 32, "the number of tones of Olivia, Contestia and RTTYM")
+	flags.Float64Var(&config.Bandwidth, "bandwidth", 1000, "the bandwidth of Olivia, Contestia and RTTYM in Hz")
+	flags.StringVar(&config.Source, "source", "", "the AFSK source callsign")
+	flags.StringVar(&config.Destination, "destination", "APRS", "the AFSK destination callsign")
+	flags.StringVar(&config.Path, "path", "", "the comma separated AFSK digipeater path")
+	flags.StringVar(&config.SSTVMode, "sstv", "m1", "the SSTV mode: m1, m2, s1 or s2")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: digimodes encode [flags] <text>")
+		fmt.Fprintln(flags.Output(), "for WSPR the text is \"<callsign> <locator> <power in dBm>\"")
+		fmt.Fprintln(flags.Output(), "for AFSK the text is the payload of the packet")
+		fmt.Fprintln(flags.Output(), "for SSTV the text is the name of a PNG, JPEG or GIF file")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	text := strings.Join(flags.Args(), " ")
+	if text == "" {
+		flags.Usage()
+		return errors.New("no text given")
+	}
+
+	samples, err := encode(config, text)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer
+	if *output == "-" {
+		out = os.Stdout
+	} else {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	return audio.WriteWAVSamples(out, samples, config.SampleRate)
+}
+
+// encode renders the transmission of the given text with the given configuration, surrounded by some silence.
+func encode(config encodeConfig, text string) ([]float64, error) {
+	if config.Frequency == 0 {
+		config.Frequency = defaultFrequencies[config.Mode]
+	}
+	samples := make([]float64, int(leadIn*float64(config.SampleRate)))
+
+	switch config.Mode {
+	case "afsk":
+		frame, err := afsk.NewFrame(config.Source, config.Destination, splitPath(config.Path), []byte(text))
+		if err != nil {
+			return nil, err
+		}
+		m := afsk.NewModulator(frame, afsk.DefaultTXDelay)
+		return append(samples, render(newRenderer(m, config.SampleRate, config.Level), m.Duration())...), nil
+	case "sstv":
+		mode, ok := sstvModes[config.SSTVMode]
+		if !ok {
+			return nil, fmt.Errorf("unknown SSTV mode %q", config.SSTVMode)
+		}
+		img, err := loadImage(text)
+		if err != nil {
+			return nil, err
+		}
+		m := sstv.NewModulator(mode, img)
+		return append(samples, render(newRenderer(m, config.SampleRate, config.Level), m.Duration())...), nil
+	}
+
+	m, err := newTextModulator(config)
+	if err != nil {
+		return nil, err
+	}
+	r := newRenderer(m, config.SampleRate, config.Level)
+	err = r.RenderText(text, time.Duration(leadOut*float64(time.Second)), encodeTimeout, func(block []float64) error {
+		samples = append(samples, block...)
+		return nil
+	})
+	return samples, err
+}
+
+// newTextModulator returns the modulator of the given text mode.
+func newTextModulator(config encodeConfig) (digimodes.Modulator, error) {
+	if config.Mode == "wspr" {
+		return wspr.NewModulator(config.Frequency), nil
+	}
+	return remote.DefaultRegistry().NewModulator(config.Mode, remote.Options{
+		"frequency": config.Frequency,
+		"wpm":       float64(config.WPM),
+		"tones":     float64(config.Tones),
+		"bandwidth": config.Bandwidth,
+	})
+}
+
+// render renders a transmission of the given duration in seconds, followed by the lead out.
+func render(r *audio.Renderer, duration float64) []float64 {
+	samples := make([]float64, int((duration+leadOut)*float64(r.SampleRate())))
+	r.Render(samples)
+	return samples
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ",")
+}
+
+func loadImage(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image %s: %v", filename, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/sstv"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tt := []struct {
+		mode     string
+		text     string
+		expected string
+	}{
+		{"cw", "cq de dl1abc", "cq de dl1abc"},
+		{"psk31", "CQ de DL1ABC\n", "CQ de DL1ABC"},
+		{"psk125", "CQ de DL1ABC\n", "CQ de DL1ABC"},
+		{"wspr", "DL1ABC JO62 23", "DL1ABC JO62 23"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.mode, func(t *testing.T) {
+			config := encodeConfig{Mode: tc.mode, SampleRate: 8000, Level: 0.5, WPM: 20}
+			samples, err := encode(config, tc.text)
+			require.NoError(t, err)
+
+			buf := new(bytes.Buffer)
+			require.NoError(t, audio.WriteWAVSamples(buf, samples, config.SampleRate))
+			wav, err := audio.NewWAVReader(buf)
+			require.NoError(t, err)
+
+			records, err := decode(context.Background(), decodeConfig{Mode: tc.mode, WPM: 20}, wav)
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			assert.Equal(t, tc.expected, records[0].Text)
+			assert.Equal(t, tc.mode, records[0].Mode)
+		})
+	}
+}
+
+func TestEncodeUnknownMode(t *testing.T) {
+	_, err := encode(encodeConfig{Mode: "foo", SampleRate: 8000}, "test")
+	assert.Error(t, err)
+
+	_, err = newDecoder(decodeConfig{Mode: "foo"}, 8000)
+	assert.Error(t, err)
+}
+
+func TestEncodeAFSK(t *testing.T) {
+	config := encodeConfig{Mode: "afsk", SampleRate: 48000, Level: 0.5, Source: "DL1ABC", Destination: "APRS"}
+	samples, err := encode(config, ">test")
+	require.NoError(t, err)
+	assert.Greater(t, len(samples), int((leadIn+leadOut)*48000))
+
+	_, err = encode(encodeConfig{Mode: "afsk", SampleRate: 48000, Destination: "APRS"}, ">test")
+	assert.Error(t, err, "no source")
+}
+
+func TestEncodeSSTV(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.png")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, 32, 32))))
+	require.NoError(t, f.Close())
+
+	samples, err := encode(encodeConfig{Mode: "sstv", SampleRate: 8000, Level: 0.5, SSTVMode: "s2"}, filename)
+	require.NoError(t, err)
+
+	duration := sstv.NewModulator(sstv.ScottieS2, image.NewRGBA(image.Rect(0, 0, 1, 1))).Duration()
+	assert.InDelta(t, (leadIn+duration+leadOut)*8000, len(samples), 2)
+
+	_, err = encode(encodeConfig{Mode: "sstv", SampleRate: 8000, SSTVMode: "x1"}, filename)
+	assert.Error(t, err)
+}
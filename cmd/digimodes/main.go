@@ -0,0 +1,63 @@
+/*
+The digimodes command exposes the digimodes library on the command line. It encodes text into WAV files, decodes
+WAV files and runs simple CW and WSPR beacons.
+
+Usage:
+
+	digimodes encode [flags] <text>
+	digimodes decode [flags] <WAV file>
+	digimodes beacon [flags] [<text>]
+
+Use "digimodes <command> -h" to get the available flags of a command.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"encode", "encode text into a WAV file", runEncode},
+	{"decode", "decode the text of a WAV file", runDecode},
+	{"beacon", "run a CW or WSPR beacon", runBeacon},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "digimodes %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "digimodes: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: digimodes <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.short)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// newRenderer returns a renderer for the given modulator with the given sample rate and output level.
+func newRenderer(m audio.Modulator, sampleRate int, level float64) *audio.Renderer {
+	result := audio.NewRenderer(m, sampleRate)
+	result.SetLevel(level)
+	return result
+}
+
+// writePCM renders blocks of samples and writes them in real time as signed 16 bit little endian PCM to the given
+// writer until done is closed.
+func writePCM(w io.Writer, r *audio.Renderer, done <-chan struct{}) error {
+	blockSize := r.SampleRate() / 50
+	blockDuration := time.Duration(blockSize) * time.Second / time.Duration(r.SampleRate())
+	next := time.Now()
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		err := r.WritePCM(w, audio.Int16, blockSize)
+		if err != nil {
+			return err
+		}
+
+		next = next.Add(blockDuration)
+		time.Sleep(time.Until(next))
+	}
+}
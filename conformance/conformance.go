@@ -0,0 +1,301 @@
+/*
+Package conformance provides a harness to validate the interoperability of the modes with other software, e.g. fldigi
+or WSJT-X.
+
+A Vector describes a transmission: the mode, its options and the transmitted text, together with the reference decode
+of the rendered signal. The reference decode is obtained by rendering the vector to a WAV file with Harness.WriteWAV
+and decoding this file with the reference software. The harness renders the vector again, decodes the audio with the
+decoder of the mode and compares the result with the reference decode. Vectors are usually kept as JSON file in the
+testdata directory of a package and checked in a test with conformancetest.Verify. The vectors of the modes of this
+library are in testdata/vectors.json.
+*/
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/psk31"
+)
+
+// DefaultSampleRate is the sample rate that is used when a vector does not specify a sample rate.
+const DefaultSampleRate = 8000
+
+// DefaultTail is the duration of silence that is rendered after the transmission to flush the decoder.
+const DefaultTail = 2 * time.Second
+
+// DefaultTimeout is the maximum duration of a rendered transmission.
+const DefaultTimeout = 10 * time.Minute
+
+// ErrTimeout is returned when the transmission of a vector does not end within the timeout of the harness.
+var ErrTimeout = errors.New("conformance: transmission timeout")
+
+// Vector describes a transmission and its reference decode.
+type Vector struct {
+	// Name identifies the vector.
+	Name string `json:"name"`
+	// Mode is the name of the mode, e.g. "psk31".
+	Mode string `json:"mode"`
+	// Options contains the numeric parameters of the mode, e.g. "frequency" or "wpm".
+	Options map[string]float64 `json:"options,omitempty"`
+	// SampleRate is the sample rate of the rendered audio in Hz, 0 means DefaultSampleRate.
+	SampleRate int `json:"sample_rate,omitempty"`
+	// Text is the text that is sent by the modulator.
+	Text string `json:"text"`
+	// Reference is the decode of the rendered signal by the reference software.
+	Reference string `json:"reference"`
+	// Source names the reference software that produced the reference decode, e.g. "fldigi 4.1.26". Without a
+	// source, the reference is the expected decode of the text, which is not confirmed by reference software yet.
+	Source string `json:"source,omitempty"`
+}
+
+// Option returns the value of the given option or the given default value if the option is not set.
+func (v Vector) Option(name string, defaultValue float64) float64 {
+	value, ok := v.Options[name]
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+func (v Vector) sampleRate() int {
+	if v.SampleRate == 0 {
+		return DefaultSampleRate
+	}
+	return v.SampleRate
+}
+
+// ReadVectors reads the vectors from the given JSON array.
+func ReadVectors(r io.Reader) ([]Vector, error) {
+	var result []Vector
+	err := json.NewDecoder(r).Decode(&result)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: cannot read vectors: %w", err)
+	}
+	return result, nil
+}
+
+// LoadVectors reads the vectors from the JSON file with the given name.
+func LoadVectors(filename string) ([]Vector, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadVectors(file)
+}
+
+// Modulator is the interface of the modulators that can be checked by the harness. The text of a vector is queued
+// as a whole transmission, see audio.Queuer.
+type Modulator interface {
+	audio.Queuer
+	io.Closer
+	audio.Modulator
+}
+
+// Mode describes how the harness renders and decodes the signal of a mode.
+type Mode struct {
+	// NewModulator returns a new modulator for the given vector.
+	NewModulator func(v Vector) (Modulator, error)
+	// Decode decodes the given audio samples of the given vector.
+	Decode func(v Vector, samples []float64) (string, error)
+}
+
+// Result is the outcome of checking a vector.
+type Result struct {
+	Vector Vector
+	// Decoded is the text that was decoded from the rendered signal.
+	Decoded string
+	// Match indicates if the decoded text matches the reference decode.
+	Match bool
+}
+
+// String returns a description of the result.
+func (r Result) String() string {
+	if r.Match {
+		return fmt.Sprintf("%s: ok", r.Vector.Name)
+	}
+	return fmt.Sprintf("%s: decoded %q, reference %q", r.Vector.Name, r.Decoded, r.Vector.Reference)
+}
+
+// Harness renders vectors with the registered modes and checks the decodes against the reference.
+type Harness struct {
+	mu    sync.RWMutex
+	modes map[string]Mode
+
+	// Tail is the duration of silence that is rendered after the transmission.
+	Tail time.Duration
+	// Timeout is the maximum duration of a rendered transmission.
+	Timeout time.Duration
+}
+
+// NewHarness returns a new Harness without any modes.
+func NewHarness() *Harness {
+	return &Harness{
+		modes:   make(map[string]Mode),
+		Tail:    DefaultTail,
+		Timeout: DefaultTimeout,
+	}
+}
+
+// DefaultHarness returns a new Harness with all modes of this library that have a decoder.
+func DefaultHarness() *Harness {
+	result := NewHarness()
+	result.Register("cw", Mode{
+		NewModulator: func(v Vector) (Modulator, error) {
+			return cw.NewModulator(v.Option("frequency", 700), int(v.Option("wpm", 20))), nil
+		},
+		Decode: func(v Vector, samples []float64) (string, error) {
+			var decoded strings.Builder
+			demodulator := cw.NewDemodulator(v.Option("frequency", 700), v.sampleRate(), int(v.Option("wpm", 20)), func(r rune) {
+				decoded.WriteRune(r)
+			})
+			demodulator.WriteSamples(samples)
+			demodulator.Flush()
+			return decoded.String(), nil
+		},
+	})
+	for mode, baud := range pskRates {
+		baud := baud
+		result.Register(mode, Mode{
+			NewModulator: func(v Vector) (Modulator, error) {
+				return psk31.NewModulatorWithRate(v.Option("frequency", 1000), baud), nil
+			},
+			Decode: func(v Vector, samples []float64) (string, error) {
+				var decoded strings.Builder
+				demodulator := psk31.NewDemodulatorWithRate(v.Option("frequency", 1000), baud, v.sampleRate(), func(b byte) {
+					decoded.WriteByte(b)
+				})
+				demodulator.WriteSamples(samples)
+				return decoded.String(), nil
+			},
+		})
+	}
+	return result
+}
+
+// pskRates are the symbol rates of the PSK modes.
+var pskRates = map[string]float64{
+	"psk31":  psk31.PSK31,
+	"psk63":  psk31.PSK63,
+	"psk125": psk31.PSK125,
+	"psk250": psk31.PSK250,
+}
+
+// Register registers the given mode under the given name.
+func (h *Harness) Register(name string, mode Mode) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.modes[name] = mode
+}
+
+// Modes returns the names of all registered modes.
+func (h *Harness) Modes() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result := make([]string, 0, len(h.modes))
+	for name := range h.modes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (h *Harness) mode(name string) (Mode, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	mode, ok := h.modes[name]
+	if !ok {
+		return Mode{}, fmt.Errorf("conformance: unknown mode %q", name)
+	}
+	return mode, nil
+}
+
+// Render renders the transmission of the given vector, followed by the tail of silence, see
+// audio.Renderer.RenderText.
+func (h *Harness) Render(v Vector) ([]float64, error) {
+	mode, err := h.mode(v.Mode)
+	if err != nil {
+		return nil, err
+	}
+	modulator, err := mode.NewModulator(v)
+	if err != nil {
+		return nil, err
+	}
+	defer modulator.Close()
+
+	sampleRate := v.sampleRate()
+	renderer := audio.NewRenderer(modulator, sampleRate)
+	result := make([]float64, 0, 10*sampleRate)
+	err = renderer.RenderText(v.Text, h.Tail, h.Timeout, func(block []float64) error {
+		result = append(result, block...)
+		return nil
+	})
+	if err == audio.ErrRenderTimeout {
+		return nil, ErrTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WriteWAV renders the transmission of the given vector and writes it as 16 bit PCM RIFF/WAVE file to the given
+// writer. The file can be decoded with the reference software to obtain the reference decode of the vector.
+func (h *Harness) WriteWAV(w io.WriteSeeker, v Vector) error {
+	samples, err := h.Render(v)
+	if err != nil {
+		return err
+	}
+	writer, err := audio.NewWAVWriter(w, v.sampleRate(), audio.Int16)
+	if err != nil {
+		return err
+	}
+	_, err = writer.WriteSamples(samples)
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Check renders the given vector, decodes the signal and compares the decoded text with the reference decode.
+func (h *Harness) Check(v Vector) (Result, error) {
+	mode, err := h.mode(v.Mode)
+	if err != nil {
+		return Result{}, err
+	}
+	samples, err := h.Render(v)
+	if err != nil {
+		return Result{}, err
+	}
+	decoded, err := mode.Decode(v, samples)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Vector:  v,
+		Decoded: decoded,
+		Match:   Match(decoded, v.Reference),
+	}, nil
+}
+
+// Match indicates if the given decoded text contains the given reference decode. Both texts are compared case
+// insensitive and with all sequences of whitespace reduced to a single space, since the modes and the reference
+// software differ in the handling of letter case and line breaks. Characters that are decoded before the receiver
+// is synchronized do not affect the match.
+func Match(decoded, reference string) bool {
+	return strings.Contains(normalize(decoded), normalize(reference))
+}
+
+func normalize(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
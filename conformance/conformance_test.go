@@ -0,0 +1,83 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("CQ CQ DE DL1ABC", "cq cq de dl1abc"))
+	assert.True(t, Match("e tCQ CQ\r\nDE  DL1ABC ", "CQ CQ DE DL1ABC"))
+	assert.False(t, Match("CQ CQ DE DL1AB", "CQ CQ DE DL1ABC"))
+}
+
+func TestReadVectors(t *testing.T) {
+	vectors, err := ReadVectors(strings.NewReader(`[
+		{"name": "cq", "mode": "psk31", "options": {"frequency": 1500}, "text": "cq", "reference": "CQ", "source": "fldigi"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, vectors, 1)
+	assert.Equal(t, "psk31", vectors[0].Mode)
+	assert.Equal(t, 1500.0, vectors[0].Option("frequency", 1000))
+	assert.Equal(t, 20.0, vectors[0].Option("wpm", 20))
+	assert.Equal(t, DefaultSampleRate, vectors[0].sampleRate())
+
+	_, err = ReadVectors(strings.NewReader(`{`))
+	assert.Error(t, err)
+}
+
+func TestDefaultHarness(t *testing.T) {
+	assert.Equal(t, []string{"cw", "psk125", "psk250", "psk31", "psk63"}, DefaultHarness().Modes())
+}
+
+func TestReferenceVectors(t *testing.T) {
+	vectors, err := LoadVectors(filepath.Join("testdata", "vectors.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors)
+
+	h := DefaultHarness()
+	modes := make(map[string]bool)
+	for _, vector := range vectors {
+		modes[vector.Mode] = true
+		result, err := h.Check(vector)
+		require.NoError(t, err, vector.Name)
+		assert.True(t, result.Match, result.String())
+	}
+	for _, mode := range h.Modes() {
+		assert.True(t, modes[mode], "no vector for %s", mode)
+	}
+}
+
+func TestCheckMismatch(t *testing.T) {
+	result, err := DefaultHarness().Check(Vector{Name: "mismatch", Mode: "cw", Options: map[string]float64{"wpm": 30}, Text: "test", Reference: "TEXT"})
+	require.NoError(t, err)
+	assert.False(t, result.Match)
+	assert.Equal(t, "test", strings.TrimSpace(result.Decoded))
+	assert.Equal(t, `mismatch: decoded "test ", reference "TEXT"`, result.String())
+}
+
+func TestCheckUnknownMode(t *testing.T) {
+	_, err := DefaultHarness().Check(Vector{Name: "unknown", Mode: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestWriteWAV(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cw.wav")
+	file, err := os.Create(filename)
+	require.NoError(t, err)
+	defer file.Close()
+
+	h := DefaultHarness()
+	h.Tail = 0
+	err = h.WriteWAV(file, Vector{Name: "cw", Mode: "cw", Options: map[string]float64{"wpm": 60}, Text: "e"})
+	require.NoError(t, err)
+
+	info, err := file.Stat()
+	require.NoError(t, err)
+	assert.Greater(t, info.Size(), int64(44+2*DefaultSampleRate/100), "header and at least one dit")
+}
@@ -0,0 +1,37 @@
+/*
+Package conformancetest provides helpers to check conformance vectors in tests.
+*/
+package conformancetest
+
+import (
+	"testing"
+
+	"github.com/ftl/digimodes/conformance"
+)
+
+// Verify checks each of the given vectors with the given harness in a subtest of the given test.
+func Verify(t *testing.T, h *conformance.Harness, vectors []conformance.Vector) {
+	t.Helper()
+	for _, vector := range vectors {
+		vector := vector
+		t.Run(vector.Name, func(t *testing.T) {
+			result, err := h.Check(vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Match {
+				t.Error(result)
+			}
+		})
+	}
+}
+
+// VerifyFile loads the vectors from the JSON file with the given name and checks them with Verify.
+func VerifyFile(t *testing.T, h *conformance.Harness, filename string) {
+	t.Helper()
+	vectors, err := conformance.LoadVectors(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Verify(t, h, vectors)
+}
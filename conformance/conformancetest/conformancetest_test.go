@@ -0,0 +1,20 @@
+package conformancetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ftl/digimodes/conformance"
+)
+
+func TestVerify(t *testing.T) {
+	Verify(t, conformance.DefaultHarness(), []conformance.Vector{
+		{Name: "cw", Mode: "cw", Options: map[string]float64{"wpm": 30}, Text: "cq de dl1abc", Reference: "CQ DE DL1ABC"},
+		{Name: "psk31", Mode: "psk31", Options: map[string]float64{"frequency": 1500}, Text: "CQ CQ de DL1ABC", Reference: "CQ CQ de DL1ABC"},
+		{Name: "psk125", Mode: "psk125", SampleRate: 11025, Text: "hello world", Reference: "hello world"},
+	})
+}
+
+func TestVerifyFile(t *testing.T) {
+	VerifyFile(t, conformance.DefaultHarness(), filepath.Join("..", "testdata", "vectors.json"))
+}
@@ -5,9 +5,7 @@ package cw
 
 import (
 	"context"
-	"strings"
 	"time"
-	"unicode"
 )
 
 // WPMToSeconds returns the duration of a dit in seconds with the given speed in WpM.
@@ -117,17 +115,19 @@ var Code = map[rune][]Symbol{
 	'§': {Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit}, // correction
 }
 
-// WriteToSymbolStream writes the content of the given text as morse symbols to the given stream.
+// WriteToSymbolStream writes the content of the given text as morse symbols to the given stream. Prosigns can be
+// written in angle brackets, e.g. "<AR>". Inline commands are ignored, the symbol stream carries no timing.
 // The first written symbol is always a Dit or a Da (key down), the last written symbol is always a WordBreak (key up).
 func WriteToSymbolStream(ctx context.Context, symbols chan<- Symbol, text string) {
-	normalized := strings.ToLower(text)
 	wasWhitespace := true
 	var canceled bool
-	for _, r := range normalized {
+	for len(text) > 0 {
 		if canceled {
 			return
 		}
-		if unicode.IsSpace(r) {
+		code, space, _, size := nextToken(text)
+		text = text[size:]
+		if space {
 			if !wasWhitespace {
 				canceled = writeSymbol(ctx, symbols, WordBreak)
 			}
@@ -135,8 +135,7 @@ func WriteToSymbolStream(ctx context.Context, symbols chan<- Symbol, text string
 			continue
 		}
 
-		code, knownCode := Code[r]
-		if !knownCode {
+		if code == nil {
 			continue
 		}
 		if !wasWhitespace {
@@ -169,7 +168,13 @@ func writeSymbol(ctx context.Context, symbols chan<- Symbol, symbol Symbol) bool
 
 // Send reads CW symbols from the given stream and transmits them using the given setKeyDown function with the given speed in WpM.
 func Send(ctx context.Context, setKeyDown func(bool), symbols <-chan Symbol, wpm int) {
-	dit := WPMToDit(wpm)
+	SendWithSpeed(ctx, setKeyDown, symbols, NewSpeed(wpm))
+}
+
+// SendWithSpeed reads CW symbols from the given stream and transmits them using the given setKeyDown function with
+// the given speed. Changes of the speed take effect at the next break between characters.
+func SendWithSpeed(ctx context.Context, setKeyDown func(bool), symbols <-chan Symbol, speed *Speed) {
+	timing := newTiming(speed, 0)
 
 	symbolEnd := time.Now().Add(-1 * time.Second)
 	keyDown := false
@@ -182,7 +187,7 @@ func Send(ctx context.Context, setKeyDown func(bool), symbols <-chan Symbol, wpm
 				continue
 			}
 
-			symbolEnd, keyDown, canceled = decodeSymbol(ctx, symbols, dit)
+			symbolEnd, keyDown, canceled = decodeSymbol(ctx, symbols, &timing)
 			if canceled {
 				setKeyDown(false)
 				return
@@ -195,10 +200,10 @@ func Send(ctx context.Context, setKeyDown func(bool), symbols <-chan Symbol, wpm
 	}
 }
 
-func decodeSymbol(ctx context.Context, symbols <-chan Symbol, dit time.Duration) (time.Time, bool, bool) {
+func decodeSymbol(ctx context.Context, symbols <-chan Symbol, timing *timing) (time.Time, bool, bool) {
 	select {
 	case symbol := <-symbols:
-		duration := time.Duration(symbol.Weight) * dit
+		duration := time.Duration(timing.duration(symbol) * float64(time.Second))
 		end := time.Now().Add(duration)
 		keyDown := symbol.KeyDown
 		return end, keyDown, false
@@ -2,9 +2,12 @@ package cw
 
 import (
 	"context"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteToSymbolStream(t *testing.T) {
@@ -23,3 +26,162 @@ func TestWriteToSymbolStream(t *testing.T) {
 	assert.Equal(t, 56, len(symbols))
 	assert.Equal(t, 100, weightSum)
 }
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+	go m.Write([]byte("paris paris"))
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestInvalidItemStopsModulator(t *testing.T) {
+	m := NewModulator(700, 20)
+	m.symbols.Send(context.Background(), item{kind: itemKind(99)})
+
+	amplitude, _, _ := m.Modulate(0, 0, 0, 0)
+
+	assert.Equal(t, 0.0, amplitude)
+	assert.Error(t, m.Err())
+	_, err := m.Write([]byte("a"))
+	assert.Equal(t, m.Err(), err)
+}
+
+func TestWriteContextCancel(t *testing.T) {
+	const sampleRate = 8000.0
+	m := NewModulator(700, 20)
+	defer m.Close()
+	n := 0
+	// modulate runs the modulator for the given duration and returns the time of the last key down
+	modulate := func(duration float64) float64 {
+		lastKeyDown := -1.0
+		for end := n + int(duration*sampleRate); n < end; n++ {
+			t := float64(n) / sampleRate
+			m.Modulate(t, 0, 0, 0)
+			if m.keyDown {
+				lastKeyDown = t
+			}
+			runtime.Gosched()
+		}
+		return lastKeyDown
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	var sent int
+	go func() {
+		var err error
+		sent, err = m.WriteContext(ctx, []byte("paris paris paris paris paris paris paris paris"))
+		result <- err
+	}()
+	// the text does not fit into the stream, the writer blocks when the stream is full
+	for m.symbols.Len() < m.symbols.Cap() {
+		runtime.Gosched()
+	}
+	chars, _ := m.Pending()
+	require.Greater(t, chars, 2)
+	modulate(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-result)
+	// "p" takes 0.66s, the last symbol of "a" starts after 0.9s
+	assert.Equal(t, 2, sent, "only the transmitted characters count")
+	canceledAt := float64(n) / sampleRate
+
+	lastKeyDown := modulate(3)
+	assert.Less(t, lastKeyDown, canceledAt+0.5, "the rest of the text is dropped")
+
+	done, err := m.Queue("e")
+	require.NoError(t, err)
+	assert.Greater(t, modulate(1), canceledAt+3, "the modulator is still usable")
+	select {
+	case <-done:
+	default:
+		assert.Fail(t, "the text is not sent")
+	}
+}
+
+func TestWriteCharacter(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+	// symbolsOf writes the given character and returns the symbols that are received by the modulator
+	symbolsOf := func(character string) ([]Symbol, error) {
+		written := make(chan error, 1)
+		go func() {
+			written <- m.WriteCharacter(context.Background(), character)
+		}()
+		var result []Symbol
+		for {
+			select {
+			case err := <-written:
+				return result, err
+			default:
+			}
+			next, ok := m.symbols.TryReceive()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			if next.kind == endOfTransmissionItem {
+				close(next.token)
+				continue
+			}
+			result = append(result, next.symbol)
+		}
+	}
+
+	symbols, err := symbolsOf("A")
+	assert.NoError(t, err)
+	assert.Equal(t, []Symbol{Dit, SymbolBreak, Da, CharBreak}, symbols)
+
+	symbols, err = symbolsOf(" ")
+	assert.NoError(t, err)
+	assert.Equal(t, []Symbol{{4, false}}, symbols)
+	assert.True(t, isCharBoundary(symbols[0]))
+
+	symbols, err = symbolsOf("SK")
+	assert.NoError(t, err)
+	assert.Equal(t, []Symbol{Dit, SymbolBreak, Dit, SymbolBreak, Dit, SymbolBreak, Da, SymbolBreak, Dit, SymbolBreak, Da, CharBreak}, symbols)
+
+	symbols, err = symbolsOf("#")
+	assert.NoError(t, err)
+	assert.Empty(t, symbols)
+
+	_, err = symbolsOf("A B")
+	assert.Equal(t, ErrUnknownProsign, err)
+}
+
+func TestModulatorPending(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+	chars, duration := m.Pending()
+	assert.Equal(t, 0, chars)
+	assert.Equal(t, time.Duration(0), duration)
+
+	// the whole text is queued when Queue returns
+	done, err := m.Queue("ee e<pause:1s>")
+	require.NoError(t, err)
+	chars, duration = m.Pending()
+	assert.Equal(t, 4, chars)
+	// three dits, a break between characters and two breaks between words, and the pause
+	assert.InDelta(t, 20*0.06+1, duration.Seconds(), 1e-6)
+
+	for n := 0; ; n++ {
+		require.Less(t, n, 10*8000, "the text is not sent")
+		m.Modulate(float64(n)/8000, 0, 0, 0)
+		select {
+		case <-done:
+		default:
+			continue
+		}
+		break
+	}
+	chars, duration = m.Pending()
+	assert.Equal(t, 0, chars)
+	assert.Equal(t, time.Duration(0), duration)
+}
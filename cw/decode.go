@@ -0,0 +1,311 @@
+package cw
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	// UnknownCode is decoded for a sequence of dits and das that is not in the code table.
+	UnknownCode = '*'
+
+	// markHistory is the number of recent key down durations used to estimate the speed.
+	markHistory = 16
+	// minClassRatio is the minimum ratio between das and dits to tell them apart in the mark history.
+	minClassRatio = 2.0
+	// clusterIterations is the number of iterations to split the mark history into dits and das.
+	clusterIterations = 4
+	// minMark is the shortest key down duration in seconds that is not ignored as glitch.
+	minMark = 0.01
+	// charBreakThreshold is the gap in dits that separates symbol breaks from character breaks, it also separates
+	// dits from das.
+	charBreakThreshold = 2.0
+	// wordBreakThreshold is the gap in dits that separates character breaks from word breaks.
+	wordBreakThreshold = 4.5
+
+	minWPM = 5
+	maxWPM = 60
+	// defaultDecoderWPM is the initial speed for decoding key events without a known speed.
+	defaultDecoderWPM = 20
+)
+
+// decodeTable maps the sequence of dits (.) and das (-) to the characters of the code table.
+var decodeTable = func() map[string]rune {
+	result := make(map[string]rune, len(Code))
+	for r, symbols := range Code {
+		code := make([]byte, len(symbols))
+		for i, s := range symbols {
+			if s == Da {
+				code[i] = '-'
+			} else {
+				code[i] = '.'
+			}
+		}
+		result[string(code)] = r
+	}
+	return result
+}()
+
+// Decoder decodes CW from key down and key up events and passes the decoded characters to a handler. It estimates
+// the speed from the recent key down durations, so it adapts to the sender and tolerates sloppy manual keying.
+// The times of the events are in seconds.
+type Decoder struct {
+	mu      sync.Mutex
+	handler func(rune)
+
+	dit   float64
+	marks []float64
+
+	keyDown    bool
+	since      float64
+	code       []byte
+	wordBreak  bool
+	characters []rune
+}
+
+// KeyEvent is a key down or key up event at the given time in seconds.
+type KeyEvent struct {
+	KeyDown bool
+	Time    float64
+}
+
+// DecodeTimings decodes the text from the given key events. The speed is estimated from all key down durations
+// before the decoding starts, so also the first characters are decoded with the right speed.
+func DecodeTimings(events []KeyEvent) string {
+	result := &strings.Builder{}
+	d := NewDecoder(defaultDecoderWPM, func(r rune) {
+		result.WriteRune(r)
+	})
+	d.learn(events)
+	for _, e := range events {
+		d.SetKey(e.KeyDown, e.Time)
+	}
+	d.Flush()
+	return result.String()
+}
+
+// DecodeKeyEvents decodes the key events from the given stream and writes the decoded characters to the given
+// stream, until the event stream is closed or the context is canceled. The decoder starts with the given speed in
+// WpM. Word breaks are decoded when the next key down event arrives.
+func DecodeKeyEvents(ctx context.Context, events <-chan KeyEvent, wpm int, characters chan<- rune) {
+	d := NewDecoder(wpm, func(r rune) {
+		select {
+		case characters <- r:
+		case <-ctx.Done():
+		}
+	})
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				d.Flush()
+				return
+			}
+			d.SetKey(e.KeyDown, e.Time)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// NewDecoder returns a new Decoder that starts with the given speed in WpM and passes the decoded characters to
+// the given handler. Word breaks are decoded as space.
+func NewDecoder(wpm int, handler func(rune)) *Decoder {
+	return &Decoder{
+		handler: handler,
+		dit:     WPMToSeconds(wpm),
+		marks:   make([]float64, 0, markHistory),
+	}
+}
+
+// WPM returns the estimated speed in WpM.
+func (d *Decoder) WPM() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return int(math.Round(WPMToSeconds(1) / d.dit))
+}
+
+// Confidence returns the consistency of the recent key down durations between 0 and 1. It is 1 if all dits and
+// all das have the same length, and drops with the deviation from the average dit and da in units of a dit.
+func (d *Decoder) Confidence() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.marks) < 2 {
+		return 0
+	}
+	threshold := charBreakThreshold * d.dit
+	var sums [2]float64
+	var counts [2]int
+	class := func(duration float64) int {
+		if duration < threshold {
+			return 0
+		}
+		return 1
+	}
+	for _, duration := range d.marks {
+		sums[class(duration)] += duration
+		counts[class(duration)]++
+	}
+	var deviation float64
+	for _, duration := range d.marks {
+		c := class(duration)
+		deviation += math.Abs(duration - sums[c]/float64(counts[c]))
+	}
+	deviation /= float64(len(d.marks)) * d.dit
+	return math.Max(0, 1-deviation)
+}
+
+// SetKey handles a key down or key up event at the given time.
+func (d *Decoder) SetKey(keyDown bool, t float64) {
+	d.mu.Lock()
+	if keyDown == d.keyDown {
+		d.mu.Unlock()
+		return
+	}
+	if keyDown {
+		d.tick(t)
+	} else {
+		d.mark(t - d.since)
+	}
+	d.keyDown = keyDown
+	d.since = t
+	d.unlockAndEmit()
+}
+
+// Tick decodes the pending character or word break if the key is up long enough at the given time.
+func (d *Decoder) Tick(t float64) {
+	d.mu.Lock()
+	d.tick(t)
+	d.unlockAndEmit()
+}
+
+// Flush decodes the pending character.
+func (d *Decoder) Flush() {
+	d.mu.Lock()
+	d.decodeCharacter()
+	d.unlockAndEmit()
+}
+
+// learn estimates the speed from the key down durations of the given events without decoding them.
+func (d *Decoder) learn(events []KeyEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keyDown := false
+	var since float64
+	for _, e := range events {
+		if e.KeyDown == keyDown {
+			continue
+		}
+		if !e.KeyDown {
+			d.mark(e.Time - since)
+		}
+		keyDown = e.KeyDown
+		since = e.Time
+	}
+	d.code = d.code[:0]
+}
+
+func (d *Decoder) unlockAndEmit() {
+	characters := d.characters
+	d.characters = nil
+	d.mu.Unlock()
+	for _, c := range characters {
+		d.handler(c)
+	}
+}
+
+func (d *Decoder) tick(t float64) {
+	if d.keyDown {
+		return
+	}
+	gap := t - d.since
+	if gap >= charBreakThreshold*d.dit {
+		d.decodeCharacter()
+	}
+	if gap >= wordBreakThreshold*d.dit && d.wordBreak {
+		d.characters = append(d.characters, ' ')
+		d.wordBreak = false
+	}
+}
+
+func (d *Decoder) mark(duration float64) {
+	if duration < minMark {
+		return
+	}
+	if len(d.marks) == markHistory {
+		copy(d.marks, d.marks[1:])
+		d.marks = d.marks[:markHistory-1]
+	}
+	d.marks = append(d.marks, duration)
+	d.estimateSpeed()
+
+	if duration < charBreakThreshold*d.dit {
+		d.code = append(d.code, '.')
+	} else {
+		d.code = append(d.code, '-')
+	}
+}
+
+func (d *Decoder) decodeCharacter() {
+	if len(d.code) == 0 {
+		return
+	}
+	c, ok := decodeTable[string(d.code)]
+	if !ok {
+		c = UnknownCode
+	}
+	d.characters = append(d.characters, c)
+	d.code = d.code[:0]
+	d.wordBreak = true
+}
+
+// estimateSpeed splits the recent marks into dits and das with a two means clustering. If all marks are of the
+// same kind, they are compared to the current estimate.
+func (d *Decoder) estimateSpeed() {
+	short, long := d.marks[0], d.marks[0]
+	for _, duration := range d.marks {
+		short = math.Min(short, duration)
+		long = math.Max(long, duration)
+	}
+	for i := 0; i < clusterIterations; i++ {
+		threshold := math.Sqrt(short * long)
+		var shortSum, longSum float64
+		var shortCount, longCount int
+		for _, duration := range d.marks {
+			if duration < threshold {
+				shortSum += duration
+				shortCount++
+			} else {
+				longSum += duration
+				longCount++
+			}
+		}
+		if shortCount == 0 || longCount == 0 {
+			break
+		}
+		short = shortSum / float64(shortCount)
+		long = longSum / float64(longCount)
+	}
+
+	var dit float64
+	if long >= minClassRatio*short {
+		// a da is two dits longer than a dit, this does not depend on how the edges of the signal are detected
+		dit = (long - short) / 2
+	} else {
+		var mean float64
+		for _, duration := range d.marks {
+			mean += duration
+		}
+		mean /= float64(len(d.marks))
+		// compare on a logarithmic scale, dits are closer to the dit duration than to the da duration
+		if math.Abs(math.Log(mean/d.dit)) < math.Abs(math.Log(mean/(3*d.dit))) {
+			dit = mean
+		} else {
+			dit = mean / 3
+		}
+	}
+
+	d.dit = math.Max(WPMToSeconds(maxWPM), math.Min(WPMToSeconds(minWPM), dit))
+}
@@ -0,0 +1,142 @@
+package cw
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timings returns the key events of the given text with the given speed. The duration of each symbol varies randomly
+// by the given relative jitter.
+func timings(text string, wpm int, jitter float64) []KeyEvent {
+	symbols := make(chan Symbol, 1000)
+	WriteToSymbolStream(context.Background(), symbols, text)
+	close(symbols)
+
+	rng := rand.New(rand.NewSource(1))
+	dit := WPMToSeconds(wpm)
+	t := 1.0
+	var result []KeyEvent
+	for s := range symbols {
+		result = append(result, KeyEvent{KeyDown: s.KeyDown, Time: t})
+		t += float64(s.Weight) * dit * (1 + jitter*(2*rng.Float64()-1))
+	}
+	return append(result, KeyEvent{KeyDown: false, Time: t})
+}
+
+// keyEvents feeds the given text with the given speed into the decoder.
+func keyEvents(decoder *Decoder, text string, wpm int, jitter float64) {
+	events := timings(text, wpm, jitter)
+	for _, e := range events {
+		decoder.SetKey(e.KeyDown, e.Time)
+	}
+	decoder.Tick(events[len(events)-1].Time + 10)
+}
+
+func TestDecoder(t *testing.T) {
+	const text = "cq cq de dl1abc dl1abc pse k"
+	testCases := []struct {
+		desc     string
+		wpm      int
+		startWPM int
+		jitter   float64
+	}{
+		{"exact", 20, 20, 0},
+		{"slower than expected", 12, 20, 0},
+		{"faster than expected", 35, 20, 0},
+		{"sloppy keying", 18, 20, 0.25},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			received := &strings.Builder{}
+			decoder := NewDecoder(tC.startWPM, func(r rune) {
+				received.WriteRune(r)
+			})
+
+			keyEvents(decoder, text, tC.wpm, tC.jitter)
+
+			assert.Contains(t, received.String(), "de dl1abc dl1abc pse k ")
+			assert.InDelta(t, tC.wpm, decoder.WPM(), float64(tC.wpm)/10+1)
+		})
+	}
+}
+
+func TestDecoderConfidence(t *testing.T) {
+	decoder := NewDecoder(20, func(rune) {})
+	assert.Equal(t, 0.0, decoder.Confidence())
+
+	keyEvents(decoder, "paris paris", 20, 0)
+	assert.InDelta(t, 1, decoder.Confidence(), 1e-6)
+
+	sloppy := NewDecoder(20, func(rune) {})
+	keyEvents(sloppy, "paris paris", 20, 0.3)
+	assert.True(t, sloppy.Confidence() < 0.9, "confidence %v", sloppy.Confidence())
+	assert.True(t, sloppy.Confidence() > 0.5, "confidence %v", sloppy.Confidence())
+}
+
+func TestDecoderUnknownCode(t *testing.T) {
+	received := &strings.Builder{}
+	decoder := NewDecoder(20, func(r rune) {
+		received.WriteRune(r)
+	})
+	dit := WPMToSeconds(20)
+	var now float64
+	for i := 0; i < 9; i++ {
+		decoder.SetKey(true, now)
+		now += dit
+		decoder.SetKey(false, now)
+		now += dit
+	}
+	decoder.Flush()
+
+	assert.Equal(t, string(UnknownCode), received.String())
+}
+
+func TestDecodeTable(t *testing.T) {
+	assert.Len(t, decodeTable, len(Code))
+	assert.Equal(t, 'a', decodeTable[".-"])
+	assert.Equal(t, '0', decodeTable["-----"])
+}
+
+func TestDecodeTimings(t *testing.T) {
+	const text = "cq cq de dl1abc pse k"
+	testCases := []struct {
+		desc   string
+		wpm    int
+		jitter float64
+	}{
+		{"slow", 8, 0},
+		{"default speed", 20, 0},
+		{"fast", 40, 0},
+		{"sloppy keying", 15, 0.2},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, text, DecodeTimings(timings(text, tC.wpm, tC.jitter)))
+		})
+	}
+}
+
+func TestDecodeKeyEvents(t *testing.T) {
+	const text = "cq de dl1abc k"
+	events := make(chan KeyEvent)
+	characters := make(chan rune, 100)
+	go func() {
+		for _, e := range timings(text, 18, 0) {
+			events <- e
+		}
+		close(events)
+	}()
+
+	DecodeKeyEvents(context.Background(), events, 18, characters)
+	close(characters)
+
+	received := &strings.Builder{}
+	for r := range characters {
+		received.WriteRune(r)
+	}
+	assert.Equal(t, text, received.String())
+}
@@ -0,0 +1,101 @@
+package cw
+
+import (
+	"sync"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/dsp"
+)
+
+// noiseSpan is the distance from the frequency in Hz within which the noise floor is measured.
+const noiseSpan = 500.0
+
+// Demodulator detects the key down and key up events in a CW signal around a configured audio frequency with a
+// ToneDetector and passes them to a Decoder. It implements audio.Sink.
+type Demodulator struct {
+	mu sync.Mutex
+
+	decoder  *Decoder
+	detector *ToneDetector
+	snr      *dsp.SNREstimator
+
+	buffer []float64
+}
+
+// NewDemodulator returns a new Demodulator for a signal at the given audio frequency and sample rate. The decoder
+// starts with the given speed in WpM and passes the decoded characters to the given handler.
+func NewDemodulator(frequency float64, sampleRate int, wpm int, handler func(rune)) *Demodulator {
+	decoder := NewDecoder(wpm, handler)
+	return &Demodulator{
+		decoder: decoder,
+		detector: NewToneDetector(frequency, sampleRate, func(keyDown bool, t float64) {
+			decoder.SetKey(keyDown, t)
+			decoder.Tick(t)
+		}),
+		snr: dsp.NewSNREstimator(sampleRate, frequency-noiseSpan, frequency+noiseSpan),
+	}
+}
+
+// SampleRate returns the sample rate of the audio in Hz.
+func (d *Demodulator) SampleRate() int {
+	return d.detector.SampleRate()
+}
+
+// WPM returns the estimated speed in WpM.
+func (d *Demodulator) WPM() int {
+	return d.decoder.WPM()
+}
+
+// Metrics returns the current quality of the received signal. The quality is the confidence of the decoder in the
+// timing of the recent dits and das. The SNR is the averaged level of the tone while the key is down relative to
+// the noise floor around the frequency. It implements digimodes.MetricsProvider.
+func (d *Demodulator) Metrics() digimodes.Metrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return digimodes.Metrics{
+		Open:      d.detector.Detected(),
+		Quality:   d.decoder.Confidence(),
+		SNR:       d.signalSNR(),
+		Frequency: d.detector.Frequency(),
+		WPM:       d.decoder.WPM(),
+	}
+}
+
+// WriteSamples demodulates the given audio samples.
+func (d *Demodulator) WriteSamples(samples []float64) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.demodulate(samples)
+	return len(samples), nil
+}
+
+// WritePCM demodulates the given 16 bit PCM samples.
+func (d *Demodulator) WritePCM(samples []int16) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cap(d.buffer) < len(samples) {
+		d.buffer = make([]float64, len(samples))
+	}
+	buffer := d.buffer[:len(samples)]
+	audio.Convert(buffer, samples)
+	d.demodulate(buffer)
+	return len(samples), nil
+}
+
+// Flush decodes the pending character.
+func (d *Demodulator) Flush() {
+	d.decoder.Flush()
+}
+
+// signalSNR returns the SNR of the tone while the key is down.
+func (d *Demodulator) signalSNR() float64 {
+	level := d.detector.SignalLevel()
+	// the power of a sine wave is half of its squared amplitude
+	return d.snr.SignalSNR(level * level / 2)
+}
+
+func (d *Demodulator) demodulate(samples []float64) {
+	d.snr.Process(samples)
+	d.detector.Process(samples)
+}
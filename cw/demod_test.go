@@ -0,0 +1,151 @@
+package cw
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/dsp"
+)
+
+// modulate renders the given text with a Modulator at the given frequency and speed, followed by one second of
+// silence. The whole text is queued before the first sample is rendered, so the signal is always the same.
+func modulate(t *testing.T, text string, frequency float64, wpm int, sampleRate int) []float64 {
+	m := NewModulator(frequency, wpm)
+	defer m.Close()
+	done, err := m.Queue(text)
+	require.NoError(t, err)
+
+	result := make([]float64, 0, 10*sampleRate)
+	var a, f, p, phase float64
+	for n := 0; ; n++ {
+		a, f, p = m.Modulate(float64(n)/float64(sampleRate), a, f, p)
+		result = append(result, a*math.Sin(phase+p))
+		phase = math.Mod(phase+2*math.Pi*f/float64(sampleRate), 2*math.Pi)
+		select {
+		case <-done:
+			return append(result, make([]float64, sampleRate)...)
+		default:
+		}
+		require.Less(t, n, 60*sampleRate, "the modulator does not end")
+	}
+}
+
+func TestDemodulator(t *testing.T) {
+	const text = "cq cq de dl1abc dl1abc pse k"
+	testCases := []struct {
+		desc       string
+		wpm        int
+		sampleRate int
+		noise      float64
+	}{
+		{"20 WpM", 20, 8000, 0},
+		{"30 WpM 48kHz", 30, 48000, 0},
+		{"12 WpM", 12, 8000, 0},
+		{"noise", 20, 8000, 0.5},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			samples := modulate(t, text, 700, tC.wpm, tC.sampleRate)
+			rng := rand.New(rand.NewSource(1))
+			for i := range samples {
+				samples[i] = 0.5*samples[i] + tC.noise*rng.NormFloat64()*0.1
+			}
+
+			received := &strings.Builder{}
+			demodulator := NewDemodulator(700, tC.sampleRate, 20, func(r rune) {
+				received.WriteRune(r)
+			})
+			assert.Equal(t, tC.sampleRate, demodulator.SampleRate())
+			for i := 0; i < len(samples); i += 512 {
+				end := i + 512
+				if end > len(samples) {
+					end = len(samples)
+				}
+				_, err := demodulator.WriteSamples(samples[i:end])
+				require.NoError(t, err)
+			}
+
+			assert.Contains(t, received.String(), "de dl1abc dl1abc pse k ")
+			assert.InDelta(t, tC.wpm, demodulator.WPM(), float64(tC.wpm)/10+1)
+		})
+	}
+}
+
+func TestDemodulatorMetrics(t *testing.T) {
+	samples := modulate(t, "cq cq de dl1abc dl1abc pse k", 700, 25, 8000)
+	rng := rand.New(rand.NewSource(1))
+	for i := range samples {
+		samples[i] = 0.5*samples[i] + 0.05*rng.NormFloat64()
+	}
+
+	demodulator := NewDemodulator(700, 8000, 20, func(rune) {})
+	assert.False(t, demodulator.Metrics().Open)
+	_, err := demodulator.WriteSamples(samples[:len(samples)/2])
+	require.NoError(t, err)
+
+	metrics := demodulator.Metrics()
+	assert.True(t, metrics.Open)
+	assert.True(t, metrics.Quality > 0.8, "quality %v", metrics.Quality)
+	assert.True(t, metrics.SNR > 10, "snr %v", metrics.SNR)
+	// a sine wave with the amplitude 0.5 in white noise with sigma 0.05 at 8000 Hz, the rendered signal and the noise
+	// are the same in each run
+	assert.InDelta(t, 10*math.Log10(0.125/(0.0025/4000*2500)), metrics.SNR, 0.5)
+	assert.Equal(t, 700.0, metrics.Frequency)
+	assert.InDelta(t, 25, metrics.WPM, 3)
+}
+
+func TestDemodulatorSNR(t *testing.T) {
+	const sampleRate = 8000
+	testCases := []struct {
+		desc      string
+		amplitude float64
+		sigma     float64
+	}{
+		{"strong", 0.5, 0.05},
+		{"weak", 0.1, 0.05},
+		{"quiet", 0.01, 0.001},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// the detector learns the noise level before the tone starts
+			rng := rand.New(rand.NewSource(1))
+			samples := make([]float64, 3*sampleRate)
+			for i := range samples {
+				samples[i] = tC.sigma * rng.NormFloat64()
+				if i >= sampleRate/2 {
+					samples[i] += tC.amplitude * math.Sin(2*math.Pi*700*float64(i)/sampleRate)
+				}
+			}
+			demodulator := NewDemodulator(700, sampleRate, 20, func(rune) {})
+
+			_, err := demodulator.WriteSamples(samples)
+			require.NoError(t, err)
+
+			// the power of the tone relative to the power of the white noise within the reference bandwidth
+			signal := tC.amplitude * tC.amplitude / 2
+			noise := tC.sigma * tC.sigma / (sampleRate / 2) * dsp.ReferenceBandwidth
+			assert.InDelta(t, 10*math.Log10(signal/noise), demodulator.Metrics().SNR, 0.5)
+		})
+	}
+}
+
+func TestDemodulatorPCM(t *testing.T) {
+	samples := modulate(t, "paris paris", 700, 20, 8000)
+	pcm := make([]int16, len(samples))
+	for i, s := range samples {
+		pcm[i] = int16(s * 16000)
+	}
+
+	received := &strings.Builder{}
+	demodulator := NewDemodulator(700, 8000, 20, func(r rune) {
+		received.WriteRune(r)
+	})
+	_, err := demodulator.WritePCM(pcm)
+	require.NoError(t, err)
+	assert.Equal(t, "paris paris ", received.String())
+}
@@ -0,0 +1,22 @@
+package cw
+
+type itemKind uint8
+
+const (
+	symbolItem itemKind = iota
+	endOfTransmissionItem
+	commandItem
+)
+
+// item is an element of the pipeline between Write and Modulate: either a symbol, an inline command or a token.
+// The write is the number of the write that produced the item, character marks the last item of a character.
+// Tokens is the number of written characters, spaces and commands that are complete when the item is sent.
+type item struct {
+	kind      itemKind
+	symbol    Symbol
+	command   command
+	token     chan struct{}
+	write     uint32
+	character bool
+	tokens    int
+}
@@ -0,0 +1,150 @@
+package cw
+
+import "sync"
+
+// KeyerMode selects how the iambic keyer completes a squeeze.
+type KeyerMode int
+
+// The iambic keyer modes.
+const (
+	// IambicA stops after the current element when both paddles are released.
+	IambicA KeyerMode = iota
+	// IambicB sends one more opposite element when both paddles are released during a squeeze.
+	IambicB
+)
+
+// Paddle is one of the two contacts of an iambic paddle.
+type Paddle int
+
+// The paddle contacts.
+const (
+	DitPaddle Paddle = iota
+	DahPaddle
+)
+
+func (p Paddle) opposite() Paddle {
+	return 1 - p
+}
+
+func (p Paddle) symbol() Symbol {
+	if p == DahPaddle {
+		return Da
+	}
+	return Dit
+}
+
+// Keyer is an iambic keyer. It generates dits and das from the paddle contact events and passes them to a handler,
+// each element is followed by a SymbolBreak. The handler is called at the start of an element, so the symbols can be
+// passed directly to a Modulator or to Send. Closing the paddle of the opposite element while an element is sent is
+// remembered (dot and dash memory). The times of the events are in seconds.
+type Keyer struct {
+	mu      sync.Mutex
+	mode    KeyerMode
+	dit     float64
+	handler func(Symbol)
+
+	paddles    [2]bool
+	memory     [2]bool
+	sending    bool
+	current    Paddle
+	elementEnd float64
+	symbols    []Symbol
+	sidetone   *Sidetone
+}
+
+// NewKeyer returns a new Keyer in the given mode and with the given speed in WpM that passes the symbols to the
+// given handler.
+func NewKeyer(mode KeyerMode, wpm int, handler func(Symbol)) *Keyer {
+	return &Keyer{
+		mode:    mode,
+		dit:     WPMToSeconds(wpm),
+		handler: handler,
+	}
+}
+
+// SetSidetone sets the sidetone that plays the elements of the keyer as soon as they start. It may be nil.
+func (k *Keyer) SetSidetone(sidetone *Sidetone) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sidetone = sidetone
+}
+
+// SetPaddle handles the closing or opening of the given paddle contact at the given time.
+func (k *Keyer) SetPaddle(paddle Paddle, closed bool, t float64) {
+	k.mu.Lock()
+	k.tick(t)
+	k.paddles[paddle] = closed
+	if closed && k.sending && paddle != k.current {
+		k.memory[paddle] = true
+	}
+	if !k.sending {
+		k.next(t)
+	}
+	k.unlockAndEmit()
+}
+
+// Tick generates the next element if the current element is complete at the given time. Tick must be called
+// regularly while the paddles are closed.
+func (k *Keyer) Tick(t float64) {
+	k.mu.Lock()
+	k.tick(t)
+	k.unlockAndEmit()
+}
+
+func (k *Keyer) unlockAndEmit() {
+	symbols := k.symbols
+	k.symbols = nil
+	k.mu.Unlock()
+	for _, s := range symbols {
+		k.handler(s)
+	}
+}
+
+func (k *Keyer) tick(t float64) {
+	for k.sending && t >= k.elementEnd {
+		k.next(k.elementEnd)
+	}
+}
+
+// next selects the element that starts at the given time.
+func (k *Keyer) next(t float64) {
+	opposite := k.current.opposite()
+	var paddle Paddle
+	switch {
+	case !k.sending && k.paddles[DitPaddle]:
+		paddle = DitPaddle
+	case !k.sending && k.paddles[DahPaddle]:
+		paddle = DahPaddle
+	case !k.sending:
+		return
+	case k.memory[opposite]:
+		paddle = opposite
+	case k.paddles[DitPaddle] && k.paddles[DahPaddle]:
+		paddle = opposite
+	case k.paddles[k.current]:
+		paddle = k.current
+	case k.paddles[opposite]:
+		paddle = opposite
+	default:
+		k.sending = false
+		return
+	}
+	k.start(paddle, t)
+}
+
+func (k *Keyer) start(paddle Paddle, t float64) {
+	k.sending = true
+	k.current = paddle
+	k.memory = [2]bool{}
+	if k.mode == IambicB && k.paddles[paddle.opposite()] {
+		// mode B latches the squeeze at the start of the element
+		k.memory[paddle.opposite()] = true
+	}
+	symbol := paddle.symbol()
+	k.elementEnd = t + float64(symbol.Weight+SymbolBreak.Weight)*k.dit
+	k.symbols = append(k.symbols, symbol, SymbolBreak)
+	if k.sidetone != nil {
+		k.sidetone.play(symbol, k.dit)
+		k.sidetone.play(SymbolBreak, k.dit)
+	}
+}
@@ -0,0 +1,108 @@
+package cw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type paddleEvent struct {
+	t      float64
+	paddle Paddle
+	closed bool
+}
+
+func TestKeyer(t *testing.T) {
+	const dit = 0.06 // 20 WpM
+	testCases := []struct {
+		desc          string
+		mode          KeyerMode
+		events        []paddleEvent
+		expected      string
+		expectedTimes []float64
+	}{
+		{
+			desc:          "dits",
+			events:        []paddleEvent{{0, DitPaddle, true}, {5 * dit, DitPaddle, false}},
+			expected:      "...",
+			expectedTimes: []float64{0, 2 * dit, 4 * dit},
+		},
+		{
+			desc:          "das",
+			events:        []paddleEvent{{0, DahPaddle, true}, {9 * dit, DahPaddle, false}},
+			expected:      "---",
+			expectedTimes: []float64{0, 4 * dit, 8 * dit},
+		},
+		{
+			desc:          "squeeze mode A",
+			mode:          IambicA,
+			events:        []paddleEvent{{0, DitPaddle, true}, {0.01, DahPaddle, true}, {5 * dit, DitPaddle, false}, {5 * dit, DahPaddle, false}},
+			expected:      ".-",
+			expectedTimes: []float64{0, 2 * dit},
+		},
+		{
+			desc:          "squeeze mode B",
+			mode:          IambicB,
+			events:        []paddleEvent{{0, DitPaddle, true}, {0.01, DahPaddle, true}, {5 * dit, DitPaddle, false}, {5 * dit, DahPaddle, false}},
+			expected:      ".-.",
+			expectedTimes: []float64{0, 2 * dit, 6 * dit},
+		},
+		{
+			desc:          "long squeeze alternates",
+			mode:          IambicA,
+			events:        []paddleEvent{{0, DahPaddle, true}, {0.01, DitPaddle, true}, {11 * dit, DitPaddle, false}, {11 * dit, DahPaddle, false}},
+			expected:      "-.-.",
+			expectedTimes: []float64{0, 4 * dit, 6 * dit, 10 * dit},
+		},
+		{
+			desc:          "dit memory",
+			events:        []paddleEvent{{0, DahPaddle, true}, {dit, DitPaddle, true}, {1.5 * dit, DitPaddle, false}, {2 * dit, DahPaddle, false}},
+			expected:      "-.",
+			expectedTimes: []float64{0, 4 * dit},
+		},
+		{
+			desc:          "pause between characters",
+			events:        []paddleEvent{{0, DahPaddle, true}, {dit, DahPaddle, false}, {10 * dit, DitPaddle, true}, {11 * dit, DitPaddle, false}},
+			expected:      "-.",
+			expectedTimes: []float64{0, 10 * dit},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var now float64
+			elements := &strings.Builder{}
+			var times []float64
+			breaks := 0
+			keyer := NewKeyer(tC.mode, 20, func(s Symbol) {
+				switch s {
+				case Dit:
+					elements.WriteByte('.')
+					times = append(times, now)
+				case Da:
+					elements.WriteByte('-')
+					times = append(times, now)
+				case SymbolBreak:
+					breaks++
+				}
+			})
+
+			events := tC.events
+			for i := 0; i < 2000; i++ {
+				now = float64(i) * 0.001
+				for len(events) > 0 && events[0].t <= now {
+					keyer.SetPaddle(events[0].paddle, events[0].closed, now)
+					events = events[1:]
+				}
+				keyer.Tick(now)
+			}
+
+			assert.Equal(t, tC.expected, elements.String())
+			assert.Equal(t, len(tC.expected), breaks)
+			assert.Equal(t, len(tC.expectedTimes), len(times))
+			for i, expected := range tC.expectedTimes {
+				assert.InDelta(t, expected, times[i], 0.0015, "element %d", i)
+			}
+		})
+	}
+}
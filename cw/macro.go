@@ -0,0 +1,173 @@
+package cw
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SerialMacro is the name of the macro that is replaced by the serial number of a contest exchange.
+const SerialMacro = "serial"
+
+// DefaultSerialDigits is the minimum number of digits of the serial number, shorter numbers get leading zeros.
+const DefaultSerialDigits = 3
+
+// Macros expands macros and abbreviations in the text that is sent, e.g. to fill the templates of contest
+// exchanges. Macros are written in angle brackets, e.g. "<call>" or "<rst>", abbreviations are whole words. Both
+// are replaced by their values, names and words are case insensitive. The macro "<serial>" is replaced by the
+// current serial number. Unknown macros are left untouched, so prosigns like "<AR>" and inline commands like
+// "<wpm:28>" still work. The values are not expanded again. Macros is safe for concurrent use.
+type Macros struct {
+	mu           sync.Mutex
+	macros       map[string]string
+	words        map[string]string
+	serial       int
+	serialDigits int
+}
+
+// NewMacros returns new Macros with the given substitutions. A key in angle brackets is a macro, e.g.
+// "<call>": "DL1ABC", any other key is an abbreviation, e.g. "gm": "good morning". The serial number starts with 1.
+func NewMacros(substitutions map[string]string) *Macros {
+	result := &Macros{
+		macros:       make(map[string]string),
+		words:        make(map[string]string),
+		serial:       1,
+		serialDigits: DefaultSerialDigits,
+	}
+	for key, value := range substitutions {
+		result.Set(key, value)
+	}
+	return result
+}
+
+// Set sets the value of the given macro or abbreviation, e.g. to change "<call>" for the next QSO. An empty value
+// removes the substitution.
+func (m *Macros) Set(key, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	substitutions := m.words
+	if name, ok := macroName(key); ok {
+		key = name
+		substitutions = m.macros
+	}
+	key = strings.ToLower(key)
+	if value == "" {
+		delete(substitutions, key)
+		return
+	}
+	substitutions[key] = value
+}
+
+func macroName(key string) (string, bool) {
+	if len(key) > 2 && strings.HasPrefix(key, "<") && strings.HasSuffix(key, ">") {
+		return key[1 : len(key)-1], true
+	}
+	return "", false
+}
+
+// Serial returns the current serial number.
+func (m *Macros) Serial() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.serial
+}
+
+// SetSerial sets the current serial number.
+func (m *Macros) SetSerial(serial int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serial = serial
+}
+
+// NextSerial increments the serial number, e.g. after a QSO is logged, and returns the new serial number.
+func (m *Macros) NextSerial() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serial++
+	return m.serial
+}
+
+// SetSerialDigits sets the minimum number of digits of the serial number, 0 sends the number without leading
+// zeros.
+func (m *Macros) SetSerialDigits(digits int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serialDigits = digits
+}
+
+// Expand returns the given text with all macros and abbreviations replaced by their values.
+func (m *Macros) Expand(text string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result strings.Builder
+	for len(text) > 0 {
+		r, _ := utf8.DecodeRuneInString(text)
+		end := strings.IndexFunc(text, func(c rune) bool {
+			return unicode.IsSpace(c) != unicode.IsSpace(r)
+		})
+		if end == -1 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = text[end:]
+
+		switch value, ok := m.words[strings.ToLower(word)]; {
+		case unicode.IsSpace(r):
+			result.WriteString(word)
+		case ok:
+			result.WriteString(value)
+		default:
+			m.expandMacros(&result, word)
+		}
+	}
+	return result.String()
+}
+
+func (m *Macros) expandMacros(result *strings.Builder, word string) {
+	for len(word) > 0 {
+		start := strings.IndexRune(word, '<')
+		if start == -1 {
+			break
+		}
+		end := strings.IndexRune(word[start:], '>')
+		if end == -1 {
+			break
+		}
+		end += start
+		result.WriteString(word[:start])
+		name := strings.ToLower(word[start+1 : end])
+		value, ok := m.macros[name]
+		switch {
+		case ok:
+			result.WriteString(value)
+		case name == SerialMacro:
+			result.WriteString(fmt.Sprintf("%0*d", m.serialDigits, m.serial))
+		default:
+			result.WriteString(word[start : end+1])
+		}
+		word = word[end+1:]
+	}
+	result.WriteString(word)
+}
+
+// Writer returns a writer that expands the written text and writes it to the given writer, e.g. a Modulator.
+func (m *Macros) Writer(w io.Writer) io.Writer {
+	return &macroWriter{macros: m, w: w}
+}
+
+type macroWriter struct {
+	macros *Macros
+	w      io.Writer
+}
+
+// Write expands the given text and writes it. It returns len(p) if the expanded text was written completely.
+func (w *macroWriter) Write(p []byte) (int, error) {
+	_, err := w.w.Write([]byte(w.macros.Expand(string(p))))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,72 @@
+package cw
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacrosExpand(t *testing.T) {
+	macros := NewMacros(map[string]string{
+		"<call>":   "DL2XYZ",
+		"<MyCall>": "DL1ABC",
+		"<rst>":    "5nn",
+		"tu":       "thank you",
+	})
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{"<call> de <mycall> <AR>", "DL2XYZ de DL1ABC <AR>"},
+		{"<CALL> <rst> <serial> tu", "DL2XYZ 5nn 001 thank you"},
+		{"TU  <call>?\n", "thank you  DL2XYZ?\n"},
+		{"<call>/p tutu", "DL2XYZ/p tutu"},
+		{"<wpm:28><unknown> <rst", "<wpm:28><unknown> <rst"},
+		{"", ""},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			assert.Equal(t, tC.expected, macros.Expand(tC.text))
+		})
+	}
+}
+
+func TestMacrosSerial(t *testing.T) {
+	macros := NewMacros(nil)
+	assert.Equal(t, 1, macros.Serial())
+	assert.Equal(t, "nr 001", macros.Expand("nr <serial>"))
+	assert.Equal(t, 2, macros.NextSerial())
+	assert.Equal(t, "nr 002", macros.Expand("nr <serial>"))
+
+	macros.SetSerial(1234)
+	assert.Equal(t, "nr 1234", macros.Expand("nr <serial>"))
+	macros.SetSerial(7)
+	macros.SetSerialDigits(0)
+	assert.Equal(t, "nr 7", macros.Expand("nr <serial>"))
+
+	// the serial macro can be overridden
+	macros.Set("<serial>", "TT7")
+	assert.Equal(t, "nr TT7", macros.Expand("nr <serial>"))
+}
+
+func TestMacrosSet(t *testing.T) {
+	macros := NewMacros(map[string]string{"<call>": "DL2XYZ"})
+	macros.Set("<call>", "DL3ABC")
+	macros.Set("gl", "good luck")
+	assert.Equal(t, "DL3ABC good luck", macros.Expand("<call> gl"))
+
+	macros.Set("<call>", "")
+	macros.Set("gl", "")
+	assert.Equal(t, "<call> gl", macros.Expand("<call> gl"))
+}
+
+func TestMacrosWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	macros := NewMacros(map[string]string{"<call>": "DL2XYZ"})
+	n, err := macros.Writer(&buffer).Write([]byte("<call> <serial>"))
+	require.NoError(t, err)
+	assert.Equal(t, len("<call> <serial>"), n)
+	assert.Equal(t, "DL2XYZ 001", buffer.String())
+}
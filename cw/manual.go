@@ -0,0 +1,82 @@
+package cw
+
+import (
+	"io"
+	"math"
+	"sync/atomic"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// ManualModulator generates a CW signal from the key down and key up events of a straight key or a bug. The
+// events are not re-timed, only the envelope is shaped to avoid key clicks. SetKey may be called concurrently to
+// Modulate.
+type ManualModulator struct {
+	pitchFrequency float64
+	envelope       Envelope
+	keyDown        int32
+
+	started  bool
+	lastT    float64
+	progress float64
+}
+
+// NewManualModulator returns a new ManualModulator for the given audio frequency.
+func NewManualModulator(frequency float64) *ManualModulator {
+	return &ManualModulator{
+		pitchFrequency: frequency,
+		envelope:       DefaultEnvelope(frequency),
+	}
+}
+
+// SetEnvelope sets the shape and the timing of the rising and falling edges. It must be called before the
+// modulator is used.
+func (m *ManualModulator) SetEnvelope(envelope Envelope) {
+	m.envelope = envelope
+}
+
+// SetKey sets the state of the key.
+func (m *ManualModulator) SetKey(keyDown bool) {
+	var value int32
+	if keyDown {
+		value = 1
+	}
+	atomic.StoreInt32(&m.keyDown, value)
+}
+
+// KeyDown returns the current state of the key.
+func (m *ManualModulator) KeyDown() bool {
+	return atomic.LoadInt32(&m.keyDown) == 1
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader never ends.
+func (m *ManualModulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, nil)
+}
+
+func (m *ManualModulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if !m.started {
+		m.started = true
+		m.lastT = t
+	}
+	elapsed := t - m.lastT
+	m.lastT = t
+
+	if m.KeyDown() {
+		m.progress = advance(m.progress, elapsed, m.envelope.Rise)
+	} else {
+		m.progress = advance(m.progress, -elapsed, m.envelope.Fall)
+	}
+	return m.envelope.Shape.Amplitude(m.progress), m.pitchFrequency, p
+}
+
+// advance moves the progress of an edge with the given duration by the given time and limits it to [0, 1].
+func advance(progress, elapsed, duration float64) float64 {
+	if duration <= 0 {
+		progress += math.Copysign(1, elapsed)
+	} else {
+		progress += elapsed / duration
+	}
+	return math.Max(0, math.Min(1, progress))
+}
@@ -0,0 +1,56 @@
+package cw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualModulator(t *testing.T) {
+	const sampleRate = 8000
+	m := NewManualModulator(750)
+	window := 7.5 / 750
+	keyEvents := map[int]bool{
+		800:  true,
+		1600: false,
+		2000: true,
+		2100: false,
+	}
+
+	var keyDownSamples int
+	var a, f, p float64
+	for n := 0; n < 4000; n++ {
+		if keyDown, ok := keyEvents[n]; ok {
+			m.SetKey(keyDown)
+		}
+		a, f, p = m.Modulate(float64(n)/sampleRate, a, f, p)
+		assert.Equal(t, 750.0, f)
+		assert.True(t, a >= 0 && a <= 1)
+		if a > 0 {
+			keyDownSamples++
+		}
+
+		switch n {
+		case 799, 3999:
+			assert.Equal(t, 0.0, a, "key up at %d", n)
+		case 800 + int(window*sampleRate), 1599:
+			assert.Equal(t, 1.0, a, "key down at %d", n)
+		}
+	}
+	// not re-timed, the ramps only shift the key up by the window
+	assert.InDelta(t, 900+2*window*sampleRate, keyDownSamples, 2)
+}
+
+func TestManualModulatorDoesNotAllocate(t *testing.T) {
+	m := NewManualModulator(700)
+	m.SetKey(true)
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+		m.SetKey(n%100 < 50)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
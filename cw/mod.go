@@ -1,71 +1,265 @@
 package cw
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"unicode"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
 )
 
 type Modulator struct {
-	symbols chan interface{}
-	closed  chan struct{}
+	symbols    *stream.Stream[item]
+	writes     uint32
+	canceled   uint32
+	cutNumbers uint32
+	pending    pending
+	// sent counts the tokens of the latest write that were taken from the stream.
+	sent stream.Progress
 
 	pitchFrequency float64
-	wpm            int
 	dit            float64
-	window         float64
+	speed          *Speed
+	timing         timing
+	envelope       Envelope
+	dfcwShift      float64
+	frequency      float64
 	symbolStart    float64
 	symbolEnd      float64
 	keyDown        bool
+
+	errLock sync.Mutex
+	err     error
 }
 
+// symbolBufferSize is the number of symbols that can be buffered between Write and Modulate.
+const symbolBufferSize = 128
+
 func NewModulator(frequency float64, wpm int) *Modulator {
+	result := newModulator(frequency, WPMToSeconds(wpm))
+	result.speed.SetWPM(wpm)
+	return result
+}
+
+func newModulator(frequency float64, dit float64) *Modulator {
+	speed := &Speed{}
 	return &Modulator{
-		symbols:        make(chan interface{}, 100),
-		closed:         make(chan struct{}),
+		symbols:        stream.New[item](symbolBufferSize),
 		pitchFrequency: frequency,
-		wpm:            wpm,
-		dit:            WPMToSeconds(wpm),
-		window:         7.5 / frequency,
+		dit:            dit,
+		speed:          speed,
+		timing:         newTiming(speed, dit),
+		envelope:       DefaultEnvelope(frequency),
+		frequency:      frequency,
 	}
 }
 
-var ErrWriteAborted = errors.New("cw: write aborted")
+// bandwidthFactor is the factor K of the ITU formula for the necessary bandwidth of CW, B = K * symbol rate,
+// for a signal on a fading path.
+const bandwidthFactor = 5
 
-type endOfTransmissionToken chan interface{}
+// Bandwidth returns the occupied bandwidth of the signal in Hz. For DFCW it includes the shift.
+func (m *Modulator) Bandwidth() float64 {
+	return bandwidthFactor*m.SymbolRate() + m.dfcwShift
+}
 
-func (m *Modulator) Close() error {
-	select {
-	case <-m.closed:
-	default:
-		close(m.closed)
+// SymbolRate returns the symbol rate of the signal in baud, which is one symbol per dit.
+func (m *Modulator) SymbolRate() float64 {
+	if wpm := m.speed.WPM(); wpm > 0 {
+		return 1 / WPMToSeconds(wpm)
 	}
+	return 1 / m.dit
+}
+
+// SetWPM changes the speed in WpM. The new speed takes effect at the next break between characters, also if
+// symbols are already queued.
+func (m *Modulator) SetWPM(wpm int) {
+	m.speed.SetWPM(wpm)
+}
+
+// SetFarnsworthWPM changes the overall speed in WpM. If it is lower than the speed of the characters, the breaks
+// between characters and words are stretched. 0 turns the Farnsworth timing off. The change takes effect at the
+// next break between characters.
+func (m *Modulator) SetFarnsworthWPM(wpm int) {
+	m.speed.SetFarnsworthWPM(wpm)
+}
+
+// SetCutNumbers defines when numbers are sent as cut numbers, see CutNumbers. The mode applies to the following
+// writes.
+func (m *Modulator) SetCutNumbers(mode CutNumberMode) {
+	atomic.StoreUint32(&m.cutNumbers, uint32(mode))
+}
+
+// Pending returns the number of characters that are queued, but not sent yet, and the estimated duration to send
+// them with the current speed, e.g. to display the transmit buffer or to limit the type-ahead. Spaces count as
+// characters, the character that is currently sent does not count.
+func (m *Modulator) Pending() (chars int, duration time.Duration) {
+	dit, space := m.speed.timing()
+	if dit == 0 {
+		dit, space = m.dit, m.dit
+	}
+	return m.pending.estimate(dit, space)
+}
+
+// pending counts the items that are queued, but not sent yet.
+type pending struct {
+	chars int64
+	// symbolUnits is the weight of the symbols and the breaks within characters, spaceUnits is the weight of the
+	// breaks between characters and words.
+	symbolUnits int64
+	spaceUnits  int64
+	pause       int64
+}
+
+// add adds the given item to the counters with the given sign, 1 when the item is queued, -1 when it is taken.
+func (p *pending) add(i item, sign int64) {
+	if i.character {
+		atomic.AddInt64(&p.chars, sign)
+	}
+	switch {
+	case i.kind == symbolItem && isCharBoundary(i.symbol):
+		atomic.AddInt64(&p.spaceUnits, sign*int64(i.symbol.Weight))
+	case i.kind == symbolItem:
+		atomic.AddInt64(&p.symbolUnits, sign*int64(i.symbol.Weight))
+	case i.kind == commandItem && i.command.kind == pauseCommand:
+		atomic.AddInt64(&p.pause, sign*int64(i.command.value*float64(time.Second)))
+	}
+}
+
+// estimate returns the number of pending characters and their duration for the given durations of a dit and of one
+// unit of the breaks between characters and words in seconds.
+func (p *pending) estimate(dit, space float64) (int, time.Duration) {
+	seconds := float64(atomic.LoadInt64(&p.symbolUnits))*dit + float64(atomic.LoadInt64(&p.spaceUnits))*space
+	duration := time.Duration(seconds*float64(time.Second)) + time.Duration(atomic.LoadInt64(&p.pause))
+	return int(atomic.LoadInt64(&p.chars)), duration
+}
+
+// SetEnvelope sets the shape and the timing of the rising and falling edges. It must be called before the
+// modulator is used.
+func (m *Modulator) SetEnvelope(envelope Envelope) {
+	m.envelope = envelope
+}
+
+var ErrWriteAborted = errors.New("cw: write aborted")
+
+func (m *Modulator) Close() error {
+	m.symbols.Close()
 	return nil
 }
 
+// Err returns the internal error that made the modulator stop, or nil.
+func (m *Modulator) Err() error {
+	m.errLock.Lock()
+	defer m.errLock.Unlock()
+	return m.err
+}
+
+// fail stops the modulator because of the given internal error.
+func (m *Modulator) fail(err error) {
+	m.errLock.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.errLock.Unlock()
+	m.symbols.Close()
+}
+
+func (m *Modulator) abortError() error {
+	if err := m.Err(); err != nil {
+		return err
+	}
+	return ErrWriteAborted
+}
+
 func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
 	go func() {
 		select {
 		case <-done:
 			m.Close()
-		case <-m.closed:
+		case <-m.symbols.Done():
 		}
 	}()
 }
 
+// Write sends the given text. Prosigns can be written in angle brackets, e.g. "<AR>". The text may contain inline
+// commands that change the speed or insert pauses: "^+" and "^-" change the speed by SpeedStep, "<wpm:28>" sets
+// the speed, "<farnsworth:15>" sets the overall speed (0 turns it off) and "<pause:500ms>" pauses for the given
+// duration. Numbers are sent as cut numbers depending on the mode set with SetCutNumbers, "<cut:on>" and
+// "<cut:off>" mark the cut numbers within the text. It returns when the text is sent completely.
 func (m *Modulator) Write(bytes []byte) (int, error) {
-	written := 0
+	return m.WriteContext(context.Background(), bytes)
+}
+
+// WriteContext sends the given text like Write. If the given context is done before the text is sent completely,
+// WriteContext returns the error of the context and the rest of the text is dropped. The modulator stays usable.
+// If the write is canceled or aborted, the returned count contains only the characters, spaces and commands that
+// were actually transmitted, the dropped rest does not count.
+func (m *Modulator) WriteContext(ctx context.Context, bytes []byte) (int, error) {
+	w := writer{m: m, ctx: ctx, write: atomic.AddUint32(&m.writes, 1)}
+	written, tokens, canceled := w.writeText(string(bytes))
+	if canceled || w.waitForEndOfTransmission(tokens) {
+		return w.result()
+	}
+	return written, nil
+}
+
+// Queue queues the given text like Write, but it does not block and does not wait until the text is sent. The
+// returned channel is closed when the text is sent completely. Queue implements audio.Queuer, e.g. to render a
+// transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	w := writer{m: m, ctx: context.Background(), write: atomic.AddUint32(&m.writes, 1), queue: true}
+	_, tokens, canceled := w.writeText(text)
+	eot := make(chan struct{})
+	if canceled || w.send(item{kind: endOfTransmissionItem, token: eot, tokens: tokens}) {
+		return nil, m.abortError()
+	}
+	return eot, nil
+}
+
+// writeText sends the symbols and commands of the given text, followed by a break between words. It returns the
+// number of written tokens, the number of tokens that are not attached to a sent item yet, and true if the write
+// is canceled or aborted.
+func (w writer) writeText(text string) (written int, tokens int, canceled bool) {
+	cutMode := CutNumberMode(atomic.LoadUint32(&w.m.cutNumbers))
+	cut := cutMode == AllCutNumbers
 	wasWhitespace := true
-	canceled := false
-	for _, r := range string(bytes) {
+	for len(text) > 0 {
 		if canceled {
-			return written, ErrWriteAborted
+			return written, tokens, true
 		}
 
-		normalized := unicode.ToLower(r)
-		if unicode.IsSpace(normalized) {
-			if !wasWhitespace {
-				canceled = m.writeSymbol(WordBreak)
+		code, space, cmd, size := nextToken(text)
+		if cutNumber, ok := cutCode(text); ok && cut {
+			code = cutNumber
+		}
+		text = text[size:]
+		if cmd.kind == cutCommand {
+			cut = cutMode != NoCutNumbers && cmd.value != 0
+			written++
+			tokens++
+			continue
+		}
+		if cmd.kind != noCommand {
+			canceled = w.send(item{kind: commandItem, command: cmd, tokens: tokens + 1})
+			if !canceled {
+				written++
+				tokens = 0
+			}
+			continue
+		}
+		if space {
+			if wasWhitespace {
+				tokens++
+			} else {
+				canceled = w.send(item{kind: symbolItem, symbol: WordBreak, character: true, tokens: tokens + 1})
+				tokens = 0
 			}
 
 			if !canceled {
@@ -75,108 +269,248 @@ func (m *Modulator) Write(bytes []byte) (int, error) {
 			continue
 		}
 
-		code, knownCode := Code[normalized]
-		if !knownCode {
+		if code == nil {
 			continue
 		}
 		if !wasWhitespace {
-			canceled = m.writeSymbol(CharBreak)
+			canceled = w.send(item{kind: symbolItem, symbol: CharBreak})
 		}
 		firstSymbol := true
-		for _, s := range code {
+		for i, s := range code {
 			if !firstSymbol {
-				canceled = m.writeSymbol(SymbolBreak)
+				canceled = w.send(item{kind: symbolItem, symbol: SymbolBreak})
+			}
+			next := item{kind: symbolItem, symbol: s, character: i == len(code)-1}
+			if next.character {
+				next.tokens = tokens + 1
 			}
-			canceled = m.writeSymbol(s)
+			canceled = w.send(next)
 			firstSymbol = false
 		}
 
 		if !canceled {
 			written++
+			tokens = 0
 		}
 		wasWhitespace = false
 	}
 
-	if !wasWhitespace && m.writeSymbol(WordBreak) {
-		return written, ErrWriteAborted
+	if canceled || !wasWhitespace && w.send(item{kind: symbolItem, symbol: WordBreak}) {
+		return written, tokens, true
 	}
-	if m.waitForEndOfTransmission() {
-		return written, ErrWriteAborted
+	return written, tokens, false
+}
+
+// WriteProsign sends the given prosign, e.g. "AR", as a single character. It returns when the prosign is sent.
+func (m *Modulator) WriteProsign(prosign string) error {
+	if _, ok := ProsignCode(prosign); !ok {
+		return ErrUnknownProsign
 	}
-	return written, nil
+	_, err := m.Write([]byte("<" + prosign + ">"))
+	return err
 }
 
-func (m *Modulator) writeSymbol(symbol Symbol) bool {
-	select {
-	case m.symbols <- symbol:
-		return false
-	case <-m.closed:
+// remainingWordBreak completes a CharBreak to a WordBreak.
+var remainingWordBreak = Symbol{WordBreak.Weight - CharBreak.Weight, false}
+
+// WriteCharacter sends a single character, followed by the break between characters. A space completes the
+// preceding break to the break between words. A character with more than one letter is sent as prosign, e.g. "AR".
+// Unlike Write, WriteCharacter does not end the transmission with a break between words, which makes it suitable
+// for keyers that send the characters one by one as they are typed. It returns when the character is sent.
+func (m *Modulator) WriteCharacter(ctx context.Context, character string) error {
+	w := writer{m: m, ctx: ctx, write: atomic.AddUint32(&m.writes, 1)}
+	var code []Symbol
+	switch {
+	case strings.TrimSpace(character) == "" && character != "":
+		code = []Symbol{remainingWordBreak}
+	case utf8.RuneCountInString(character) == 1:
+		r, _ := utf8.DecodeRuneInString(strings.ToLower(character))
+		var ok bool
+		code, ok = Code[r]
+		if !ok {
+			return nil
+		}
+	default:
+		var ok bool
+		code, ok = ProsignCode(character)
+		if !ok {
+			return ErrUnknownProsign
+		}
+	}
+
+	for i, s := range code {
+		if i > 0 && w.send(item{kind: symbolItem, symbol: SymbolBreak}) {
+			return w.err()
+		}
+		if w.send(item{kind: symbolItem, symbol: s, character: i == len(code)-1}) {
+			return w.err()
+		}
+	}
+	if code[0].KeyDown && w.send(item{kind: symbolItem, symbol: CharBreak}) {
+		return w.err()
+	}
+	if w.waitForEndOfTransmission(0) {
+		return w.err()
+	}
+	return nil
+}
+
+// writer sends the items of one write to the modulator.
+type writer struct {
+	m     *Modulator
+	ctx   context.Context
+	write uint32
+	// queue makes the writer push the items into the stream without blocking.
+	queue bool
+}
+
+// send sends the given item. It returns true if the write is canceled or aborted.
+func (w writer) send(i item) bool {
+	if w.ctx.Err() != nil {
 		return true
 	}
+	i.write = w.write
+	w.m.pending.add(i, 1)
+	var err error
+	if w.queue {
+		err = w.m.symbols.Push(i)
+	} else {
+		err = w.m.symbols.Send(w.ctx, i)
+	}
+	if err != nil {
+		w.m.pending.add(i, -1)
+		return true
+	}
+	return false
 }
 
-func (m *Modulator) waitForEndOfTransmission() bool {
-	eot := make(endOfTransmissionToken)
-	select {
-	case m.symbols <- eot:
-	case <-m.closed:
+// waitForEndOfTransmission waits until all items of the write are sent. The given number of written tokens is
+// attached to the end of the transmission. It returns true if the write is canceled or aborted.
+func (w writer) waitForEndOfTransmission(tokens int) bool {
+	eot := make(chan struct{})
+	if w.send(item{kind: endOfTransmissionItem, token: eot, tokens: tokens}) {
 		return true
 	}
 	select {
 	case <-eot:
 		return false
-	case <-m.closed:
+	case <-w.m.symbols.Done():
+		return true
+	case <-w.ctx.Done():
 		return true
 	}
 }
 
-func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
-	var delta float64
-	switch {
-	case m.symbolEnd-t <= m.window:
-		delta = m.symbolEnd - t
-	case t-m.symbolStart <= m.window:
-		delta = t - m.symbolStart
-	default:
-		delta = m.window
+// result returns the number of tokens that were transmitted and the error of a canceled or aborted write.
+func (w writer) result() (int, error) {
+	err := w.err()
+	return w.m.sent.Count(w.write), err
+}
+
+// err returns the error of a canceled or aborted write. If the context is done, the rest of the write is dropped.
+func (w writer) err() error {
+	if err := w.ctx.Err(); err != nil {
+		w.m.cancel(w.write)
+		return err
 	}
+	return w.m.abortError()
+}
+
+// cancel makes the modulator drop the items of the given write and of all writes before.
+func (m *Modulator) cancel(write uint32) {
+	for {
+		canceled := atomic.LoadUint32(&m.canceled)
+		if write <= canceled || atomic.CompareAndSwapUint32(&m.canceled, canceled, write) {
+			return
+		}
+	}
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.symbols.Done())
+}
+
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
 	if m.keyDown {
-		amplitude = delta / m.window
+		amplitude = m.envelope.amplitude(t-m.symbolStart, m.symbolEnd-t)
 	} else {
 		amplitude = 0
 	}
 
 	if m.symbolEnd > t {
-		return amplitude, m.pitchFrequency, p
+		return amplitude, m.frequency, p
+	}
+	nextEnd, keyDown, canceled, err := m.nextAction(t)
+	if err != nil {
+		m.fail(err)
+		m.keyDown = false
+		return 0, m.frequency, p
 	}
-	nextEnd, keyDown, canceled := m.nextAction(t)
 	if canceled {
-		return 0, m.pitchFrequency, p
+		return 0, m.frequency, p
 	}
 
 	m.symbolStart = t
 	m.symbolEnd = nextEnd
 	m.keyDown = keyDown
 
-	return amplitude, m.pitchFrequency, p
+	return amplitude, m.frequency, p
 }
 
-func (m *Modulator) nextAction(now float64) (float64, bool, bool) {
-	select {
-	case raw := <-m.symbols:
-		switch symbol := raw.(type) {
-		case Symbol:
-			duration := float64(symbol.Weight) * m.dit
-			return now + duration, symbol.KeyDown, false
-		case endOfTransmissionToken:
-			close(symbol)
-			return now + 0.000001, false, false
-		default:
-			panic(fmt.Errorf("unknown token/symbol type %T", raw))
-		}
-	case <-m.closed:
-		return now, false, true
+func (m *Modulator) nextAction(now float64) (float64, bool, bool, error) {
+	if m.symbols.Closed() {
+		return now, false, true, nil
+	}
+	next, ok := m.symbols.TryReceive()
+	for ok && next.write != 0 && next.write <= atomic.LoadUint32(&m.canceled) {
+		m.pending.add(next, -1)
+		if next.token != nil {
+			close(next.token)
+		}
+		next, ok = m.symbols.TryReceive()
+	}
+	if !ok {
+		return now + 0.000001, false, false, nil
+	}
+	m.pending.add(next, -1)
+	m.sent.Add(next.write, next.tokens)
+	switch next.kind {
+	case symbolItem:
+		symbol := next.symbol
+		m.frequency = m.pitchFrequency
+		if m.dfcwShift != 0 && symbol == Da {
+			// DFCW sends das with the length of dits on a higher frequency
+			symbol = Dit
+			m.frequency = m.pitchFrequency + m.dfcwShift
+		}
+		duration := m.timing.duration(symbol)
+		return now + duration, next.symbol.KeyDown, false, nil
+	case commandItem:
+		return m.execute(now, next.command), false, false, nil
+	case endOfTransmissionItem:
+		close(next.token)
+		return now + 0.000001, false, false, nil
 	default:
-		return now + 0.000001, false, false
+		return now, false, true, fmt.Errorf("cw: unknown item kind %d", next.kind)
+	}
+}
+
+// execute executes the given inline command and returns the end of its execution. Speed changes take effect at the
+// next break between characters.
+func (m *Modulator) execute(now float64, cmd command) float64 {
+	switch cmd.kind {
+	case wpmCommand:
+		m.speed.SetWPM(int(cmd.value))
+	case wpmStepCommand:
+		if wpm := m.speed.WPM(); wpm > 0 && wpm+int(cmd.value) > 0 {
+			m.speed.SetWPM(wpm + int(cmd.value))
+		}
+	case farnsworthCommand:
+		m.speed.SetFarnsworthWPM(int(cmd.value))
+	case pauseCommand:
+		return now + cmd.value
 	}
+	return now + 0.000001
 }
@@ -0,0 +1,24 @@
+package cw
+
+// The dit durations of the common QRSS modes in seconds.
+const (
+	QRSS3  = 3.0
+	QRSS10 = 10.0
+	QRSS60 = 60.0
+)
+
+// DefaultDFCWShift is the common distance between the dit and the da frequency of DFCW in Hz.
+const DefaultDFCWShift = 5.0
+
+// NewQRSSModulator returns a new Modulator for ultra-slow CW with the given dit duration in seconds, e.g. QRSS10.
+func NewQRSSModulator(frequency float64, dit float64) *Modulator {
+	return newModulator(frequency, dit)
+}
+
+// NewDFCWModulator returns a new Modulator for dual frequency CW with the given dit duration in seconds. Dits and
+// das have the same length, the das are sent on the frequency shifted by the given amount in Hz.
+func NewDFCWModulator(frequency float64, dit float64, shift float64) *Modulator {
+	result := newModulator(frequency, dit)
+	result.dfcwShift = shift
+	return result
+}
@@ -0,0 +1,50 @@
+package cw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQRSSAndDFCW(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		m        *Modulator
+		expected map[float64]float64
+	}{
+		{
+			desc:     "QRSS3",
+			m:        NewQRSSModulator(1000, QRSS3),
+			expected: map[float64]float64{1000: 4 * QRSS3},
+		},
+		{
+			desc:     "DFCW3",
+			m:        NewDFCWModulator(1000, QRSS3, DefaultDFCWShift),
+			expected: map[float64]float64{1000: QRSS3, 1000 + DefaultDFCWShift: QRSS3},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			// "et"
+			for _, s := range []Symbol{Dit, CharBreak, Da, WordBreak} {
+				tC.m.symbols.Send(context.Background(), item{kind: symbolItem, symbol: s})
+			}
+
+			const step = 0.001
+			keyDown := make(map[float64]float64)
+			var a, f, p float64
+			for i := 0; i < 30000; i++ {
+				a, f, p = tC.m.Modulate(float64(i)*step, a, f, p)
+				if a > 0 {
+					keyDown[f] += step
+				}
+			}
+
+			assert.Equal(t, len(tC.expected), len(keyDown))
+			for frequency, duration := range tC.expected {
+				assert.InDelta(t, duration, keyDown[frequency], 0.01, "%v Hz", frequency)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package cw
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+)
+
+const (
+	// recordQueueSize is the number of records that are buffered by a RecordDecoder.
+	recordQueueSize = 16
+	// maxRecordLength is the maximum length of the text of a record, longer texts are split.
+	maxRecordLength = 80
+	// recordTimeout is the time without a decoded character after which a record ends.
+	recordTimeout = 3 * time.Second
+	// recordResolution is the resolution of the time of the records.
+	recordResolution = 10 * time.Millisecond
+)
+
+// RecordDecoder decodes a CW signal around a fixed audio frequency with a Demodulator and delivers the received
+// text as decode records. A record ends when no character was decoded for three seconds, or after maxRecordLength
+// characters. Its time is the time when the first character was decoded. It implements digimodes.Decoder.
+type RecordDecoder struct {
+	*digimodes.RecordQueue
+
+	demodulator *Demodulator
+	clock       *digimodes.SampleClock
+	chunk       int
+
+	text     strings.Builder
+	record   digimodes.DecodeRecord
+	received bool
+	last     time.Time
+}
+
+// NewRecordDecoder returns a new RecordDecoder for a signal at the given audio frequency and sample rate. The
+// decoder starts with the given speed in WpM. The first sample that is fed into the decoder was taken at the given
+// start time.
+func NewRecordDecoder(frequency float64, sampleRate int, wpm int, start time.Time) *RecordDecoder {
+	chunk := int(time.Duration(sampleRate) * recordResolution / time.Second)
+	if chunk < 1 {
+		chunk = 1
+	}
+	result := &RecordDecoder{
+		RecordQueue: digimodes.NewRecordQueue(recordQueueSize),
+		clock:       digimodes.NewSampleClock(start, sampleRate),
+		chunk:       chunk,
+	}
+	result.demodulator = NewDemodulator(frequency, sampleRate, wpm, result.receive)
+	return result
+}
+
+// Demodulator returns the demodulator of this decoder.
+func (d *RecordDecoder) Demodulator() *Demodulator {
+	return d.demodulator
+}
+
+// Feed decodes the given samples.
+func (d *RecordDecoder) Feed(ctx context.Context, samples []float64) error {
+	if d.Closed() {
+		return digimodes.ErrDecoderClosed
+	}
+	for len(samples) > 0 {
+		n := d.chunk
+		if n > len(samples) {
+			n = len(samples)
+		}
+		d.received = false
+		d.demodulator.WriteSamples(samples[:n])
+		d.clock.Advance(n)
+		samples = samples[n:]
+
+		if d.received {
+			// the metrics describe the signal while the characters are received
+			metrics := d.demodulator.Metrics()
+			d.record.AudioFrequency = metrics.Frequency
+			d.record.SNR = metrics.SNR
+			d.last = d.clock.Now()
+		}
+		if d.text.Len() == 0 {
+			continue
+		}
+		if d.text.Len() < maxRecordLength && d.clock.Now().Sub(d.last) < recordTimeout {
+			continue
+		}
+		text := strings.TrimSpace(d.text.String())
+		d.text.Reset()
+		if text == "" {
+			continue
+		}
+		d.record.Text = text
+		if err := d.Emit(ctx, d.record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receive is the handler of the demodulator, it is called while the demodulator is locked.
+func (d *RecordDecoder) receive(c rune) {
+	if d.text.Len() == 0 {
+		if c == ' ' {
+			return
+		}
+		d.record = digimodes.DecodeRecord{
+			Time: d.clock.Now(),
+			Mode: "cw",
+		}
+	}
+	d.text.WriteRune(c)
+	d.received = true
+}
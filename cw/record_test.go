@@ -0,0 +1,55 @@
+package cw
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+func TestRecordDecoder(t *testing.T) {
+	const sampleRate = 8000
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	samples := make([]float64, sampleRate)
+	samples = append(samples, modulate(t, "cq cq de dl1abc k", 700, 25, sampleRate)...)
+	samples = append(samples, make([]float64, 3*sampleRate)...)
+	samples = append(samples, modulate(t, "dl1abc de w1aw", 700, 25, sampleRate)...)
+	samples = append(samples, make([]float64, 3*sampleRate)...)
+	rng := rand.New(rand.NewSource(1))
+	for i := range samples {
+		samples[i] = 0.5*samples[i] + 0.05*rng.NormFloat64()
+	}
+
+	decoder := NewRecordDecoder(700, sampleRate, 20, start)
+	var _ digimodes.Decoder = decoder
+	for i := 0; i < len(samples); i += 512 {
+		end := i + 512
+		if end > len(samples) {
+			end = len(samples)
+		}
+		require.NoError(t, decoder.Feed(context.Background(), samples[i:end]))
+	}
+	require.NoError(t, decoder.Close())
+
+	records := make([]digimodes.DecodeRecord, 0)
+	for record := range decoder.Records() {
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Contains(t, records[0].Text, "de dl1abc k")
+	assert.Equal(t, "dl1abc de w1aw", records[1].Text)
+	for _, record := range records {
+		assert.Equal(t, "cw", record.Mode)
+		assert.Equal(t, 700.0, record.AudioFrequency)
+		assert.Greater(t, record.SNR, 10.0)
+	}
+	assert.True(t, records[0].Time.After(start.Add(time.Second)))
+	assert.True(t, records[1].Time.After(records[0].Time.Add(3*time.Second)))
+
+	assert.Equal(t, digimodes.ErrDecoderClosed, decoder.Feed(context.Background(), samples))
+}
@@ -0,0 +1,56 @@
+package cw
+
+import "math"
+
+// Shape is the shape of the rising and falling edges of a CW signal. Softer shapes reduce key clicks, a linear
+// ramp sounds crisper.
+type Shape int
+
+// The supported shapes.
+const (
+	Linear Shape = iota
+	RaisedCosine
+	Blackman
+)
+
+// Amplitude returns the amplitude at the given progress of an edge, from 0 (key up) to 1 (key down).
+func (s Shape) Amplitude(progress float64) float64 {
+	x := math.Max(0, math.Min(1, progress))
+	switch s {
+	case RaisedCosine:
+		return 0.5 - 0.5*math.Cos(math.Pi*x)
+	case Blackman:
+		return 0.42 - 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+	default:
+		return x
+	}
+}
+
+// Envelope describes the rising and falling edges of a CW signal. The rise and fall times are in seconds.
+type Envelope struct {
+	Shape Shape
+	Rise  float64
+	Fall  float64
+}
+
+// DefaultEnvelope returns the default envelope for the given pitch in Hz: linear edges of 7.5 periods of the pitch.
+func DefaultEnvelope(pitch float64) Envelope {
+	return Envelope{
+		Shape: Linear,
+		Rise:  7.5 / pitch,
+		Fall:  7.5 / pitch,
+	}
+}
+
+// amplitude returns the amplitude of a key down interval that started rising before the given time and ends
+// falling after the given time, both in seconds.
+func (e Envelope) amplitude(sinceStart, untilEnd float64) float64 {
+	result := 1.0
+	if sinceStart < e.Rise {
+		result = e.Shape.Amplitude(sinceStart / e.Rise)
+	}
+	if untilEnd < e.Fall {
+		result = math.Min(result, e.Shape.Amplitude(untilEnd/e.Fall))
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package cw
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeAmplitude(t *testing.T) {
+	for _, shape := range []Shape{Linear, RaisedCosine, Blackman} {
+		t.Run(fmt.Sprintf("%d", shape), func(t *testing.T) {
+			assert.InDelta(t, 0, shape.Amplitude(-1), 1e-9)
+			assert.InDelta(t, 0, shape.Amplitude(0), 1e-9)
+			assert.InDelta(t, 1, shape.Amplitude(1), 1e-9)
+			assert.InDelta(t, 1, shape.Amplitude(2), 1e-9)
+
+			last := 0.0
+			for i := 1; i <= 100; i++ {
+				value := shape.Amplitude(float64(i) / 100)
+				assert.True(t, value >= last, "%d", i)
+				last = value
+			}
+		})
+	}
+	assert.InDelta(t, 0.5, Linear.Amplitude(0.5), 1e-9)
+	assert.InDelta(t, 0.5, RaisedCosine.Amplitude(0.5), 1e-9)
+	assert.True(t, Blackman.Amplitude(0.1) < RaisedCosine.Amplitude(0.1))
+	assert.True(t, RaisedCosine.Amplitude(0.1) < Linear.Amplitude(0.1))
+}
+
+func TestEnvelopeAmplitude(t *testing.T) {
+	envelope := Envelope{Shape: Linear, Rise: 0.01, Fall: 0.02}
+
+	assert.InDelta(t, 0, envelope.amplitude(0, 1), 1e-9)
+	assert.InDelta(t, 0.5, envelope.amplitude(0.005, 1), 1e-9)
+	assert.InDelta(t, 1, envelope.amplitude(0.5, 0.5), 1e-9)
+	assert.InDelta(t, 0.5, envelope.amplitude(1, 0.01), 1e-9)
+	assert.InDelta(t, 0.25, envelope.amplitude(0.0025, 0.01), 1e-9)
+
+	hard := Envelope{Shape: Linear}
+	assert.InDelta(t, 1, hard.amplitude(0, 0.1), 1e-9)
+}
+
+func TestManualModulatorEnvelope(t *testing.T) {
+	m := NewManualModulator(750)
+	m.SetEnvelope(Envelope{Shape: RaisedCosine, Rise: 0.004, Fall: 0.002})
+
+	m.SetKey(true)
+	m.Modulate(0, 0, 0, 0)
+	a, _, _ := m.Modulate(0.002, 0, 0, 0)
+	assert.InDelta(t, 0.5, a, 1e-9)
+	a, _, _ = m.Modulate(0.004, 0, 0, 0)
+	assert.InDelta(t, 1, a, 1e-9)
+
+	m.SetKey(false)
+	a, _, _ = m.Modulate(0.005, 0, 0, 0)
+	assert.InDelta(t, 0.5, a, 1e-9)
+	a, _, _ = m.Modulate(0.006, 0, 0, 0)
+	assert.InDelta(t, 0, a, 1e-9)
+}
@@ -0,0 +1,128 @@
+package cw
+
+import (
+	"io"
+	"sync"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// Sidetone generates the local audio feedback of a Keyer or a straight key, independent of the transmit chain. The
+// elements of the keyer are played as soon as they are generated, at the configured pitch and volume. The sidetone
+// implements audio.Modulator, it should be rendered with a small buffer to keep the latency low. All methods may be
+// called concurrently to Modulate.
+type Sidetone struct {
+	mu       sync.Mutex
+	pitch    float64
+	volume   float64
+	envelope Envelope
+
+	segments  []sidetoneSegment
+	remaining float64
+	keyed     bool
+	manual    bool
+
+	started  bool
+	lastT    float64
+	progress float64
+}
+
+// sidetoneSegment is a key down or key up interval with the given duration in seconds.
+type sidetoneSegment struct {
+	keyDown  bool
+	duration float64
+}
+
+// NewSidetone returns a new Sidetone with the given pitch in Hz and volume in the range [0.0, 1.0].
+func NewSidetone(pitch float64, volume float64) *Sidetone {
+	return &Sidetone{
+		pitch:    pitch,
+		volume:   volume,
+		envelope: DefaultEnvelope(pitch),
+	}
+}
+
+// Pitch returns the frequency of the sidetone in Hz.
+func (s *Sidetone) Pitch() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pitch
+}
+
+// SetPitch sets the frequency of the sidetone in Hz.
+func (s *Sidetone) SetPitch(pitch float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pitch = pitch
+}
+
+// Volume returns the volume of the sidetone in the range [0.0, 1.0].
+func (s *Sidetone) Volume() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.volume
+}
+
+// SetVolume sets the volume of the sidetone in the range [0.0, 1.0].
+func (s *Sidetone) SetVolume(volume float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volume = volume
+}
+
+// SetEnvelope sets the shape and the timing of the rising and falling edges.
+func (s *Sidetone) SetEnvelope(envelope Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.envelope = envelope
+}
+
+// SetKey sets the state of a straight key, the sidetone sounds while the key is down.
+func (s *Sidetone) SetKey(keyDown bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manual = keyDown
+}
+
+// play appends the given symbol with the given duration of a dit in seconds to the played elements.
+func (s *Sidetone) play(symbol Symbol, dit float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.segments = append(s.segments, sidetoneSegment{keyDown: symbol.KeyDown, duration: float64(symbol.Weight) * dit})
+}
+
+// SamplesReader returns a reader that renders the sidetone as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate. The reader never ends.
+func (s *Sidetone) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(s, sampleRate, audio.Int16, nil)
+}
+
+func (s *Sidetone) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	elapsed := t - s.lastT
+	s.lastT = t
+
+	s.remaining -= elapsed
+	for s.remaining <= 0 && len(s.segments) > 0 {
+		s.keyed = s.segments[0].keyDown
+		s.remaining += s.segments[0].duration
+		s.segments = s.segments[1:]
+	}
+	if s.remaining <= 0 {
+		// idle, the next element starts immediately
+		s.keyed = false
+		s.remaining = 0
+	}
+
+	if s.keyed || s.manual {
+		s.progress = advance(s.progress, elapsed, s.envelope.Rise)
+	} else {
+		s.progress = advance(s.progress, -elapsed, s.envelope.Fall)
+	}
+	return s.volume * s.envelope.Shape.Amplitude(s.progress), s.pitch, p
+}
@@ -0,0 +1,113 @@
+package cw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// keyedIntervals returns the start and end times of the intervals where the envelope of the given samples is above
+// half of the given level.
+func keyedIntervals(samples []float64, sampleRate int, level float64) [][2]float64 {
+	var result [][2]float64
+	window := sampleRate / 500
+	keyed := false
+	for i := 0; i+window < len(samples); i++ {
+		peak := 0.0
+		for _, x := range samples[i : i+window] {
+			peak = math.Max(peak, math.Abs(x))
+		}
+		t := float64(i) / float64(sampleRate)
+		switch {
+		case !keyed && peak > level/2:
+			keyed = true
+			result = append(result, [2]float64{t, 0})
+		case keyed && peak < level/2:
+			keyed = false
+			result[len(result)-1][1] = t
+		}
+	}
+	return result
+}
+
+func TestSidetoneKeyer(t *testing.T) {
+	const dit = 0.06 // 20 WpM
+	const sampleRate = 8000
+	sidetone := NewSidetone(700, 0.5)
+	keyer := NewKeyer(IambicA, 20, func(Symbol) {})
+	keyer.SetSidetone(sidetone)
+	renderer := audio.NewRenderer(sidetone, sampleRate)
+
+	samples := make([]float64, sampleRate)
+	keyer.SetPaddle(DahPaddle, true, 0)
+	renderer.Render(samples[:sampleRate/10])
+	keyer.SetPaddle(DahPaddle, false, 0.1)
+	keyer.SetPaddle(DitPaddle, true, 0.1)
+	keyer.Tick(4 * dit)
+	keyer.SetPaddle(DitPaddle, false, 4*dit)
+	keyer.Tick(6 * dit)
+	renderer.Render(samples[sampleRate/10:])
+
+	intervals := keyedIntervals(samples, sampleRate, 0.5)
+	if assert.Len(t, intervals, 2) {
+		assert.InDelta(t, 0, intervals[0][0], 0.005)
+		assert.InDelta(t, 3*dit, intervals[0][1], 0.01, "da")
+		assert.InDelta(t, 4*dit, intervals[1][0], 0.005)
+		assert.InDelta(t, 5*dit, intervals[1][1], 0.01, "dit")
+	}
+	for _, x := range samples {
+		assert.LessOrEqual(t, math.Abs(x), 0.5)
+	}
+}
+
+func TestSidetoneStartsImmediately(t *testing.T) {
+	const sampleRate = 8000
+	sidetone := NewSidetone(600, 1)
+	renderer := audio.NewRenderer(sidetone, sampleRate)
+	idle := make([]float64, sampleRate)
+	renderer.Render(idle)
+	for _, x := range idle {
+		assert.Equal(t, 0.0, x)
+	}
+
+	// the first element after a pause starts with the next sample, not at the time of the keyer
+	sidetone.play(Dit, 0.05)
+	samples := make([]float64, sampleRate/10)
+	renderer.Render(samples)
+	intervals := keyedIntervals(samples, sampleRate, 1)
+	if assert.Len(t, intervals, 1) {
+		assert.InDelta(t, 0, intervals[0][0], 0.005)
+		assert.InDelta(t, 0.05, intervals[0][1], 0.01)
+	}
+}
+
+func TestSidetoneSettings(t *testing.T) {
+	const sampleRate = 8000
+	sidetone := NewSidetone(600, 0.5)
+	assert.Equal(t, 600.0, sidetone.Pitch())
+	assert.Equal(t, 0.5, sidetone.Volume())
+
+	sidetone.SetPitch(800)
+	sidetone.SetVolume(0.25)
+	sidetone.SetKey(true)
+	samples := make([]float64, sampleRate/10)
+	audio.NewRenderer(sidetone, sampleRate).Render(samples)
+
+	crossings := 0
+	peak := 0.0
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1] < 0 && samples[i] >= 0 {
+			crossings++
+		}
+		peak = math.Max(peak, math.Abs(samples[i]))
+	}
+	assert.InDelta(t, 80, crossings, 1, "800Hz")
+	assert.InDelta(t, 0.25, peak, 0.02)
+
+	sidetone.SetKey(false)
+	audio.NewRenderer(sidetone, sampleRate).Render(samples)
+	assert.Equal(t, 0.0, samples[len(samples)-1])
+}
@@ -0,0 +1,138 @@
+package cw
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// parisWeight is the weight of the word "PARIS " that defines the speed in WpM, parisSpaceWeight is the part of
+// it that falls on the character and word breaks.
+const (
+	parisWeight      = 50
+	parisSpaceWeight = 19
+)
+
+// FarnsworthSpaceSeconds returns the duration of one unit of the breaks between characters and words in seconds
+// for characters sent with the given speed in WpM and an overall speed of the given Farnsworth speed in WpM. If the
+// Farnsworth speed is not lower than the character speed, the breaks are not stretched.
+func FarnsworthSpaceSeconds(wpm int, farnsworthWPM int) float64 {
+	dit := WPMToSeconds(wpm)
+	if farnsworthWPM <= 0 || farnsworthWPM >= wpm {
+		return dit
+	}
+	wordTime := 60 / float64(farnsworthWPM)
+	return (wordTime - float64(parisWeight-parisSpaceWeight)*dit) / parisSpaceWeight
+}
+
+// Speed is the speed of a CW transmission that can be changed while the transmission is running. The changes are
+// picked up at the next character boundary. Speed may be used concurrently.
+type Speed struct {
+	version uint32
+
+	lock          sync.Mutex
+	wpm           int
+	farnsworthWPM int
+}
+
+// NewSpeed returns a new Speed with the given speed in WpM.
+func NewSpeed(wpm int) *Speed {
+	result := &Speed{}
+	result.SetWPM(wpm)
+	return result
+}
+
+// SetWPM sets the character speed in WpM.
+func (s *Speed) SetWPM(wpm int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.wpm = wpm
+	atomic.AddUint32(&s.version, 1)
+}
+
+// SetFarnsworthWPM sets the overall speed in WpM. If it is lower than the character speed, the breaks between
+// characters and words are stretched to reach the overall speed. 0 turns the Farnsworth timing off.
+func (s *Speed) SetFarnsworthWPM(wpm int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.farnsworthWPM = wpm
+	atomic.AddUint32(&s.version, 1)
+}
+
+// WPM returns the character speed in WpM.
+func (s *Speed) WPM() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.wpm
+}
+
+// FarnsworthWPM returns the overall speed in WpM, or 0 if the Farnsworth timing is off.
+func (s *Speed) FarnsworthWPM() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.farnsworthWPM
+}
+
+// changed returns the current version of the speed and if it differs from the given version.
+func (s *Speed) changed(version uint32) (uint32, bool) {
+	current := atomic.LoadUint32(&s.version)
+	return current, current != version
+}
+
+// timing returns the duration of a dit and of one unit of the breaks between characters and words in seconds.
+func (s *Speed) timing() (dit float64, space float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.wpm <= 0 {
+		return 0, 0
+	}
+	return WPMToSeconds(s.wpm), FarnsworthSpaceSeconds(s.wpm, s.farnsworthWPM)
+}
+
+// isCharBoundary indicates if a speed change may be applied before the given symbol.
+func isCharBoundary(symbol Symbol) bool {
+	return !symbol.KeyDown && symbol.Weight >= CharBreak.Weight
+}
+
+// timing tracks the durations of the symbols while the speed changes.
+type timing struct {
+	speed      *Speed
+	version    uint32
+	atBoundary bool
+	dit        float64
+	space      float64
+}
+
+func newTiming(speed *Speed, dit float64) timing {
+	return timing{
+		speed:      speed,
+		atBoundary: true,
+		dit:        dit,
+		space:      dit,
+	}
+}
+
+// duration returns the duration of the given symbol in seconds. Speed changes are applied at the breaks between
+// characters and words.
+func (t *timing) duration(symbol Symbol) float64 {
+	boundary := isCharBoundary(symbol)
+	if t.atBoundary || boundary {
+		t.update()
+	}
+	t.atBoundary = boundary
+	if boundary {
+		return float64(symbol.Weight) * t.space
+	}
+	return float64(symbol.Weight) * t.dit
+}
+
+func (t *timing) update() {
+	version, changed := t.speed.changed(t.version)
+	if !changed {
+		return
+	}
+	t.version = version
+	if dit, space := t.speed.timing(); dit > 0 {
+		t.dit = dit
+		t.space = space
+	}
+}
@@ -0,0 +1,66 @@
+package cw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFarnsworthSpaceSeconds(t *testing.T) {
+	assert.Equal(t, WPMToSeconds(20), FarnsworthSpaceSeconds(20, 0))
+	assert.Equal(t, WPMToSeconds(20), FarnsworthSpaceSeconds(20, 20))
+	assert.Equal(t, WPMToSeconds(20), FarnsworthSpaceSeconds(20, 25))
+	assert.InDelta(t, (6-31*0.06)/19, FarnsworthSpaceSeconds(20, 10), 1e-9)
+
+	// one "PARIS " takes exactly one minute at the overall speed
+	wordTime := 31*WPMToSeconds(18) + 19*FarnsworthSpaceSeconds(18, 5)
+	assert.InDelta(t, 60.0/5, wordTime, 1e-9)
+}
+
+func TestTimingChangesAtCharBoundary(t *testing.T) {
+	speed := NewSpeed(20)
+	timing := newTiming(speed, 0)
+
+	assert.InDelta(t, 0.06, timing.duration(Dit), 1e-9)
+	speed.SetWPM(40)
+	assert.InDelta(t, 0.06, timing.duration(SymbolBreak), 1e-9)
+	assert.InDelta(t, 0.18, timing.duration(Da), 1e-9)
+	assert.InDelta(t, 0.09, timing.duration(CharBreak), 1e-9)
+	assert.InDelta(t, 0.03, timing.duration(Dit), 1e-9)
+
+	speed.SetFarnsworthWPM(20)
+	assert.InDelta(t, 0.03, timing.duration(SymbolBreak), 1e-9)
+	assert.InDelta(t, 7*FarnsworthSpaceSeconds(40, 20), timing.duration(WordBreak), 1e-9)
+	assert.InDelta(t, 0.03, timing.duration(Dit), 1e-9)
+}
+
+func TestModulatorSetWPM(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+	for _, symbol := range []Symbol{Dit, SymbolBreak, Dit, CharBreak, Dit, WordBreak} {
+		m.symbols.Send(context.Background(), item{kind: symbolItem, symbol: symbol})
+	}
+
+	const sampleRate = 8000.0
+	var keyDowns []float64
+	keyDown := 0
+	for n := 0; n < int(sampleRate); n++ {
+		m.Modulate(float64(n)/sampleRate, 0, 0, 0)
+		if n == 1 {
+			m.SetWPM(40)
+		}
+		if m.keyDown {
+			keyDown++
+		} else if keyDown > 0 {
+			keyDowns = append(keyDowns, float64(keyDown)/sampleRate)
+			keyDown = 0
+		}
+	}
+
+	if assert.Equal(t, 3, len(keyDowns)) {
+		assert.InDelta(t, 0.06, keyDowns[0], 0.001)
+		assert.InDelta(t, 0.06, keyDowns[1], 0.001)
+		assert.InDelta(t, 0.03, keyDowns[2], 0.001)
+	}
+}
@@ -0,0 +1,185 @@
+package cw
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnknownProsign is returned when writing a prosign that cannot be encoded.
+var ErrUnknownProsign = errors.New("cw: unknown prosign")
+
+// Prosigns contains the codes of the common procedural signs. A prosign is sent as a single character without
+// the breaks between its letters. In text, prosigns are written in angle brackets, e.g. "<AR>".
+var Prosigns = map[string][]Symbol{
+	"AR":  {Dit, Da, Dit, Da, Dit},
+	"AS":  {Dit, Da, Dit, Dit, Dit},
+	"BK":  {Da, Dit, Dit, Dit, Da, Dit, Da},
+	"BT":  {Da, Dit, Dit, Dit, Da},
+	"CT":  {Da, Dit, Da, Dit, Da},
+	"HH":  {Dit, Dit, Dit, Dit, Dit, Dit, Dit, Dit},
+	"KA":  {Da, Dit, Da, Dit, Da},
+	"KN":  {Da, Dit, Da, Da, Dit},
+	"SK":  {Dit, Dit, Dit, Da, Dit, Da},
+	"SN":  {Dit, Dit, Dit, Da, Dit},
+	"SOS": {Dit, Dit, Dit, Da, Da, Da, Dit, Dit, Dit},
+	"VE":  {Dit, Dit, Dit, Da, Dit},
+}
+
+// ProsignCode returns the code of the given prosign. Prosigns that are not contained in Prosigns are built by
+// joining the codes of their characters.
+func ProsignCode(prosign string) ([]Symbol, bool) {
+	prosign = strings.ToUpper(prosign)
+	if code, ok := Prosigns[prosign]; ok {
+		return code, true
+	}
+	if prosign == "" {
+		return nil, false
+	}
+	var result []Symbol
+	for _, r := range strings.ToLower(prosign) {
+		code, ok := Code[r]
+		if !ok || unicode.IsSpace(r) {
+			return nil, false
+		}
+		result = append(result, code...)
+	}
+	return result, true
+}
+
+// CutNumbers contains the letters that are sent instead of the digits when numbers are sent as cut numbers, e.g.
+// "5NN" instead of "599". Further abbreviations can be added, e.g. '5': 'e'.
+var CutNumbers = map[rune]rune{
+	'0': 't',
+	'1': 'a',
+	'9': 'n',
+}
+
+// CutNumberMode defines when the Modulator sends numbers as cut numbers.
+type CutNumberMode uint32
+
+// The modes of the cut numbers.
+const (
+	// NoCutNumbers sends all numbers as they are written, <cut:on> is ignored.
+	NoCutNumbers CutNumberMode = iota
+	// MarkedCutNumbers sends numbers as cut numbers only between <cut:on> and <cut:off>, e.g. "ur <cut:on>599<cut:off>".
+	MarkedCutNumbers
+	// AllCutNumbers sends all numbers as cut numbers, except between <cut:off> and <cut:on>.
+	AllCutNumbers
+)
+
+// cutCode returns the code of the cut number at the start of the given text.
+func cutCode(text string) ([]Symbol, bool) {
+	r, _ := utf8.DecodeRuneInString(text)
+	cut, ok := CutNumbers[r]
+	if !ok {
+		return nil, false
+	}
+	return Code[cut], true
+}
+
+// SpeedStep is the change of the speed in WpM by the inline commands "^+" and "^-".
+const SpeedStep = 2
+
+type commandKind uint8
+
+const (
+	noCommand commandKind = iota
+	wpmCommand
+	wpmStepCommand
+	farnsworthCommand
+	pauseCommand
+	cutCommand
+)
+
+// command is an inline command in the text that is sent. The value is the speed in WpM, the change of the speed in
+// WpM or the duration of the pause in seconds, depending on the kind of the command.
+//
+// The supported commands are:
+//
+//	^+ and ^-         increase or decrease the speed by SpeedStep
+//	<wpm:28>          set the speed to 28 WpM
+//	<farnsworth:15>   set the overall speed to 15 WpM, <farnsworth:0> turns the Farnsworth timing off
+//	<pause:500ms>     pause for the given duration
+//	<cut:on>          send the following numbers as cut numbers, <cut:off> ends the cut numbers
+type command struct {
+	kind  commandKind
+	value float64
+}
+
+// parseCommand parses the given content of an angle bracket command, e.g. "wpm:28".
+func parseCommand(s string) (command, bool) {
+	separator := strings.IndexRune(s, ':')
+	if separator < 0 {
+		return command{}, false
+	}
+	name := strings.ToLower(strings.TrimSpace(s[:separator]))
+	arg := strings.TrimSpace(s[separator+1:])
+	switch name {
+	case "wpm":
+		wpm, err := strconv.Atoi(arg)
+		if err != nil || wpm <= 0 {
+			return command{}, false
+		}
+		return command{kind: wpmCommand, value: float64(wpm)}, true
+	case "farnsworth":
+		wpm, err := strconv.Atoi(arg)
+		if err != nil || wpm < 0 {
+			return command{}, false
+		}
+		return command{kind: farnsworthCommand, value: float64(wpm)}, true
+	case "pause":
+		pause, err := time.ParseDuration(arg)
+		if err != nil || pause <= 0 {
+			return command{}, false
+		}
+		return command{kind: pauseCommand, value: pause.Seconds()}, true
+	case "cut":
+		switch strings.ToLower(arg) {
+		case "on":
+			return command{kind: cutCommand, value: 1}, true
+		case "off":
+			return command{kind: cutCommand, value: 0}, true
+		default:
+			return command{}, false
+		}
+	default:
+		return command{}, false
+	}
+}
+
+// nextToken reads the next character from the given text. It returns the code of the character, or nil if the
+// character is unknown, if the character is whitespace, the inline command, and the number of bytes that were read.
+// A prosign in angle brackets is read as a single character, an angle bracket command is read as a whole.
+func nextToken(text string) (code []Symbol, space bool, cmd command, size int) {
+	r, size := utf8.DecodeRuneInString(text)
+	if unicode.IsSpace(r) {
+		return nil, true, cmd, size
+	}
+	if r == '^' && len(text) > 1 {
+		switch text[1] {
+		case '+':
+			return nil, false, command{kind: wpmStepCommand, value: SpeedStep}, 2
+		case '-':
+			return nil, false, command{kind: wpmStepCommand, value: -SpeedStep}, 2
+		}
+	}
+	if r == '<' {
+		end := strings.IndexRune(text, '>')
+		if end > 0 {
+			if strings.ContainsRune(text[1:end], ':') {
+				// invalid commands are skipped
+				cmd, _ := parseCommand(text[1:end])
+				return nil, false, cmd, end + 1
+			}
+			code, ok := ProsignCode(text[1:end])
+			if ok {
+				return code, false, cmd, end + 1
+			}
+		}
+	}
+	return Code[unicode.ToLower(r)], false, cmd, size
+}
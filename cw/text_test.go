@@ -0,0 +1,210 @@
+package cw
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProsignCode(t *testing.T) {
+	testCases := []struct {
+		prosign  string
+		expected []Symbol
+		ok       bool
+	}{
+		{"AR", []Symbol{Dit, Da, Dit, Da, Dit}, true},
+		{"sk", []Symbol{Dit, Dit, Dit, Da, Dit, Da}, true},
+		{"SOS", []Symbol{Dit, Dit, Dit, Da, Da, Da, Dit, Dit, Dit}, true},
+		{"NJ", []Symbol{Da, Dit, Dit, Da, Da, Da}, true},
+		{"", nil, false},
+		{"A R", nil, false},
+		{"A#", nil, false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.prosign, func(t *testing.T) {
+			code, ok := ProsignCode(tC.prosign)
+			assert.Equal(t, tC.ok, ok)
+			assert.Equal(t, tC.expected, code)
+		})
+	}
+}
+
+func TestWriteProsignsToSymbolStream(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected []Symbol
+	}{
+		{"<AR>", []Symbol{Dit, SymbolBreak, Da, SymbolBreak, Dit, SymbolBreak, Da, SymbolBreak, Dit, WordBreak}},
+		{"e<kn>", []Symbol{Dit, CharBreak, Da, SymbolBreak, Dit, SymbolBreak, Da, SymbolBreak, Da, SymbolBreak, Dit, WordBreak}},
+		{"<e", []Symbol{Dit, WordBreak}},
+		{"<e t>", []Symbol{Dit, WordBreak, Da, WordBreak}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			buf := make(chan Symbol, 100)
+			WriteToSymbolStream(context.Background(), buf, tC.text)
+			close(buf)
+
+			actual := make([]Symbol, 0, len(tC.expected))
+			for s := range buf {
+				actual = append(actual, s)
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestWriteUnknownProsign(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+
+	err := m.WriteProsign("A#")
+
+	assert.Equal(t, ErrUnknownProsign, err)
+}
+
+func TestNextTokenCommands(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected command
+		size     int
+	}{
+		{"^+e", command{kind: wpmStepCommand, value: SpeedStep}, 2},
+		{"^-", command{kind: wpmStepCommand, value: -SpeedStep}, 2},
+		{"<wpm:28>e", command{kind: wpmCommand, value: 28}, 8},
+		{"<WPM: 28>", command{kind: wpmCommand, value: 28}, 9},
+		{"<farnsworth:15>", command{kind: farnsworthCommand, value: 15}, 15},
+		{"<farnsworth:0>", command{kind: farnsworthCommand, value: 0}, 14},
+		{"<pause:500ms>", command{kind: pauseCommand, value: 0.5}, 13},
+		{"<wpm:0>", command{}, 7},
+		{"<wpm:fast>", command{}, 10},
+		{"<pause:-1s>", command{}, 11},
+		{"<cut:on>", command{kind: cutCommand, value: 1}, 8},
+		{"<CUT:off>", command{kind: cutCommand, value: 0}, 9},
+		{"<cut:maybe>", command{}, 11},
+		{"<speed:20>", command{}, 10},
+		{"^e", command{}, 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			_, _, cmd, size := nextToken(tC.text)
+			assert.Equal(t, tC.expected, cmd)
+			assert.Equal(t, tC.size, size)
+		})
+	}
+}
+
+func TestWriteToSymbolStreamIgnoresCommands(t *testing.T) {
+	buf := make(chan Symbol, 100)
+	WriteToSymbolStream(context.Background(), buf, "e^+<wpm:30>e<pause:1s>")
+	close(buf)
+
+	actual := make([]Symbol, 0, 4)
+	for s := range buf {
+		actual = append(actual, s)
+	}
+	assert.Equal(t, []Symbol{Dit, CharBreak, Dit, WordBreak}, actual)
+}
+
+func TestModulatorInlineSpeedCommands(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+	done := make(chan struct{})
+	go func() {
+		m.Write([]byte("e <wpm:40>e ^-^-e"))
+		close(done)
+	}()
+
+	const sampleRate = 8000.0
+	var keyDowns []float64
+	keyDown := 0
+	for n := 0; n < 10*int(sampleRate); n++ {
+		select {
+		case <-done:
+			n = 10 * int(sampleRate)
+		default:
+		}
+		m.Modulate(float64(n)/sampleRate, 0, 0, 0)
+		if m.keyDown {
+			keyDown++
+		} else if keyDown > 0 {
+			keyDowns = append(keyDowns, float64(keyDown)/sampleRate)
+			keyDown = 0
+		}
+		if !m.keyDown {
+			runtime.Gosched()
+		}
+	}
+
+	if assert.Equal(t, 3, len(keyDowns)) {
+		assert.InDelta(t, 0.06, keyDowns[0], 0.001)
+		assert.InDelta(t, 0.03, keyDowns[1], 0.001)
+		assert.InDelta(t, WPMToSeconds(36), keyDowns[2], 0.001)
+	}
+}
+
+func TestModulatorPauseCommand(t *testing.T) {
+	m := NewModulator(700, 20)
+	defer m.Close()
+
+	end := m.execute(1, command{kind: pauseCommand, value: 0.5})
+
+	assert.Equal(t, 1.5, end)
+}
+
+func TestModulatorCutNumbers(t *testing.T) {
+	testCases := []struct {
+		mode     CutNumberMode
+		text     string
+		expected string
+	}{
+		{NoCutNumbers, "599 019", "599 019"},
+		{NoCutNumbers, "<cut:on>599<cut:off>", "599"},
+		{AllCutNumbers, "599 019", "5nn tan"},
+		{AllCutNumbers, "<cut:off>599<cut:on> 019", "599 tan"},
+		{MarkedCutNumbers, "599 019", "599 019"},
+		{MarkedCutNumbers, "ur <cut:on>599 019<cut:off> 10", "ur 5nn tan 10"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			plain := NewModulator(700, 20)
+			defer plain.Close()
+			m := NewModulator(700, 20)
+			defer m.Close()
+			m.SetCutNumbers(tC.mode)
+
+			assert.Equal(t, writtenSymbols(plain, tC.expected), writtenSymbols(m, tC.text))
+		})
+	}
+}
+
+// writtenSymbols writes the given text to the modulator and returns the symbols that are queued by the write.
+func writtenSymbols(m *Modulator, text string) []Symbol {
+	done := make(chan struct{})
+	go func() {
+		m.Write([]byte(text))
+		close(done)
+	}()
+
+	var result []Symbol
+	for {
+		select {
+		case <-done:
+			return result
+		default:
+		}
+		next, ok := m.symbols.TryReceive()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		switch next.kind {
+		case symbolItem:
+			result = append(result, next.symbol)
+		case endOfTransmissionItem:
+			close(next.token)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cw
+
+import "math"
+
+const (
+	// blockDuration is the time resolution of the tone detection in seconds.
+	blockDuration = 0.005
+	// peakDecay is the weight of a new block in the decaying peak level.
+	peakDecay = 0.01
+	// noiseAveraging is the weight of a new block without signal in the averaged noise level.
+	noiseAveraging = 0.05
+	// signalAveraging is the weight of a new block while the key is down in the averaged signal level.
+	signalAveraging = 0.05
+	// minSignalToNoise is the minimum ratio of the peak level to the noise level to detect the key at all.
+	minSignalToNoise = 4.0
+	// keyHysteresis is the relative distance of the key down and key up thresholds from the middle between the
+	// noise and the peak level.
+	keyHysteresis = 0.1
+)
+
+// ToneDetector detects a CW tone at a given audio frequency and converts it into key down and key up events. The
+// level of the tone is measured in blocks of 5 ms using the Goertzel algorithm. The key thresholds follow the
+// decaying peak level and the averaged noise level, so the detector adapts to the signal strength (AGC). The
+// thresholds are separated by a hysteresis to avoid chattering of the key.
+type ToneDetector struct {
+	sampleRate  int
+	frequency   float64
+	coefficient float64
+	handler     func(keyDown bool, t float64)
+
+	blockSize  int
+	blockCount int
+	s1, s2     float64
+	samples    int64
+
+	peak    float64
+	noise   float64
+	signal  float64
+	keyDown bool
+}
+
+// NewToneDetector returns a new ToneDetector for a tone at the given audio frequency and sample rate. The handler
+// is called with the state of the key and the time in seconds after each block of samples, so it can be fed
+// directly into a Decoder. The handler may be nil if the state of the key is polled with KeyDown.
+func NewToneDetector(frequency float64, sampleRate int, handler func(keyDown bool, t float64)) *ToneDetector {
+	blockSize := int(math.Round(blockDuration * float64(sampleRate)))
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	result := &ToneDetector{
+		sampleRate: sampleRate,
+		handler:    handler,
+		blockSize:  blockSize,
+	}
+	result.SetFrequency(frequency)
+	return result
+}
+
+// SampleRate returns the sample rate of the audio in Hz.
+func (d *ToneDetector) SampleRate() int {
+	return d.sampleRate
+}
+
+// Frequency returns the audio frequency of the detected tone in Hz.
+func (d *ToneDetector) Frequency() float64 {
+	return d.frequency
+}
+
+// SetFrequency sets the audio frequency of the detected tone in Hz. It takes effect with the next block.
+func (d *ToneDetector) SetFrequency(frequency float64) {
+	d.frequency = frequency
+	d.coefficient = 2 * math.Cos(2*math.Pi*frequency/float64(d.sampleRate))
+}
+
+// KeyDown indicates if the tone is currently detected.
+func (d *ToneDetector) KeyDown() bool {
+	return d.keyDown
+}
+
+// Levels returns the current peak level and the noise level of the tone.
+func (d *ToneDetector) Levels() (peak float64, noise float64) {
+	return d.peak, d.noise
+}
+
+// SignalLevel returns the averaged level of the tone while the key is down. Unlike the peak level, it is not
+// raised by the noise on top of the tone.
+func (d *ToneDetector) SignalLevel() float64 {
+	return d.signal
+}
+
+// Detected indicates if the peak level is far enough above the noise level to detect the key at all.
+func (d *ToneDetector) Detected() bool {
+	return d.noise > 0 && d.peak >= minSignalToNoise*d.noise
+}
+
+// Process detects the tone in the given audio samples. It implements dsp.Processor, the samples are not modified.
+func (d *ToneDetector) Process(samples []float64) {
+	for _, x := range samples {
+		s0 := x + d.coefficient*d.s1 - d.s2
+		d.s2 = d.s1
+		d.s1 = s0
+		d.samples++
+		d.blockCount++
+		if d.blockCount < d.blockSize {
+			continue
+		}
+		power := d.s1*d.s1 + d.s2*d.s2 - d.coefficient*d.s1*d.s2
+		magnitude := 2 * math.Sqrt(math.Max(0, power)) / float64(d.blockCount)
+		d.s1 = 0
+		d.s2 = 0
+		d.blockCount = 0
+		d.processBlock(magnitude, float64(d.samples)/float64(d.sampleRate))
+	}
+}
+
+func (d *ToneDetector) processBlock(magnitude float64, t float64) {
+	if magnitude > d.peak {
+		d.peak = magnitude
+	} else {
+		d.peak = (1-peakDecay)*d.peak + peakDecay*magnitude
+	}
+	middle := (d.peak + d.noise) / 2
+	if d.noise == 0 {
+		d.noise = magnitude
+	} else if magnitude < middle {
+		d.noise = (1-noiseAveraging)*d.noise + noiseAveraging*magnitude
+	}
+
+	span := d.peak - d.noise
+	switch {
+	case d.peak < minSignalToNoise*d.noise:
+		d.keyDown = false
+	case magnitude > middle+keyHysteresis*span:
+		d.keyDown = true
+	case magnitude < middle-keyHysteresis*span:
+		d.keyDown = false
+	}
+	if d.keyDown && d.signal == 0 {
+		d.signal = magnitude
+	} else if d.keyDown {
+		d.signal = (1-signalAveraging)*d.signal + signalAveraging*magnitude
+	}
+	if d.handler != nil {
+		d.handler(d.keyDown, t)
+	}
+}
@@ -0,0 +1,106 @@
+package cw
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// minKeyDown is the shortest key down interval that detectKeyDowns reports, shorter ones are glitches caused by
+// noise or by the edges of the signal.
+const minKeyDown = 0.02
+
+// detectKeyDowns returns the durations of the key down intervals detected in the given samples.
+func detectKeyDowns(detector *ToneDetector, samples []float64) []float64 {
+	var result []float64
+	var start float64
+	keyDown := false
+	detector.handler = func(down bool, t float64) {
+		if down && !keyDown {
+			start = t
+		} else if !down && keyDown && t-start >= minKeyDown {
+			result = append(result, t-start)
+		}
+		keyDown = down
+	}
+	detector.Process(samples)
+	return result
+}
+
+// keying returns the samples of a tone at the given frequency with the amplitude 1, keyed by the given intervals
+// in seconds. The intervals alternate between key up and key down, starting with key up.
+func keying(frequency float64, sampleRate int, intervals ...float64) []float64 {
+	var result []float64
+	keyDown := false
+	for _, interval := range intervals {
+		for i := 0; i < int(interval*float64(sampleRate)); i++ {
+			x := 0.0
+			if keyDown {
+				x = math.Sin(2 * math.Pi * frequency * float64(len(result)) / float64(sampleRate))
+			}
+			result = append(result, x)
+		}
+		keyDown = !keyDown
+	}
+	return result
+}
+
+func TestToneDetectorAdaptsToLevel(t *testing.T) {
+	const sampleRate = 8000
+	// "ee t" at 20 WpM
+	signal := keying(700, sampleRate, 0.2, 0.06, 0.18, 0.06, 0.42, 0.18, 0.5)
+	for _, level := range []float64{0.001, 0.1, 1} {
+		t.Run(fmt.Sprintf("%g", level), func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			samples := make([]float64, len(signal))
+			for i, x := range signal {
+				samples[i] = level*x + level*0.02*rng.NormFloat64()
+			}
+			detector := NewToneDetector(700, sampleRate, nil)
+
+			keyDowns := detectKeyDowns(detector, samples)
+
+			if assert.Equal(t, 3, len(keyDowns), "%v", keyDowns) {
+				assert.InDelta(t, 0.06, keyDowns[0], 0.011)
+				assert.InDelta(t, 0.06, keyDowns[1], 0.011)
+				assert.InDelta(t, 0.18, keyDowns[2], 0.011)
+			}
+			assert.False(t, detector.KeyDown())
+		})
+	}
+}
+
+func TestToneDetectorWithoutHandler(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	samples := keying(700, 8000, 0.2, 0.1)
+	for i := range samples {
+		samples[i] += 0.02 * rng.NormFloat64()
+	}
+	detector := NewToneDetector(700, 8000, nil)
+
+	detector.Process(samples)
+
+	assert.True(t, detector.KeyDown())
+}
+
+func TestToneDetectorIgnoresOtherFrequencies(t *testing.T) {
+	const sampleRate = 8000
+	samples := modulate(t, "ee t", 1500, 20, sampleRate)
+	rng := rand.New(rand.NewSource(1))
+	for i := range samples {
+		samples[i] += 0.1 * rng.NormFloat64()
+	}
+	detector := NewToneDetector(700, sampleRate, nil)
+
+	keyDowns := detectKeyDowns(detector, samples)
+
+	assert.Empty(t, keyDowns)
+
+	detector.SetFrequency(1500)
+	assert.Equal(t, 1500.0, detector.Frequency())
+	keyDowns = detectKeyDowns(detector, samples)
+	assert.Equal(t, 3, len(keyDowns))
+}
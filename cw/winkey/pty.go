@@ -0,0 +1,42 @@
+package winkey
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrNotSupported is returned when pseudo terminals are not supported on this system.
+var ErrNotSupported = errors.New("winkey: pseudo terminals not supported")
+
+// PTY is a pseudo terminal in raw mode. The keyer runs on the PTY, the host connects to the terminal device with
+// the name returned by Name, e.g. /dev/pts/3. Many applications only offer real serial ports, in this case a
+// symbolic link like /dev/ttyWK can be created that points to the terminal device.
+type PTY struct {
+	master *os.File
+	slave  *os.File
+}
+
+// Name returns the name of the terminal device.
+func (p *PTY) Name() string {
+	return p.slave.Name()
+}
+
+// Read reads the bytes that the host wrote to the terminal device.
+func (p *PTY) Read(bytes []byte) (int, error) {
+	return p.master.Read(bytes)
+}
+
+// Write writes the given bytes to the host.
+func (p *PTY) Write(bytes []byte) (int, error) {
+	return p.master.Write(bytes)
+}
+
+// Close closes the pseudo terminal.
+func (p *PTY) Close() error {
+	err := p.master.Close()
+	slaveErr := p.slave.Close()
+	if err == nil {
+		err = slaveErr
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+package winkey
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// OpenPTY opens a new pseudo terminal in raw mode.
+func OpenPTY() (*PTY, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var unlock int32
+	err = ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock))
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	var number uint32
+	err = ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&number))
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	// the slave is kept open, otherwise reading from the master fails until the host opens the terminal device
+	slave, err := os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(number), 10), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	err = makeRaw(slave)
+	if err != nil {
+		master.Close()
+		slave.Close()
+		return nil, err
+	}
+	return &PTY{master: master, slave: slave}, nil
+}
+
+// makeRaw turns off the processing of the input and output of the given terminal, like cfmakeraw.
+func makeRaw(terminal *os.File) error {
+	var termios syscall.Termios
+	err := ioctl(terminal, syscall.TCGETS, unsafe.Pointer(&termios))
+	if err != nil {
+		return err
+	}
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Oflag &^= syscall.OPOST
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	return ioctl(terminal, syscall.TCSETS, unsafe.Pointer(&termios))
+}
+
+func ioctl(file *os.File, request uintptr, arg unsafe.Pointer) error {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
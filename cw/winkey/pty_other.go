@@ -0,0 +1,8 @@
+//go:build !linux
+
+package winkey
+
+// OpenPTY returns ErrNotSupported, because pseudo terminals are only supported on Linux.
+func OpenPTY() (*PTY, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,617 @@
+/*
+Package winkey emulates the host protocol of the K1EL WinKeyer 2. Contest loggers like N1MM Logger+ or DXLog connect
+to the emulated keyer through a serial port or a pseudo terminal and send their CW messages through a cw.Modulator.
+
+The emulation covers the parts of the protocol that the loggers use to send messages: opening and closing the host
+mode, the speed (including the speed pot), the character buffer with backspace and clear, pause, the Farnsworth
+speed, merged letters, buffered speed changes, PTT and waits, and the status and speed pot bytes. Commands that
+configure the hardware of a WinKeyer (sidetone, weighting, paddles, pins, calibration, EEPROM) are accepted and
+ignored.
+*/
+package winkey
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/cw"
+)
+
+// Version is the firmware version that is reported when the host opens the keyer.
+const Version = 23
+
+// BufferSize is the size of the character buffer.
+const BufferSize = 128
+
+// xoffLevel is the number of buffered items from which on the keyer reports that the buffer is almost full.
+const xoffLevel = 2 * BufferSize / 3
+
+// Defaults of the configuration.
+const (
+	DefaultPotWPM   = 20
+	DefaultMinWPM   = 10
+	DefaultWPMRange = 25
+)
+
+// The admin commands, they are prefixed by the admin command byte 0x00.
+const (
+	adminReset       = 0x01
+	adminHostOpen    = 0x02
+	adminHostClose   = 0x03
+	adminEcho        = 0x04
+	adminPaddleA2D   = 0x05
+	adminSpeedA2D    = 0x06
+	adminGetValues   = 0x07
+	adminGetCal      = 0x09
+	adminDumpEEPROM  = 0x0C
+	adminLoadEEPROM  = 0x0D
+	adminSendMessage = 0x0E
+	adminLoadX1Mode  = 0x0F
+)
+
+// The host commands.
+const (
+	cmdAdmin          = 0x00
+	cmdSidetone       = 0x01
+	cmdSpeed          = 0x02
+	cmdWeighting      = 0x03
+	cmdPTTLeadTail    = 0x04
+	cmdSpeedPotSetup  = 0x05
+	cmdPause          = 0x06
+	cmdGetSpeedPot    = 0x07
+	cmdBackspace      = 0x08
+	cmdPinConfig      = 0x09
+	cmdClearBuffer    = 0x0A
+	cmdKeyImmediate   = 0x0B
+	cmdHSCW           = 0x0C
+	cmdFarnsworth     = 0x0D
+	cmdMode           = 0x0E
+	cmdLoadDefaults   = 0x0F
+	cmdFirstExtension = 0x10
+	cmdKeyComp        = 0x11
+	cmdPaddleSwitch   = 0x12
+	cmdNull           = 0x13
+	cmdSoftwarePaddle = 0x14
+	cmdStatus         = 0x15
+	cmdPointer        = 0x16
+	cmdDitDahRatio    = 0x17
+
+	// the buffered commands are executed in order with the characters
+	cmdBufferedPTT    = 0x18
+	cmdKeyBuffered    = 0x19
+	cmdWait           = 0x1A
+	cmdMerge          = 0x1B
+	cmdBufferedSpeed  = 0x1C
+	cmdBufferedHSCW   = 0x1D
+	cmdCancelSpeed    = 0x1E
+	cmdBufferedNop    = 0x1F
+	firstCharacter    = 0x20
+	lastCharacter     = 0x7F
+	pointerAddNulls   = 0x03
+	loadDefaultsCount = 15
+	eepromSize        = 256
+)
+
+// argumentCounts contains the number of argument bytes of the commands that have arguments.
+var argumentCounts = map[byte]int{
+	cmdSidetone:       1,
+	cmdSpeed:          1,
+	cmdWeighting:      1,
+	cmdPTTLeadTail:    2,
+	cmdSpeedPotSetup:  3,
+	cmdPause:          1,
+	cmdPinConfig:      1,
+	cmdKeyImmediate:   1,
+	cmdHSCW:           1,
+	cmdFarnsworth:     1,
+	cmdMode:           1,
+	cmdLoadDefaults:   loadDefaultsCount,
+	cmdFirstExtension: 1,
+	cmdKeyComp:        1,
+	cmdPaddleSwitch:   1,
+	cmdSoftwarePaddle: 1,
+	cmdPointer:        1,
+	cmdDitDahRatio:    1,
+	cmdBufferedPTT:    1,
+	cmdKeyBuffered:    1,
+	cmdWait:           1,
+	cmdMerge:          2,
+	cmdBufferedSpeed:  1,
+	cmdBufferedHSCW:   1,
+}
+
+// The bits of the status byte.
+const (
+	statusTag     = 0xC0
+	statusWait    = 0x10
+	statusKeyDown = 0x08
+	statusBusy    = 0x04
+	statusBreakIn = 0x02
+	statusXOFF    = 0x01
+	speedPotTag   = 0x80
+	speedPotMask  = 0x3F
+)
+
+// modeSerialEcho is the bit of the mode register that enables the echo of the sent characters.
+const modeSerialEcho = 0x04
+
+// Config of a Keyer.
+type Config struct {
+	// PotWPM is the initial speed of the emulated speed pot in WpM. 0 means DefaultPotWPM.
+	PotWPM int
+	// PTT switches the transmitter. The keyer switches it on before the first character of a message, with the
+	// lead-in time that is configured by the host, and off after the tail time when the buffer is empty. It is
+	// optional.
+	PTT func(bool)
+}
+
+// item is an entry in the buffer: a character or a buffered command.
+type item struct {
+	command byte
+	value   byte
+	text    string
+}
+
+// Keyer emulates a WinKeyer on the given port and sends the characters through a cw.Modulator. The modulator must
+// be rendered to the audio output by the application.
+type Keyer struct {
+	modulator *cw.Modulator
+	port      io.ReadWriter
+	config    Config
+
+	writeLock sync.Mutex
+
+	mu         sync.Mutex
+	open       bool
+	mode       byte
+	hostWPM    int
+	potWPM     int
+	minWPM     int
+	wpmRange   int
+	bufferWPM  int
+	farnsworth int
+	leadIn     time.Duration
+	tail       time.Duration
+	paused     bool
+	busy       bool
+	ptt        bool
+	manualPTT  bool
+	status     byte
+	buffer     []item
+	ctx        context.Context
+	abort      context.CancelFunc
+	changed    chan struct{}
+}
+
+// New returns a new Keyer that talks to the host through the given port.
+func New(modulator *cw.Modulator, port io.ReadWriter, config Config) *Keyer {
+	if config.PotWPM == 0 {
+		config.PotWPM = DefaultPotWPM
+	}
+	result := &Keyer{
+		modulator: modulator,
+		port:      port,
+		config:    config,
+		potWPM:    config.PotWPM,
+		changed:   make(chan struct{}, 1),
+	}
+	result.ctx, result.abort = context.WithCancel(context.Background())
+	result.reset()
+	return result
+}
+
+// reset sets the keyer to its initial state, the caller must hold the lock.
+func (k *Keyer) reset() {
+	k.mode = 0
+	k.hostWPM = 0
+	k.minWPM = DefaultMinWPM
+	k.wpmRange = DefaultWPMRange
+	k.bufferWPM = 0
+	k.farnsworth = 0
+	k.leadIn = 0
+	k.tail = 0
+	k.paused = false
+	k.clear()
+	k.updateSpeed()
+}
+
+// Run reads the commands of the host from the port until the port is closed, reading fails or the context is done.
+// Since a read cannot be interrupted, the context is only checked between the commands.
+func (k *Keyer) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	senderDone := make(chan struct{})
+	go func() {
+		defer close(senderDone)
+		k.send(ctx)
+	}()
+	defer func() {
+		cancel()
+		k.mu.Lock()
+		k.clear()
+		k.mu.Unlock()
+		<-senderDone
+	}()
+
+	reader := bufio.NewReader(k.port)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := k.handleCommand(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (k *Keyer) handleCommand(reader *bufio.Reader) error {
+	command, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if command == cmdAdmin {
+		return k.handleAdminCommand(reader)
+	}
+
+	args := make([]byte, argumentCounts[command])
+	_, err = io.ReadFull(reader, args)
+	if err != nil {
+		return err
+	}
+	if command == cmdPointer && args[0] == pointerAddNulls {
+		_, err = reader.ReadByte()
+		if err != nil {
+			return err
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	switch {
+	case command == cmdSpeed:
+		k.hostWPM = int(args[0])
+		k.updateSpeed()
+	case command == cmdPTTLeadTail:
+		k.leadIn = time.Duration(args[0]) * 10 * time.Millisecond
+		k.tail = time.Duration(args[1]) * 10 * time.Millisecond
+	case command == cmdSpeedPotSetup:
+		k.minWPM = int(args[0])
+		k.wpmRange = int(args[1])
+		k.updateSpeed()
+	case command == cmdPause:
+		k.paused = args[0] != 0
+		k.notify()
+	case command == cmdGetSpeedPot:
+		k.writeSpeedPot()
+	case command == cmdBackspace:
+		if n := len(k.buffer); n > 0 {
+			k.buffer = k.buffer[:n-1]
+			k.updateStatus()
+		}
+	case command == cmdClearBuffer:
+		k.clear()
+	case command == cmdFarnsworth:
+		k.farnsworth = int(args[0])
+		k.modulator.SetFarnsworthWPM(k.farnsworth)
+	case command == cmdMode:
+		k.mode = args[0]
+	case command == cmdLoadDefaults:
+		k.mode = args[0]
+		k.hostWPM = int(args[1])
+		k.leadIn = time.Duration(args[4]) * 10 * time.Millisecond
+		k.tail = time.Duration(args[5]) * 10 * time.Millisecond
+		k.minWPM = int(args[6])
+		k.wpmRange = int(args[7])
+		k.farnsworth = int(args[10])
+		k.modulator.SetFarnsworthWPM(k.farnsworth)
+		k.updateSpeed()
+	case command == cmdStatus:
+		k.write(k.currentStatus())
+	case command == cmdMerge:
+		k.enqueue(item{text: string(args)})
+	case command >= cmdBufferedPTT && command <= cmdBufferedNop:
+		var value byte
+		if len(args) > 0 {
+			value = args[0]
+		}
+		k.enqueue(item{command: command, value: value})
+	case command >= firstCharacter && command <= lastCharacter:
+		k.enqueue(item{text: string(rune(command))})
+	}
+	return nil
+}
+
+func (k *Keyer) handleAdminCommand(reader *bufio.Reader) error {
+	command, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	var args []byte
+	switch command {
+	case adminEcho, adminSendMessage, adminLoadX1Mode:
+		args = make([]byte, 1)
+	case adminLoadEEPROM:
+		args = make([]byte, eepromSize)
+	}
+	_, err = io.ReadFull(reader, args)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	switch command {
+	case adminReset:
+		k.reset()
+	case adminHostOpen:
+		k.open = true
+		k.write(Version)
+	case adminHostClose:
+		k.open = false
+		k.clear()
+	case adminEcho:
+		k.write(args[0])
+	case adminPaddleA2D, adminSpeedA2D, adminGetCal:
+		k.write(0)
+	case adminGetValues:
+		k.write(k.mode, byte(k.hostWPM), 0, 50, byte(k.leadIn/(10*time.Millisecond)), byte(k.tail/(10*time.Millisecond)),
+			byte(k.minWPM), byte(k.wpmRange), 0, 0, byte(k.farnsworth), 50, 50, 0, 0)
+	case adminDumpEEPROM:
+		k.write(make([]byte, eepromSize)...)
+	}
+	return nil
+}
+
+// SetSpeedPot sets the speed of the emulated speed pot in WpM. If the host opened the keyer, the new value is
+// reported to the host.
+func (k *Keyer) SetSpeedPot(wpm int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.potWPM = wpm
+	k.updateSpeed()
+	if k.open {
+		k.writeSpeedPot()
+	}
+}
+
+// WPM returns the current speed in WpM.
+func (k *Keyer) WPM() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.wpm()
+}
+
+// Open indicates if the host opened the keyer.
+func (k *Keyer) Open() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.open
+}
+
+// wpm returns the current speed, the caller must hold the lock.
+func (k *Keyer) wpm() int {
+	switch {
+	case k.bufferWPM != 0:
+		return k.bufferWPM
+	case k.hostWPM != 0:
+		return k.hostWPM
+	default:
+		return k.minWPM + k.potValue()
+	}
+}
+
+// potValue returns the value of the speed pot relative to the minimum speed.
+func (k *Keyer) potValue() int {
+	value := k.potWPM - k.minWPM
+	if value > k.wpmRange {
+		value = k.wpmRange
+	}
+	if value < 0 {
+		value = 0
+	}
+	return value
+}
+
+func (k *Keyer) updateSpeed() {
+	k.modulator.SetWPM(k.wpm())
+}
+
+func (k *Keyer) writeSpeedPot() {
+	k.write(speedPotTag | byte(k.potValue())&speedPotMask)
+}
+
+// write writes the given bytes to the host. Errors are ignored, they show up when reading the next command.
+func (k *Keyer) write(bytes ...byte) {
+	k.writeLock.Lock()
+	defer k.writeLock.Unlock()
+	k.port.Write(bytes)
+}
+
+// currentStatus returns the status byte, the caller must hold the lock.
+func (k *Keyer) currentStatus() byte {
+	result := byte(statusTag)
+	if k.busy {
+		result |= statusBusy
+	}
+	if len(k.buffer) >= xoffLevel {
+		result |= statusXOFF
+	}
+	return result
+}
+
+// updateStatus reports a changed status to the host, the caller must hold the lock.
+func (k *Keyer) updateStatus() {
+	status := k.currentStatus()
+	if status == k.status {
+		return
+	}
+	k.status = status
+	if k.open {
+		k.write(status)
+	}
+}
+
+// clear aborts the current character and drops the buffer, the caller must hold the lock.
+func (k *Keyer) clear() {
+	k.abort()
+	k.ctx, k.abort = context.WithCancel(context.Background())
+	k.buffer = nil
+	k.bufferWPM = 0
+	k.updateSpeed()
+	k.updateStatus()
+	k.notify()
+}
+
+// enqueue adds the given item to the buffer, the caller must hold the lock. If the buffer is full, the item is
+// dropped.
+func (k *Keyer) enqueue(i item) {
+	if len(k.buffer) >= BufferSize {
+		return
+	}
+	k.buffer = append(k.buffer, i)
+	k.updateStatus()
+	k.notify()
+}
+
+// notify wakes up the sender, the caller must hold the lock.
+func (k *Keyer) notify() {
+	select {
+	case k.changed <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the next item from the buffer. It returns false if the buffer is empty or paused.
+func (k *Keyer) next() (item, context.Context, time.Duration, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.buffer) == 0 || k.paused {
+		return item{}, nil, 0, false
+	}
+	result := k.buffer[0]
+	k.buffer = k.buffer[1:]
+	var leadIn time.Duration
+	if !k.busy {
+		k.busy = true
+		if !k.ptt {
+			k.setPTT(true)
+			leadIn = k.leadIn
+		}
+	}
+	k.updateStatus()
+	return result, k.ctx, leadIn, true
+}
+
+// idle reports the end of the transmission when the buffer is empty. It returns the tail time, if the PTT needs
+// to be switched off.
+func (k *Keyer) idle() (time.Duration, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.buffer) > 0 && !k.paused {
+		return 0, false
+	}
+	k.busy = false
+	if k.bufferWPM != 0 {
+		// buffered speed changes end with the buffer
+		k.bufferWPM = 0
+		k.updateSpeed()
+	}
+	k.updateStatus()
+	return k.tail, k.ptt && !k.manualPTT
+}
+
+// releasePTT switches the PTT off after the tail time, unless the transmission continues.
+func (k *Keyer) releasePTT() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.busy || k.manualPTT {
+		return
+	}
+	k.setPTT(false)
+}
+
+// setPTT switches the PTT, the caller must hold the lock.
+func (k *Keyer) setPTT(on bool) {
+	if k.ptt == on {
+		return
+	}
+	k.ptt = on
+	if k.config.PTT != nil {
+		k.config.PTT(on)
+	}
+}
+
+// send sends the buffered items until the context is done.
+func (k *Keyer) send(ctx context.Context) {
+	for {
+		next, itemCtx, leadIn, ok := k.next()
+		if !ok {
+			tail, release := k.idle()
+			var tailTimer <-chan time.Time
+			if release {
+				tailTimer = time.After(tail)
+			}
+			select {
+			case <-ctx.Done():
+				k.releasePTT()
+				return
+			case <-tailTimer:
+				k.releasePTT()
+			case <-k.changed:
+			}
+			continue
+		}
+
+		wait(itemCtx, leadIn)
+		k.execute(itemCtx, next)
+	}
+}
+
+// execute sends the given character or executes the given buffered command.
+func (k *Keyer) execute(ctx context.Context, i item) {
+	switch i.command {
+	case 0:
+		err := k.modulator.WriteCharacter(ctx, i.text)
+		k.mu.Lock()
+		echo := err == nil && k.open && k.mode&modeSerialEcho != 0
+		k.mu.Unlock()
+		if echo && len(i.text) == 1 {
+			k.write(i.text[0])
+		}
+	case cmdBufferedPTT:
+		k.mu.Lock()
+		k.manualPTT = i.value != 0
+		k.setPTT(k.manualPTT || k.busy)
+		k.mu.Unlock()
+	case cmdKeyBuffered, cmdWait:
+		// the modulator cannot key a continuous carrier, a buffered key down is sent as a wait of the same time
+		wait(ctx, time.Duration(i.value)*time.Second)
+	case cmdBufferedSpeed:
+		k.mu.Lock()
+		k.bufferWPM = int(i.value)
+		k.updateSpeed()
+		k.mu.Unlock()
+	case cmdCancelSpeed:
+		k.mu.Lock()
+		k.bufferWPM = 0
+		k.updateSpeed()
+		k.mu.Unlock()
+	}
+}
+
+func wait(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,241 @@
+package winkey
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+)
+
+const sampleRate = 8000
+
+// host runs a keyer with a modulator that is rendered ten times faster than real time and decoded.
+type host struct {
+	keyer    *Keyer
+	conn     net.Conn
+	received chan byte
+
+	mu      sync.Mutex
+	decoded strings.Builder
+	ptt     []bool
+}
+
+func startHost(t *testing.T) *host {
+	result := &host{received: make(chan byte, 1000)}
+	modulator := cw.NewModulator(700, 20)
+	port, conn := net.Pipe()
+	result.conn = conn
+	result.keyer = New(modulator, port, Config{PTT: func(on bool) {
+		result.mu.Lock()
+		defer result.mu.Unlock()
+		result.ptt = append(result.ptt, on)
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+		port.Close()
+	})
+	go func() {
+		demodulator := cw.NewDemodulator(700, sampleRate, 30, func(r rune) {
+			result.mu.Lock()
+			defer result.mu.Unlock()
+			result.decoded.WriteRune(r)
+		})
+		renderer := audio.NewRenderer(modulator, sampleRate)
+		block := make([]float64, sampleRate/100)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renderer.Render(block)
+				demodulator.WriteSamples(block)
+			}
+		}
+	}()
+	go result.keyer.Run(ctx)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			n, err := conn.Read(buffer)
+			if err != nil {
+				return
+			}
+			for _, b := range buffer[:n] {
+				result.received <- b
+			}
+		}
+	}()
+	return result
+}
+
+func (h *host) send(t *testing.T, bytes ...byte) {
+	_, err := h.conn.Write(bytes)
+	require.NoError(t, err)
+}
+
+func (h *host) receive(t *testing.T) byte {
+	select {
+	case b := <-h.received:
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+		return 0
+	}
+}
+
+// waitFor receives bytes until the given byte is received.
+func (h *host) waitFor(t *testing.T, expected byte) {
+	for h.receive(t) != expected {
+	}
+}
+
+// waitForBusy receives bytes until a status with the busy bit is received.
+func (h *host) waitForBusy(t *testing.T) {
+	for {
+		b := h.receive(t)
+		if b&statusTag == statusTag && b&statusBusy != 0 {
+			return
+		}
+	}
+}
+
+func (h *host) Decoded() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.decoded.String()
+}
+
+func (h *host) PTT() []bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]bool{}, h.ptt...)
+}
+
+func TestOpenAndSpeed(t *testing.T) {
+	host := startHost(t)
+
+	host.send(t, cmdAdmin, adminHostOpen)
+	assert.Equal(t, byte(Version), host.receive(t))
+	assert.True(t, host.keyer.Open())
+	host.send(t, cmdAdmin, adminEcho, 0x55)
+	assert.Equal(t, byte(0x55), host.receive(t))
+
+	host.send(t, cmdGetSpeedPot)
+	assert.Equal(t, byte(speedPotTag|10), host.receive(t))
+	assert.Equal(t, DefaultPotWPM, host.keyer.WPM())
+
+	host.keyer.SetSpeedPot(50)
+	assert.Equal(t, byte(speedPotTag|DefaultWPMRange), host.receive(t), "limited to the range of the pot")
+	assert.Equal(t, DefaultMinWPM+DefaultWPMRange, host.keyer.WPM())
+
+	host.send(t, cmdSpeed, 28)
+	host.send(t, cmdStatus)
+	assert.Equal(t, byte(statusTag), host.receive(t))
+	assert.Equal(t, 28, host.keyer.WPM())
+
+	host.send(t, cmdSpeedPotSetup, 5, 30, 0)
+	host.send(t, cmdSpeed, 0)
+	host.send(t, cmdStatus)
+	host.receive(t)
+	assert.Equal(t, 35, host.keyer.WPM())
+
+	host.send(t, cmdAdmin, adminHostClose)
+	host.send(t, cmdAdmin, adminEcho, 0x33)
+	assert.Equal(t, byte(0x33), host.receive(t))
+	assert.False(t, host.keyer.Open())
+}
+
+func TestSendMessage(t *testing.T) {
+	host := startHost(t)
+
+	host.send(t, cmdAdmin, adminHostOpen)
+	host.receive(t)
+	host.send(t, cmdSpeed, 30, cmdPTTLeadTail, 1, 5, cmdMode, modeSerialEcho)
+	host.send(t, []byte("CQ ")...)
+	host.send(t, cmdMerge, 'A', 'R')
+
+	host.waitForBusy(t)
+	assert.Equal(t, byte('C'), host.receive(t))
+	assert.Equal(t, byte('Q'), host.receive(t))
+	assert.Equal(t, byte(' '), host.receive(t))
+	host.waitFor(t, statusTag)
+	assert.Contains(t, host.Decoded(), "cq")
+
+	assert.Eventually(t, func() bool {
+		ptt := host.PTT()
+		return len(ptt) == 2 && ptt[0] && !ptt[1]
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClearBuffer(t *testing.T) {
+	host := startHost(t)
+
+	host.send(t, cmdAdmin, adminHostOpen)
+	host.receive(t)
+	host.send(t, cmdSpeed, 30)
+	host.send(t, []byte(strings.Repeat("PARIS ", 20))...)
+	host.waitForBusy(t)
+	host.send(t, cmdClearBuffer)
+	host.waitFor(t, statusTag)
+	assert.NotContains(t, host.Decoded(), "paris paris paris")
+}
+
+func TestBufferLimit(t *testing.T) {
+	keyer := New(cw.NewModulator(700, 20), nil, Config{})
+	keyer.mu.Lock()
+	defer keyer.mu.Unlock()
+
+	keyer.enqueue(item{text: "A"})
+	keyer.enqueue(item{text: "B"})
+	for i := 0; i < BufferSize; i++ {
+		keyer.enqueue(item{text: "C"})
+	}
+	assert.Equal(t, BufferSize, len(keyer.buffer))
+	assert.Equal(t, byte(statusTag|statusXOFF), keyer.currentStatus())
+
+	keyer.clear()
+	assert.Empty(t, keyer.buffer)
+	assert.Equal(t, byte(statusTag), keyer.currentStatus())
+}
+
+func TestPTY(t *testing.T) {
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("no pseudo terminals")
+	}
+	pty, err := OpenPTY()
+	require.NoError(t, err)
+	defer pty.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go New(cw.NewModulator(700, 20), pty, Config{}).Run(ctx)
+
+	terminal, err := os.OpenFile(pty.Name(), os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer terminal.Close()
+	_, err = terminal.Write([]byte{cmdAdmin, adminHostOpen, cmdAdmin, adminEcho, '\n'})
+	require.NoError(t, err)
+
+	reply := make([]byte, 2)
+	n := 0
+	for n < len(reply) {
+		m, err := terminal.Read(reply[n:])
+		require.NoError(t, err)
+		n += m
+	}
+	assert.Equal(t, []byte{Version, '\n'}, reply, "raw mode")
+}
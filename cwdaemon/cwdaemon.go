@@ -0,0 +1,332 @@
+/*
+Package cwdaemon implements a server for the UDP protocol of cwdaemon. Contest loggers like TLF, or N1MM Logger+
+through a gateway, send the text of their CW messages to the server, which transmits them through a cw.Modulator.
+
+A datagram either contains the text to send or a command, which starts with ESC (0x1b) and a command character.
+The server supports the following commands:
+
+	ESC 0            reset the speed and the PTT delay and abort the current message
+	ESC 2 <wpm>      set the speed (4-60 WpM)
+	ESC 4            abort the current message
+	ESC 5            stop the server
+	ESC a <0|1>      switch the PTT off or on
+	ESC d <ms>       set the delay between PTT on and the start of the message (0-50 ms)
+	ESC h <text>     reply "h<text>" when the next message is sent completely
+
+The other commands of cwdaemon (tone, weighting, device, tune, sound and band switching) are ignored. In the text,
+"+" and "-" increase or decrease the speed by cw.SpeedStep and "~" is ignored, like in cwdaemon.
+*/
+package cwdaemon
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/cw"
+)
+
+// DefaultAddress is the default address of the cwdaemon server.
+const DefaultAddress = ":6789"
+
+// DefaultWPM is the default speed in WpM.
+const DefaultWPM = 24
+
+// The limits of the parameters.
+const (
+	MinWPM      = 4
+	MaxWPM      = 60
+	MaxPTTDelay = 50 * time.Millisecond
+)
+
+// escape starts a command.
+const escape = 0x1b
+
+// maxDatagramSize is the maximum size of a datagram.
+const maxDatagramSize = 256
+
+// Config of a Server.
+type Config struct {
+	// WPM is the initial speed in WpM. 0 means DefaultWPM.
+	WPM int
+	// PTT switches the transmitter. It is called with true before a message is sent and with false when no message
+	// is left. It is optional.
+	PTT func(bool)
+	// PTTDelay is the initial delay between PTT on and the start of a message.
+	PTTDelay time.Duration
+}
+
+// message is a text message that waits to be sent.
+type message struct {
+	ctx   context.Context
+	text  string
+	echo  string
+	reply net.Addr
+}
+
+// Server receives cwdaemon datagrams and sends the messages through a cw.Modulator. The modulator must be rendered
+// to the audio output by the application.
+type Server struct {
+	modulator *cw.Modulator
+	config    Config
+
+	mu        sync.Mutex
+	ctx       context.Context
+	abort     context.CancelFunc
+	wpm       int
+	pttDelay  time.Duration
+	manualPTT bool
+	keyed     bool
+	echo      string
+	echoTo    net.Addr
+	queue     []message
+	queued    chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+	conn      net.PacketConn
+}
+
+// NewServer returns a new Server that sends the messages through the given modulator.
+func NewServer(modulator *cw.Modulator, config Config) *Server {
+	if config.WPM == 0 {
+		config.WPM = DefaultWPM
+	}
+	result := &Server{
+		modulator: modulator,
+		config:    config,
+		queued:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+	result.ctx, result.abort = context.WithCancel(context.Background())
+	result.reset()
+	return result
+}
+
+// ListenAndServe listens on the given UDP address and serves the cwdaemon protocol until the context is done or
+// the server is stopped.
+func ListenAndServe(ctx context.Context, address string, modulator *cw.Modulator, config Config) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return NewServer(modulator, config).Serve(ctx, conn)
+}
+
+// Serve handles the datagrams received through the given connection until the context is done or the server is
+// stopped by a command. It returns the error of the context or nil, if the server was stopped.
+func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.stop:
+		}
+		conn.SetReadDeadline(time.Now())
+	}()
+	senderDone := make(chan struct{})
+	go func() {
+		defer close(senderDone)
+		s.send(ctx)
+	}()
+	defer func() {
+		cancel()
+		s.Abort()
+		<-senderDone
+	}()
+
+	buffer := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.stop:
+			return nil
+		default:
+		}
+		if err != nil {
+			return err
+		}
+		s.Handle(buffer[:n], addr)
+	}
+}
+
+// Handle handles the given datagram that was received from the given address.
+func (s *Server) Handle(datagram []byte, from net.Addr) {
+	if len(datagram) == 0 {
+		return
+	}
+	if datagram[0] != escape {
+		s.enqueue(string(datagram))
+		return
+	}
+	if len(datagram) < 2 {
+		return
+	}
+	argument := strings.TrimSpace(string(datagram[2:]))
+	switch datagram[1] {
+	case '0':
+		s.Abort()
+		s.mu.Lock()
+		s.reset()
+		s.mu.Unlock()
+	case '2':
+		wpm, err := strconv.Atoi(argument)
+		if err == nil && wpm >= MinWPM && wpm <= MaxWPM {
+			s.SetWPM(wpm)
+		}
+	case '4':
+		s.Abort()
+	case '5':
+		s.stopOnce.Do(func() { close(s.stop) })
+	case 'a':
+		s.SetPTT(argument == "1")
+	case 'd':
+		delay, err := strconv.Atoi(argument)
+		if err == nil && delay >= 0 && time.Duration(delay)*time.Millisecond <= MaxPTTDelay {
+			s.mu.Lock()
+			s.pttDelay = time.Duration(delay) * time.Millisecond
+			s.mu.Unlock()
+		}
+	case 'h':
+		s.mu.Lock()
+		s.echo = string(datagram[2:])
+		s.echoTo = from
+		s.mu.Unlock()
+	}
+}
+
+// reset sets the speed and the PTT delay to the configured values, the caller must hold the lock.
+func (s *Server) reset() {
+	s.wpm = s.config.WPM
+	s.modulator.SetWPM(s.wpm)
+	s.pttDelay = s.config.PTTDelay
+	s.echo = ""
+	s.echoTo = nil
+}
+
+// WPM returns the current speed in WpM.
+func (s *Server) WPM() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wpm
+}
+
+// SetWPM sets the speed in WpM. It takes effect at the next break between characters.
+func (s *Server) SetWPM(wpm int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wpm = wpm
+	s.modulator.SetWPM(wpm)
+}
+
+// SetPTT switches the PTT on or off manually. While the PTT is switched on manually, it stays on between the
+// messages.
+func (s *Server) SetPTT(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manualPTT = on
+	if on || len(s.queue) == 0 {
+		s.setKeyed(on)
+	}
+}
+
+// setKeyed switches the PTT, the caller must hold the lock.
+func (s *Server) setKeyed(on bool) {
+	if s.keyed == on {
+		return
+	}
+	s.keyed = on
+	if s.config.PTT != nil {
+		s.config.PTT(on)
+	}
+}
+
+// Abort aborts the current message and drops all queued messages.
+func (s *Server) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.abort()
+	s.ctx, s.abort = context.WithCancel(context.Background())
+	s.queue = nil
+}
+
+func (s *Server) enqueue(text string) {
+	text = strings.TrimRight(text, "\r\n")
+	text = strings.NewReplacer("+", "^+", "-", "^-", "~", "").Replace(text)
+
+	s.mu.Lock()
+	s.queue = append(s.queue, message{ctx: s.ctx, text: text, echo: s.echo, reply: s.echoTo})
+	s.echo = ""
+	s.echoTo = nil
+	s.mu.Unlock()
+
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the next message from the queue and switches the PTT on, if necessary.
+func (s *Server) next() (message, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		if !s.manualPTT {
+			s.setKeyed(false)
+		}
+		return message{}, 0, false
+	}
+	result := s.queue[0]
+	s.queue = s.queue[1:]
+	var delay time.Duration
+	if !s.keyed {
+		s.setKeyed(true)
+		delay = s.pttDelay
+	}
+	return result, delay, true
+}
+
+// send sends the queued messages until the context is done.
+func (s *Server) send(ctx context.Context) {
+	for {
+		msg, delay, ok := s.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.queued:
+				continue
+			}
+		}
+
+		if delay > 0 {
+			select {
+			case <-msg.ctx.Done():
+			case <-time.After(delay):
+			}
+		}
+		_, err := s.modulator.WriteContext(msg.ctx, []byte(msg.text))
+		if err == nil && msg.echo != "" && msg.reply != nil {
+			s.reply(msg.reply, "h"+msg.echo)
+		}
+	}
+}
+
+func (s *Server) reply(to net.Addr, text string) {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn != nil {
+		conn.WriteTo([]byte(text), to)
+	}
+}
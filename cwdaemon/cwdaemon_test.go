@@ -0,0 +1,164 @@
+package cwdaemon
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+)
+
+const sampleRate = 8000
+
+// station runs a server with a modulator that is rendered ten times faster than real time and decoded.
+type station struct {
+	server *Server
+	client net.Conn
+	served chan error
+
+	mu      sync.Mutex
+	decoded strings.Builder
+	ptt     []bool
+}
+
+func startStation(t *testing.T) *station {
+	result := &station{served: make(chan error, 1)}
+	modulator := cw.NewModulator(700, 30)
+	result.server = NewServer(modulator, Config{WPM: 30, PTT: func(on bool) {
+		result.mu.Lock()
+		defer result.mu.Unlock()
+		result.ptt = append(result.ptt, on)
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		demodulator := cw.NewDemodulator(700, sampleRate, 30, func(r rune) {
+			result.mu.Lock()
+			defer result.mu.Unlock()
+			result.decoded.WriteRune(r)
+		})
+		renderer := audio.NewRenderer(modulator, sampleRate)
+		block := make([]float64, sampleRate/100)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renderer.Render(block)
+				demodulator.WriteSamples(block)
+			}
+		}
+	}()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		result.served <- result.server.Serve(ctx, conn)
+	}()
+
+	result.client, err = net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { result.client.Close() })
+	return result
+}
+
+func (s *station) send(t *testing.T, datagram string) {
+	_, err := s.client.Write([]byte(datagram))
+	require.NoError(t, err)
+}
+
+func (s *station) waitForReply(t *testing.T, timeout time.Duration) string {
+	s.client.SetReadDeadline(time.Now().Add(timeout))
+	buffer := make([]byte, maxDatagramSize)
+	n, err := s.client.Read(buffer)
+	require.NoError(t, err)
+	return string(buffer[:n])
+}
+
+func (s *station) Decoded() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.decoded.String()
+}
+
+func (s *station) PTT() []bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]bool{}, s.ptt...)
+}
+
+func TestSendMessage(t *testing.T) {
+	station := startStation(t)
+
+	station.send(t, "\x1bhdone")
+	station.send(t, "cq test\r\n")
+	assert.Equal(t, "hdone", station.waitForReply(t, 5*time.Second))
+	assert.Contains(t, strings.ToUpper(station.Decoded()), "CQ TEST")
+
+	assert.Eventually(t, func() bool {
+		ptt := station.PTT()
+		return len(ptt) == 2 && ptt[0] && !ptt[1]
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAbort(t *testing.T) {
+	station := startStation(t)
+
+	station.send(t, strings.Repeat("paris ", 20))
+	time.Sleep(100 * time.Millisecond)
+	station.send(t, "\x1b4")
+	station.send(t, "\x1bhdone")
+	station.send(t, "test")
+	assert.Equal(t, "hdone", station.waitForReply(t, 2*time.Second))
+	assert.NotContains(t, strings.ToUpper(station.Decoded()), "PARIS PARIS PARIS")
+}
+
+func TestStop(t *testing.T) {
+	station := startStation(t)
+
+	station.send(t, "\x1b5")
+	select {
+	case err := <-station.served:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the server did not stop")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	var ptt []bool
+	server := NewServer(cw.NewModulator(700, 20), Config{PTT: func(on bool) { ptt = append(ptt, on) }})
+	assert.Equal(t, DefaultWPM, server.WPM())
+
+	server.Handle([]byte("\x1b232"), nil)
+	assert.Equal(t, 32, server.WPM())
+	server.Handle([]byte("\x1b2100"), nil)
+	assert.Equal(t, 32, server.WPM(), "out of range")
+	server.Handle([]byte("\x1bd20"), nil)
+	assert.Equal(t, 20*time.Millisecond, server.pttDelay)
+
+	server.Handle([]byte("\x1ba1"), nil)
+	server.Handle([]byte("\x1ba0"), nil)
+	assert.Equal(t, []bool{true, false}, ptt)
+
+	server.Handle([]byte("\x1b0"), nil)
+	assert.Equal(t, DefaultWPM, server.WPM())
+	assert.Equal(t, time.Duration(0), server.pttDelay)
+
+	server.Handle([]byte("\x1b37"), nil)
+	server.Handle([]byte("\x1b"), nil)
+	server.Handle([]byte("a+b-c~d"), nil)
+	require.Len(t, server.queue, 1)
+	assert.Equal(t, "a^+b^-cd", server.queue[0].text)
+}
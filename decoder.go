@@ -0,0 +1,145 @@
+/*
+Package digimodes defines the common interfaces of the digital modes that are implemented in the subpackages.
+*/
+package digimodes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDecoderClosed is returned when feeding samples into a closed decoder.
+var ErrDecoderClosed = errors.New("digimodes: decoder closed")
+
+// DecodeRecord is a piece of information that was decoded from a received signal.
+type DecodeRecord struct {
+	// Time is the UTC time of the start of the decoded signal.
+	Time time.Time
+	// Mode is the name of the mode, e.g. "psk31".
+	Mode string
+	// AudioFrequency is the audio frequency of the decoded signal in Hz.
+	AudioFrequency float64
+	// RFFrequency is the radio frequency of the decoded signal in Hz, or 0 if the dial frequency is unknown.
+	RFFrequency float64
+	// Text is the decoded text.
+	Text string
+	// SNR is the signal to noise ratio of the decoded signal in dB.
+	SNR float64
+}
+
+// Decoder is the common interface of all decoders.
+//
+// Samples are fed into the decoder in blocks, the decoded records are delivered through the channel
+// returned by Records. The records channel is buffered. If the buffer is full, Feed blocks until the consumer
+// receives the next record or the given context is done (backpressure). In the latter case, the pending record
+// is discarded and Feed returns the error of the context. Consumers must therefore always drain the records
+// channel or cancel the context.
+//
+// Close releases the decoder and closes the records channel. After Close, Feed returns ErrDecoderClosed.
+type Decoder interface {
+	// Feed decodes the given samples.
+	Feed(ctx context.Context, samples []float64) error
+	// Records returns the channel of decoded records.
+	Records() <-chan DecodeRecord
+	// Close closes the decoder.
+	Close() error
+}
+
+// RecordQueue implements the delivery of decode records according to the rules of the Decoder interface.
+// Decoder implementations can use it to provide the records channel.
+type RecordQueue struct {
+	records chan DecodeRecord
+	done    chan struct{}
+
+	mu        sync.RWMutex
+	closeOnce sync.Once
+}
+
+// NewRecordQueue returns a new RecordQueue that buffers up to size records.
+func NewRecordQueue(size int) *RecordQueue {
+	return &RecordQueue{
+		records: make(chan DecodeRecord, size),
+		done:    make(chan struct{}),
+	}
+}
+
+// Emit delivers the given record. It blocks while the queue is full until the record is delivered,
+// the context is done, or the queue is closed.
+func (q *RecordQueue) Emit(ctx context.Context, record DecodeRecord) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	select {
+	case <-q.done:
+		return ErrDecoderClosed
+	default:
+	}
+
+	select {
+	case q.records <- record:
+		return nil
+	case <-q.done:
+		return ErrDecoderClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Records returns the channel of delivered records.
+func (q *RecordQueue) Records() <-chan DecodeRecord {
+	return q.records
+}
+
+// Closed indicates if the queue is closed.
+func (q *RecordQueue) Closed() bool {
+	select {
+	case <-q.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the queue and the records channel. Blocked emitters return ErrDecoderClosed.
+func (q *RecordQueue) Close() error {
+	q.closeOnce.Do(func() {
+		close(q.done)
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		close(q.records)
+	})
+	return nil
+}
+
+// SampleClock keeps track of the time of the samples that are fed into a decoder.
+type SampleClock struct {
+	start      time.Time
+	sampleRate int
+	count      int64
+}
+
+// NewSampleClock returns a SampleClock for samples at the given rate, where the first sample was taken at the given start time.
+func NewSampleClock(start time.Time, sampleRate int) *SampleClock {
+	return &SampleClock{
+		start:      start.UTC(),
+		sampleRate: sampleRate,
+	}
+}
+
+// Advance advances the clock by the given number of samples.
+func (c *SampleClock) Advance(samples int) {
+	c.count += int64(samples)
+}
+
+// Now returns the time of the next sample.
+func (c *SampleClock) Now() time.Time {
+	return c.At(0)
+}
+
+// At returns the time of the sample with the given offset to the next sample. The offset may be negative.
+func (c *SampleClock) At(offset int) time.Time {
+	samples := c.count + int64(offset)
+	return c.start.Add(time.Duration(samples) * time.Second / time.Duration(c.sampleRate))
+}
@@ -0,0 +1,57 @@
+package digimodes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordQueue(t *testing.T) {
+	q := NewRecordQueue(1)
+
+	err := q.Emit(context.Background(), DecodeRecord{Text: "one"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = q.Emit(ctx, DecodeRecord{Text: "two"})
+	assert.Equal(t, context.DeadlineExceeded, err, "the queue is full")
+
+	record := <-q.Records()
+	assert.Equal(t, "one", record.Text)
+}
+
+func TestRecordQueueClose(t *testing.T) {
+	q := NewRecordQueue(1)
+	q.Emit(context.Background(), DecodeRecord{Text: "one"})
+
+	emitted := make(chan error)
+	go func() {
+		emitted <- q.Emit(context.Background(), DecodeRecord{Text: "two"})
+	}()
+	time.Sleep(time.Millisecond)
+	q.Close()
+	q.Close()
+
+	assert.Equal(t, ErrDecoderClosed, <-emitted)
+	assert.True(t, q.Closed())
+	record, ok := <-q.Records()
+	assert.True(t, ok)
+	assert.Equal(t, "one", record.Text)
+	_, ok = <-q.Records()
+	assert.False(t, ok)
+	assert.Equal(t, ErrDecoderClosed, q.Emit(context.Background(), DecodeRecord{}))
+}
+
+func TestSampleClock(t *testing.T) {
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewSampleClock(start, 8000)
+
+	assert.Equal(t, start, clock.Now())
+	clock.Advance(12000)
+	assert.Equal(t, start.Add(1500*time.Millisecond), clock.Now())
+	assert.Equal(t, start.Add(time.Second), clock.At(-4000))
+}
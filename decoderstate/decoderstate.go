@@ -0,0 +1,153 @@
+/*
+Package decoderstate saves and restores the long-lived state of decoders, like callsign hash tables, AFC
+frequency memories or the adaptive CW speed per frequency. An unattended monitor can restore this context after
+a restart instead of accumulating it again.
+
+Each state is stored as a JSON file in a directory, together with the version of its format. A state with a
+different version is not restored.
+*/
+package decoderstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ftl/digimodes/timesource"
+)
+
+const fileExtension = ".json"
+
+var (
+	// ErrNotFound is returned when a state was never saved.
+	ErrNotFound = errors.New("decoderstate: state not found")
+	// ErrVersionMismatch is returned when the saved state has a different version.
+	ErrVersionMismatch = errors.New("decoderstate: version mismatch")
+)
+
+// State is the persistent state of a decoder.
+type State interface {
+	json.Marshaler
+	json.Unmarshaler
+	// StateName returns the unique name of the state, used as file name.
+	StateName() string
+	// StateVersion returns the version of the JSON format of the state.
+	StateVersion() int
+}
+
+// envelope is the content of a state file.
+type envelope struct {
+	Version int             `json:"version"`
+	Saved   time.Time       `json:"saved"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Store saves and restores states in a directory.
+type Store struct {
+	directory string
+	clock     timesource.Clock
+}
+
+// Open returns a new Store in the given directory, the directory is created if necessary. If clock is nil, the
+// system clock is used.
+func Open(directory string, clock timesource.Clock) (*Store, error) {
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	return &Store{
+		directory: directory,
+		clock:     clock,
+	}, nil
+}
+
+// Save saves the given states. Each file is replaced atomically, so that a crash never leaves a broken state.
+func (s *Store) Save(states ...State) error {
+	for _, state := range states {
+		err := s.save(state)
+		if err != nil {
+			return fmt.Errorf("decoderstate: cannot save %s: %w", state.StateName(), err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) save(state State) error {
+	data, err := state.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(envelope{
+		Version: state.StateVersion(),
+		Saved:   s.clock.Now().UTC(),
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.directory, state.StateName()+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.filename(state))
+}
+
+// Restore restores the given state and returns the time when it was saved.
+func (s *Store) Restore(state State) (time.Time, error) {
+	content, err := os.ReadFile(s.filename(state))
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	var saved envelope
+	err = json.Unmarshal(content, &saved)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decoderstate: cannot read %s: %w", state.StateName(), err)
+	}
+	if saved.Version != state.StateVersion() {
+		return saved.Saved, ErrVersionMismatch
+	}
+	err = state.UnmarshalJSON(saved.Data)
+	if err != nil {
+		return saved.Saved, fmt.Errorf("decoderstate: cannot restore %s: %w", state.StateName(), err)
+	}
+	return saved.Saved, nil
+}
+
+// RestoreAll restores all given states. States that were never saved or have a different version are skipped,
+// they start empty.
+func (s *Store) RestoreAll(states ...State) error {
+	for _, state := range states {
+		_, err := s.Restore(state)
+		if err == ErrNotFound || err == ErrVersionMismatch {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) filename(state State) string {
+	return filepath.Join(s.directory, state.StateName()+fileExtension)
+}
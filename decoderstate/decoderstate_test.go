@@ -0,0 +1,102 @@
+package decoderstate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/timesource"
+)
+
+var testTime = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type versionedState struct {
+	*FrequencyMemory
+	version int
+}
+
+func (s versionedState) StateVersion() int {
+	return s.version
+}
+
+func TestSaveRestore(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "state")
+	store, err := Open(directory, timesource.ClockFunc(func() time.Time { return testTime }))
+	require.NoError(t, err)
+
+	hashes := NewCallsignHashes("wspr-hashes", 10)
+	hashes.Add(12345, "PJ4/DL1ABC", testTime)
+	speeds := NewFrequencyMemory("cw-speed", 50)
+	speeds.Remember(700, 25, testTime)
+	require.NoError(t, store.Save(hashes, speeds))
+
+	files, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "no temporary files are left")
+
+	restoredHashes := NewCallsignHashes("wspr-hashes", 10)
+	restoredSpeeds := NewFrequencyMemory("cw-speed", 50)
+	saved, err := store.Restore(restoredHashes)
+	require.NoError(t, err)
+	assert.Equal(t, testTime, saved)
+	require.NoError(t, store.RestoreAll(restoredSpeeds))
+
+	callsign, ok := restoredHashes.Lookup(12345)
+	assert.True(t, ok)
+	assert.Equal(t, "PJ4/DL1ABC", callsign)
+	speed, ok := restoredSpeeds.Recall(710)
+	assert.True(t, ok)
+	assert.Equal(t, 25.0, speed)
+}
+
+func TestRestoreMissingOrIncompatible(t *testing.T) {
+	store, err := Open(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	_, err = store.Restore(NewFrequencyMemory("afc", 10))
+	assert.Equal(t, ErrNotFound, err)
+
+	memory := NewFrequencyMemory("afc", 10)
+	memory.Remember(1500, 2.5, testTime)
+	require.NoError(t, store.Save(versionedState{memory, 1}))
+
+	newer := versionedState{NewFrequencyMemory("afc", 10), 2}
+	_, err = store.Restore(newer)
+	assert.Equal(t, ErrVersionMismatch, err)
+	assert.Equal(t, 0, newer.Len())
+
+	require.NoError(t, store.RestoreAll(newer, NewCallsignHashes("unknown", 10)))
+}
+
+func TestRestoreBrokenFile(t *testing.T) {
+	directory := t.TempDir()
+	store, err := Open(directory, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "afc.json"), []byte("{broken"), 0o644))
+
+	_, err = store.Restore(NewFrequencyMemory("afc", 10))
+	assert.Error(t, err)
+	assert.Error(t, store.RestoreAll(NewFrequencyMemory("afc", 10)))
+}
+
+func TestFileFormat(t *testing.T) {
+	directory := t.TempDir()
+	store, err := Open(directory, timesource.ClockFunc(func() time.Time { return testTime }))
+	require.NoError(t, err)
+	hashes := NewCallsignHashes("ft8-hashes", 10)
+	hashes.Add(7, "DL1ABC/P", testTime)
+	require.NoError(t, store.Save(hashes))
+
+	content, err := os.ReadFile(filepath.Join(directory, "ft8-hashes.json"))
+	require.NoError(t, err)
+	var saved envelope
+	require.NoError(t, json.Unmarshal(content, &saved))
+	assert.Equal(t, 1, saved.Version)
+	assert.Equal(t, testTime, saved.Saved)
+	assert.JSONEq(t, `[{"hash":7,"callsign":"DL1ABC/P","seen":"2020-05-01T12:00:00Z"}]`, string(saved.Data))
+}
@@ -0,0 +1,112 @@
+package decoderstate
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// frequenciesVersion is the version of the JSON format of FrequencyMemory.
+const frequenciesVersion = 1
+
+// FrequencyMemory remembers a value per frequency, e.g. the AFC correction of a PSK signal or the speed of a CW
+// signal. The frequencies are quantized to the given resolution.
+type FrequencyMemory struct {
+	name       string
+	resolution float64
+
+	mu      sync.RWMutex
+	entries map[int64]frequencyEntry
+}
+
+type frequencyEntry struct {
+	Frequency float64   `json:"frequency"`
+	Value     float64   `json:"value"`
+	Seen      time.Time `json:"seen"`
+}
+
+// NewFrequencyMemory returns a new memory with the given state name and frequency resolution in Hz.
+func NewFrequencyMemory(name string, resolution float64) *FrequencyMemory {
+	return &FrequencyMemory{
+		name:       name,
+		resolution: resolution,
+		entries:    make(map[int64]frequencyEntry),
+	}
+}
+
+// StateName returns the name of the memory.
+func (m *FrequencyMemory) StateName() string {
+	return m.name
+}
+
+// StateVersion returns the version of the JSON format.
+func (m *FrequencyMemory) StateVersion() int {
+	return frequenciesVersion
+}
+
+func (m *FrequencyMemory) key(frequency float64) int64 {
+	return int64(math.Round(frequency / m.resolution))
+}
+
+// Remember stores the given value for the given frequency, seen at the given time.
+func (m *FrequencyMemory) Remember(frequency, value float64, seen time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[m.key(frequency)] = frequencyEntry{Frequency: frequency, Value: value, Seen: seen}
+}
+
+// Recall returns the value that was stored for the given frequency.
+func (m *FrequencyMemory) Recall(frequency float64) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.entries[m.key(frequency)]
+	return entry.Value, ok
+}
+
+// Len returns the number of stored frequencies.
+func (m *FrequencyMemory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// Expire removes all values that were not seen since the given time.
+func (m *FrequencyMemory) Expire(since time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.entries {
+		if entry.Seen.Before(since) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// MarshalJSON encodes the memory as list of entries, ordered by frequency.
+func (m *FrequencyMemory) MarshalJSON() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make([]frequencyEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Frequency < entries[j].Frequency })
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces the content of the memory.
+func (m *FrequencyMemory) UnmarshalJSON(data []byte) error {
+	var entries []frequencyEntry
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[int64]frequencyEntry, len(entries))
+	for _, entry := range entries {
+		m.entries[m.key(entry.Frequency)] = entry
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package decoderstate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrequencyMemory(t *testing.T) {
+	memory := NewFrequencyMemory("cw-speed", 50)
+	memory.Remember(700, 25, testTime)
+	memory.Remember(1000, 18, testTime)
+	memory.Remember(710, 28, testTime.Add(time.Minute))
+
+	testCases := []struct {
+		frequency float64
+		expected  float64
+		found     bool
+	}{
+		{frequency: 700, expected: 28, found: true},
+		{frequency: 690, expected: 28, found: true},
+		{frequency: 1010, expected: 18, found: true},
+		{frequency: 850},
+	}
+	for _, tC := range testCases {
+		value, ok := memory.Recall(tC.frequency)
+		assert.Equal(t, tC.found, ok, "%v", tC.frequency)
+		assert.Equal(t, tC.expected, value, "%v", tC.frequency)
+	}
+	assert.Equal(t, 2, memory.Len())
+
+	memory.Expire(testTime.Add(30 * time.Second))
+	assert.Equal(t, 1, memory.Len())
+}
+
+func TestFrequencyMemoryJSON(t *testing.T) {
+	memory := NewFrequencyMemory("afc", 10)
+	memory.Remember(1500, -1.5, testTime)
+	data, err := memory.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"frequency":1500,"value":-1.5,"seen":"2020-05-01T12:00:00Z"}]`, string(data))
+
+	restored := NewFrequencyMemory("afc", 10)
+	assert.NoError(t, restored.UnmarshalJSON(data))
+	value, ok := restored.Recall(1501)
+	assert.True(t, ok)
+	assert.Equal(t, -1.5, value)
+}
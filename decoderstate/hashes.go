@@ -0,0 +1,120 @@
+package decoderstate
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hashesVersion is the version of the JSON format of CallsignHashes.
+const hashesVersion = 1
+
+// CallsignHashes resolves the hashes of callsigns in compressed messages (e.g. WSPR type 3 or FT8 nonstandard
+// callsigns) to the callsigns that were received in full before. The hash function is defined by the mode. If the
+// table is full, the callsign that was seen least recently is removed.
+type CallsignHashes struct {
+	name     string
+	capacity int
+
+	mu      sync.RWMutex
+	entries map[uint32]hashEntry
+}
+
+type hashEntry struct {
+	Hash     uint32    `json:"hash"`
+	Callsign string    `json:"callsign"`
+	Seen     time.Time `json:"seen"`
+}
+
+// NewCallsignHashes returns a new table with the given state name and capacity.
+func NewCallsignHashes(name string, capacity int) *CallsignHashes {
+	return &CallsignHashes{
+		name:     name,
+		capacity: capacity,
+		entries:  make(map[uint32]hashEntry),
+	}
+}
+
+// StateName returns the name of the table.
+func (h *CallsignHashes) StateName() string {
+	return h.name
+}
+
+// StateVersion returns the version of the JSON format.
+func (h *CallsignHashes) StateVersion() int {
+	return hashesVersion
+}
+
+// Add adds the given callsign with its hash, seen at the given time.
+func (h *CallsignHashes) Add(hash uint32, callsign string, seen time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[hash] = hashEntry{Hash: hash, Callsign: callsign, Seen: seen}
+	if len(h.entries) <= h.capacity {
+		return
+	}
+	var oldest hashEntry
+	first := true
+	for _, entry := range h.entries {
+		if first || entry.Seen.Before(oldest.Seen) {
+			oldest = entry
+			first = false
+		}
+	}
+	delete(h.entries, oldest.Hash)
+}
+
+// Lookup returns the callsign with the given hash.
+func (h *CallsignHashes) Lookup(hash uint32) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.entries[hash]
+	return entry.Callsign, ok
+}
+
+// Len returns the number of callsigns in the table.
+func (h *CallsignHashes) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.entries)
+}
+
+// Expire removes all callsigns that were not seen since the given time.
+func (h *CallsignHashes) Expire(since time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for hash, entry := range h.entries {
+		if entry.Seen.Before(since) {
+			delete(h.entries, hash)
+		}
+	}
+}
+
+// MarshalJSON encodes the table as list of entries, ordered by hash.
+func (h *CallsignHashes) MarshalJSON() ([]byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entries := make([]hashEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hash < entries[j].Hash })
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces the content of the table.
+func (h *CallsignHashes) UnmarshalJSON(data []byte) error {
+	var entries []hashEntry
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.entries = make(map[uint32]hashEntry, len(entries))
+	h.mu.Unlock()
+	for _, entry := range entries {
+		h.Add(entry.Hash, entry.Callsign, entry.Seen)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package decoderstate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallsignHashesCapacity(t *testing.T) {
+	hashes := NewCallsignHashes("hashes", 2)
+	hashes.Add(1, "DL1ABC", testTime)
+	hashes.Add(2, "W1AW", testTime.Add(time.Minute))
+	hashes.Add(1, "DL1ABC", testTime.Add(2*time.Minute))
+	hashes.Add(3, "K1A", testTime.Add(3*time.Minute))
+
+	assert.Equal(t, 2, hashes.Len())
+	_, ok := hashes.Lookup(2)
+	assert.False(t, ok, "the least recently seen callsign is removed")
+	callsign, ok := hashes.Lookup(1)
+	assert.True(t, ok)
+	assert.Equal(t, "DL1ABC", callsign)
+}
+
+func TestCallsignHashesExpire(t *testing.T) {
+	hashes := NewCallsignHashes("hashes", 10)
+	hashes.Add(1, "DL1ABC", testTime)
+	hashes.Add(2, "W1AW", testTime.Add(time.Hour))
+
+	hashes.Expire(testTime.Add(30 * time.Minute))
+	assert.Equal(t, 1, hashes.Len())
+	_, ok := hashes.Lookup(2)
+	assert.True(t, ok)
+}
+
+func TestCallsignHashesJSON(t *testing.T) {
+	hashes := NewCallsignHashes("hashes", 10)
+	hashes.Add(2, "W1AW", testTime)
+	hashes.Add(1, "DL1ABC", testTime)
+	data, err := hashes.MarshalJSON()
+	assert.NoError(t, err)
+
+	restored := NewCallsignHashes("hashes", 1)
+	assert.NoError(t, restored.UnmarshalJSON(data))
+	assert.Equal(t, 1, restored.Len(), "the capacity is applied")
+}
@@ -0,0 +1,130 @@
+/*
+Package decodestore stores decode records and provides queries by time range, band, mode and callsign. When a
+record is added, the store detects callsigns and grid squares that were not seen on the band before ("new call",
+"new grid").
+
+Store is the interface of the storage backends, MemoryStore is the in-memory backend.
+*/
+package decodestore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/bandplan"
+)
+
+// ErrClosed is returned when a closed store is used.
+var ErrClosed = errors.New("decodestore: store closed")
+
+// Record is a decode record together with the information derived from it.
+type Record struct {
+	digimodes.DecodeRecord
+	// ID identifies the record within its store. IDs increase in the order the records were added.
+	ID int64
+	// Band is the band of the RF frequency, or empty if the RF frequency is unknown or outside the amateur bands.
+	Band bandplan.BandName
+	// Callsigns are the callsigns contained in the text.
+	Callsigns []string
+	// Locators are the grid squares contained in the text.
+	Locators []string
+	// NewCallsigns are the callsigns that were not seen on the band before this record.
+	NewCallsigns []string
+	// NewLocators are the grid squares that were not seen on the band before this record.
+	NewLocators []string
+}
+
+// NewRecord returns a new Record with the information derived from the given decode record. The ID and the
+// novelty information are left to the store.
+func NewRecord(region bandplan.Region, decode digimodes.DecodeRecord) Record {
+	result := Record{
+		DecodeRecord: decode,
+		Callsigns:    Callsigns(decode.Text),
+		Locators:     Locators(decode.Text),
+	}
+	if decode.RFFrequency != 0 {
+		band, ok := bandplan.BandOf(region, bandplan.Frequency(decode.RFFrequency))
+		if ok {
+			result.Band = band.Name
+		}
+	}
+	return result
+}
+
+// Query selects records. Zero values match all records.
+type Query struct {
+	// From is the earliest time of a record (inclusive).
+	From time.Time
+	// To is the latest time of a record (exclusive).
+	To time.Time
+	// Band is the band of a record.
+	Band bandplan.BandName
+	// Mode is the mode of a record, case insensitive.
+	Mode string
+	// Callsign is a callsign that a record must contain, case insensitive.
+	Callsign string
+	// Limit is the maximum number of records in the result. If the limit is exceeded, the latest records are
+	// returned.
+	Limit int
+}
+
+// Matches indicates if the given record matches the query. The limit is not taken into account.
+func (q Query) Matches(r Record) bool {
+	if !q.From.IsZero() && r.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !r.Time.Before(q.To) {
+		return false
+	}
+	if q.Band != "" && r.Band != q.Band {
+		return false
+	}
+	if q.Mode != "" && !strings.EqualFold(r.Mode, q.Mode) {
+		return false
+	}
+	if q.Callsign != "" && !containsFold(r.Callsigns, q.Callsign) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is the common interface of all storage backends.
+type Store interface {
+	// Add stores the given decode record and returns the stored record with the derived information.
+	Add(ctx context.Context, decode digimodes.DecodeRecord) (Record, error)
+	// Query returns the records that match the given query, ordered by time.
+	Query(ctx context.Context, query Query) ([]Record, error)
+	// Close releases the store.
+	Close() error
+}
+
+// Collect adds all records from the given channel to the store until the channel is closed or the context is
+// done. It can be used to store the records of a digimodes.Decoder.
+func Collect(ctx context.Context, store Store, records <-chan digimodes.DecodeRecord) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case record, ok := <-records:
+			if !ok {
+				return nil
+			}
+			_, err := store.Add(ctx, record)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
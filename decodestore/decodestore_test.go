@@ -0,0 +1,117 @@
+package decodestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/bandplan"
+)
+
+func TestCallsignsAndLocators(t *testing.T) {
+	testCases := []struct {
+		text      string
+		callsigns []string
+		locators  []string
+	}{
+		{"CQ DL1ABC JO31", []string{"DL1ABC"}, []string{"JO31"}},
+		{"dl1abc k1a rr73", []string{"DL1ABC", "K1A"}, nil},
+		{"CQ TEST PA/DL1ABC/P JO31AB", []string{"PA/DL1ABC/P"}, []string{"JO31"}},
+		{"2E0ABC 9A1AA 3DA0XY 5NN 599 73", []string{"2E0ABC", "9A1AA", "3DA0XY"}, nil},
+		{"UR5X DE W1AW FN31 FN31PR", []string{"UR5X", "W1AW"}, []string{"FN31"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			assert.Equal(t, tC.callsigns, Callsigns(tC.text))
+			assert.Equal(t, tC.locators, Locators(tC.text))
+		})
+	}
+}
+
+func TestMemoryStoreNovelty(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(bandplan.Region1, 0)
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	record, err := store.Add(ctx, digimodes.DecodeRecord{Time: start, Mode: "ft8", RFFrequency: 14074000, Text: "CQ DL1ABC JO31"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), record.ID)
+	assert.Equal(t, bandplan.BandName("20m"), record.Band)
+	assert.Equal(t, []string{"DL1ABC"}, record.NewCallsigns)
+	assert.Equal(t, []string{"JO31"}, record.NewLocators)
+
+	record, err = store.Add(ctx, digimodes.DecodeRecord{Time: start, Mode: "ft8", RFFrequency: 14074000, Text: "W1AW DL1ABC JO31"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), record.ID)
+	assert.Equal(t, []string{"W1AW"}, record.NewCallsigns)
+	assert.Empty(t, record.NewLocators)
+
+	record, err = store.Add(ctx, digimodes.DecodeRecord{Time: start, Mode: "ft8", RFFrequency: 7074000, Text: "CQ DL1ABC JO31"})
+	require.NoError(t, err)
+	assert.Equal(t, bandplan.BandName("40m"), record.Band)
+	assert.Equal(t, []string{"DL1ABC"}, record.NewCallsigns, "new on band")
+	assert.Equal(t, []string{"JO31"}, record.NewLocators, "new on band")
+}
+
+func TestMemoryStoreQuery(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(bandplan.Region1, 4)
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	decodes := []digimodes.DecodeRecord{
+		{Time: start.Add(3 * time.Minute), Mode: "psk31", RFFrequency: 14070000, Text: "CQ W1AW"},
+		{Time: start, Mode: "ft8", RFFrequency: 14074000, Text: "CQ DL1ABC JO31"},
+		{Time: start.Add(time.Minute), Mode: "ft8", RFFrequency: 7074000, Text: "CQ DL1ABC JO31"},
+		{Time: start.Add(2 * time.Minute), Mode: "ft8", RFFrequency: 14074000, Text: "DL1ABC W1AW FN31"},
+		{Time: start.Add(4 * time.Minute), Mode: "FT8", Text: "CQ K1A FN42"},
+	}
+	for _, decode := range decodes {
+		_, err := store.Add(ctx, decode)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, store.Len(), "the oldest record is dropped")
+
+	testCases := []struct {
+		desc     string
+		query    Query
+		expected []string
+	}{
+		{"all", Query{}, []string{"CQ DL1ABC JO31", "DL1ABC W1AW FN31", "CQ W1AW", "CQ K1A FN42"}},
+		{"time range", Query{From: start.Add(2 * time.Minute), To: start.Add(4 * time.Minute)}, []string{"DL1ABC W1AW FN31", "CQ W1AW"}},
+		{"band", Query{Band: "20m"}, []string{"DL1ABC W1AW FN31", "CQ W1AW"}},
+		{"mode", Query{Mode: "ft8"}, []string{"CQ DL1ABC JO31", "DL1ABC W1AW FN31", "CQ K1A FN42"}},
+		{"callsign", Query{Callsign: "w1aw"}, []string{"DL1ABC W1AW FN31", "CQ W1AW"}},
+		{"limit", Query{Mode: "ft8", Limit: 1}, []string{"CQ K1A FN42"}},
+		{"nothing", Query{Callsign: "N0CALL"}, []string{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			records, err := store.Query(ctx, tC.query)
+			require.NoError(t, err)
+			actual := make([]string, len(records))
+			for i, record := range records {
+				actual[i] = record.Text
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	store := NewMemoryStore(bandplan.Region1, 0)
+	records := make(chan digimodes.DecodeRecord, 2)
+	records <- digimodes.DecodeRecord{Text: "CQ DL1ABC"}
+	records <- digimodes.DecodeRecord{Text: "CQ W1AW"}
+	close(records)
+
+	err := Collect(context.Background(), store, records)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	store.Close()
+	_, err = store.Add(context.Background(), digimodes.DecodeRecord{})
+	assert.Equal(t, ErrClosed, err)
+}
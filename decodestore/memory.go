@@ -0,0 +1,125 @@
+package decodestore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/bandplan"
+)
+
+// MemoryStore is a Store that keeps the records in memory.
+type MemoryStore struct {
+	region   bandplan.Region
+	capacity int
+
+	mu            sync.RWMutex
+	records       []Record
+	nextID        int64
+	seenCallsigns map[seenKey]bool
+	seenLocators  map[seenKey]bool
+	closed        bool
+}
+
+type seenKey struct {
+	band  bandplan.BandName
+	value string
+}
+
+// NewMemoryStore returns a new MemoryStore for the given IARU region. If capacity is greater than zero, the store
+// keeps at most capacity records and drops the oldest records first. Callsigns and grid squares of dropped records
+// are still known to the novelty detection.
+func NewMemoryStore(region bandplan.Region, capacity int) *MemoryStore {
+	return &MemoryStore{
+		region:        region,
+		capacity:      capacity,
+		nextID:        1,
+		seenCallsigns: make(map[seenKey]bool),
+		seenLocators:  make(map[seenKey]bool),
+	}
+}
+
+// Add stores the given decode record.
+func (s *MemoryStore) Add(ctx context.Context, decode digimodes.DecodeRecord) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+	record := NewRecord(s.region, decode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return Record{}, ErrClosed
+	}
+
+	record.ID = s.nextID
+	s.nextID++
+	record.NewCallsigns = markSeen(s.seenCallsigns, record.Band, record.Callsigns)
+	record.NewLocators = markSeen(s.seenLocators, record.Band, record.Locators)
+
+	// keep the records ordered by time, decoders may deliver records slightly out of order
+	i := sort.Search(len(s.records), func(i int) bool {
+		return s.records[i].Time.After(record.Time)
+	})
+	s.records = append(s.records, Record{})
+	copy(s.records[i+1:], s.records[i:])
+	s.records[i] = record
+
+	if s.capacity > 0 && len(s.records) > s.capacity {
+		s.records = append(s.records[:0], s.records[len(s.records)-s.capacity:]...)
+	}
+	return record, nil
+}
+
+func markSeen(seen map[seenKey]bool, band bandplan.BandName, values []string) []string {
+	var result []string
+	for _, value := range values {
+		key := seenKey{band: band, value: value}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, value)
+	}
+	return result
+}
+
+// Query returns the records that match the given query, ordered by time.
+func (s *MemoryStore) Query(ctx context.Context, query Query) ([]Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	result := make([]Record, 0)
+	for _, record := range s.records {
+		if query.Matches(record) {
+			result = append(result, record)
+		}
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+	return result, nil
+}
+
+// Len returns the number of stored records.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// Close releases the records. After Close, Add and Query return ErrClosed.
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.records = nil
+	return nil
+}
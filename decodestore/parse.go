@@ -0,0 +1,54 @@
+package decodestore
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	callsignExpression = regexp.MustCompile(`^([A-Z0-9]{1,4}/)?[A-Z0-9]{0,2}[A-Z][0-9][A-Z0-9]*[A-Z](/[A-Z0-9]{1,4})?$`)
+	locatorExpression  = regexp.MustCompile(`^[A-R]{2}[0-9]{2}([A-X]{2})?$`)
+)
+
+// nonLocators are words that look like locators, but have a special meaning in the digital modes.
+var nonLocators = map[string]bool{
+	"RR73": true,
+}
+
+// Callsigns returns all words of the given text that look like callsigns, in upper case.
+func Callsigns(text string) []string {
+	var result []string
+	for _, word := range words(text) {
+		if callsignExpression.MatchString(word) && !locatorExpression.MatchString(word) {
+			result = appendUnique(result, word)
+		}
+	}
+	return result
+}
+
+// Locators returns the grid squares (the first four characters) of all words of the given text that look like
+// Maidenhead locators, in upper case.
+func Locators(text string) []string {
+	var result []string
+	for _, word := range words(text) {
+		if locatorExpression.MatchString(word) && !nonLocators[word] {
+			result = appendUnique(result, word[:4])
+		}
+	}
+	return result
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '/')
+	})
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,93 @@
+package dsp
+
+import (
+	"math"
+	"time"
+)
+
+// Default parameters of the AGC.
+const (
+	DefaultAGCTarget  = 0.5
+	DefaultAGCAttack  = 5 * time.Millisecond
+	DefaultAGCDecay   = 500 * time.Millisecond
+	DefaultAGCMaxGain = 1000.0
+)
+
+// AGC is an automatic gain control that keeps the peak level of the samples at the target level. The envelope of
+// the signal follows rising levels with the attack time and falling levels with the decay time. It is a Processor
+// that scales the samples in place.
+type AGC struct {
+	sampleRate int
+	target     float64
+	maxGain    float64
+	attack     float64
+	decay      float64
+	envelope   float64
+}
+
+// NewAGC returns a new AGC for the given sample rate with the default parameters.
+func NewAGC(sampleRate int) *AGC {
+	result := &AGC{
+		sampleRate: sampleRate,
+		target:     DefaultAGCTarget,
+		maxGain:    DefaultAGCMaxGain,
+	}
+	result.SetAttack(DefaultAGCAttack)
+	result.SetDecay(DefaultAGCDecay)
+	return result
+}
+
+// SetTarget sets the target peak level of the output.
+func (a *AGC) SetTarget(target float64) {
+	a.target = target
+}
+
+// SetMaxGain sets the maximum gain, which limits the amplification of the noise during pauses of the signal.
+func (a *AGC) SetMaxGain(maxGain float64) {
+	a.maxGain = maxGain
+}
+
+// SetAttack sets the time constant of the envelope for rising levels.
+func (a *AGC) SetAttack(attack time.Duration) {
+	a.attack = a.coefficient(attack)
+}
+
+// SetDecay sets the time constant of the envelope for falling levels.
+func (a *AGC) SetDecay(decay time.Duration) {
+	a.decay = a.coefficient(decay)
+}
+
+// coefficient returns the weight of a new sample for an exponential average with the given time constant.
+func (a *AGC) coefficient(timeConstant time.Duration) float64 {
+	samples := timeConstant.Seconds() * float64(a.sampleRate)
+	if samples < 1 {
+		return 1
+	}
+	return 1 - math.Exp(-1/samples)
+}
+
+// Gain returns the current gain.
+func (a *AGC) Gain() float64 {
+	if a.envelope*a.maxGain <= a.target {
+		return a.maxGain
+	}
+	return a.target / a.envelope
+}
+
+// Reset clears the envelope.
+func (a *AGC) Reset() {
+	a.envelope = 0
+}
+
+// Process scales the given samples in place.
+func (a *AGC) Process(samples []float64) {
+	for i, x := range samples {
+		level := math.Abs(x)
+		if level > a.envelope {
+			a.envelope += a.attack * (level - a.envelope)
+		} else {
+			a.envelope += a.decay * (level - a.envelope)
+		}
+		samples[i] = x * a.Gain()
+	}
+}
@@ -0,0 +1,35 @@
+package dsp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAGC(t *testing.T) {
+	agc := NewAGC(8000)
+	samples := sine(1000, 0.01, 8000, 8000)
+	agc.Process(samples)
+	assert.InDelta(t, DefaultAGCTarget, maxOf(samples[4000:]), 0.02)
+	assert.InDelta(t, 50, agc.Gain(), 2)
+
+	// a sudden strong signal is reduced within the attack time
+	strong := sine(1000, 1, 8000, 800)
+	agc.Process(strong)
+	assert.InDelta(t, DefaultAGCTarget, maxOf(strong[400:]), 0.05)
+}
+
+func TestAGCLimitsGain(t *testing.T) {
+	agc := NewAGC(8000)
+	agc.SetMaxGain(10)
+	agc.Process(sine(1000, 1, 8000, 800))
+	assert.InDelta(t, 0.5, agc.Gain(), 0.02)
+
+	agc.SetDecay(10 * time.Millisecond)
+	silence := make([]float64, 8000)
+	silence[7999] = 0.001
+	agc.Process(silence)
+	assert.Equal(t, 10.0, agc.Gain())
+	assert.InDelta(t, 0.01, silence[7999], 1e-9)
+}
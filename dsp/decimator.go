@@ -0,0 +1,70 @@
+package dsp
+
+// Decimator is a FIR filter that downsamples by an integer factor. It only computes the output samples that are
+// kept. The filter must remove the frequencies above half of the output sample rate.
+type Decimator struct {
+	factor  int
+	taps    []float64
+	history []float64
+	index   int
+	count   int
+}
+
+// NewDecimator returns a new Decimator with the given filter taps and decimation factor.
+func NewDecimator(taps []float64, factor int) *Decimator {
+	return &Decimator{
+		factor:  factor,
+		taps:    taps,
+		history: make([]float64, len(taps)),
+	}
+}
+
+// NewDecimatorFor returns a new Decimator from the given input sample rate to the given output sample rate, with a
+// lowpass filter at 40% of the output rate. The output rate must divide the input rate.
+func NewDecimatorFor(inputRate, outputRate int) (*Decimator, error) {
+	if outputRate <= 0 || inputRate%outputRate != 0 {
+		return nil, ErrInvalidRate
+	}
+	factor := inputRate / outputRate
+	taps := LowPass(0.4*float64(outputRate), inputRate, channelFilterSymbols*factor+1)
+	return NewDecimator(taps, factor), nil
+}
+
+// Factor returns the decimation factor.
+func (d *Decimator) Factor() int {
+	return d.factor
+}
+
+// Decimate writes one output sample into dst for each factor samples of src. It returns the number of written
+// samples. If dst is too small, only the input samples that fit are processed. Incomplete groups of input samples
+// are kept for the next call.
+func (d *Decimator) Decimate(dst, src []float64) int {
+	n := 0
+	for _, x := range src[:minInt(len(src), len(dst)*d.factor-d.count)] {
+		d.history[d.index] = x
+		d.index = (d.index + 1) % len(d.history)
+		d.count++
+		if d.count < d.factor {
+			continue
+		}
+		d.count = 0
+
+		// d.index points to the oldest sample
+		var y float64
+		for k, tap := range d.taps {
+			y += tap * d.history[(d.index+k)%len(d.history)]
+		}
+		dst[n] = y
+		n++
+	}
+	return n
+}
+
+// Reset clears the history of the filter.
+func (d *Decimator) Reset() {
+	for i := range d.history {
+		d.history[i] = 0
+	}
+	d.index = 0
+	d.count = 0
+}
@@ -0,0 +1,50 @@
+package dsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimator(t *testing.T) {
+	d, err := NewDecimatorFor(8000, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, 4, d.Factor())
+
+	input := sine(300, 1, 8000, 8000)
+	output := make([]float64, len(input)/4)
+	n := d.Decimate(output, input)
+	assert.Equal(t, len(output), n)
+	assert.InDelta(t, 1, maxOf(output[1000:]), 0.02)
+	assert.InDelta(t, 1, GoertzelMagnitude(output[1000:], 300, 2000), 0.02)
+
+	d.Reset()
+	alias := sine(1700, 1, 8000, 8000)
+	n = d.Decimate(output, alias)
+	assert.Equal(t, len(output), n)
+	assert.InDelta(t, 0, maxOf(output[1000:]), 0.02, "1700 Hz would alias to 300 Hz")
+}
+
+func TestDecimatorKeepsIncompleteGroups(t *testing.T) {
+	input := sine(300, 1, 8000, 400)
+	d := NewDecimator(LowPass(800, 8000, 33), 4)
+	expected := make([]float64, 100)
+	d.Decimate(expected, input)
+
+	d.Reset()
+	actual := make([]float64, 0, 100)
+	buffer := make([]float64, 3)
+	for start := 0; start < len(input); start += 7 {
+		end := minInt(start+7, len(input))
+		for chunk := input[start:end]; len(chunk) > 0; {
+			n := d.Decimate(buffer, chunk)
+			actual = append(actual, buffer[:n]...)
+			chunk = chunk[minInt(len(chunk), len(buffer)*4):]
+		}
+	}
+	assert.Equal(t, expected, actual)
+
+	_, err := NewDecimatorFor(8000, 3000)
+	assert.Equal(t, ErrInvalidRate, err)
+}
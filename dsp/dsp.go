@@ -0,0 +1,51 @@
+/*
+Package dsp provides signal processing stages for receive audio that can be inserted in front of the decoders, and
+the building blocks of the demodulators: oscillators, tone detectors, filters, decimators and an AGC.
+*/
+package dsp
+
+import (
+	"context"
+
+	"github.com/ftl/digimodes"
+)
+
+// Processor processes blocks of audio samples in place.
+type Processor interface {
+	Process(samples []float64)
+}
+
+// Chain is a Processor that applies several processors in order.
+type Chain []Processor
+
+// Process applies all processors of the chain to the given samples.
+func (c Chain) Process(samples []float64) {
+	for _, p := range c {
+		p.Process(samples)
+	}
+}
+
+// Preprocess returns a decoder that applies the given processor to the samples before they are fed into the given
+// decoder. The samples passed to Feed are not modified.
+func Preprocess(decoder digimodes.Decoder, processor Processor) digimodes.Decoder {
+	return &preprocessedDecoder{
+		Decoder:   decoder,
+		processor: processor,
+	}
+}
+
+type preprocessedDecoder struct {
+	digimodes.Decoder
+	processor Processor
+	buffer    []float64
+}
+
+func (d *preprocessedDecoder) Feed(ctx context.Context, samples []float64) error {
+	if cap(d.buffer) < len(samples) {
+		d.buffer = make([]float64, len(samples))
+	}
+	buffer := d.buffer[:len(samples)]
+	copy(buffer, samples)
+	d.processor.Process(buffer)
+	return d.Decoder.Feed(ctx, buffer)
+}
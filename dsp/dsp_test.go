@@ -0,0 +1,41 @@
+package dsp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+type gain float64
+
+func (s gain) Process(samples []float64) {
+	for i := range samples {
+		samples[i] *= float64(s)
+	}
+}
+
+type testDecoder struct {
+	digimodes.Decoder
+	fed []float64
+}
+
+func (d *testDecoder) Feed(ctx context.Context, samples []float64) error {
+	d.fed = append(d.fed, samples...)
+	return nil
+}
+
+func TestPreprocess(t *testing.T) {
+	decoder := new(testDecoder)
+	preprocessed := Preprocess(decoder, Chain{gain(2), gain(3)})
+
+	samples := []float64{0.1, -0.1}
+	err := preprocessed.Feed(context.Background(), samples)
+	require.NoError(t, err)
+
+	assert.InDeltaSlice(t, []float64{0.6, -0.6}, decoder.fed, 1e-9)
+	assert.Equal(t, []float64{0.1, -0.1}, samples, "the input is not modified")
+}
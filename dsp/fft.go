@@ -0,0 +1,43 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft computes the discrete Fourier transform of x in place. The length of x must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a := x[start+k]
+				b := w * x[start+k+size/2]
+				x[start+k] = a + b
+				x[start+k+size/2] = a - b
+				w *= step
+			}
+		}
+	}
+}
+
+// hann returns a Hann window of the given length.
+func hann(n int) []float64 {
+	result := make([]float64, n)
+	for i := range result {
+		result[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+	return result
+}
@@ -0,0 +1,197 @@
+package dsp
+
+import "math"
+
+// LowPass returns the taps of a windowed sinc (Hamming) lowpass filter with the given cutoff frequency for the
+// given sample rate. The filter has unit DC gain.
+func LowPass(cutoff float64, sampleRate int, length int) []float64 {
+	result := make([]float64, length)
+	center := float64(length-1) / 2
+	fc := cutoff / float64(sampleRate)
+	for i := range result {
+		t := float64(i) - center
+		sinc := 2 * fc
+		if t != 0 {
+			sinc = math.Sin(2*math.Pi*fc*t) / (math.Pi * t)
+		}
+		window := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(length-1))
+		result[i] = sinc * window
+	}
+	normalizeSum(result)
+	return result
+}
+
+// HighPass returns the taps of a windowed sinc highpass filter with the given cutoff frequency for the given sample
+// rate, built by spectral inversion of the lowpass. The length must be odd.
+func HighPass(cutoff float64, sampleRate int, length int) []float64 {
+	result := LowPass(cutoff, sampleRate, length)
+	for i := range result {
+		result[i] = -result[i]
+	}
+	result[length/2] += 1
+	return result
+}
+
+// BandPass returns the taps of a windowed sinc bandpass filter between the given frequencies for the given sample
+// rate, built by shifting a lowpass to the center of the passband. The filter has unit gain at the center.
+func BandPass(low, high float64, sampleRate int, length int) []float64 {
+	result := LowPass((high-low)/2, sampleRate, length)
+	center := float64(length-1) / 2
+	w := 2 * math.Pi * (low + high) / 2 / float64(sampleRate)
+	for i := range result {
+		result[i] *= 2 * math.Cos(w*(float64(i)-center))
+	}
+	return result
+}
+
+// FIR is a finite impulse response filter with the given taps. It is a Processor that filters the samples in place.
+type FIR struct {
+	taps    []float64
+	history []float64
+	index   int
+}
+
+// NewFIR returns a new FIR filter with the given taps.
+func NewFIR(taps []float64) *FIR {
+	return &FIR{
+		taps:    taps,
+		history: make([]float64, len(taps)),
+	}
+}
+
+// Filter returns the next output sample for the given input sample.
+func (f *FIR) Filter(x float64) float64 {
+	f.history[f.index] = x
+	f.index = (f.index + 1) % len(f.history)
+	// f.index points to the oldest sample, the first tap belongs to the newest one
+	var y float64
+	n := len(f.history)
+	for k, tap := range f.taps {
+		y += tap * f.history[(f.index+n-1-k)%n]
+	}
+	return y
+}
+
+// Process filters the given samples in place.
+func (f *FIR) Process(samples []float64) {
+	for i, x := range samples {
+		samples[i] = f.Filter(x)
+	}
+}
+
+// Reset clears the history of the filter.
+func (f *FIR) Reset() {
+	for i := range f.history {
+		f.history[i] = 0
+	}
+	f.index = 0
+}
+
+// Biquad is a second order IIR filter. The constructors compute the coefficients according to the RBJ audio EQ
+// cookbook. It is a Processor that filters the samples in place.
+type Biquad struct {
+	b0, b1, b2     float64
+	a1, a2         float64
+	x1, x2, y1, y2 float64
+}
+
+type biquadShape int
+
+const (
+	lowPassShape biquadShape = iota
+	highPassShape
+	bandPassShape
+	notchShape
+)
+
+func newBiquad(shape biquadShape, frequency, q float64, sampleRate int) *Biquad {
+	result := new(Biquad)
+	result.tune(shape, frequency, q, float64(sampleRate))
+	return result
+}
+
+// tune computes the coefficients and keeps the state of the filter.
+func (f *Biquad) tune(shape biquadShape, frequency, q, sampleRate float64) {
+	w0 := 2 * math.Pi * frequency / sampleRate
+	cos := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+	var b0, b1, b2 float64
+	switch shape {
+	case lowPassShape:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+	case highPassShape:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+	case bandPassShape:
+		b0, b1, b2 = alpha, 0, -alpha
+	case notchShape:
+		b0, b1, b2 = 1, -2*cos, 1
+	}
+	a0 := 1 + alpha
+	f.b0 = b0 / a0
+	f.b1 = b1 / a0
+	f.b2 = b2 / a0
+	f.a1 = -2 * cos / a0
+	f.a2 = (1 - alpha) / a0
+}
+
+// LowPassBiquad returns a second order lowpass filter with the given cutoff frequency and quality factor.
+func LowPassBiquad(cutoff, q float64, sampleRate int) *Biquad {
+	return newBiquad(lowPassShape, cutoff, q, sampleRate)
+}
+
+// HighPassBiquad returns a second order highpass filter with the given cutoff frequency and quality factor.
+func HighPassBiquad(cutoff, q float64, sampleRate int) *Biquad {
+	return newBiquad(highPassShape, cutoff, q, sampleRate)
+}
+
+// BandPassBiquad returns a second order bandpass filter with unit gain at the given center frequency and the given
+// quality factor (center frequency / bandwidth).
+func BandPassBiquad(center, q float64, sampleRate int) *Biquad {
+	return newBiquad(bandPassShape, center, q, sampleRate)
+}
+
+// NotchBiquad returns a second order notch filter at the given frequency with the given quality factor.
+func NotchBiquad(frequency, q float64, sampleRate int) *Biquad {
+	return newBiquad(notchShape, frequency, q, sampleRate)
+}
+
+// Filter returns the next output sample for the given input sample.
+func (f *Biquad) Filter(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// Process filters the given samples in place.
+func (f *Biquad) Process(samples []float64) {
+	for i, x := range samples {
+		samples[i] = f.Filter(x)
+	}
+}
+
+// Reset clears the state of the filter.
+func (f *Biquad) Reset() {
+	f.x1, f.x2, f.y1, f.y2 = 0, 0, 0, 0
+}
+
+// Cascade is a Processor that applies several filters in order, e.g. biquads to get a higher order IIR filter.
+type Cascade []*Biquad
+
+// Process filters the given samples in place.
+func (c Cascade) Process(samples []float64) {
+	for _, f := range c {
+		f.Process(samples)
+	}
+}
+
+// ButterworthLowPass returns a lowpass filter of the given even order with a maximally flat passband, built from
+// cascaded biquads.
+func ButterworthLowPass(cutoff float64, order int, sampleRate int) Cascade {
+	result := make(Cascade, order/2)
+	for k := range result {
+		q := 1 / (2 * math.Cos(math.Pi*float64(2*k+1)/float64(2*order)))
+		result[k] = LowPassBiquad(cutoff, q, sampleRate)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gainAt returns the gain of the given processor for a sine with the given frequency after the filter settled.
+func gainAt(p Processor, frequency float64, sampleRate int) float64 {
+	samples := sine(frequency, 1, sampleRate, sampleRate)
+	p.Process(samples)
+	return maxOf(samples[sampleRate/2:])
+}
+
+func TestFIR(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		taps      []float64
+		frequency float64
+		gain      float64
+	}{
+		{"lowpass passband", LowPass(1000, 8000, 101), 500, 1},
+		{"lowpass stopband", LowPass(1000, 8000, 101), 2000, 0},
+		{"highpass passband", HighPass(1000, 8000, 101), 2000, 1},
+		{"highpass stopband", HighPass(1000, 8000, 101), 300, 0},
+		{"bandpass center", BandPass(1400, 1600, 8000, 401), 1500, 1},
+		{"bandpass below", BandPass(1400, 1600, 8000, 401), 1000, 0},
+		{"bandpass above", BandPass(1400, 1600, 8000, 401), 2000, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.InDelta(t, tC.gain, gainAt(NewFIR(tC.taps), tC.frequency, 8000), 0.02)
+		})
+	}
+}
+
+func TestFIRImpulseResponse(t *testing.T) {
+	f := NewFIR([]float64{1, 2, 3})
+	samples := []float64{1, 0, 0, 0}
+	f.Process(samples)
+	assert.Equal(t, []float64{1, 2, 3, 0}, samples)
+	f.Reset()
+	assert.Equal(t, 0.0, f.Filter(0))
+}
+
+func TestBiquad(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		filter    func() Processor
+		frequency float64
+		gain      float64
+	}{
+		{"lowpass passband", func() Processor { return LowPassBiquad(1000, math.Sqrt2/2, 8000) }, 100, 1},
+		{"lowpass cutoff", func() Processor { return LowPassBiquad(1000, math.Sqrt2/2, 8000) }, 1000, math.Sqrt2 / 2},
+		{"highpass passband", func() Processor { return HighPassBiquad(1000, math.Sqrt2/2, 8000) }, 3500, 1},
+		{"highpass cutoff", func() Processor { return HighPassBiquad(1000, math.Sqrt2/2, 8000) }, 1000, math.Sqrt2 / 2},
+		{"bandpass center", func() Processor { return BandPassBiquad(1500, 10, 8000) }, 1500, 1},
+		{"notch", func() Processor { return NotchBiquad(1500, 10, 8000) }, 1500, 0},
+		{"butterworth passband", func() Processor { return ButterworthLowPass(1000, 4, 8000) }, 100, 1},
+		{"butterworth cutoff", func() Processor { return ButterworthLowPass(1000, 4, 8000) }, 1000, math.Sqrt2 / 2},
+		{"butterworth stopband", func() Processor { return ButterworthLowPass(1000, 4, 8000) }, 3000, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.InDelta(t, tC.gain, gainAt(tC.filter(), tC.frequency, 8000), 0.02)
+		})
+	}
+}
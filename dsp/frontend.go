@@ -0,0 +1,170 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// ErrInvalidRate is returned when the output rate of a channel does not divide the input rate of the front-end.
+var ErrInvalidRate = errors.New("dsp: the output rate must divide the input rate")
+
+// channelFilterSymbols is the length of the channel filter in periods of the output rate.
+const channelFilterSymbols = 8
+
+// FrontEnd mixes channels of a shared input stream to complex baseband, filters and decimates them to the sample
+// rate of each channel. Several decoders can share one input stream this way.
+//
+// The input is either real audio (Process) or complex IQ samples (ProcessIQ).
+type FrontEnd struct {
+	sampleRate int
+
+	mu       sync.Mutex
+	channels []*Channel
+	buffer   []complex128
+}
+
+// NewFrontEnd returns a new FrontEnd for the given input sample rate.
+func NewFrontEnd(sampleRate int) *FrontEnd {
+	return &FrontEnd{
+		sampleRate: sampleRate,
+	}
+}
+
+// SampleRate returns the input sample rate.
+func (f *FrontEnd) SampleRate() int {
+	return f.sampleRate
+}
+
+// AddChannel adds a channel at the given offset from the center of the input with the given bandwidth and output
+// sample rate. The baseband samples are passed to the given handler after each processed block of input samples.
+func (f *FrontEnd) AddChannel(offset, bandwidth float64, outputRate int, handler func([]complex128)) (*Channel, error) {
+	if outputRate <= 0 || f.sampleRate%outputRate != 0 {
+		return nil, ErrInvalidRate
+	}
+	factor := f.sampleRate / outputRate
+	result := &Channel{
+		frontEnd:   f,
+		outputRate: outputRate,
+		handler:    handler,
+		taps:       LowPass(bandwidth/2, f.sampleRate, channelFilterSymbols*factor+1),
+		factor:     factor,
+	}
+	result.history = make([]complex128, len(result.taps))
+	result.SetOffset(offset)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels = append(f.channels, result)
+	return result, nil
+}
+
+// RemoveChannel removes the given channel.
+func (f *FrontEnd) RemoveChannel(channel *Channel) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.channels {
+		if c == channel {
+			f.channels = append(f.channels[:i], f.channels[i+1:]...)
+			return
+		}
+	}
+}
+
+// Process passes the given real audio samples to all channels. The samples are not modified.
+func (f *FrontEnd) Process(samples []float64) {
+	f.mu.Lock()
+	if cap(f.buffer) < len(samples) {
+		f.buffer = make([]complex128, len(samples))
+	}
+	buffer := f.buffer[:len(samples)]
+	f.mu.Unlock()
+
+	for i, x := range samples {
+		buffer[i] = complex(x, 0)
+	}
+	f.ProcessIQ(buffer)
+}
+
+// ProcessIQ passes the given complex samples to all channels.
+func (f *FrontEnd) ProcessIQ(samples []complex128) {
+	f.mu.Lock()
+	channels := append([]*Channel{}, f.channels...)
+	f.mu.Unlock()
+
+	for _, c := range channels {
+		c.process(samples)
+	}
+}
+
+// Channel is a channel of a FrontEnd.
+type Channel struct {
+	frontEnd   *FrontEnd
+	outputRate int
+	handler    func([]complex128)
+
+	mu       sync.Mutex
+	offset   float64
+	rotation complex128
+	phasor   complex128
+	taps     []float64
+	factor   int
+	history  []complex128
+	index    int
+	count    int
+	output   []complex128
+}
+
+// SampleRate returns the output sample rate of the channel.
+func (c *Channel) SampleRate() int {
+	return c.outputRate
+}
+
+// Offset returns the offset of the channel from the center of the input in Hz.
+func (c *Channel) Offset() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// SetOffset tunes the channel to the given offset from the center of the input in Hz.
+func (c *Channel) SetOffset(offset float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = offset
+	c.rotation = cmplx.Exp(complex(0, -2*math.Pi*offset/float64(c.frontEnd.sampleRate)))
+	if c.phasor == 0 {
+		c.phasor = 1
+	}
+}
+
+func (c *Channel) process(samples []complex128) {
+	c.mu.Lock()
+	c.output = c.output[:0]
+	for _, x := range samples {
+		c.history[c.index] = x * c.phasor
+		c.index = (c.index + 1) % len(c.history)
+		c.phasor *= c.rotation
+		c.count++
+		if c.count < c.factor {
+			continue
+		}
+		c.count = 0
+
+		// c.index points to the oldest sample
+		var y complex128
+		for k, tap := range c.taps {
+			y += complex(tap, 0) * c.history[(c.index+k)%len(c.history)]
+		}
+		c.output = append(c.output, y)
+	}
+	// keep the magnitude of the phasor at 1 despite rounding errors
+	c.phasor /= complex(cmplx.Abs(c.phasor), 0)
+	output := c.output
+	c.mu.Unlock()
+
+	if len(output) > 0 && c.handler != nil {
+		c.handler(output)
+	}
+}
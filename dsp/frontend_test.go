@@ -0,0 +1,79 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLowPass(t *testing.T) {
+	taps := LowPass(500, 8000, 101)
+	assert.Len(t, taps, 101)
+	var sum float64
+	for _, tap := range taps {
+		sum += tap
+	}
+	assert.InDelta(t, 1, sum, 1e-9)
+
+	// response at the given frequency
+	response := func(frequency float64) float64 {
+		var h complex128
+		for i, tap := range taps {
+			h += complex(tap, 0) * cmplx.Exp(complex(0, -2*math.Pi*frequency*float64(i)/8000))
+		}
+		return cmplx.Abs(h)
+	}
+	assert.InDelta(t, 1, response(100), 0.01)
+	assert.Less(t, response(1000), 0.01)
+}
+
+func TestFrontEnd(t *testing.T) {
+	const rate = 12000
+	f := NewFrontEnd(rate)
+
+	var low, high []complex128
+	_, err := f.AddChannel(1000, 200, 7000, nil)
+	assert.Equal(t, ErrInvalidRate, err)
+	lowChannel, err := f.AddChannel(1500, 200, 500, func(block []complex128) { low = append(low, block...) })
+	require.NoError(t, err)
+	assert.Equal(t, 500, lowChannel.SampleRate())
+	highChannel, err := f.AddChannel(2500, 200, 1000, func(block []complex128) { high = append(high, block...) })
+	require.NoError(t, err)
+
+	samples := make([]float64, rate)
+	for i := range samples {
+		tm := float64(i) / rate
+		samples[i] = 0.4*math.Cos(2*math.Pi*1500*tm) + 0.2*math.Cos(2*math.Pi*2510*tm)
+	}
+	input := append([]float64{}, samples...)
+	for i := 0; i < len(samples); i += 480 {
+		f.Process(samples[i : i+480])
+	}
+	assert.Equal(t, input, samples, "the input is not modified")
+
+	require.Len(t, low, 500)
+	require.Len(t, high, 1000)
+
+	// a real tone shows up with half its amplitude, the other tone is filtered out
+	for _, x := range low[100:] {
+		assert.InDelta(t, 0.2, cmplx.Abs(x), 0.005)
+	}
+	// the 2510Hz tone is 10Hz above the channel center
+	for i, x := range high[200:] {
+		assert.InDelta(t, 0.1, cmplx.Abs(x), 0.005)
+		if i > 0 {
+			rotation := cmplx.Phase(x / high[200+i-1])
+			assert.InDelta(t, 2*math.Pi*10/1000, rotation, 1e-3)
+		}
+	}
+
+	f.RemoveChannel(highChannel)
+	lowChannel.SetOffset(2510)
+	assert.Equal(t, 2510.0, lowChannel.Offset())
+	high = nil
+	f.Process(samples[:480])
+	assert.Empty(t, high)
+}
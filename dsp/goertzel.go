@@ -0,0 +1,79 @@
+package dsp
+
+import "math"
+
+// Goertzel detects a single tone with the Goertzel algorithm. It measures the magnitude of the tone over blocks of
+// samples, which is much cheaper than a full FFT if only a few frequencies are of interest. It is a Processor that
+// does not modify the samples.
+type Goertzel struct {
+	frequency   float64
+	sampleRate  int
+	blockSize   int
+	handler     func(magnitude float64)
+	coefficient float64
+	s1, s2      float64
+	count       int
+	magnitude   float64
+}
+
+// NewGoertzel returns a new Goertzel detector for the given frequency in Hz and sample rate, that reports the
+// magnitude of the tone after each block of the given number of samples to the given handler. The handler may be
+// nil.
+func NewGoertzel(frequency float64, sampleRate int, blockSize int, handler func(magnitude float64)) *Goertzel {
+	result := &Goertzel{
+		sampleRate: sampleRate,
+		blockSize:  blockSize,
+		handler:    handler,
+	}
+	result.SetFrequency(frequency)
+	return result
+}
+
+// Frequency returns the frequency of the detected tone in Hz.
+func (g *Goertzel) Frequency() float64 {
+	return g.frequency
+}
+
+// SetFrequency sets the frequency of the detected tone in Hz. The current block is restarted.
+func (g *Goertzel) SetFrequency(frequency float64) {
+	g.frequency = frequency
+	g.coefficient = 2 * math.Cos(2*math.Pi*frequency/float64(g.sampleRate))
+	g.Reset()
+}
+
+// Magnitude returns the amplitude of the tone in the last completed block. A sine with the amplitude 1 has the
+// magnitude 1.
+func (g *Goertzel) Magnitude() float64 {
+	return g.magnitude
+}
+
+// Reset restarts the current block.
+func (g *Goertzel) Reset() {
+	g.s1, g.s2 = 0, 0
+	g.count = 0
+}
+
+// Process adds the given samples to the measurement.
+func (g *Goertzel) Process(samples []float64) {
+	for _, x := range samples {
+		s0 := x + g.coefficient*g.s1 - g.s2
+		g.s2, g.s1 = g.s1, s0
+		g.count++
+		if g.count < g.blockSize {
+			continue
+		}
+		power := g.s1*g.s1 + g.s2*g.s2 - g.coefficient*g.s1*g.s2
+		g.magnitude = 2 * math.Sqrt(math.Max(0, power)) / float64(g.blockSize)
+		g.Reset()
+		if g.handler != nil {
+			g.handler(g.magnitude)
+		}
+	}
+}
+
+// GoertzelMagnitude returns the amplitude of the tone with the given frequency in Hz in the given block of samples.
+func GoertzelMagnitude(samples []float64, frequency float64, sampleRate int) float64 {
+	g := NewGoertzel(frequency, sampleRate, len(samples), nil)
+	g.Process(samples)
+	return g.Magnitude()
+}
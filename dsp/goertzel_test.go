@@ -0,0 +1,30 @@
+package dsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoertzel(t *testing.T) {
+	var magnitudes []float64
+	g := NewGoertzel(1000, 8000, 400, func(magnitude float64) {
+		magnitudes = append(magnitudes, magnitude)
+	})
+	samples := sine(1000, 0.7, 8000, 1000)
+	g.Process(samples[:300])
+	g.Process(samples[300:])
+
+	require.Len(t, magnitudes, 2, "two complete blocks")
+	assert.InDelta(t, 0.7, magnitudes[0], 1e-3)
+	assert.InDelta(t, 0.7, magnitudes[1], 1e-3)
+	assert.Equal(t, magnitudes[1], g.Magnitude())
+	assert.InDelta(t, 0, samples[0], 1e-9, "the samples are not modified")
+}
+
+func TestGoertzelMagnitude(t *testing.T) {
+	samples := sine(1000, 1, 8000, 800)
+	assert.InDelta(t, 1, GoertzelMagnitude(samples, 1000, 8000), 1e-3)
+	assert.InDelta(t, 0, GoertzelMagnitude(samples, 1200, 8000), 1e-3)
+}
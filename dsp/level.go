@@ -0,0 +1,164 @@
+package dsp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// ClipLevel is the absolute sample value from which on a sample counts as clipped.
+	ClipLevel = 0.999
+	// MinDBFS is the lowest level that is reported, it stands for silence.
+	MinDBFS = -120.0
+)
+
+// Level is a measurement of the audio level over one metering interval.
+type Level struct {
+	// Peak is the highest absolute sample value.
+	Peak float64
+	// RMS is the root mean square of the samples.
+	RMS float64
+	// PeakDBFS is the peak level in dB relative to full scale.
+	PeakDBFS float64
+	// RMSDBFS is the RMS level in dB relative to full scale.
+	RMSDBFS float64
+	// Clipped is the number of clipped samples.
+	Clipped int
+	// Calibrated indicates if the level in dBm is known.
+	Calibrated bool
+	// PeakDBm is the calibrated peak level in dBm.
+	PeakDBm float64
+	// RMSDBm is the calibrated RMS level in dBm.
+	RMSDBm float64
+}
+
+// DBFS converts the given linear level into dB relative to full scale.
+func DBFS(level float64) float64 {
+	if level <= 0 {
+		return MinDBFS
+	}
+	return math.Max(MinDBFS, 20*math.Log10(level))
+}
+
+// Calibration maps dBFS to dBm, based on a reference signal with a known level.
+type Calibration struct {
+	// ReferenceDBFS is the measured level of the reference signal.
+	ReferenceDBFS float64
+	// ReferenceDBm is the known level of the reference signal.
+	ReferenceDBm float64
+}
+
+// DBm converts the given level in dBFS into dBm.
+func (c Calibration) DBm(dBFS float64) float64 {
+	return dBFS - c.ReferenceDBFS + c.ReferenceDBm
+}
+
+// LevelMeter measures peak and RMS level and counts clipped samples. It is a Processor that does not modify the
+// samples. At the end of each metering interval, the measured level is reported to the listener.
+type LevelMeter struct {
+	mu sync.Mutex
+
+	intervalSamples int
+	listener        func(Level)
+	calibration     *Calibration
+
+	peak    float64
+	sum     float64
+	clipped int
+	count   int
+	last    Level
+}
+
+// NewLevelMeter returns a new LevelMeter for the given sample rate that reports the level of every interval to
+// the given listener. The listener may be nil.
+func NewLevelMeter(sampleRate int, interval time.Duration, listener func(Level)) *LevelMeter {
+	intervalSamples := int(interval.Seconds() * float64(sampleRate))
+	if intervalSamples < 1 {
+		intervalSamples = 1
+	}
+	return &LevelMeter{
+		intervalSamples: intervalSamples,
+		listener:        listener,
+		last: Level{
+			PeakDBFS: MinDBFS,
+			RMSDBFS:  MinDBFS,
+		},
+	}
+}
+
+// SetCalibration sets the calibration that is used to report the level in dBm.
+func (m *LevelMeter) SetCalibration(calibration Calibration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calibration = &calibration
+}
+
+// Calibrate measures the given RMS level in dBFS of a reference signal with the given known level in dBm and uses
+// the result as calibration.
+func (m *LevelMeter) Calibrate(referenceDBFS, referenceDBm float64) Calibration {
+	result := Calibration{
+		ReferenceDBFS: referenceDBFS,
+		ReferenceDBm:  referenceDBm,
+	}
+	m.SetCalibration(result)
+	return result
+}
+
+// Level returns the level of the last completed metering interval.
+func (m *LevelMeter) Level() Level {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Process measures the level of the given samples.
+func (m *LevelMeter) Process(samples []float64) {
+	var reports []Level
+
+	m.mu.Lock()
+	for _, x := range samples {
+		a := math.Abs(x)
+		if a > m.peak {
+			m.peak = a
+		}
+		if a >= ClipLevel {
+			m.clipped++
+		}
+		m.sum += x * x
+		m.count++
+		if m.count == m.intervalSamples {
+			m.last = m.measure()
+			reports = append(reports, m.last)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.listener == nil {
+		return
+	}
+	for _, level := range reports {
+		m.listener(level)
+	}
+}
+
+func (m *LevelMeter) measure() Level {
+	result := Level{
+		Peak:    m.peak,
+		RMS:     math.Sqrt(m.sum / float64(m.count)),
+		Clipped: m.clipped,
+	}
+	result.PeakDBFS = DBFS(result.Peak)
+	result.RMSDBFS = DBFS(result.RMS)
+	if m.calibration != nil {
+		result.Calibrated = true
+		result.PeakDBm = m.calibration.DBm(result.PeakDBFS)
+		result.RMSDBm = m.calibration.DBm(result.RMSDBFS)
+	}
+
+	m.peak = 0
+	m.sum = 0
+	m.clipped = 0
+	m.count = 0
+	return result
+}
@@ -0,0 +1,62 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelMeter(t *testing.T) {
+	var levels []Level
+	m := NewLevelMeter(1000, 100*time.Millisecond, func(level Level) {
+		levels = append(levels, level)
+	})
+	assert.Equal(t, MinDBFS, m.Level().RMSDBFS)
+
+	samples := make([]float64, 250)
+	for i := range samples {
+		samples[i] = 0.5 * math.Cos(2*math.Pi*float64(i)/10)
+	}
+	samples[120] = 1.0
+	samples[121] = -1.0
+	m.Process(samples)
+
+	require.Len(t, levels, 2, "250 samples are two complete intervals")
+	assert.InDelta(t, 0.5, levels[0].Peak, 1e-9)
+	assert.InDelta(t, 0.5/math.Sqrt2, levels[0].RMS, 1e-9)
+	assert.InDelta(t, -6.02, levels[0].PeakDBFS, 0.01)
+	assert.InDelta(t, -9.03, levels[0].RMSDBFS, 0.01)
+	assert.Equal(t, 0, levels[0].Clipped)
+	assert.False(t, levels[0].Calibrated)
+
+	assert.Equal(t, 1.0, levels[1].Peak)
+	assert.Equal(t, 2, levels[1].Clipped)
+	assert.Equal(t, levels[1], m.Level())
+	assert.Equal(t, 0.5, samples[0], "the samples are not modified")
+}
+
+func TestLevelMeterCalibration(t *testing.T) {
+	m := NewLevelMeter(100, time.Second, nil)
+	calibration := m.Calibrate(-20, -73)
+	assert.Equal(t, -93.0, calibration.DBm(-40))
+
+	samples := make([]float64, 100)
+	for i := range samples {
+		samples[i] = 0.1
+	}
+	m.Process(samples)
+
+	level := m.Level()
+	assert.True(t, level.Calibrated)
+	assert.InDelta(t, -20, level.RMSDBFS, 1e-9)
+	assert.InDelta(t, -73, level.RMSDBm, 1e-9)
+}
+
+func TestDBFS(t *testing.T) {
+	assert.Equal(t, 0.0, DBFS(1))
+	assert.Equal(t, MinDBFS, DBFS(0))
+	assert.Equal(t, MinDBFS, DBFS(1e-9))
+}
@@ -0,0 +1,85 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// NCO is a numerically controlled oscillator. It generates a complex phasor with an adjustable frequency and
+// phase, e.g. to mix a signal to baseband or to generate the carrier of a modulator.
+type NCO struct {
+	sampleRate float64
+	frequency  float64
+	phase      float64
+	increment  float64
+}
+
+// NewNCO returns a new NCO with the given frequency in Hz for the given sample rate.
+func NewNCO(frequency float64, sampleRate int) *NCO {
+	result := &NCO{sampleRate: float64(sampleRate)}
+	result.SetFrequency(frequency)
+	return result
+}
+
+// Frequency returns the frequency in Hz.
+func (o *NCO) Frequency() float64 {
+	return o.frequency
+}
+
+// SetFrequency sets the frequency in Hz. The phase stays continuous.
+func (o *NCO) SetFrequency(frequency float64) {
+	o.frequency = frequency
+	o.increment = 2 * math.Pi * frequency / o.sampleRate
+}
+
+// AdjustFrequency adds the given offset in Hz to the frequency, e.g. as correction of a tracking loop.
+func (o *NCO) AdjustFrequency(offset float64) {
+	o.SetFrequency(o.frequency + offset)
+}
+
+// Phase returns the current phase in radians between 0 and 2π.
+func (o *NCO) Phase() float64 {
+	return o.phase
+}
+
+// SetPhase sets the current phase in radians.
+func (o *NCO) SetPhase(phase float64) {
+	o.phase = wrapPhase(phase)
+}
+
+// AdjustPhase adds the given offset in radians to the current phase.
+func (o *NCO) AdjustPhase(offset float64) {
+	o.SetPhase(o.phase + offset)
+}
+
+// Next returns the phasor of the current phase and advances the phase by one sample.
+func (o *NCO) Next() complex128 {
+	result := cmplx.Rect(1, o.phase)
+	o.phase = wrapPhase(o.phase + o.increment)
+	return result
+}
+
+// Generate writes the cosine of the oscillator into the given samples.
+func (o *NCO) Generate(samples []float64) {
+	for i := range samples {
+		samples[i] = real(o.Next())
+	}
+}
+
+// MixDown shifts the given real samples down by the frequency of the oscillator and writes the complex result
+// into dst. It returns the number of written samples.
+func (o *NCO) MixDown(dst []complex128, src []float64) int {
+	n := minInt(len(dst), len(src))
+	for i, x := range src[:n] {
+		dst[i] = complex(x, 0) * cmplx.Conj(o.Next())
+	}
+	return n
+}
+
+func wrapPhase(phase float64) float64 {
+	phase = math.Mod(phase, 2*math.Pi)
+	if phase < 0 {
+		phase += 2 * math.Pi
+	}
+	return phase
+}
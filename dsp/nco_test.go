@@ -0,0 +1,50 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sine(frequency, amplitude float64, sampleRate, n int) []float64 {
+	result := make([]float64, n)
+	for i := range result {
+		result[i] = amplitude * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate))
+	}
+	return result
+}
+
+func TestNCO(t *testing.T) {
+	o := NewNCO(1000, 8000)
+	samples := make([]float64, 8)
+	o.Generate(samples)
+	for i, x := range samples {
+		assert.InDelta(t, math.Cos(2*math.Pi*float64(i)/8), x, 1e-9)
+	}
+	assert.InDelta(t, 0, o.Phase(), 1e-9, "full period")
+
+	o.SetFrequency(2000)
+	o.AdjustPhase(-math.Pi / 2)
+	assert.InDelta(t, 3*math.Pi/2, o.Phase(), 1e-9)
+	assert.InDelta(t, 0, real(o.Next()), 1e-9)
+	assert.InDelta(t, 0, o.Phase(), 1e-9)
+	o.AdjustFrequency(-1000)
+	assert.Equal(t, 1000.0, o.Frequency())
+}
+
+func TestNCOMixDown(t *testing.T) {
+	input := sine(1010, 1, 8000, 8000)
+	o := NewNCO(1000, 8000)
+	output := make([]complex128, len(input))
+	n := o.MixDown(output, input)
+	assert.Equal(t, len(input), n)
+
+	// the tone is now at 10 Hz with half of the amplitude, its mirror is at -2010 Hz
+	var dc complex128
+	for i, y := range output {
+		dc += y * complex(math.Cos(2*math.Pi*10*float64(i)/8000), -math.Sin(2*math.Pi*10*float64(i)/8000))
+	}
+	dc /= complex(float64(len(output)), 0)
+	assert.InDelta(t, 0.5, math.Hypot(real(dc), imag(dc)), 1e-3)
+}
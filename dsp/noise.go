@@ -0,0 +1,128 @@
+package dsp
+
+import "sync"
+
+// Default parameters of the NoiseReducer.
+const (
+	DefaultNoiseReducerTaps  = 64
+	DefaultNoiseReducerDelay = 16
+	DefaultNoiseReducerMu    = 0.01
+)
+
+// epsilon keeps the normalization of the NLMS step size finite during silence.
+const epsilon = 1e-9
+
+// NoiseReducer is an adaptive line enhancer based on a normalized least mean squares (NLMS) filter. It predicts
+// the current sample from delayed samples, which works for the correlated, narrowband components of the wanted
+// signal, but not for the uncorrelated broadband noise. The prediction is therefore a version of the signal with
+// reduced noise.
+//
+// The strength controls how much of the prediction is mixed into the output. With bypass enabled, the samples
+// pass unchanged, but the filter keeps adapting.
+type NoiseReducer struct {
+	mu sync.Mutex
+
+	delay    int
+	stepSize float64
+	strength float64
+	bypass   bool
+
+	weights []float64
+	history []float64
+	index   int
+}
+
+// NewNoiseReducer returns a new NoiseReducer with the given number of filter taps, decorrelation delay in samples
+// and NLMS step size (0 < stepSize < 2). The strength is 1.
+func NewNoiseReducer(taps, delay int, stepSize float64) *NoiseReducer {
+	return &NoiseReducer{
+		delay:    delay,
+		stepSize: stepSize,
+		strength: 1,
+		weights:  make([]float64, taps),
+		history:  make([]float64, delay+taps),
+	}
+}
+
+// SetStrength sets the strength of the noise reduction between 0 (off) and 1 (full).
+func (r *NoiseReducer) SetStrength(strength float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strength = clamp(strength, 0, 1)
+}
+
+// Strength returns the strength of the noise reduction.
+func (r *NoiseReducer) Strength() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.strength
+}
+
+// SetBypass enables or disables the bypass.
+func (r *NoiseReducer) SetBypass(bypass bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bypass = bypass
+}
+
+// Bypass indicates if the bypass is enabled.
+func (r *NoiseReducer) Bypass() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bypass
+}
+
+// Reset clears the adapted filter and the history.
+func (r *NoiseReducer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.weights {
+		r.weights[i] = 0
+	}
+	for i := range r.history {
+		r.history[i] = 0
+	}
+	r.index = 0
+}
+
+// Process reduces the noise of the given samples in place.
+func (r *NoiseReducer) Process(samples []float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := len(r.history)
+	for i, x := range samples {
+		// the history holds the last size samples, r.index points to the oldest one
+		var prediction, power float64
+		for k := range r.weights {
+			u := r.history[(r.index+size-1-r.delay-k+size)%size]
+			prediction += r.weights[k] * u
+			power += u * u
+		}
+
+		e := x - prediction
+		step := r.stepSize * e / (epsilon + power)
+		for k := range r.weights {
+			u := r.history[(r.index+size-1-r.delay-k+size)%size]
+			r.weights[k] += step * u
+		}
+
+		r.history[r.index] = x
+		r.index = (r.index + 1) % size
+
+		if !r.bypass {
+			samples[i] = (1-r.strength)*x + r.strength*prediction
+		}
+	}
+}
+
+func clamp(value, lower, upper float64) float64 {
+	switch {
+	case value < lower:
+		return lower
+	case value > upper:
+		return upper
+	default:
+		return value
+	}
+}
@@ -0,0 +1,70 @@
+package dsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noisySine(rate, frequency, noise float64, n int, seed int64) (clean, noisy []float64) {
+	random := rand.New(rand.NewSource(seed))
+	clean = make([]float64, n)
+	noisy = make([]float64, n)
+	for i := range clean {
+		clean[i] = 0.5 * math.Sin(2*math.Pi*frequency*float64(i)/rate)
+		noisy[i] = clean[i] + noise*random.NormFloat64()
+	}
+	return clean, noisy
+}
+
+func errorPower(expected, actual []float64) float64 {
+	var sum float64
+	for i := range expected {
+		d := expected[i] - actual[i]
+		sum += d * d
+	}
+	return sum / float64(len(expected))
+}
+
+func TestNoiseReducerImprovesSNR(t *testing.T) {
+	clean, noisy := noisySine(8000, 1000, 0.3, 32000, 1)
+	input := append([]float64{}, noisy...)
+
+	r := NewNoiseReducer(DefaultNoiseReducerTaps, DefaultNoiseReducerDelay, DefaultNoiseReducerMu)
+	for i := 0; i < len(noisy); i += 256 {
+		r.Process(noisy[i : i+256])
+	}
+
+	// compare the second half, after the filter converged
+	half := len(clean) / 2
+	before := errorPower(clean[half:], input[half:])
+	after := errorPower(clean[half:], noisy[half:])
+	assert.Less(t, after, before/4, "at least 6dB better")
+}
+
+func TestNoiseReducerBypassAndStrength(t *testing.T) {
+	_, noisy := noisySine(8000, 1000, 0.3, 1000, 2)
+
+	r := NewNoiseReducer(16, 4, DefaultNoiseReducerMu)
+	r.SetBypass(true)
+	samples := append([]float64{}, noisy...)
+	r.Process(samples)
+	assert.Equal(t, noisy, samples)
+	assert.True(t, r.Bypass())
+
+	r = NewNoiseReducer(16, 4, DefaultNoiseReducerMu)
+	r.SetStrength(-1)
+	assert.Equal(t, 0.0, r.Strength())
+	samples = append([]float64{}, noisy...)
+	r.Process(samples)
+	assert.Equal(t, noisy, samples)
+
+	var p Processor = r
+	r.SetStrength(1)
+	r.Reset()
+	samples = append([]float64{}, noisy...)
+	Chain{p}.Process(samples)
+	assert.NotEqual(t, noisy, samples)
+}
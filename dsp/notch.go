@@ -0,0 +1,253 @@
+package dsp
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Default parameters of the AutoNotch.
+const (
+	// DefaultMaxNotches is the maximum number of carriers that are removed at the same time.
+	DefaultMaxNotches = 4
+	// DefaultNotchThreshold is the minimum level of a carrier above the noise floor in dB.
+	DefaultNotchThreshold = 20
+	// DefaultNotchPersistence is the number of analysis blocks a carrier must be present before it is removed.
+	DefaultNotchPersistence = 4
+	// notchQ is the quality factor of the notch filters.
+	notchQ = 30
+	// notchTolerance is the number of bins a carrier may drift between two analysis blocks.
+	notchTolerance = 1
+)
+
+// AutoNotch detects steady carriers in the receive audio and removes them with notch filters. Carriers within
+// the protected window around the frequency of the wanted signal are never removed.
+//
+// The detection works on blocks of analysis samples. A carrier is a peak in the spectrum that is at least the
+// threshold above the noise floor (the median of the spectrum) in several consecutive blocks.
+type AutoNotch struct {
+	mu sync.Mutex
+
+	sampleRate      float64
+	protectedCenter float64
+	protectedWidth  float64
+	maxNotches      int
+	threshold       float64
+	persistence     int
+	bypass          bool
+
+	window     []float64
+	analysis   []float64
+	analyzed   int
+	spectrum   []complex128
+	power      []float64
+	candidates map[int]int
+	notches    []*notch
+}
+
+// NewAutoNotch returns a new AutoNotch for the given sample rate. The analysis block size is a power of two,
+// chosen for a frequency resolution of about 6Hz.
+func NewAutoNotch(sampleRate int) *AutoNotch {
+	size := 1
+	for size < sampleRate/6 {
+		size <<= 1
+	}
+	return &AutoNotch{
+		sampleRate:  float64(sampleRate),
+		maxNotches:  DefaultMaxNotches,
+		threshold:   math.Pow(10, DefaultNotchThreshold/10.0),
+		persistence: DefaultNotchPersistence,
+		window:      hann(size),
+		analysis:    make([]float64, size),
+		spectrum:    make([]complex128, size),
+		power:       make([]float64, size/2),
+		candidates:  make(map[int]int),
+	}
+}
+
+// SetProtectedWindow sets the window around the tuned audio frequency of the decoder where carriers are not
+// removed. A width of 0 disables the protection.
+func (n *AutoNotch) SetProtectedWindow(center, width float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.protectedCenter = center
+	n.protectedWidth = width
+	n.notches = n.unprotected(n.notches)
+}
+
+// SetMaxNotches sets the maximum number of carriers that are removed at the same time.
+func (n *AutoNotch) SetMaxNotches(maxNotches int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.maxNotches = maxNotches
+	if len(n.notches) > maxNotches {
+		n.notches = n.notches[:maxNotches]
+	}
+}
+
+// SetThreshold sets the minimum level of a carrier above the noise floor in dB.
+func (n *AutoNotch) SetThreshold(dB float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.threshold = math.Pow(10, dB/10)
+}
+
+// SetBypass enables or disables the bypass. With bypass enabled, the samples pass unchanged, but the detection
+// continues.
+func (n *AutoNotch) SetBypass(bypass bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.bypass = bypass
+}
+
+// Notches returns the frequencies of the currently removed carriers in Hz.
+func (n *AutoNotch) Notches() []float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	result := make([]float64, len(n.notches))
+	for i, notch := range n.notches {
+		result[i] = notch.frequency
+	}
+	return result
+}
+
+// Process removes the detected carriers from the given samples in place.
+func (n *AutoNotch) Process(samples []float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, x := range samples {
+		n.analysis[n.analyzed] = x
+		n.analyzed++
+		if n.analyzed == len(n.analysis) {
+			n.analyze()
+			n.analyzed = 0
+		}
+
+		if n.bypass {
+			continue
+		}
+		y := x
+		for _, notch := range n.notches {
+			y = notch.filter(y)
+		}
+		samples[i] = y
+	}
+}
+
+func (n *AutoNotch) analyze() {
+	for i, x := range n.analysis {
+		n.spectrum[i] = complex(x*n.window[i], 0)
+	}
+	fft(n.spectrum)
+	for i := range n.power {
+		re, im := real(n.spectrum[i]), imag(n.spectrum[i])
+		n.power[i] = re*re + im*im
+	}
+	floor := median(n.power)
+
+	candidates := make(map[int]int)
+	var carriers []carrier
+	for bin := 1; bin < len(n.power)-1; bin++ {
+		p := n.power[bin]
+		if p <= floor*n.threshold || p < n.power[bin-1] || p < n.power[bin+1] {
+			continue
+		}
+		count := 1
+		for d := -notchTolerance; d <= notchTolerance; d++ {
+			if previous := n.candidates[bin+d]; previous+1 > count {
+				count = previous + 1
+			}
+		}
+		candidates[bin] = count
+		if count >= n.persistence {
+			carriers = append(carriers, carrier{frequency: n.interpolate(bin), power: p})
+		}
+	}
+	n.candidates = candidates
+
+	sort.Slice(carriers, func(i, j int) bool {
+		return carriers[i].power > carriers[j].power
+	})
+	n.updateNotches(carriers)
+}
+
+type carrier struct {
+	frequency float64
+	power     float64
+}
+
+// interpolate estimates the frequency of the peak at the given bin using a parabola through the log power of the
+// neighboring bins.
+func (n *AutoNotch) interpolate(bin int) float64 {
+	left := math.Log(n.power[bin-1] + epsilon)
+	center := math.Log(n.power[bin] + epsilon)
+	right := math.Log(n.power[bin+1] + epsilon)
+	offset := 0.0
+	if denominator := left - 2*center + right; denominator != 0 {
+		offset = 0.5 * (left - right) / denominator
+	}
+	return (float64(bin) + offset) * n.sampleRate / float64(len(n.analysis))
+}
+
+func (n *AutoNotch) updateNotches(carriers []carrier) {
+	binWidth := n.sampleRate / float64(len(n.analysis))
+	notches := make([]*notch, 0, n.maxNotches)
+	for _, c := range carriers {
+		if len(notches) == n.maxNotches {
+			break
+		}
+		if n.protected(c.frequency) {
+			continue
+		}
+		var existing *notch
+		for _, notch := range n.notches {
+			if math.Abs(notch.frequency-c.frequency) <= (notchTolerance+1)*binWidth {
+				existing = notch
+				break
+			}
+		}
+		if existing == nil {
+			existing = new(notch)
+		}
+		// keep the state of an existing filter to avoid clicks
+		existing.tune(c.frequency, n.sampleRate)
+		notches = append(notches, existing)
+	}
+	n.notches = notches
+}
+
+func (n *AutoNotch) protected(frequency float64) bool {
+	return n.protectedWidth > 0 && math.Abs(frequency-n.protectedCenter) <= n.protectedWidth/2
+}
+
+func (n *AutoNotch) unprotected(notches []*notch) []*notch {
+	result := notches[:0]
+	for _, notch := range notches {
+		if !n.protected(notch.frequency) {
+			result = append(result, notch)
+		}
+	}
+	return result
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// notch is a second order IIR notch filter that follows a carrier.
+type notch struct {
+	frequency float64
+	biquad    Biquad
+}
+
+func (f *notch) tune(frequency, sampleRate float64) {
+	f.frequency = frequency
+	f.biquad.tune(notchShape, frequency, notchQ, sampleRate)
+}
+
+func (f *notch) filter(x float64) float64 {
+	return f.biquad.Filter(x)
+}
@@ -0,0 +1,115 @@
+package dsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tonePower returns the power of the given frequency in the samples.
+func tonePower(samples []float64, frequency, rate float64) float64 {
+	var re, im float64
+	for i, x := range samples {
+		phase := 2 * math.Pi * frequency * float64(i) / rate
+		re += x * math.Cos(phase)
+		im -= x * math.Sin(phase)
+	}
+	return (re*re + im*im) / float64(len(samples)*len(samples))
+}
+
+func twoTones(rate float64, n int) []float64 {
+	random := rand.New(rand.NewSource(1))
+	result := make([]float64, n)
+	for i := range result {
+		t := float64(i) / rate
+		result[i] = 0.3*math.Sin(2*math.Pi*1000*t) + 0.3*math.Sin(2*math.Pi*1500*t) + 0.01*random.NormFloat64()
+	}
+	return result
+}
+
+func TestAutoNotch(t *testing.T) {
+	const rate = 8000
+	testCases := []struct {
+		desc             string
+		protectedWidth   float64
+		expectedNotches  []float64
+		wantedAttenuated bool
+	}{
+		{
+			desc:             "protected window",
+			protectedWidth:   200,
+			expectedNotches:  []float64{1500},
+			wantedAttenuated: false,
+		},
+		{
+			desc:             "no protection",
+			protectedWidth:   0,
+			expectedNotches:  []float64{1000, 1500},
+			wantedAttenuated: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			input := twoTones(rate, 5*rate)
+			output := append([]float64{}, input...)
+
+			n := NewAutoNotch(rate)
+			n.SetProtectedWindow(1000, tC.protectedWidth)
+			for i := 0; i < len(output); i += 256 {
+				n.Process(output[i : i+256])
+			}
+
+			notches := n.Notches()
+			require.Len(t, notches, len(tC.expectedNotches))
+			for _, expected := range tC.expectedNotches {
+				found := false
+				for _, actual := range notches {
+					found = found || math.Abs(expected-actual) < 2
+				}
+				assert.True(t, found, "notch at %v in %v", expected, notches)
+			}
+
+			last := len(input) - rate
+			carrierAttenuation := tonePower(input[last:], 1500, rate) / tonePower(output[last:], 1500, rate)
+			assert.Greater(t, carrierAttenuation, 100.0, "at least 20dB")
+			wantedAttenuation := tonePower(input[last:], 1000, rate) / tonePower(output[last:], 1000, rate)
+			if tC.wantedAttenuated {
+				assert.Greater(t, wantedAttenuation, 100.0)
+			} else {
+				assert.InDelta(t, 1, wantedAttenuation, 0.1)
+			}
+		})
+	}
+}
+
+func TestAutoNotchBypass(t *testing.T) {
+	const rate = 8000
+	input := twoTones(rate, 2*rate)
+	output := append([]float64{}, input...)
+
+	n := NewAutoNotch(rate)
+	n.SetBypass(true)
+	n.Process(output)
+
+	assert.Equal(t, input, output)
+	assert.Len(t, n.Notches(), 2, "the detection continues")
+}
+
+func TestFFT(t *testing.T) {
+	x := make([]complex128, 8)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*float64(i)/8), 0)
+	}
+	fft(x)
+	for i, v := range x {
+		expected := 0.0
+		if i == 1 || i == 7 {
+			expected = 4
+		}
+		assert.InDelta(t, expected, real(v), 1e-9, "bin %d", i)
+		assert.InDelta(t, 0, imag(v), 1e-9, "bin %d", i)
+	}
+}
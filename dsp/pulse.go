@@ -0,0 +1,152 @@
+package dsp
+
+import "math"
+
+// RootRaisedCosine returns the taps of a root raised cosine pulse shaping filter with the given rolloff
+// (0 < rolloff <= 1), the given number of samples per symbol and a length of span symbols. The filter has
+// span*samplesPerSymbol+1 taps and unit energy, so that two of them in series (transmit and matched receive filter)
+// form a raised cosine filter without intersymbol interference.
+func RootRaisedCosine(rolloff float64, samplesPerSymbol, span int) []float64 {
+	result := make([]float64, span*samplesPerSymbol+1)
+	center := len(result) / 2
+	for i := range result {
+		t := float64(i-center) / float64(samplesPerSymbol)
+		result[i] = rootRaisedCosine(t, rolloff)
+	}
+	normalizeEnergy(result)
+	return result
+}
+
+func rootRaisedCosine(t, rolloff float64) float64 {
+	const singularityTolerance = 1e-9
+	switch {
+	case math.Abs(t) < singularityTolerance:
+		return 1 - rolloff + 4*rolloff/math.Pi
+	case rolloff > 0 && math.Abs(math.Abs(t)-1/(4*rolloff)) < singularityTolerance:
+		return rolloff / math.Sqrt2 * ((1+2/math.Pi)*math.Sin(math.Pi/(4*rolloff)) + (1-2/math.Pi)*math.Cos(math.Pi/(4*rolloff)))
+	default:
+		numerator := math.Sin(math.Pi*t*(1-rolloff)) + 4*rolloff*t*math.Cos(math.Pi*t*(1+rolloff))
+		denominator := math.Pi * t * (1 - (4*rolloff*t)*(4*rolloff*t))
+		return numerator / denominator
+	}
+}
+
+// Gaussian returns the taps of a Gaussian pulse shaping filter with the given bandwidth-time product bt, the given
+// number of samples per symbol and a length of span symbols. The filter has span*samplesPerSymbol+1 taps and unit
+// DC gain.
+func Gaussian(bt float64, samplesPerSymbol, span int) []float64 {
+	result := make([]float64, span*samplesPerSymbol+1)
+	center := len(result) / 2
+	for i := range result {
+		t := float64(i-center) / float64(samplesPerSymbol)
+		result[i] = math.Exp(-2 * math.Pi * math.Pi * bt * bt * t * t / math.Ln2)
+	}
+	normalizeSum(result)
+	return result
+}
+
+// GaussianFrequencyPulse returns the frequency pulse of GFSK with the given bandwidth-time product bt, i.e. a
+// rectangular pulse of one symbol convolved with a Gaussian filter. It has the given number of samples per symbol
+// and a length of span symbols (span*samplesPerSymbol taps). The pulses of consecutive symbols add up to 1, so
+// the pulse can be applied directly to the frequency deviation of each symbol, as in FST4W and MSK144.
+func GaussianFrequencyPulse(bt float64, samplesPerSymbol, span int) []float64 {
+	c := math.Pi * math.Sqrt(2/math.Ln2)
+	result := make([]float64, span*samplesPerSymbol)
+	for i := range result {
+		t := float64(i)/float64(samplesPerSymbol) - float64(span)/2
+		result[i] = 0.5 * (math.Erf(c*bt*(t+0.5)) - math.Erf(c*bt*(t-0.5)))
+	}
+	return result
+}
+
+func normalizeEnergy(taps []float64) {
+	var energy float64
+	for _, tap := range taps {
+		energy += tap * tap
+	}
+	scale(taps, 1/math.Sqrt(energy))
+}
+
+func normalizeSum(taps []float64) {
+	var sum float64
+	for _, tap := range taps {
+		sum += tap
+	}
+	scale(taps, 1/sum)
+}
+
+func scale(values []float64, factor float64) {
+	for i := range values {
+		values[i] *= factor
+	}
+}
+
+// Interpolator is a polyphase interpolating FIR filter. It upsamples by an integer factor and applies the filter
+// without computing the products with the inserted zeros. Used with a pulse shaping filter and one input sample
+// per symbol, it generates the shaped baseband signal.
+type Interpolator struct {
+	factor   int
+	branches [][]float64
+	history  []float64
+}
+
+// NewInterpolator returns a new Interpolator with the given filter taps and interpolation factor.
+func NewInterpolator(taps []float64, factor int) *Interpolator {
+	length := (len(taps) + factor - 1) / factor
+	branches := make([][]float64, factor)
+	for p := range branches {
+		branches[p] = make([]float64, length)
+		for k := range branches[p] {
+			if i := k*factor + p; i < len(taps) {
+				branches[p][k] = taps[i]
+			}
+		}
+	}
+	return &Interpolator{
+		factor:   factor,
+		branches: branches,
+		history:  make([]float64, length),
+	}
+}
+
+// Factor returns the interpolation factor.
+func (f *Interpolator) Factor() int {
+	return f.factor
+}
+
+// Delay returns the number of input samples that are needed to flush the filter.
+func (f *Interpolator) Delay() int {
+	return len(f.history) - 1
+}
+
+// Interpolate writes factor output samples into dst for each sample of src. It returns the number of written
+// samples. If dst is too small, only the input samples that fit are processed.
+func (f *Interpolator) Interpolate(dst, src []float64) int {
+	n := minInt(len(src), len(dst)/f.factor)
+	for i, x := range src[:n] {
+		copy(f.history[1:], f.history)
+		f.history[0] = x
+		for p, branch := range f.branches {
+			var y float64
+			for k, tap := range branch {
+				y += tap * f.history[k]
+			}
+			dst[i*f.factor+p] = y
+		}
+	}
+	return n * f.factor
+}
+
+// Reset clears the history of the filter.
+func (f *Interpolator) Reset() {
+	for i := range f.history {
+		f.history[i] = 0
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,103 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func convolve(a, b []float64) []float64 {
+	result := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			result[i+j] += x * y
+		}
+	}
+	return result
+}
+
+func TestRootRaisedCosineIsNyquist(t *testing.T) {
+	const sps = 8
+	for _, rolloff := range []float64{0.25, 0.35, 0.5, 1} {
+		taps := RootRaisedCosine(rolloff, sps, 16)
+		assert.Len(t, taps, 16*sps+1)
+
+		var energy float64
+		for _, tap := range taps {
+			energy += tap * tap
+		}
+		assert.InDelta(t, 1, energy, 1e-9)
+
+		raisedCosine := convolve(taps, taps)
+		center := len(raisedCosine) / 2
+		assert.InDelta(t, 1, raisedCosine[center], 1e-9)
+		for k := 1; k < 6; k++ {
+			assert.InDelta(t, 0, raisedCosine[center+k*sps], 0.01, "rolloff %v, symbol %d", rolloff, k)
+			assert.InDelta(t, 0, raisedCosine[center-k*sps], 0.01, "rolloff %v, symbol %d", rolloff, k)
+		}
+	}
+}
+
+func TestGaussian(t *testing.T) {
+	taps := Gaussian(0.5, 4, 4)
+	assert.Len(t, taps, 17)
+	var sum float64
+	for i, tap := range taps {
+		sum += tap
+		assert.InDelta(t, tap, taps[len(taps)-1-i], 1e-12, "symmetric")
+	}
+	assert.InDelta(t, 1, sum, 1e-9)
+	assert.Equal(t, taps[8], maxOf(taps))
+
+	narrow := Gaussian(0.3, 4, 4)
+	assert.Less(t, narrow[8], taps[8], "a smaller BT spreads the pulse")
+}
+
+func maxOf(values []float64) float64 {
+	result := math.Inf(-1)
+	for _, v := range values {
+		result = math.Max(result, v)
+	}
+	return result
+}
+
+func TestGaussianFrequencyPulse(t *testing.T) {
+	const sps = 16
+	pulse := GaussianFrequencyPulse(2.0, sps, 3)
+	assert.Len(t, pulse, 3*sps)
+
+	// a constant symbol stream leads to a constant frequency
+	for i := 0; i < sps; i++ {
+		var sum float64
+		for k := 0; k < 3; k++ {
+			sum += pulse[i+k*sps]
+		}
+		assert.InDelta(t, 1, sum, 1e-6)
+	}
+}
+
+func TestInterpolator(t *testing.T) {
+	taps := RootRaisedCosine(0.35, 4, 6)
+	f := NewInterpolator(taps, 4)
+	assert.Equal(t, 4, f.Factor())
+
+	symbols := []float64{1, -1, -1, 1, 1, 1, -1, 0, 0, 0, 0, 0, 0}
+	stuffed := make([]float64, 4*len(symbols))
+	for i, s := range symbols {
+		stuffed[4*i] = s
+	}
+	expected := convolve(stuffed, taps)[:len(stuffed)]
+
+	actual := make([]float64, len(stuffed))
+	n := f.Interpolate(actual[:8], symbols[:3])
+	assert.Equal(t, 8, n, "only two symbols fit")
+	n += f.Interpolate(actual[8:], symbols[2:])
+	assert.Equal(t, len(stuffed), n)
+	assert.InDeltaSlice(t, expected, actual, 1e-12)
+
+	f.Reset()
+	actual = make([]float64, 4)
+	f.Interpolate(actual, []float64{0})
+	assert.Equal(t, []float64{0, 0, 0, 0}, actual)
+}
@@ -0,0 +1,187 @@
+package dsp
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// ReferenceBandwidth is the standard noise bandwidth of SNR reports in Hz, as used by WSJT-X for WSPR and FT8.
+	ReferenceBandwidth = 2500.0
+	// MinSNR is the lowest SNR that is reported in dB.
+	MinSNR = -60.0
+	// DefaultSNRAveraging is the default weight of a new analysis block in the averaged spectrum.
+	DefaultSNRAveraging = 0.1
+)
+
+// SNREstimator tracks the noise floor within a passband of the receive audio and computes the SNR of signals,
+// relative to the noise in the ReferenceBandwidth. Decoders of all modes use it so that the reported SNRs are
+// comparable: the PSK and CW demodulators measure the signal within its bandwidth, the WSPR decoder measures it
+// with the tones of the transmission.
+//
+// The estimator averages the power spectrum over analysis blocks. The noise floor is the median of the averaged
+// spectrum within the passband, which ignores the few bins that contain signals.
+type SNREstimator struct {
+	mu sync.Mutex
+
+	sampleRate float64
+	low        float64
+	high       float64
+	averaging  float64
+
+	window   []float64
+	scale    float64
+	analysis []float64
+	analyzed int
+	blocks   int
+	spectrum []complex128
+	power    []float64
+}
+
+// NewSNREstimator returns a new SNREstimator for the given sample rate that measures the noise floor between
+// the given low and high audio frequencies. The analysis block size is a power of two, chosen for a frequency
+// resolution of about 6Hz.
+func NewSNREstimator(sampleRate int, low, high float64) *SNREstimator {
+	size := 1
+	for size < sampleRate/6 {
+		size <<= 1
+	}
+	window := hann(size)
+	windowPower := 0.0
+	for _, w := range window {
+		windowPower += w * w
+	}
+	return &SNREstimator{
+		sampleRate: float64(sampleRate),
+		low:        low,
+		high:       high,
+		averaging:  DefaultSNRAveraging,
+		window:     window,
+		scale:      2 / (float64(size) * windowPower),
+		analysis:   make([]float64, size),
+		spectrum:   make([]complex128, size),
+		power:      make([]float64, size/2),
+	}
+}
+
+// SetPassband sets the audio frequencies between which the noise floor is measured, e.g. after the receiver was
+// tuned to another signal.
+func (e *SNREstimator) SetPassband(low, high float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.low = low
+	e.high = high
+}
+
+// SetAveraging sets the weight of a new analysis block in the averaged spectrum, between 0 (exclusive) and 1.
+func (e *SNREstimator) SetAveraging(averaging float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.averaging = clamp(averaging, math.SmallestNonzeroFloat64, 1)
+}
+
+// Ready indicates if at least one analysis block was processed.
+func (e *SNREstimator) Ready() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.blocks > 0
+}
+
+// Process adds the given samples to the analysis. The samples are not modified.
+func (e *SNREstimator) Process(samples []float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, x := range samples {
+		e.analysis[e.analyzed] = x
+		e.analyzed++
+		if e.analyzed == len(e.analysis) {
+			e.analyze()
+			e.analyzed = 0
+		}
+	}
+}
+
+func (e *SNREstimator) analyze() {
+	for i, x := range e.analysis {
+		e.spectrum[i] = complex(x*e.window[i], 0)
+	}
+	fft(e.spectrum)
+
+	weight := e.averaging
+	if e.blocks == 0 {
+		weight = 1
+	}
+	for i := range e.power {
+		re, im := real(e.spectrum[i]), imag(e.spectrum[i])
+		power := (re*re + im*im) * e.scale
+		e.power[i] = (1-weight)*e.power[i] + weight*power
+	}
+	e.blocks++
+}
+
+// NoiseDensity returns the power of the noise floor per Hz.
+func (e *SNREstimator) NoiseDensity() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.noiseDensity()
+}
+
+func (e *SNREstimator) noiseDensity() float64 {
+	from, to := e.bins(e.low, e.high)
+	if to <= from {
+		return 0
+	}
+	return median(e.power[from:to]) / e.binWidth()
+}
+
+// NoiseFloor returns the power of the noise in the ReferenceBandwidth in dBFS.
+func (e *SNREstimator) NoiseFloor() float64 {
+	return DBFS(math.Sqrt(e.NoiseDensity() * ReferenceBandwidth))
+}
+
+// SNR measures the power within the given bandwidth around the given audio frequency and returns the SNR of the
+// signal in dB, relative to the noise in the ReferenceBandwidth. The noise within the bandwidth is subtracted
+// from the measured power.
+func (e *SNREstimator) SNR(frequency, bandwidth float64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	from, to := e.bins(frequency-bandwidth/2, frequency+bandwidth/2)
+	if to < len(e.power) {
+		// include the bin of the upper edge
+		to++
+	}
+	power := 0.0
+	for _, p := range e.power[from:to] {
+		power += p
+	}
+	density := e.noiseDensity()
+	signal := power - density*e.binWidth()*float64(to-from)
+	return snr(signal, density)
+}
+
+// SignalSNR returns the SNR of a signal with the given power (the mean square of its normalized samples) in dB,
+// relative to the noise in the ReferenceBandwidth. Decoders use it with the power of their matched filter output.
+func (e *SNREstimator) SignalSNR(power float64) float64 {
+	return snr(power, e.NoiseDensity())
+}
+
+func snr(signal, density float64) float64 {
+	if signal <= 0 || density <= 0 {
+		return MinSNR
+	}
+	return math.Max(MinSNR, 10*math.Log10(signal/(density*ReferenceBandwidth)))
+}
+
+func (e *SNREstimator) binWidth() float64 {
+	return e.sampleRate / float64(len(e.analysis))
+}
+
+func (e *SNREstimator) bins(low, high float64) (int, int) {
+	from := int(math.Round(low / e.binWidth()))
+	to := int(math.Round(high / e.binWidth()))
+	from = int(clamp(float64(from), 0, float64(len(e.power))))
+	to = int(clamp(float64(to), float64(from), float64(len(e.power))))
+	return from, to
+}
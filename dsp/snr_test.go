@@ -0,0 +1,71 @@
+package dsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noise(rng *rand.Rand, samples []float64, sigma float64) {
+	for i := range samples {
+		samples[i] = rng.NormFloat64() * sigma
+	}
+}
+
+func TestSNREstimatorNoiseFloor(t *testing.T) {
+	const rate = 12000
+	rng := rand.New(rand.NewSource(1))
+	estimator := NewSNREstimator(rate, 300, 2700)
+	assert.False(t, estimator.Ready())
+
+	samples := make([]float64, 10*rate)
+	noise(rng, samples, 0.01)
+	estimator.Process(samples)
+	assert.True(t, estimator.Ready())
+
+	// white noise with the variance sigma² spreads evenly over rate/2
+	expected := 0.01 * 0.01 / (rate / 2)
+	assert.InDelta(t, 10*math.Log10(expected), 10*math.Log10(estimator.NoiseDensity()), 1)
+	assert.InDelta(t, DBFS(math.Sqrt(expected*ReferenceBandwidth)), estimator.NoiseFloor(), 1)
+}
+
+func TestSNREstimatorSNR(t *testing.T) {
+	const rate = 12000
+	testCases := []struct {
+		desc string
+		snr  float64
+	}{
+		{"strong", 10},
+		{"weak", -10},
+		{"very weak", -20},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(2))
+			estimator := NewSNREstimator(rate, 300, 2700)
+
+			sigma := 0.01
+			density := sigma * sigma / (rate / 2)
+			power := density * ReferenceBandwidth * math.Pow(10, tC.snr/10)
+			amplitude := math.Sqrt(2 * power)
+
+			samples := make([]float64, 20*rate)
+			noise(rng, samples, sigma)
+			for i := range samples {
+				samples[i] += amplitude * math.Sin(2*math.Pi*1500*float64(i)/rate)
+			}
+			estimator.Process(samples)
+
+			assert.InDelta(t, tC.snr, estimator.SNR(1500, 50), 1.5)
+			assert.InDelta(t, tC.snr, estimator.SignalSNR(power), 1)
+		})
+	}
+}
+
+func TestSNREstimatorWithoutSignal(t *testing.T) {
+	estimator := NewSNREstimator(12000, 300, 2700)
+	assert.Equal(t, MinSNR, estimator.SNR(1500, 50))
+	assert.Equal(t, MinSNR, estimator.SignalSNR(0.1))
+}
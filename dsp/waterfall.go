@@ -0,0 +1,219 @@
+package dsp
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Default parameters of the Waterfall.
+const (
+	DefaultWaterfallFFTSize     = 4096
+	DefaultWaterfallFrameRate   = 10.0
+	DefaultWaterfallQueueLength = 16
+)
+
+// ErrInvalidFFTSize is returned when the FFT size of a waterfall is not a power of two.
+var ErrInvalidFFTSize = errors.New("dsp: the FFT size must be a power of two")
+
+// SpectrumFrame is one line of a waterfall display: the magnitude spectrum of the latest FFT size samples.
+type SpectrumFrame struct {
+	// Index counts the frames, starting with 0.
+	Index int
+	// Elapsed is the time of the last analyzed sample since the first processed sample.
+	Elapsed time.Duration
+	// Low is the audio frequency of the first bin in Hz.
+	Low float64
+	// BinWidth is the distance between two bins in Hz.
+	BinWidth float64
+	// Magnitudes contains the level of each bin in dBFS, a sine with the amplitude 1 has 0 dBFS.
+	Magnitudes []float64
+}
+
+// Frequency returns the audio frequency of the given bin in Hz.
+func (f SpectrumFrame) Frequency(bin int) float64 {
+	return f.Low + float64(bin)*f.BinWidth
+}
+
+// Bin returns the bin of the given audio frequency in Hz, or -1 if the frequency is outside of the frame.
+func (f SpectrumFrame) Bin(frequency float64) int {
+	result := int(math.Round((frequency - f.Low) / f.BinWidth))
+	if result < 0 || result >= len(f.Magnitudes) {
+		return -1
+	}
+	return result
+}
+
+// WaterfallConfig contains the parameters of a Waterfall. Zero values are replaced by the defaults.
+type WaterfallConfig struct {
+	// FFTSize is the number of samples of each analysis, a power of two. It defines the frequency resolution.
+	FFTSize int
+	// FrameRate is the number of frames per second. The analyses overlap if the frame rate is higher than
+	// sample rate / FFT size.
+	FrameRate float64
+	// Low and High limit the range of the frames in Hz, the default is the whole range up to half of the sample rate.
+	Low, High float64
+	// QueueLength is the number of frames that are queued in the channel of the waterfall.
+	QueueLength int
+}
+
+// Waterfall computes magnitude spectra of the receive audio at a fixed frame rate. The bins of the frames are
+// audio frequencies, just like the frequencies of the modems, so a display can align both. It is a Processor that
+// does not modify the samples.
+//
+// The frames are passed to the handler of the waterfall. Without a handler, they are queued in the channel
+// returned by Frames, frames are dropped if the queue is full.
+type Waterfall struct {
+	mu sync.Mutex
+
+	sampleRate int
+	handler    func(SpectrumFrame)
+	frames     chan SpectrumFrame
+	closed     bool
+	dropped    int
+
+	window    []float64
+	scale     float64
+	from, to  int
+	hop       float64
+	history   []float64
+	index     int
+	processed int
+	nextFrame float64
+	count     int
+	spectrum  []complex128
+}
+
+// NewWaterfall returns a new Waterfall for the given sample rate, that passes the frames to the given handler. The
+// handler may be nil.
+func NewWaterfall(sampleRate int, config WaterfallConfig, handler func(SpectrumFrame)) (*Waterfall, error) {
+	if config.FFTSize == 0 {
+		config.FFTSize = DefaultWaterfallFFTSize
+	}
+	if config.FrameRate == 0 {
+		config.FrameRate = DefaultWaterfallFrameRate
+	}
+	if config.QueueLength == 0 {
+		config.QueueLength = DefaultWaterfallQueueLength
+	}
+	if config.High == 0 {
+		config.High = float64(sampleRate) / 2
+	}
+	if config.FFTSize < 2 || config.FFTSize&(config.FFTSize-1) != 0 {
+		return nil, ErrInvalidFFTSize
+	}
+
+	binWidth := float64(sampleRate) / float64(config.FFTSize)
+	// the bins between from and to are included, the last bin is below half of the sample rate
+	lastBin := float64(config.FFTSize/2 - 1)
+	from := int(clamp(math.Round(config.Low/binWidth), 0, lastBin))
+	to := int(clamp(math.Round(config.High/binWidth), float64(from), lastBin))
+	window := hann(config.FFTSize)
+	windowSum := 0.0
+	for _, w := range window {
+		windowSum += w
+	}
+	hop := float64(sampleRate) / config.FrameRate
+
+	result := &Waterfall{
+		sampleRate: sampleRate,
+		handler:    handler,
+		window:     window,
+		scale:      2 / windowSum,
+		from:       from,
+		to:         to,
+		hop:        hop,
+		history:    make([]float64, config.FFTSize),
+		nextFrame:  hop,
+		spectrum:   make([]complex128, config.FFTSize),
+	}
+	if handler == nil {
+		result.frames = make(chan SpectrumFrame, config.QueueLength)
+	}
+	return result, nil
+}
+
+// Frames returns the channel of the frames if the waterfall has no handler, otherwise nil. The channel is closed
+// by Close.
+func (w *Waterfall) Frames() <-chan SpectrumFrame {
+	return w.frames
+}
+
+// Dropped returns the number of frames that were dropped because the queue was full.
+func (w *Waterfall) Dropped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Close closes the channel of the frames. Samples that are processed afterwards are ignored.
+func (w *Waterfall) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if w.frames != nil {
+		close(w.frames)
+	}
+}
+
+// Process adds the given samples to the analysis. The samples are not modified.
+func (w *Waterfall) Process(samples []float64) {
+	var frames []SpectrumFrame
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	for _, x := range samples {
+		w.history[w.index] = x
+		w.index = (w.index + 1) % len(w.history)
+		w.processed++
+		if float64(w.processed) < w.nextFrame {
+			continue
+		}
+		w.nextFrame += w.hop
+		frame := w.analyze()
+		if w.frames == nil {
+			frames = append(frames, frame)
+			continue
+		}
+		select {
+		case w.frames <- frame:
+		default:
+			w.dropped++
+		}
+	}
+	w.mu.Unlock()
+
+	for _, frame := range frames {
+		w.handler(frame)
+	}
+}
+
+func (w *Waterfall) analyze() SpectrumFrame {
+	// w.index points to the oldest sample
+	for i := range w.spectrum {
+		w.spectrum[i] = complex(w.history[(w.index+i)%len(w.history)]*w.window[i], 0)
+	}
+	fft(w.spectrum)
+
+	magnitudes := make([]float64, w.to-w.from+1)
+	for i := range magnitudes {
+		c := w.spectrum[w.from+i]
+		magnitudes[i] = DBFS(math.Hypot(real(c), imag(c)) * w.scale)
+	}
+	binWidth := float64(w.sampleRate) / float64(len(w.spectrum))
+	result := SpectrumFrame{
+		Index:      w.count,
+		Elapsed:    time.Duration(float64(w.processed) / float64(w.sampleRate) * float64(time.Second)),
+		Low:        float64(w.from) * binWidth,
+		BinWidth:   binWidth,
+		Magnitudes: magnitudes,
+	}
+	w.count++
+	return result
+}
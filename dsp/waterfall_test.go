@@ -0,0 +1,61 @@
+package dsp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaterfall(t *testing.T) {
+	var frames []SpectrumFrame
+	w, err := NewWaterfall(8000, WaterfallConfig{FFTSize: 1024, FrameRate: 20, Low: 500, High: 2500}, func(frame SpectrumFrame) {
+		frames = append(frames, frame)
+	})
+	require.NoError(t, err)
+	assert.Nil(t, w.Frames())
+
+	samples := sine(1500, 0.5, 8000, 4000)
+	w.Process(samples[:1000])
+	w.Process(samples[1000:])
+
+	require.Len(t, frames, 10, "20 frames per second")
+	frame := frames[9]
+	assert.Equal(t, 9, frame.Index)
+	assert.Equal(t, 500*time.Millisecond, frame.Elapsed)
+	assert.Equal(t, 7.8125, frame.BinWidth)
+	assert.Equal(t, 500.0, frame.Low)
+	assert.Len(t, frame.Magnitudes, 257)
+	assert.Equal(t, 2500.0, frame.Frequency(len(frame.Magnitudes)-1))
+
+	bin := frame.Bin(1500)
+	require.Equal(t, 128, bin)
+	assert.InDelta(t, -6.02, frame.Magnitudes[bin], 0.1)
+	assert.True(t, frame.Magnitudes[frame.Bin(2000)] < -60)
+	assert.Equal(t, -1, frame.Bin(3000))
+}
+
+func TestWaterfallChannel(t *testing.T) {
+	w, err := NewWaterfall(8000, WaterfallConfig{FFTSize: 256, FrameRate: 100, QueueLength: 2}, nil)
+	require.NoError(t, err)
+
+	w.Process(make([]float64, 400))
+	w.Close()
+	w.Process(make([]float64, 400))
+
+	var frames []SpectrumFrame
+	for frame := range w.Frames() {
+		frames = append(frames, frame)
+	}
+	require.Len(t, frames, 2)
+	assert.Equal(t, 1, frames[1].Index)
+	assert.Len(t, frames[0].Magnitudes, 128)
+	assert.Equal(t, MinDBFS, frames[0].Magnitudes[10])
+	assert.Equal(t, 3, w.Dropped())
+}
+
+func TestWaterfallInvalidFFTSize(t *testing.T) {
+	_, err := NewWaterfall(8000, WaterfallConfig{FFTSize: 1000}, nil)
+	assert.Equal(t, ErrInvalidFFTSize, err)
+}
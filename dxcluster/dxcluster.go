@@ -0,0 +1,197 @@
+/*
+Package dxcluster implements a telnet client for DX clusters. It receives spots, e.g. to steer decoders and
+schedulers toward active frequencies, and posts spots generated from the decodes of this package.
+*/
+package dxcluster
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/timesource"
+)
+
+// DefaultReconnectDelay is the time to wait before reconnecting after the connection was lost.
+const DefaultReconnectDelay = 10 * time.Second
+
+// ErrNotConnected is returned when a spot is posted while the client is not connected.
+var ErrNotConnected = errors.New("dxcluster: not connected")
+
+// loginPrompts are the prompts of the common cluster software asking for the callsign.
+var loginPrompts = []string{"login:", "call:", "callsign:"}
+
+// Config of a Client.
+type Config struct {
+	// Address of the cluster, host:port.
+	Address string
+	// Callsign to log in.
+	Callsign string
+	// Commands are sent after the login, e.g. to set server side filters like "set/filter dxbm/pass 20".
+	Commands []string
+	// Accept filters the received spots on the client side. If nil, all spots are accepted.
+	Accept func(Spot) bool
+	// ReconnectDelay is the time to wait before reconnecting. 0 means DefaultReconnectDelay.
+	ReconnectDelay time.Duration
+	// Disconnected is called with the reason when the connection is lost or cannot be established. It is optional.
+	Disconnected func(error)
+}
+
+// Client is a DX cluster client that reconnects automatically.
+type Client struct {
+	config Config
+	clock  timesource.Clock
+	dial   func(ctx context.Context, address string) (net.Conn, error)
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewClient returns a new Client with the given configuration. If clock is nil, the system clock is used.
+func NewClient(config Config, clock timesource.Clock) *Client {
+	if config.ReconnectDelay == 0 {
+		config.ReconnectDelay = DefaultReconnectDelay
+	}
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	var dialer net.Dialer
+	return &Client{
+		config: config,
+		clock:  clock,
+		dial: func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		},
+	}
+}
+
+// Connected indicates if the client is currently connected.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Post sends the given spot to the cluster.
+func (c *Client) Post(spot Spot) error {
+	return c.send(spot.Command())
+}
+
+// Send sends the given command to the cluster.
+func (c *Client) Send(command string) error {
+	return c.send(command)
+}
+
+func (c *Client) send(line string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	_, err := io.WriteString(c.conn, line+"\r\n")
+	return err
+}
+
+// Run connects to the cluster and passes the received spots to the given handler until the context is done.
+// If the connection is lost, Run reconnects after the configured delay.
+func (c *Client) Run(ctx context.Context, handler func(Spot)) error {
+	for {
+		err := c.runConnection(ctx, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.config.Disconnected != nil {
+			c.config.Disconnected(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.config.ReconnectDelay):
+		}
+	}
+}
+
+func (c *Client) runConnection(ctx context.Context, handler func(Spot)) error {
+	conn, err := c.dial(ctx, c.config.Address)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	loggedIn := false
+	var line []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == '\n' {
+			c.handleLine(strings.TrimRight(string(line), "\r"), handler)
+			line = line[:0]
+			continue
+		}
+		line = append(line, b)
+
+		// prompts are not terminated by a newline
+		if loggedIn || reader.Buffered() > 0 || !isLoginPrompt(string(line)) {
+			continue
+		}
+		line = line[:0]
+		loggedIn = true
+		_, err = io.WriteString(conn, c.config.Callsign+"\r\n")
+		if err != nil {
+			return err
+		}
+		for _, command := range c.config.Commands {
+			_, err = io.WriteString(conn, command+"\r\n")
+			if err != nil {
+				return err
+			}
+		}
+		c.mu.Lock()
+		c.conn = conn
+		c.mu.Unlock()
+	}
+}
+
+func (c *Client) handleLine(line string, handler func(Spot)) {
+	spot, err := ParseSpot(line, c.clock.Now())
+	if err != nil {
+		return
+	}
+	if c.config.Accept != nil && !c.config.Accept(spot) {
+		return
+	}
+	handler(spot)
+}
+
+func isLoginPrompt(line string) bool {
+	line = strings.ToLower(strings.TrimSpace(line))
+	for _, prompt := range loginPrompts {
+		if strings.HasSuffix(line, prompt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package dxcluster
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+var testDay = time.Date(2020, 5, 1, 8, 30, 0, 0, time.UTC)
+
+func TestParseSpot(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected Spot
+		invalid  bool
+	}{
+		{
+			line:     "DX de DL1ABC:     14074.0  W1AW         FT8 -10dB                      1234Z",
+			expected: Spot{Spotter: "DL1ABC", Frequency: 14074000, DXCall: "W1AW", Comment: "FT8 -10dB", Time: time.Date(2020, 5, 1, 12, 34, 0, 0, time.UTC)},
+		},
+		{
+			line:     "DX de DL1ABC-#:    7030.5  K1A          CW 22 dB 25 WPM CQ             0812Z JO31",
+			expected: Spot{Spotter: "DL1ABC", Frequency: 7030500, DXCall: "K1A", Comment: "CW 22 dB 25 WPM CQ", Time: time.Date(2020, 5, 1, 8, 12, 0, 0, time.UTC)},
+		},
+		{
+			line:     "DX de W1AW: 3573 DL2XYZ",
+			expected: Spot{Spotter: "W1AW", Frequency: 3573000, DXCall: "DL2XYZ"},
+		},
+		{line: "Hello DL1ABC, this is DB0XYZ", invalid: true},
+		{line: "DX de DL1ABC: W1AW", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.line, func(t *testing.T) {
+			actual, err := ParseSpot(tC.line, testDay)
+			if tC.invalid {
+				assert.Equal(t, ErrNoSpot, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestSpotString(t *testing.T) {
+	spot := Spot{Spotter: "DL1ABC-#", Frequency: 14074000, DXCall: "W1AW", Comment: "FT8 -10dB", Time: time.Date(2020, 5, 1, 12, 34, 56, 0, time.UTC)}
+	line := spot.String()
+	assert.Equal(t, "DX de DL1ABC-#:   14074.0  W1AW         FT8 -10dB                      1234Z", line)
+
+	parsed, err := ParseSpot(line, testDay)
+	require.NoError(t, err)
+	assert.Equal(t, "DL1ABC", parsed.Spotter)
+	assert.Equal(t, spot.Frequency, parsed.Frequency)
+	assert.Equal(t, spot.DXCall, parsed.DXCall)
+	assert.Equal(t, spot.Comment, parsed.Comment)
+	assert.Equal(t, time.Date(2020, 5, 1, 12, 34, 0, 0, time.UTC), parsed.Time)
+}
+
+func TestSpotFromDecode(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		record   digimodes.DecodeRecord
+		expected string
+		invalid  bool
+	}{
+		{
+			desc:     "FT8 CQ",
+			record:   digimodes.DecodeRecord{Mode: "ft8", RFFrequency: 14075234, Text: "CQ W1AW FN31", SNR: -12},
+			expected: "DX 14075.2 W1AW FT8 -12dB",
+		},
+		{
+			desc:     "free text with DE",
+			record:   digimodes.DecodeRecord{Mode: "psk31", RFFrequency: 14070800, Text: "CQ CQ DE K1A K1A PSE K", SNR: 7},
+			expected: "DX 14070.8 K1A PSK31 +7dB",
+		},
+		{
+			desc:    "unknown RF frequency",
+			record:  digimodes.DecodeRecord{Mode: "ft8", AudioFrequency: 1234, Text: "CQ W1AW FN31"},
+			invalid: true,
+		},
+		{
+			desc:    "no sender",
+			record:  digimodes.DecodeRecord{Mode: "psk31", RFFrequency: 14070800, Text: "TNX FER QSO"},
+			invalid: true,
+		},
+		{
+			desc:    "own call",
+			record:  digimodes.DecodeRecord{Mode: "ft8", RFFrequency: 14075234, Text: "W1AW DL1ABC -05"},
+			invalid: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			spot, ok := SpotFromDecode(tC.record, "dl1abc")
+			if tC.invalid {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, "DL1ABC", spot.Spotter)
+			assert.Equal(t, tC.expected, spot.Command())
+		})
+	}
+}
+
+// fakeCluster accepts connections, asks for the login and sends the given lines. The lines received from the
+// client are sent to the returned channel.
+func fakeCluster(t *testing.T, lines ...string) (*Client, <-chan string, func()) {
+	received := make(chan string, 20)
+	var conns []net.Conn
+	client := NewClient(Config{
+		Address:        "cluster",
+		Callsign:       "DL1ABC",
+		Commands:       []string{"set/filter dxbm/pass 20"},
+		ReconnectDelay: time.Millisecond,
+		Accept: func(spot Spot) bool {
+			return !strings.HasPrefix(spot.Comment, "ignore")
+		},
+	}, nil)
+	client.dial = func(ctx context.Context, address string) (net.Conn, error) {
+		clientConn, serverConn := net.Pipe()
+		conns = append(conns, serverConn)
+		go func() {
+			reader := bufio.NewReader(serverConn)
+			io.WriteString(serverConn, "Welcome to the test cluster\r\nPlease enter your call: ")
+			for i := 0; i < 2; i++ {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				received <- strings.TrimSpace(line)
+			}
+			for _, line := range lines {
+				io.WriteString(serverConn, line+"\r\n")
+			}
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				received <- strings.TrimSpace(line)
+			}
+		}()
+		return clientConn, nil
+	}
+	disconnect := func() {
+		conns[len(conns)-1].Close()
+	}
+	return client, received, disconnect
+}
+
+func TestClient(t *testing.T) {
+	client, received, disconnect := fakeCluster(t,
+		"DX de W1AW:     14074.0  K1A          FT8 -10dB                      1234Z",
+		"DX de W1AW:     14074.0  K2A          ignore                         1234Z",
+		"DX de W1AW:      7074.0  K3A          FT8 -3dB                       1235Z",
+	)
+	assert.Equal(t, ErrNotConnected, client.Post(Spot{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	spots := make(chan Spot, 10)
+	runResult := make(chan error)
+	go func() {
+		runResult <- client.Run(ctx, func(spot Spot) {
+			spots <- spot
+		})
+	}()
+
+	assert.Equal(t, "DL1ABC", <-received)
+	assert.Equal(t, "set/filter dxbm/pass 20", <-received)
+	assert.Equal(t, "K1A", (<-spots).DXCall)
+	assert.Equal(t, "K3A", (<-spots).DXCall)
+	require.True(t, client.Connected())
+	require.NoError(t, client.Post(Spot{Frequency: 14074000, DXCall: "W1AW", Comment: "FT8 -12dB"}))
+	assert.Equal(t, "DX 14074.0 W1AW FT8 -12dB", <-received)
+
+	disconnect()
+	assert.Equal(t, "DL1ABC", <-received, "reconnect")
+	assert.Equal(t, "set/filter dxbm/pass 20", <-received)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-runResult)
+	assert.False(t, client.Connected())
+}
@@ -0,0 +1,116 @@
+package dxcluster
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/decodestore"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// ErrNoSpot is returned when a line is not a spot.
+var ErrNoSpot = errors.New("dxcluster: no spot")
+
+// maxCommentLength is the maximum length of the comment of a spot.
+const maxCommentLength = 30
+
+var spotExpression = regexp.MustCompile(`^DX de ([A-Z0-9/\-#]+):?\s+([0-9]+(?:\.[0-9]+)?)\s+([A-Z0-9/]+)(?:\s+(.*?))?\s*(?:([0-9]{4})Z.*)?$`)
+
+// Spot is a DX spot.
+type Spot struct {
+	Spotter string
+	// Frequency in Hz.
+	Frequency float64
+	DXCall    string
+	Comment   string
+	// Time of the spot, only hour and minute are known for received spots.
+	Time time.Time
+}
+
+// ParseSpot parses a spot in the common "DX de" format of the DX clusters. The time of the spot is the given day
+// with the hour and minute of the spot.
+func ParseSpot(line string, day time.Time) (Spot, error) {
+	groups := spotExpression.FindStringSubmatch(strings.TrimSpace(line))
+	if groups == nil {
+		return Spot{}, ErrNoSpot
+	}
+	kHz, err := strconv.ParseFloat(groups[2], 64)
+	if err != nil {
+		return Spot{}, ErrNoSpot
+	}
+	result := Spot{
+		Spotter:   strings.TrimSuffix(groups[1], "-#"),
+		Frequency: kHz * 1000,
+		DXCall:    groups[3],
+		Comment:   groups[4],
+	}
+	if groups[5] != "" {
+		hour, _ := strconv.Atoi(groups[5][:2])
+		minute, _ := strconv.Atoi(groups[5][2:])
+		day = day.UTC()
+		result.Time = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC)
+	}
+	return result, nil
+}
+
+// Command returns the cluster command to post this spot.
+func (s Spot) Command() string {
+	comment := s.Comment
+	if len(comment) > maxCommentLength {
+		comment = comment[:maxCommentLength]
+	}
+	return strings.TrimSpace(fmt.Sprintf("DX %.1f %s %s", s.Frequency/1000, s.DXCall, comment))
+}
+
+// String returns the spot in the common "DX de" format of the DX clusters, as it is sent to the cluster users.
+func (s Spot) String() string {
+	comment := s.Comment
+	if len(comment) > maxCommentLength {
+		comment = comment[:maxCommentLength]
+	}
+	spotter := fmt.Sprintf("DX de %s:", s.Spotter)
+	t := s.Time.UTC()
+	return fmt.Sprintf("%-15s %9.1f  %-12s %-30s %02d%02dZ", spotter, s.Frequency/1000, s.DXCall, comment, t.Hour(), t.Minute())
+}
+
+// SpotFromDecode creates a spot of the station that sent the given decode record. The RF frequency of the record
+// must be known. The sender is taken from a standard FT8/FT4 message or from "DE <callsign>" in free text.
+func SpotFromDecode(record digimodes.DecodeRecord, spotter string) (Spot, bool) {
+	if record.RFFrequency == 0 {
+		return Spot{}, false
+	}
+	dxCall := senderOf(record.Text)
+	if dxCall == "" || strings.EqualFold(dxCall, spotter) {
+		return Spot{}, false
+	}
+	return Spot{
+		Spotter:   strings.ToUpper(spotter),
+		Frequency: record.RFFrequency,
+		DXCall:    dxCall,
+		Comment:   fmt.Sprintf("%s %+.0fdB", strings.ToUpper(record.Mode), record.SNR),
+		Time:      record.Time,
+	}, true
+}
+
+func senderOf(text string) string {
+	message, err := sequencer.ParseMessage(text)
+	if err == nil {
+		return message.From
+	}
+	words := strings.Fields(strings.ToUpper(text))
+	for i := len(words) - 2; i >= 0; i-- {
+		if words[i] != "DE" {
+			continue
+		}
+		callsigns := decodestore.Callsigns(words[i+1])
+		if len(callsigns) == 1 {
+			return callsigns[0]
+		}
+	}
+	return ""
+}
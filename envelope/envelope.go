@@ -0,0 +1,224 @@
+/*
+Package envelope provides a checked message container that can be layered on the text stream of any text mode,
+e.g. PSK31, RTTY or CW. A message is framed with its sequence number and length, protected by a CRC and
+optionally by a Reed-Solomon code, and sent as base32 text between parentheses. This is "hello world!" with the
+sequence number 1 and a CRC-16:
+
+	(AAAQADDIMVWGY3ZAO5XXE3DEEGOXU)
+
+The receiver picks the frames out of the received text, corrects and verifies them, and delivers the payloads.
+Both sides must use the same Codec.
+*/
+package envelope
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+)
+
+// The markers that enclose a frame in the text stream.
+const (
+	StartMarker = '('
+	EndMarker   = ')'
+)
+
+// headerSize is the size of the sequence number and the length.
+const headerSize = 4
+
+// MaxPayloadSize is the maximum size of the payload of a message.
+const MaxPayloadSize = 0xFFFF
+
+var (
+	// ErrPayloadTooLarge is returned when the payload exceeds MaxPayloadSize.
+	ErrPayloadTooLarge = errors.New("envelope: payload too large")
+	// ErrInvalidFrame is returned when a frame cannot be decoded.
+	ErrInvalidFrame = errors.New("envelope: invalid frame")
+	// ErrChecksum is returned when the CRC of a frame does not match.
+	ErrChecksum = errors.New("envelope: checksum mismatch")
+	// ErrInvalidCodec is returned for an invalid codec configuration.
+	ErrInvalidCodec = errors.New("envelope: invalid codec")
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// CRC selects the checksum of a frame.
+type CRC int
+
+// All CRCs.
+const (
+	// CRC16 is the CRC-16/CCITT-FALSE.
+	CRC16 CRC = iota
+	// CRC32 is the CRC-32/IEEE.
+	CRC32
+)
+
+// Size returns the size of the checksum in bytes.
+func (c CRC) Size() int {
+	if c == CRC32 {
+		return 4
+	}
+	return 2
+}
+
+func (c CRC) append(frame []byte) []byte {
+	checksum := make([]byte, c.Size())
+	if c == CRC32 {
+		binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(frame))
+	} else {
+		binary.BigEndian.PutUint16(checksum, crc16(frame))
+	}
+	return append(frame, checksum...)
+}
+
+func (c CRC) verify(frame []byte) bool {
+	data := frame[:len(frame)-c.Size()]
+	checksum := frame[len(frame)-c.Size():]
+	if c == CRC32 {
+		return binary.BigEndian.Uint32(checksum) == crc32.ChecksumIEEE(data)
+	}
+	return binary.BigEndian.Uint16(checksum) == crc16(data)
+}
+
+// crc16 computes the CRC-16/CCITT-FALSE of the given data.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Codec defines the protection of the frames.
+type Codec struct {
+	// CRC is the checksum of the frames.
+	CRC CRC
+	// Parity is the number of Reed-Solomon parity bytes per block of up to 255 bytes. It corrects up to Parity/2
+	// wrong bytes per block. 0 disables the forward error correction.
+	Parity int
+}
+
+// Message is a received message.
+type Message struct {
+	Sequence uint16
+	Payload  []byte
+	// Corrected is the number of bytes that were corrected by the forward error correction.
+	Corrected int
+}
+
+func (c Codec) validate() error {
+	if c.Parity < 0 || c.Parity >= rsBlockSize-1 {
+		return ErrInvalidCodec
+	}
+	return nil
+}
+
+// Encode returns the text of the frame with the given sequence number and payload, including the markers.
+func (c Codec) Encode(sequence uint16, payload []byte) (string, error) {
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	if len(payload) > MaxPayloadSize {
+		return "", ErrPayloadTooLarge
+	}
+	frame := make([]byte, headerSize, headerSize+len(payload)+c.CRC.Size())
+	binary.BigEndian.PutUint16(frame[0:], sequence)
+	binary.BigEndian.PutUint16(frame[2:], uint16(len(payload)))
+	frame = append(frame, payload...)
+	frame = c.CRC.append(frame)
+
+	if c.Parity > 0 {
+		protected := make([]byte, 0, len(frame)+(len(frame)/(rsBlockSize-c.Parity)+1)*c.Parity)
+		for start := 0; start < len(frame); start += rsBlockSize - c.Parity {
+			end := start + rsBlockSize - c.Parity
+			if end > len(frame) {
+				end = len(frame)
+			}
+			block, err := rsEncode(frame[start:end], c.Parity)
+			if err != nil {
+				return "", err
+			}
+			protected = append(protected, block...)
+		}
+		frame = protected
+	}
+	return string(StartMarker) + encoding.EncodeToString(frame) + string(EndMarker), nil
+}
+
+// Decode decodes, corrects and verifies the given frame. The markers are optional, whitespace is ignored and the
+// case of the letters does not matter.
+func (c Codec) Decode(text string) (Message, error) {
+	if err := c.validate(); err != nil {
+		return Message{}, err
+	}
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, string(StartMarker))
+	text = strings.TrimSuffix(text, string(EndMarker))
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '2' && r <= '7'):
+			return r
+		default:
+			// keep the position of garbled characters, the error correction may repair them
+			return 'A'
+		}
+	}, text)
+	frame, err := encoding.DecodeString(text)
+	if err != nil {
+		return Message{}, ErrInvalidFrame
+	}
+
+	corrected := 0
+	if c.Parity > 0 {
+		unprotected := make([]byte, 0, len(frame))
+		for start := 0; start < len(frame); start += rsBlockSize {
+			end := start + rsBlockSize
+			if end > len(frame) {
+				end = len(frame)
+			}
+			block := frame[start:end]
+			if len(block) <= c.Parity {
+				return Message{}, ErrInvalidFrame
+			}
+			n, err := rsDecode(block, c.Parity)
+			if err == errTooManyErrors {
+				return Message{}, ErrChecksum
+			}
+			if err != nil {
+				return Message{}, err
+			}
+			corrected += n
+			unprotected = append(unprotected, block[:len(block)-c.Parity]...)
+		}
+		frame = unprotected
+	}
+
+	if len(frame) < headerSize+c.CRC.Size() {
+		return Message{}, ErrInvalidFrame
+	}
+	if !c.CRC.verify(frame) {
+		return Message{}, ErrChecksum
+	}
+	length := int(binary.BigEndian.Uint16(frame[2:]))
+	if headerSize+length+c.CRC.Size() != len(frame) {
+		return Message{}, ErrInvalidFrame
+	}
+	return Message{
+		Sequence:  binary.BigEndian.Uint16(frame[0:]),
+		Payload:   frame[headerSize : headerSize+length],
+		Corrected: corrected,
+	}, nil
+}
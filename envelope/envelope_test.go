@@ -0,0 +1,94 @@
+package envelope
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	assert.Equal(t, uint16(0x29B1), crc16([]byte("123456789")))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		codec   Codec
+		payload string
+	}{
+		{"crc16", Codec{CRC: CRC16}, "hello world"},
+		{"crc32", Codec{CRC: CRC32}, "hello world"},
+		{"empty", Codec{CRC: CRC16}, ""},
+		{"fec", Codec{CRC: CRC16, Parity: 8}, "hello world"},
+		{"fec several blocks", Codec{CRC: CRC32, Parity: 16}, strings.Repeat("0123456789", 60)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			text, err := tC.codec.Encode(42, []byte(tC.payload))
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(text, "("))
+			assert.True(t, strings.HasSuffix(text, ")"))
+
+			message, err := tC.codec.Decode(text)
+			require.NoError(t, err)
+			assert.Equal(t, uint16(42), message.Sequence)
+			assert.Equal(t, tC.payload, string(message.Payload))
+			assert.Equal(t, 0, message.Corrected)
+		})
+	}
+}
+
+func TestDecodeTolerance(t *testing.T) {
+	codec := Codec{CRC: CRC16}
+	text, err := codec.Encode(1, []byte("73"))
+	require.NoError(t, err)
+
+	message, err := codec.Decode(" " + strings.ToLower(text[:5]) + " " + text[5:] + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, "73", string(message.Payload))
+}
+
+func TestDecodeDetectsErrors(t *testing.T) {
+	codec := Codec{CRC: CRC16}
+	text, err := codec.Encode(1, []byte("hello world"))
+	require.NoError(t, err)
+
+	_, err = codec.Decode(flip(text, 5))
+	assert.Equal(t, ErrChecksum, err)
+	_, err = codec.Decode("(A)")
+	assert.Equal(t, ErrInvalidFrame, err)
+	_, err = codec.Decode("(AAAA)")
+	assert.Equal(t, ErrInvalidFrame, err)
+}
+
+func TestDecodeCorrectsErrors(t *testing.T) {
+	codec := Codec{CRC: CRC16, Parity: 8}
+	text, err := codec.Encode(7, []byte("hello world"))
+	require.NoError(t, err)
+
+	garbled := flip(text, 3)
+	garbled = garbled[:10] + "#" + garbled[11:]
+	message, err := codec.Decode(garbled)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(message.Payload))
+	// a base32 character may span two bytes
+	assert.True(t, message.Corrected >= 2, "corrected: %d", message.Corrected)
+}
+
+func TestInvalidCodec(t *testing.T) {
+	_, err := Codec{Parity: 254}.Encode(0, nil)
+	assert.Equal(t, ErrInvalidCodec, err)
+	_, err = Codec{}.Encode(0, make([]byte, MaxPayloadSize+1))
+	assert.Equal(t, ErrPayloadTooLarge, err)
+}
+
+// flip replaces the character at the given position with a different base32 character.
+func flip(text string, position int) string {
+	replacement := "B"
+	if text[position] == 'B' {
+		replacement = "C"
+	}
+	return text[:position] + replacement + text[position+1:]
+}
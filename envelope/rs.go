@@ -0,0 +1,48 @@
+package envelope
+
+import (
+	"errors"
+
+	"github.com/ftl/digimodes/fec"
+)
+
+// errTooManyErrors is returned when a Reed-Solomon block contains more errors than can be corrected.
+var errTooManyErrors = errors.New("envelope: too many errors")
+
+// rsBlockSize is the maximum size of a Reed-Solomon block over GF(256), data and parity.
+const rsBlockSize = 255
+
+// rsCode returns the Reed-Solomon code over GF(256) with the primitive polynomial x^8+x^4+x^3+x^2+1 and the given
+// number of parity symbols.
+func rsCode(parity int) (*fec.ReedSolomon, error) {
+	return fec.NewReedSolomon(8, 0x11d, 0, parity)
+}
+
+// rsEncode returns the given data followed by the given number of parity symbols.
+func rsEncode(data []byte, parity int) ([]byte, error) {
+	code, err := rsCode(parity)
+	if err != nil {
+		return nil, err
+	}
+	return code.Encode(data)
+}
+
+// rsValid indicates if the given block of data and parity symbols is free of errors.
+func rsValid(block []byte, parity int) bool {
+	code, err := rsCode(parity)
+	return err == nil && code.Valid(block)
+}
+
+// rsDecode corrects the errors in the given block of data and parity symbols in place. It returns the number of
+// corrected symbols, or errTooManyErrors if the block cannot be corrected.
+func rsDecode(block []byte, parity int) (int, error) {
+	code, err := rsCode(parity)
+	if err != nil {
+		return 0, err
+	}
+	result, err := code.Decode(block)
+	if err != nil {
+		return 0, errTooManyErrors
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package envelope
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonCorrectsErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	testCases := []struct {
+		desc   string
+		length int
+		parity int
+		errors int
+	}{
+		{"no errors", 20, 8, 0},
+		{"one error", 20, 8, 1},
+		{"max errors", 20, 8, 4},
+		{"full block", 255 - 16, 16, 8},
+		{"odd parity", 10, 5, 2},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data := make([]byte, tC.length)
+			rng.Read(data)
+			block, err := rsEncode(data, tC.parity)
+			require.NoError(t, err)
+			require.Len(t, block, tC.length+tC.parity)
+			require.True(t, rsValid(block, tC.parity))
+
+			for _, position := range rng.Perm(len(block))[:tC.errors] {
+				block[position] ^= byte(rng.Intn(255) + 1)
+			}
+			corrected, err := rsDecode(block, tC.parity)
+			require.NoError(t, err)
+			assert.Equal(t, tC.errors, corrected)
+			assert.Equal(t, data, block[:tC.length])
+		})
+	}
+}
+
+func TestReedSolomonDetectsTooManyErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	failures := 0
+	for i := 0; i < 100; i++ {
+		data := make([]byte, 30)
+		rng.Read(data)
+		block, err := rsEncode(data, 4)
+		require.NoError(t, err)
+		for _, position := range rng.Perm(len(block))[:5] {
+			block[position] ^= byte(rng.Intn(255) + 1)
+		}
+		_, err = rsDecode(block, 4)
+		if err != nil {
+			failures++
+		}
+	}
+	// a few miscorrections are possible, the CRC of the frame catches them
+	assert.True(t, failures > 90, "failures: %d", failures)
+}
+
+func TestReedSolomonInvalidParity(t *testing.T) {
+	_, err := rsEncode([]byte("data"), 0)
+	assert.Error(t, err)
+	_, err = rsDecode([]byte("data"), 0)
+	assert.Error(t, err)
+	assert.NotEqual(t, errTooManyErrors, err)
+	assert.False(t, rsValid([]byte("data"), 0))
+}
@@ -0,0 +1,104 @@
+package envelope
+
+import (
+	"io"
+	"sync"
+)
+
+// maxFrameLength is the maximum number of characters between the markers that are collected by a Receiver.
+const maxFrameLength = 2 * 1024 * 1024
+
+// Sender sends messages as frames over the text stream of a mode, e.g. a modulator.
+type Sender struct {
+	codec Codec
+	w     io.Writer
+
+	mu       sync.Mutex
+	sequence uint16
+}
+
+// NewSender returns a new Sender that writes the frames to the given writer.
+func NewSender(w io.Writer, codec Codec) *Sender {
+	return &Sender{
+		codec: codec,
+		w:     w,
+	}
+}
+
+// Send sends the given payload with the next sequence number. It returns the sequence number of the message.
+func (s *Sender) Send(payload []byte) (uint16, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text, err := s.codec.Encode(s.sequence, payload)
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.WriteString(s.w, text+" ")
+	if err != nil {
+		return 0, err
+	}
+	sequence := s.sequence
+	s.sequence++
+	return sequence, nil
+}
+
+// Receiver picks the frames out of the received text of a mode. It implements io.Writer, the decoded text is
+// written into it. The text outside of the frames is ignored.
+type Receiver struct {
+	codec   Codec
+	handler func(Message)
+
+	mu         sync.Mutex
+	collecting bool
+	frame      []byte
+	rejected   int
+}
+
+// NewReceiver returns a new Receiver that passes all valid messages to the given handler.
+func NewReceiver(codec Codec, handler func(Message)) *Receiver {
+	return &Receiver{
+		codec:   codec,
+		handler: handler,
+	}
+}
+
+// Write processes the given received text.
+func (r *Receiver) Write(p []byte) (int, error) {
+	messages := make([]Message, 0)
+	r.mu.Lock()
+	for _, b := range p {
+		switch {
+		case b == StartMarker:
+			r.collecting = true
+			r.frame = r.frame[:0]
+		case b == EndMarker && r.collecting:
+			r.collecting = false
+			message, err := r.codec.Decode(string(r.frame))
+			if err != nil {
+				r.rejected++
+				continue
+			}
+			messages = append(messages, message)
+		case r.collecting:
+			if len(r.frame) == maxFrameLength {
+				r.collecting = false
+				r.rejected++
+				continue
+			}
+			r.frame = append(r.frame, b)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, message := range messages {
+		r.handler(message)
+	}
+	return len(p), nil
+}
+
+// Rejected returns the number of frames that were rejected because they were invalid or could not be corrected.
+func (r *Receiver) Rejected() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rejected
+}
@@ -0,0 +1,45 @@
+package envelope
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderReceiver(t *testing.T) {
+	codec := Codec{CRC: CRC32, Parity: 4}
+	buffer := &bytes.Buffer{}
+	sender := NewSender(buffer, codec)
+
+	sequence, err := sender.Send([]byte(`{"temp":21.5}`))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0), sequence)
+	sequence, err = sender.Send([]byte("second"))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1), sequence)
+
+	messages := make([]Message, 0)
+	receiver := NewReceiver(codec, func(message Message) {
+		messages = append(messages, message)
+	})
+	received := "CQ CQ de DL1ABC " + buffer.String() + "(BROKEN) pse k"
+	// the text arrives in small pieces
+	for i := 0; i < len(received); i += 3 {
+		end := i + 3
+		if end > len(received) {
+			end = len(received)
+		}
+		n, err := receiver.Write([]byte(received[i:end]))
+		require.NoError(t, err)
+		assert.Equal(t, end-i, n)
+	}
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, uint16(0), messages[0].Sequence)
+	assert.Equal(t, `{"temp":21.5}`, string(messages[0].Payload))
+	assert.Equal(t, uint16(1), messages[1].Sequence)
+	assert.Equal(t, "second", string(messages[1].Payload))
+	assert.Equal(t, 1, receiver.Rejected())
+}
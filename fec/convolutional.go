@@ -0,0 +1,291 @@
+package fec
+
+import "math"
+
+// Convolutional describes a convolutional code with the rate 1/len(Polynomials). The shift register of the encoder
+// holds the last Constraint bits, the newest bit is the lowest bit. Each polynomial selects the bits of the shift
+// register that are combined into one output bit.
+type Convolutional struct {
+	Constraint  int
+	Polynomials []uint32
+}
+
+// The convolutional codes used by the digital modes.
+var (
+	// WSPR is the K=32, r=1/2 code of WSPR (Layland-Lushbaugh polynomials).
+	WSPR = Convolutional{Constraint: 32, Polynomials: []uint32{0xf2d05351, 0xe4613c47}}
+	// K7 is the K=7, r=1/2 code of NASA/CCSDS (octal 171 and 133, given here in the bit order of the shift register).
+	K7 = Convolutional{Constraint: 7, Polynomials: []uint32{0x4f, 0x6d}}
+)
+
+// MaxViterbiConstraint is the longest constraint length that can be decoded with the Viterbi decoder.
+const MaxViterbiConstraint = 16
+
+// Rate returns the number of output bits per input bit.
+func (c Convolutional) Rate() int {
+	return len(c.Polynomials)
+}
+
+// TailBits returns the number of zero bits that flush the shift register at the end of a block.
+func (c Convolutional) TailBits() int {
+	return c.Constraint - 1
+}
+
+// EncodedLength returns the number of output bits for the given number of data bits, including the tail.
+func (c Convolutional) EncodedLength(dataBits int) int {
+	return (dataBits + c.TailBits()) * c.Rate()
+}
+
+// DataLength returns the number of data bits for the given number of encoded bits, or -1 if there is no such
+// number.
+func (c Convolutional) DataLength(encodedBits int) int {
+	if c.Rate() == 0 || encodedBits%c.Rate() != 0 || encodedBits/c.Rate() < c.TailBits() {
+		return -1
+	}
+	return encodedBits/c.Rate() - c.TailBits()
+}
+
+func (c Convolutional) valid() bool {
+	return c.Constraint > 1 && c.Constraint <= 32 && c.Rate() > 0
+}
+
+// output returns the output bits for the given content of the shift register, the bit of the first polynomial is
+// the highest bit.
+func (c Convolutional) output(register uint32) int {
+	result := 0
+	for _, polynomial := range c.Polynomials {
+		result = result<<1 | parityOf(register&polynomial)
+	}
+	return result
+}
+
+// Encode returns the encoded bits for the given data bits. The encoder starts with an empty shift register and
+// appends the tail bits to flush it at the end.
+func (c Convolutional) Encode(bits []byte) []byte {
+	rate := c.Rate()
+	result := make([]byte, 0, c.EncodedLength(len(bits)))
+	var register uint32
+	for i := 0; i < len(bits)+c.TailBits(); i++ {
+		register <<= 1
+		if i < len(bits) {
+			register |= uint32(bits[i] & 1)
+		}
+		output := c.output(register)
+		for j := rate - 1; j >= 0; j-- {
+			result = append(result, byte(output>>j)&1)
+		}
+	}
+	return result
+}
+
+// branchMetrics returns the correlation of each possible output with the received soft bits of one step.
+func (c Convolutional) branchMetrics(soft []float64) []float64 {
+	rate := c.Rate()
+	result := make([]float64, 1<<rate)
+	for output := range result {
+		for j, s := range soft {
+			if (output>>(rate-1-j))&1 == 1 {
+				result[output] += s
+			} else {
+				result[output] -= s
+			}
+		}
+	}
+	return result
+}
+
+// DecodeViterbi recovers the data bits from the received soft bits with a maximum likelihood (Viterbi) decoder.
+// The received block must end with the tail bits. The constraint length must not exceed MaxViterbiConstraint.
+func (c Convolutional) DecodeViterbi(soft []float64) ([]byte, error) {
+	if !c.valid() {
+		return nil, ErrInvalidCode
+	}
+	if c.Constraint > MaxViterbiConstraint {
+		return nil, ErrConstraintTooLong
+	}
+	dataBits := c.DataLength(len(soft))
+	if dataBits < 0 {
+		return nil, ErrInvalidLength
+	}
+	rate := c.Rate()
+	steps := dataBits + c.TailBits()
+	states := 1 << (c.Constraint - 1)
+	mask := uint32(states - 1)
+
+	metrics := make([]float64, states)
+	next := make([]float64, states)
+	for i := range metrics {
+		metrics[i] = math.Inf(-1)
+	}
+	metrics[0] = 0
+	// decisions[step][state] is the oldest bit of the register on the surviving path into the state
+	decisions := make([][]byte, steps)
+
+	for step := 0; step < steps; step++ {
+		branches := c.branchMetrics(soft[step*rate : (step+1)*rate])
+		decisions[step] = make([]byte, states)
+		for state := range next {
+			best := math.Inf(-1)
+			for oldest := uint32(0); oldest < 2; oldest++ {
+				register := uint32(state) | oldest<<(c.Constraint-1)
+				metric := metrics[register>>1] + branches[c.output(register)]
+				if metric > best {
+					best = metric
+					decisions[step][state] = byte(oldest)
+				}
+			}
+			next[state] = best
+		}
+		metrics, next = next, metrics
+	}
+
+	// the tail flushes the encoder into state 0
+	result := make([]byte, steps)
+	state := uint32(0)
+	for step := steps - 1; step >= 0; step-- {
+		result[step] = byte(state & 1)
+		register := state | uint32(decisions[step][state])<<(c.Constraint-1)
+		state = (register >> 1) & mask
+	}
+	return result[:dataBits], nil
+}
+
+// FanoConfig contains the parameters of the Fano decoder. Zero values are replaced by the defaults.
+type FanoConfig struct {
+	// Delta is the step of the threshold, the default is 4.
+	Delta float64
+	// MaxCycles is the maximum number of cycles per bit before the decoder gives up, the default is 10000.
+	MaxCycles int
+	// MinProbability limits the confidence in a single received bit, the default is 0.01.
+	MinProbability float64
+}
+
+func (c *FanoConfig) setDefaults() {
+	if c.Delta == 0 {
+		c.Delta = 4
+	}
+	if c.MaxCycles == 0 {
+		c.MaxCycles = 10000
+	}
+	if c.MinProbability == 0 {
+		c.MinProbability = 0.01
+	}
+}
+
+// bitMetric is the Fano metric of a received soft bit between -1 and 1 given the transmitted bit.
+func (c FanoConfig) bitMetric(bit int, received float64, rate int) float64 {
+	if bit == 0 {
+		received = -received
+	}
+	p := math.Max(c.MinProbability, math.Min(1-c.MinProbability, (1+received)/2))
+	return math.Log2(2*p) - 1/float64(rate)
+}
+
+type fanoNode struct {
+	metrics  []float64
+	register uint32
+	gamma    float64
+	branches [2]float64
+	branch   int
+}
+
+// DecodeFano recovers the data bits from the received soft bits between -1 and 1 with a sequential (Fano)
+// decoder, which also works for long constraint lengths. The received block must end with the tail bits.
+func (c Convolutional) DecodeFano(soft []float64, config FanoConfig) ([]byte, error) {
+	if !c.valid() {
+		return nil, ErrInvalidCode
+	}
+	dataBits := c.DataLength(len(soft))
+	if dataBits < 0 {
+		return nil, ErrInvalidLength
+	}
+	config.setDefaults()
+	rate := c.Rate()
+	steps := dataBits + c.TailBits()
+
+	nodes := make([]fanoNode, steps+1)
+	for i := 0; i < steps; i++ {
+		nodes[i].metrics = make([]float64, 1<<rate)
+		for output := range nodes[i].metrics {
+			for j := 0; j < rate; j++ {
+				bit := (output >> (rate - 1 - j)) & 1
+				nodes[i].metrics[output] += config.bitMetric(bit, soft[i*rate+j], rate)
+			}
+		}
+	}
+
+	// the register of a node holds the bit of the current branch, a one bit is the register with the lowest bit set
+	sortBranches := func(n *fanoNode) {
+		m0 := n.metrics[c.output(n.register&^1)]
+		m1 := n.metrics[c.output(n.register|1)]
+		if m0 >= m1 {
+			n.branches = [2]float64{m0, m1}
+			n.register &^= 1
+		} else {
+			n.branches = [2]float64{m1, m0}
+			n.register |= 1
+		}
+		n.branch = 0
+	}
+
+	np := 0
+	sortBranches(&nodes[np])
+	threshold := 0.0
+	cycles := 0
+	for np < steps {
+		cycles++
+		if cycles > config.MaxCycles*steps {
+			return nil, ErrDecodeFailed
+		}
+
+		n := &nodes[np]
+		gamma := n.gamma + n.branches[n.branch]
+		if gamma >= threshold {
+			// move forward
+			if n.gamma < threshold+config.Delta {
+				for gamma >= threshold+config.Delta {
+					threshold += config.Delta
+				}
+			}
+			nodes[np+1].gamma = gamma
+			nodes[np+1].register = n.register << 1
+			np++
+			if np == steps {
+				break
+			}
+			next := &nodes[np]
+			if np >= dataBits {
+				// only zeros in the tail
+				next.branches[0] = next.metrics[c.output(next.register)]
+				next.branch = 0
+			} else {
+				sortBranches(next)
+			}
+			continue
+		}
+
+		// move back
+		for {
+			if np == 0 || nodes[np-1].gamma < threshold {
+				threshold -= config.Delta
+				if nodes[np].branch != 0 {
+					nodes[np].branch = 0
+					nodes[np].register ^= 1
+				}
+				break
+			}
+			np--
+			if np < dataBits && nodes[np].branch != 1 {
+				nodes[np].branch++
+				nodes[np].register ^= 1
+				break
+			}
+		}
+	}
+
+	result := make([]byte, dataBits)
+	for i := range result {
+		result[i] = byte(nodes[i].register & 1)
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package fec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomBits(rng *rand.Rand, n int) []byte {
+	result := make([]byte, n)
+	for i := range result {
+		result[i] = byte(rng.Intn(2))
+	}
+	return result
+}
+
+// toSoft converts the given bits into soft bits, the given number of bits is inverted.
+func toSoft(rng *rand.Rand, bits []byte, errors int) []float64 {
+	result := make([]float64, len(bits))
+	for i, bit := range bits {
+		result[i] = float64(2*int(bit) - 1)
+	}
+	for _, i := range rng.Perm(len(result))[:errors] {
+		result[i] = -result[i]
+	}
+	return result
+}
+
+func TestConvolutionalEncode(t *testing.T) {
+	encoded := K7.Encode([]byte{1, 0, 1, 1})
+	require.Len(t, encoded, K7.EncodedLength(4))
+	assert.Equal(t, 20, len(encoded))
+	// a single one bit shifts the polynomials through the register
+	impulse := K7.Encode([]byte{1})
+	expected := make([]byte, 0, len(impulse))
+	for i := 0; i < K7.Constraint; i++ {
+		expected = append(expected, byte(K7.Polynomials[0]>>i)&1, byte(K7.Polynomials[1]>>i)&1)
+	}
+	assert.Equal(t, expected, impulse)
+	assert.Equal(t, 4, K7.DataLength(len(encoded)))
+	assert.Equal(t, -1, K7.DataLength(len(encoded)+1))
+}
+
+func TestViterbi(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	testCases := []struct {
+		desc   string
+		length int
+		errors int
+	}{
+		{"no errors", 100, 0},
+		{"scattered errors", 100, 8},
+		{"short block", 10, 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			bits := randomBits(rng, tC.length)
+			soft := toSoft(rng, K7.Encode(bits), tC.errors)
+
+			decoded, err := K7.DecodeViterbi(soft)
+
+			require.NoError(t, err)
+			assert.Equal(t, bits, decoded)
+		})
+	}
+}
+
+func TestViterbiRejectsLongConstraint(t *testing.T) {
+	_, err := WSPR.DecodeViterbi(make([]float64, WSPR.EncodedLength(50)))
+	assert.Equal(t, ErrConstraintTooLong, err)
+	_, err = K7.DecodeViterbi(make([]float64, 5))
+	assert.Equal(t, ErrInvalidLength, err)
+}
+
+func TestFano(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, code := range []Convolutional{WSPR, K7} {
+		bits := randomBits(rng, 50)
+		soft := toSoft(rng, code.Encode(bits), 6)
+
+		decoded, err := code.DecodeFano(soft, FanoConfig{})
+
+		require.NoError(t, err)
+		assert.Equal(t, bits, decoded)
+	}
+}
+
+func TestFanoGivesUp(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	soft := make([]float64, WSPR.EncodedLength(50))
+	for i := range soft {
+		soft[i] = rng.Float64()*2 - 1
+	}
+	_, err := WSPR.DecodeFano(soft, FanoConfig{MaxCycles: 10})
+	assert.Equal(t, ErrDecodeFailed, err)
+}
@@ -0,0 +1,35 @@
+/*
+Package fec provides the forward error correction codes that are used by the digital modes, so that each mode does
+not need to implement its own error correction:
+
+  - convolutional codes with a Viterbi decoder for short constraint lengths (e.g. K=7) and a Fano sequential
+    decoder for long constraint lengths (e.g. the K=32 code of WSPR)
+  - Reed-Solomon codes over GF(2^m), e.g. the RS(63,12) code of JT65
+  - LDPC codes with a belief propagation decoder for a given parity check matrix, e.g. the (174,91) code of FT8
+
+Bits are represented as bytes with the value 0 or 1. Soft bits are float64 values, positive values stand for a 1,
+negative values for a 0, the magnitude is the confidence.
+*/
+package fec
+
+import "errors"
+
+// Errors of the codecs.
+var (
+	ErrDecodeFailed      = errors.New("fec: cannot decode")
+	ErrTooManyErrors     = errors.New("fec: too many errors")
+	ErrInvalidLength     = errors.New("fec: invalid length")
+	ErrInvalidSymbol     = errors.New("fec: invalid symbol")
+	ErrInvalidCode       = errors.New("fec: invalid code parameters")
+	ErrConstraintTooLong = errors.New("fec: constraint length too long for the Viterbi decoder")
+)
+
+// parityOf returns the parity (0 or 1) of the set bits of x.
+func parityOf(x uint32) int {
+	x ^= x >> 16
+	x ^= x >> 8
+	x ^= x >> 4
+	x ^= x >> 2
+	x ^= x >> 1
+	return int(x & 1)
+}
@@ -0,0 +1,204 @@
+package fec
+
+import "math"
+
+// LDPC is a systematic low density parity check code. A code word holds the K message bits followed by the N-K
+// parity bits. The code is defined by its sparse parity check matrix, the matrix of the parity bits must be
+// invertible.
+//
+// The (174,91) code of FT8 and FT4 is such a code, see FT8LDPC.
+type LDPC struct {
+	n, k int
+	// checks contains the indices of the bits that take part in each parity check
+	checks [][]int
+	// parityRows contains for each parity bit the message bits that are added up to calculate it
+	parityRows [][]int
+}
+
+// The dimensions of the LDPC code of FT8 and FT4.
+const (
+	FT8CodeLength    = 174
+	FT8MessageLength = 91
+)
+
+// NewLDPC returns the LDPC code with the given length of a code word, length of the message and parity checks. Each
+// parity check contains the indices of the bits of the code word whose sum must be zero. There must be N-K parity
+// checks.
+func NewLDPC(n, k int, checks [][]int) (*LDPC, error) {
+	m := n - k
+	if k < 1 || m < 1 || len(checks) != m {
+		return nil, ErrInvalidCode
+	}
+
+	// the dense matrix [H_message | H_parity] as rows of bits
+	rows := make([][]byte, m)
+	for i, check := range checks {
+		rows[i] = make([]byte, n)
+		for _, bit := range check {
+			if bit < 0 || bit >= n {
+				return nil, ErrInvalidCode
+			}
+			rows[i][bit] ^= 1
+		}
+	}
+
+	// Gauss-Jordan elimination turns H_parity into the identity, the message part then defines the parity bits
+	for column := 0; column < m; column++ {
+		pivot := -1
+		for row := column; row < m; row++ {
+			if rows[row][k+column] == 1 {
+				pivot = row
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, ErrInvalidCode
+		}
+		rows[column], rows[pivot] = rows[pivot], rows[column]
+		for row := 0; row < m; row++ {
+			if row == column || rows[row][k+column] == 0 {
+				continue
+			}
+			for i := range rows[row] {
+				rows[row][i] ^= rows[column][i]
+			}
+		}
+	}
+
+	parityRows := make([][]int, m)
+	for i, row := range rows {
+		for j := 0; j < k; j++ {
+			if row[j] == 1 {
+				parityRows[i] = append(parityRows[i], j)
+			}
+		}
+	}
+
+	copied := make([][]int, m)
+	for i, check := range checks {
+		copied[i] = append([]int{}, check...)
+	}
+	return &LDPC{n: n, k: k, checks: copied, parityRows: parityRows}, nil
+}
+
+// N returns the length of a code word.
+func (l *LDPC) N() int {
+	return l.n
+}
+
+// K returns the length of a message.
+func (l *LDPC) K() int {
+	return l.k
+}
+
+// Encode returns the code word for the given message bits.
+func (l *LDPC) Encode(message []byte) ([]byte, error) {
+	if len(message) != l.k {
+		return nil, ErrInvalidLength
+	}
+	result := make([]byte, l.n)
+	for i, bit := range message {
+		result[i] = bit & 1
+	}
+	for i, row := range l.parityRows {
+		var parity byte
+		for _, j := range row {
+			parity ^= result[j]
+		}
+		result[l.k+i] = parity
+	}
+	return result, nil
+}
+
+// Valid indicates if the given bits are a code word.
+func (l *LDPC) Valid(codeword []byte) bool {
+	if len(codeword) != l.n {
+		return false
+	}
+	return l.satisfied(codeword)
+}
+
+func (l *LDPC) satisfied(codeword []byte) bool {
+	for _, check := range l.checks {
+		var sum byte
+		for _, bit := range check {
+			sum ^= codeword[bit] & 1
+		}
+		if sum != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// maxLLR limits the log likelihood ratios to keep the hyperbolic functions finite.
+const maxLLR = 30.0
+
+// Decode recovers the message bits from the received log likelihood ratios of the code word with belief
+// propagation (sum-product algorithm). A positive ratio stands for a 1. It returns the message and the number of
+// iterations that were needed, or ErrDecodeFailed if no code word was found within the given number of iterations.
+func (l *LDPC) Decode(llr []float64, maxIterations int) ([]byte, int, error) {
+	if len(llr) != l.n {
+		return nil, 0, ErrInvalidLength
+	}
+
+	// internally, the ratios are log(P(0)/P(1))
+	channel := make([]float64, l.n)
+	for i, r := range llr {
+		channel[i] = -r
+	}
+	// toCheck[c][j] is the message from the j-th bit of check c to the check, toBit[c][j] the message back
+	toCheck := make([][]float64, len(l.checks))
+	toBit := make([][]float64, len(l.checks))
+	for c, check := range l.checks {
+		toCheck[c] = make([]float64, len(check))
+		toBit[c] = make([]float64, len(check))
+		for j, bit := range check {
+			toCheck[c][j] = channel[bit]
+		}
+	}
+
+	codeword := make([]byte, l.n)
+	total := make([]float64, l.n)
+	for iteration := 0; iteration <= maxIterations; iteration++ {
+		copy(total, channel)
+		for c, check := range l.checks {
+			for j, bit := range check {
+				total[bit] += toBit[c][j]
+			}
+		}
+		for i, t := range total {
+			if t < 0 {
+				codeword[i] = 1
+			} else {
+				codeword[i] = 0
+			}
+		}
+		if l.satisfied(codeword) {
+			return codeword[:l.k], iteration, nil
+		}
+		if iteration == maxIterations {
+			break
+		}
+
+		for c, check := range l.checks {
+			for j, bit := range check {
+				toCheck[c][j] = total[bit] - toBit[c][j]
+			}
+			for j := range check {
+				product := 1.0
+				for i, q := range toCheck[c] {
+					if i != j {
+						product *= math.Tanh(clampLLR(q) / 2)
+					}
+				}
+				toBit[c][j] = clampLLR(2 * math.Atanh(math.Max(-0.999999, math.Min(0.999999, product))))
+			}
+		}
+	}
+	return nil, maxIterations, ErrDecodeFailed
+}
+
+func clampLLR(x float64) float64 {
+	return math.Max(-maxLLR, math.Min(maxLLR, x))
+}
@@ -0,0 +1,72 @@
+package fec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLDPC returns a random (174,91) code with three checks per message bit and a staircase parity part.
+func testLDPC(t *testing.T, rng *rand.Rand) *LDPC {
+	n, k := FT8CodeLength, FT8MessageLength
+	m := n - k
+	checks := make([][]int, m)
+	for bit := 0; bit < k; bit++ {
+		for _, c := range rng.Perm(m)[:3] {
+			checks[c] = append(checks[c], bit)
+		}
+	}
+	for i := 0; i < m; i++ {
+		checks[i] = append(checks[i], k+i)
+		if i > 0 {
+			checks[i] = append(checks[i], k+i-1)
+		}
+	}
+	result, err := NewLDPC(n, k, checks)
+	require.NoError(t, err)
+	return result
+}
+
+func TestLDPC(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	code := testLDPC(t, rng)
+
+	for _, errors := range []int{0, 3, 8} {
+		message := randomBits(rng, code.K())
+		codeword, err := code.Encode(message)
+		require.NoError(t, err)
+		require.True(t, code.Valid(codeword))
+		assert.Equal(t, message, codeword[:code.K()])
+
+		llr := toSoft(rng, codeword, 0)
+		for i := range llr {
+			llr[i] *= 2 + rng.NormFloat64()*0.5
+		}
+		for _, i := range rng.Perm(len(llr))[:errors] {
+			llr[i] = -llr[i] / 4
+		}
+
+		decoded, _, err := code.Decode(llr, 30)
+		require.NoError(t, err, "errors: %d", errors)
+		assert.Equal(t, message, decoded, "errors: %d", errors)
+	}
+}
+
+func TestLDPCFails(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	code := testLDPC(t, rng)
+	llr := make([]float64, code.N())
+	for i := range llr {
+		llr[i] = rng.NormFloat64()
+	}
+	_, iterations, err := code.Decode(llr, 10)
+	assert.Equal(t, ErrDecodeFailed, err)
+	assert.Equal(t, 10, iterations)
+}
+
+func TestNewLDPCRejectsSingularParity(t *testing.T) {
+	_, err := NewLDPC(4, 2, [][]int{{0, 2}, {1, 2}})
+	assert.Equal(t, ErrInvalidCode, err)
+}
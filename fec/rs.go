@@ -0,0 +1,291 @@
+package fec
+
+// galoisField implements the arithmetic of GF(2^m) with the given primitive polynomial.
+type galoisField struct {
+	size int // number of non-zero elements, 2^m-1
+	exp  []byte
+	log  []int
+}
+
+func newGaloisField(symbolSize int, polynomial int) (*galoisField, error) {
+	if symbolSize < 2 || symbolSize > 8 || polynomial>>symbolSize != 1 {
+		return nil, ErrInvalidCode
+	}
+	size := 1<<symbolSize - 1
+	result := &galoisField{
+		size: size,
+		exp:  make([]byte, 2*size),
+		log:  make([]int, size+1),
+	}
+	x := 1
+	for i := 0; i < size; i++ {
+		if i > 0 && x == 1 {
+			// the polynomial is not primitive
+			return nil, ErrInvalidCode
+		}
+		result.exp[i] = byte(x)
+		result.log[x] = i
+		x <<= 1
+		if x&(size+1) != 0 {
+			x ^= polynomial
+		}
+	}
+	for i := size; i < len(result.exp); i++ {
+		result.exp[i] = result.exp[i-size]
+	}
+	return result, nil
+}
+
+func (f *galoisField) mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return f.exp[f.log[a]+f.log[b]]
+}
+
+func (f *galoisField) div(a, b byte) byte {
+	if b == 0 {
+		panic("division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return f.exp[(f.log[a]+f.size-f.log[b])%f.size]
+}
+
+// alpha returns the power of the primitive element, negative powers are allowed.
+func (f *galoisField) alpha(power int) byte {
+	power %= f.size
+	if power < 0 {
+		power += f.size
+	}
+	return f.exp[power]
+}
+
+// ReedSolomon is a systematic Reed-Solomon code over GF(2^m). The symbols are bytes with values below 2^m. A block
+// holds the data symbols followed by the parity symbols, the first symbol is the coefficient of the highest degree.
+// Shortened blocks with less data symbols than BlockSize()-Parity() are supported.
+type ReedSolomon struct {
+	field     *galoisField
+	parity    int
+	firstRoot int
+	generator []byte
+}
+
+// NewReedSolomon returns a Reed-Solomon code with m bits per symbol, the given primitive polynomial of the field,
+// the power of the first consecutive root of the generator polynomial and the number of parity symbols.
+func NewReedSolomon(symbolSize int, polynomial int, firstRoot int, parity int) (*ReedSolomon, error) {
+	field, err := newGaloisField(symbolSize, polynomial)
+	if err != nil {
+		return nil, err
+	}
+	if parity < 1 || parity >= field.size {
+		return nil, ErrInvalidCode
+	}
+
+	// the generator polynomial is the product of (x - alpha^(firstRoot+i)), highest degree first
+	generator := []byte{1}
+	for i := 0; i < parity; i++ {
+		root := field.alpha(firstRoot + i)
+		next := make([]byte, len(generator)+1)
+		for j, c := range generator {
+			next[j] ^= c
+			next[j+1] ^= field.mul(c, root)
+		}
+		generator = next
+	}
+
+	return &ReedSolomon{
+		field:     field,
+		parity:    parity,
+		firstRoot: firstRoot,
+		generator: generator,
+	}, nil
+}
+
+// RS6312 returns the RS(63,12) code of JT65 over GF(64) with the primitive polynomial x^6+x+1 and the first root 3.
+// The symbol order of JT65 (reversed data and parity) has to be applied by the mode.
+func RS6312() *ReedSolomon {
+	result, err := NewReedSolomon(6, 0x43, 3, 51)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// BlockSize returns the maximum number of symbols of a block, data and parity.
+func (rs *ReedSolomon) BlockSize() int {
+	return rs.field.size
+}
+
+// Parity returns the number of parity symbols of a block.
+func (rs *ReedSolomon) Parity() int {
+	return rs.parity
+}
+
+// DataSize returns the maximum number of data symbols of a block.
+func (rs *ReedSolomon) DataSize() int {
+	return rs.field.size - rs.parity
+}
+
+func (rs *ReedSolomon) validSymbols(symbols []byte) bool {
+	for _, s := range symbols {
+		if int(s) > rs.field.size {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode returns the given data symbols followed by the parity symbols.
+func (rs *ReedSolomon) Encode(data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data) > rs.DataSize() {
+		return nil, ErrInvalidLength
+	}
+	if !rs.validSymbols(data) {
+		return nil, ErrInvalidSymbol
+	}
+	result := make([]byte, len(data)+rs.parity)
+	copy(result, data)
+	for i := range data {
+		coefficient := result[i]
+		if coefficient == 0 {
+			continue
+		}
+		for j := 1; j < len(rs.generator); j++ {
+			result[i+j] ^= rs.field.mul(rs.generator[j], coefficient)
+		}
+	}
+	copy(result, data)
+	return result, nil
+}
+
+// syndromes returns the values of the block at the roots of the generator polynomial.
+func (rs *ReedSolomon) syndromes(block []byte) ([]byte, bool) {
+	result := make([]byte, rs.parity)
+	valid := true
+	for i := range result {
+		x := rs.field.alpha(rs.firstRoot + i)
+		var y byte
+		for _, c := range block {
+			y = rs.field.mul(y, x) ^ c
+		}
+		result[i] = y
+		if y != 0 {
+			valid = false
+		}
+	}
+	return result, valid
+}
+
+// Valid indicates if the given block is a valid code word.
+func (rs *ReedSolomon) Valid(block []byte) bool {
+	if len(block) <= rs.parity || len(block) > rs.field.size || !rs.validSymbols(block) {
+		return false
+	}
+	_, valid := rs.syndromes(block)
+	return valid
+}
+
+// Decode corrects the errors in the given block of data and parity symbols in place. It returns the number of
+// corrected symbols. Up to Parity()/2 errors can be corrected.
+func (rs *ReedSolomon) Decode(block []byte) (int, error) {
+	if len(block) <= rs.parity || len(block) > rs.field.size {
+		return 0, ErrInvalidLength
+	}
+	if !rs.validSymbols(block) {
+		return 0, ErrInvalidSymbol
+	}
+	syndromes, valid := rs.syndromes(block)
+	if valid {
+		return 0, nil
+	}
+
+	locator := rs.errorLocator(syndromes)
+	errors := len(locator) - 1
+	if errors*2 > rs.parity {
+		return 0, ErrTooManyErrors
+	}
+
+	// Chien search: the roots of the locator are the inverse error locations alpha^-degree
+	degrees := make([]int, 0, errors)
+	for degree := 0; degree < len(block); degree++ {
+		if rs.evalLow(locator, rs.field.alpha(-degree)) == 0 {
+			degrees = append(degrees, degree)
+		}
+	}
+	if len(degrees) != errors {
+		return 0, ErrTooManyErrors
+	}
+
+	// Forney algorithm: the error evaluator is syndromes*locator mod x^parity
+	evaluator := make([]byte, rs.parity)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= rs.field.mul(locator[j], syndromes[i-j])
+		}
+	}
+	// the formal derivative of the locator contains only the odd coefficients
+	derivative := make([]byte, len(locator)-1)
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+	for _, degree := range degrees {
+		xInverse := rs.field.alpha(-degree)
+		numerator := rs.field.mul(rs.field.alpha(degree*(1-rs.firstRoot)), rs.evalLow(evaluator, xInverse))
+		denominator := rs.evalLow(derivative, xInverse)
+		if denominator == 0 {
+			return 0, ErrTooManyErrors
+		}
+		block[len(block)-1-degree] ^= rs.field.div(numerator, denominator)
+	}
+
+	if _, valid := rs.syndromes(block); !valid {
+		return 0, ErrTooManyErrors
+	}
+	return errors, nil
+}
+
+// errorLocator computes the error locator polynomial with the Berlekamp-Massey algorithm, lowest degree first.
+func (rs *ReedSolomon) errorLocator(syndromes []byte) []byte {
+	locator := make([]byte, rs.parity+1)
+	previous := make([]byte, rs.parity+1)
+	locator[0] = 1
+	previous[0] = 1
+	length := 0
+	shift := 1
+	lastDiscrepancy := byte(1)
+	for n := 0; n < rs.parity; n++ {
+		discrepancy := syndromes[n]
+		for i := 1; i <= length; i++ {
+			discrepancy ^= rs.field.mul(locator[i], syndromes[n-i])
+		}
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+		scale := rs.field.div(discrepancy, lastDiscrepancy)
+		old := append([]byte{}, locator...)
+		for i := 0; i+shift < len(locator); i++ {
+			locator[i+shift] ^= rs.field.mul(scale, previous[i])
+		}
+		if 2*length <= n {
+			length = n + 1 - length
+			previous = old
+			lastDiscrepancy = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+	}
+	return locator[:length+1]
+}
+
+// evalLow evaluates the given polynomial with the lowest degree first at x.
+func (rs *ReedSolomon) evalLow(p []byte, x byte) byte {
+	var y byte
+	for i := len(p) - 1; i >= 0; i-- {
+		y = rs.field.mul(y, x) ^ p[i]
+	}
+	return y
+}
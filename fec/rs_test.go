@@ -0,0 +1,79 @@
+package fec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomon(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gf256, err := NewReedSolomon(8, 0x11d, 0, 16)
+	require.NoError(t, err)
+	testCases := []struct {
+		desc   string
+		code   *ReedSolomon
+		length int
+		errors int
+	}{
+		{"RS(63,12) no errors", RS6312(), 12, 0},
+		{"RS(63,12) one error", RS6312(), 12, 1},
+		{"RS(63,12) max errors", RS6312(), 12, 25},
+		{"GF(256) shortened", gf256, 20, 8},
+		{"GF(256) full block", gf256, 239, 8},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			symbols := tC.code.BlockSize() + 1
+			data := make([]byte, tC.length)
+			for i := range data {
+				data[i] = byte(rng.Intn(symbols))
+			}
+			block, err := tC.code.Encode(data)
+			require.NoError(t, err)
+			require.Len(t, block, tC.length+tC.code.Parity())
+			require.True(t, tC.code.Valid(block))
+
+			for _, position := range rng.Perm(len(block))[:tC.errors] {
+				block[position] ^= byte(rng.Intn(symbols-1) + 1)
+			}
+			corrected, err := tC.code.Decode(block)
+			require.NoError(t, err)
+			assert.Equal(t, tC.errors, corrected)
+			assert.Equal(t, data, block[:tC.length])
+		})
+	}
+}
+
+func TestReedSolomonDetectsTooManyErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	code := RS6312()
+	failures := 0
+	for i := 0; i < 100; i++ {
+		data := make([]byte, 12)
+		for j := range data {
+			data[j] = byte(rng.Intn(64))
+		}
+		block, err := code.Encode(data)
+		require.NoError(t, err)
+		for _, position := range rng.Perm(len(block))[:30] {
+			block[position] ^= byte(rng.Intn(63) + 1)
+		}
+		if _, err := code.Decode(block); err != nil {
+			failures++
+		}
+	}
+	assert.True(t, failures > 90, "failures: %d", failures)
+}
+
+func TestReedSolomonInvalidInput(t *testing.T) {
+	code := RS6312()
+	_, err := code.Encode([]byte{64})
+	assert.Equal(t, ErrInvalidSymbol, err)
+	_, err = code.Encode(make([]byte, 13))
+	assert.Equal(t, ErrInvalidLength, err)
+	_, err = NewReedSolomon(6, 0x41, 0, 4)
+	assert.Equal(t, ErrInvalidCode, err, "not primitive")
+}
@@ -0,0 +1,98 @@
+/*
+Package ft8 implements the encoding of the FT8 and FT4 digital modes of WSJT-X for the standard QSO messages of
+package sequencer.
+
+Both modes share the source encoding: the message is packed into 77 bits, protected by a CRC-14 and encoded with the
+(174,91) LDPC code of package fec. FT4 scrambles the message bits before the CRC. The modes differ in the number of
+tones, the length of the symbols, the Costas arrays for the synchronization and the shaping of the GFSK signal.
+
+The encoding follows the description in "The FT4 and FT8 Communication Protocols" by K1JT, K9AN and G4WJS (QEX,
+July/August 2020) and the reference implementation of WSJT-X.
+*/
+package ft8
+
+import (
+	"github.com/ftl/digimodes/fec"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// crcPolynomial is the polynomial of the CRC-14 without the leading term.
+const crcPolynomial = 0x2757
+
+// crcBits is the number of bits of the CRC.
+const crcBits = 14
+
+// scrambler is XORed with the message bits of FT4, so that messages with many zeros do not produce long runs of the
+// same tone.
+var scrambler = []byte{0x4A, 0x5E, 0x89, 0xB4, 0xB0, 0x8A, 0x79, 0x55, 0xBE, 0x28}
+
+// Encode returns the tones of a transmission of the given message in this mode.
+func (m Mode) Encode(message sequencer.Message) ([]int, error) {
+	bits, err := Pack(message)
+	if err != nil {
+		return nil, err
+	}
+	return m.EncodeBits(bits)
+}
+
+// EncodeBits returns the tones of a transmission of the given 77 message bits in this mode, one bit per byte.
+func (m Mode) EncodeBits(bits []byte) ([]int, error) {
+	if len(bits) != MessageBits {
+		return nil, ErrInvalidMessageBits
+	}
+	message := make([]byte, MessageBits, fec.FT8MessageLength)
+	copy(message, bits)
+	if m.scramble {
+		for i := range message {
+			message[i] ^= (scrambler[i/8] >> (7 - i%8)) & 1
+		}
+	}
+	message = appendBits(message, uint64(crc14(message)), crcBits)
+
+	codeword, err := fec.FT8LDPC().Encode(message)
+	if err != nil {
+		return nil, err
+	}
+	return m.tones(codeword), nil
+}
+
+// crc14 returns the CRC-14 of the given message bits, one bit per byte. The message is extended with zeros to 82
+// bits like in WSJT-X.
+func crc14(bits []byte) uint16 {
+	var result uint16
+	for i := 0; i < fec.FT8MessageLength-crcBits; i++ {
+		var bit uint16
+		if i < len(bits) {
+			bit = uint16(bits[i])
+		}
+		feedback := (result>>(crcBits-1))&1 ^ bit
+		result = (result << 1) & (1<<crcBits - 1)
+		if feedback == 1 {
+			result ^= crcPolynomial
+		}
+	}
+	return result
+}
+
+// tones maps the bits of the given codeword to the data symbols and places them between the Costas arrays.
+func (m Mode) tones(codeword []byte) []int {
+	bitsPerSymbol := m.bitsPerSymbol()
+	blockSymbols := len(codeword) / bitsPerSymbol / (len(m.costas) - 1)
+
+	result := make([]int, 0, m.Symbols())
+	for i, costas := range m.costas {
+		result = append(result, costas...)
+		if i == len(m.costas)-1 {
+			break
+		}
+		for j := 0; j < blockSymbols; j++ {
+			value := 0
+			for _, bit := range codeword[:bitsPerSymbol] {
+				value = value<<1 | int(bit)
+			}
+			codeword = codeword[bitsPerSymbol:]
+			result = append(result, m.gray[value])
+		}
+	}
+	return result
+}
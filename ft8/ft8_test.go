@@ -0,0 +1,118 @@
+package ft8
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/fec"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// decodeTones returns the codeword of the given tones after checking the Costas arrays.
+func decodeTones(t *testing.T, mode Mode, tones []int) []byte {
+	t.Helper()
+	inverseGray := make([]int, len(mode.gray))
+	for value, tone := range mode.gray {
+		inverseGray[tone] = value
+	}
+	bitsPerSymbol := mode.bitsPerSymbol()
+
+	var codeword []byte
+	for i, costas := range mode.costas {
+		require.Equal(t, costas, tones[:len(costas)], "Costas array %d", i)
+		tones = tones[len(costas):]
+		if i == len(mode.costas)-1 {
+			break
+		}
+		for _, tone := range tones[:29] {
+			codeword = appendBits(codeword, uint64(inverseGray[tone]), bitsPerSymbol)
+		}
+		tones = tones[29:]
+	}
+	require.Empty(t, tones)
+	return codeword
+}
+
+func TestMode(t *testing.T) {
+	assert.Equal(t, 79, FT8.Symbols())
+	assert.Equal(t, 160*time.Millisecond, FT8.SymbolDuration())
+	assert.Equal(t, 6.25, FT8.ToneSpacing())
+	assert.Equal(t, 50.0, FT8.Bandwidth())
+	assert.Equal(t, 12640*time.Millisecond, FT8.TransmissionDuration())
+
+	assert.Equal(t, 103, FT4.Symbols())
+	assert.Equal(t, 48*time.Millisecond, FT4.SymbolDuration())
+	assert.InDelta(t, 20.833, FT4.ToneSpacing(), 0.001)
+	assert.InDelta(t, 83.333, FT4.Bandwidth(), 0.001)
+	assert.Equal(t, 5040*time.Millisecond, FT4.TransmissionDuration())
+}
+
+func TestCRC14(t *testing.T) {
+	message, err := Pack(sequencer.Message{Kind: sequencer.CQ, From: "K1ABC", Grid: "FN42"})
+	require.NoError(t, err)
+	crc := crc14(message)
+	assert.Less(t, crc, uint16(1<<crcBits))
+
+	// the remainder of the message extended to 82 bits followed by its CRC is zero
+	check := make([]byte, fec.FT8MessageLength-crcBits, fec.FT8MessageLength)
+	copy(check, message)
+	check = appendBits(check, uint64(crc), crcBits)
+	var remainder uint16
+	for _, bit := range check {
+		feedback := (remainder>>(crcBits-1))&1 ^ uint16(bit)
+		remainder = (remainder << 1) & (1<<crcBits - 1)
+		if feedback == 1 {
+			remainder ^= crcPolynomial
+		}
+	}
+	assert.Equal(t, uint16(0), remainder)
+
+	for i := range message {
+		message[i] ^= 1
+		assert.NotEqual(t, crc, crc14(message), "bit %d", i)
+		message[i] ^= 1
+	}
+}
+
+func TestEncode(t *testing.T) {
+	message, err := sequencer.ParseMessage("K1ABC W9XYZ EN37")
+	require.NoError(t, err)
+	bits, err := Pack(message)
+	require.NoError(t, err)
+
+	for _, mode := range []Mode{FT8, FT4} {
+		t.Run(mode.Name, func(t *testing.T) {
+			tones, err := mode.Encode(message)
+			require.NoError(t, err)
+			require.Len(t, tones, mode.Symbols())
+			for i, tone := range tones {
+				assert.True(t, tone >= 0 && tone < mode.Tones, "tone %d: %d", i, tone)
+			}
+
+			codeword := decodeTones(t, mode, tones)
+			require.Len(t, codeword, fec.FT8CodeLength)
+			assert.True(t, fec.FT8LDPC().Valid(codeword))
+
+			payload := codeword[:MessageBits]
+			crc := codeword[MessageBits:fec.FT8MessageLength]
+			assert.Equal(t, appendBits(nil, uint64(crc14(payload)), crcBits), crc)
+			if mode.scramble {
+				descrambled := make([]byte, MessageBits)
+				for i := range payload {
+					descrambled[i] = payload[i] ^ (scrambler[i/8]>>(7-i%8))&1
+				}
+				assert.NotEqual(t, bits, payload)
+				payload = descrambled
+			}
+			assert.Equal(t, bits, payload)
+		})
+	}
+}
+
+func TestEncodeBitsInvalidLength(t *testing.T) {
+	_, err := FT8.EncodeBits(make([]byte, MessageBits-1))
+	assert.Equal(t, ErrInvalidMessageBits, err)
+}
@@ -0,0 +1,180 @@
+package ft8
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// transmissionBufferSize is the number of transmissions that can be buffered between Transmit and Modulate.
+const transmissionBufferSize = 2
+
+var ErrWriteAborted = errors.New("ft8: write aborted")
+
+// item is an element of the pipeline between Transmit and Modulate.
+type item struct {
+	tones []int
+	token chan struct{}
+}
+
+// Modulator generates the continuous phase GFSK audio signal of FT8 or FT4 transmissions. The given frequency is the
+// frequency of the lowest tone, the other tones are above. Modulator implements the io.Writer interface, the written
+// text is a standard QSO message, see sequencer.ParseMessage. The modulator sends each transmission as soon as it is
+// written, it is up to the caller to start it in time, i.e. half a second after the start of the transmission cycle.
+type Modulator struct {
+	transmissions *stream.Stream[item]
+	writeLock     sync.Mutex
+
+	mode      Mode
+	frequency float64
+	on        bool
+	start     float64
+	tones     []int
+	token     chan struct{}
+}
+
+// NewModulator returns a new Modulator for the given mode with the lowest tone at the given audio frequency.
+func NewModulator(mode Mode, frequency float64) *Modulator {
+	return &Modulator{
+		transmissions: stream.New[item](transmissionBufferSize),
+		mode:          mode,
+		frequency:     frequency,
+	}
+}
+
+// Bandwidth returns the occupied bandwidth of the signal in Hz.
+func (m *Modulator) Bandwidth() float64 {
+	return m.mode.Bandwidth()
+}
+
+// SymbolRate returns the symbol rate of the signal in baud.
+func (m *Modulator) SymbolRate() float64 {
+	return 1 / m.mode.symbolTime()
+}
+
+// Pending returns the number of transmissions that are queued, but not started yet, and the duration to send them.
+func (m *Modulator) Pending() (transmissions int, duration time.Duration) {
+	transmissions = m.transmissions.Len()
+	return transmissions, time.Duration(transmissions) * m.mode.TransmissionDuration()
+}
+
+func (m *Modulator) Close() error {
+	m.transmissions.Close()
+	return nil
+}
+
+func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
+	go func() {
+		select {
+		case <-done:
+			m.Close()
+		case <-m.transmissions.Done():
+		}
+	}()
+}
+
+// Write encodes the given standard QSO message and sends it. It returns when the message is sent completely.
+func (m *Modulator) Write(bytes []byte) (int, error) {
+	tones, err := m.encode(string(bytes))
+	if err != nil {
+		return 0, err
+	}
+	err = m.Transmit(tones)
+	if err != nil {
+		return 0, err
+	}
+	return len(bytes), nil
+}
+
+// Queue encodes the given standard QSO message and queues it like Write, but it does not block and does not wait
+// until the message is sent. The returned channel is closed when the message is sent completely. Queue implements
+// audio.Queuer, e.g. to render a transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	tones, err := m.encode(text)
+	if err != nil {
+		return nil, err
+	}
+
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	token := make(chan struct{})
+	err = m.transmissions.Push(item{tones: tones, token: token})
+	if err != nil {
+		return nil, ErrWriteAborted
+	}
+	return token, nil
+}
+
+func (m *Modulator) encode(text string) ([]int, error) {
+	message, err := sequencer.ParseMessage(text)
+	if err != nil {
+		return nil, err
+	}
+	return m.mode.Encode(message)
+}
+
+// Transmit sends the given tones, see Mode.Encode. It returns when the transmission is sent completely.
+func (m *Modulator) Transmit(tones []int) error {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+
+	token := make(chan struct{})
+	err := m.transmissions.Send(context.Background(), item{tones: tones, token: token})
+	if err != nil {
+		return ErrWriteAborted
+	}
+	select {
+	case <-token:
+		return nil
+	case <-m.transmissions.Done():
+		return ErrWriteAborted
+	}
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.transmissions.Done())
+}
+
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if m.on && t >= m.end() {
+		m.on = false
+		close(m.token)
+	}
+	if !m.on {
+		m.nextTransmission(t)
+	}
+	if !m.on {
+		return 0, m.frequency, p
+	}
+
+	symbol := (t-m.start)/m.mode.symbolTime() - float64(m.mode.rampSymbols)
+	frequency = m.frequency + m.mode.ToneSpacing()*m.mode.frequencyOffset(m.tones, symbol)
+	amplitude = m.mode.envelope(t-m.start, m.end()-t)
+	return amplitude, frequency, p
+}
+
+func (m *Modulator) end() float64 {
+	return m.start + float64(len(m.tones)+2*m.mode.rampSymbols)*m.mode.symbolTime()
+}
+
+func (m *Modulator) nextTransmission(t float64) {
+	if m.transmissions.Closed() {
+		return
+	}
+	next, ok := m.transmissions.TryReceive()
+	if !ok {
+		return
+	}
+	m.on = true
+	m.start = t
+	m.tones = next.tones
+	m.token = next.token
+}
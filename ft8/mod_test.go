@@ -0,0 +1,116 @@
+package ft8
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+func TestModulator(t *testing.T) {
+	for _, mode := range []Mode{FT8, FT4} {
+		t.Run(mode.Name, func(t *testing.T) {
+			message, err := sequencer.ParseMessage("CQ K1ABC FN42")
+			require.NoError(t, err)
+			tones, err := mode.Encode(message)
+			require.NoError(t, err)
+			m := NewModulator(mode, 1000)
+			defer m.Close()
+
+			done, err := m.Queue("CQ K1ABC FN42")
+			require.NoError(t, err)
+
+			var a, f, p float64
+			start := 1.0
+			a, _, _ = m.Modulate(start, a, f, p)
+			assert.Equal(t, 0.0, a, "ramp up")
+			lead := start + float64(mode.rampSymbols)*mode.symbolTime()
+			for i, tone := range tones {
+				now := lead + (float64(i)+0.5)*mode.symbolTime()
+				a, f, p = m.Modulate(now, a, f, p)
+				assert.Equal(t, 1.0, a, "symbol %d", i)
+				assert.Equal(t, tone, int(math.Round((f-1000)/mode.ToneSpacing())), "symbol %d", i)
+			}
+
+			end := start + mode.TransmissionDuration().Seconds()
+			a, _, _ = m.Modulate(end-mode.ramp*mode.symbolTime()/2, a, f, p)
+			assert.InDelta(t, 0.5, a, 1e-6, "ramp down")
+			a, _, _ = m.Modulate(end, a, f, p)
+			assert.Equal(t, 0.0, a)
+			select {
+			case <-done:
+			default:
+				assert.Fail(t, "transmission not done")
+			}
+		})
+	}
+}
+
+func TestModulatorIsContinuous(t *testing.T) {
+	m := NewModulator(FT8, 1000)
+	defer m.Close()
+
+	observer := &stepObserver{Modulator: m}
+	err := audio.NewRenderer(observer, 12000).RenderText("K1ABC W9XYZ RR73", time.Second, 20*time.Second, func([]float64) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the Gaussian pulses spread the changes of the frequency over a symbol, switching the tones would change the
+	// frequency by up to 43.75Hz from one sample to the next
+	assert.True(t, observer.maxStep > 0)
+	assert.Less(t, observer.maxStep, 0.5)
+}
+
+// stepObserver records the largest change of the frequency between two samples of the wrapped modulator while the
+// signal is on.
+type stepObserver struct {
+	*Modulator
+	maxStep float64
+}
+
+func (o *stepObserver) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	amplitude, frequency, phase = o.Modulator.Modulate(t, a, f, p)
+	if a > 0 && amplitude > 0 {
+		o.maxStep = math.Max(o.maxStep, math.Abs(frequency-f))
+	}
+	return amplitude, frequency, phase
+}
+
+func TestModulatorWrite(t *testing.T) {
+	m := NewModulator(FT4, 1000)
+	defer m.Close()
+
+	_, err := m.Write([]byte("K1ABC"))
+	assert.Equal(t, sequencer.ErrInvalidMessage, err)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := m.Write([]byte("K1ABC W9XYZ 73"))
+		written <- err
+	}()
+	for n := 0; len(written) == 0; n++ {
+		m.Modulate(float64(n)/1000, 0, 0, 0)
+	}
+	assert.NoError(t, <-written)
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(FT8, 1000)
+	defer m.Close()
+	_, err := m.Queue("CQ K1ABC FN42")
+	require.NoError(t, err)
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/12000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
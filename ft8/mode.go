@@ -0,0 +1,152 @@
+package ft8
+
+import (
+	"math"
+	"time"
+
+	"github.com/ftl/digimodes/fec"
+)
+
+// Mode is a variant of the FT8 family. All variants use the same message packing and the same (174,91) LDPC code,
+// they differ in the number of tones, the length of the symbols, the synchronization arrays and the shaping of the
+// signal. The tone spacing is the inverse of the symbol length.
+type Mode struct {
+	Name string
+	// Tones is the number of tones.
+	Tones int
+	// SymbolLength is the length of one symbol in samples at 12000 Hz.
+	SymbolLength int
+	// BT is the bandwidth-time product of the Gaussian filter that smoothes the frequency changes.
+	BT float64
+	// Period is the period of the transmission cycles.
+	Period time.Duration
+
+	// costas contains the Costas arrays for the synchronization, the data symbols are sent between them.
+	costas [][]int
+	// gray maps the value of the bits of a data symbol to its tone.
+	gray []int
+	// scramble tells if the message bits are scrambled before the CRC is added.
+	scramble bool
+	// rampSymbols is the number of additional symbols before and after the transmission that carry the ramps.
+	rampSymbols int
+	// ramp is the length of the amplitude ramps at the start and the end of a transmission in symbols.
+	ramp float64
+}
+
+// The modes of the FT8 family.
+var (
+	// FT8 uses 8 tones with 6.25Hz spacing and 15 second cycles.
+	FT8 = Mode{
+		Name:         "FT8",
+		Tones:        8,
+		SymbolLength: 1920,
+		BT:           2,
+		Period:       15 * time.Second,
+		costas:       [][]int{ft8Costas, ft8Costas, ft8Costas},
+		gray:         []int{0, 1, 3, 2, 5, 6, 4, 7},
+		ramp:         1.0 / 8,
+	}
+	// FT4 uses 4 tones with 20.8Hz spacing and 7.5 second cycles, e.g. for contests.
+	FT4 = Mode{
+		Name:         "FT4",
+		Tones:        4,
+		SymbolLength: 576,
+		BT:           1,
+		Period:       7500 * time.Millisecond,
+		costas:       [][]int{{0, 1, 3, 2}, {1, 0, 2, 3}, {2, 3, 1, 0}, {3, 2, 0, 1}},
+		gray:         []int{0, 1, 3, 2},
+		scramble:     true,
+		rampSymbols:  1,
+		ramp:         1,
+	}
+)
+
+var ft8Costas = []int{3, 1, 4, 0, 6, 5, 2}
+
+// symbolTime returns the duration of one symbol in seconds.
+func (m Mode) symbolTime() float64 {
+	return float64(m.SymbolLength) / 12000
+}
+
+// SymbolDuration returns the duration of one symbol.
+func (m Mode) SymbolDuration() time.Duration {
+	return time.Duration(m.SymbolLength) * time.Second / 12000
+}
+
+// ToneSpacing returns the distance between two adjacent tones in Hz.
+func (m Mode) ToneSpacing() float64 {
+	return 12000 / float64(m.SymbolLength)
+}
+
+// Bandwidth returns the bandwidth of the signal in Hz.
+func (m Mode) Bandwidth() float64 {
+	return float64(m.Tones) * m.ToneSpacing()
+}
+
+// Symbols returns the number of symbols of a transmission.
+func (m Mode) Symbols() int {
+	result := fec.FT8CodeLength / m.bitsPerSymbol()
+	for _, costas := range m.costas {
+		result += len(costas)
+	}
+	return result
+}
+
+// TransmissionDuration returns the duration of a transmission including the ramps.
+func (m Mode) TransmissionDuration() time.Duration {
+	return time.Duration(m.Symbols()+2*m.rampSymbols) * m.SymbolDuration()
+}
+
+// bitsPerSymbol returns the number of codeword bits of a data symbol.
+func (m Mode) bitsPerSymbol() int {
+	result := 0
+	for 1<<result < m.Tones {
+		result++
+	}
+	return result
+}
+
+// gaussian is the constant of the Gaussian frequency pulse, pi*sqrt(2/ln 2).
+var gaussian = math.Pi * math.Sqrt(2/math.Ln2)
+
+// pulse returns the frequency pulse of one symbol at the given time in symbols relative to the center of the symbol.
+func (m Mode) pulse(t float64) float64 {
+	return 0.5 * (math.Erf(gaussian*m.BT*(t+0.5)) - math.Erf(gaussian*m.BT*(t-0.5)))
+}
+
+// frequencyOffset returns the frequency of the signal of the given tones relative to the lowest tone in units of
+// the tone spacing at the given time in symbols. The pulse of each tone spreads over three symbols, the first and
+// the last tone are extended by one symbol, so the frequency is smooth at the start and the end.
+func (m Mode) frequencyOffset(tones []int, t float64) float64 {
+	result := 0.0
+	first := int(math.Floor(t)) - 1
+	for j := first; j <= first+2; j++ {
+		var tone int
+		switch {
+		case j < -1 || j > len(tones):
+			continue
+		case j < 0:
+			tone = tones[0]
+		case j >= len(tones):
+			tone = tones[len(tones)-1]
+		default:
+			tone = tones[j]
+		}
+		result += float64(tone) * m.pulse(t-float64(j)-0.5)
+	}
+	return result
+}
+
+// envelope returns the amplitude of the signal with the given times in seconds since the start and until the end of
+// the transmission. The amplitude is ramped up and down with a raised cosine.
+func (m Mode) envelope(sinceStart, untilEnd float64) float64 {
+	ramp := m.ramp * m.symbolTime()
+	switch {
+	case sinceStart < ramp:
+		return (1 - math.Cos(math.Pi*sinceStart/ramp)) / 2
+	case untilEnd < ramp:
+		return (1 - math.Cos(math.Pi*untilEnd/ramp)) / 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,182 @@
+package ft8
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// MessageBits is the number of bits of a packed message.
+const MessageBits = 77
+
+// The limits of the value ranges in the packed message of WSJT-X.
+const (
+	// tokens is the number of special tokens (DE, QRZ, CQ and the directed CQs) before the callsigns.
+	tokens = 2063592
+	// hashes is the number of hashed callsigns before the standard callsigns.
+	hashes = 4194304
+	// maxGrid4 is the number of four character grid squares, the special exchanges and reports follow.
+	maxGrid4 = 32400
+)
+
+// The exchanges that replace the grid square.
+const (
+	noGrid = maxGrid4 + 1 + iota
+	rrr
+	rr73
+	seventyThree
+)
+
+var (
+	ErrUnsupportedCallsign = errors.New("ft8: only standard callsigns are supported")
+	ErrInvalidModifier     = errors.New("ft8: the CQ modifier must be three digits or up to four letters")
+	ErrInvalidReport       = errors.New("ft8: the report must be within -50dB and +49dB")
+	ErrInvalidMessageBits  = errors.New("ft8: a packed message has 77 bits")
+)
+
+// The alphabets of the characters of a standard callsign.
+const (
+	alphanumericOrSpace = " 0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	alphanumeric        = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numeric             = "0123456789"
+	letterOrSpace       = " ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// Pack packs the given standard QSO message into the 77 bits of a standard message of WSJT-X (i3=1). Each byte of
+// the result holds one bit, the most significant bit of each field comes first. Compound and nonstandard callsigns
+// and the /R and /P suffixes are not supported.
+func Pack(message sequencer.Message) ([]byte, error) {
+	var to uint32
+	var err error
+	if message.Kind == sequencer.CQ {
+		to, err = packCQ(message.Modifier)
+	} else {
+		to, err = packCallsign(message.To)
+	}
+	if err != nil {
+		return nil, err
+	}
+	from, err := packCallsign(message.From)
+	if err != nil {
+		return nil, err
+	}
+	roger, exchange, err := packExchange(message)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, MessageBits)
+	result = appendBits(result, uint64(to), 28)
+	result = appendBits(result, 0, 1)
+	result = appendBits(result, uint64(from), 28)
+	result = appendBits(result, 0, 1)
+	result = appendBits(result, uint64(roger), 1)
+	result = appendBits(result, uint64(exchange), 15)
+	result = appendBits(result, 1, 3)
+	return result, nil
+}
+
+// packCallsign returns the 28 bit value of the given standard callsign.
+func packCallsign(callsign string) (uint32, error) {
+	switch callsign {
+	case "DE":
+		return 0, nil
+	case "QRZ":
+		return 1, nil
+	case "CQ":
+		return 2, nil
+	}
+
+	// the call area is the last digit, at the second or third position
+	area := strings.LastIndexAny(callsign, numeric)
+	if area < 1 || area > 2 {
+		return 0, ErrUnsupportedCallsign
+	}
+	prefix := callsign[:area]
+	if strings.Trim(prefix, numeric) == "" {
+		return 0, ErrUnsupportedCallsign
+	}
+	if area == 1 {
+		callsign = " " + callsign
+	}
+	if len(callsign) > 6 {
+		return 0, ErrUnsupportedCallsign
+	}
+	callsign += strings.Repeat(" ", 6-len(callsign))
+
+	var result uint32
+	for i, alphabet := range []string{alphanumericOrSpace, alphanumeric, numeric, letterOrSpace, letterOrSpace, letterOrSpace} {
+		value := strings.IndexByte(alphabet, callsign[i])
+		if value == -1 {
+			return 0, ErrUnsupportedCallsign
+		}
+		result = result*uint32(len(alphabet)) + uint32(value)
+	}
+	return tokens + hashes + result, nil
+}
+
+// packCQ returns the 28 bit value of a CQ with the given modifier, e.g. "DX" or "EU" or a frequency of three digits.
+func packCQ(modifier string) (uint32, error) {
+	switch {
+	case modifier == "":
+		return 2, nil
+	case len(modifier) == 3 && strings.Trim(modifier, numeric) == "":
+		var result uint32
+		for i := range modifier {
+			result = 10*result + uint32(modifier[i]-'0')
+		}
+		return 3 + result, nil
+	case len(modifier) <= 4 && strings.Trim(modifier, letterOrSpace[1:]) == "":
+		// the letters are right aligned
+		var result uint32
+		for i := range modifier {
+			result = 27*result + uint32(modifier[i]-'A'+1)
+		}
+		return 3 + 1000 + result, nil
+	default:
+		return 0, ErrInvalidModifier
+	}
+}
+
+// packExchange returns the roger bit and the 15 bit value of the grid square, the report or the final exchange of
+// the given message.
+func packExchange(message sequencer.Message) (roger uint8, exchange uint32, err error) {
+	switch message.Kind {
+	case sequencer.CQ, sequencer.Grid:
+		if message.Grid == "" {
+			return 0, noGrid, nil
+		}
+		grid := message.Grid
+		return 0, ((uint32(grid[0]-'A')*18+uint32(grid[1]-'A'))*10+uint32(grid[2]-'0'))*10 + uint32(grid[3]-'0'), nil
+	case sequencer.RRR:
+		return 0, rrr, nil
+	case sequencer.RR73:
+		return 0, rr73, nil
+	case sequencer.SeventyThree:
+		return 0, seventyThree, nil
+	case sequencer.RogerReport:
+		roger = 1
+	case sequencer.Report:
+	default:
+		return 0, 0, sequencer.ErrInvalidMessage
+	}
+
+	report := message.Report
+	switch {
+	case report < -50 || report > 49:
+		return 0, 0, ErrInvalidReport
+	case report < -30:
+		// the reports below -30dB use the values above +49dB
+		report += 101
+	}
+	return roger, uint32(maxGrid4 + 35 + report), nil
+}
+
+// appendBits appends the given number of bits of the given value, the most significant bit first.
+func appendBits(bits []byte, value uint64, count int) []byte {
+	for i := count - 1; i >= 0; i-- {
+		bits = append(bits, byte(value>>i)&1)
+	}
+	return bits
+}
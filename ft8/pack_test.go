@@ -0,0 +1,132 @@
+package ft8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/sequencer"
+)
+
+func TestPackCallsign(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint32
+		invalid  bool
+	}{
+		{value: "DE", expected: 0},
+		{value: "QRZ", expected: 1},
+		{value: "CQ", expected: 2},
+		// the example of the FT4/FT8 protocol paper
+		{value: "K1ABC", expected: 10214965},
+		{value: "W9XYZ", expected: tokens + hashes + ((((32*10+9)*27+24)*27+25)*27 + 26)},
+		{value: "DL1ABC", expected: tokens + hashes + (((((14*36+21)*10+1)*27+1)*27+2)*27 + 3)},
+		{value: "9A1A", expected: tokens + hashes + (((((10*36+10)*10+1)*27+1)*27+0)*27 + 0)},
+		{value: "", invalid: true},
+		{value: "ABC", invalid: true},
+		{value: "123A", invalid: true},
+		{value: "1A", invalid: true},
+		{value: "ABC1A", invalid: true},
+		{value: "K1ABCD", invalid: true},
+		{value: "DL1ABC/P", invalid: true},
+		{value: "PJ4/K1ABC", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			actual, err := packCallsign(tC.value)
+			if tC.invalid {
+				assert.Equal(t, ErrUnsupportedCallsign, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestPackCQ(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint32
+		invalid  bool
+	}{
+		{value: "", expected: 2},
+		{value: "000", expected: 3},
+		{value: "290", expected: 293},
+		{value: "A", expected: 1004},
+		{value: "DX", expected: 1003 + 4*27 + 24},
+		{value: "TEST", expected: 1003 + ((20*27+5)*27+19)*27 + 20},
+		{value: "12", invalid: true},
+		{value: "1234", invalid: true},
+		{value: "CONTEST", invalid: true},
+		{value: "D1", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			actual, err := packCQ(tC.value)
+			if tC.invalid {
+				assert.Equal(t, ErrInvalidModifier, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+// fields splits the given packed message into its fields.
+func fields(bits []byte) []uint64 {
+	var result []uint64
+	for _, size := range []int{28, 1, 28, 1, 1, 15, 3} {
+		var value uint64
+		for _, bit := range bits[:size] {
+			value = value<<1 | uint64(bit)
+		}
+		result = append(result, value)
+		bits = bits[size:]
+	}
+	return result
+}
+
+func TestPack(t *testing.T) {
+	k1abc := uint64(10214965)
+	w9xyz := uint64(tokens + hashes + ((((32*10+9)*27+24)*27+25)*27 + 26))
+	testCases := []struct {
+		value          string
+		to, from       uint64
+		roger          uint64
+		exchange       uint64
+		invalidMessage error
+	}{
+		{value: "CQ K1ABC FN42", to: 2, from: k1abc, exchange: (5*18+13)*100 + 42},
+		{value: "CQ K1ABC", to: 2, from: k1abc, exchange: maxGrid4 + 1},
+		{value: "CQ DX K1ABC FN42", to: 1003 + 4*27 + 24, from: k1abc, exchange: (5*18+13)*100 + 42},
+		{value: "K1ABC W9XYZ EN37", to: k1abc, from: w9xyz, exchange: (4*18+13)*100 + 37},
+		{value: "W9XYZ K1ABC -11", to: w9xyz, from: k1abc, exchange: maxGrid4 + 35 - 11},
+		{value: "K1ABC W9XYZ R-09", to: k1abc, from: w9xyz, roger: 1, exchange: maxGrid4 + 35 - 9},
+		{value: "K1ABC W9XYZ R+49", to: k1abc, from: w9xyz, roger: 1, exchange: maxGrid4 + 35 + 49},
+		{value: "K1ABC W9XYZ -50", to: k1abc, from: w9xyz, exchange: maxGrid4 + 35 + 51},
+		{value: "W9XYZ K1ABC RRR", to: w9xyz, from: k1abc, exchange: maxGrid4 + 2},
+		{value: "W9XYZ K1ABC RR73", to: w9xyz, from: k1abc, exchange: maxGrid4 + 3},
+		{value: "K1ABC W9XYZ 73", to: k1abc, from: w9xyz, exchange: maxGrid4 + 4},
+		{value: "K1ABC W9XYZ -51", invalidMessage: ErrInvalidReport},
+		{value: "CQ CONTEST K1ABC", invalidMessage: ErrInvalidModifier},
+		{value: "K1ABC/P W9XYZ 73", invalidMessage: ErrUnsupportedCallsign},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			message, err := sequencer.ParseMessage(tC.value)
+			require.NoError(t, err)
+
+			actual, err := Pack(message)
+			if tC.invalidMessage != nil {
+				assert.Equal(t, tC.invalidMessage, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, actual, MessageBits)
+			assert.Equal(t, []uint64{tC.to, 0, tC.from, 0, tC.roger, tC.exchange, 1}, fields(actual))
+		})
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Package httpapi provides an optional embedded HTTP server to monitor and control a station remotely.
+
+The server exposes the following endpoints, all responses are JSON:
+
+	GET  /status   the current mode, transmit state, queue and scheduler status
+	GET  /queue    the texts that are queued for transmission
+	POST /queue    queue the text in the request body (text/plain or {"text": "..."})
+	POST /abort    abort the current transmission and clear the queue
+	GET  /decodes  the recent decodes, optionally filtered with ?since=<RFC3339 time>
+*/
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+)
+
+// maxTextLength is the maximum length of a text that can be queued through the API.
+const maxTextLength = 4096
+
+// Station is the interface to the station that is managed by the server.
+type Station interface {
+	// Status returns the current status of the station.
+	Status() Status
+	// Enqueue queues the given text for transmission.
+	Enqueue(text string) error
+	// Abort aborts the current transmission and clears the queue.
+	Abort() error
+}
+
+// Status of the station.
+type Status struct {
+	Mode         string           `json:"mode"`
+	Transmitting bool             `json:"transmitting"`
+	Queue        []string         `json:"queue"`
+	Scheduler    *SchedulerStatus `json:"scheduler,omitempty"`
+}
+
+// SchedulerStatus describes the state of a transmit scheduler.
+type SchedulerStatus struct {
+	Active           bool      `json:"active"`
+	NextTransmission time.Time `json:"next_transmission"`
+	Description      string    `json:"description,omitempty"`
+}
+
+// Decode is the JSON representation of a digimodes.DecodeRecord.
+type Decode struct {
+	Time           time.Time `json:"time"`
+	Mode           string    `json:"mode"`
+	AudioFrequency float64   `json:"audio_frequency"`
+	RFFrequency    float64   `json:"rf_frequency,omitempty"`
+	Text           string    `json:"text"`
+	SNR            float64   `json:"snr"`
+}
+
+// DecodeLog keeps the most recent decode records.
+type DecodeLog struct {
+	mu      sync.RWMutex
+	size    int
+	records []digimodes.DecodeRecord
+}
+
+// NewDecodeLog returns a new DecodeLog that keeps up to size records.
+func NewDecodeLog(size int) *DecodeLog {
+	return &DecodeLog{
+		size:    size,
+		records: make([]digimodes.DecodeRecord, 0, size),
+	}
+}
+
+// Add adds the given record to the log. If the log is full, the oldest record is discarded.
+func (l *DecodeLog) Add(record digimodes.DecodeRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) == l.size {
+		copy(l.records, l.records[1:])
+		l.records = l.records[:len(l.records)-1]
+	}
+	l.records = append(l.records, record)
+}
+
+// Collect adds all records from the given channel until the channel is closed or the context is done.
+func (l *DecodeLog) Collect(ctx context.Context, records <-chan digimodes.DecodeRecord) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case record, ok := <-records:
+			if !ok {
+				return
+			}
+			l.Add(record)
+		}
+	}
+}
+
+// Since returns all records with a time after the given time, in chronological order of insertion.
+func (l *DecodeLog) Since(since time.Time) []digimodes.DecodeRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make([]digimodes.DecodeRecord, 0, len(l.records))
+	for _, record := range l.records {
+		if record.Time.After(since) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// Server is the HTTP server that provides the API.
+type Server struct {
+	station Station
+	decodes *DecodeLog
+	mux     *http.ServeMux
+}
+
+// NewServer returns a new Server for the given station and decode log. The decode log may be nil.
+func NewServer(station Station, decodes *DecodeLog) *Server {
+	result := &Server{
+		station: station,
+		decodes: decodes,
+		mux:     http.NewServeMux(),
+	}
+	result.mux.HandleFunc("/status", result.handleStatus)
+	result.mux.HandleFunc("/queue", result.handleQueue)
+	result.mux.HandleFunc("/abort", result.handleAbort)
+	result.mux.HandleFunc("/decodes", result.handleDecodes)
+	return result
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on the given address until the context is done.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the API on the given listener until the context is done.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	status := s.station.Status()
+	if status.Queue == nil {
+		status.Queue = []string{}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		queue := s.station.Status().Queue
+		if queue == nil {
+			queue = []string{}
+		}
+		writeJSON(w, http.StatusOK, queue)
+		return
+	}
+
+	text, err := readText(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err = s.station.Enqueue(text)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"queued": text})
+}
+
+func (s *Server) handleAbort(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	err := s.station.Abort()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"aborted": true})
+}
+
+func (s *Server) handleDecodes(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	result := []Decode{}
+	if s.decodes == nil {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	for _, record := range s.decodes.Since(since) {
+		result = append(result, Decode{
+			Time:           record.Time,
+			Mode:           record.Mode,
+			AudioFrequency: record.AudioFrequency,
+			RFFrequency:    record.RFFrequency,
+			Text:           record.Text,
+			SNR:            record.SNR,
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func readText(r *http.Request) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTextLength+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > maxTextLength {
+		return "", errors.New("text too long")
+	}
+
+	text := string(body)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var request struct {
+			Text string `json:"text"`
+		}
+		err := json.Unmarshal(body, &request)
+		if err != nil {
+			return "", err
+		}
+		text = request.Text
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("no text given")
+	}
+	return text, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
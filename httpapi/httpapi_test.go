@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+type testStation struct {
+	queue   []string
+	aborted bool
+}
+
+func (s *testStation) Status() Status {
+	return Status{Mode: "cw", Transmitting: len(s.queue) > 0, Queue: s.queue}
+}
+
+func (s *testStation) Enqueue(text string) error {
+	if text == "busy" {
+		return errors.New("busy")
+	}
+	s.queue = append(s.queue, text)
+	return nil
+}
+
+func (s *testStation) Abort() error {
+	s.queue = nil
+	s.aborted = true
+	return nil
+}
+
+func TestStatus(t *testing.T) {
+	station := &testStation{queue: []string{"cq"}}
+	server := NewServer(station, nil)
+
+	response := request(server, http.MethodGet, "/status", "", "")
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	var status Status
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &status))
+	assert.Equal(t, Status{Mode: "cw", Transmitting: true, Queue: []string{"cq"}}, status)
+
+	response = request(server, http.MethodPost, "/status", "", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
+
+func TestQueueAndAbort(t *testing.T) {
+	station := &testStation{}
+	server := NewServer(station, nil)
+
+	response := request(server, http.MethodPost, "/queue", "text/plain", "cq de dl0abc")
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	response = request(server, http.MethodPost, "/queue", "application/json", `{"text":"test"}`)
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	response = request(server, http.MethodPost, "/queue", "text/plain", " ")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response = request(server, http.MethodPost, "/queue", "text/plain", "busy")
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	response = request(server, http.MethodGet, "/queue", "", "")
+	assert.JSONEq(t, `["cq de dl0abc","test"]`, response.Body.String())
+
+	response = request(server, http.MethodPost, "/abort", "", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, station.aborted)
+	response = request(server, http.MethodGet, "/queue", "", "")
+	assert.JSONEq(t, `[]`, response.Body.String())
+}
+
+func TestDecodes(t *testing.T) {
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	log := NewDecodeLog(2)
+	log.Add(digimodes.DecodeRecord{Time: start, Mode: "psk31", Text: "one"})
+	log.Add(digimodes.DecodeRecord{Time: start.Add(time.Minute), Mode: "psk31", Text: "two"})
+	log.Add(digimodes.DecodeRecord{Time: start.Add(2 * time.Minute), Mode: "psk31", Text: "three"})
+	server := NewServer(&testStation{}, log)
+
+	response := request(server, http.MethodGet, "/decodes", "", "")
+	var decodes []Decode
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &decodes))
+	require.Len(t, decodes, 2)
+	assert.Equal(t, "two", decodes[0].Text)
+	assert.Equal(t, "three", decodes[1].Text)
+
+	response = request(server, http.MethodGet, "/decodes?since=2020-05-01T12:01:00Z", "", "")
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &decodes))
+	require.Len(t, decodes, 1)
+	assert.Equal(t, "three", decodes[0].Text)
+
+	response = request(server, http.MethodGet, "/decodes?since=yesterday", "", "")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func request(handler http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
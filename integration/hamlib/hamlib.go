@@ -0,0 +1,274 @@
+/*
+Package hamlib implements a small client for the network protocol of rigctld, the rig control daemon of Hamlib.
+
+The methods SetPTT and SetFrequency have the signatures of the callbacks of this library, e.g. SetPTT can be
+passed as the activateTransmitter callback of wspr.Send. Since the callbacks cannot return an error, the client
+keeps the first error, which is available through Err, and passes every error to the optional Failed function of
+its configuration.
+*/
+package hamlib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/bandplan"
+)
+
+// DefaultAddress is the default address of rigctld.
+const DefaultAddress = "localhost:4532"
+
+// DefaultTimeout is the default timeout of a command.
+const DefaultTimeout = 2 * time.Second
+
+// ErrUnexpectedReply is returned when rigctld replies something that the client does not understand.
+var ErrUnexpectedReply = errors.New("hamlib: unexpected reply")
+
+// Error is a negative return code reported by rigctld.
+type Error int
+
+func (e Error) Error() string {
+	return fmt.Sprintf("hamlib: rigctld returned error %d", int(e))
+}
+
+// Config of a Client.
+type Config struct {
+	// Address of rigctld, host:port. Empty means DefaultAddress.
+	Address string
+	// Timeout of a command, including the connection setup. 0 means DefaultTimeout.
+	Timeout time.Duration
+	// Failed is called with every error of SetPTT and SetFrequency. It is optional.
+	Failed func(error)
+	// Region of the band plan. If it is set, the client refuses to tune the radio outside of the amateur radio
+	// bands of this region.
+	Region bandplan.Region
+}
+
+// Client is a rigctld client. It connects on the first command and reconnects with the next command after the
+// connection was lost.
+type Client struct {
+	config Config
+	dial   func(ctx context.Context, address string) (net.Conn, error)
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+
+	errLock sync.Mutex
+	err     error
+}
+
+// NewClient returns a new Client with the given configuration.
+func NewClient(config Config) *Client {
+	if config.Address == "" {
+		config.Address = DefaultAddress
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	var dialer net.Dialer
+	return &Client{
+		config: config,
+		dial: func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		},
+	}
+}
+
+// Close closes the connection to rigctld.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.disconnect()
+}
+
+func (c *Client) disconnect() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
+
+// Err returns the first error of SetPTT or SetFrequency, or nil.
+func (c *Client) Err() error {
+	c.errLock.Lock()
+	defer c.errLock.Unlock()
+	return c.err
+}
+
+func (c *Client) fail(err error) {
+	c.errLock.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.errLock.Unlock()
+	if c.config.Failed != nil {
+		c.config.Failed(err)
+	}
+}
+
+// SetPTT switches the transmitter on or off. It can be used as activateTransmitter callback.
+func (c *Client) SetPTT(on bool) {
+	err := c.PTT(on)
+	if err != nil {
+		c.fail(err)
+	}
+}
+
+// SetFrequency tunes the radio to the given frequency in Hz.
+func (c *Client) SetFrequency(frequency float64) {
+	err := c.Tune(frequency)
+	if err != nil {
+		c.fail(err)
+	}
+}
+
+// PTT switches the transmitter on or off.
+func (c *Client) PTT(on bool) error {
+	value := 0
+	if on {
+		value = 1
+	}
+	return c.set(fmt.Sprintf("T %d", value))
+}
+
+// Tune tunes the radio to the given frequency in Hz. If the configuration has a region, the frequency must be within
+// an amateur radio band of this region.
+func (c *Client) Tune(frequency float64) error {
+	if c.config.Region != 0 {
+		err := bandplan.CheckTransmitFrequency(c.config.Region, "", bandplan.Frequency(frequency), 0)
+		if err != nil {
+			return err
+		}
+	}
+	return c.set(fmt.Sprintf("F %.0f", frequency))
+}
+
+// TuneSignal tunes the radio to the dial frequency of the given tuning, after checking that a signal of the given
+// mode and bandwidth at the audio offset of the tuning is within the conventional segments of the mode in the
+// region of the configuration. Without a region, only the dial frequency is set.
+func (c *Client) TuneSignal(mode string, tuning bandplan.Tuning, bandwidth float64) error {
+	if c.config.Region != 0 {
+		err := bandplan.CheckTransmitFrequency(c.config.Region, mode, tuning.RF(), bandplan.Frequency(bandwidth))
+		if err != nil {
+			return err
+		}
+	}
+	return c.Tune(float64(tuning.Dial))
+}
+
+// Frequency returns the current frequency of the radio in Hz.
+func (c *Client) Frequency() (float64, error) {
+	reply, err := c.get("f")
+	if err != nil {
+		return 0, err
+	}
+	frequency, err := strconv.ParseFloat(reply, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+	return frequency, nil
+}
+
+// Transmitting indicates if the transmitter is switched on.
+func (c *Client) Transmitting() (bool, error) {
+	reply, err := c.get("t")
+	if err != nil {
+		return false, err
+	}
+	switch reply {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+}
+
+// set sends the given set command and checks the return code.
+func (c *Client) set(command string) error {
+	reply, err := c.command(command)
+	if err != nil {
+		return err
+	}
+	return parseReturnCode(reply)
+}
+
+// get sends the given get command and returns the value of the reply.
+func (c *Client) get(command string) (string, error) {
+	reply, err := c.command(command)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(reply, "RPRT ") {
+		return "", parseReturnCode(reply)
+	}
+	return reply, nil
+}
+
+// command sends the given command and returns the first line of the reply.
+func (c *Client) command(command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := time.Now().Add(c.config.Timeout)
+	if c.conn == nil {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		conn, err := c.dial(ctx, c.config.Address)
+		if err != nil {
+			return "", err
+		}
+		c.conn = conn
+		c.reader = bufio.NewReader(conn)
+	}
+
+	reply, err := c.roundTrip(command, deadline)
+	if err != nil {
+		c.disconnect()
+		return "", err
+	}
+	return reply, nil
+}
+
+func (c *Client) roundTrip(command string, deadline time.Time) (string, error) {
+	err := c.conn.SetDeadline(deadline)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.WriteString(c.conn, command+"\n")
+	if err != nil {
+		return "", err
+	}
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func parseReturnCode(reply string) error {
+	if !strings.HasPrefix(reply, "RPRT ") {
+		return fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+	code, err := strconv.Atoi(strings.TrimPrefix(reply, "RPRT "))
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+	if code != 0 {
+		return Error(code)
+	}
+	return nil
+}
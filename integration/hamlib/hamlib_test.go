@@ -0,0 +1,165 @@
+package hamlib
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/bandplan"
+)
+
+// fakeRig is a minimal rigctld that keeps the state of one radio.
+type fakeRig struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	frequency string
+	ptt       string
+	failing   bool
+	commands  []string
+}
+
+func startFakeRig(t *testing.T) *fakeRig {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	rig := &fakeRig{listener: listener, frequency: "14097100", ptt: "0"}
+	go rig.serve()
+	t.Cleanup(func() { listener.Close() })
+	return rig
+}
+
+func (r *fakeRig) serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *fakeRig) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		r.mu.Lock()
+		r.commands = append(r.commands, scanner.Text())
+		var reply string
+		switch {
+		case r.failing:
+			reply = "RPRT -9"
+		case len(fields) == 1 && fields[0] == "f":
+			reply = r.frequency
+		case len(fields) == 1 && fields[0] == "t":
+			reply = r.ptt
+		case len(fields) == 2 && fields[0] == "F":
+			r.frequency = fields[1]
+			reply = "RPRT 0"
+		case len(fields) == 2 && fields[0] == "T":
+			r.ptt = fields[1]
+			reply = "RPRT 0"
+		case len(fields) == 1 && fields[0] == "q":
+			r.mu.Unlock()
+			return
+		default:
+			reply = "RPRT -1"
+		}
+		r.mu.Unlock()
+		conn.Write([]byte(reply + "\n"))
+	}
+}
+
+func (r *fakeRig) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.commands...)
+}
+
+func TestClient(t *testing.T) {
+	rig := startFakeRig(t)
+	client := NewClient(Config{Address: rig.listener.Addr().String()})
+	defer client.Close()
+
+	frequency, err := client.Frequency()
+	require.NoError(t, err)
+	assert.Equal(t, 14097100.0, frequency)
+
+	client.SetFrequency(7040100.4)
+	client.SetPTT(true)
+	require.NoError(t, client.Err())
+
+	frequency, err = client.Frequency()
+	require.NoError(t, err)
+	assert.Equal(t, 7040100.0, frequency)
+	transmitting, err := client.Transmitting()
+	require.NoError(t, err)
+	assert.True(t, transmitting)
+
+	client.SetPTT(false)
+	transmitting, err = client.Transmitting()
+	require.NoError(t, err)
+	assert.False(t, transmitting)
+
+	assert.Equal(t, []string{"f", "F 7040100", "T 1", "f", "t", "T 0", "t"}, rig.Commands())
+}
+
+func TestClientBandplan(t *testing.T) {
+	rig := startFakeRig(t)
+	client := NewClient(Config{Address: rig.listener.Addr().String(), Region: bandplan.Region1})
+	defer client.Close()
+
+	assert.Error(t, client.Tune(7250000), "outside of the 40m band in region 1")
+	assert.Error(t, client.TuneSignal("wspr", bandplan.NewTuning(14095600, 1700), 6), "outside of the WSPR segment")
+	assert.NoError(t, client.TuneSignal("wspr", bandplan.NewTuning(14095600, 1500), 6))
+	client.SetFrequency(7250000)
+	assert.Error(t, client.Err())
+
+	assert.Equal(t, []string{"F 14095600"}, rig.Commands())
+}
+
+func TestClientError(t *testing.T) {
+	rig := startFakeRig(t)
+	var failures []error
+	client := NewClient(Config{Address: rig.listener.Addr().String(), Failed: func(err error) {
+		failures = append(failures, err)
+	}})
+	defer client.Close()
+
+	err := client.set("X 1")
+	assert.Equal(t, Error(-1), err)
+
+	rig.mu.Lock()
+	rig.failing = true
+	rig.mu.Unlock()
+	client.SetPTT(true)
+	require.Len(t, failures, 1)
+	assert.Equal(t, Error(-9), client.Err())
+
+	rig.mu.Lock()
+	rig.failing = false
+	rig.mu.Unlock()
+	_, err = client.command("q")
+	assert.Error(t, err, "connection closed by rigctld")
+	client.SetPTT(true)
+	assert.Len(t, failures, 1, "reconnected")
+	assert.Equal(t, []string{"X 1", "T 1", "q", "T 1"}, rig.Commands())
+}
+
+func TestClientNotReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	client := NewClient(Config{Address: address})
+	client.SetPTT(true)
+	var opErr *net.OpError
+	assert.True(t, errors.As(client.Err(), &opErr))
+}
@@ -0,0 +1,225 @@
+/*
+Package stream implements a bounded, context-aware FIFO that connects the writing side of a mode with its
+audio path.
+
+The buffer of a stream is preallocated, sending and receiving elements does not allocate. TryReceive never
+blocks, so it can be used safely in the audio path. Push grows the buffer instead of blocking, e.g. to queue a
+whole transmission at once before it is rendered offline.
+*/
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned when sending to or receiving from a closed stream.
+var ErrClosed = errors.New("stream: closed")
+
+// Stream is a bounded FIFO of elements of type T.
+type Stream[T any] struct {
+	mu       sync.Mutex
+	elements []T
+	// size is the number of elements that Send buffers, Push may grow the buffer beyond
+	size   int
+	head   int
+	length int32
+
+	notFull  chan struct{}
+	notEmpty chan struct{}
+	done     chan struct{}
+	close    sync.Once
+}
+
+// New returns a new stream that can buffer up to size elements.
+func New[T any](size int) *Stream[T] {
+	return &Stream[T]{
+		elements: make([]T, size),
+		size:     size,
+		notFull:  make(chan struct{}, 1),
+		notEmpty: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Send appends the given element to the stream. It blocks while the stream is full. Send returns ErrClosed if
+// the stream is closed or the error of the context if the context is done before the element could be appended.
+func (s *Stream[T]) Send(ctx context.Context, element T) error {
+	for {
+		s.mu.Lock()
+		if s.Closed() {
+			s.mu.Unlock()
+			return ErrClosed
+		}
+		if int(s.length) < s.size {
+			s.elements[(s.head+int(s.length))%len(s.elements)] = element
+			full := int(atomic.AddInt32(&s.length, 1)) >= s.size
+			s.mu.Unlock()
+			notify(s.notEmpty)
+			if !full {
+				notify(s.notFull)
+			}
+			return nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.notFull:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Push appends the given element to the stream without blocking. If the buffer is full, it grows, which
+// allocates. Push returns ErrClosed if the stream is closed.
+func (s *Stream[T]) Push(element T) error {
+	s.mu.Lock()
+	if s.Closed() {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if int(s.length) == len(s.elements) {
+		s.grow()
+	}
+	s.elements[(s.head+int(s.length))%len(s.elements)] = element
+	atomic.AddInt32(&s.length, 1)
+	s.mu.Unlock()
+	notify(s.notEmpty)
+	return nil
+}
+
+// grow doubles the buffer, it must be called while holding the lock.
+func (s *Stream[T]) grow() {
+	elements := make([]T, 2*len(s.elements)+1)
+	for i := 0; i < int(s.length); i++ {
+		elements[i] = s.elements[(s.head+i)%len(s.elements)]
+	}
+	s.elements = elements
+	s.head = 0
+}
+
+// Receive removes the first element from the stream. It blocks while the stream is empty. Receive returns
+// ErrClosed if the stream is closed or the error of the context if the context is done before an element
+// could be removed.
+func (s *Stream[T]) Receive(ctx context.Context) (T, error) {
+	for {
+		if s.Closed() {
+			var zero T
+			return zero, ErrClosed
+		}
+		element, ok := s.TryReceive()
+		if ok {
+			return element, nil
+		}
+
+		select {
+		case <-s.notEmpty:
+		case <-s.done:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TryReceive removes the first element from the stream, if available. It never blocks.
+func (s *Stream[T]) TryReceive() (T, bool) {
+	var zero T
+	if atomic.LoadInt32(&s.length) == 0 {
+		return zero, false
+	}
+	s.mu.Lock()
+	if s.length == 0 {
+		s.mu.Unlock()
+		return zero, false
+	}
+	result := s.elements[s.head]
+	s.elements[s.head] = zero
+	s.head = (s.head + 1) % len(s.elements)
+	empty := atomic.AddInt32(&s.length, -1) == 0
+	s.mu.Unlock()
+
+	if int(atomic.LoadInt32(&s.length)) < s.size {
+		notify(s.notFull)
+	}
+	if !empty {
+		notify(s.notEmpty)
+	}
+	return result, true
+}
+
+// Len returns the number of elements in the stream.
+func (s *Stream[T]) Len() int {
+	return int(atomic.LoadInt32(&s.length))
+}
+
+// Cap returns the number of elements that Send buffers before it blocks.
+func (s *Stream[T]) Cap() int {
+	return s.size
+}
+
+// Close closes the stream and wakes up all blocked senders and receivers. Close can be called multiple times.
+func (s *Stream[T]) Close() {
+	s.close.Do(func() {
+		close(s.done)
+	})
+}
+
+// Closed indicates if the stream is closed.
+func (s *Stream[T]) Closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel that is closed when the stream is closed.
+func (s *Stream[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+func notify(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// Progress tracks how much of the latest write was taken from a stream by the receiving side, e.g. the number of
+// characters of a write that were actually transmitted. The writes are identified by increasing numbers. Progress
+// is safe for concurrent use and does not allocate.
+type Progress struct {
+	mu    sync.Mutex
+	write uint32
+	count int
+}
+
+// Add adds the given count to the progress of the given write. A newer write starts over with the given count.
+func (p *Progress) Add(write uint32, count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case write == p.write:
+		p.count += count
+	case write > p.write:
+		p.write = write
+		p.count = count
+	}
+}
+
+// Count returns the progress of the given write, 0 if nothing of the write was taken yet or if a newer write was
+// taken since.
+func (p *Progress) Count(write uint32) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if write != p.write {
+		return 0
+	}
+	return p.count
+}
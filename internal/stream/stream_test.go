@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendAndReceive(t *testing.T) {
+	s := New[int](3)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.Send(ctx, i))
+	}
+	assert.Equal(t, 3, s.Len())
+
+	for i := 1; i <= 3; i++ {
+		actual, err := s.Receive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, i, actual)
+	}
+	_, ok := s.TryReceive()
+	assert.False(t, ok)
+}
+
+func TestSendBlocksWhileFull(t *testing.T) {
+	s := New[int](1)
+	require.NoError(t, s.Send(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Send(ctx, 2)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	sent := make(chan error)
+	go func() {
+		sent <- s.Send(context.Background(), 3)
+	}()
+	actual, ok := s.TryReceive()
+	assert.True(t, ok)
+	assert.Equal(t, 1, actual)
+	assert.NoError(t, <-sent)
+
+	actual, ok = s.TryReceive()
+	assert.True(t, ok)
+	assert.Equal(t, 3, actual)
+}
+
+func TestPushGrowsTheBuffer(t *testing.T) {
+	s := New[int](2)
+	ctx := context.Background()
+	require.NoError(t, s.Send(ctx, 1))
+	_, ok := s.TryReceive()
+	require.True(t, ok)
+
+	// the elements wrap around the end of the buffer when it grows
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, s.Push(i))
+	}
+	assert.Equal(t, 5, s.Len())
+	assert.Equal(t, 2, s.Cap())
+
+	// Send still blocks while more than the capacity is buffered
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Send(timeout, 6))
+
+	for i := 1; i <= 5; i++ {
+		actual, ok := s.TryReceive()
+		require.True(t, ok)
+		assert.Equal(t, i, actual)
+	}
+	_, ok = s.TryReceive()
+	assert.False(t, ok)
+	require.NoError(t, s.Send(ctx, 7))
+
+	s.Close()
+	assert.Equal(t, ErrClosed, s.Push(8))
+}
+
+func TestReceiveBlocksWhileEmpty(t *testing.T) {
+	s := New[string](1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.Receive(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	received := make(chan string)
+	go func() {
+		actual, _ := s.Receive(context.Background())
+		received <- actual
+	}()
+	require.NoError(t, s.Send(context.Background(), "hello"))
+	assert.Equal(t, "hello", <-received)
+}
+
+func TestCloseWakesUpBlockedSenders(t *testing.T) {
+	s := New[int](1)
+	require.NoError(t, s.Send(context.Background(), 1))
+
+	sent := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			sent <- s.Send(context.Background(), 2)
+		}()
+	}
+	s.Close()
+	s.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrClosed, <-sent)
+	}
+	assert.True(t, s.Closed())
+	_, err := s.Receive(context.Background())
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestManySenders(t *testing.T) {
+	s := New[int](4)
+	const senders = 10
+	const count = 100
+	for i := 0; i < senders; i++ {
+		go func() {
+			for j := 0; j < count; j++ {
+				s.Send(context.Background(), 1)
+			}
+		}()
+	}
+
+	sum := 0
+	for sum < senders*count {
+		actual, err := s.Receive(context.Background())
+		require.NoError(t, err)
+		sum += actual
+	}
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestProgress(t *testing.T) {
+	var p Progress
+	assert.Equal(t, 0, p.Count(1))
+
+	p.Add(1, 2)
+	p.Add(1, 3)
+	assert.Equal(t, 5, p.Count(1))
+
+	p.Add(3, 1)
+	assert.Equal(t, 1, p.Count(3))
+	assert.Equal(t, 0, p.Count(1))
+
+	p.Add(2, 4)
+	assert.Equal(t, 1, p.Count(3), "older writes are ignored")
+}
@@ -0,0 +1,160 @@
+/*
+Package gpio implements a keyout backend that keys the transmitter through a GPIO pin, e.g. of a Raspberry Pi.
+
+The pin is controlled through the sysfs interface of the Linux kernel (/sys/class/gpio), so no additional libraries
+or cgo are required. The user needs write access to the sysfs files, usually through membership in the gpio group.
+*/
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ftl/digimodes/keyout"
+)
+
+// exportTimeout is the time to wait for the sysfs files of an exported pin to become writable.
+const exportTimeout = time.Second
+
+// sysfsRoot is the directory of the GPIO sysfs interface.
+var sysfsRoot = "/sys/class/gpio"
+
+// Config of a GPIO keyer.
+type Config struct {
+	// Pin is the GPIO number of the pin in the numbering of the kernel (BCM numbering on the Raspberry Pi).
+	Pin int
+	// ActiveLow means that the transmitter is keyed when the pin is low.
+	ActiveLow bool
+	// Lead is the time between activating the pin and the start of the signal.
+	Lead time.Duration
+	// Tail is the time the pin stays active after the end of the signal.
+	Tail time.Duration
+	// Failed is called with every error of the pin. It is optional.
+	Failed func(error)
+}
+
+// Open opens the pin of the given configuration and returns a keyer for it. The pin is released initially.
+func Open(config Config) (*keyout.Keyer, error) {
+	pin, err := OpenPin(config.Pin, config.ActiveLow)
+	if err != nil {
+		return nil, err
+	}
+	return keyout.New(pin, keyout.Config{
+		Lead:   config.Lead,
+		Tail:   config.Tail,
+		Failed: config.Failed,
+	}), nil
+}
+
+// Pin is a GPIO pin that is configured as output. It implements keyout.Line.
+type Pin struct {
+	number    int
+	activeLow bool
+	value     *os.File
+	exported  bool
+}
+
+// OpenPin exports the GPIO pin with the given number, configures it as output and releases it.
+func OpenPin(number int, activeLow bool) (*Pin, error) {
+	result := &Pin{
+		number:    number,
+		activeLow: activeLow,
+	}
+
+	dir := result.dir()
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		err := writeFile(filepath.Join(sysfsRoot, "export"), strconv.Itoa(number))
+		if err != nil {
+			return nil, fmt.Errorf("gpio: cannot export pin %d: %w", number, err)
+		}
+		result.exported = true
+	}
+
+	// after the export, udev may need some time to grant access to the files of the pin
+	initial := "low"
+	if activeLow {
+		initial = "high"
+	}
+	deadline := time.Now().Add(exportTimeout)
+	for {
+		err := writeFile(filepath.Join(dir, "direction"), initial)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			result.unexport()
+			return nil, fmt.Errorf("gpio: cannot configure pin %d: %w", number, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	value, err := os.OpenFile(filepath.Join(dir, "value"), os.O_WRONLY, 0)
+	if err != nil {
+		result.unexport()
+		return nil, fmt.Errorf("gpio: cannot open pin %d: %w", number, err)
+	}
+	result.value = value
+	return result, nil
+}
+
+func (p *Pin) dir() string {
+	return filepath.Join(sysfsRoot, "gpio"+strconv.Itoa(p.number))
+}
+
+// Number returns the GPIO number of the pin.
+func (p *Pin) Number() int {
+	return p.number
+}
+
+// Set activates or releases the pin, according to its active level.
+func (p *Pin) Set(active bool) error {
+	level := "0"
+	if active != p.activeLow {
+		level = "1"
+	}
+	_, err := p.value.WriteAt([]byte(level), 0)
+	if err != nil {
+		return fmt.Errorf("gpio: cannot set pin %d: %w", p.number, err)
+	}
+	return nil
+}
+
+// Close releases the pin and unexports it, if it was exported by OpenPin.
+func (p *Pin) Close() error {
+	err := p.Set(false)
+	closeErr := p.value.Close()
+	if err == nil {
+		err = closeErr
+	}
+	unexportErr := p.unexport()
+	if err == nil {
+		err = unexportErr
+	}
+	return err
+}
+
+func (p *Pin) unexport() error {
+	if !p.exported {
+		return nil
+	}
+	p.exported = false
+	return writeFile(filepath.Join(sysfsRoot, "unexport"), strconv.Itoa(p.number))
+}
+
+// writeFile writes the given value to the existing sysfs file with the given name.
+func writeFile(name string, value string) error {
+	file, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(value)
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,72 @@
+package gpio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSysfs creates the files of the GPIO sysfs interface with an exported pin in a temporary directory.
+func fakeSysfs(t *testing.T, pin string) string {
+	root := t.TempDir()
+	original := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = original })
+
+	for _, name := range []string{"export", "unexport"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), nil, 0644))
+	}
+	if pin != "" {
+		require.NoError(t, os.Mkdir(filepath.Join(root, "gpio"+pin), 0755))
+		for _, name := range []string{"direction", "value"} {
+			require.NoError(t, os.WriteFile(filepath.Join(root, "gpio"+pin, name), nil, 0644))
+		}
+	}
+	return root
+}
+
+func readFile(t *testing.T, name string) string {
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestKeyer(t *testing.T) {
+	root := fakeSysfs(t, "17")
+	keyer, err := Open(Config{Pin: 17})
+	require.NoError(t, err)
+
+	assert.Equal(t, "low", readFile(t, filepath.Join(root, "gpio17", "direction")))
+	keyer.SetKeyDown(true)
+	assert.Equal(t, "1", readFile(t, filepath.Join(root, "gpio17", "value")))
+	keyer.SetKeyDown(false)
+	assert.Equal(t, "0", readFile(t, filepath.Join(root, "gpio17", "value")))
+
+	require.NoError(t, keyer.Close())
+	assert.NoError(t, keyer.Err())
+	assert.Equal(t, "", readFile(t, filepath.Join(root, "unexport")), "the pin was exported before")
+}
+
+func TestActiveLow(t *testing.T) {
+	root := fakeSysfs(t, "4")
+	pin, err := OpenPin(4, true)
+	require.NoError(t, err)
+	defer pin.Close()
+
+	assert.Equal(t, "high", readFile(t, filepath.Join(root, "gpio4", "direction")))
+	require.NoError(t, pin.Set(true))
+	assert.Equal(t, "0", readFile(t, filepath.Join(root, "gpio4", "value")))
+	require.NoError(t, pin.Set(false))
+	assert.Equal(t, "1", readFile(t, filepath.Join(root, "gpio4", "value")))
+}
+
+func TestExportFails(t *testing.T) {
+	root := fakeSysfs(t, "")
+	_, err := OpenPin(22, false)
+	assert.Error(t, err)
+	assert.Equal(t, "22", readFile(t, filepath.Join(root, "export")))
+	assert.Equal(t, "22", readFile(t, filepath.Join(root, "unexport")))
+}
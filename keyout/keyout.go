@@ -0,0 +1,153 @@
+/*
+Package keyout keys a transmitter through a hardware line, e.g. a GPIO pin or the DTR/RTS line of a serial port.
+The backends for the different kinds of lines are implemented in the subpackages.
+
+A Keyer wraps a Line and provides the SetKeyDown method, which can be used as the setKeyDown callback of cw.Send,
+the activateTransmitter callback of wspr.Send or the transmit function of a vox.VOX or txlimit.Limiter.
+*/
+package keyout
+
+import (
+	"sync"
+	"time"
+)
+
+// Line is an output line that keys the transmitter.
+type Line interface {
+	// Set activates or releases the line.
+	Set(active bool) error
+	// Close releases the line and frees the underlying resources.
+	Close() error
+}
+
+// Config of a Keyer.
+//
+// Lead and Tail are meant for PTT lines, where the transmitter needs some time to switch between receive and
+// transmit. For a line that keys the CW signal directly, both should be 0.
+type Config struct {
+	// Lead is the time SetKeyDown(true) blocks after activating the line, before the signal may start.
+	Lead time.Duration
+	// Tail is the time the line stays active after SetKeyDown(false). A key down within this time keeps the line
+	// active without another lead time.
+	Tail time.Duration
+	// Failed is called with every error of the line. It is optional.
+	Failed func(error)
+}
+
+// Keyer keys a transmitter through a Line.
+type Keyer struct {
+	line   Line
+	config Config
+
+	mu         sync.Mutex
+	active     bool
+	generation int
+	tail       *time.Timer
+
+	errLock sync.Mutex
+	err     error
+}
+
+// New returns a new Keyer for the given line.
+func New(line Line, config Config) *Keyer {
+	return &Keyer{
+		line:   line,
+		config: config,
+	}
+}
+
+// SetKeyDown activates the line when down is true and releases it after the tail time when down is false.
+func (k *Keyer) SetKeyDown(down bool) {
+	k.mu.Lock()
+	k.generation++
+	if k.tail != nil {
+		k.tail.Stop()
+		k.tail = nil
+	}
+
+	if down {
+		if k.active {
+			k.mu.Unlock()
+			return
+		}
+		k.set(true)
+		k.mu.Unlock()
+		if k.config.Lead > 0 {
+			time.Sleep(k.config.Lead)
+		}
+		return
+	}
+
+	defer k.mu.Unlock()
+	if !k.active {
+		return
+	}
+	if k.config.Tail <= 0 {
+		k.set(false)
+		return
+	}
+	generation := k.generation
+	k.tail = time.AfterFunc(k.config.Tail, func() {
+		k.release(generation)
+	})
+}
+
+// release releases the line if there was no other key event since the tail time was started.
+func (k *Keyer) release(generation int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.generation != generation {
+		return
+	}
+	k.tail = nil
+	k.set(false)
+}
+
+// set changes the state of the line, the caller must hold the lock.
+func (k *Keyer) set(active bool) {
+	k.active = active
+	err := k.line.Set(active)
+	if err != nil {
+		k.fail(err)
+	}
+}
+
+// Active indicates if the line is currently active.
+func (k *Keyer) Active() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.active
+}
+
+// Err returns the first error of the line, or nil.
+func (k *Keyer) Err() error {
+	k.errLock.Lock()
+	defer k.errLock.Unlock()
+	return k.err
+}
+
+func (k *Keyer) fail(err error) {
+	k.errLock.Lock()
+	if k.err == nil {
+		k.err = err
+	}
+	k.errLock.Unlock()
+	if k.config.Failed != nil {
+		k.config.Failed(err)
+	}
+}
+
+// Close releases the line immediately and closes it.
+func (k *Keyer) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.generation++
+	if k.tail != nil {
+		k.tail.Stop()
+		k.tail = nil
+	}
+	if k.active {
+		k.set(false)
+	}
+	return k.line.Close()
+}
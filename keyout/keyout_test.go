@@ -0,0 +1,114 @@
+package keyout
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLine struct {
+	mu      sync.Mutex
+	states  []bool
+	err     error
+	closed  bool
+	changed chan struct{}
+}
+
+func newTestLine() *testLine {
+	return &testLine{changed: make(chan struct{}, 10)}
+}
+
+func (l *testLine) Set(active bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.states = append(l.states, active)
+	l.changed <- struct{}{}
+	return l.err
+}
+
+func (l *testLine) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+func (l *testLine) States() []bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]bool{}, l.states...)
+}
+
+func TestKeyerWithoutDelays(t *testing.T) {
+	line := newTestLine()
+	keyer := New(line, Config{})
+
+	keyer.SetKeyDown(true)
+	assert.True(t, keyer.Active())
+	keyer.SetKeyDown(true)
+	keyer.SetKeyDown(false)
+	assert.False(t, keyer.Active())
+	keyer.SetKeyDown(false)
+
+	assert.Equal(t, []bool{true, false}, line.States())
+	assert.NoError(t, keyer.Err())
+}
+
+func TestKeyerLead(t *testing.T) {
+	keyer := New(newTestLine(), Config{Lead: 50 * time.Millisecond})
+
+	start := time.Now()
+	keyer.SetKeyDown(true)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	start = time.Now()
+	keyer.SetKeyDown(true)
+	assert.True(t, time.Since(start) < 50*time.Millisecond, "no lead while the line is active")
+}
+
+func TestKeyerTail(t *testing.T) {
+	line := newTestLine()
+	keyer := New(line, Config{Tail: 50 * time.Millisecond})
+
+	keyer.SetKeyDown(true)
+	keyer.SetKeyDown(false)
+	assert.True(t, keyer.Active())
+	keyer.SetKeyDown(true)
+	keyer.SetKeyDown(false)
+	assert.Equal(t, []bool{true}, line.States(), "key down within the tail time")
+
+	<-line.changed
+	select {
+	case <-line.changed:
+	case <-time.After(time.Second):
+		t.Fatal("the line was not released")
+	}
+	assert.False(t, keyer.Active())
+	assert.Equal(t, []bool{true, false}, line.States())
+}
+
+func TestKeyerClose(t *testing.T) {
+	line := newTestLine()
+	keyer := New(line, Config{Tail: time.Hour})
+
+	keyer.SetKeyDown(true)
+	keyer.SetKeyDown(false)
+	assert.NoError(t, keyer.Close())
+	assert.Equal(t, []bool{true, false}, line.States())
+	assert.True(t, line.closed)
+}
+
+func TestKeyerError(t *testing.T) {
+	line := newTestLine()
+	line.err = errors.New("broken line")
+	var failures []error
+	keyer := New(line, Config{Failed: func(err error) { failures = append(failures, err) }})
+
+	keyer.SetKeyDown(true)
+	keyer.SetKeyDown(false)
+	assert.Equal(t, line.err, keyer.Err())
+	assert.Len(t, failures, 2)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package serial
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ttyModem controls the modem control lines of a tty device through ioctl.
+type ttyModem struct {
+	file *os.File
+}
+
+func openModem(device string) (modem, error) {
+	file, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ttyModem{file: file}, nil
+}
+
+func (m *ttyModem) setSignal(signal Signal, on bool) error {
+	var bits uint32
+	if signal&DTR != 0 {
+		bits |= syscall.TIOCM_DTR
+	}
+	if signal&RTS != 0 {
+		bits |= syscall.TIOCM_RTS
+	}
+	request := uintptr(syscall.TIOCMBIC)
+	if on {
+		request = syscall.TIOCMBIS
+	}
+
+	conn, err := m.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(&bits)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (m *ttyModem) close() error {
+	return m.file.Close()
+}
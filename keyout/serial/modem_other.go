@@ -0,0 +1,7 @@
+//go:build !linux
+
+package serial
+
+func openModem(device string) (modem, error) {
+	return nil, ErrNotSupported
+}
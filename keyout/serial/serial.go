@@ -0,0 +1,140 @@
+/*
+Package serial implements a keyout backend that keys the transmitter through the DTR or RTS line of a serial port.
+This is the common interface of simple keying circuits and of the CAT interfaces of many radios, e.g. CW on DTR and
+PTT on RTS.
+
+The modem control lines are only supported on Linux, on other systems Open returns ErrNotSupported.
+*/
+package serial
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes/keyout"
+)
+
+// ErrNotSupported is returned when the modem control lines are not supported on this system.
+var ErrNotSupported = errors.New("serial: modem control lines not supported")
+
+// Signal is a set of modem control lines of a serial port.
+type Signal int
+
+// The modem control lines.
+const (
+	DTR Signal = 1 << iota
+	RTS
+)
+
+func (s Signal) String() string {
+	var names []string
+	if s&DTR != 0 {
+		names = append(names, "DTR")
+	}
+	if s&RTS != 0 {
+		names = append(names, "RTS")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
+
+// Config of a serial keyer.
+type Config struct {
+	// Device is the name of the serial port, e.g. "/dev/ttyUSB0".
+	Device string
+	// Signal is the set of lines that key the transmitter, e.g. DTR or DTR|RTS.
+	Signal Signal
+	// Inverted means that the transmitter is keyed when the lines are cleared.
+	Inverted bool
+	// Lead is the time between activating the lines and the start of the signal.
+	Lead time.Duration
+	// Tail is the time the lines stay active after the end of the signal.
+	Tail time.Duration
+	// Failed is called with every error of the port. It is optional.
+	Failed func(error)
+}
+
+// Open opens the serial port of the given configuration and returns a keyer for it. The lines are released
+// initially.
+func Open(config Config) (*keyout.Keyer, error) {
+	port, err := OpenPort(config.Device, config.Signal, config.Inverted)
+	if err != nil {
+		return nil, err
+	}
+	return keyout.New(port, keyout.Config{
+		Lead:   config.Lead,
+		Tail:   config.Tail,
+		Failed: config.Failed,
+	}), nil
+}
+
+// modem controls the modem control lines of a serial port.
+type modem interface {
+	setSignal(signal Signal, on bool) error
+	close() error
+}
+
+// Port keys the transmitter through modem control lines of a serial port. It implements keyout.Line. The lines
+// of the port that are not used for keying are not changed.
+type Port struct {
+	device   string
+	signal   Signal
+	inverted bool
+	modem    modem
+}
+
+// OpenPort opens the given serial port to key the transmitter through the given lines and releases them.
+func OpenPort(device string, signal Signal, inverted bool) (*Port, error) {
+	if signal == 0 {
+		return nil, fmt.Errorf("serial: no line to key %s", device)
+	}
+	modem, err := openModem(device)
+	if err != nil {
+		return nil, err
+	}
+	result := &Port{
+		device:   device,
+		signal:   signal,
+		inverted: inverted,
+		modem:    modem,
+	}
+	err = result.Set(false)
+	if err != nil {
+		modem.close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// Device returns the name of the serial port.
+func (p *Port) Device() string {
+	return p.device
+}
+
+// Signal returns the lines that key the transmitter.
+func (p *Port) Signal() Signal {
+	return p.signal
+}
+
+// Set activates or releases the lines.
+func (p *Port) Set(active bool) error {
+	err := p.modem.setSignal(p.signal, active != p.inverted)
+	if err != nil {
+		return fmt.Errorf("serial: cannot set %s of %s: %w", p.signal, p.device, err)
+	}
+	return nil
+}
+
+// Close releases the lines and closes the serial port.
+func (p *Port) Close() error {
+	err := p.Set(false)
+	closeErr := p.modem.close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package serial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/keyout"
+)
+
+type testModem struct {
+	lines  Signal
+	closed bool
+}
+
+func (m *testModem) setSignal(signal Signal, on bool) error {
+	if on {
+		m.lines |= signal
+	} else {
+		m.lines &^= signal
+	}
+	return nil
+}
+
+func (m *testModem) close() error {
+	m.closed = true
+	return nil
+}
+
+func TestSignalString(t *testing.T) {
+	assert.Equal(t, "DTR", DTR.String())
+	assert.Equal(t, "DTR+RTS", (DTR | RTS).String())
+	assert.Equal(t, "none", Signal(0).String())
+}
+
+func TestPort(t *testing.T) {
+	modem := &testModem{lines: RTS}
+	port := &Port{device: "test", signal: DTR, modem: modem}
+	keyer := keyout.New(port, keyout.Config{})
+
+	keyer.SetKeyDown(true)
+	assert.Equal(t, DTR|RTS, modem.lines, "RTS is not used for keying")
+	keyer.SetKeyDown(false)
+	assert.Equal(t, RTS, modem.lines)
+
+	keyer.SetKeyDown(true)
+	require.NoError(t, keyer.Close())
+	assert.Equal(t, RTS, modem.lines)
+	assert.True(t, modem.closed)
+}
+
+func TestInvertedPort(t *testing.T) {
+	modem := &testModem{}
+	port := &Port{device: "test", signal: DTR | RTS, inverted: true, modem: modem}
+
+	require.NoError(t, port.Set(false))
+	assert.Equal(t, DTR|RTS, modem.lines)
+	require.NoError(t, port.Set(true))
+	assert.Equal(t, Signal(0), modem.lines)
+}
+
+func TestOpenPort(t *testing.T) {
+	_, err := OpenPort("/dev/ttyS0", 0, false)
+	assert.Error(t, err, "no line")
+
+	_, err = OpenPort("/dev/does-not-exist", DTR, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+/*
+Package locator implements Maidenhead locators (grid squares), which are used by many digital modes to exchange
+the position of the stations, and the calculation of distances and bearings between them.
+
+A locator consists of up to four pairs of characters: the field (two letters A-R), the square (two digits), the
+subsquare (two letters A-X) and the extended square (two digits). Each pair gives the longitude first and the
+latitude second.
+*/
+package locator
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// ErrInvalid is returned for invalid locators.
+var ErrInvalid = errors.New("locator: invalid Maidenhead locator")
+
+// EarthRadius is the mean radius of the earth in km.
+const EarthRadius = 6371.0
+
+// pair describes one pair of characters of a locator: the first valid character, the number of values and the
+// size of the area in degrees of longitude; the latitude is half of it.
+type pair struct {
+	first  byte
+	count  int
+	degree float64
+}
+
+var pairs = []pair{
+	{'A', 18, 20},
+	{'0', 10, 2},
+	{'A', 24, 2.0 / 24},
+	{'0', 10, 2.0 / 240},
+}
+
+// Locator is a valid Maidenhead locator with 4, 6 or 8 characters in upper case.
+type Locator string
+
+// Parse parses the given locator with 4, 6 or 8 characters. The letters may be upper or lower case.
+func Parse(s string) (Locator, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) != 4 && len(s) != 6 && len(s) != 8 {
+		return "", ErrInvalid
+	}
+	for i := 0; i < len(s); i++ {
+		p := pairs[i/2]
+		if s[i] < p.first || s[i] >= p.first+byte(p.count) {
+			return "", ErrInvalid
+		}
+	}
+	return Locator(s), nil
+}
+
+// Valid indicates if the given text is a valid locator.
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// FromLatLon returns the locator with the given length (4, 6 or 8) of the area that contains the given position
+// in degrees, north and east are positive.
+func FromLatLon(latitude, longitude float64, length int) (Locator, error) {
+	if length != 4 && length != 6 && length != 8 {
+		return "", ErrInvalid
+	}
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return "", ErrInvalid
+	}
+	// the north pole and the date line belong to the last area
+	lon := math.Min(longitude+180, 360-1e-9)
+	lat := math.Min(latitude+90, 180-1e-9)
+
+	result := make([]byte, length)
+	for i := 0; i < length; i += 2 {
+		p := pairs[i/2]
+		lonIndex := int(lon / p.degree)
+		latIndex := int(lat / (p.degree / 2))
+		result[i] = p.first + byte(lonIndex)
+		result[i+1] = p.first + byte(latIndex)
+		lon -= float64(lonIndex) * p.degree
+		lat -= float64(latIndex) * p.degree / 2
+	}
+	return Locator(result), nil
+}
+
+// String returns the locator in upper case.
+func (l Locator) String() string {
+	return string(l)
+}
+
+// Square returns the first four characters of the locator, the grid square.
+func (l Locator) Square() Locator {
+	if len(l) < 4 {
+		return l
+	}
+	return l[:4]
+}
+
+// LatLon returns the position of the center of the locator's area in degrees, north and east are positive.
+func (l Locator) LatLon() (latitude, longitude float64) {
+	longitude = -180
+	latitude = -90
+	var size float64
+	for i := 0; i+1 < len(l); i += 2 {
+		p := pairs[i/2]
+		size = p.degree
+		longitude += float64(l[i]-p.first) * p.degree
+		latitude += float64(l[i+1]-p.first) * p.degree / 2
+	}
+	return latitude + size/4, longitude + size/2
+}
+
+// Distance returns the great circle distance to the given locator in km, between the centers of both areas.
+func (l Locator) Distance(other Locator) float64 {
+	lat1, lon1 := l.LatLon()
+	lat2, lon2 := other.LatLon()
+	return Distance(lat1, lon1, lat2, lon2)
+}
+
+// Bearing returns the initial bearing to the given locator in degrees (0-360, clockwise from north), between the
+// centers of both areas.
+func (l Locator) Bearing(other Locator) float64 {
+	lat1, lon1 := l.LatLon()
+	lat2, lon2 := other.LatLon()
+	return Bearing(lat1, lon1, lat2, lon2)
+}
+
+// Distance returns the great circle distance between the given positions in km.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1R, lat2R := radians(lat1), radians(lat2)
+	dLat := lat2R - lat1R
+	dLon := radians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1R)*math.Cos(lat2R)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Bearing returns the initial bearing from the first to the second position in degrees (0-360, clockwise from
+// north).
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1R, lat2R := radians(lat1), radians(lat2)
+	dLon := radians(lon2 - lon1)
+	y := math.Sin(dLon) * math.Cos(lat2R)
+	x := math.Cos(lat1R)*math.Sin(lat2R) - math.Sin(lat1R)*math.Cos(lat2R)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
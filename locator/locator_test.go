@@ -0,0 +1,93 @@
+package locator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected Locator
+		invalid  bool
+	}{
+		{value: "JO62", expected: "JO62"},
+		{value: "jo62qm", expected: "JO62QM"},
+		{value: "JO62qm45", expected: "JO62QM45"},
+		{value: "RR99XX99", expected: "RR99XX99"},
+		{value: "JO6", invalid: true},
+		{value: "JO62Q", invalid: true},
+		{value: "SO62", invalid: true},
+		{value: "JOA2", invalid: true},
+		{value: "JO62YA", invalid: true},
+		{value: "JO62QMA5", invalid: true},
+		{value: "JO62QM45AA", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			actual, err := Parse(tC.value)
+			if tC.invalid {
+				assert.Equal(t, ErrInvalid, err)
+				assert.False(t, Valid(tC.value))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+			assert.Equal(t, tC.expected[:4], actual.Square())
+		})
+	}
+}
+
+func TestLatLon(t *testing.T) {
+	testCases := []struct {
+		locator   Locator
+		latitude  float64
+		longitude float64
+	}{
+		{"JO62", 52.5, 13},
+		{"JO62QM", 52.520833, 13.375},
+		{"JO62QM45", 52.522917, 13.370833},
+		{"AA00", -89.5, -179},
+		{"RR99XX99", 89.997917, 179.995833},
+	}
+	for _, tC := range testCases {
+		t.Run(string(tC.locator), func(t *testing.T) {
+			latitude, longitude := tC.locator.LatLon()
+			assert.InDelta(t, tC.latitude, latitude, 0.000001)
+			assert.InDelta(t, tC.longitude, longitude, 0.000001)
+
+			actual, err := FromLatLon(latitude, longitude, len(tC.locator))
+			require.NoError(t, err)
+			assert.Equal(t, tC.locator, actual)
+		})
+	}
+}
+
+func TestFromLatLon(t *testing.T) {
+	berlin, err := FromLatLon(52.52, 13.405, 6)
+	require.NoError(t, err)
+	assert.Equal(t, Locator("JO62QM"), berlin)
+
+	corner, err := FromLatLon(90, 180, 4)
+	require.NoError(t, err)
+	assert.Equal(t, Locator("RR99"), corner)
+
+	_, err = FromLatLon(91, 0, 4)
+	assert.Equal(t, ErrInvalid, err)
+	_, err = FromLatLon(0, 0, 5)
+	assert.Equal(t, ErrInvalid, err)
+}
+
+func TestDistanceAndBearing(t *testing.T) {
+	// Berlin to New York
+	assert.InDelta(t, 6385, Distance(52.52, 13.405, 40.7128, -74.006), 5)
+	assert.InDelta(t, 296.0, Bearing(52.52, 13.405, 40.7128, -74.006), 0.5)
+
+	assert.InDelta(t, 0, Locator("JO62").Distance("JO62"), 0.000001)
+	assert.InDelta(t, 111.2, Locator("JO62").Distance("JO63"), 0.1, "one degree of latitude")
+	assert.InDelta(t, 0, Locator("JO62").Bearing("JO63"), 0.000001)
+	assert.InDelta(t, 180, Locator("JO63").Bearing("JO62"), 0.000001)
+	assert.InDelta(t, 90, Bearing(0, 0, 0, 10), 0.000001)
+}
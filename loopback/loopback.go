@@ -0,0 +1,179 @@
+/*
+Package loopback connects the modulator of a mode with its demodulator: the text is queued in the modulator, the
+signal is rendered with an audio.Renderer, passed through the stages of a simulated channel (see package simulate)
+and written to the demodulator. The helpers are meant for tests, e.g. to validate the audio plumbing of an
+application or the robustness of a mode against the conditions of an HF channel. Package loopbacktest provides the
+assertions.
+
+	pair := loopback.PSK31(1000)
+	config := loopback.Config{Channel: []dsp.Processor{simulate.NewAWGN(0.05, nil)}}
+	received, err := loopback.Run(config, pair, "cq cq de dl1abc pse k")
+*/
+package loopback
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/dsp"
+	"github.com/ftl/digimodes/psk31"
+)
+
+// Default parameters of a loopback transmission.
+const (
+	DefaultSampleRate = 8000
+	DefaultLevel      = 0.5
+	DefaultTail       = 2 * time.Second
+	DefaultTimeout    = 10 * time.Minute
+)
+
+// ErrTimeout is returned when a transmission does not end within the timeout.
+var ErrTimeout = errors.New("loopback: transmission timeout")
+
+// Modulator is the interface of the modulators that can be looped back. The text is queued as a whole transmission,
+// see audio.Queuer.
+type Modulator interface {
+	audio.Queuer
+	io.Closer
+	audio.Modulator
+}
+
+// Demodulator is the interface of the demodulators that receive the looped back signal. If the demodulator also
+// has a Flush method, it is called after the tail of the transmission to decode the pending character.
+type Demodulator interface {
+	WriteSamples(samples []float64) (int, error)
+}
+
+// Config of a loopback transmission. Zero values are replaced by the defaults.
+type Config struct {
+	// SampleRate of the rendered audio in Hz.
+	SampleRate int
+	// Level of the rendered signal in the range (0.0, 1.0].
+	Level float64
+	// Channel contains the stages that are applied to the rendered audio in the given order, e.g. simulate.AWGN.
+	Channel []dsp.Processor
+	// Tail is the duration that is rendered after the end of the transmission to flush the demodulator. The tail
+	// also passes the channel, so it contains the noise of the channel.
+	Tail time.Duration
+	// Timeout is the maximum duration of the rendered transmission.
+	Timeout time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.SampleRate == 0 {
+		c.SampleRate = DefaultSampleRate
+	}
+	if c.Level == 0 {
+		c.Level = DefaultLevel
+	}
+	if c.Tail == 0 {
+		c.Tail = DefaultTail
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	return c
+}
+
+// Transmit queues the given text in the modulator and passes the rendered signal through the channel to the
+// demodulator, until the transmission and its tail are complete. The modulator is closed afterwards. The signal
+// is rendered as fast as possible, see audio.Renderer.RenderText.
+func Transmit(config Config, modulator Modulator, demodulator Demodulator, text string) error {
+	config = config.withDefaults()
+	defer modulator.Close()
+
+	renderer := audio.NewRenderer(modulator, config.SampleRate)
+	renderer.SetLevel(config.Level)
+	err := renderer.RenderText(text, config.Tail, config.Timeout, func(block []float64) error {
+		for _, stage := range config.Channel {
+			stage.Process(block)
+		}
+		_, err := demodulator.WriteSamples(block)
+		return err
+	})
+	if err == audio.ErrRenderTimeout {
+		return ErrTimeout
+	}
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := demodulator.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// Text collects the characters that are decoded by a demodulator. It is safe for concurrent use.
+type Text struct {
+	mu   sync.Mutex
+	text strings.Builder
+}
+
+// Byte adds the given character, it can be used as handler of the psk31 demodulators.
+func (t *Text) Byte(b byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.text.WriteByte(b)
+}
+
+// Rune adds the given character, it can be used as handler of the cw demodulators.
+func (t *Text) Rune(r rune) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.text.WriteRune(r)
+}
+
+// String returns the collected text.
+func (t *Text) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.text.String()
+}
+
+// Pair is the modulator of a mode together with a matching demodulator. A pair can be used for one transmission.
+type Pair struct {
+	Modulator Modulator
+	// NewDemodulator returns a new demodulator for the given sample rate that passes the decoded characters to the
+	// given text.
+	NewDemodulator func(sampleRate int, received *Text) Demodulator
+}
+
+// PSK31 returns a pair of a PSK31 modulator and demodulator at the given audio frequency.
+func PSK31(frequency float64) Pair {
+	return PSK(frequency, psk31.PSK31)
+}
+
+// PSK returns a pair of a PSK modulator and demodulator with the given symbol rate at the given audio frequency.
+func PSK(frequency float64, baud float64) Pair {
+	return Pair{
+		Modulator: psk31.NewModulatorWithRate(frequency, baud),
+		NewDemodulator: func(sampleRate int, received *Text) Demodulator {
+			return psk31.NewDemodulatorWithRate(frequency, baud, sampleRate, received.Byte)
+		},
+	}
+}
+
+// CW returns a pair of a CW modulator and demodulator at the given audio frequency and speed in WpM.
+func CW(frequency float64, wpm int) Pair {
+	return Pair{
+		Modulator: cw.NewModulator(frequency, wpm),
+		NewDemodulator: func(sampleRate int, received *Text) Demodulator {
+			return cw.NewDemodulator(frequency, sampleRate, wpm, received.Rune)
+		},
+	}
+}
+
+// Run transmits the given text with the modulator of the given pair and returns the text that was decoded by its
+// demodulator.
+func Run(config Config, pair Pair, text string) (string, error) {
+	config = config.withDefaults()
+	received := new(Text)
+	err := Transmit(config, pair.Modulator, pair.NewDemodulator(config.SampleRate, received), text)
+	return received.String(), err
+}
@@ -0,0 +1,44 @@
+package loopback
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const text = "cq cq de dl1abc dl1abc pse k"
+
+func TestRun(t *testing.T) {
+	received, err := Run(Config{}, PSK31(1000), "hello world")
+	require.NoError(t, err)
+	assert.Contains(t, received, "hello world")
+}
+
+// failingDemodulator fails after the given number of samples.
+type failingDemodulator struct {
+	samples int
+}
+
+func (d *failingDemodulator) WriteSamples(samples []float64) (int, error) {
+	d.samples -= len(samples)
+	if d.samples < 0 {
+		return 0, errTest
+	}
+	return len(samples), nil
+}
+
+var errTest = errors.New("test error")
+
+func TestTransmitDemodulatorError(t *testing.T) {
+	err := Transmit(Config{}, PSK31(1000).Modulator, &failingDemodulator{samples: DefaultSampleRate}, text)
+	assert.Equal(t, errTest, err)
+}
+
+func TestTransmitTimeout(t *testing.T) {
+	received := new(Text)
+	pair := PSK31(1000)
+	err := Transmit(Config{Timeout: 1}, pair.Modulator, pair.NewDemodulator(DefaultSampleRate, received), text)
+	assert.Equal(t, ErrTimeout, err)
+}
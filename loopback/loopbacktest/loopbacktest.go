@@ -0,0 +1,40 @@
+/*
+Package loopbacktest provides assertions for loopback transmissions in tests.
+
+	pair := loopback.PSK31(1000)
+	config := loopback.Config{Channel: []dsp.Processor{simulate.NewAWGN(0.05, nil)}}
+	loopbacktest.Assert(t, config, pair, "cq cq de dl1abc pse k")
+*/
+package loopbacktest
+
+import (
+	"testing"
+
+	"github.com/ftl/digimodes/conformance"
+	"github.com/ftl/digimodes/loopback"
+)
+
+// Assert transmits the given text with the given pair and asserts that the decoded text contains the transmitted
+// text, see AssertText. It returns true if the assertion holds.
+func Assert(t testing.TB, config loopback.Config, pair loopback.Pair, text string) bool {
+	t.Helper()
+	received, err := loopback.Run(config, pair, text)
+	if err != nil {
+		t.Errorf("loopback transmission failed: %v", err)
+		return false
+	}
+	return AssertText(t, received, text)
+}
+
+// AssertText asserts that the received text contains the transmitted text. The texts are compared like the
+// decodes of the conformance package: case insensitive and with all sequences of whitespace reduced to a single
+// space. Characters that are decoded before the receiver is synchronized do not matter. It returns true if the
+// assertion holds.
+func AssertText(t testing.TB, received, transmitted string) bool {
+	t.Helper()
+	if conformance.Match(received, transmitted) {
+		return true
+	}
+	t.Errorf("the transmitted text was not recovered\ntransmitted: %q\nreceived:    %q", transmitted, received)
+	return false
+}
@@ -0,0 +1,56 @@
+package loopbacktest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/dsp"
+	"github.com/ftl/digimodes/loopback"
+	"github.com/ftl/digimodes/simulate"
+)
+
+const text = "cq cq de dl1abc dl1abc pse k"
+
+func TestAssert(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		pair   loopback.Pair
+		config loopback.Config
+	}{
+		{"psk31", loopback.PSK31(1000), loopback.Config{}},
+		{"psk63 48kHz", loopback.PSK(1500, 62.5), loopback.Config{SampleRate: 48000}},
+		{"psk31 noise", loopback.PSK31(1000), loopback.Config{Channel: []dsp.Processor{simulate.NewAWGN(0.1, rand.New(rand.NewSource(1)))}}},
+		{"psk31 drift", loopback.PSK31(1000), loopback.Config{Channel: []dsp.Processor{simulate.NewDrift(loopback.DefaultSampleRate, 3, 0.1)}}},
+		{"cw", loopback.CW(700, 20), loopback.Config{}},
+		{"cw noise", loopback.CW(700, 25), loopback.Config{Channel: []dsp.Processor{simulate.NewAWGN(0.1, rand.New(rand.NewSource(1)))}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			Assert(t, tC.config, tC.pair, text)
+		})
+	}
+}
+
+// recordingT records the errors of an assertion.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertText(t *testing.T) {
+	recorder := &recordingT{TB: t}
+	assert.True(t, AssertText(recorder, "e t CQ CQ DE DL1ABC\n", "cq cq de dl1abc"))
+	assert.Empty(t, recorder.errors)
+
+	assert.False(t, AssertText(recorder, "CQ CQ DE DL1AB", "cq cq de dl1abc"))
+	assert.Len(t, recorder.errors, 1)
+}
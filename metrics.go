@@ -0,0 +1,24 @@
+package digimodes
+
+// Metrics describes the quality of the signal that a decoder currently receives. Applications use them to gate
+// the decoding (squelch) and to display the signal quality. Values that a decoder does not measure are zero.
+type Metrics struct {
+	// Open indicates that the squelch of the decoder is open, i.e. it decodes the received signal.
+	Open bool
+	// Quality is the mode specific signal quality from 0 (noise) to 1 (clean signal), e.g. the phase consistency of
+	// PSK, the sync quality of WSPR or the timing consistency of CW.
+	Quality float64
+	// SNR is the estimated signal to noise ratio in dB, relative to the noise in 2500 Hz bandwidth.
+	SNR float64
+	// Frequency is the audio frequency of the received signal in Hz.
+	Frequency float64
+	// IMD is the intermodulation distortion of a PSK signal in dB, measured during the idle periods.
+	IMD float64
+	// WPM is the speed of a CW signal in words per minute.
+	WPM int
+}
+
+// MetricsProvider is implemented by decoders that measure the quality of the received signal.
+type MetricsProvider interface {
+	Metrics() Metrics
+}
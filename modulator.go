@@ -0,0 +1,21 @@
+package digimodes
+
+import "io"
+
+// Modulator is the common interface of the modulators of the text modes, e.g. psk31, cw, rtty, olivia or wspr.
+//
+// Text is written to the modulator through the io.Writer interface. Write blocks until the text is transmitted.
+// Modulate is called for each sample of the audio signal with the time t in seconds and the amplitude a, frequency f
+// and phase p of the previous sample, it returns the amplitude, frequency and phase of the current sample.
+// Close aborts the transmission, blocked writers return an error. AbortWhenDone closes the modulator when the
+// given channel is closed.
+type Modulator interface {
+	io.WriteCloser
+	Modulate(t, a, f, p float64) (amplitude, frequency, phase float64)
+	AbortWhenDone(done <-chan struct{})
+
+	// Bandwidth returns the occupied bandwidth of the signal in Hz.
+	Bandwidth() float64
+	// SymbolRate returns the symbol rate of the signal in baud.
+	SymbolRate() float64
+}
@@ -0,0 +1,42 @@
+package digimodes_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/olivia"
+	"github.com/ftl/digimodes/psk31"
+	"github.com/ftl/digimodes/rtty"
+	"github.com/ftl/digimodes/wspr"
+)
+
+func TestModulators(t *testing.T) {
+	mfsk, err := olivia.NewModulator(olivia.Olivia(32, 1000), 1500)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		modulator  digimodes.Modulator
+		bandwidth  float64
+		symbolRate float64
+	}{
+		{"psk31", psk31.NewModulator(1000), 31.25, 31.25},
+		{"psk125", psk31.NewModulatorWithRate(1000, psk31.PSK125), 125, 125},
+		{"cw", cw.NewModulator(700, 20), 83.33, 16.67},
+		{"dfcw", cw.NewDFCWModulator(700, cw.QRSS3, cw.DefaultDFCWShift), 6.67, 0.33},
+		{"rtty", rtty.NewModulator(1500, rtty.DefaultStopBits), 224.54, 45.45},
+		{"olivia", mfsk, 1000, 31.25},
+		{"wspr", wspr.NewModulator(1500), 5.86, 1.46},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer tC.modulator.Close()
+			assert.InDelta(t, tC.bandwidth, tC.modulator.Bandwidth(), 0.01)
+			assert.InDelta(t, tC.symbolRate, tC.modulator.SymbolRate(), 0.01)
+		})
+	}
+}
@@ -0,0 +1,435 @@
+/*
+Package multidecoder decodes all signals of one mode within the receive passband at the same time, like the signal
+browser of fldigi. It finds the signals as peaks in the spectrum, starts a demodulator for each of them and
+reports the decoded text per frequency slot.
+*/
+package multidecoder
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/dsp"
+	"github.com/ftl/digimodes/psk31"
+)
+
+// Default parameters of the MultiDecoder.
+const (
+	DefaultLow       = 200.0
+	DefaultHigh      = 3200.0
+	DefaultSlotWidth = 100.0
+	DefaultThreshold = 15.0
+	DefaultTimeout   = 30 * time.Second
+	DefaultMaxSlots  = 30
+	DefaultHistory   = 120
+)
+
+// fftSize is the size of the analysis that finds the signals, it gives a resolution of about 2Hz at 8kHz.
+const fftSize = 4096
+
+// frameRate is the number of analyses per second.
+const frameRate = 4
+
+// smoothing is the weight of a new spectrum in the average.
+const smoothing = 0.25
+
+// minLevel is the lowest level in dBFS, it limits the levels of digital silence.
+const minLevel = -120.0
+
+// skirtLevel is the level in dB below a nearby stronger signal, where a peak is considered as a sideband.
+const skirtLevel = 20.0
+
+// ErrNoMode is returned when a MultiDecoder is configured without mode.
+var ErrNoMode = errors.New("multidecoder: no mode configured")
+
+// Demodulator is a demodulator that runs in one slot. The demodulators of the psk31 and the cw package implement
+// it. If the demodulator also implements digimodes.MetricsProvider, the slot follows its tracked frequency.
+type Demodulator interface {
+	WriteSamples(samples []float64) (int, error)
+}
+
+// Mode describes the demodulators that run in the slots. There are predefined modes for PSK31 and CW, other modes
+// can be added with their own demodulator. RTTY is not predefined, the rtty package does not provide a demodulator.
+type Mode struct {
+	// Name of the mode, e.g. "psk31".
+	Name string
+	// Bandwidth of a signal in Hz. The center of a signal is the center of the power within this bandwidth.
+	Bandwidth float64
+	// NewDemodulator returns a new demodulator for a signal at the given audio frequency and sample rate, that
+	// passes the decoded characters to the given handler.
+	NewDemodulator func(frequency float64, sampleRate int, handler func(rune)) Demodulator
+}
+
+// PSK31 is the mode of PSK31 signals.
+var PSK31 = Mode{
+	Name:      "psk31",
+	Bandwidth: 2 * psk31.PSK31,
+	NewDemodulator: func(frequency float64, sampleRate int, handler func(rune)) Demodulator {
+		return psk31.NewDemodulator(frequency, sampleRate, func(c byte) {
+			handler(rune(c))
+		})
+	},
+}
+
+// CW returns the mode of CW signals, the demodulators start with the given speed in WpM.
+func CW(wpm int) Mode {
+	return Mode{
+		Name:      "cw",
+		Bandwidth: 20,
+		NewDemodulator: func(frequency float64, sampleRate int, handler func(rune)) Demodulator {
+			return cw.NewDemodulator(frequency, sampleRate, wpm, handler)
+		},
+	}
+}
+
+// Config of a MultiDecoder. Zero values are replaced by the defaults.
+type Config struct {
+	// Mode of the signals.
+	Mode Mode
+	// SampleRate of the receive audio.
+	SampleRate int
+	// Low and High limit the passband in Hz where signals are searched.
+	Low, High float64
+	// SlotWidth is the width of a frequency slot in Hz. Each slot decodes at most one signal.
+	SlotWidth float64
+	// Threshold is the minimum level of a signal above the noise floor in dB.
+	Threshold float64
+	// Timeout is the time after which a slot without signal is released.
+	Timeout time.Duration
+	// MaxSlots limits the number of signals that are decoded at the same time.
+	MaxSlots int
+	// History is the number of recent characters that are kept for each slot.
+	History int
+	// Decoded is called with the text that was decoded in a slot. It is optional.
+	Decoded func(Decode)
+}
+
+// Decode is a piece of text that was decoded in a slot.
+type Decode struct {
+	// Slot is the index of the frequency slot, counting from the low edge of the passband.
+	Slot int
+	// Frequency is the audio frequency of the signal in Hz.
+	Frequency float64
+	// Text is the decoded text.
+	Text string
+}
+
+// Slot is the state of a frequency slot with a signal.
+type Slot struct {
+	// Index of the slot, counting from the low edge of the passband.
+	Index int
+	// Frequency is the audio frequency of the signal in Hz.
+	Frequency float64
+	// Text contains the recently decoded characters.
+	Text string
+	// Metrics of the signal, if the demodulator provides them.
+	Metrics digimodes.Metrics
+}
+
+type slot struct {
+	frequency   float64
+	demodulator Demodulator
+	pending     []rune
+	history     []rune
+	started     time.Duration
+	lastSeen    time.Duration
+}
+
+// MultiDecoder searches the receive audio for signals and decodes each of them in its own frequency slot. It
+// implements audio.Sink and dsp.Processor, the samples are not modified.
+type MultiDecoder struct {
+	config    Config
+	waterfall *dsp.Waterfall
+
+	mu      sync.Mutex
+	slots   []*slot
+	average []float64
+}
+
+// New returns a new MultiDecoder with the given configuration.
+func New(config Config) (*MultiDecoder, error) {
+	if config.Mode.NewDemodulator == nil {
+		return nil, ErrNoMode
+	}
+	if config.Low == 0 {
+		config.Low = DefaultLow
+	}
+	if config.High == 0 {
+		config.High = DefaultHigh
+	}
+	if config.SlotWidth == 0 {
+		config.SlotWidth = DefaultSlotWidth
+	}
+	if config.Threshold == 0 {
+		config.Threshold = DefaultThreshold
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxSlots == 0 {
+		config.MaxSlots = DefaultMaxSlots
+	}
+	if config.History == 0 {
+		config.History = DefaultHistory
+	}
+
+	result := &MultiDecoder{
+		config: config,
+	}
+	waterfall, err := dsp.NewWaterfall(config.SampleRate, dsp.WaterfallConfig{
+		FFTSize:   fftSize,
+		FrameRate: frameRate,
+		Low:       config.Low,
+		High:      config.High,
+	}, result.analyze)
+	if err != nil {
+		return nil, err
+	}
+	result.waterfall = waterfall
+	return result, nil
+}
+
+// SampleRate returns the sample rate of the receive audio in Hz.
+func (d *MultiDecoder) SampleRate() int {
+	return d.config.SampleRate
+}
+
+// Slots returns the slots that currently decode a signal, ordered by frequency.
+func (d *MultiDecoder) Slots() []Slot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]Slot, 0, len(d.slots))
+	for _, s := range d.slots {
+		entry := Slot{
+			Index:     d.slotIndex(s.frequency),
+			Frequency: s.frequency,
+			Text:      string(s.history),
+		}
+		if provider, ok := s.demodulator.(digimodes.MetricsProvider); ok {
+			entry.Metrics = provider.Metrics()
+			entry.Frequency = entry.Metrics.Frequency
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+	return result
+}
+
+// WriteSamples decodes the given audio samples.
+func (d *MultiDecoder) WriteSamples(samples []float64) (int, error) {
+	d.Process(samples)
+	return len(samples), nil
+}
+
+// Process decodes the given audio samples.
+func (d *MultiDecoder) Process(samples []float64) {
+	// the analysis may start new slots, they decode the samples from here on
+	d.waterfall.Process(samples)
+
+	d.mu.Lock()
+	slots := append([]*slot{}, d.slots...)
+	d.mu.Unlock()
+
+	for _, s := range slots {
+		s.demodulator.WriteSamples(samples)
+	}
+
+	d.mu.Lock()
+	var decodes []Decode
+	for _, s := range slots {
+		if len(s.pending) == 0 {
+			continue
+		}
+		frequency := d.trackedFrequency(s)
+		decodes = append(decodes, Decode{Slot: d.slotIndex(frequency), Frequency: frequency, Text: string(s.pending)})
+		s.history = append(s.history, s.pending...)
+		if len(s.history) > d.config.History {
+			s.history = append(s.history[:0], s.history[len(s.history)-d.config.History:]...)
+		}
+		s.pending = s.pending[:0]
+	}
+	d.mu.Unlock()
+
+	if d.config.Decoded == nil {
+		return
+	}
+	sort.Slice(decodes, func(i, j int) bool {
+		return decodes[i].Slot < decodes[j].Slot
+	})
+	for _, decode := range decodes {
+		d.config.Decoded(decode)
+	}
+}
+
+// analyze finds the signals in the given spectrum, starts slots for new signals and releases the slots of signals
+// that disappeared.
+func (d *MultiDecoder) analyze(frame dsp.SpectrumFrame) {
+	spectrum := d.smooth(frame)
+	floor := median(spectrum.Magnitudes)
+	threshold := floor + d.config.Threshold
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := frame.Elapsed
+
+	for _, s := range d.slots {
+		s.frequency = d.trackedFrequency(s)
+		if d.level(spectrum, s.frequency) < threshold {
+			continue
+		}
+		if provider, ok := s.demodulator.(digimodes.MetricsProvider); ok && !provider.Metrics().Open {
+			continue
+		}
+		s.lastSeen = now
+	}
+
+	for _, peak := range d.peaks(spectrum, threshold) {
+		if len(d.slots) >= d.config.MaxSlots {
+			break
+		}
+		frequency := d.center(spectrum, peak, threshold)
+		if d.occupied(frequency) {
+			continue
+		}
+		d.startSlot(frequency, now)
+	}
+
+	active := d.slots[:0]
+	for _, s := range d.slots {
+		if now-s.lastSeen <= d.config.Timeout && !d.duplicate(s) {
+			active = append(active, s)
+		}
+	}
+	d.slots = active
+}
+
+// smooth averages the levels of the spectra to reduce the variance of the noise.
+func (d *MultiDecoder) smooth(frame dsp.SpectrumFrame) dsp.SpectrumFrame {
+	if len(d.average) != len(frame.Magnitudes) {
+		d.average = append([]float64{}, frame.Magnitudes...)
+	}
+	result := frame
+	result.Magnitudes = make([]float64, len(frame.Magnitudes))
+	for i, magnitude := range frame.Magnitudes {
+		d.average[i] += smoothing * (math.Max(magnitude, minLevel) - d.average[i])
+		result.Magnitudes[i] = d.average[i]
+	}
+	return result
+}
+
+// peaks returns the bins of the local maxima above the threshold, the strongest first. Maxima that are more than
+// skirtLevel below a stronger bin within two slots are considered as the sidebands of a stronger signal.
+func (d *MultiDecoder) peaks(frame dsp.SpectrumFrame, threshold float64) []int {
+	neighbourhood := int(2 * d.config.SlotWidth / frame.BinWidth)
+	var result []int
+	for bin, magnitude := range frame.Magnitudes {
+		if magnitude < threshold {
+			continue
+		}
+		if bin > 0 && frame.Magnitudes[bin-1] > magnitude {
+			continue
+		}
+		if bin < len(frame.Magnitudes)-1 && frame.Magnitudes[bin+1] > magnitude {
+			continue
+		}
+		sideband := false
+		for i := bin - neighbourhood; i <= bin+neighbourhood; i++ {
+			if i >= 0 && i < len(frame.Magnitudes) && frame.Magnitudes[i]-magnitude > skirtLevel {
+				sideband = true
+				break
+			}
+		}
+		if !sideband {
+			result = append(result, bin)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return frame.Magnitudes[result[i]] > frame.Magnitudes[result[j]]
+	})
+	return result
+}
+
+// level returns the strongest magnitude within the bandwidth of the mode around the given frequency.
+func (d *MultiDecoder) level(frame dsp.SpectrumFrame, frequency float64) float64 {
+	result := math.Inf(-1)
+	for f := frequency - d.config.Mode.Bandwidth/2; f <= frequency+d.config.Mode.Bandwidth/2; f += frame.BinWidth {
+		if bin := frame.Bin(f); bin != -1 {
+			result = math.Max(result, frame.Magnitudes[bin])
+		}
+	}
+	return result
+}
+
+// occupied indicates if a slot already decodes the signal at the given frequency.
+func (d *MultiDecoder) occupied(frequency float64) bool {
+	index := d.slotIndex(frequency)
+	for _, s := range d.slots {
+		if d.slotIndex(s.frequency) == index || math.Abs(s.frequency-frequency) < d.config.Mode.Bandwidth {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicate indicates if the demodulator of the given slot was pulled to the signal of an older slot.
+func (d *MultiDecoder) duplicate(s *slot) bool {
+	for _, other := range d.slots {
+		if other != s && other.started < s.started && math.Abs(other.frequency-s.frequency) < d.config.Mode.Bandwidth/2 {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *MultiDecoder) trackedFrequency(s *slot) float64 {
+	if provider, ok := s.demodulator.(digimodes.MetricsProvider); ok {
+		return provider.Metrics().Frequency
+	}
+	return s.frequency
+}
+
+func (d *MultiDecoder) startSlot(frequency float64, now time.Duration) {
+	s := &slot{
+		frequency: frequency,
+		started:   now,
+		lastSeen:  now,
+	}
+	s.demodulator = d.config.Mode.NewDemodulator(frequency, d.config.SampleRate, func(r rune) {
+		// the demodulators run within Process, which collects the pending characters
+		s.pending = append(s.pending, r)
+	})
+	d.slots = append(d.slots, s)
+}
+
+// center returns the center of the power above the threshold within the bandwidth of the mode around the given
+// peak.
+func (d *MultiDecoder) center(frame dsp.SpectrumFrame, peak int, threshold float64) float64 {
+	width := int(math.Ceil(d.config.Mode.Bandwidth / 2 / frame.BinWidth))
+	var sum, weighted float64
+	for bin := peak - width; bin <= peak+width; bin++ {
+		if bin < 0 || bin >= len(frame.Magnitudes) || frame.Magnitudes[bin] < threshold {
+			continue
+		}
+		power := math.Pow(10, frame.Magnitudes[bin]/10)
+		sum += power
+		weighted += power * frame.Frequency(bin)
+	}
+	return weighted / sum
+}
+
+func (d *MultiDecoder) slotIndex(frequency float64) int {
+	return int((frequency - d.config.Low) / d.config.SlotWidth)
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
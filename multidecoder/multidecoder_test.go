@@ -0,0 +1,137 @@
+package multidecoder
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/psk31"
+)
+
+const sampleRate = 8000
+
+type modulator interface {
+	io.WriteCloser
+	audio.Modulator
+}
+
+// modulate renders the given text with a PSK31 Modulator at the given frequency.
+func modulate(t *testing.T, text string, frequency float64) []float64 {
+	return modulateWith(t, psk31.NewModulator(frequency), text)
+}
+
+// modulateWith renders the given text with the given modulator, followed by the tail of the transmission.
+func modulateWith(t *testing.T, m modulator, text string) []float64 {
+	defer m.Close()
+	result := make([]float64, 0, 10*sampleRate)
+	err := audio.NewRenderer(m, sampleRate).RenderText(text, 500*time.Millisecond, time.Minute, func(block []float64) error {
+		result = append(result, block...)
+		return nil
+	})
+	require.NoError(t, err)
+	return result
+}
+
+// mix adds the given signals with some noise.
+func mix(signals ...[]float64) []float64 {
+	length := 0
+	for _, signal := range signals {
+		if len(signal) > length {
+			length = len(signal)
+		}
+	}
+	random := rand.New(rand.NewSource(1))
+	result := make([]float64, length+sampleRate)
+	for i := range result {
+		result[i] = 0.01 * random.NormFloat64()
+	}
+	for _, signal := range signals {
+		for i, x := range signal {
+			result[i] += 0.3 * x
+		}
+	}
+	return result
+}
+
+func TestMultiDecoder(t *testing.T) {
+	const text1 = "cq cq de dl1abc dl1abc pse k"
+	const text2 = "dl1abc de dl2xyz k"
+	samples := mix(modulate(t, text1, 1050), modulate(t, text2, 1550))
+
+	decoded := make(map[int]*strings.Builder)
+	decoder, err := New(Config{
+		Mode:       PSK31,
+		SampleRate: sampleRate,
+		Decoded: func(decode Decode) {
+			if _, ok := decoded[decode.Slot]; !ok {
+				decoded[decode.Slot] = &strings.Builder{}
+			}
+			decoded[decode.Slot].WriteString(decode.Text)
+		},
+	})
+	require.NoError(t, err)
+	for i := 0; i < len(samples); i += 256 {
+		end := i + 256
+		if end > len(samples) {
+			end = len(samples)
+		}
+		decoder.Process(samples[i:end])
+	}
+
+	slots := decoder.Slots()
+	require.Len(t, slots, 2)
+	assert.Equal(t, 8, slots[0].Index)
+	assert.InDelta(t, 1050, slots[0].Frequency, 2)
+	assert.Contains(t, slots[0].Text, "dl1abc pse k")
+	assert.Contains(t, decoded[8].String(), "dl1abc pse k")
+	assert.Equal(t, 13, slots[1].Index)
+	assert.InDelta(t, 1550, slots[1].Frequency, 2)
+	assert.Contains(t, slots[1].Text, "dl2xyz k")
+	assert.Contains(t, decoded[13].String(), "dl2xyz k")
+}
+
+func TestMultiDecoderCW(t *testing.T) {
+	samples := mix(modulateWith(t, cw.NewModulator(750, 20), "cq cq de dl1abc dl1abc pse k"))
+
+	decoder, err := New(Config{
+		Mode:       CW(20),
+		SampleRate: sampleRate,
+	})
+	require.NoError(t, err)
+	_, err = decoder.WriteSamples(samples)
+	require.NoError(t, err)
+
+	slots := decoder.Slots()
+	require.Len(t, slots, 1)
+	assert.Equal(t, 5, slots[0].Index)
+	assert.InDelta(t, 750, slots[0].Frequency, 5)
+	assert.Contains(t, strings.ToLower(slots[0].Text), "dl1abc pse k")
+}
+
+func TestMultiDecoderReleasesSlots(t *testing.T) {
+	samples := mix(modulate(t, "test test", 1250), make([]float64, 6*sampleRate))
+
+	decoder, err := New(Config{
+		Mode:       PSK31,
+		SampleRate: sampleRate,
+		Timeout:    time.Second,
+	})
+	require.NoError(t, err)
+	decoder.Process(samples[:sampleRate])
+	assert.Len(t, decoder.Slots(), 1)
+
+	decoder.Process(samples[sampleRate:])
+	assert.Empty(t, decoder.Slots())
+}
+
+func TestMultiDecoderWithoutMode(t *testing.T) {
+	_, err := New(Config{SampleRate: sampleRate})
+	assert.Equal(t, ErrNoMode, err)
+}
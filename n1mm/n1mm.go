@@ -0,0 +1,236 @@
+/*
+Package n1mm emits the UDP XML broadcasts of N1MM Logger+ for contacts and spots, which are also understood by
+DXLog and other contest loggers. With it, the decodes and completed QSOs of this package appear automatically in
+the contest logger on the LAN.
+*/
+package n1mm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/dxcluster"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+// DefaultAddress is the default address of the N1MM Logger+ broadcasts.
+const DefaultAddress = "127.0.0.1:12060"
+
+// DefaultApp is the application name in the datagrams.
+const DefaultApp = "digimodes"
+
+const (
+	contactTimestampLayout = "2006-01-02 15:04:05"
+	spotTimestampLayout    = "2006/01/02 15:04:05"
+)
+
+// n1mmBands maps the band names to the band values of N1MM Logger+ (MHz).
+var n1mmBands = map[bandplan.BandName]string{
+	"2200m": "0.136",
+	"630m":  "0.472",
+	"160m":  "1.8",
+	"80m":   "3.5",
+	"60m":   "5",
+	"40m":   "7",
+	"30m":   "10",
+	"20m":   "14",
+	"17m":   "18",
+	"15m":   "21",
+	"12m":   "24",
+	"10m":   "28",
+	"6m":    "50",
+	"2m":    "144",
+}
+
+// Contact is a completed QSO.
+type Contact struct {
+	Time        time.Time
+	ContestName string
+	MyCall      string
+	Operator    string
+	Call        string
+	Mode        string
+	// RXFrequency in Hz.
+	RXFrequency float64
+	// TXFrequency in Hz.
+	TXFrequency float64
+	Sent        string
+	Received    string
+	Gridsquare  string
+	Comment     string
+	// ID uniquely identifies the contact. It is generated if empty.
+	ID string
+}
+
+// ContactFromQSO returns the contact of the given QSO that was completed by the sequencer on the given RF
+// frequency in Hz.
+func ContactFromQSO(qso sequencer.QSO, myCall string, frequency float64) Contact {
+	return Contact{
+		Time:        qso.End,
+		MyCall:      strings.ToUpper(myCall),
+		Call:        qso.Call,
+		Mode:        strings.ToUpper(qso.Mode),
+		RXFrequency: frequency,
+		TXFrequency: frequency,
+		Sent:        sequencer.FormatReport(qso.SentReport),
+		Received:    sequencer.FormatReport(qso.ReceivedReport),
+		Gridsquare:  qso.Grid,
+	}
+}
+
+type contactInfo struct {
+	XMLName       xml.Name `xml:"contactinfo"`
+	App           string   `xml:"app"`
+	ContestName   string   `xml:"contestname"`
+	ContestNr     string   `xml:"contestnr"`
+	Timestamp     string   `xml:"timestamp"`
+	MyCall        string   `xml:"mycall"`
+	Band          string   `xml:"band"`
+	RXFreq        int64    `xml:"rxfreq"`
+	TXFreq        int64    `xml:"txfreq"`
+	Operator      string   `xml:"operator"`
+	Mode          string   `xml:"mode"`
+	Call          string   `xml:"call"`
+	Snt           string   `xml:"snt"`
+	Rcv           string   `xml:"rcv"`
+	Gridsquare    string   `xml:"gridsquare"`
+	Comment       string   `xml:"comment"`
+	RadioNr       int      `xml:"radionr"`
+	IsOriginal    string   `xml:"IsOriginal"`
+	StationName   string   `xml:"StationName"`
+	ID            string   `xml:"ID"`
+	IsClaimedQso  int      `xml:"IsClaimedQso"`
+	NetworkedComp int      `xml:"NetworkedCompNr"`
+}
+
+type spotInfo struct {
+	XMLName     xml.Name `xml:"spot"`
+	App         string   `xml:"app"`
+	StationName string   `xml:"StationName"`
+	DXCall      string   `xml:"dxcall"`
+	Frequency   string   `xml:"frequency"`
+	SpotterCall string   `xml:"spottercall"`
+	Comment     string   `xml:"comment"`
+	Action      string   `xml:"action"`
+	Mode        string   `xml:"mode"`
+	Timestamp   string   `xml:"timestamp"`
+}
+
+// Emitter sends the broadcasts. Each broadcast is written with a single call to Write of the underlying writer,
+// e.g. a UDP socket.
+type Emitter struct {
+	w       io.Writer
+	app     string
+	station string
+
+	mu     sync.Mutex
+	nextID uint64
+}
+
+// Dial returns a new Emitter that sends the broadcasts to the given UDP address.
+func Dial(address string, station string) (*Emitter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewEmitter(conn, DefaultApp, station), nil
+}
+
+// NewEmitter returns a new Emitter that writes to the given writer. The app and station names identify the
+// sender in the datagrams.
+func NewEmitter(w io.Writer, app, station string) *Emitter {
+	return &Emitter{
+		w:       w,
+		app:     app,
+		station: station,
+		nextID:  uint64(time.Now().UnixNano()),
+	}
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (e *Emitter) Close() error {
+	if closer, ok := e.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Contact sends the given contact.
+func (e *Emitter) Contact(contact Contact) error {
+	id := contact.ID
+	if id == "" {
+		id = e.newID()
+	}
+	return e.send(contactInfo{
+		App:         e.app,
+		ContestName: contact.ContestName,
+		Timestamp:   contact.Time.UTC().Format(contactTimestampLayout),
+		MyCall:      contact.MyCall,
+		Band:        band(contact.RXFrequency),
+		RXFreq:      tensOfHz(contact.RXFrequency),
+		TXFreq:      tensOfHz(contact.TXFrequency),
+		Operator:    contact.Operator,
+		Mode:        contact.Mode,
+		Call:        contact.Call,
+		Snt:         contact.Sent,
+		Rcv:         contact.Received,
+		Gridsquare:  contact.Gridsquare,
+		Comment:     contact.Comment,
+		RadioNr:     1,
+		IsOriginal:  "True",
+		StationName: e.station,
+		ID:          id,
+	})
+}
+
+// Spot sends the given spot in the given mode.
+func (e *Emitter) Spot(spot dxcluster.Spot, mode string) error {
+	return e.send(spotInfo{
+		App:         e.app,
+		StationName: e.station,
+		DXCall:      spot.DXCall,
+		Frequency:   fmt.Sprintf("%.2f", spot.Frequency/1000),
+		SpotterCall: spot.Spotter,
+		Comment:     spot.Comment,
+		Action:      "add",
+		Mode:        strings.ToUpper(mode),
+		Timestamp:   spot.Time.UTC().Format(spotTimestampLayout),
+	})
+}
+
+func (e *Emitter) send(v interface{}) error {
+	content, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	datagram := append([]byte(xml.Header), content...)
+	_, err = e.w.Write(datagram)
+	return err
+}
+
+func (e *Emitter) newID() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextID++
+	return fmt.Sprintf("%032x", e.nextID)
+}
+
+func band(frequency float64) string {
+	for _, region := range []bandplan.Region{bandplan.Region1, bandplan.Region2, bandplan.Region3} {
+		b, ok := bandplan.BandOf(region, bandplan.Frequency(frequency))
+		if ok {
+			return n1mmBands[b.Name]
+		}
+	}
+	return ""
+}
+
+func tensOfHz(frequency float64) int64 {
+	return int64(frequency/10 + 0.5)
+}
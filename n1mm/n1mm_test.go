@@ -0,0 +1,102 @@
+package n1mm
+
+import (
+	"encoding/xml"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/dxcluster"
+	"github.com/ftl/digimodes/sequencer"
+)
+
+type datagrams []string
+
+func (d *datagrams) Write(p []byte) (int, error) {
+	*d = append(*d, string(p))
+	return len(p), nil
+}
+
+func TestContact(t *testing.T) {
+	var sent datagrams
+	e := NewEmitter(&sent, "test", "shack")
+
+	qso := sequencer.QSO{
+		Mode:           "ft8",
+		Call:           "W1AW",
+		Grid:           "FN31",
+		SentReport:     -10,
+		ReceivedReport: 3,
+		End:            time.Date(2020, 5, 1, 12, 34, 56, 0, time.UTC),
+	}
+	require.NoError(t, e.Contact(ContactFromQSO(qso, "dl1abc", 14075234)))
+	require.Len(t, sent, 1)
+	assert.True(t, strings.HasPrefix(sent[0], xml.Header))
+
+	var actual contactInfo
+	require.NoError(t, xml.Unmarshal([]byte(sent[0]), &actual))
+	assert.Equal(t, "test", actual.App)
+	assert.Equal(t, "shack", actual.StationName)
+	assert.Equal(t, "2020-05-01 12:34:56", actual.Timestamp)
+	assert.Equal(t, "DL1ABC", actual.MyCall)
+	assert.Equal(t, "W1AW", actual.Call)
+	assert.Equal(t, "FT8", actual.Mode)
+	assert.Equal(t, "14", actual.Band)
+	assert.Equal(t, int64(1407523), actual.RXFreq)
+	assert.Equal(t, int64(1407523), actual.TXFreq)
+	assert.Equal(t, "-10", actual.Snt)
+	assert.Equal(t, "+03", actual.Rcv)
+	assert.Equal(t, "FN31", actual.Gridsquare)
+	assert.Len(t, actual.ID, 32)
+
+	require.NoError(t, e.Contact(Contact{Call: "K1A"}))
+	var second contactInfo
+	require.NoError(t, xml.Unmarshal([]byte(sent[1]), &second))
+	assert.NotEqual(t, actual.ID, second.ID)
+	assert.Equal(t, "", second.Band)
+}
+
+func TestSpot(t *testing.T) {
+	var sent datagrams
+	e := NewEmitter(&sent, "test", "shack")
+
+	spot := dxcluster.Spot{Spotter: "DL1ABC", Frequency: 7074500, DXCall: "W1AW", Comment: "FT8 -12dB", Time: time.Date(2020, 5, 1, 12, 34, 0, 0, time.UTC)}
+	require.NoError(t, e.Spot(spot, "ft8"))
+
+	var actual spotInfo
+	require.NoError(t, xml.Unmarshal([]byte(sent[0]), &actual))
+	assert.Equal(t, spotInfo{
+		XMLName:     xml.Name{Local: "spot"},
+		App:         "test",
+		StationName: "shack",
+		DXCall:      "W1AW",
+		Frequency:   "7074.50",
+		SpotterCall: "DL1ABC",
+		Comment:     "FT8 -12dB",
+		Action:      "add",
+		Mode:        "FT8",
+		Timestamp:   "2020/05/01 12:34:00",
+	}, actual)
+}
+
+func TestDial(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	e, err := Dial(listener.LocalAddr().String(), "shack")
+	require.NoError(t, err)
+	defer e.Close()
+	require.NoError(t, e.Contact(Contact{Call: "W1AW"}))
+
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	buffer := make([]byte, 4096)
+	n, _, err := listener.ReadFrom(buffer)
+	require.NoError(t, err)
+	assert.Contains(t, string(buffer[:n]), "<call>W1AW</call>")
+	assert.Contains(t, string(buffer[:n]), "<app>digimodes</app>")
+}
@@ -0,0 +1,138 @@
+package netaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+const (
+	frameHeaderSize = 4
+	// maxFrameSize limits the size of a received frame to protect against corrupt streams.
+	maxFrameSize = 1 << 20
+)
+
+// ErrInvalidFrame is returned when a received frame is too large or does not contain complete samples.
+var ErrInvalidFrame = errors.New("netaudio: invalid frame")
+
+// FrameSink is an audio.Sink that writes the samples in length-prefixed frames to a stream, e.g. a TCP connection.
+// Each frame consists of the payload size in bytes as big endian uint32, followed by the encoded samples.
+type FrameSink struct {
+	w          io.Writer
+	sampleRate int
+	format     audio.SampleFormat
+	buffer     []byte
+}
+
+// NewFrameSink returns a new FrameSink that writes samples with the given rate and format to the given writer.
+func NewFrameSink(w io.Writer, sampleRate int, format audio.SampleFormat) *FrameSink {
+	return &FrameSink{
+		w:          w,
+		sampleRate: sampleRate,
+		format:     format,
+	}
+}
+
+// SampleRate returns the sample rate of the sink in Hz.
+func (s *FrameSink) SampleRate() int {
+	return s.sampleRate
+}
+
+// WriteSamples writes the given samples as one or more frames.
+func (s *FrameSink) WriteSamples(samples []float64) (int, error) {
+	maxSamples := maxFrameSize / s.format.Size()
+	written := 0
+	for len(samples) > 0 {
+		n := len(samples)
+		if n > maxSamples {
+			n = maxSamples
+		}
+		size := n * s.format.Size()
+		if cap(s.buffer) < frameHeaderSize+size {
+			s.buffer = make([]byte, frameHeaderSize+size)
+		}
+		frame := s.buffer[:frameHeaderSize+size]
+		binary.BigEndian.PutUint32(frame, uint32(size))
+		audio.Encode(s.format, frame[frameHeaderSize:], samples[:n])
+
+		_, err := s.w.Write(frame)
+		if err != nil {
+			return written, err
+		}
+		written += n
+		samples = samples[n:]
+	}
+	return written, nil
+}
+
+// FrameSource is an audio.Source that reads the samples from length-prefixed frames written by a FrameSink.
+type FrameSource struct {
+	r          io.Reader
+	sampleRate int
+	format     audio.SampleFormat
+
+	header  [frameHeaderSize]byte
+	buffer  []byte
+	pending []float64
+	samples []float64
+}
+
+// NewFrameSource returns a new FrameSource that reads samples with the given rate and format from the given reader.
+func NewFrameSource(r io.Reader, sampleRate int, format audio.SampleFormat) *FrameSource {
+	return &FrameSource{
+		r:          r,
+		sampleRate: sampleRate,
+		format:     format,
+	}
+}
+
+// SampleRate returns the sample rate of the source in Hz.
+func (s *FrameSource) SampleRate() int {
+	return s.sampleRate
+}
+
+// ReadSamples reads samples from the received frames. It returns io.EOF when the stream ends between two frames
+// and io.ErrUnexpectedEOF when it ends within a frame.
+func (s *FrameSource) ReadSamples(samples []float64) (int, error) {
+	for len(s.pending) == 0 {
+		err := s.readFrame()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(samples, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *FrameSource) readFrame() error {
+	_, err := io.ReadFull(s.r, s.header[:])
+	if err != nil {
+		return err
+	}
+	size := int(binary.BigEndian.Uint32(s.header[:]))
+	if size > maxFrameSize || size%s.format.Size() != 0 {
+		return ErrInvalidFrame
+	}
+	if cap(s.buffer) < size {
+		s.buffer = make([]byte, size)
+	}
+	payload := s.buffer[:size]
+	_, err = io.ReadFull(s.r, payload)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+
+	count := size / s.format.Size()
+	if cap(s.samples) < count {
+		s.samples = make([]float64, count)
+	}
+	s.pending = s.samples[:count]
+	audio.Decode(s.format, s.pending, payload)
+	return nil
+}
@@ -0,0 +1,135 @@
+package netaudio
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// packets collects each written packet and returns them one by one on Read.
+type packets [][]byte
+
+func (p *packets) Write(packet []byte) (int, error) {
+	*p = append(*p, append([]byte{}, packet...))
+	return len(packet), nil
+}
+
+func (p *packets) Read(buffer []byte) (int, error) {
+	if len(*p) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(buffer, (*p)[0])
+	*p = (*p)[1:]
+	return n, nil
+}
+
+func readAll(t *testing.T, source audio.Source) []float64 {
+	var result []float64
+	block := make([]float64, 3)
+	for {
+		n, err := source.ReadSamples(block)
+		result = append(result, block[:n]...)
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestRTPRoundTrip(t *testing.T) {
+	var p packets
+	sink := NewRTPSink(&p, 8000, 4)
+	n, err := sink.WriteSamples([]float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	require.Len(t, p, 2)
+	assert.Equal(t, rtpHeaderSize+8, len(p[0]))
+	assert.Equal(t, rtpHeaderSize+4, len(p[1]))
+
+	source := NewRTPSource(&p, 8000)
+	assert.InDeltaSlice(t, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}, readAll(t, source), 1e-4)
+	assert.Equal(t, 0, source.Lost())
+}
+
+func TestRTPLossAndReordering(t *testing.T) {
+	var sent packets
+	sink := NewRTPSink(&sent, 8000, 2)
+	_, err := sink.WriteSamples([]float64{0.1, 0.1, 0.2, 0.2, 0.3, 0.3, 0.4, 0.4})
+	require.NoError(t, err)
+	require.Len(t, sent, 4)
+
+	// the second packet is lost, the first packet arrives again late, garbage is ignored
+	received := packets{sent[0], sent[2], sent[0], []byte{1, 2, 3}, sent[3]}
+	source := NewRTPSource(&received, 8000)
+	assert.InDeltaSlice(t, []float64{0.1, 0.1, 0, 0, 0.3, 0.3, 0.4, 0.4}, readAll(t, source), 1e-4)
+	assert.Equal(t, 1, source.Lost())
+}
+
+func TestRTPOverUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	conn, err := net.Dial("udp", listener.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink := NewRTPSink(conn, 8000, 0)
+	_, err = sink.WriteSamples([]float64{0.5, -0.5})
+	require.NoError(t, err)
+
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	source := NewRTPSource(packetReader{listener}, 8000)
+	samples := make([]float64, 10)
+	n, err := source.ReadSamples(samples)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0.5, -0.5}, samples[:n], 1e-4)
+}
+
+type packetReader struct {
+	conn net.PacketConn
+}
+
+func (r packetReader) Read(buffer []byte) (int, error) {
+	n, _, err := r.conn.ReadFrom(buffer)
+	return n, err
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	sink := NewFrameSink(buffer, 8000, audio.Float32)
+	_, err := sink.WriteSamples([]float64{0.1, 0.2})
+	require.NoError(t, err)
+	_, err = sink.WriteSamples([]float64{0.3, 0.4, 0.5, 0.6})
+	require.NoError(t, err)
+	assert.Equal(t, 2*frameHeaderSize+6*4, buffer.Len())
+
+	source := NewFrameSource(buffer, 8000, audio.Float32)
+	assert.InDeltaSlice(t, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}, readAll(t, source), 1e-6)
+}
+
+func TestFrameSourceInvalidFrames(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		stream   []byte
+		expected error
+	}{
+		{"truncated header", []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"truncated payload", []byte{0, 0, 0, 4, 1, 2}, io.ErrUnexpectedEOF},
+		{"incomplete sample", []byte{0, 0, 0, 3, 1, 2, 3}, ErrInvalidFrame},
+		{"too large", []byte{0xFF, 0, 0, 0}, ErrInvalidFrame},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			source := NewFrameSource(bytes.NewReader(tC.stream), 8000, audio.Int16)
+			_, err := source.ReadSamples(make([]float64, 10))
+			assert.Equal(t, tC.expected, err)
+		})
+	}
+}
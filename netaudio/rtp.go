@@ -0,0 +1,211 @@
+/*
+Package netaudio transports audio samples over the network, so that the DSP can run on a server while the device
+attached to the radio only moves audio.
+
+RTPSink and RTPSource send and receive mono L16 audio in RTP packets (RFC 3550, RFC 3551), usually over UDP.
+FrameSink and FrameSource use a simple length-prefixed framing for reliable stream connections like TCP.
+*/
+package netaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+const (
+	rtpVersion    = 2
+	rtpHeaderSize = 12
+	// DefaultPayloadType is the dynamic RTP payload type used for L16 mono audio.
+	DefaultPayloadType = 96
+	// DefaultPacketSamples is the number of samples per packet, 20ms at 12kHz.
+	DefaultPacketSamples = 240
+	// maxPacketSize is the size of the receive buffer, large enough for any UDP datagram.
+	maxPacketSize = 65536
+	// maxConcealedSamples limits the silence that is inserted for lost packets.
+	maxConcealedSamples = 1 << 16
+)
+
+// ErrInvalidPacket is returned for packets that are not valid RTP packets.
+var ErrInvalidPacket = errors.New("netaudio: invalid RTP packet")
+
+// RTPSink is an audio.Sink that sends the samples in RTP packets. Each packet is written with a single call to
+// Write of the underlying writer, e.g. a connected UDP socket.
+type RTPSink struct {
+	w             io.Writer
+	sampleRate    int
+	packetSamples int
+	payloadType   uint8
+	ssrc          uint32
+
+	sequence  uint16
+	timestamp uint32
+	buffer    []byte
+}
+
+// NewRTPSink returns a new RTPSink that sends packets with the given number of samples to the given writer.
+func NewRTPSink(w io.Writer, sampleRate int, packetSamples int) *RTPSink {
+	if packetSamples <= 0 {
+		packetSamples = DefaultPacketSamples
+	}
+	return &RTPSink{
+		w:             w,
+		sampleRate:    sampleRate,
+		packetSamples: packetSamples,
+		payloadType:   DefaultPayloadType,
+		ssrc:          rand.Uint32(),
+		sequence:      uint16(rand.Uint32()),
+		timestamp:     rand.Uint32(),
+		buffer:        make([]byte, rtpHeaderSize+2*packetSamples),
+	}
+}
+
+// SampleRate returns the sample rate of the sink in Hz.
+func (s *RTPSink) SampleRate() int {
+	return s.sampleRate
+}
+
+// WriteSamples sends the given samples. The last packet may contain less samples than configured.
+func (s *RTPSink) WriteSamples(samples []float64) (int, error) {
+	written := 0
+	for len(samples) > 0 {
+		n := len(samples)
+		if n > s.packetSamples {
+			n = s.packetSamples
+		}
+		packet := s.buffer[:rtpHeaderSize+2*n]
+		packet[0] = rtpVersion << 6
+		packet[1] = s.payloadType & 0x7F
+		binary.BigEndian.PutUint16(packet[2:], s.sequence)
+		binary.BigEndian.PutUint32(packet[4:], s.timestamp)
+		binary.BigEndian.PutUint32(packet[8:], s.ssrc)
+		for i, sample := range samples[:n] {
+			binary.BigEndian.PutUint16(packet[rtpHeaderSize+2*i:], uint16(audio.FromFloat64[int16](sample)))
+		}
+
+		_, err := s.w.Write(packet)
+		if err != nil {
+			return written, err
+		}
+		s.sequence++
+		s.timestamp += uint32(n)
+		written += n
+		samples = samples[n:]
+	}
+	return written, nil
+}
+
+// RTPSource is an audio.Source that receives samples from RTP packets. Each call to Read of the underlying reader
+// must return a single packet, e.g. from a UDP socket. Late packets are dropped, lost packets are replaced by
+// silence.
+type RTPSource struct {
+	r          io.Reader
+	sampleRate int
+
+	packet  []byte
+	pending []float64
+
+	started       bool
+	ssrc          uint32
+	nextSequence  uint16
+	nextTimestamp uint32
+	lost          int
+}
+
+// NewRTPSource returns a new RTPSource that receives packets from the given reader.
+func NewRTPSource(r io.Reader, sampleRate int) *RTPSource {
+	return &RTPSource{
+		r:          r,
+		sampleRate: sampleRate,
+		packet:     make([]byte, maxPacketSize),
+	}
+}
+
+// SampleRate returns the sample rate of the source in Hz.
+func (s *RTPSource) SampleRate() int {
+	return s.sampleRate
+}
+
+// Lost returns the number of packets that were lost so far.
+func (s *RTPSource) Lost() int {
+	return s.lost
+}
+
+// ReadSamples reads samples from the received packets. Invalid packets are skipped.
+func (s *RTPSource) ReadSamples(samples []float64) (int, error) {
+	for len(s.pending) == 0 {
+		n, err := s.r.Read(s.packet)
+		if n > 0 {
+			s.receive(s.packet[:n])
+		}
+		if err != nil && len(s.pending) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(samples, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *RTPSource) receive(packet []byte) {
+	payload, sequence, timestamp, ssrc, err := parseRTPPacket(packet)
+	if err != nil {
+		return
+	}
+	count := len(payload) / 2
+
+	if !s.started || ssrc != s.ssrc {
+		s.started = true
+		s.ssrc = ssrc
+	} else {
+		sequenceGap := int16(sequence - s.nextSequence)
+		if sequenceGap < 0 {
+			return
+		}
+		s.lost += int(sequenceGap)
+		silence := int(int32(timestamp - s.nextTimestamp))
+		if silence > maxConcealedSamples {
+			silence = maxConcealedSamples
+		}
+		if silence > 0 {
+			s.pending = append(s.pending, make([]float64, silence)...)
+		}
+	}
+	s.nextSequence = sequence + 1
+	s.nextTimestamp = timestamp + uint32(count)
+
+	for i := 0; i < count; i++ {
+		s.pending = append(s.pending, audio.ToFloat64(int16(binary.BigEndian.Uint16(payload[2*i:]))))
+	}
+}
+
+func parseRTPPacket(packet []byte) (payload []byte, sequence uint16, timestamp uint32, ssrc uint32, err error) {
+	if len(packet) < rtpHeaderSize || packet[0]>>6 != rtpVersion {
+		return nil, 0, 0, 0, ErrInvalidPacket
+	}
+	padding := packet[0]&0x20 != 0
+	extension := packet[0]&0x10 != 0
+	csrcCount := int(packet[0] & 0x0F)
+	sequence = binary.BigEndian.Uint16(packet[2:])
+	timestamp = binary.BigEndian.Uint32(packet[4:])
+	ssrc = binary.BigEndian.Uint32(packet[8:])
+
+	offset := rtpHeaderSize + 4*csrcCount
+	if extension {
+		if len(packet) < offset+4 {
+			return nil, 0, 0, 0, ErrInvalidPacket
+		}
+		offset += 4 + 4*int(binary.BigEndian.Uint16(packet[offset+2:]))
+	}
+	end := len(packet)
+	if padding && end > 0 {
+		end -= int(packet[end-1])
+	}
+	if offset > end {
+		return nil, 0, 0, 0, ErrInvalidPacket
+	}
+	return packet[offset:end], sequence, timestamp, ssrc, nil
+}
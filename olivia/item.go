@@ -0,0 +1,16 @@
+package olivia
+
+type itemKind uint8
+
+const (
+	characterItem itemKind = iota
+	endOfTransmissionItem
+	endItem
+)
+
+// item is an element of the pipeline between Write and Modulate: either an encoded character or a token.
+type item struct {
+	kind      itemKind
+	character uint8
+	token     chan struct{}
+}
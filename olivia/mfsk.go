@@ -0,0 +1,198 @@
+package olivia
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConfig is returned for a configuration with an unsupported number of tones, bandwidth or character size.
+var ErrInvalidConfig = errors.New("olivia: invalid configuration")
+
+// scrambleCode is the pseudo random sequence that scrambles the Walsh functions of a block.
+const scrambleCode = uint64(0xE257E6D0291574EC)
+
+// scrambleOffset is the offset in the scramble code between the characters of a block.
+const scrambleOffset = 13
+
+// Config describes an MFSK mode of the Olivia family. The symbol rate and the tone spacing are both the bandwidth
+// divided by the number of tones. Each block of 2^(BitsPerCharacter-1) symbols carries log2(Tones) characters.
+type Config struct {
+	Name             string
+	Tones            int
+	Bandwidth        float64
+	BitsPerCharacter int
+}
+
+// Olivia returns the configuration of Olivia with the given number of tones and bandwidth in Hz, e.g. 32 and 1000.
+// Olivia transmits 7 bit ASCII.
+func Olivia(tones int, bandwidth float64) Config {
+	return Config{Name: fmt.Sprintf("olivia-%d-%.0f", tones, bandwidth), Tones: tones, Bandwidth: bandwidth, BitsPerCharacter: 7}
+}
+
+// Contestia returns the configuration of Contestia with the given number of tones and bandwidth in Hz. Contestia
+// transmits a reduced 6 bit character set and is twice as fast as Olivia with the same tones and bandwidth.
+func Contestia(tones int, bandwidth float64) Config {
+	return Config{Name: fmt.Sprintf("contestia-%d-%.0f", tones, bandwidth), Tones: tones, Bandwidth: bandwidth, BitsPerCharacter: 6}
+}
+
+// RTTYM returns the configuration of RTTYM with the given number of tones and bandwidth in Hz. RTTYM transmits only
+// letters in 5 bit characters and is four times as fast as Olivia with the same tones and bandwidth.
+func RTTYM(tones int, bandwidth float64) Config {
+	return Config{Name: fmt.Sprintf("rttym-%d-%.0f", tones, bandwidth), Tones: tones, Bandwidth: bandwidth, BitsPerCharacter: 5}
+}
+
+// Validate checks if the configuration is supported.
+func (c Config) Validate() error {
+	if c.Tones < 2 || c.Tones > 256 || c.Tones&(c.Tones-1) != 0 {
+		return fmt.Errorf("%w: %d tones", ErrInvalidConfig, c.Tones)
+	}
+	if c.Bandwidth <= 0 {
+		return fmt.Errorf("%w: %v Hz bandwidth", ErrInvalidConfig, c.Bandwidth)
+	}
+	if c.BitsPerCharacter < 5 || c.BitsPerCharacter > 7 {
+		return fmt.Errorf("%w: %d bits per character", ErrInvalidConfig, c.BitsPerCharacter)
+	}
+	return nil
+}
+
+// SymbolRate returns the symbol rate in baud, which is also the tone spacing in Hz.
+func (c Config) SymbolRate() float64 {
+	return c.Bandwidth / float64(c.Tones)
+}
+
+// BitsPerSymbol returns the number of bits per symbol, which is also the number of characters per block.
+func (c Config) BitsPerSymbol() int {
+	result := 0
+	for tones := c.Tones; tones > 1; tones >>= 1 {
+		result++
+	}
+	return result
+}
+
+// SymbolsPerBlock returns the number of symbols of a block.
+func (c Config) SymbolsPerBlock() int {
+	return 1 << (c.BitsPerCharacter - 1)
+}
+
+// ToneFrequency returns the audio frequency of the given tone for a signal centered at the given frequency.
+func (c Config) ToneFrequency(center float64, tone int) float64 {
+	spacing := c.SymbolRate()
+	return center - c.Bandwidth/2 + spacing/2 + float64(tone)*spacing
+}
+
+// EncodeCharacter maps the given character to the character set of the configuration. Characters that are not in
+// the character set are reported as not ok.
+func (c Config) EncodeCharacter(r rune) (uint8, bool) {
+	switch c.BitsPerCharacter {
+	case 7:
+		if r < 0 || r > 127 {
+			return 0, false
+		}
+		return uint8(r), true
+	case 6:
+		if r >= 'a' && r <= 'z' {
+			r += 'A' - 'a'
+		}
+		switch {
+		case r == ' ':
+			return 59, true
+		case r == '\r':
+			return 60, true
+		case r == '\n':
+			return 0, true
+		case r == '\b':
+			return 61, true
+		case r >= 33 && r <= 90:
+			return uint8(r - 32), true
+		}
+		return 0, false
+	default:
+		if r >= 'a' && r <= 'z' {
+			r += 'A' - 'a'
+		}
+		switch {
+		case r == ' ':
+			return 29, true
+		case r == '\r':
+			return 30, true
+		case r == '\n':
+			return 0, true
+		case r == '\b':
+			return 31, true
+		case r >= 'A' && r <= 'Z':
+			return uint8(r - 'A' + 1), true
+		}
+		return 0, false
+	}
+}
+
+// EncodeBlock encodes the given characters into the tones of one block. There must be one character per bit of a
+// symbol. Each character selects a Walsh function that is scrambled and spread over the symbols of the block, the
+// resulting tone numbers are Gray coded.
+func (c Config) EncodeBlock(characters []uint8) []int {
+	tones := make([]int, c.SymbolsPerBlock())
+	c.encodeBlock(tones, make([]int, len(tones)), characters)
+	return tones
+}
+
+// encodeBlock encodes the given characters into the given tones, using the given buffer for the Walsh functions.
+func (c Config) encodeBlock(tones []int, walsh []int, characters []uint8) {
+	bitsPerSymbol := c.BitsPerSymbol()
+	symbolsPerBlock := len(tones)
+	for i := range tones {
+		tones[i] = 0
+	}
+
+	for freqBit := 0; freqBit < bitsPerSymbol; freqBit++ {
+		character := int(characters[freqBit]) & (2*symbolsPerBlock - 1)
+		for i := range walsh {
+			walsh[i] = 0
+		}
+		if character < symbolsPerBlock {
+			walsh[character] = 1
+		} else {
+			walsh[character-symbolsPerBlock] = -1
+		}
+		hadamard(walsh)
+
+		codeBit := (freqBit * scrambleOffset) & 63
+		for i := range walsh {
+			if scrambleCode&(1<<uint(codeBit)) != 0 {
+				walsh[i] = -walsh[i]
+			}
+			codeBit = (codeBit + 1) & 63
+		}
+
+		rotate := 0
+		for timeBit, value := range walsh {
+			if value < 0 {
+				bit := freqBit + rotate
+				if bit >= bitsPerSymbol {
+					bit -= bitsPerSymbol
+				}
+				tones[timeBit] |= 1 << uint(bit)
+			}
+			rotate++
+			if rotate >= bitsPerSymbol {
+				rotate -= bitsPerSymbol
+			}
+		}
+	}
+
+	for i, tone := range tones {
+		tones[i] = tone ^ (tone >> 1)
+	}
+}
+
+// hadamard transforms the given data with the fast Walsh-Hadamard transform in place. The length of the data must
+// be a power of two.
+func hadamard(data []int) {
+	for step := len(data) / 2; step > 0; step /= 2 {
+		for start := 0; start < len(data); start += 2 * step {
+			for i := start; i < start+step; i++ {
+				a, b := data[i], data[i+step]
+				data[i], data[i+step] = a+b, a-b
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package olivia
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeBlock recovers the characters from the tones of one block by correlating with all Walsh functions.
+func decodeBlock(c Config, tones []int) []uint8 {
+	bitsPerSymbol := c.BitsPerSymbol()
+	symbolsPerBlock := c.SymbolsPerBlock()
+	binary := make([]int, len(tones))
+	for i, tone := range tones {
+		for shift := tone; shift != 0; shift >>= 1 {
+			binary[i] ^= shift
+		}
+	}
+
+	result := make([]uint8, bitsPerSymbol)
+	walsh := make([]int, symbolsPerBlock)
+	for freqBit := 0; freqBit < bitsPerSymbol; freqBit++ {
+		rotate := 0
+		codeBit := (freqBit * scrambleOffset) & 63
+		for timeBit := range walsh {
+			bit := freqBit + rotate
+			if bit >= bitsPerSymbol {
+				bit -= bitsPerSymbol
+			}
+			walsh[timeBit] = 1
+			if binary[timeBit]&(1<<uint(bit)) != 0 {
+				walsh[timeBit] = -1
+			}
+			if scrambleCode&(1<<uint(codeBit)) != 0 {
+				walsh[timeBit] = -walsh[timeBit]
+			}
+			codeBit = (codeBit + 1) & 63
+			rotate++
+			if rotate >= bitsPerSymbol {
+				rotate -= bitsPerSymbol
+			}
+		}
+		hadamard(walsh)
+
+		best := 0
+		for i, value := range walsh {
+			if abs(value) > abs(walsh[best]) {
+				best = i
+			}
+		}
+		if walsh[best] < 0 {
+			best += symbolsPerBlock
+		}
+		result[freqBit] = uint8(best)
+	}
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		value Config
+		valid bool
+	}{
+		{"olivia 32/1000", Olivia(32, 1000), true},
+		{"olivia 8/250", Olivia(8, 250), true},
+		{"contestia 4/125", Contestia(4, 125), true},
+		{"rttym 256/2000", RTTYM(256, 2000), true},
+		{"too few tones", Olivia(1, 1000), false},
+		{"too many tones", Olivia(512, 1000), false},
+		{"tones not a power of two", Olivia(12, 1000), false},
+		{"no bandwidth", Olivia(32, 0), false},
+		{"invalid character size", Config{Tones: 32, Bandwidth: 1000, BitsPerCharacter: 8}, false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.value.Validate()
+			if tC.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidConfig), "%v", err)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	c := Olivia(32, 1000)
+	assert.Equal(t, "olivia-32-1000", c.Name)
+	assert.Equal(t, 31.25, c.SymbolRate())
+	assert.Equal(t, 5, c.BitsPerSymbol())
+	assert.Equal(t, 64, c.SymbolsPerBlock())
+	assert.Equal(t, 1015.625, c.ToneFrequency(1500, 0))
+	assert.Equal(t, 1984.375, c.ToneFrequency(1500, 31))
+
+	assert.Equal(t, 32, Contestia(32, 1000).SymbolsPerBlock())
+	assert.Equal(t, 16, RTTYM(32, 1000).SymbolsPerBlock())
+}
+
+func TestEncodeCharacter(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   Config
+		value    rune
+		ok       bool
+		expected uint8
+	}{
+		{"olivia letter", Olivia(32, 1000), 'a', true, 'a'},
+		{"olivia control", Olivia(32, 1000), '\n', true, '\n'},
+		{"olivia non ascii", Olivia(32, 1000), 'ä', false, 0},
+		{"contestia upper case", Contestia(32, 1000), 'A', true, 'A' - 32},
+		{"contestia lower case", Contestia(32, 1000), 'a', true, 'A' - 32},
+		{"contestia figure", Contestia(32, 1000), '5', true, '5' - 32},
+		{"contestia space", Contestia(32, 1000), ' ', true, 59},
+		{"contestia newline", Contestia(32, 1000), '\n', true, 0},
+		{"contestia unknown", Contestia(32, 1000), '{', false, 0},
+		{"rttym letter", RTTYM(32, 1000), 'b', true, 2},
+		{"rttym space", RTTYM(32, 1000), ' ', true, 29},
+		{"rttym figure", RTTYM(32, 1000), '5', false, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual, ok := tC.config.EncodeCharacter(tC.value)
+			assert.Equal(t, tC.ok, ok)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestEncodeBlock(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		config     Config
+		characters []uint8
+	}{
+		{"olivia 32/1000", Olivia(32, 1000), []uint8{'C', 'Q', ' ', 'd', 'e'}},
+		{"olivia 8/250", Olivia(8, 250), []uint8{0, 127, 64}},
+		{"contestia 16/500", Contestia(16, 500), []uint8{33, 34, 59, 0}},
+		{"rttym 4/125", RTTYM(4, 125), []uint8{31, 1}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tones := tC.config.EncodeBlock(tC.characters)
+
+			assert.Len(t, tones, tC.config.SymbolsPerBlock())
+			for _, tone := range tones {
+				assert.True(t, tone >= 0 && tone < tC.config.Tones, "tone %d", tone)
+			}
+			assert.Equal(t, tC.characters, decodeBlock(tC.config, tones))
+		})
+	}
+}
+
+func TestHadamard(t *testing.T) {
+	data := []int{1, 0, 0, 0}
+	hadamard(data)
+	assert.Equal(t, []int{1, 1, 1, 1}, data)
+
+	data = []int{0, 1, 0, 0}
+	hadamard(data)
+	assert.Equal(t, []int{1, -1, 1, -1}, data)
+
+	hadamard(data)
+	assert.Equal(t, []int{0, 4, 0, 0}, data)
+}
@@ -0,0 +1,329 @@
+/*
+Package olivia implements the Olivia MFSK mode and its variants Contestia and RTTYM.
+
+The block encoding follows the description of Olivia by Pawel Jalocha, SP9VRC.
+*/
+package olivia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
+)
+
+// window is the time in seconds to ramp the amplitude up and down at the start and the end of a transmission.
+const window = 0.005
+
+// characterBufferSize is the number of characters that can be buffered between Write and Modulate.
+const characterBufferSize = 64
+
+var ErrWriteAborted = errors.New("olivia: write aborted")
+
+// Modulator generates the signal of a mode of the Olivia family and provides the io.Writer interface. While
+// a transmission is active and there is nothing to send, the modulator sends blocks of null characters.
+type Modulator struct {
+	characters *stream.Stream[item]
+	writeLock  sync.Mutex
+
+	config     Config
+	center     float64
+	symbolTime float64
+
+	block      []uint8
+	tones      []int
+	walsh      []int
+	toneIndex  int
+	eotToken   chan struct{}
+	on         bool
+	onStart    float64
+	ending     bool
+	endToken   chan struct{}
+	frequency  float64
+	symbolEnd  float64
+	lastSymbol bool
+
+	errLock sync.Mutex
+	err     error
+}
+
+// NewModulator returns a new Modulator for the given mode configuration at the given center frequency.
+func NewModulator(config Config, frequency float64) (*Modulator, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Modulator{
+		characters: stream.New[item](characterBufferSize),
+		config:     config,
+		center:     frequency,
+		symbolTime: 1 / config.SymbolRate(),
+		block:      make([]uint8, config.BitsPerSymbol()),
+		tones:      make([]int, config.SymbolsPerBlock()),
+		walsh:      make([]int, config.SymbolsPerBlock()),
+		frequency:  frequency,
+	}, nil
+}
+
+// Config returns the configuration of the mode.
+func (m *Modulator) Config() Config {
+	return m.config
+}
+
+// Bandwidth returns the occupied bandwidth of the signal in Hz.
+func (m *Modulator) Bandwidth() float64 {
+	return m.config.Bandwidth
+}
+
+// SymbolRate returns the symbol rate of the signal in baud.
+func (m *Modulator) SymbolRate() float64 {
+	return m.config.SymbolRate()
+}
+
+// End ends the transmission after all written text is sent.
+func (m *Modulator) End() error {
+	end := make(chan struct{})
+	err := m.characters.Send(context.Background(), item{kind: endItem, token: end})
+	if err != nil {
+		return m.abortError()
+	}
+	return m.waitFor(end)
+}
+
+func (m *Modulator) Close() error {
+	m.characters.Close()
+	return nil
+}
+
+// Err returns the internal error that made the modulator stop, or nil.
+func (m *Modulator) Err() error {
+	m.errLock.Lock()
+	defer m.errLock.Unlock()
+	return m.err
+}
+
+// fail stops the modulator because of the given internal error.
+func (m *Modulator) fail(err error) {
+	m.errLock.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.errLock.Unlock()
+	m.characters.Close()
+}
+
+func (m *Modulator) abortError() error {
+	if err := m.Err(); err != nil {
+		return err
+	}
+	return ErrWriteAborted
+}
+
+func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
+	go func() {
+		select {
+		case <-done:
+			m.Close()
+		case <-m.characters.Done():
+		}
+	}()
+}
+
+// Write sends the given text. Characters that are not in the character set of the mode are dropped. Write
+// returns when the text is sent completely.
+func (m *Modulator) Write(bytes []byte) (int, error) {
+	m.writeLock.Lock()
+	err := m.writeText(bytes, false)
+	if err != nil {
+		m.writeLock.Unlock()
+		return 0, m.abortError()
+	}
+
+	eot := make(chan struct{})
+	err = m.characters.Send(context.Background(), item{kind: endOfTransmissionItem, token: eot})
+	m.writeLock.Unlock()
+	if err != nil {
+		return 0, m.abortError()
+	}
+	err = m.waitFor(eot)
+	if err != nil {
+		return 0, err
+	}
+	return len(bytes), nil
+}
+
+// Queue queues the given text like Write, followed by the end of the transmission like End, but it does not block
+// and does not wait until the text is sent. The returned channel is closed when the transmission is complete. Queue
+// implements audio.Queuer, e.g. to render a transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	err := m.writeText([]byte(text), true)
+	if err != nil {
+		return nil, m.abortError()
+	}
+	end := make(chan struct{})
+	err = m.characters.Push(item{kind: endItem, token: end})
+	if err != nil {
+		return nil, m.abortError()
+	}
+	return end, nil
+}
+
+// writeText sends the characters of the given text. If queue is set, the characters are pushed without blocking.
+// It must be called while holding the write lock.
+func (m *Modulator) writeText(bytes []byte, queue bool) error {
+	for _, r := range string(bytes) {
+		character, ok := m.config.EncodeCharacter(r)
+		if !ok {
+			continue
+		}
+		next := item{kind: characterItem, character: character}
+		var err error
+		if queue {
+			err = m.characters.Push(next)
+		} else {
+			err = m.characters.Send(context.Background(), next)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Modulator) waitFor(token chan struct{}) error {
+	select {
+	case <-token:
+		return nil
+	case <-m.characters.Done():
+		return m.abortError()
+	}
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.characters.Done())
+}
+
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if t >= m.symbolEnd {
+		err := m.nextSymbol(t)
+		if err != nil {
+			m.fail(err)
+			m.on = false
+		}
+	}
+	if !m.on {
+		return 0, m.frequency, p
+	}
+
+	amplitude = 1
+	if t-m.onStart < window {
+		amplitude = (t - m.onStart) / window
+	}
+	if m.lastSymbol && m.symbolEnd-t < window {
+		amplitude = (m.symbolEnd - t) / window
+	}
+	return amplitude, m.frequency, p
+}
+
+func (m *Modulator) nextSymbol(t float64) error {
+	if m.on && m.toneIndex < len(m.tones) {
+		m.startSymbol(t)
+		return nil
+	}
+
+	// the block is complete
+	if m.eotToken != nil {
+		close(m.eotToken)
+		m.eotToken = nil
+	}
+	if m.ending {
+		m.on = false
+		m.ending = false
+		m.lastSymbol = false
+		close(m.endToken)
+	}
+	if m.characters.Closed() {
+		m.on = false
+		return nil
+	}
+
+	count, err := m.collectBlock()
+	if err != nil {
+		return err
+	}
+	if count == 0 && !m.on {
+		if m.ending {
+			m.ending = false
+			close(m.endToken)
+		}
+		return nil
+	}
+	if count == 0 && m.ending {
+		m.on = false
+		m.ending = false
+		close(m.endToken)
+		return nil
+	}
+
+	if !m.on {
+		m.on = true
+		m.onStart = t
+		m.symbolEnd = t
+	}
+	m.config.encodeBlock(m.tones, m.walsh, m.block)
+	m.toneIndex = 0
+	m.startSymbol(t)
+	return nil
+}
+
+// collectBlock fills the next block with the available characters and null characters. It returns the number of
+// available characters.
+func (m *Modulator) collectBlock() (int, error) {
+	for i := range m.block {
+		m.block[i] = 0
+	}
+	count := 0
+	for count < len(m.block) && !m.ending {
+		next, ok := m.characters.TryReceive()
+		if !ok {
+			break
+		}
+		switch next.kind {
+		case characterItem:
+			m.block[count] = next.character
+			count++
+		case endOfTransmissionItem:
+			if count == 0 {
+				close(next.token)
+			} else {
+				m.eotToken = next.token
+			}
+		case endItem:
+			m.ending = true
+			m.endToken = next.token
+		default:
+			return 0, fmt.Errorf("olivia: unknown item kind %d", next.kind)
+		}
+	}
+	return count, nil
+}
+
+func (m *Modulator) startSymbol(t float64) {
+	// keep the timing of continuous symbols exact
+	start := m.symbolEnd
+	if t-m.symbolEnd >= m.symbolTime {
+		start = t
+	}
+	m.frequency = m.config.ToneFrequency(m.center, m.tones[m.toneIndex])
+	m.toneIndex++
+	m.symbolEnd = start + m.symbolTime
+	m.lastSymbol = m.ending && m.toneIndex == len(m.tones)
+}
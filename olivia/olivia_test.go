@@ -0,0 +1,132 @@
+package olivia
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// receiveTones samples the output of the modulator in the middle of the symbols and returns the tones. It stops
+// when the writer is done and the modulator is off.
+func receiveTones(t *testing.T, m *Modulator, done <-chan struct{}) []int {
+	config := m.Config()
+	symbolTime := 1 / config.SymbolRate()
+	var tones []int
+	wasOn := false
+	var start float64
+	for n := 0; ; n++ {
+		at := float64(n) * symbolTime / 10
+		amplitude, _, _ := m.Modulate(at, 0, 0, 0)
+		if amplitude > 0 && !wasOn {
+			wasOn = true
+			start = at
+			break
+		}
+		require.Less(t, n, 1000000, "the modulator does not start")
+	}
+	for n := 0; ; n++ {
+		at := start + (float64(n)+0.5)*symbolTime
+		amplitude, frequency, _ := m.Modulate(at, 0, 0, 0)
+		if amplitude == 0 {
+			if isDone(done) {
+				return tones
+			}
+			continue
+		}
+		tone := (frequency - config.ToneFrequency(m.center, 0)) / config.SymbolRate()
+		tones = append(tones, int(math.Round(tone)))
+	}
+}
+
+func TestModulate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   Config
+		text     string
+		expected string
+	}{
+		{"olivia", Olivia(32, 1000), "CQ de DL1ABC", "CQ de DL1ABC"},
+		{"olivia 8/250", Olivia(8, 250), "cq", "cq"},
+		{"contestia", Contestia(16, 500), "cq de dl1abc", "CQ DE DL1ABC"},
+		{"rttym", RTTYM(32, 1000), "cq de dlabc", "CQ DE DLABC"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m, err := NewModulator(tC.config, 1500)
+			require.NoError(t, err)
+			defer m.Close()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, err := m.Write([]byte(tC.text))
+				assert.NoError(t, err)
+				assert.NoError(t, m.End())
+			}()
+
+			tones := receiveTones(t, m, done)
+
+			symbolsPerBlock := tC.config.SymbolsPerBlock()
+			require.Equal(t, 0, len(tones)%symbolsPerBlock, "whole blocks")
+			var received []uint8
+			for i := 0; i < len(tones); i += symbolsPerBlock {
+				received = append(received, decodeBlock(tC.config, tones[i:i+symbolsPerBlock])...)
+			}
+			var expected []uint8
+			for _, r := range tC.expected {
+				c, ok := tC.config.EncodeCharacter(r)
+				require.True(t, ok)
+				expected = append(expected, c)
+			}
+			require.True(t, len(received) >= len(expected))
+			assert.Equal(t, expected, received[:len(expected)])
+			for _, c := range received[len(expected):] {
+				assert.Equal(t, uint8(0), c, "null characters after the text")
+			}
+		})
+	}
+}
+
+func TestNewModulatorInvalidConfig(t *testing.T) {
+	_, err := NewModulator(Olivia(3, 1000), 1500)
+	assert.Error(t, err)
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m, err := NewModulator(Olivia(32, 1000), 1500)
+	require.NoError(t, err)
+	defer m.Close()
+	go m.Write([]byte("the quick brown fox jumps over the lazy dog"))
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestInvalidItemStopsModulator(t *testing.T) {
+	m, err := NewModulator(Olivia(32, 1000), 1500)
+	require.NoError(t, err)
+	m.characters.Send(context.Background(), item{kind: itemKind(99)})
+
+	amplitude, _, _ := m.Modulate(0, 0, 0, 0)
+
+	assert.Equal(t, 0.0, amplitude)
+	assert.Error(t, m.Err())
+	_, err = m.Write([]byte("a"))
+	assert.Equal(t, m.Err(), err)
+}
@@ -0,0 +1,131 @@
+package psk31
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ftl/digimodes"
+)
+
+const (
+	// recordQueueSize is the number of records that are buffered by a RecordDecoder.
+	recordQueueSize = 16
+	// maxRecordLength is the maximum length of the text of a record, longer lines are split.
+	maxRecordLength = 80
+	// recordTimeout is the time without a received character after which a record ends.
+	recordTimeout = 2 * time.Second
+)
+
+// RecordDecoder decodes a PSK signal around a fixed audio frequency with a Demodulator and delivers the received
+// text as decode records. A record contains one line of text, it ends with a line break, when the squelch closes,
+// when no character was received for two seconds, or after maxRecordLength characters. Its time is the time when the first character was received. It
+// implements digimodes.Decoder.
+type RecordDecoder struct {
+	*digimodes.RecordQueue
+
+	demodulator *Demodulator
+	clock       *digimodes.SampleClock
+	mode        string
+	chunk       int
+
+	line     strings.Builder
+	record   digimodes.DecodeRecord
+	last     time.Time
+	complete []digimodes.DecodeRecord
+}
+
+// NewRecordDecoder returns a new RecordDecoder for a signal with the given symbol rate in baud at the given audio
+// frequency. The first sample that is fed into the decoder was taken at the given start time.
+func NewRecordDecoder(frequency float64, baud float64, sampleRate int, start time.Time) *RecordDecoder {
+	result := &RecordDecoder{
+		RecordQueue: digimodes.NewRecordQueue(recordQueueSize),
+		clock:       digimodes.NewSampleClock(start, sampleRate),
+		mode:        modeName(baud),
+		chunk:       int(math.Max(1, math.Round(float64(sampleRate)/baud))),
+	}
+	result.demodulator = NewDemodulatorWithRate(frequency, baud, sampleRate, result.receive)
+	return result
+}
+
+// modeName returns the name of the mode with the given symbol rate.
+func modeName(baud float64) string {
+	switch baud {
+	case PSK63:
+		return "psk63"
+	case PSK125:
+		return "psk125"
+	case PSK250:
+		return "psk250"
+	default:
+		return "psk31"
+	}
+}
+
+// Demodulator returns the demodulator of this decoder, e.g. to adjust the squelch or the carrier tracking.
+func (d *RecordDecoder) Demodulator() *Demodulator {
+	return d.demodulator
+}
+
+// Feed decodes the given samples. The samples are demodulated in blocks of one symbol, this is the resolution of
+// the time of the records.
+func (d *RecordDecoder) Feed(ctx context.Context, samples []float64) error {
+	if d.Closed() {
+		return digimodes.ErrDecoderClosed
+	}
+	for len(samples) > 0 {
+		n := d.chunk
+		if n > len(samples) {
+			n = len(samples)
+		}
+		d.demodulator.WriteSamples(samples[:n])
+		d.clock.Advance(n)
+		samples = samples[n:]
+
+		if d.line.Len() > 0 && (!d.demodulator.Locked() || d.clock.Now().Sub(d.last) >= recordTimeout) {
+			d.completeLine()
+		}
+		for len(d.complete) > 0 {
+			if err := d.Emit(ctx, d.complete[0]); err != nil {
+				d.complete = d.complete[:0]
+				return err
+			}
+			d.complete = d.complete[1:]
+		}
+	}
+	return nil
+}
+
+// receive is the handler of the demodulator.
+func (d *RecordDecoder) receive(c byte) {
+	if c == '\n' || c == '\r' {
+		d.completeLine()
+		return
+	}
+	if d.line.Len() == 0 {
+		d.record = digimodes.DecodeRecord{
+			Time: d.clock.Now(),
+			Mode: d.mode,
+		}
+	}
+	d.line.WriteByte(c)
+	d.last = d.clock.Now()
+	metrics := d.demodulator.Metrics()
+	d.record.AudioFrequency = metrics.Frequency
+	d.record.SNR = metrics.SNR
+	if d.line.Len() >= maxRecordLength {
+		d.completeLine()
+	}
+}
+
+// completeLine completes the record of the current line, whitespace only lines are dropped.
+func (d *RecordDecoder) completeLine() {
+	text := strings.TrimSpace(d.line.String())
+	d.line.Reset()
+	if text == "" {
+		return
+	}
+	d.record.Text = text
+	d.complete = append(d.complete, d.record)
+}
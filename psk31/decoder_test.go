@@ -0,0 +1,61 @@
+package psk31
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+func TestRecordDecoder(t *testing.T) {
+	const sampleRate = 8000
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	silence := make([]float64, sampleRate)
+	samples := append(silence, modulate(t, "CQ CQ de DL1ABC pse k\nDL1ABC DL1ABC", 1000, PSK63, sampleRate)...)
+	samples = append(samples, make([]float64, 3*sampleRate)...)
+
+	decoder := NewRecordDecoder(1000, PSK63, sampleRate, start)
+	var _ digimodes.Decoder = decoder
+	for i := 0; i < len(samples); i += 512 {
+		end := i + 512
+		if end > len(samples) {
+			end = len(samples)
+		}
+		require.NoError(t, decoder.Feed(context.Background(), samples[i:end]))
+	}
+	require.NoError(t, decoder.Close())
+
+	records := make([]digimodes.DecodeRecord, 0)
+	for record := range decoder.Records() {
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Contains(t, records[0].Text, "DL1ABC pse k")
+	assert.Equal(t, "DL1ABC DL1ABC", records[1].Text)
+	for _, record := range records {
+		assert.Equal(t, "psk63", record.Mode)
+		assert.InDelta(t, 1000, record.AudioFrequency, 1)
+		assert.Greater(t, record.SNR, 10.0)
+	}
+	assert.True(t, records[0].Time.After(start.Add(time.Second)))
+	assert.True(t, records[1].Time.After(records[0].Time))
+
+	assert.Equal(t, digimodes.ErrDecoderClosed, decoder.Feed(context.Background(), silence))
+}
+
+func TestRecordDecoderCanceled(t *testing.T) {
+	samples := modulate(t, "a\nb\nc\n", 1000, PSK63, 8000)
+	decoder := NewRecordDecoder(1000, PSK63, 8000, time.Now())
+	decoder.RecordQueue = digimodes.NewRecordQueue(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := decoder.Feed(ctx, samples)
+
+	assert.Equal(t, context.Canceled, err)
+	decoder.Close()
+}
@@ -0,0 +1,409 @@
+package psk31
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/dsp"
+)
+
+const (
+	// subSamplesPerSymbol is the resolution of the symbol timing recovery.
+	subSamplesPerSymbol = 16
+	// symbolFilterSymbols is the length of the symbol filter in symbols.
+	symbolFilterSymbols = 2
+	// DefaultTrackingRange is the maximum distance of the tracked carrier from the configured frequency in Hz.
+	DefaultTrackingRange = 25
+	// defaultAFCGain is the default gain of the carrier tracking loop per symbol.
+	defaultAFCGain = 0.05
+	// frequencyReportStep is the minimum change of the tracked frequency in Hz that is reported to the listener.
+	frequencyReportStep = 0.1
+	// timingAveraging is the weight of a new symbol in the averaged envelope of the timing recovery.
+	timingAveraging = 0.05
+	// qualityAveraging is the weight of a new symbol in the averaged signal quality.
+	qualityAveraging = 0.1
+	// DefaultSquelch is the minimum signal quality to decode characters.
+	DefaultSquelch = 0.5
+	// levelDecay is the weight of a new symbol in the decaying peak level of the signal.
+	levelDecay = 0.01
+	// minRelativeLevel is the minimum level of a symbol relative to the peak level to be decoded.
+	minRelativeLevel = 0.25
+	// imdSymbols is the length of an IMD measurement in symbols, the signal must idle for this time.
+	imdSymbols = 16
+	// imdSettleSymbols is the number of idle symbols that pass the symbol filter before the IMD is measured.
+	imdSettleSymbols = 4
+	// MinIMD is the lowest IMD that is reported in dB.
+	MinIMD = -60.0
+	// minNoiseSpan is the minimum distance from the frequency in Hz within which the noise floor is measured.
+	minNoiseSpan = 500.0
+)
+
+// Demodulator receives a PSK signal around a configured audio frequency and passes the decoded characters to
+// a handler. It tracks the carrier within a limited range around the configured frequency (AFC) and recovers the
+// symbol timing from the envelope of the signal. The carrier tracking pulls in signals that are off by up to a
+// quarter of the symbol rate, the lock bandwidth defines how fast it follows a drifting signal. It implements
+// audio.Sink.
+//
+// The signal quality is the consistency of the phase steps between the symbols, from 0 (noise) to 1 (clean
+// signal). Below the squelch level, no characters are decoded and the carrier is not tracked. The same applies to
+// symbols that are much weaker than the recent peak level, e.g. after the signal ended.
+type Demodulator struct {
+	mu sync.Mutex
+
+	sampleRate    int
+	baud          float64
+	center        float64
+	trackingRange float64
+	frequency     float64
+	afcGain       float64
+	squelch       float64
+	handler       func(byte)
+	listener      func(float64)
+	reported      float64
+
+	phase      float64
+	subPeriod  float64
+	subElapsed float64
+	subSum     complex128
+	subCount   int
+
+	filter   []float64
+	history  []complex128
+	envelope [subSamplesPerSymbol]float64
+	subIndex int
+	since    int
+	previous complex128
+	quality  complex128
+	level    float64
+
+	varicode VaricodeDecoder
+
+	// the IMD is measured with the two tones of the idle signal at ±baud/2 and the third order products at ±3baud/2
+	imdTones     [4]*dsp.Goertzel
+	imdFrequency float64
+	idleBits     int
+	imd          float64
+
+	snr *dsp.SNREstimator
+
+	buffer []float64
+}
+
+// NewDemodulator returns a new Demodulator for a PSK31 signal at the given audio frequency and sample rate. The
+// decoded characters are passed to the given handler.
+func NewDemodulator(frequency float64, sampleRate int, handler func(byte)) *Demodulator {
+	return NewDemodulatorWithRate(frequency, PSK31, sampleRate, handler)
+}
+
+// NewDemodulatorWithRate returns a new Demodulator for a signal with the given symbol rate in baud, e.g. PSK63.
+func NewDemodulatorWithRate(frequency float64, baud float64, sampleRate int, handler func(byte)) *Demodulator {
+	subRate := int(math.Round(baud * subSamplesPerSymbol))
+	filter := dsp.LowPass(baud, subRate, symbolFilterSymbols*subSamplesPerSymbol+1)
+	result := &Demodulator{
+		sampleRate:    sampleRate,
+		baud:          baud,
+		center:        frequency,
+		trackingRange: DefaultTrackingRange,
+		frequency:     frequency,
+		afcGain:       defaultAFCGain,
+		squelch:       DefaultSquelch,
+		handler:       handler,
+		subPeriod:     float64(sampleRate) / float64(subRate),
+		filter:        filter,
+		history:       make([]complex128, len(filter)),
+	}
+	low, high := noisePassband(frequency, baud)
+	result.snr = dsp.NewSNREstimator(sampleRate, low, high)
+	imdBlock := int(math.Round(imdSymbols * float64(sampleRate) / baud))
+	for i := range result.imdTones {
+		result.imdTones[i] = dsp.NewGoertzel(frequency, sampleRate, imdBlock, nil)
+	}
+	result.imdTones[len(result.imdTones)-1] = dsp.NewGoertzel(frequency, sampleRate, imdBlock, func(float64) {
+		result.measureIMD()
+	})
+	result.tuneIMD()
+	return result
+}
+
+// SampleRate returns the sample rate of the audio in Hz.
+func (d *Demodulator) SampleRate() int {
+	return d.sampleRate
+}
+
+// Frequency returns the frequency of the tracked carrier in Hz.
+func (d *Demodulator) Frequency() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.frequency
+}
+
+// SetFrequency tunes the demodulator to the given audio frequency.
+func (d *Demodulator) SetFrequency(frequency float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.center = frequency
+	d.frequency = frequency
+	d.snr.SetPassband(noisePassband(frequency, d.baud))
+}
+
+// noisePassband returns the passband around the given frequency within which the noise floor is measured. It is
+// wide enough that the signal occupies only a few bins.
+func noisePassband(frequency, baud float64) (low, high float64) {
+	span := math.Max(minNoiseSpan, 8*baud)
+	return frequency - span, frequency + span
+}
+
+// SetTrackingRange sets the maximum distance of the tracked carrier from the configured frequency in Hz, i.e. the
+// search range of the AFC. 0 disables the carrier tracking.
+func (d *Demodulator) SetTrackingRange(trackingRange float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.trackingRange = trackingRange
+	d.frequency = d.center
+}
+
+// SetLockBandwidth sets the noise bandwidth of the carrier tracking loop in Hz. A wider bandwidth follows drifting
+// signals faster, a narrower bandwidth is more stable with weak signals. The default is 1.25% of the symbol rate,
+// about 0.4Hz for PSK31.
+func (d *Demodulator) SetLockBandwidth(bandwidth float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the noise bandwidth of a first order loop that is updated once per symbol is gain * baud / 4
+	d.afcGain = math.Max(0, math.Min(1, 4*bandwidth/d.baud))
+}
+
+// LockBandwidth returns the noise bandwidth of the carrier tracking loop in Hz.
+func (d *Demodulator) LockBandwidth() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.afcGain * d.baud / 4
+}
+
+// Locked indicates if the carrier tracking is locked to a signal, i.e. the signal quality is above the squelch
+// level.
+func (d *Demodulator) Locked() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.quality != 0 && cmplx.Abs(d.quality) >= d.squelch
+}
+
+// SetFrequencyListener sets a listener that is notified about changes of the tracked carrier frequency in Hz.
+// Changes of less than 0.1Hz are not reported. The listener may be nil.
+func (d *Demodulator) SetFrequencyListener(listener func(frequency float64)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listener = listener
+	d.reported = d.frequency
+}
+
+// SetSquelch sets the minimum signal quality to decode characters, between 0 and 1.
+func (d *Demodulator) SetSquelch(squelch float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.squelch = squelch
+}
+
+// Metrics returns the current quality of the received signal. The SNR is the power within twice the symbol rate
+// around the tracked carrier relative to the noise floor around the frequency, the IMD is measured while the signal
+// idles. It implements digimodes.MetricsProvider.
+func (d *Demodulator) Metrics() digimodes.Metrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	quality := cmplx.Abs(d.quality)
+	return digimodes.Metrics{
+		Open:      d.quality != 0 && quality >= d.squelch,
+		Quality:   quality,
+		SNR:       d.snr.SNR(d.frequency, 2*d.baud),
+		Frequency: d.frequency,
+		IMD:       d.imd,
+	}
+}
+
+// tuneIMD tunes the IMD measurement to the tracked carrier.
+func (d *Demodulator) tuneIMD() {
+	d.imdFrequency = d.frequency
+	for i, tone := range d.imdTones {
+		tone.SetFrequency(d.frequency + float64(2*i-3)*d.baud/2)
+	}
+}
+
+// measureIMD is called after each block of the IMD measurement.
+func (d *Demodulator) measureIMD() {
+	idle := d.idleBits >= imdSymbols+imdSettleSymbols
+	locked := d.quality != 0 && cmplx.Abs(d.quality) >= d.squelch
+	if idle && locked {
+		products := d.imdTones[0].Magnitude() + d.imdTones[3].Magnitude()
+		tones := d.imdTones[1].Magnitude() + d.imdTones[2].Magnitude()
+		if tones > 0 {
+			d.imd = MinIMD
+			if products > 0 {
+				d.imd = math.Max(MinIMD, 20*math.Log10(products/tones))
+			}
+		}
+	}
+	if math.Abs(d.frequency-d.imdFrequency) > 0.5 {
+		d.tuneIMD()
+	}
+}
+
+// Quality returns the current signal quality between 0 and 1.
+func (d *Demodulator) Quality() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return cmplx.Abs(d.quality)
+}
+
+// WriteSamples demodulates the given audio samples.
+func (d *Demodulator) WriteSamples(samples []float64) (int, error) {
+	d.mu.Lock()
+	characters := d.demodulate(samples, nil)
+	d.mu.Unlock()
+	d.notify(characters)
+	return len(samples), nil
+}
+
+// WritePCM demodulates the given 16 bit PCM samples.
+func (d *Demodulator) WritePCM(samples []int16) (int, error) {
+	d.mu.Lock()
+	if cap(d.buffer) < len(samples) {
+		d.buffer = make([]float64, len(samples))
+	}
+	buffer := d.buffer[:len(samples)]
+	audio.Convert(buffer, samples)
+	characters := d.demodulate(buffer, nil)
+	d.mu.Unlock()
+	d.notify(characters)
+	return len(samples), nil
+}
+
+// notify passes the decoded characters to the handler and reports a changed carrier frequency to the listener.
+func (d *Demodulator) notify(characters []byte) {
+	for _, c := range characters {
+		d.handler(c)
+	}
+
+	d.mu.Lock()
+	listener := d.listener
+	frequency := d.frequency
+	changed := math.Abs(frequency-d.reported) >= frequencyReportStep
+	if changed {
+		d.reported = frequency
+	}
+	d.mu.Unlock()
+	if listener != nil && changed {
+		listener(frequency)
+	}
+}
+
+func (d *Demodulator) demodulate(samples []float64, characters []byte) []byte {
+	d.snr.Process(samples)
+	for i, x := range samples {
+		for _, tone := range d.imdTones {
+			tone.Process(samples[i : i+1])
+		}
+		d.subSum += complex(x*math.Cos(d.phase), -x*math.Sin(d.phase))
+		d.subCount++
+		d.phase += 2 * math.Pi * d.frequency / float64(d.sampleRate)
+		if d.phase > 2*math.Pi {
+			d.phase -= 2 * math.Pi
+		}
+
+		d.subElapsed++
+		if d.subElapsed < d.subPeriod {
+			continue
+		}
+		d.subElapsed -= d.subPeriod
+		subSample := d.subSum / complex(float64(d.subCount), 0)
+		d.subSum = 0
+		d.subCount = 0
+		characters = d.processSubSample(subSample, characters)
+	}
+	return characters
+}
+
+func (d *Demodulator) processSubSample(x complex128, characters []byte) []byte {
+	copy(d.history[1:], d.history)
+	d.history[0] = x
+	var y complex128
+	for k, tap := range d.filter {
+		y += complex(tap, 0) * d.history[k]
+	}
+
+	// the envelope is at its maximum in the middle of the symbols
+	magnitude := cmplx.Abs(y)
+	d.envelope[d.subIndex] = (1-timingAveraging)*d.envelope[d.subIndex] + timingAveraging*magnitude
+	peak := 0
+	for i, e := range d.envelope {
+		if e > d.envelope[peak] {
+			peak = i
+		}
+	}
+	index := d.subIndex
+	d.subIndex = (d.subIndex + 1) % subSamplesPerSymbol
+	d.since++
+	if d.since < subSamplesPerSymbol {
+		return characters
+	}
+
+	// sample once per symbol and move the sampling point towards the peak by at most one sub-sample
+	distance := (peak-index+subSamplesPerSymbol+subSamplesPerSymbol/2)%subSamplesPerSymbol - subSamplesPerSymbol/2
+	switch {
+	case distance > 0 && d.since == subSamplesPerSymbol:
+		return characters
+	case distance < 0:
+		d.since = 1
+	default:
+		d.since = 0
+	}
+	return d.processSymbol(y, characters)
+}
+
+func (d *Demodulator) processSymbol(symbol complex128, characters []byte) []byte {
+	product := symbol * cmplx.Conj(d.previous)
+	d.previous = symbol
+	if product == 0 {
+		return characters
+	}
+
+	magnitude := cmplx.Abs(symbol)
+	if magnitude > d.level {
+		d.level = magnitude
+	} else {
+		d.level = (1-levelDecay)*d.level + levelDecay*magnitude
+	}
+	if magnitude < minRelativeLevel*d.level {
+		return characters
+	}
+
+	// squaring removes the modulation, the remaining rotation is the frequency error
+	squared := product * product / complex(cmplx.Abs(product)*cmplx.Abs(product), 0)
+	d.quality = complex(1-qualityAveraging, 0)*d.quality + complex(qualityAveraging, 0)*squared
+	if cmplx.Abs(d.quality) < d.squelch {
+		return characters
+	}
+	if d.trackingRange > 0 {
+		offset := cmplx.Phase(squared) / 2 / (2 * math.Pi) * d.baud
+		d.frequency += d.afcGain * offset
+		d.frequency = math.Max(d.center-d.trackingRange, math.Min(d.center+d.trackingRange, d.frequency))
+	}
+
+	// a phase reversal is a zero
+	bit := uint16(0)
+	if real(product) > 0 {
+		bit = 1
+		d.idleBits = 0
+	} else {
+		d.idleBits++
+	}
+	return d.processBit(bit, characters)
+}
+
+func (d *Demodulator) processBit(bit uint16, characters []byte) []byte {
+	if c, ok := d.varicode.Decode(bit); ok {
+		characters = append(characters, c)
+	}
+	return characters
+}
@@ -0,0 +1,219 @@
+package psk31
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// modulate renders the given text with a Modulator at the given frequency and symbol rate.
+func modulate(t *testing.T, text string, frequency float64, baud float64, sampleRate int) []float64 {
+	return modulateWith(t, NewModulatorWithRate(frequency, baud), text, sampleRate)
+}
+
+// modulateWith renders the given text with the given Modulator.
+func modulateWith(t *testing.T, m *Modulator, text string, sampleRate int) []float64 {
+	defer m.Close()
+	result := make([]float64, 0, 10*sampleRate)
+	err := audio.NewRenderer(m, sampleRate).RenderText(text, 0, time.Minute, func(block []float64) error {
+		result = append(result, block...)
+		return nil
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestDemodulator(t *testing.T) {
+	const text = "CQ CQ de DL1ABC pse k"
+	testCases := []struct {
+		desc       string
+		frequency  float64
+		tuned      float64
+		sampleRate int
+		noise      float64
+	}{
+		{"exact", 1000, 1000, 8000, 0},
+		{"48kHz", 1500, 1500, 48000, 0},
+		{"non-integer rate", 1000, 1000, 11025, 0},
+		{"offset", 1005, 1000, 8000, 0},
+		{"negative offset", 995, 1000, 8000, 0},
+		{"noise", 1000, 1000, 8000, 0.5},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			samples := modulate(t, text, tC.frequency, PSK31, tC.sampleRate)
+			rng := rand.New(rand.NewSource(1))
+			for i := range samples {
+				samples[i] = 0.5*samples[i] + tC.noise*rng.NormFloat64()*0.1
+			}
+
+			received := &strings.Builder{}
+			demodulator := NewDemodulator(tC.tuned, tC.sampleRate, func(c byte) {
+				received.WriteByte(c)
+			})
+			assert.Equal(t, tC.sampleRate, demodulator.SampleRate())
+			for i := 0; i < len(samples); i += 512 {
+				end := i + 512
+				if end > len(samples) {
+					end = len(samples)
+				}
+				_, err := demodulator.WriteSamples(samples[i:end])
+				require.NoError(t, err)
+			}
+
+			assert.Contains(t, received.String(), "DL1ABC pse k")
+			assert.NotContains(t, received.String(), "k ", "no characters from the noise after the signal")
+			assert.InDelta(t, tC.frequency, demodulator.Frequency(), 1)
+		})
+	}
+}
+
+func TestDemodulatorWithRate(t *testing.T) {
+	const text = "CQ CQ de DL1ABC pse k"
+	testCases := []struct {
+		desc       string
+		baud       float64
+		sampleRate int
+	}{
+		{"PSK63", PSK63, 8000},
+		{"PSK125", PSK125, 8000},
+		{"PSK250", PSK250, 8000},
+		{"PSK250 48kHz", PSK250, 48000},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			samples := modulate(t, text, 1000, tC.baud, tC.sampleRate)
+
+			received := &strings.Builder{}
+			demodulator := NewDemodulatorWithRate(1000, tC.baud, tC.sampleRate, func(c byte) {
+				received.WriteByte(c)
+			})
+			_, err := demodulator.WriteSamples(samples)
+			require.NoError(t, err)
+
+			assert.Contains(t, received.String(), "DL1ABC pse k")
+		})
+	}
+}
+
+func TestDemodulatorPCM(t *testing.T) {
+	samples := modulate(t, "hello world", 1000, PSK31, 8000)
+	pcm := make([]int16, len(samples))
+	for i, s := range samples {
+		pcm[i] = int16(s * 16000)
+	}
+
+	received := &strings.Builder{}
+	demodulator := NewDemodulator(1000, 8000, func(c byte) {
+		received.WriteByte(c)
+	})
+	_, err := demodulator.WritePCM(pcm)
+	require.NoError(t, err)
+	assert.Contains(t, received.String(), "llo world")
+}
+
+func TestVaricodeLookup(t *testing.T) {
+	assert.Len(t, varicodeLookup, len(Varicode))
+	assert.Equal(t, byte('e'), varicodeLookup[0b11])
+	assert.Equal(t, byte(' '), varicodeLookup[0b1])
+	assert.Equal(t, byte('A'), varicodeLookup[0b1111101])
+}
+
+func TestDemodulatorAFC(t *testing.T) {
+	samples := modulate(t, "CQ CQ de DL1ABC pse k", 1006, PSK31, 8000)
+
+	received := &strings.Builder{}
+	var frequencies []float64
+	demodulator := NewDemodulator(1000, 8000, func(c byte) {
+		received.WriteByte(c)
+	})
+	demodulator.SetLockBandwidth(0.8)
+	assert.InDelta(t, 0.8, demodulator.LockBandwidth(), 1e-9)
+	demodulator.SetFrequencyListener(func(frequency float64) {
+		frequencies = append(frequencies, frequency)
+	})
+	assert.False(t, demodulator.Locked())
+
+	for i := 0; i < len(samples); i += 512 {
+		end := i + 512
+		if end > len(samples) {
+			end = len(samples)
+		}
+		_, err := demodulator.WriteSamples(samples[i:end])
+		require.NoError(t, err)
+	}
+
+	assert.Contains(t, received.String(), "DL1ABC pse k")
+	assert.True(t, demodulator.Locked())
+	require.NotEmpty(t, frequencies)
+	assert.InDelta(t, 1006, frequencies[len(frequencies)-1], 1)
+	for i := 1; i < len(frequencies); i++ {
+		assert.True(t, math.Abs(frequencies[i]-frequencies[i-1]) >= frequencyReportStep)
+	}
+}
+
+func TestDemodulatorAFCRange(t *testing.T) {
+	samples := modulate(t, "CQ CQ de DL1ABC pse k", 1008, PSK31, 8000)
+
+	var frequencies []float64
+	demodulator := NewDemodulator(1000, 8000, func(byte) {})
+	demodulator.SetTrackingRange(4)
+	demodulator.SetFrequencyListener(func(frequency float64) {
+		frequencies = append(frequencies, frequency)
+	})
+	_, err := demodulator.WriteSamples(samples)
+	require.NoError(t, err)
+
+	for _, frequency := range frequencies {
+		assert.True(t, frequency >= 996 && frequency <= 1004, "frequency %v", frequency)
+	}
+}
+
+func TestDemodulatorMetrics(t *testing.T) {
+	clean := modulateWith(t, NewModulator(1000, WithPreamble(100)), "hello world", 8000)
+	distorted := make([]float64, len(clean))
+	for i, x := range clean {
+		distorted[i] = x - 0.3*x*x*x
+	}
+
+	var imds []float64
+	for _, samples := range [][]float64{clean, distorted} {
+		demodulator := NewDemodulator(1000, 8000, func(byte) {})
+		assert.False(t, demodulator.Metrics().Open)
+		// the metrics are taken during the preamble
+		_, err := demodulator.WriteSamples(samples[:80*8000/32])
+		require.NoError(t, err)
+
+		metrics := demodulator.Metrics()
+		assert.True(t, metrics.Open)
+		assert.True(t, metrics.Quality > 0.9, "quality %v", metrics.Quality)
+		assert.True(t, metrics.SNR > 10, "snr %v", metrics.SNR)
+		assert.InDelta(t, 1000, metrics.Frequency, 0.5)
+		imds = append(imds, metrics.IMD)
+	}
+	assert.True(t, imds[0] < -25, "clean imd %v", imds[0])
+	assert.True(t, imds[1] > imds[0]+5 && imds[1] < -10, "distorted imd %v", imds[1])
+}
+
+func TestDemodulatorMetricsNoise(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float64, 8000*5)
+	for i := range samples {
+		samples[i] = 0.1 * rng.NormFloat64()
+	}
+	demodulator := NewDemodulator(1000, 8000, func(byte) {})
+	_, err := demodulator.WriteSamples(samples)
+	require.NoError(t, err)
+
+	metrics := demodulator.Metrics()
+	assert.False(t, metrics.Open)
+	assert.True(t, metrics.Quality < DefaultSquelch)
+	assert.Equal(t, 0.0, metrics.IMD)
+}
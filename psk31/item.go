@@ -0,0 +1,21 @@
+package psk31
+
+type itemKind uint8
+
+const (
+	bitsItem itemKind = iota
+	preambleItem
+	endOfTransmissionItem
+	endItem
+)
+
+// item is an element of the pipeline between Write and Modulate: either eight packed bits or a token. The write is
+// the number of the write that produced the item, 0 if the item does not belong to a write. Chars is the number of
+// characters that end within the packed bits.
+type item struct {
+	kind  itemKind
+	bits  uint8
+	token chan struct{}
+	write uint32
+	chars uint8
+}
@@ -0,0 +1,97 @@
+package psk31
+
+import (
+	"math"
+	"sync"
+)
+
+// MultiModulator drives several PSK modulators on different carrier frequencies and adds their signals. The sum of
+// several carriers is not a single tone, therefore it renders the audio samples itself instead of providing the
+// Modulate function. It implements audio.Source.
+type MultiModulator struct {
+	mu         sync.Mutex
+	carriers   []carrier
+	sampleRate int
+	n          int
+}
+
+type carrier struct {
+	modulator *Modulator
+	a, f, p   float64
+	phase     float64
+}
+
+// NewMultiModulator returns a new MultiModulator with a PSK31 carrier for each of the given audio frequencies, which
+// renders samples at the given sample rate.
+func NewMultiModulator(frequencies []float64, sampleRate int) *MultiModulator {
+	return NewMultiModulatorWithRate(frequencies, PSK31, sampleRate)
+}
+
+// NewMultiModulatorWithRate returns a new MultiModulator with a carrier for each of the given audio frequencies
+// with the given symbol rate in baud, which renders samples at the given sample rate.
+func NewMultiModulatorWithRate(frequencies []float64, baud float64, sampleRate int) *MultiModulator {
+	result := &MultiModulator{
+		carriers:   make([]carrier, len(frequencies)),
+		sampleRate: sampleRate,
+	}
+	for i, frequency := range frequencies {
+		result.carriers[i].modulator = NewModulatorWithRate(frequency, baud)
+	}
+	return result
+}
+
+// Carriers returns the number of carriers.
+func (m *MultiModulator) Carriers() int {
+	return len(m.carriers)
+}
+
+// Carrier returns the modulator of the carrier with the given index. The text to transmit on this carrier is
+// written to the returned modulator.
+func (m *MultiModulator) Carrier(i int) *Modulator {
+	return m.carriers[i].modulator
+}
+
+// Close closes the modulators of all carriers.
+func (m *MultiModulator) Close() error {
+	for _, c := range m.carriers {
+		c.modulator.Close()
+	}
+	return nil
+}
+
+// SampleRate returns the sample rate of the rendered audio in Hz.
+func (m *MultiModulator) SampleRate() int {
+	return m.sampleRate
+}
+
+// ReadSamples renders the next len(samples) samples of the combined signal. The signal is scaled by the number of
+// carriers, so it stays within [-1.0, 1.0].
+func (m *MultiModulator) ReadSamples(samples []float64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.carriers) == 0 {
+		for i := range samples {
+			samples[i] = 0
+		}
+		return len(samples), nil
+	}
+
+	scale := 1 / float64(len(m.carriers))
+	for i := range samples {
+		t := float64(m.n) / float64(m.sampleRate)
+		var sum float64
+		for j := range m.carriers {
+			c := &m.carriers[j]
+			c.a, c.f, c.p = c.modulator.Modulate(t, c.a, c.f, c.p)
+			sum += c.a * math.Sin(c.phase+c.p)
+
+			c.phase += 2 * math.Pi * c.f / float64(m.sampleRate)
+			if c.phase > 2*math.Pi {
+				c.phase -= 2 * math.Pi
+			}
+		}
+		samples[i] = scale * sum
+		m.n++
+	}
+	return len(samples), nil
+}
@@ -0,0 +1,65 @@
+package psk31
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiModulator(t *testing.T) {
+	const sampleRate = 8000
+	frequencies := []float64{800, 1600}
+	texts := []string{"CQ CQ de DL1ABC pse k", "QRZ de DL2XYZ k"}
+	m := NewMultiModulator(frequencies, sampleRate)
+	defer m.Close()
+	assert.Equal(t, len(frequencies), m.Carriers())
+	assert.Equal(t, sampleRate, m.SampleRate())
+
+	written := make(chan error, len(texts))
+	for i, text := range texts {
+		go func(c *Modulator, text string) {
+			_, err := c.Write([]byte(text))
+			if err == nil {
+				err = c.End()
+			}
+			written <- err
+		}(m.Carrier(i), text)
+	}
+
+	received := make([]*strings.Builder, len(frequencies))
+	demodulators := make([]*Demodulator, len(frequencies))
+	for i, frequency := range frequencies {
+		r := &strings.Builder{}
+		received[i] = r
+		demodulators[i] = NewDemodulator(frequency, sampleRate, func(c byte) {
+			r.WriteByte(c)
+		})
+	}
+
+	block := make([]float64, 512)
+	ended := 0
+	for n := 0; ended < len(texts); n += len(block) {
+		require.Less(t, n, 60*sampleRate, "the modulator does not end")
+		_, err := m.ReadSamples(block)
+		require.NoError(t, err)
+		for _, sample := range block {
+			require.LessOrEqual(t, sample, 1.0)
+			require.GreaterOrEqual(t, sample, -1.0)
+		}
+		for _, d := range demodulators {
+			d.WriteSamples(block)
+		}
+		select {
+		case err := <-written:
+			require.NoError(t, err)
+			ended++
+		default:
+		}
+	}
+
+	for i, text := range texts {
+		assert.Contains(t, received[i].String(), text[5:])
+	}
+}
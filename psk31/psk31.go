@@ -1,180 +1,559 @@
 /*
-Package psk31 implements the PSK31 digital mode.
+Package psk31 implements the PSK31 digital mode and its faster variants PSK63, PSK125 and PSK250.
 */
 package psk31
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
 )
 
 const (
+	// window and raster describe the shape of a symbol in units of 1/32 of a symbol period, the signal ramps up
+	// and down within window units at the edges of a symbol.
 	window = 10
 	raster = 32
 
-	preambleLength = 25
-	endLength      = 25
+	// DefaultPreamble and DefaultTail are the default lengths of the preamble and the tail in symbols.
+	DefaultPreamble = 25
+	DefaultTail     = 25
+)
+
+// The symbol rates of the supported variants in baud.
+const (
+	PSK31  = 31.25
+	PSK63  = 62.5
+	PSK125 = 125.0
+	PSK250 = 250.0
 )
 
 // Symbol for PSK
 type Symbol uint16
 
-// Modulator generates a PSK31 signal and provides the io.Writer interface.
+// Modulator generates a PSK31 signal and provides the io.Writer interface. By default, the modulator holds the
+// carrier with idle phase reversals between writes, like PSK programs do during typing pauses, until End is called.
+//
+// Each Write is a transmission of its own, it starts with the preamble and waits until the text is sent. For
+// interactive typing, a transmission can also be controlled explicitly: StartTransmission keys the carrier and sends
+// the preamble, WriteText queues the characters as they are typed and StopTransmission sends the tail.
 type Modulator struct {
-	symbols chan interface{}
-	packed  chan interface{}
-	closed  chan struct{}
+	packed *stream.Stream[item]
+
+	writeLock sync.Mutex
+	packer    symbolPacker
+	writes    uint32
+	streaming bool
 
 	block            block
 	blocks           *blocks
 	phaseSwitchCycle bool
 
 	carrierFrequency float64
+	baud             float64
+	idle             bool
+	latin1           bool
+
+	errLock sync.Mutex
+	err     error
 }
 
 type block interface {
 	Cycle(a, p, delta float64, phaseSwitchCycle bool) (amplitude, phase float64, needNextBlock bool)
 }
 
-func NewModulator(frequency float64) *Modulator {
+// packedBufferSize is the number of packed items that can be buffered between Write and Modulate.
+const packedBufferSize = 64
+
+// Option configures a Modulator.
+type Option func(*Modulator)
+
+// WithPreamble sets the length of the preamble, the phase reversals that are sent before the text, in symbols.
+// A short preamble gives a fast turnaround in keyboard QSOs, a long one helps the receivers to synchronize.
+func WithPreamble(symbols int) Option {
+	return func(m *Modulator) {
+		m.blocks.preambleLength = symbols
+	}
+}
+
+// WithTail sets the length of the tail, the unmodulated carrier that is sent at the end of a transmission, in symbols.
+func WithTail(symbols int) Option {
+	return func(m *Modulator) {
+		m.blocks.endLength = symbols
+	}
+}
+
+// WithIdleCarrier defines if the modulator holds the carrier with idle phase reversals between writes, which is the
+// default. Without the idle carrier, each write is a complete transmission that ends with the tail.
+func WithIdleCarrier(idle bool) Option {
+	return func(m *Modulator) {
+		m.idle = idle
+	}
+}
+
+// WithLatin1 defines if the modulator transcodes the written text from UTF-8 to ISO 8859-1 (Latin-1), like the
+// common PSK programs send umlauts and other non-ASCII characters. Characters that are not contained in Latin-1
+// are sent as '?'. By default, the written bytes are sent as they are.
+func WithLatin1(latin1 bool) Option {
+	return func(m *Modulator) {
+		m.latin1 = latin1
+	}
+}
+
+// NewModulator returns a new PSK31 Modulator for the given audio frequency.
+func NewModulator(frequency float64, options ...Option) *Modulator {
+	return NewModulatorWithRate(frequency, PSK31, options...)
+}
+
+// NewModulatorWithRate returns a new Modulator for the given audio frequency and symbol rate in baud, e.g. PSK63.
+func NewModulatorWithRate(frequency float64, baud float64, options ...Option) *Modulator {
 	result := &Modulator{
-		symbols:          make(chan interface{}),
-		packed:           make(chan interface{}),
-		closed:           make(chan struct{}),
+		packed:           stream.New[item](packedBufferSize),
 		carrierFrequency: frequency,
+		baud:             baud,
+		idle:             true,
 		blocks:           newBlocks(),
 	}
+	for _, option := range options {
+		option(result)
+	}
+	result.blocks.idle = result.idle
 	result.block = result.blocks.off(false)
-	go result.pack()
+	result.packer.pending = &result.blocks.pending
 	return result
 }
 
+// Bandwidth returns the occupied bandwidth of the signal in Hz, which is the symbol rate.
+func (m *Modulator) Bandwidth() float64 {
+	return m.baud
+}
+
+// SymbolRate returns the symbol rate of the signal in baud.
+func (m *Modulator) SymbolRate() float64 {
+	return m.baud
+}
+
+// Pending returns the number of characters that are queued, but not sent yet, and the estimated duration to send
+// them, e.g. to display the transmit buffer or to limit the type-ahead. The duration does not include the preamble
+// and the tail.
+func (m *Modulator) Pending() (chars int, duration time.Duration) {
+	chars, symbols := m.blocks.pending.get()
+	return chars, time.Duration(float64(symbols) / m.baud * float64(time.Second))
+}
+
+// pending counts the characters and symbols of the items that are queued, but not sent yet.
+type pending struct {
+	chars   int64
+	symbols int64
+}
+
+// add adds the given item to the counters with the given sign, 1 when the item is queued, -1 when it is taken.
+func (p *pending) add(i item, sign int64) {
+	if p == nil || i.kind != bitsItem {
+		return
+	}
+	// a zero byte is sent as a single bit
+	symbols := int64(8)
+	if i.bits == 0 {
+		symbols = 1
+	}
+	atomic.AddInt64(&p.chars, sign*int64(i.chars))
+	atomic.AddInt64(&p.symbols, sign*symbols)
+}
+
+func (p *pending) get() (chars int, symbols int64) {
+	return int(atomic.LoadInt64(&p.chars)), atomic.LoadInt64(&p.symbols)
+}
+
 var ErrWriteAborted = errors.New("psk31: write aborted")
 
-type preambleToken chan interface{}
-type endOfTransmissionToken chan interface{}
-type endToken chan interface{}
+// ErrNoTransmission is returned by WriteText when no transmission was started with StartTransmission.
+var ErrNoTransmission = errors.New("psk31: no transmission started")
 
 func (m *Modulator) End() error {
-	end := make(endToken)
-	m.symbols <- end
-	select {
-	case <-end:
-		return nil
-	case <-m.closed:
-		return ErrWriteAborted
+	end := make(chan struct{})
+	m.writeLock.Lock()
+	m.streaming = false
+	m.packer.write = 0
+	err := m.writeToken(context.Background(), endItem, end)
+	m.writeLock.Unlock()
+	if err != nil {
+		return m.abortError()
 	}
+	return m.waitFor(context.Background(), end)
 }
 
 func (m *Modulator) Close() error {
-	select {
-	case <-m.closed:
-	default:
-		close(m.closed)
-	}
+	m.packed.Close()
 	return nil
 }
 
+// Err returns the internal error that made the modulator stop, or nil.
+func (m *Modulator) Err() error {
+	m.errLock.Lock()
+	defer m.errLock.Unlock()
+	return m.err
+}
+
+// fail stops the modulator because of the given internal error.
+func (m *Modulator) fail(err error) {
+	m.errLock.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.errLock.Unlock()
+	m.packed.Close()
+}
+
+func (m *Modulator) abortError() error {
+	if err := m.Err(); err != nil {
+		return err
+	}
+	return ErrWriteAborted
+}
+
 func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
 	go func() {
 		select {
 		case <-done:
 			m.Close()
-		case <-m.closed:
+		case <-m.packed.Done():
 		}
 	}()
 }
 
+// Write sends the given text and returns when the text is sent completely. Outside of a transmission that was
+// started with StartTransmission, the text is sent with the preamble, and without the idle carrier also with the tail.
 func (m *Modulator) Write(bytes []byte) (int, error) {
-	m.symbols <- make(preambleToken)
+	return m.WriteContext(context.Background(), bytes)
+}
+
+// StartTransmission starts a transmission that lasts until StopTransmission is called: the carrier is keyed and the
+// preamble is sent, between the texts written with WriteText the carrier is held with idle phase reversals. It
+// returns when the preamble is queued.
+func (m *Modulator) StartTransmission(ctx context.Context) error {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	if m.streaming {
+		return nil
+	}
+	m.writes++
+	m.packer.write = m.writes
+	err := m.writeToken(ctx, preambleItem, make(chan struct{}))
+	if err != nil {
+		return m.writeError(ctx)
+	}
+	m.streaming = true
+	return nil
+}
+
+// WriteText queues the given text for the transmission that was started with StartTransmission, e.g. the characters
+// as they are typed. Unlike Write, it does not wait until the text is sent, it only blocks while the buffer of the
+// modulator is full. If the given context is done before the text is queued completely, WriteText returns the error
+// of the context and the text of the transmission that is not sent yet is dropped.
+func (m *Modulator) WriteText(ctx context.Context, bytes []byte) (int, error) {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	if !m.streaming {
+		return 0, ErrNoTransmission
+	}
+	m.packer.write = m.writes
 
 	n := 0
-	for _, b := range bytes {
-		select {
-		case m.symbols <- Varicode[b&0x7F]:
-			n++
-		case <-m.closed:
-			return n, ErrWriteAborted
+	for n < len(bytes) {
+		c, size := m.nextCharacter(bytes[n:])
+		err := m.packer.Pack(ctx, m.packed, Varicode[c])
+		if err != nil {
+			return n, m.writeError(ctx)
 		}
+		n += size
+	}
+	// the characters are sent right away, the padding bits are idle phase reversals
+	err := m.packer.Flush(ctx, m.packed)
+	if err != nil {
+		return n, m.writeError(ctx)
 	}
+	return n, nil
+}
 
-	eot := make(endOfTransmissionToken)
-	m.symbols <- eot
-	select {
-	case <-eot:
-		return n, nil
-	case <-m.closed:
-		return n, ErrWriteAborted
+// StopTransmission ends the transmission that was started with StartTransmission: the queued text is sent, followed
+// by the tail. It returns when the transmission is complete, or with the error of the given context if the context
+// is done before.
+func (m *Modulator) StopTransmission(ctx context.Context) error {
+	end := make(chan struct{})
+	m.writeLock.Lock()
+	if !m.streaming {
+		m.writeLock.Unlock()
+		return nil
+	}
+	m.streaming = false
+	m.packer.write = m.writes
+	err := m.writeToken(ctx, endItem, end)
+	if err != nil {
+		err = m.writeError(ctx)
+		m.writeLock.Unlock()
+		return err
 	}
+	m.writeLock.Unlock()
+	return m.waitFor(ctx, end)
 }
 
-func (m *Modulator) pack() {
-	packer := symbolPacker{}
+// WriteContext sends the given text like Write. If the given context is done before the text is sent completely,
+// WriteContext returns the error of the context and the rest of the text is dropped. The modulator stays usable,
+// the carrier is handled like at the end of a completed write. If the write is canceled or aborted, the returned
+// count is the number of bytes whose characters were actually transmitted, the dropped rest does not count.
+func (m *Modulator) WriteContext(ctx context.Context, bytes []byte) (int, error) {
+	m.writeLock.Lock()
+	m.writes++
+	m.packer.write = m.writes
+	write := m.writes
+	var err error
+	if !m.streaming {
+		err = m.writeToken(ctx, preambleItem, make(chan struct{}))
+	}
+	if err != nil {
+		m.writeLock.Unlock()
+		return 0, m.writeError(ctx)
+	}
+
+	n := 0
+	for n < len(bytes) {
+		c, size := m.nextCharacter(bytes[n:])
+		err := m.packer.Pack(ctx, m.packed, Varicode[c])
+		if err != nil {
+			err = m.writeError(ctx)
+			m.writeLock.Unlock()
+			return m.sentBytes(bytes, write), err
+		}
+		n += size
+	}
+
+	eot := make(chan struct{})
+	if m.idle || m.streaming {
+		err = m.writeToken(ctx, endOfTransmissionItem, eot)
+	} else {
+		err = m.writeToken(ctx, endItem, eot)
+	}
+	if err != nil {
+		err = m.writeError(ctx)
+		m.writeLock.Unlock()
+		return m.sentBytes(bytes, write), err
+	}
+	m.writeLock.Unlock()
+	err = m.waitFor(ctx, eot)
+	if err != nil {
+		if err == ctx.Err() {
+			m.cancel(write)
+		}
+		return m.sentBytes(bytes, write), err
+	}
+	return n, nil
+}
+
+// Queue queues the given text like Write, followed by the end of the transmission like End, but it does not block
+// and does not wait until the text is sent. The returned channel is closed when the transmission is complete. Queue
+// implements audio.Queuer, e.g. to render a transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	ctx := context.Background()
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	m.packer.queue = true
+	defer func() { m.packer.queue = false }()
+	m.writes++
+	m.packer.write = m.writes
+	if !m.streaming {
+		err := m.writeToken(ctx, preambleItem, make(chan struct{}))
+		if err != nil {
+			return nil, m.abortError()
+		}
+	}
+
+	bytes := []byte(text)
+	for n := 0; n < len(bytes); {
+		c, size := m.nextCharacter(bytes[n:])
+		err := m.packer.Pack(ctx, m.packed, Varicode[c])
+		if err != nil {
+			return nil, m.abortError()
+		}
+		n += size
+	}
+
+	end := make(chan struct{})
+	m.streaming = false
+	err := m.writeToken(ctx, endItem, end)
+	if err != nil {
+		return nil, m.abortError()
+	}
+	return end, nil
+}
+
+// sentBytes returns the number of bytes of the given text whose characters were transmitted by the given write.
+func (m *Modulator) sentBytes(text []byte, write uint32) int {
+	n := 0
+	for chars := m.blocks.sent.Count(write); chars > 0 && n < len(text); chars-- {
+		_, size := m.nextCharacter(text[n:])
+		n += size
+	}
+	return n
+}
+
+// writeError returns the error of an interrupted write and cancels the write if the context is done. It must be
+// called while holding the write lock.
+func (m *Modulator) writeError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		m.cancel(m.writes)
+		m.packer = symbolPacker{pending: &m.blocks.pending}
+		return err
+	}
+	return m.abortError()
+}
+
+// cancel makes the modulator drop the items of the given write and of all writes before.
+func (m *Modulator) cancel(write uint32) {
 	for {
-		select {
-		case s := <-m.symbols:
-			packer.Pack(m.packed, s)
-		case <-m.closed:
+		canceled := atomic.LoadUint32(&m.blocks.canceled)
+		if write <= canceled || atomic.CompareAndSwapUint32(&m.blocks.canceled, canceled, write) {
 			return
 		}
 	}
 }
 
+// nextCharacter returns the next character to send from the given text and the number of bytes it occupies.
+func (m *Modulator) nextCharacter(text []byte) (byte, int) {
+	if !m.latin1 {
+		return text[0], 1
+	}
+	r, size := utf8.DecodeRune(text)
+	if r > 0xFF {
+		return '?', size
+	}
+	return byte(r), size
+}
+
+func (m *Modulator) writeToken(ctx context.Context, kind itemKind, token chan struct{}) error {
+	err := m.packer.Flush(ctx, m.packed)
+	if err != nil {
+		return err
+	}
+	return m.packer.put(ctx, m.packed, item{kind: kind, token: token, write: m.packer.write})
+}
+
+func (m *Modulator) waitFor(ctx context.Context, token chan struct{}) error {
+	select {
+	case <-token:
+		return nil
+	case <-m.packed.Done():
+		return m.abortError()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 type symbolPacker struct {
+	write       uint32
 	out         uint8
 	lastWasZero bool
 	outBitIndex int
 	dirty       bool
+	chars       uint8
+	pending     *pending
+	// queue makes the packer push the items into the stream without blocking.
+	queue bool
 }
 
-func (p *symbolPacker) Pack(packed chan<- interface{}, s interface{}) {
-	switch in := s.(type) {
-	case Symbol:
-		p.dirty = true
-		for i := 15; i >= 0; i-- {
-			inBit := (in >> uint8(i)) & 0x0001
-			p.out = (p.out << 1) | uint8(inBit)
-			p.outBitIndex = (p.outBitIndex + 1) % 8
+func (p *symbolPacker) Pack(ctx context.Context, packed *stream.Stream[item], in Symbol) error {
+	p.dirty = true
+	for i := 15; i >= 0; i-- {
+		inBit := (in >> uint8(i)) & 0x0001
+		p.out = (p.out << 1) | uint8(inBit)
+		p.outBitIndex = (p.outBitIndex + 1) % 8
+		if (p.lastWasZero && inBit == 0) || i == 0 {
+			p.chars++
+		}
 
-			if p.outBitIndex == 0 {
-				packed <- p.out
-				p.out = 0
+		if p.outBitIndex == 0 {
+			err := p.send(ctx, packed, p.out)
+			if err != nil {
+				return err
 			}
+			p.out = 0
+		}
 
-			if p.lastWasZero && (inBit == 0) {
-				break
-			}
-			p.lastWasZero = (inBit == 0)
+		if p.lastWasZero && (inBit == 0) {
+			break
 		}
-	default: // all the tokens
-		p.Flush(packed)
-		packed <- in
+		p.lastWasZero = (inBit == 0)
 	}
+	return nil
 }
 
-func (p *symbolPacker) Flush(packed chan<- interface{}) {
+func (p *symbolPacker) Flush(ctx context.Context, packed *stream.Stream[item]) error {
 	if (p.outBitIndex == 0 && p.lastWasZero) || !p.dirty {
 		p.dirty = false
-		return
+		return nil
 	}
 
+	// a zero byte is sent as a single bit, so two remaining zeros of the character need one more zero bit
+	zeros := p.out == 0 && p.outBitIndex > 1
 	p.out = (p.out << uint8(8-p.outBitIndex))
-	packed <- p.out
+	err := p.send(ctx, packed, p.out)
+	if err != nil {
+		return err
+	}
 
-	if p.out&0x3 != 0 {
-		packed <- uint8(0)
+	if p.out&0x3 != 0 || zeros {
+		err := p.send(ctx, packed, 0)
+		if err != nil {
+			return err
+		}
 	}
 
 	p.out = 0
 	p.outBitIndex = 0
 	p.dirty = false
+	return nil
+}
+
+// send sends the given bits together with the characters that end within them.
+func (p *symbolPacker) send(ctx context.Context, packed *stream.Stream[item], bits uint8) error {
+	next := item{kind: bitsItem, bits: bits, write: p.write, chars: p.chars}
+	p.pending.add(next, 1)
+	err := p.put(ctx, packed, next)
+	if err != nil {
+		p.pending.add(next, -1)
+		return err
+	}
+	p.chars = 0
+	return nil
+}
+
+// put appends the given item to the stream, it blocks while the stream is full unless the packer queues.
+func (p *symbolPacker) put(ctx context.Context, packed *stream.Stream[item], next item) error {
+	if p.queue {
+		return packed.Push(next)
+	}
+	return packed.Send(ctx, next)
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.packed.Done())
 }
 
 func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
-	ms := t * 1000.0
-	fraction := ms - float64(int(ms))
-	rasterTime := int(ms) % raster
+	units := t * m.baud * raster
+	fraction := units - float64(int(units))
+	rasterTime := int(units) % raster
 
 	var delta float64
 	switch {
@@ -192,13 +571,31 @@ func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase fl
 	m.phaseSwitchCycle = rasterTime != 0
 
 	if needNextBlock {
-		m.block = m.blocks.Next(m.packed, m.block, m.closed)
+		var err error
+		m.block, err = m.blocks.Next(m.packed, m.block)
+		if err != nil {
+			m.fail(err)
+			return 0, m.carrierFrequency, phase
+		}
 	}
 
 	return amplitude, m.carrierFrequency, phase
 }
 
 type blocks struct {
+	preambleLength int
+	endLength      int
+	idle           bool
+
+	// canceled is the number of the last canceled write, the items of this and all earlier writes are dropped.
+	canceled uint32
+	// lastWrite is the write of the last item that was taken from the stream.
+	lastWrite uint32
+	// pending counts the queued items.
+	pending pending
+	// sent counts the characters of the latest write that were taken from the stream.
+	sent stream.Progress
+
 	_off      *offBlock
 	_preamble *preambleBlock
 	_transmit *transmitBlock
@@ -207,6 +604,9 @@ type blocks struct {
 
 func newBlocks() *blocks {
 	return &blocks{
+		preambleLength: DefaultPreamble,
+		endLength:      DefaultTail,
+
 		_off:      new(offBlock),
 		_preamble: new(preambleBlock),
 		_transmit: new(transmitBlock),
@@ -214,28 +614,66 @@ func newBlocks() *blocks {
 	}
 }
 
-func (b *blocks) Next(packedSymbols <-chan interface{}, currentBlock block, closed <-chan struct{}) block {
-	select {
-	case s := <-packedSymbols:
-		switch s := s.(type) {
-		case uint8:
-			return b.transmit(s)
-		case preambleToken:
-			if _, ok := currentBlock.(*transmitBlock); ok {
-				close(s)
-				return b.Next(packedSymbols, currentBlock, closed)
+// Next returns the next block to modulate. If the next item in the packed stream is invalid, Next returns
+// the off block and an error.
+func (b *blocks) Next(packed *stream.Stream[item], currentBlock block) (block, error) {
+	if packed.Closed() {
+		return b.off(true), nil
+	}
+	for {
+		next, ok := packed.TryReceive()
+		if !ok {
+			return b.afterCanceledWrite(currentBlock), nil
+		}
+		b.lastWrite = next.write
+		b.pending.add(next, -1)
+		if next.write != 0 && next.write <= atomic.LoadUint32(&b.canceled) {
+			if next.token != nil {
+				close(next.token)
+			}
+			continue
+		}
+		switch next.kind {
+		case bitsItem:
+			b.sent.Add(next.write, int(next.chars))
+			return b.transmit(next.bits), nil
+		case preambleItem:
+			if _, ok := currentBlock.(*transmitBlock); ok || b.preambleLength <= 0 {
+				close(next.token)
+				continue
 			}
-			return b.preamble(s)
-		case endOfTransmissionToken:
-			close(s)
-			return b.Next(packedSymbols, currentBlock, closed)
-		case endToken:
-			return b.end(s)
+			return b.preamble(next.token), nil
+		case endOfTransmissionItem:
+			close(next.token)
+			continue
+		case endItem:
+			if b.endLength <= 0 {
+				close(next.token)
+				return b.off(false), nil
+			}
+			return b.end(next.token), nil
 		default:
-			panic(fmt.Sprintf("unknown token type %T", s))
+			return b.off(true), fmt.Errorf("psk31: unknown item kind %d", next.kind)
+		}
+	}
+}
+
+// afterCanceledWrite returns the block to continue with when the stream ran empty. After a canceled write, the
+// transmission ends like after a completed write: without the idle carrier, the tail is sent.
+func (b *blocks) afterCanceledWrite(currentBlock block) block {
+	if b.lastWrite == 0 || b.lastWrite > atomic.LoadUint32(&b.canceled) {
+		return currentBlock
+	}
+	b.lastWrite = 0
+	if b.idle {
+		return currentBlock
+	}
+	switch currentBlock.(type) {
+	case *preambleBlock, *transmitBlock:
+		if b.endLength <= 0 {
+			return b.off(false)
 		}
-	case <-closed:
-		return b.off(true)
+		return b.end(make(chan struct{}))
 	default:
 		return currentBlock
 	}
@@ -246,8 +684,9 @@ func (b *blocks) off(closed bool) *offBlock {
 	return b._off
 }
 
-func (b *blocks) preamble(token preambleToken) *preambleBlock {
-	b._preamble.cycles = preambleLength
+func (b *blocks) preamble(token chan struct{}) *preambleBlock {
+	b._preamble.length = b.preambleLength
+	b._preamble.cycles = b.preambleLength
 	b._preamble.token = token
 	return b._preamble
 }
@@ -259,8 +698,9 @@ func (b *blocks) transmit(bits uint8) *transmitBlock {
 	return b._transmit
 }
 
-func (b *blocks) end(token endToken) *endBlock {
-	b._end.cycles = endLength
+func (b *blocks) end(token chan struct{}) *endBlock {
+	b._end.length = b.endLength
+	b._end.cycles = b.endLength
 	b._end.token = token
 	return b._end
 }
@@ -274,12 +714,13 @@ func (b *offBlock) Cycle(a, p, delta float64, phaseSwitchCycle bool) (amplitude,
 }
 
 type preambleBlock struct {
+	length int
 	cycles int
-	token  preambleToken
+	token  chan struct{}
 }
 
 func (b *preambleBlock) Cycle(a, p, delta float64, phaseSwitchCycle bool) (amplitude, phase float64, needNextBlock bool) {
-	if b.cycles == preambleLength {
+	if b.cycles == b.length {
 		amplitude = a
 	} else {
 		amplitude = delta / float64(window)
@@ -342,14 +783,15 @@ func (b *transmitBlock) Cycle(a, p, delta float64, phaseSwitchCycle bool) (ampli
 }
 
 type endBlock struct {
+	length int
 	cycles int
-	token  endToken
+	token  chan struct{}
 }
 
 func (b *endBlock) Cycle(a, p, delta float64, phaseSwitchCycle bool) (amplitude, phase float64, needNextBlock bool) {
 	newAmplitude := delta / float64(window)
 	switch {
-	case b.cycles == endLength && a < newAmplitude:
+	case b.cycles == b.length && a < newAmplitude:
 		amplitude = newAmplitude
 	case b.cycles == 1 && a > newAmplitude:
 		amplitude = newAmplitude
@@ -1,9 +1,19 @@
 package psk31
 
 import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/internal/stream"
 )
 
 func TestSymbolPacker(t *testing.T) {
@@ -13,29 +23,367 @@ func TestSymbolPacker(t *testing.T) {
 		expected []uint8
 	}{
 		{"<empty>", []byte(""), []uint8{}},
-		{"aaa", []byte("aaa"), []uint8{0b10110010, 0b11001011, 0}},
+		// a zero byte is sent as a single bit, the characters end with two zero bits
+		{"aaa", []byte("aaa"), []uint8{0b10110010, 0b11001011, 0, 0}},
 		{"aat", []byte("aat"), []uint8{0b10110010, 0b11001010, 0}},
 		{"A", []byte("A"), []uint8{0b11111010, 0}},
-		{"B", []byte("B"), []uint8{0b11101011, 0}},
+		{"B", []byte("B"), []uint8{0b11101011, 0, 0}},
 		{"-", []byte("-"), []uint8{0b11010100}},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
-			packed := make(chan interface{}, len(tC.input)*2+2)
+			packed := stream.New[item](len(tC.input)*2 + 2)
 			packer := symbolPacker{}
 			for _, s := range tC.input {
-				packer.Pack(packed, Varicode[s])
+				packer.Pack(context.Background(), packed, Varicode[s])
 			}
-			packer.Pack(packed, make(endToken))
-			close(packed)
+			packer.Flush(context.Background(), packed)
 			actual := make([]uint8, 0, len(tC.expected))
-			for raw := range packed {
-				s, ok := raw.(uint8)
-				if ok {
-					actual = append(actual, s)
+			for {
+				next, ok := packed.TryReceive()
+				if !ok {
+					break
+				}
+				if next.kind == bitsItem {
+					actual = append(actual, next.bits)
 				}
 			}
 			assert.Equal(t, tC.expected, actual)
 		})
 	}
 }
+
+func TestSymbolPackerTerminatesLastCharacter(t *testing.T) {
+	// The transmit block sends a zero byte as a single zero bit. If both separating zeros of the last character
+	// fall into the padded byte, e.g. for "B" (1110101100) or "k" (1011111100), the padded byte is zero and
+	// a second zero byte is needed, otherwise the tail of the transmission (unmodulated carrier, i.e. ones)
+	// follows a single zero and the receiver never sees the end of the last character.
+	for _, text := range []string{"aaa", "aat", "A", "B", "-", "599 k"} {
+		t.Run(text, func(t *testing.T) {
+			packed := stream.New[item](2*len(text) + 2)
+			packer := symbolPacker{}
+			for _, c := range []byte(text) {
+				packer.Pack(context.Background(), packed, Varicode[c])
+			}
+			packer.Flush(context.Background(), packed)
+
+			var decoder VaricodeDecoder
+			var received []byte
+			decode := func(bit uint8) {
+				if c, ok := decoder.Decode(uint16(bit)); ok {
+					received = append(received, c)
+				}
+			}
+			for {
+				next, ok := packed.TryReceive()
+				if !ok {
+					break
+				}
+				if next.bits == 0 {
+					decode(0)
+					continue
+				}
+				for i := 7; i >= 0; i-- {
+					decode((next.bits >> uint(i)) & 1)
+				}
+			}
+			for i := 0; i < 8; i++ {
+				decode(1)
+			}
+
+			assert.Equal(t, text, string(received))
+		})
+	}
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(1000)
+	defer m.Close()
+	go m.Write([]byte("the quick brown fox jumps over the lazy dog"))
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestInvalidItemStopsModulator(t *testing.T) {
+	m := NewModulator(1000)
+	m.packed.Send(context.Background(), item{kind: itemKind(99)})
+
+	amplitude, _, _ := m.Modulate(0, 0, 0, 0)
+
+	assert.Equal(t, 0.0, amplitude)
+	assert.Error(t, m.Err())
+	_, err := m.Write([]byte("a"))
+	assert.Equal(t, m.Err(), err)
+}
+
+func TestPreambleAndTail(t *testing.T) {
+	const sampleRate = 8000
+	samplesPerSymbol := sampleRate / PSK31
+	signalLength := func(samples []float64) float64 {
+		first, last := -1, -1
+		for i, s := range samples {
+			if math.Abs(s) > 0.01 {
+				if first == -1 {
+					first = i
+				}
+				last = i
+			}
+		}
+		return float64(last-first) / samplesPerSymbol
+	}
+	textLength := signalLength(modulateWith(t, NewModulator(1000, WithPreamble(0), WithTail(0)), "e", sampleRate))
+
+	testCases := []struct {
+		desc     string
+		options  []Option
+		expected int
+	}{
+		{"default", nil, DefaultPreamble + DefaultTail},
+		{"short", []Option{WithPreamble(8), WithTail(4)}, 12},
+		{"no preamble", []Option{WithPreamble(0)}, DefaultTail},
+		{"no tail", []Option{WithTail(0)}, DefaultPreamble},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := NewModulator(1000, tC.options...)
+			length := signalLength(modulateWith(t, m, "e", sampleRate))
+			assert.InDelta(t, float64(tC.expected), length-textLength, 1)
+		})
+	}
+}
+
+func TestIdleCarrier(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		idle     bool
+		expected float64
+	}{
+		{"idle", true, 1},
+		{"without idle", false, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := NewModulator(1000, WithIdleCarrier(tC.idle))
+			defer m.Close()
+			written := make(chan struct{})
+			go func() {
+				m.Write([]byte("e"))
+				close(written)
+			}()
+
+			var a, f, p float64
+			n := 0
+			modulate := func() {
+				a, f, p = m.Modulate(float64(n)/8000, a, f, p)
+				n++
+			}
+			for done := false; !done; {
+				require.Less(t, n, 60*8000, "the write does not end")
+				modulate()
+				runtime.Gosched()
+				select {
+				case <-written:
+					done = true
+				default:
+				}
+			}
+
+			max := 0.0
+			for i := 0; i < 8000; i++ {
+				modulate()
+				max = math.Max(max, a)
+			}
+			assert.InDelta(t, tC.expected, max, 0.01)
+		})
+	}
+}
+
+func TestWriteContextCancel(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		idle     bool
+		expected float64
+	}{
+		{"idle", true, 1},
+		{"without idle", false, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := NewModulator(1000, WithIdleCarrier(tC.idle))
+			defer m.Close()
+			var a, f, p float64
+			n := 0
+			// modulate runs the modulator for the given duration and returns the maximum amplitude
+			modulate := func(duration float64) float64 {
+				max := 0.0
+				for end := n + int(duration*8000); n < end; n++ {
+					a, f, p = m.Modulate(float64(n)/8000, a, f, p)
+					max = math.Max(max, a)
+					runtime.Gosched()
+				}
+				return max
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			result := make(chan error, 1)
+			var sent int
+			go func() {
+				var err error
+				sent, err = m.WriteContext(ctx, []byte(strings.Repeat("the quick brown fox ", 20)))
+				result <- err
+			}()
+			// the preamble takes 0.8s, the rest is enough for a few characters
+			modulate(2)
+			cancel()
+			assert.Equal(t, context.Canceled, <-result)
+			assert.True(t, sent > 0 && sent < 10, "only the transmitted characters count: %d", sent)
+
+			modulate(2)
+			assert.InDelta(t, tC.expected, modulate(1), 0.01, "the carrier is handled like after a write")
+
+			written := make(chan error, 1)
+			go func() {
+				_, err := m.Write([]byte("e"))
+				written <- err
+			}()
+			for done := false; !done; {
+				require.Less(t, float64(n), 60*8000.0, "the write does not end")
+				modulate(0.01)
+				select {
+				case err := <-written:
+					assert.NoError(t, err)
+					done = true
+				default:
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingTransmission(t *testing.T) {
+	const sampleRate = 8000
+	m := NewModulator(1000, WithIdleCarrier(false))
+	defer m.Close()
+	var received strings.Builder
+	demodulator := NewDemodulator(1000, sampleRate, func(b byte) { received.WriteByte(b) })
+
+	var a, f, p float64
+	n := 0
+	// modulate runs the modulator and the demodulator for the given duration and returns the minimum amplitude
+	modulate := func(duration float64) float64 {
+		samples := make([]float64, int(duration*sampleRate))
+		min := 1.0
+		for i := range samples {
+			a, f, p = m.Modulate(float64(n)/sampleRate, a, f, p)
+			samples[i] = a * math.Cos(2*math.Pi*f*float64(n)/sampleRate+p)
+			min = math.Min(min, a)
+			n++
+			runtime.Gosched()
+		}
+		demodulator.WriteSamples(samples)
+		return min
+	}
+
+	_, err := m.WriteText(context.Background(), []byte("e"))
+	assert.Equal(t, ErrNoTransmission, err)
+
+	require.NoError(t, m.StartTransmission(context.Background()))
+	modulate(1)
+	for _, c := range "cq cq de dl1abc" {
+		n, err := m.WriteText(context.Background(), []byte(string(c)))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		modulate(0.1)
+	}
+	modulate(4)
+	assert.Equal(t, 0.0, modulate(0.5), "the idle carrier has phase reversals")
+	_, err = m.WriteText(context.Background(), []byte(" pse k "))
+	require.NoError(t, err)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- m.StopTransmission(context.Background())
+	}()
+	for done := false; !done; {
+		require.Less(t, n, 60*sampleRate, "the transmission does not end")
+		modulate(0.01)
+		select {
+		case err := <-stopped:
+			assert.NoError(t, err)
+			done = true
+		default:
+		}
+	}
+	modulate(1)
+
+	assert.Contains(t, received.String(), "cq cq de dl1abc pse k")
+	max := 0.0
+	for i := 0; i < sampleRate; i++ {
+		a, f, p = m.Modulate(float64(n)/sampleRate, a, f, p)
+		max = math.Max(max, a)
+		n++
+	}
+	assert.Equal(t, 0.0, max, "the carrier is off after the transmission")
+}
+
+func TestPending(t *testing.T) {
+	m := NewModulator(1000)
+	defer m.Close()
+	chars, duration := m.Pending()
+	assert.Equal(t, 0, chars)
+	assert.Equal(t, time.Duration(0), duration)
+
+	require.NoError(t, m.StartTransmission(context.Background()))
+	_, err := m.WriteText(context.Background(), []byte("cq cq"))
+	require.NoError(t, err)
+	chars, duration = m.Pending()
+	assert.Equal(t, 5, chars)
+	// the varicodes of the characters with two zero bits each, plus the padding of the last byte
+	bits := float64(2*len("101101") + 2*len("110111101") + len("1") + 5*2)
+	assert.True(t, duration.Seconds() >= bits/PSK31, duration)
+	assert.True(t, duration.Seconds() < (bits+8)/PSK31, duration)
+
+	var a, f, p float64
+	for n := 0; chars > 0; n++ {
+		require.Less(t, n, 10*8000, "the characters are not sent")
+		a, f, p = m.Modulate(float64(n)/8000, a, f, p)
+		previous := chars
+		chars, _ = m.Pending()
+		assert.True(t, chars <= previous)
+	}
+	_, duration = m.Pending()
+	assert.Equal(t, time.Duration(0), duration)
+}
+
+func TestSamplesReader(t *testing.T) {
+	m := NewModulator(1000)
+	go func() {
+		_, err := m.Write([]byte("cq cq de dl1abc pse k "))
+		if err == nil {
+			err = m.End()
+		}
+		assert.NoError(t, err)
+		m.Close()
+	}()
+
+	pcm, err := io.ReadAll(m.SamplesReader(8000))
+	require.NoError(t, err)
+	samples := make([]int16, len(pcm)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+	}
+
+	received := &strings.Builder{}
+	demodulator := NewDemodulator(1000, 8000, func(c byte) {
+		received.WriteByte(c)
+	})
+	_, err = demodulator.WritePCM(samples)
+	require.NoError(t, err)
+	assert.Contains(t, received.String(), "dl1abc pse k")
+}
@@ -1,19 +1,20 @@
 package psk31
 
-// Varicode contains all the PSK symbols as unpacket 16 bit words.
+// Varicode contains all the PSK symbols as unpacket 16 bit words. The codes of the characters 128-255 are the
+// extended varicode that is used by the common PSK programs for ISO 8859-1 (Latin-1).
 var Varicode = []Symbol{
 	0xAAC0, // 0b1010 1010 1100 0000,  // 0 NUL
 	0xB6C0, // 0b1011 0110 1100 0000,  // 1 SOH
 	0xBB40, // 0b1011 1011 0100 0000,  // 2 STX
 	0xDDC0, // 0b1101 1101 1100 0000,  // 3 ETX
 	0xBAC0, // 0b1011 1010 1100 0000,  // 4 EOT
-	0xD7A0, // 0b1101 0111 1100 0000,  // 5 ENQ
-	0xBBA0, // 0b1011 1011 1100 0000,  // 6 ACK
+	0xD7C0, // 0b1101 0111 1100 0000,  // 5 ENQ
+	0xBBC0, // 0b1011 1011 1100 0000,  // 6 ACK
 	0xBF40, // 0b1011 1111 0100 0000,  // 7 BEL
-	0xBFA0, // 0b1011 1111 1100 0000,  // 8 BS
+	0xBFC0, // 0b1011 1111 1100 0000,  // 8 BS
 	0xEF00, // 0b1110 1111 0000 0000,  // 9 HT
 	0xE800, // 0b1110 1000 0000 0000,  // 10 LF
-	0xDBA0, // 0b1101 1011 1100 0000,  // 11 VT
+	0xDBC0, // 0b1101 1011 1100 0000,  // 11 VT
 	0xB740, // 0b1011 0111 0100 0000,  // 12 FF
 	0xF800, // 0b1111 1000 0000 0000,  // 13 CR
 	0xDD40, // 0b1101 1101 0100 0000,  // 14 SO
@@ -37,7 +38,7 @@ var Varicode = []Symbol{
 	0x8000, // 0b1000 0000 0000 0000,  // 32 SP
 	0xFF80, // 0b1111 1111 1000 0000,  // 33 !
 	0xAF80, // 0b1010 1111 1000 0000,  // 34 "
-	0xFC80, // 0b1111 1010 1000 0000,  // 35 #
+	0xFA80, // 0b1111 1010 1000 0000,  // 35 #
 	0xED80, // 0b1110 1101 1000 0000,  // 36 $
 	0xB540, // 0b1011 0101 0100 0000,  // 37 %
 	0xAEC0, // 0b1010 1110 1100 0000,  // 38 &
@@ -48,7 +49,7 @@ var Varicode = []Symbol{
 	0xEF80, // 0b1110 1111 1000 0000,  // 43 +
 	0xEA00, // 0b1110 1010 0000 0000,  // 44 ,
 	0xD400, // 0b1101 0100 0000 0000,  // 45 -
-	0xCE00, // 0b1010 1110 0000 0000,  // 46 .
+	0xAE00, // 0b1010 1110 0000 0000,  // 46 .
 	0xD780, // 0b1101 0111 1000 0000,  // 47 /
 	0xB700, // 0b1011 0111 0000 0000,  // 48 0
 	0xBD00, // 0b1011 1101 0000 0000,  // 49 1
@@ -130,4 +131,221 @@ var Varicode = []Symbol{
 	0xAD40, // 0b1010 1101 0100 0000,  // 125 }
 	0xB5C0, // 0b1011 0101 1100 0000,  // 126 ~
 	0xED40, // 0b1110 1101 0100 0000,  // 127 (del)
+	// extended varicode for the characters 128-255, the upper half of ISO 8859-1 (Latin-1)
+	0xEF40, // 0b1110 1111 0100 0000,  // 128
+	0xEFC0, // 0b1110 1111 1100 0000,  // 129
+	0xF540, // 0b1111 0101 0100 0000,  // 130
+	0xF5C0, // 0b1111 0101 1100 0000,  // 131
+	0xF6C0, // 0b1111 0110 1100 0000,  // 132
+	0xF740, // 0b1111 0111 0100 0000,  // 133
+	0xF7C0, // 0b1111 0111 1100 0000,  // 134
+	0xFAC0, // 0b1111 1010 1100 0000,  // 135
+	0xFB40, // 0b1111 1011 0100 0000,  // 136
+	0xFBC0, // 0b1111 1011 1100 0000,  // 137
+	0xFD40, // 0b1111 1101 0100 0000,  // 138
+	0xFDC0, // 0b1111 1101 1100 0000,  // 139
+	0xFEC0, // 0b1111 1110 1100 0000,  // 140
+	0xFF40, // 0b1111 1111 0100 0000,  // 141
+	0xFFC0, // 0b1111 1111 1100 0000,  // 142
+	0xAAA0, // 0b1010 1010 1010 0000,  // 143
+	0xAAE0, // 0b1010 1010 1110 0000,  // 144
+	0xAB60, // 0b1010 1011 0110 0000,  // 145
+	0xABA0, // 0b1010 1011 1010 0000,  // 146
+	0xABE0, // 0b1010 1011 1110 0000,  // 147
+	0xAD60, // 0b1010 1101 0110 0000,  // 148
+	0xADA0, // 0b1010 1101 1010 0000,  // 149
+	0xADE0, // 0b1010 1101 1110 0000,  // 150
+	0xAEA0, // 0b1010 1110 1010 0000,  // 151
+	0xAEE0, // 0b1010 1110 1110 0000,  // 152
+	0xAF60, // 0b1010 1111 0110 0000,  // 153
+	0xAFA0, // 0b1010 1111 1010 0000,  // 154
+	0xAFE0, // 0b1010 1111 1110 0000,  // 155
+	0xB560, // 0b1011 0101 0110 0000,  // 156
+	0xB5A0, // 0b1011 0101 1010 0000,  // 157
+	0xB5E0, // 0b1011 0101 1110 0000,  // 158
+	0xB6A0, // 0b1011 0110 1010 0000,  // 159
+	0xB6E0, // 0b1011 0110 1110 0000,  // 160 (nbsp)
+	0xB760, // 0b1011 0111 0110 0000,  // 161 ¡
+	0xB7A0, // 0b1011 0111 1010 0000,  // 162 ¢
+	0xB7E0, // 0b1011 0111 1110 0000,  // 163 £
+	0xBAA0, // 0b1011 1010 1010 0000,  // 164 ¤
+	0xBAE0, // 0b1011 1010 1110 0000,  // 165 ¥
+	0xBB60, // 0b1011 1011 0110 0000,  // 166 ¦
+	0xBBA0, // 0b1011 1011 1010 0000,  // 167 §
+	0xBBE0, // 0b1011 1011 1110 0000,  // 168 ¨
+	0xBD60, // 0b1011 1101 0110 0000,  // 169 ©
+	0xBDA0, // 0b1011 1101 1010 0000,  // 170 ª
+	0xBDE0, // 0b1011 1101 1110 0000,  // 171 «
+	0xBEA0, // 0b1011 1110 1010 0000,  // 172 ¬
+	0xBEE0, // 0b1011 1110 1110 0000,  // 173 (shy)
+	0xBF60, // 0b1011 1111 0110 0000,  // 174 ®
+	0xBFA0, // 0b1011 1111 1010 0000,  // 175 ¯
+	0xBFE0, // 0b1011 1111 1110 0000,  // 176 °
+	0xD560, // 0b1101 0101 0110 0000,  // 177 ±
+	0xD5A0, // 0b1101 0101 1010 0000,  // 178 ²
+	0xD5E0, // 0b1101 0101 1110 0000,  // 179 ³
+	0xD6A0, // 0b1101 0110 1010 0000,  // 180 ´
+	0xD6E0, // 0b1101 0110 1110 0000,  // 181 µ
+	0xD760, // 0b1101 0111 0110 0000,  // 182 ¶
+	0xD7A0, // 0b1101 0111 1010 0000,  // 183 ·
+	0xD7E0, // 0b1101 0111 1110 0000,  // 184 ¸
+	0xDAA0, // 0b1101 1010 1010 0000,  // 185 ¹
+	0xDAE0, // 0b1101 1010 1110 0000,  // 186 º
+	0xDB60, // 0b1101 1011 0110 0000,  // 187 »
+	0xDBA0, // 0b1101 1011 1010 0000,  // 188 ¼
+	0xDBE0, // 0b1101 1011 1110 0000,  // 189 ½
+	0xDD60, // 0b1101 1101 0110 0000,  // 190 ¾
+	0xDDA0, // 0b1101 1101 1010 0000,  // 191 ¿
+	0xDDE0, // 0b1101 1101 1110 0000,  // 192 À
+	0xDEA0, // 0b1101 1110 1010 0000,  // 193 Á
+	0xDEE0, // 0b1101 1110 1110 0000,  // 194 Â
+	0xDF60, // 0b1101 1111 0110 0000,  // 195 Ã
+	0xDFA0, // 0b1101 1111 1010 0000,  // 196 Ä
+	0xDFE0, // 0b1101 1111 1110 0000,  // 197 Å
+	0xEAA0, // 0b1110 1010 1010 0000,  // 198 Æ
+	0xEAE0, // 0b1110 1010 1110 0000,  // 199 Ç
+	0xEB60, // 0b1110 1011 0110 0000,  // 200 È
+	0xEBA0, // 0b1110 1011 1010 0000,  // 201 É
+	0xEBE0, // 0b1110 1011 1110 0000,  // 202 Ê
+	0xED60, // 0b1110 1101 0110 0000,  // 203 Ë
+	0xEDA0, // 0b1110 1101 1010 0000,  // 204 Ì
+	0xEDE0, // 0b1110 1101 1110 0000,  // 205 Í
+	0xEEA0, // 0b1110 1110 1010 0000,  // 206 Î
+	0xEEE0, // 0b1110 1110 1110 0000,  // 207 Ï
+	0xEF60, // 0b1110 1111 0110 0000,  // 208 Ð
+	0xEFA0, // 0b1110 1111 1010 0000,  // 209 Ñ
+	0xEFE0, // 0b1110 1111 1110 0000,  // 210 Ò
+	0xF560, // 0b1111 0101 0110 0000,  // 211 Ó
+	0xF5A0, // 0b1111 0101 1010 0000,  // 212 Ô
+	0xF5E0, // 0b1111 0101 1110 0000,  // 213 Õ
+	0xF6A0, // 0b1111 0110 1010 0000,  // 214 Ö
+	0xF6E0, // 0b1111 0110 1110 0000,  // 215 ×
+	0xF760, // 0b1111 0111 0110 0000,  // 216 Ø
+	0xF7A0, // 0b1111 0111 1010 0000,  // 217 Ù
+	0xF7E0, // 0b1111 0111 1110 0000,  // 218 Ú
+	0xFAA0, // 0b1111 1010 1010 0000,  // 219 Û
+	0xFAE0, // 0b1111 1010 1110 0000,  // 220 Ü
+	0xFB60, // 0b1111 1011 0110 0000,  // 221 Ý
+	0xFBA0, // 0b1111 1011 1010 0000,  // 222 Þ
+	0xFBE0, // 0b1111 1011 1110 0000,  // 223 ß
+	0xFD60, // 0b1111 1101 0110 0000,  // 224 à
+	0xFDA0, // 0b1111 1101 1010 0000,  // 225 á
+	0xFDE0, // 0b1111 1101 1110 0000,  // 226 â
+	0xFEA0, // 0b1111 1110 1010 0000,  // 227 ã
+	0xFEE0, // 0b1111 1110 1110 0000,  // 228 ä
+	0xFF60, // 0b1111 1111 0110 0000,  // 229 å
+	0xFFA0, // 0b1111 1111 1010 0000,  // 230 æ
+	0xFFE0, // 0b1111 1111 1110 0000,  // 231 ç
+	0xAAB0, // 0b1010 1010 1011 0000,  // 232 è
+	0xAAD0, // 0b1010 1010 1101 0000,  // 233 é
+	0xAAF0, // 0b1010 1010 1111 0000,  // 234 ê
+	0xAB50, // 0b1010 1011 0101 0000,  // 235 ë
+	0xAB70, // 0b1010 1011 0111 0000,  // 236 ì
+	0xABB0, // 0b1010 1011 1011 0000,  // 237 í
+	0xABD0, // 0b1010 1011 1101 0000,  // 238 î
+	0xABF0, // 0b1010 1011 1111 0000,  // 239 ï
+	0xAD50, // 0b1010 1101 0101 0000,  // 240 ð
+	0xAD70, // 0b1010 1101 0111 0000,  // 241 ñ
+	0xADB0, // 0b1010 1101 1011 0000,  // 242 ò
+	0xADD0, // 0b1010 1101 1101 0000,  // 243 ó
+	0xADF0, // 0b1010 1101 1111 0000,  // 244 ô
+	0xAEB0, // 0b1010 1110 1011 0000,  // 245 õ
+	0xAED0, // 0b1010 1110 1101 0000,  // 246 ö
+	0xAEF0, // 0b1010 1110 1111 0000,  // 247 ÷
+	0xAF50, // 0b1010 1111 0101 0000,  // 248 ø
+	0xAF70, // 0b1010 1111 0111 0000,  // 249 ù
+	0xAFB0, // 0b1010 1111 1011 0000,  // 250 ú
+	0xAFD0, // 0b1010 1111 1101 0000,  // 251 û
+	0xAFF0, // 0b1010 1111 1111 0000,  // 252 ü
+	0xB550, // 0b1011 0101 0101 0000,  // 253 ý
+	0xB570, // 0b1011 0101 0111 0000,  // 254 þ
+	0xB5B0, // 0b1011 0101 1011 0000,  // 255 ÿ
+}
+
+// maxCodeLength is the length of the longest varicode without the separating zeros.
+const maxCodeLength = 12
+
+// varicodeLookup maps the varicode bits without the separating zeros to the characters.
+var varicodeLookup = func() map[uint16]byte {
+	result := make(map[uint16]byte, len(Varicode))
+	for c, symbol := range Varicode {
+		var code uint16
+		lastWasZero := false
+		for i := 15; i >= 0; i-- {
+			bit := uint16(symbol>>uint(i)) & 1
+			if bit == 0 && lastWasZero {
+				code >>= 1
+				break
+			}
+			code = code<<1 | bit
+			lastWasZero = bit == 0
+		}
+		result[code] = byte(c)
+	}
+	return result
+}()
+
+// VaricodeDecoder converts a stream of varicode bits back into characters. The codes of the characters are
+// separated by two or more zeros. Codes that are unknown or too long, e.g. the idle carrier, are dropped. After a
+// code that is too long, the bits are dropped until the next separator. The zero value is ready to use.
+type VaricodeDecoder struct {
+	code        uint16
+	codeLength  int
+	lastWasZero bool
+	overflow    bool
+}
+
+// Decode processes the next bit, 0 or 1. It returns the character when the bit completes its code.
+func (d *VaricodeDecoder) Decode(bit uint16) (byte, bool) {
+	bit &= 1
+	if bit == 0 && d.lastWasZero {
+		var c byte
+		ok := false
+		if d.codeLength > 0 && !d.overflow {
+			c, ok = varicodeLookup[d.code>>1]
+		}
+		d.code = 0
+		d.codeLength = 0
+		d.overflow = false
+		return c, ok
+	}
+	d.lastWasZero = bit == 0
+	if d.overflow {
+		// the rest of a code that is too long
+		return 0, false
+	}
+	if d.codeLength == 0 && bit == 0 {
+		// a code starts with a one
+		return 0, false
+	}
+	d.code = d.code<<1 | bit
+	d.codeLength++
+	if d.codeLength > maxCodeLength+1 {
+		// too long, e.g. the idle carrier
+		d.code = 0
+		d.codeLength = 0
+		d.overflow = true
+	}
+	return 0, false
+}
+
+// Reset drops the partially received code.
+func (d *VaricodeDecoder) Reset() {
+	*d = VaricodeDecoder{}
+}
+
+// DecodeVaricode decodes the given varicode bitstream, packed with the most significant bit first like the
+// modulator sends it, into characters. A code at the end of the bitstream that is not terminated by two zeros is
+// dropped.
+func DecodeVaricode(packed []byte) []byte {
+	var decoder VaricodeDecoder
+	var result []byte
+	for _, b := range packed {
+		for i := 7; i >= 0; i-- {
+			if c, ok := decoder.Decode(uint16(b>>uint(i)) & 1); ok {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
 }
@@ -1,9 +1,12 @@
 package psk31
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/internal/stream"
 )
 
 func TestVaricodeUniqueness(t *testing.T) {
@@ -14,3 +17,112 @@ func TestVaricodeUniqueness(t *testing.T) {
 		codes[c] = true
 	}
 }
+
+// pack packs the varicode of the given text like the modulator does.
+func pack(text []byte) []byte {
+	packed := stream.New[item](len(text)*2 + 2)
+	packer := symbolPacker{}
+	for _, c := range text {
+		packer.Pack(context.Background(), packed, Varicode[c])
+	}
+	packer.Flush(context.Background(), packed)
+	result := make([]byte, 0, packed.Len())
+	for {
+		next, ok := packed.TryReceive()
+		if !ok {
+			return result
+		}
+		result = append(result, next.bits)
+	}
+}
+
+func TestDecodeVaricode(t *testing.T) {
+	all := make([]byte, len(Varicode))
+	for i := range all {
+		all[i] = byte(i)
+	}
+	testCases := []struct {
+		desc   string
+		packed []byte
+		expect []byte
+	}{
+		{"empty", nil, nil},
+		{"aat", []byte{0b10110010, 0b11001010, 0}, []byte("aat")},
+		{"leading zeros", []byte{0, 0b00101100}, []byte("a")},
+		{"too long", []byte{0xFF, 0xF0, 0b11000000}, []byte("e")},
+		{"rest of a too long code", []byte{0xFF, 0xFE, 0x00}, nil},
+		{"after a too long code", []byte{0xFF, 0xFE, 0b00110000}, []byte("e")},
+		{"unterminated", []byte{0b11001011}, []byte("e")},
+		{"round trip", pack([]byte("Hello, World! 123")), []byte("Hello, World! 123")},
+		{"all characters", pack(all), all},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expect, DecodeVaricode(tC.packed))
+		})
+	}
+}
+
+func TestVaricodeDecoderReset(t *testing.T) {
+	var decoder VaricodeDecoder
+	decoder.Decode(1)
+	decoder.Decode(0)
+	decoder.Decode(1)
+	decoder.Reset()
+
+	for _, bit := range []uint16{1, 1, 0} {
+		_, ok := decoder.Decode(bit)
+		assert.False(t, ok)
+	}
+	c, ok := decoder.Decode(0)
+	assert.True(t, ok)
+	assert.Equal(t, byte('e'), c)
+}
+
+func TestExtendedVaricode(t *testing.T) {
+	assert.Len(t, Varicode, 256)
+	assert.Equal(t, Symbol(0b1110111101<<6), Varicode[128])
+	assert.Equal(t, Symbol(0b10101010101<<5), Varicode[143])
+	assert.Equal(t, byte(0xC4), DecodeVaricode(pack([]byte{0xC4}))[0])
+}
+
+func TestLatin1(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		latin1   bool
+		text     string
+		expected []byte
+	}{
+		{"ascii", false, "abc", []byte("abc")},
+		{"raw", false, "Grüße", []byte("Gr\xc3\xbc\xc3\x9fe")},
+		{"latin1", true, "Grüße", []byte("Gr\xfc\xdfe")},
+		{"not in latin1", true, "1€", []byte("1?")},
+		{"invalid utf-8", true, "a\xff", []byte("a?")},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := NewModulator(1000, WithLatin1(tC.latin1))
+			var actual []byte
+			for text := []byte(tC.text); len(text) > 0; {
+				c, size := m.nextCharacter(text)
+				actual = append(actual, c)
+				text = text[size:]
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestLatin1RoundTrip(t *testing.T) {
+	const sampleRate = 8000
+	samples := modulateWith(t, NewModulator(1000, WithLatin1(true)), "Grüße aus Köln", sampleRate)
+
+	var received []byte
+	demodulator := NewDemodulator(1000, sampleRate, func(c byte) {
+		received = append(received, c)
+	})
+	_, err := demodulator.WriteSamples(samples)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(received), "Gr\xfc\xdfe aus K\xf6ln")
+}
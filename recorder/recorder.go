@@ -0,0 +1,370 @@
+/*
+Package recorder captures transmitted and received audio into timestamped WAV files. Each recording is accompanied
+by a JSON sidecar file with its metadata (direction, mode, frequency, text). Long recordings are split into several
+files, and old recordings are removed according to a retention policy. The recordings can be used for later
+analysis and as regression corpora for the decoders.
+*/
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// Direction of a recording.
+type Direction string
+
+// All directions.
+const (
+	Transmit Direction = "tx"
+	Receive  Direction = "rx"
+)
+
+const (
+	audioExtension    = ".wav"
+	metadataExtension = ".json"
+	timestampLayout   = "20060102T150405.000Z"
+)
+
+// ErrClosed is returned when samples are written to a closed recording.
+var ErrClosed = errors.New("recorder: recording closed")
+
+// Metadata of a recording, stored in the sidecar file.
+type Metadata struct {
+	Direction      Direction `json:"direction"`
+	Mode           string    `json:"mode"`
+	AudioFrequency float64   `json:"audio_frequency,omitempty"`
+	RFFrequency    float64   `json:"rf_frequency,omitempty"`
+	Text           string    `json:"text,omitempty"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	SampleRate     int       `json:"sample_rate"`
+	Samples        int64     `json:"samples"`
+	Part           int       `json:"part"`
+}
+
+// Duration of the recorded audio.
+func (m Metadata) Duration() time.Duration {
+	if m.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(m.Samples) * time.Second / time.Duration(m.SampleRate)
+}
+
+// Config of a Recorder. Zero values disable rotation and the corresponding retention limit.
+type Config struct {
+	// Directory is the directory where the recordings are stored.
+	Directory string
+	// Format is the sample format of the WAV files.
+	Format audio.SampleFormat
+	// MaxDuration is the maximum duration of a single file. Longer recordings are continued in a new file.
+	MaxDuration time.Duration
+	// MaxAge is the maximum age of a recording, measured from its end.
+	MaxAge time.Duration
+	// MaxTotalSize is the maximum size in bytes of all recordings in the directory.
+	MaxTotalSize int64
+}
+
+// Recorder creates recordings in a directory and applies the retention policy.
+type Recorder struct {
+	config Config
+	clock  timesource.Clock
+
+	pruneLock sync.Mutex
+}
+
+// New returns a new Recorder with the given configuration. If clock is nil, the system clock is used.
+func New(config Config, clock timesource.Clock) (*Recorder, error) {
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	err := os.MkdirAll(config.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		config: config,
+		clock:  clock,
+	}, nil
+}
+
+// Entry is a recording that is stored in the directory of the recorder.
+type Entry struct {
+	Metadata
+	// Path of the WAV file.
+	Path string
+}
+
+// Recordings returns all completed recordings in chronological order.
+func (r *Recorder) Recordings() ([]Entry, error) {
+	filenames, err := filepath.Glob(filepath.Join(r.config.Directory, "*"+metadataExtension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames)
+
+	result := make([]Entry, 0, len(filenames))
+	for _, filename := range filenames {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		err = json.Unmarshal(content, &entry.Metadata)
+		if err != nil {
+			continue
+		}
+		entry.Path = strings.TrimSuffix(filename, metadataExtension) + audioExtension
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// Prune removes the recordings that violate the retention policy, oldest first.
+func (r *Recorder) Prune() error {
+	r.pruneLock.Lock()
+	defer r.pruneLock.Unlock()
+
+	entries, err := r.Recordings()
+	if err != nil {
+		return err
+	}
+
+	sizes := make([]int64, len(entries))
+	var totalSize int64
+	for i, entry := range entries {
+		sizes[i] = fileSize(entry.Path) + fileSize(metadataFilename(entry.Path))
+		totalSize += sizes[i]
+	}
+
+	now := r.clock.Now()
+	for i, entry := range entries {
+		expired := r.config.MaxAge > 0 && now.Sub(entry.End) > r.config.MaxAge
+		tooLarge := r.config.MaxTotalSize > 0 && totalSize > r.config.MaxTotalSize
+		if !expired && !tooLarge {
+			continue
+		}
+		err := remove(entry.Path)
+		if err != nil {
+			return err
+		}
+		totalSize -= sizes[i]
+	}
+	return nil
+}
+
+func remove(path string) error {
+	// remove the sidecar first, so that an interrupted prune does not leave a listed recording without audio
+	err := os.Remove(metadataFilename(path))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func fileSize(filename string) int64 {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func metadataFilename(path string) string {
+	return strings.TrimSuffix(path, audioExtension) + metadataExtension
+}
+
+// Start a new recording with the given metadata and sample rate. Start, End, Samples and Part of the metadata are
+// maintained by the recording.
+func (r *Recorder) Start(metadata Metadata, sampleRate int) (*Recording, error) {
+	metadata.Start = r.clock.Now().UTC()
+	metadata.End = metadata.Start
+	metadata.SampleRate = sampleRate
+	metadata.Samples = 0
+	metadata.Part = 0
+
+	result := &Recording{
+		recorder: r,
+		metadata: metadata,
+	}
+	if r.config.MaxDuration > 0 {
+		result.maxSamples = int64(r.config.MaxDuration.Seconds() * float64(sampleRate))
+	}
+	err := result.open()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Recorder) filename(metadata Metadata) string {
+	name := strings.Join([]string{
+		metadata.Start.Format(timestampLayout),
+		string(metadata.Direction),
+		sanitize(metadata.Mode),
+	}, "-")
+	return filepath.Join(r.config.Directory, name+audioExtension)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// Recording is an audio.Sink that writes into the files of a single recording.
+type Recording struct {
+	recorder   *Recorder
+	maxSamples int64
+
+	lock     sync.Mutex
+	metadata Metadata
+	file     *os.File
+	wav      *audio.WAVWriter
+	closed   bool
+}
+
+// SampleRate of the recording in Hz.
+func (r *Recording) SampleRate() int {
+	return r.metadata.SampleRate
+}
+
+// Metadata returns the metadata of the current file of the recording.
+func (r *Recording) Metadata() Metadata {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.metadata
+}
+
+// SetText sets the text that is stored in the sidecar file.
+func (r *Recording) SetText(text string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metadata.Text = text
+}
+
+// AppendText appends the given text to the text that is stored in the sidecar file.
+func (r *Recording) AppendText(text string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metadata.Text += text
+}
+
+// WriteSamples writes the given samples into the recording. If the current file reaches the maximum duration,
+// the recording continues in a new file.
+func (r *Recording) WriteSamples(samples []float64) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return 0, ErrClosed
+	}
+
+	written := 0
+	for len(samples) > 0 {
+		if r.maxSamples > 0 && r.metadata.Samples >= r.maxSamples {
+			err := r.rotate()
+			if err != nil {
+				return written, err
+			}
+		}
+		block := samples
+		if r.maxSamples > 0 && r.metadata.Samples+int64(len(block)) > r.maxSamples {
+			block = block[:r.maxSamples-r.metadata.Samples]
+		}
+		n, err := r.wav.WriteSamples(block)
+		r.metadata.Samples += int64(n)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		samples = samples[n:]
+	}
+	return written, nil
+}
+
+// Close finishes the current file of the recording, writes its sidecar file and applies the retention policy.
+func (r *Recording) Close() error {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	r.closed = true
+	err := r.finish()
+	r.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.recorder.Prune()
+}
+
+func (r *Recording) open() error {
+	filename := r.recorder.filename(r.metadata)
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	wav, err := audio.NewWAVWriter(file, r.metadata.SampleRate, r.recorder.config.Format)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.wav = wav
+	return nil
+}
+
+func (r *Recording) rotate() error {
+	err := r.finish()
+	if err != nil {
+		return err
+	}
+	r.metadata.Start = r.metadata.End
+	r.metadata.Samples = 0
+	r.metadata.Part++
+	err = r.open()
+	if err != nil {
+		return err
+	}
+	return r.recorder.Prune()
+}
+
+func (r *Recording) finish() error {
+	r.metadata.End = r.metadata.Start.Add(r.metadata.Duration())
+
+	err := r.wav.Close()
+	if err != nil {
+		r.file.Close()
+		return err
+	}
+	err = r.file.Close()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(r.metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metadataFilename(r.file.Name()), content, 0644)
+}
@@ -0,0 +1,132 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/timesource"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestRecorder(t *testing.T, config Config) (*Recorder, *testClock) {
+	clock := &testClock{now: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)}
+	config.Directory = t.TempDir()
+	r, err := New(config, clock)
+	require.NoError(t, err)
+	return r, clock
+}
+
+func TestRecording(t *testing.T) {
+	r, _ := newTestRecorder(t, Config{Format: audio.Int16})
+
+	recording, err := r.Start(Metadata{Direction: Transmit, Mode: "PSK31", AudioFrequency: 1000}, 1000)
+	require.NoError(t, err)
+	n, err := recording.WriteSamples(make([]float64, 500))
+	require.NoError(t, err)
+	assert.Equal(t, 500, n)
+	recording.AppendText("CQ ")
+	recording.AppendText("DL0ABC")
+	require.NoError(t, recording.Close())
+
+	_, err = recording.WriteSamples(make([]float64, 1))
+	assert.Equal(t, ErrClosed, err)
+
+	entries, err := r.Recordings()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "20200501T120000.000Z-tx-psk31.wav", filepath.Base(entry.Path))
+	assert.Equal(t, Transmit, entry.Direction)
+	assert.Equal(t, "PSK31", entry.Mode)
+	assert.Equal(t, 1000.0, entry.AudioFrequency)
+	assert.Equal(t, "CQ DL0ABC", entry.Text)
+	assert.Equal(t, int64(500), entry.Samples)
+	assert.Equal(t, 500*time.Millisecond, entry.End.Sub(entry.Start))
+
+	info, err := os.Stat(entry.Path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(44+2*500), info.Size())
+}
+
+func TestRotation(t *testing.T) {
+	r, _ := newTestRecorder(t, Config{Format: audio.Int16, MaxDuration: time.Second})
+
+	recording, err := r.Start(Metadata{Direction: Receive, Mode: "cw"}, 100)
+	require.NoError(t, err)
+	n, err := recording.WriteSamples(make([]float64, 250))
+	require.NoError(t, err)
+	assert.Equal(t, 250, n)
+	require.NoError(t, recording.Close())
+
+	entries, err := r.Recordings()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for i, entry := range entries {
+		assert.Equal(t, i, entry.Part)
+		assert.Equal(t, time.Duration(i)*time.Second, entry.Start.Sub(entries[0].Start))
+	}
+	assert.Equal(t, int64(100), entries[0].Samples)
+	assert.Equal(t, int64(100), entries[1].Samples)
+	assert.Equal(t, int64(50), entries[2].Samples)
+}
+
+func TestRetention(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   Config
+		expected int
+	}{
+		{
+			desc:     "no limits",
+			config:   Config{},
+			expected: 3,
+		},
+		{
+			desc:     "max age",
+			config:   Config{MaxAge: 150 * time.Minute},
+			expected: 2,
+		},
+		{
+			desc:     "max total size",
+			config:   Config{MaxTotalSize: 100},
+			expected: 0,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tC.config.Format = audio.Int16
+			r, clock := newTestRecorder(t, tC.config)
+			for i := 0; i < 3; i++ {
+				recording, err := r.Start(Metadata{Direction: Receive, Mode: "wspr"}, 100)
+				require.NoError(t, err)
+				_, err = recording.WriteSamples(make([]float64, 10))
+				require.NoError(t, err)
+				clock.now = clock.now.Add(time.Hour)
+				require.NoError(t, recording.Close())
+			}
+
+			entries, err := r.Recordings()
+			require.NoError(t, err)
+			assert.Len(t, entries, tC.expected)
+		})
+	}
+}
+
+func TestSystemClockIsDefault(t *testing.T) {
+	r, err := New(Config{Directory: t.TempDir()}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, timesource.SystemClock, r.clock)
+}
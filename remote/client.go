@@ -0,0 +1,170 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"net/rpc"
+	"time"
+
+	"github.com/ftl/digimodes"
+)
+
+// pollWait is the time a receiver waits for decode records with one call.
+const pollWait = time.Second
+
+// Client is a client of the remote service.
+type Client struct {
+	rpc *rpc.Client
+}
+
+// Dial connects to the service at the given TCP address.
+func Dial(address string) (*Client, error) {
+	client, err := rpc.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{rpc: client}, nil
+}
+
+// NewClient returns a new client that uses the given connection.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{rpc: rpc.NewClient(conn)}
+}
+
+// Close closes the connection to the service.
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+// Modes returns the modes that are available at the service.
+func (c *Client) Modes() (ModesReply, error) {
+	var reply ModesReply
+	err := c.call(context.Background(), "Modes", struct{}{}, &reply)
+	return reply, err
+}
+
+// OpenTransmitter opens a new transmitter session for the given mode.
+func (c *Client) OpenTransmitter(mode string, sampleRate int, options Options) (*Transmitter, error) {
+	var session SessionID
+	err := c.call(context.Background(), "OpenTransmitter", OpenTransmitterArgs{Mode: mode, SampleRate: sampleRate, Options: options}, &session)
+	if err != nil {
+		return nil, err
+	}
+	return &Transmitter{client: c, session: session, sampleRate: sampleRate}, nil
+}
+
+// OpenReceiver opens a new receiver session for the given mode.
+func (c *Client) OpenReceiver(mode string, sampleRate int, options Options) (*Receiver, error) {
+	var session SessionID
+	err := c.call(context.Background(), "OpenReceiver", OpenReceiverArgs{Mode: mode, SampleRate: sampleRate, Options: options}, &session)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := &Receiver{
+		client:  c,
+		session: session,
+		records: make(chan digimodes.DecodeRecord),
+		cancel:  cancel,
+	}
+	go result.poll(ctx)
+	return result, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	call := c.rpc.Go(ServiceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if call.Error != nil && call.Error.Error() == ErrUnknownSession.Error() {
+			return ErrUnknownSession
+		}
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Transmitter is a remote transmitter session. Text written to the transmitter is modulated remotely,
+// the audio is read through the audio.Source interface.
+type Transmitter struct {
+	client     *Client
+	session    SessionID
+	sampleRate int
+}
+
+// Write transmits the given text. It returns when the text is transmitted completely,
+// therefore the audio must be read concurrently.
+func (t *Transmitter) Write(p []byte) (int, error) {
+	var n int
+	err := t.client.call(context.Background(), "Write", WriteArgs{Session: t.session, Text: string(p)}, &n)
+	return n, err
+}
+
+// SampleRate returns the sample rate of the transmitter in Hz.
+func (t *Transmitter) SampleRate() int {
+	return t.sampleRate
+}
+
+// ReadSamples reads the next block of audio samples.
+func (t *Transmitter) ReadSamples(samples []float64) (int, error) {
+	var block AudioBlock
+	err := t.client.call(context.Background(), "ReadAudio", ReadAudioArgs{Session: t.session, Samples: len(samples)}, &block)
+	if err != nil {
+		return 0, err
+	}
+	for i, sample := range block.Samples {
+		samples[i] = float64(sample)
+	}
+	return len(block.Samples), nil
+}
+
+// Close closes the remote session.
+func (t *Transmitter) Close() error {
+	return t.client.call(context.Background(), "Close", t.session, &struct{}{})
+}
+
+// Receiver is a remote receiver session. It implements the digimodes.Decoder interface.
+type Receiver struct {
+	client  *Client
+	session SessionID
+	records chan digimodes.DecodeRecord
+	cancel  context.CancelFunc
+}
+
+// Feed feeds the given samples into the remote decoder.
+func (r *Receiver) Feed(ctx context.Context, samples []float64) error {
+	converted := make([]float32, len(samples))
+	for i, sample := range samples {
+		converted[i] = float32(sample)
+	}
+	return r.client.call(ctx, "Feed", FeedArgs{Session: r.session, Samples: converted}, &struct{}{})
+}
+
+// Records returns the channel of decoded records.
+func (r *Receiver) Records() <-chan digimodes.DecodeRecord {
+	return r.records
+}
+
+// Close closes the remote session.
+func (r *Receiver) Close() error {
+	r.cancel()
+	return r.client.call(context.Background(), "Close", r.session, &struct{}{})
+}
+
+func (r *Receiver) poll(ctx context.Context) {
+	defer close(r.records)
+	for {
+		var records []digimodes.DecodeRecord
+		err := r.client.call(ctx, "ReadDecodes", ReadDecodesArgs{Session: r.session, Wait: pollWait}, &records)
+		if err != nil {
+			return
+		}
+		for _, record := range records {
+			select {
+			case r.records <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
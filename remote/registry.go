@@ -0,0 +1,155 @@
+package remote
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/cw"
+	"github.com/ftl/digimodes/olivia"
+	"github.com/ftl/digimodes/psk31"
+	"github.com/ftl/digimodes/rtty"
+)
+
+// Modulator is the interface of the modulators that can be used remotely.
+type Modulator = digimodes.Modulator
+
+// Options contains the numeric parameters of a mode, e.g. "frequency" or "wpm".
+type Options map[string]float64
+
+// Get returns the value of the given option or the given default value if the option is not set.
+func (o Options) Get(name string, defaultValue float64) float64 {
+	value, ok := o[name]
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// ModulatorFactory creates a new modulator with the given options.
+type ModulatorFactory func(options Options) (Modulator, error)
+
+// DecoderFactory creates a new decoder for the given sample rate with the given options.
+type DecoderFactory func(sampleRate int, options Options) (digimodes.Decoder, error)
+
+// Registry contains the modes that are available through the service.
+type Registry struct {
+	mu         sync.RWMutex
+	modulators map[string]ModulatorFactory
+	decoders   map[string]DecoderFactory
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		modulators: make(map[string]ModulatorFactory),
+		decoders:   make(map[string]DecoderFactory),
+	}
+}
+
+// pskRates are the symbol rates of the PSK modes.
+var pskRates = map[string]float64{
+	"psk31":  psk31.PSK31,
+	"psk63":  psk31.PSK63,
+	"psk125": psk31.PSK125,
+	"psk250": psk31.PSK250,
+}
+
+// mfskModes are the modes of the Olivia family.
+var mfskModes = map[string]func(tones int, bandwidth float64) olivia.Config{
+	"olivia":    olivia.Olivia,
+	"contestia": olivia.Contestia,
+	"rttym":     olivia.RTTYM,
+}
+
+// DefaultRegistry returns a new Registry that contains all modes of this library.
+func DefaultRegistry() *Registry {
+	result := NewRegistry()
+	result.RegisterModulator("cw", func(options Options) (Modulator, error) {
+		return cw.NewModulator(options.Get("frequency", 700), int(options.Get("wpm", 20))), nil
+	})
+	result.RegisterDecoder("cw", func(sampleRate int, options Options) (digimodes.Decoder, error) {
+		return cw.NewRecordDecoder(options.Get("frequency", 700), sampleRate, int(options.Get("wpm", 20)), time.Now()), nil
+	})
+	for mode, baud := range pskRates {
+		baud := baud
+		result.RegisterModulator(mode, func(options Options) (Modulator, error) {
+			return psk31.NewModulatorWithRate(options.Get("frequency", 1000), baud), nil
+		})
+		result.RegisterDecoder(mode, func(sampleRate int, options Options) (digimodes.Decoder, error) {
+			return psk31.NewRecordDecoder(options.Get("frequency", 1000), baud, sampleRate, time.Now()), nil
+		})
+	}
+	result.RegisterModulator("rtty", func(options Options) (Modulator, error) {
+		return rtty.NewModulator(options.Get("frequency", 1500), options.Get("stopbits", rtty.DefaultStopBits)), nil
+	})
+	for mode, config := range mfskModes {
+		config := config
+		result.RegisterModulator(mode, func(options Options) (Modulator, error) {
+			mode := config(int(options.Get("tones", 32)), options.Get("bandwidth", 1000))
+			return olivia.NewModulator(mode, options.Get("frequency", 1500))
+		})
+	}
+	return result
+}
+
+// RegisterModulator registers the given modulator factory for the given mode.
+func (r *Registry) RegisterModulator(mode string, factory ModulatorFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.modulators[mode] = factory
+}
+
+// RegisterDecoder registers the given decoder factory for the given mode.
+func (r *Registry) RegisterDecoder(mode string, factory DecoderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[mode] = factory
+}
+
+// NewModulator creates a new modulator for the given mode.
+func (r *Registry) NewModulator(mode string, options Options) (Modulator, error) {
+	r.mu.RLock()
+	factory, ok := r.modulators[mode]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no modulator for mode %q", mode)
+	}
+	return factory(options)
+}
+
+// NewDecoder creates a new decoder for the given mode.
+func (r *Registry) NewDecoder(mode string, sampleRate int, options Options) (digimodes.Decoder, error) {
+	r.mu.RLock()
+	factory, ok := r.decoders[mode]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no decoder for mode %q", mode)
+	}
+	return factory(sampleRate, options)
+}
+
+// Modulators returns the names of all modes with a modulator.
+func (r *Registry) Modulators() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.modulators)
+}
+
+// Decoders returns the names of all modes with a decoder.
+func (r *Registry) Decoders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.decoders)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
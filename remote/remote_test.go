@@ -0,0 +1,113 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+type countingDecoder struct {
+	*digimodes.RecordQueue
+}
+
+func (d *countingDecoder) Feed(ctx context.Context, samples []float64) error {
+	return d.Emit(ctx, digimodes.DecodeRecord{Mode: "count", Text: fmt.Sprintf("%d", len(samples))})
+}
+
+func startService(t *testing.T) *Client {
+	t.Helper()
+	registry := DefaultRegistry()
+	registry.RegisterDecoder("count", func(sampleRate int, options Options) (digimodes.Decoder, error) {
+		return &countingDecoder{digimodes.NewRecordQueue(10)}, nil
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go NewService(registry).Serve(ctx, listener)
+
+	client, err := Dial(listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestModes(t *testing.T) {
+	client := startService(t)
+
+	modes, err := client.Modes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"contestia", "cw", "olivia", "psk125", "psk250", "psk31", "psk63", "rtty", "rttym"}, modes.Modulators)
+	assert.Equal(t, []string{"count", "cw", "psk125", "psk250", "psk31", "psk63"}, modes.Decoders)
+
+	_, err = client.OpenTransmitter("mt63", 8000, nil)
+	assert.Error(t, err)
+}
+
+func TestDefaultDecoders(t *testing.T) {
+	registry := DefaultRegistry()
+	for _, mode := range registry.Decoders() {
+		decoder, err := registry.NewDecoder(mode, 8000, Options{"frequency": 1500})
+		require.NoError(t, err, mode)
+		assert.NoError(t, decoder.Feed(context.Background(), make([]float64, 800)), mode)
+		assert.NoError(t, decoder.Close(), mode)
+	}
+}
+
+func TestTransmitter(t *testing.T) {
+	client := startService(t)
+	transmitter, err := client.OpenTransmitter("cw", 8000, Options{"wpm": 40})
+	require.NoError(t, err)
+
+	written := make(chan error)
+	go func() {
+		_, err := transmitter.Write([]byte("e"))
+		written <- err
+	}()
+
+	peak := 0.0
+	samples := make([]float64, 800)
+	for done := false; !done; {
+		n, err := transmitter.ReadSamples(samples)
+		require.NoError(t, err)
+		for _, s := range samples[:n] {
+			if s > peak {
+				peak = s
+			}
+		}
+		select {
+		case err := <-written:
+			require.NoError(t, err)
+			done = true
+		default:
+		}
+	}
+	assert.InDelta(t, 1.0, peak, 0.01)
+
+	require.NoError(t, transmitter.Close())
+	_, err = transmitter.ReadSamples(samples)
+	assert.Equal(t, ErrUnknownSession, err)
+}
+
+func TestReceiver(t *testing.T) {
+	client := startService(t)
+	receiver, err := client.OpenReceiver("count", 8000, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, receiver.Feed(context.Background(), make([]float64, 42)))
+	select {
+	case record := <-receiver.Records():
+		assert.Equal(t, "42", record.Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record received")
+	}
+
+	require.NoError(t, receiver.Close())
+}
@@ -0,0 +1,323 @@
+/*
+Package remote provides a net/rpc service that makes the modulators and decoders of this library available
+to other processes, and a client to use this service.
+
+Text is written to a remote transmitter, its audio is read in blocks of samples. Samples are fed into a remote
+receiver, its decode records are read in batches. Since net/rpc does not support streaming, the streams are
+implemented through repeated calls, the client may issue calls concurrently.
+*/
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/audio"
+)
+
+// ServiceName is the name of the service that is registered at the RPC server.
+const ServiceName = "Digimodes"
+
+// maxAudioBlock is the maximum number of samples that can be read with one call.
+const maxAudioBlock = 96000
+
+// maxDecodeWait is the maximum time a ReadDecodes call waits for new records.
+const maxDecodeWait = 10 * time.Second
+
+// ErrUnknownSession is returned if a session ID is not valid.
+var ErrUnknownSession = errors.New("remote: unknown session")
+
+// SessionID identifies a transmitter or receiver session.
+type SessionID uint64
+
+// ModesReply contains the available modes.
+type ModesReply struct {
+	Modulators []string
+	Decoders   []string
+}
+
+// OpenTransmitterArgs are the arguments to open a transmitter session.
+type OpenTransmitterArgs struct {
+	Mode       string
+	SampleRate int
+	Options    Options
+}
+
+// OpenReceiverArgs are the arguments to open a receiver session.
+type OpenReceiverArgs struct {
+	Mode       string
+	SampleRate int
+	Options    Options
+}
+
+// WriteArgs are the arguments to write text to a transmitter session.
+type WriteArgs struct {
+	Session SessionID
+	Text    string
+}
+
+// ReadAudioArgs are the arguments to read audio from a transmitter session.
+type ReadAudioArgs struct {
+	Session SessionID
+	Samples int
+}
+
+// AudioBlock is a block of samples.
+type AudioBlock struct {
+	// Offset is the index of the first sample of the block since the start of the session.
+	Offset  int64
+	Samples []float32
+}
+
+// FeedArgs are the arguments to feed samples into a receiver session.
+type FeedArgs struct {
+	Session SessionID
+	Samples []float32
+}
+
+// ReadDecodesArgs are the arguments to read decode records from a receiver session.
+type ReadDecodesArgs struct {
+	Session SessionID
+	// Wait is the maximum time to wait for the first record.
+	Wait time.Duration
+}
+
+// Service is the RPC service. All exported methods are callable through net/rpc.
+type Service struct {
+	registry *Registry
+
+	mu           sync.Mutex
+	nextID       SessionID
+	transmitters map[SessionID]*transmitter
+	receivers    map[SessionID]*receiver
+}
+
+type transmitter struct {
+	modulator Modulator
+
+	mu       sync.Mutex
+	renderer *audio.Renderer
+	samples  []float64
+}
+
+type receiver struct {
+	decoder digimodes.Decoder
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewService returns a new service that provides the modes of the given registry.
+func NewService(registry *Registry) *Service {
+	return &Service{
+		registry:     registry,
+		transmitters: make(map[SessionID]*transmitter),
+		receivers:    make(map[SessionID]*receiver),
+	}
+}
+
+// Serve accepts connections on the given listener and serves the service until the context is done.
+func (s *Service) Serve(ctx context.Context, listener net.Listener) error {
+	server := rpc.NewServer()
+	err := server.RegisterName(ServiceName, s)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go server.ServeConn(conn)
+	}
+}
+
+// Modes returns the available modes.
+func (s *Service) Modes(_ struct{}, reply *ModesReply) error {
+	reply.Modulators = s.registry.Modulators()
+	reply.Decoders = s.registry.Decoders()
+	return nil
+}
+
+// OpenTransmitter opens a new transmitter session.
+func (s *Service) OpenTransmitter(args OpenTransmitterArgs, reply *SessionID) error {
+	if args.SampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate %d", args.SampleRate)
+	}
+	modulator, err := s.registry.NewModulator(args.Mode, args.Options)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.transmitters[s.nextID] = &transmitter{
+		modulator: modulator,
+		renderer:  audio.NewRenderer(modulator, args.SampleRate),
+	}
+	*reply = s.nextID
+	return nil
+}
+
+// OpenReceiver opens a new receiver session.
+func (s *Service) OpenReceiver(args OpenReceiverArgs, reply *SessionID) error {
+	if args.SampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate %d", args.SampleRate)
+	}
+	decoder, err := s.registry.NewDecoder(args.Mode, args.SampleRate, args.Options)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.receivers[s.nextID] = &receiver{
+		decoder: decoder,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	*reply = s.nextID
+	return nil
+}
+
+// Write writes the given text to the transmitter session. It returns when the text is transmitted completely,
+// therefore the audio must be read concurrently.
+func (s *Service) Write(args WriteArgs, reply *int) error {
+	t, err := s.transmitter(args.Session)
+	if err != nil {
+		return err
+	}
+	*reply, err = t.modulator.Write([]byte(args.Text))
+	return err
+}
+
+// ReadAudio renders the next block of samples of the transmitter session.
+func (s *Service) ReadAudio(args ReadAudioArgs, reply *AudioBlock) error {
+	t, err := s.transmitter(args.Session)
+	if err != nil {
+		return err
+	}
+	if args.Samples <= 0 || args.Samples > maxAudioBlock {
+		return fmt.Errorf("invalid number of samples %d", args.Samples)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reply.Offset = t.renderer.Samples()
+	if cap(t.samples) < args.Samples {
+		t.samples = make([]float64, args.Samples)
+	}
+	t.samples = t.samples[:args.Samples]
+	t.renderer.Render(t.samples)
+	reply.Samples = make([]float32, args.Samples)
+	audio.Convert(reply.Samples, t.samples)
+	return nil
+}
+
+// Feed feeds the given samples into the receiver session.
+func (s *Service) Feed(args FeedArgs, _ *struct{}) error {
+	r, err := s.receiver(args.Session)
+	if err != nil {
+		return err
+	}
+	samples := make([]float64, len(args.Samples))
+	for i, sample := range args.Samples {
+		samples[i] = float64(sample)
+	}
+	return r.decoder.Feed(r.ctx, samples)
+}
+
+// ReadDecodes returns the available decode records of the receiver session. If no record is available, it waits
+// up to the given time for the next record.
+func (s *Service) ReadDecodes(args ReadDecodesArgs, reply *[]digimodes.DecodeRecord) error {
+	r, err := s.receiver(args.Session)
+	if err != nil {
+		return err
+	}
+	wait := args.Wait
+	if wait > maxDecodeWait {
+		wait = maxDecodeWait
+	}
+
+	result := make([]digimodes.DecodeRecord, 0)
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	select {
+	case record, ok := <-r.decoder.Records():
+		if !ok {
+			return ErrUnknownSession
+		}
+		result = append(result, record)
+	case <-timeout.C:
+		*reply = result
+		return nil
+	}
+	for {
+		select {
+		case record, ok := <-r.decoder.Records():
+			if !ok {
+				*reply = result
+				return nil
+			}
+			result = append(result, record)
+		default:
+			*reply = result
+			return nil
+		}
+	}
+}
+
+// Close closes the given session.
+func (s *Service) Close(session SessionID, _ *struct{}) error {
+	s.mu.Lock()
+	t, isTransmitter := s.transmitters[session]
+	r, isReceiver := s.receivers[session]
+	delete(s.transmitters, session)
+	delete(s.receivers, session)
+	s.mu.Unlock()
+
+	switch {
+	case isTransmitter:
+		return t.modulator.Close()
+	case isReceiver:
+		r.cancel()
+		return r.decoder.Close()
+	default:
+		return ErrUnknownSession
+	}
+}
+
+func (s *Service) transmitter(session SessionID) (*transmitter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.transmitters[session]
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+	return result, nil
+}
+
+func (s *Service) receiver(session SessionID) (*receiver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.receivers[session]
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+	return result, nil
+}
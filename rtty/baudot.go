@@ -0,0 +1,92 @@
+package rtty
+
+import "unicode"
+
+// The Baudot codes that switch between the letters and the figures.
+const (
+	LTRS = 0x1F
+	FIGS = 0x1B
+)
+
+// shiftState is the state of the Baudot encoding.
+type shiftState uint8
+
+const (
+	noShift shiftState = iota
+	lettersShift
+	figuresShift
+)
+
+// Letters contains the characters of the letters shift, indexed by their Baudot code.
+var Letters = [32]rune{
+	0, 'E', '\n', 'A', ' ', 'S', 'I', 'U', '\r', 'D', 'R', 'J', 'N', 'F', 'C', 'K',
+	'T', 'Z', 'L', 'W', 'H', 'Y', 'P', 'Q', 'O', 'B', 'G', 0, 'M', 'X', 'V', 0,
+}
+
+// Figures contains the characters of the figures shift, indexed by their Baudot code. It follows the US TTY
+// layout that is common in amateur radio.
+var Figures = [32]rune{
+	0, '3', '\n', '-', ' ', '\a', '8', '7', '\r', '$', '4', '\'', ',', '!', ':', '(',
+	'5', '"', ')', '2', '#', '6', '0', '1', '9', '?', '&', 0, '.', '/', ';', 0,
+}
+
+type baudotCode struct {
+	code  uint8
+	shift shiftState
+}
+
+// encodeTable maps the characters to their Baudot code and the required shift.
+var encodeTable = func() map[rune]baudotCode {
+	result := make(map[rune]baudotCode, 64)
+	for code := range Letters {
+		if code == 0 || code == LTRS || code == FIGS {
+			continue
+		}
+		letter, figure := Letters[code], Figures[code]
+		if letter == figure {
+			result[letter] = baudotCode{uint8(code), noShift}
+			continue
+		}
+		result[letter] = baudotCode{uint8(code), lettersShift}
+		result[figure] = baudotCode{uint8(code), figuresShift}
+	}
+	return result
+}()
+
+// encoder converts text into Baudot codes and inserts the shift codes as needed. After a space, a figure gets
+// a FIGS code again, for receivers that unshift on space.
+type encoder struct {
+	shift      shiftState
+	afterSpace bool
+}
+
+// Encode appends the Baudot codes for the given character to the given codes. A newline is sent as CR LF,
+// characters that cannot be encoded are dropped.
+func (e *encoder) Encode(codes []uint8, r rune) []uint8 {
+	if r == '\r' {
+		return codes
+	}
+	c, ok := encodeTable[unicode.ToUpper(r)]
+	if !ok {
+		return codes
+	}
+	if r == '\n' {
+		codes = append(codes, encodeTable['\r'].code)
+	}
+	switch {
+	case c.shift == lettersShift && e.shift != lettersShift:
+		codes = append(codes, LTRS)
+		e.shift = lettersShift
+	case c.shift == figuresShift && (e.shift != figuresShift || e.afterSpace):
+		codes = append(codes, FIGS)
+		e.shift = figuresShift
+	}
+	e.afterSpace = r == ' '
+	return append(codes, c.code)
+}
+
+// Reset forgets the shift state, the next character gets a shift code in any case.
+func (e *encoder) Reset() {
+	e.shift = noShift
+	e.afterSpace = false
+}
@@ -0,0 +1,60 @@
+package rtty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	const (
+		a     = 0x03
+		c     = 0x0E
+		h     = 0x14
+		i     = 0x06
+		q     = 0x17
+		one   = 0x17
+		two   = 0x13
+		five  = 0x10
+		nine  = 0x18
+		space = 0x04
+		cr    = 0x08
+		lf    = 0x02
+	)
+	testCases := []struct {
+		desc     string
+		value    string
+		expected []uint8
+	}{
+		{"<empty>", "", nil},
+		{"letters", "CQ", []uint8{LTRS, c, q}},
+		{"lower case", "cq", []uint8{LTRS, c, q}},
+		{"figures", "599", []uint8{FIGS, five, nine, nine}},
+		{"mixed", "A1A", []uint8{LTRS, a, FIGS, one, LTRS, a}},
+		{"figures after space", "1 2", []uint8{FIGS, one, space, FIGS, two}},
+		{"letters after space", "A A", []uint8{LTRS, a, space, a}},
+		{"newline", "HI\n", []uint8{LTRS, h, i, cr, lf}},
+		{"carriage return and newline", "HI\r\n", []uint8{LTRS, h, i, cr, lf}},
+		{"unknown characters", "A%~A", []uint8{LTRS, a, a}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			e := encoder{}
+			var actual []uint8
+			for _, r := range tC.value {
+				actual = e.Encode(actual, r)
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestEncodeTable(t *testing.T) {
+	for code := range Letters {
+		if Letters[code] == 0 {
+			continue
+		}
+		assert.Equal(t, uint8(code), encodeTable[Letters[code]].code, "letter %q", Letters[code])
+		assert.Equal(t, uint8(code), encodeTable[Figures[code]].code, "figure %q", Figures[code])
+	}
+}
@@ -0,0 +1,21 @@
+package rtty
+
+type itemKind uint8
+
+const (
+	codeItem itemKind = iota
+	preambleItem
+	endOfTransmissionItem
+	endItem
+)
+
+// item is an element of the pipeline between Write and Modulate: either a Baudot code or a token. The write is the
+// number of the write that produced the item, 0 if the item does not belong to a write. Bytes is the number of
+// written bytes that are complete when the item is sent.
+type item struct {
+	kind  itemKind
+	code  uint8
+	token chan struct{}
+	write uint32
+	bytes int
+}
@@ -0,0 +1,172 @@
+package rtty
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRate = 10000.0
+
+// receive samples the output of the modulator in the middle of the bits and returns the received codes and the
+// times of their start bits. It stops when the writer is done and the modulator is off for longer than a character.
+func receive(t *testing.T, m *Modulator, done <-chan struct{}) ([]uint8, []float64) {
+	var codes []uint8
+	var starts []float64
+	sample := func(at float64) (bool, bool) {
+		amplitude, frequency, _ := m.Modulate(at, 0, 0, 0)
+		return amplitude > 0, frequency == m.mark
+	}
+
+	n := 0
+	wasOn := false
+	for off := 0; off < int(testRate/4) || !wasOn || !isDone(done); n++ {
+		on, mark := sample(float64(n) / testRate)
+		if !on {
+			off++
+			continue
+		}
+		off = 0
+		wasOn = true
+		if mark {
+			continue
+		}
+
+		// start bit
+		charStart := float64(n) / testRate
+		var code uint8
+		for bit := 0; bit < dataBits; bit++ {
+			_, mark := sample(charStart + (float64(bit)+1.5)/Baud)
+			if mark {
+				code |= 1 << uint(bit)
+			}
+		}
+		_, stop := sample(charStart + (dataBits+1.5)/Baud)
+		assert.True(t, stop, "stop bit")
+		codes = append(codes, code)
+		starts = append(starts, charStart)
+		n = int((charStart + (dataBits+1.5)/Baud) * testRate)
+	}
+	return codes, starts
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestModulate(t *testing.T) {
+	m := NewModulator(1000, DefaultStopBits)
+	defer m.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := m.Write([]byte("RY 73"))
+		assert.NoError(t, err)
+		assert.NoError(t, m.End())
+	}()
+
+	codes, _ := receive(t, m, done)
+
+	// preamble, text, diddles until the end
+	expected := []uint8{LTRS, LTRS, LTRS, 0x0A, 0x15, 0x04, FIGS, 0x07, 0x01}
+	require.True(t, len(codes) > len(expected))
+	assert.Equal(t, expected, codes[:len(expected)])
+	for _, code := range codes[len(expected):] {
+		assert.Equal(t, uint8(FIGS), code)
+	}
+}
+
+func TestStopBits(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		stopBits float64
+	}{
+		{"1 stop bit", 1},
+		{"1.5 stop bits", 1.5},
+		{"2 stop bits", 2},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			m := NewModulator(1000, tC.stopBits)
+			defer m.Close()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				m.Write([]byte("RYRYRY"))
+				m.End()
+			}()
+
+			_, starts := receive(t, m, done)
+
+			require.True(t, len(starts) > 2)
+			expected := (1 + dataBits + tC.stopBits) / Baud
+			for i := 1; i < len(starts); i++ {
+				assert.InDelta(t, expected, starts[i]-starts[i-1], 2/testRate)
+			}
+		})
+	}
+}
+
+func TestFrequencies(t *testing.T) {
+	m := NewModulator(1000, DefaultStopBits)
+	assert.Equal(t, 1085.0, m.mark)
+	assert.Equal(t, 915.0, m.space)
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(1000, DefaultStopBits)
+	defer m.Close()
+	go m.Write([]byte("the quick brown fox jumps over the lazy dog"))
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestInvalidItemStopsModulator(t *testing.T) {
+	m := NewModulator(1000, DefaultStopBits)
+	m.codes.Send(context.Background(), item{kind: itemKind(99)})
+
+	amplitude, _, _ := m.Modulate(0, 0, 0, 0)
+
+	assert.Equal(t, 0.0, amplitude)
+	assert.Error(t, m.Err())
+	_, err := m.Write([]byte("a"))
+	assert.Equal(t, m.Err(), err)
+}
+
+func TestWriteCountsTransmittedBytesOnAbort(t *testing.T) {
+	m := NewModulator(1000, DefaultStopBits)
+	type result struct {
+		n   int
+		err error
+	}
+	written := make(chan result, 1)
+	go func() {
+		n, err := m.Write([]byte("RYRYRYRYRYRYRYRYRYRY"))
+		written <- result{n, err}
+	}()
+
+	// two diddles and the shift code before the text, each character takes 0.165s
+	for n := 0; n < int(testRate); n++ {
+		m.Modulate(float64(n)/testRate, 0, 0, 0)
+		runtime.Gosched()
+	}
+	m.Close()
+
+	actual := <-written
+	assert.Equal(t, ErrWriteAborted, actual.err)
+	assert.InDelta(t, 4, actual.n, 1, "only the transmitted characters count")
+}
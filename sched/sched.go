@@ -0,0 +1,181 @@
+/*
+Package sched aligns transmissions to the time slots of the time-synchronized digital modes. The slots have a fixed
+duration, counted from midnight UTC, e.g. 15s for FT8, 7.5s for FT4, one minute for JT65 or two minutes for WSPR.
+A transmission starts with a given offset into its slot.
+
+The clock of a Scheduler can be replaced, e.g. by a timesource.DisciplinedClock or by a fake clock in tests.
+*/
+package sched
+
+import (
+	"context"
+	"time"
+
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// The slot durations of the common modes.
+const (
+	FT8  = 15 * time.Second
+	FT4  = 7500 * time.Millisecond
+	JT65 = time.Minute
+	JT9  = time.Minute
+	WSPR = 2 * time.Minute
+)
+
+// Clock is the time base of a Scheduler.
+type Clock interface {
+	timesource.Clock
+	// After waits for the given duration and then sends the current time on the returned channel. The returned
+	// stop function releases the wait if it is not needed anymore, like time.Timer.Stop.
+	After(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+// RealClock returns a Clock that waits in real time and reads the current time from the given clock, e.g. a
+// timesource.DisciplinedClock. If clock is nil, the system clock is used.
+func RealClock(clock timesource.Clock) Clock {
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	return &realClock{clock}
+}
+
+type realClock struct {
+	timesource.Clock
+}
+
+func (c *realClock) After(d time.Duration) (<-chan time.Time, func() bool) {
+	result := make(chan time.Time, 1)
+	timer := time.AfterFunc(d, func() {
+		result <- c.Now()
+	})
+	return result, timer.Stop
+}
+
+// Scheduler calculates the time slots and waits for them. The slots are counted from the Unix epoch, so a slot
+// duration that divides a day has its first slot of each day at midnight UTC.
+type Scheduler struct {
+	clock    Clock
+	duration time.Duration
+	offset   time.Duration
+}
+
+// New returns a new Scheduler for slots with the given duration, where the transmissions start with the given
+// offset into the slot. If clock is nil, the system clock is used.
+func New(clock Clock, duration time.Duration, offset time.Duration) *Scheduler {
+	if clock == nil {
+		clock = RealClock(nil)
+	}
+	return &Scheduler{
+		clock:    clock,
+		duration: duration,
+		offset:   offset,
+	}
+}
+
+// Clock returns the clock of the scheduler.
+func (s *Scheduler) Clock() Clock {
+	return s.clock
+}
+
+// Duration returns the duration of a slot.
+func (s *Scheduler) Duration() time.Duration {
+	return s.duration
+}
+
+// Offset returns the offset of the transmissions into a slot.
+func (s *Scheduler) Offset() time.Duration {
+	return s.offset
+}
+
+// Now returns the current time of the clock of the scheduler.
+func (s *Scheduler) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Index returns the number of the slot whose transmission starts at or before the given time, counted from the
+// Unix epoch. Even and odd slots can be told apart by the index, e.g. for the two sequences of an FT8 QSO.
+func (s *Scheduler) Index(t time.Time) int64 {
+	elapsed := t.Sub(time.Unix(0, 0)) - s.offset
+	result := int64(elapsed / s.duration)
+	if elapsed%s.duration < 0 {
+		result--
+	}
+	return result
+}
+
+// Start returns the start of the transmission in the slot with the given index.
+func (s *Scheduler) Start(index int64) time.Time {
+	return time.Unix(0, 0).UTC().Add(time.Duration(index)*s.duration + s.offset)
+}
+
+// SlotStart returns the start of the transmission in the slot of the given time, at or before the given time.
+func (s *Scheduler) SlotStart(t time.Time) time.Time {
+	return s.Start(s.Index(t)).In(t.Location())
+}
+
+// NextStart returns the start of the next transmission at or after the given time.
+func (s *Scheduler) NextStart(t time.Time) time.Time {
+	result := s.SlotStart(t)
+	if result.Before(t) {
+		result = result.Add(s.duration)
+	}
+	return result
+}
+
+// WaitForStart waits for the start of the next transmission and returns its time. It returns false if the given
+// context is done before.
+func (s *Scheduler) WaitForStart(ctx context.Context) (time.Time, bool) {
+	start := s.NextStart(s.clock.Now())
+	if !s.WaitUntil(ctx, start) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// Channel is the RF frequency and the bandwidth of a transmission in a mode, to check it against the band plan of a
+// region.
+type Channel struct {
+	Region    bandplan.Region
+	Mode      string
+	Frequency bandplan.Frequency
+	Bandwidth bandplan.Frequency
+}
+
+// Check checks if the channel is within the amateur radio bands and the conventional segments of the mode.
+func (c Channel) Check() error {
+	return bandplan.CheckTransmitFrequency(c.Region, c.Mode, c.Frequency, c.Bandwidth)
+}
+
+// WaitForTransmission checks the given channel and waits for the start of the next transmission on this channel.
+// It returns the error of the check without waiting, or the error of the context if it is done before the start.
+func (s *Scheduler) WaitForTransmission(ctx context.Context, channel Channel) (time.Time, error) {
+	if err := channel.Check(); err != nil {
+		return time.Time{}, err
+	}
+	start, ok := s.WaitForStart(ctx)
+	if !ok {
+		return time.Time{}, ctx.Err()
+	}
+	return start, nil
+}
+
+// WaitUntil waits until the given time. It returns false if the given context is done before.
+func (s *Scheduler) WaitUntil(ctx context.Context, t time.Time) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	d := t.Sub(s.clock.Now())
+	if d <= 0 {
+		return true
+	}
+	after, stop := s.clock.After(d)
+	select {
+	case <-after:
+		return true
+	case <-ctx.Done():
+		stop()
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+package sched
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/bandplan"
+)
+
+// fakeClock advances instantly when waiting.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	result := make(chan time.Time, 1)
+	result <- c.now
+	return result, func() bool { return false }
+}
+
+func at(hour, min, sec, msec int) time.Time {
+	return time.Date(2024, 3, 1, hour, min, sec, msec*int(time.Millisecond), time.UTC)
+}
+
+func TestSchedulerNextStart(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		duration time.Duration
+		offset   time.Duration
+		now      time.Time
+		expected time.Time
+	}{
+		{"FT8 exact", FT8, 0, at(12, 0, 15, 0), at(12, 0, 15, 0)},
+		{"FT8", FT8, 0, at(12, 0, 16, 0), at(12, 0, 30, 0)},
+		{"FT8 offset", FT8, 500 * time.Millisecond, at(12, 0, 14, 0), at(12, 0, 15, 500)},
+		{"FT4", FT4, 0, at(12, 0, 1, 0), at(12, 0, 7, 500)},
+		{"FT4 half second", FT4, 0, at(12, 0, 8, 0), at(12, 0, 15, 0)},
+		{"JT65", JT65, time.Second, at(12, 0, 2, 0), at(12, 1, 1, 0)},
+		{"WSPR", WSPR, 0, at(12, 3, 0, 0), at(12, 4, 0, 0)},
+		{"midnight", FT8, 0, at(23, 59, 50, 0), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			scheduler := New(&fakeClock{}, tC.duration, tC.offset)
+			assert.Equal(t, tC.expected, scheduler.NextStart(tC.now))
+		})
+	}
+}
+
+func TestSchedulerSlots(t *testing.T) {
+	scheduler := New(&fakeClock{}, FT8, time.Second)
+	assert.Equal(t, FT8, scheduler.Duration())
+	assert.Equal(t, time.Second, scheduler.Offset())
+
+	index := scheduler.Index(at(12, 0, 16, 0))
+	assert.Equal(t, index, scheduler.Index(at(12, 0, 30, 999)))
+	assert.Equal(t, index+1, scheduler.Index(at(12, 0, 31, 0)))
+	assert.Equal(t, int64(0), scheduler.Index(at(12, 0, 1, 0))%2, "the first slot of a minute is even")
+	assert.Equal(t, int64(1), index%2)
+	assert.Equal(t, at(12, 0, 16, 0), scheduler.Start(index))
+	assert.Equal(t, at(12, 0, 16, 0), scheduler.SlotStart(at(12, 0, 20, 0)))
+	assert.Equal(t, int64(-1), scheduler.Index(time.Unix(0, 0)))
+}
+
+func TestSchedulerWaitForStart(t *testing.T) {
+	clock := &fakeClock{now: at(12, 0, 3, 0)}
+	scheduler := New(clock, FT4, 0)
+
+	start, ok := scheduler.WaitForStart(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, at(12, 0, 7, 500), start)
+	assert.Equal(t, start, clock.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = scheduler.WaitForStart(ctx)
+	assert.False(t, ok)
+	assert.False(t, scheduler.WaitUntil(ctx, at(12, 1, 0, 0)))
+	assert.Equal(t, start, clock.Now(), "the canceled wait does not advance the clock")
+}
+
+func TestSchedulerWaitForTransmission(t *testing.T) {
+	clock := &fakeClock{now: at(12, 0, 3, 0)}
+	scheduler := New(clock, FT8, 0)
+
+	_, err := scheduler.WaitForTransmission(context.Background(), Channel{Region: bandplan.Region1, Mode: "cw", Frequency: 14100000, Bandwidth: 100})
+	assert.Error(t, err)
+	assert.Equal(t, at(12, 0, 3, 0), clock.Now(), "an invalid channel does not wait")
+
+	start, err := scheduler.WaitForTransmission(context.Background(), Channel{Region: bandplan.Region1, Mode: "cw", Frequency: 14030000, Bandwidth: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, at(12, 0, 15, 0), start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = scheduler.WaitForTransmission(ctx, Channel{Region: bandplan.Region1, Mode: "cw", Frequency: 14030000, Bandwidth: 100})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRealClock(t *testing.T) {
+	scheduler := New(nil, FT8, 0)
+	now := scheduler.Now()
+	assert.True(t, scheduler.WaitUntil(context.Background(), now.Add(10*time.Millisecond)))
+	assert.False(t, scheduler.Now().Before(now.Add(10*time.Millisecond)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, scheduler.WaitUntil(ctx, now.Add(time.Hour)))
+}
+
+func TestRealClockStop(t *testing.T) {
+	after, stop := RealClock(nil).After(10 * time.Millisecond)
+	assert.True(t, stop(), "the wait is released before it ends")
+	select {
+	case <-after:
+		t.Error("the stopped wait ended")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	after, stop = RealClock(nil).After(time.Millisecond)
+	<-after
+	assert.False(t, stop(), "the wait already ended")
+}
@@ -0,0 +1,189 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidConfig is returned for invalid calendar configurations.
+var ErrInvalidConfig = errors.New("schedule: invalid configuration")
+
+// Config describes a calendar rule in a form that is suitable for configuration files. All given conditions must
+// be met for the rule to be active; empty conditions are ignored.
+type Config struct {
+	// Days are the days of the week (UTC), e.g. "mon" or "saturday".
+	Days []string `json:"days,omitempty"`
+	// Windows are daily time windows (UTC), e.g. "22:00-06:00". The rule is active within any of the windows.
+	Windows []string `json:"windows,omitempty"`
+	// Blackouts are periods in which the rule is never active.
+	Blackouts []BlackoutConfig `json:"blackouts,omitempty"`
+	// Locator is the Maidenhead locator of the station, required for Sun.
+	Locator string `json:"locator,omitempty"`
+	// Sun is a window relative to sunrise and sunset: "night", "day" or "<event>[±offset]-<event>[±offset]",
+	// e.g. "sunset-30m-sunrise+30m".
+	Sun string `json:"sun,omitempty"`
+}
+
+// BlackoutConfig is a blackout period.
+type BlackoutConfig struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Rule builds the rule described by the configuration.
+func (c Config) Rule() (Rule, error) {
+	rules := make([]Rule, 0, 3)
+
+	if len(c.Days) > 0 {
+		days := make(Weekdays, 0, len(c.Days))
+		for _, name := range c.Days {
+			day, err := ParseWeekday(name)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, day)
+		}
+		rules = append(rules, days)
+	}
+
+	if len(c.Windows) > 0 {
+		windows := make([]Rule, 0, len(c.Windows))
+		for _, s := range c.Windows {
+			window, err := ParseWindow(s)
+			if err != nil {
+				return nil, err
+			}
+			windows = append(windows, window)
+		}
+		rules = append(rules, Any(windows...))
+	}
+
+	if c.Sun != "" {
+		position, err := LocatorPosition(c.Locator)
+		if err != nil {
+			return nil, err
+		}
+		window, err := ParseSunWindow(position, c.Sun)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, window)
+	}
+
+	var result Rule = Always
+	if len(rules) > 0 {
+		result = All(rules...)
+	}
+	if len(c.Blackouts) > 0 {
+		periods := make([]Period, len(c.Blackouts))
+		for i, blackout := range c.Blackouts {
+			if !blackout.To.After(blackout.From) {
+				return nil, fmt.Errorf("%w: blackout ends before it starts", ErrInvalidConfig)
+			}
+			periods[i] = Period{From: blackout.From, To: blackout.To}
+		}
+		result = Blackout(result, periods...)
+	}
+	return result, nil
+}
+
+// ParseWeekday parses the english name of a day of the week or its first three letters.
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if len(name) >= 3 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			dayName := strings.ToLower(day.String())
+			if name == dayName || name == dayName[:3] {
+				return day, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidConfig, s)
+}
+
+// ParseWindow parses a daily time window in the form "hh:mm-hh:mm".
+func ParseWindow(s string) (Window, error) {
+	fromString, toString, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("%w: invalid window %q", ErrInvalidConfig, s)
+	}
+	from, err := parseTimeOfDay(fromString)
+	if err != nil {
+		return Window{}, err
+	}
+	to, err := parseTimeOfDay(toString)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{From: from, To: to}, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time of day %q", ErrInvalidConfig, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseSunWindow parses a window relative to sunrise and sunset at the given position: "night", "day" or
+// "<event>[±offset]-<event>[±offset]", e.g. "sunset-30m-sunrise+30m".
+func ParseSunWindow(position Position, s string) (SunWindow, error) {
+	spec := strings.ToLower(strings.TrimSpace(s))
+	switch spec {
+	case "night":
+		return Night(position), nil
+	case "day":
+		return Daylight(position), nil
+	}
+
+	result := SunWindow{Position: position}
+	var err error
+	var rest string
+	result.From, result.FromOffset, rest, err = parseSunEvent(spec)
+	if err != nil {
+		return SunWindow{}, fmt.Errorf("%w: invalid sun window %q", ErrInvalidConfig, s)
+	}
+	if !strings.HasPrefix(rest, "-") {
+		return SunWindow{}, fmt.Errorf("%w: invalid sun window %q", ErrInvalidConfig, s)
+	}
+	result.To, result.ToOffset, rest, err = parseSunEvent(rest[1:])
+	if err != nil || rest != "" {
+		return SunWindow{}, fmt.Errorf("%w: invalid sun window %q", ErrInvalidConfig, s)
+	}
+	return result, nil
+}
+
+func parseSunEvent(s string) (SunEvent, time.Duration, string, error) {
+	var event SunEvent
+	switch {
+	case strings.HasPrefix(s, "sunrise"):
+		event = Sunrise
+		s = s[len("sunrise"):]
+	case strings.HasPrefix(s, "sunset"):
+		event = Sunset
+		s = s[len("sunset"):]
+	default:
+		return 0, 0, "", ErrInvalidConfig
+	}
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return event, 0, s, nil
+	}
+
+	// the offset is optional, a '-' may also separate the two events
+	end := 1
+	for end < len(s) && s[end] != '+' && s[end] != '-' {
+		end++
+	}
+	offset, err := time.ParseDuration(s[:end])
+	if err != nil {
+		return event, 0, s, nil
+	}
+	return event, offset, s[end:], nil
+}
@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigRule(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{
+		"days": ["sat", "Sunday"],
+		"locator": "JO62",
+		"sun": "night",
+		"blackouts": [{"from": "2020-06-27T00:00:00Z", "to": "2020-06-29T00:00:00Z"}]
+	}`), &config)
+	require.NoError(t, err)
+	rule, err := config.Rule()
+	require.NoError(t, err)
+
+	assert.True(t, rule.Active(at("2020-06-20T22:00:00Z")), "saturday night")
+	assert.False(t, rule.Active(at("2020-06-20T12:00:00Z")), "saturday noon")
+	assert.False(t, rule.Active(at("2020-06-22T22:00:00Z")), "monday night")
+	assert.False(t, rule.Active(at("2020-06-27T22:00:00Z")), "blackout")
+}
+
+func TestConfigWindows(t *testing.T) {
+	rule, err := Config{Windows: []string{"06:00-08:00", "22:00-02:00"}}.Rule()
+	require.NoError(t, err)
+	assert.True(t, rule.Active(at("2020-06-20T07:00:00Z")))
+	assert.True(t, rule.Active(at("2020-06-20T01:00:00Z")))
+	assert.False(t, rule.Active(at("2020-06-20T12:00:00Z")))
+
+	rule, err = Config{}.Rule()
+	require.NoError(t, err)
+	assert.True(t, rule.Active(at("2020-06-20T12:00:00Z")))
+}
+
+func TestConfigInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config Config
+	}{
+		{"weekday", Config{Days: []string{"xyz"}}},
+		{"window", Config{Windows: []string{"06:00"}}},
+		{"time of day", Config{Windows: []string{"06:00-25:00"}}},
+		{"sun", Config{Locator: "JO62", Sun: "dusk"}},
+		{"blackout", Config{Blackouts: []BlackoutConfig{{From: at("2020-06-21T00:00:00Z"), To: at("2020-06-20T00:00:00Z")}}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := tC.config.Rule()
+			assert.True(t, errors.Is(err, ErrInvalidConfig), "%v", err)
+		})
+	}
+
+	_, err := Config{Locator: "XX", Sun: "night"}.Rule()
+	assert.True(t, errors.Is(err, ErrInvalidLocator), "%v", err)
+}
+
+func TestParseSunWindow(t *testing.T) {
+	position := Position{Latitude: 52.5, Longitude: 13}
+	testCases := []struct {
+		value    string
+		expected SunWindow
+	}{
+		{"night", Night(position)},
+		{"Day", Daylight(position)},
+		{"sunset-sunrise", SunWindow{Position: position, From: Sunset, To: Sunrise}},
+		{"sunset-30m-sunrise+30m", SunWindow{Position: position, From: Sunset, FromOffset: -30 * time.Minute, To: Sunrise, ToOffset: 30 * time.Minute}},
+		{"sunrise+1h-sunset-1h30m", SunWindow{Position: position, From: Sunrise, FromOffset: time.Hour, To: Sunset, ToOffset: -90 * time.Minute}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			actual, err := ParseSunWindow(position, tC.value)
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Package schedule provides calendar rules that decide when a transmission is allowed: days of the week, daily time
+windows, blackout periods and windows relative to sunrise and sunset at a given locator. Rules can be combined and
+built from a JSON configuration, e.g. to express "WSPR on 630m only at night".
+*/
+package schedule
+
+import (
+	"time"
+)
+
+// Rule decides if a transmission is allowed at a given time.
+type Rule interface {
+	Active(t time.Time) bool
+}
+
+// RuleFunc is a function that implements Rule.
+type RuleFunc func(t time.Time) bool
+
+// Active calls f.
+func (f RuleFunc) Active(t time.Time) bool {
+	return f(t)
+}
+
+// Always is a rule that is always active.
+var Always Rule = RuleFunc(func(time.Time) bool { return true })
+
+// All returns a rule that is active if all of the given rules are active.
+func All(rules ...Rule) Rule {
+	return RuleFunc(func(t time.Time) bool {
+		for _, rule := range rules {
+			if !rule.Active(t) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Any returns a rule that is active if any of the given rules is active.
+func Any(rules ...Rule) Rule {
+	return RuleFunc(func(t time.Time) bool {
+		for _, rule := range rules {
+			if rule.Active(t) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not returns a rule that is active if the given rule is not active.
+func Not(rule Rule) Rule {
+	return RuleFunc(func(t time.Time) bool {
+		return !rule.Active(t)
+	})
+}
+
+// Weekdays is a rule that is active on the given days of the week (UTC).
+type Weekdays []time.Weekday
+
+// Active indicates if t is on one of the days.
+func (w Weekdays) Active(t time.Time) bool {
+	day := t.UTC().Weekday()
+	for _, d := range w {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Window is a rule that is active every day between two times of the day (UTC). If From is after To, the window
+// spans midnight.
+type Window struct {
+	// From is the start of the window as duration since midnight.
+	From time.Duration
+	// To is the end of the window as duration since midnight (exclusive).
+	To time.Duration
+}
+
+// Active indicates if t is within the window.
+func (w Window) Active(t time.Time) bool {
+	t = t.UTC()
+	timeOfDay := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.From <= w.To {
+		return timeOfDay >= w.From && timeOfDay < w.To
+	}
+	return timeOfDay >= w.From || timeOfDay < w.To
+}
+
+// Period is a rule that is active between two points in time.
+type Period struct {
+	From time.Time
+	// To is the end of the period (exclusive).
+	To time.Time
+}
+
+// Active indicates if t is within the period.
+func (p Period) Active(t time.Time) bool {
+	return !t.Before(p.From) && t.Before(p.To)
+}
+
+// Blackout returns a rule that is active if the given rule is active and t is not within any of the given periods.
+func Blackout(rule Rule, periods ...Period) Rule {
+	return RuleFunc(func(t time.Time) bool {
+		for _, period := range periods {
+			if period.Active(t) {
+				return false
+			}
+		}
+		return rule.Active(t)
+	})
+}
+
+// Next returns the first time at or after from, in steps of the given resolution, when the given rule is active.
+// It searches up to the given limit.
+func Next(rule Rule, from time.Time, resolution, limit time.Duration) (time.Time, bool) {
+	for t := from; t.Sub(from) <= limit; t = t.Add(resolution) {
+		if rule.Active(t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
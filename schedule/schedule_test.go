@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWeekdays(t *testing.T) {
+	weekend := Weekdays{time.Saturday, time.Sunday}
+	assert.True(t, weekend.Active(at("2020-06-20T12:00:00Z")))
+	assert.True(t, weekend.Active(at("2020-06-21T23:59:00Z")))
+	assert.False(t, weekend.Active(at("2020-06-22T00:00:00Z")))
+}
+
+func TestWindow(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		window   Window
+		value    string
+		expected bool
+	}{
+		{"inside", Window{From: 8 * time.Hour, To: 17 * time.Hour}, "2020-06-20T12:00:00Z", true},
+		{"start", Window{From: 8 * time.Hour, To: 17 * time.Hour}, "2020-06-20T08:00:00Z", true},
+		{"end", Window{From: 8 * time.Hour, To: 17 * time.Hour}, "2020-06-20T17:00:00Z", false},
+		{"midnight evening", Window{From: 22 * time.Hour, To: 6 * time.Hour}, "2020-06-20T23:00:00Z", true},
+		{"midnight morning", Window{From: 22 * time.Hour, To: 6 * time.Hour}, "2020-06-20T05:59:00Z", true},
+		{"midnight outside", Window{From: 22 * time.Hour, To: 6 * time.Hour}, "2020-06-20T12:00:00Z", false},
+		{"other zone", Window{From: 8 * time.Hour, To: 17 * time.Hour}, "2020-06-20T12:00:00+06:00", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tC.window.Active(at(tC.value)))
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	weekend := Weekdays{time.Saturday, time.Sunday}
+	evening := Window{From: 18 * time.Hour, To: 22 * time.Hour}
+	saturday := at("2020-06-20T19:00:00Z")
+	monday := at("2020-06-22T19:00:00Z")
+
+	assert.True(t, All(weekend, evening).Active(saturday))
+	assert.False(t, All(weekend, evening).Active(monday))
+	assert.True(t, Any(weekend, evening).Active(monday))
+	assert.False(t, Not(evening).Active(monday))
+	assert.True(t, All().Active(monday))
+	assert.False(t, Any().Active(monday))
+
+	blackout := Blackout(evening, Period{From: at("2020-06-20T00:00:00Z"), To: at("2020-06-21T00:00:00Z")})
+	assert.False(t, blackout.Active(saturday))
+	assert.True(t, blackout.Active(monday))
+}
+
+func TestNext(t *testing.T) {
+	evening := Window{From: 18 * time.Hour, To: 22 * time.Hour}
+
+	next, ok := Next(evening, at("2020-06-20T12:00:00Z"), time.Minute, 24*time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, at("2020-06-20T18:00:00Z"), next)
+
+	_, ok = Next(evening, at("2020-06-20T12:00:00Z"), time.Minute, time.Hour)
+	assert.False(t, ok)
+}
@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ftl/digimodes/locator"
+)
+
+// ErrInvalidLocator is returned for invalid Maidenhead locators.
+var ErrInvalidLocator = errors.New("schedule: invalid locator")
+
+// SunEvent is the rise or set of the sun.
+type SunEvent int
+
+// All sun events.
+const (
+	Sunrise SunEvent = iota
+	Sunset
+)
+
+// Position on the earth in degrees, north and east are positive.
+type Position struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// LocatorPosition returns the position of the center of the given 4, 6 or 8 character Maidenhead locator.
+func LocatorPosition(loc string) (Position, error) {
+	parsed, err := locator.Parse(loc)
+	if err != nil {
+		return Position{}, ErrInvalidLocator
+	}
+	latitude, longitude := parsed.LatLon()
+	return Position{Latitude: latitude, Longitude: longitude}, nil
+}
+
+// SunTimes returns sunrise and sunset at the given position on the UTC day of t. If the sun does not rise or set
+// on that day, ok is false and daylight tells if it is polar day (true) or polar night (false).
+func SunTimes(position Position, t time.Time) (sunrise, sunset time.Time, daylight bool, ok bool) {
+	// sunrise equation, see https://en.wikipedia.org/wiki/Sunrise_equation
+	const j2000 = 2451545.0
+	t = t.UTC()
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(julianDay(noon) - j2000 + 0.0008)
+
+	meanSolarTime := n - position.Longitude/360
+	meanAnomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	m := radians(meanAnomaly)
+	center := 1.9148*math.Sin(m) + 0.02*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	eclipticLongitude := radians(math.Mod(meanAnomaly+center+180+102.9372, 360))
+	transit := j2000 + meanSolarTime + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*eclipticLongitude)
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(radians(23.4397)))
+
+	latitude := radians(position.Latitude)
+	cosHourAngle := (math.Sin(radians(-0.833)) - math.Sin(latitude)*math.Sin(declination)) / (math.Cos(latitude) * math.Cos(declination))
+	switch {
+	case cosHourAngle < -1:
+		return time.Time{}, time.Time{}, true, false
+	case cosHourAngle > 1:
+		return time.Time{}, time.Time{}, false, false
+	}
+	hourAngle := math.Acos(cosHourAngle) / (2 * math.Pi)
+	return fromJulianDay(transit - hourAngle), fromJulianDay(transit + hourAngle), false, true
+}
+
+func julianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulianDay(jd float64) time.Time {
+	seconds := (jd - 2440587.5) * 86400
+	return time.Unix(0, int64(seconds*1e9)).UTC()
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// SunWindow is a rule that is active between two sun events at a given position, each shifted by an offset.
+// E.g. the night is the window from sunset to sunrise, the grey line around sunset is the window from sunset-30m
+// to sunset+30m.
+type SunWindow struct {
+	Position   Position
+	From       SunEvent
+	FromOffset time.Duration
+	To         SunEvent
+	ToOffset   time.Duration
+}
+
+// Night returns the window from sunset to sunrise at the given position.
+func Night(position Position) SunWindow {
+	return SunWindow{Position: position, From: Sunset, To: Sunrise}
+}
+
+// Daylight returns the window from sunrise to sunset at the given position.
+func Daylight(position Position) SunWindow {
+	return SunWindow{Position: position, From: Sunrise, To: Sunset}
+}
+
+// Active indicates if t is within the window. On days without sunrise or sunset, a window that starts at sunset
+// is active during polar night and a window that starts at sunrise is active during polar day.
+func (w SunWindow) Active(t time.Time) bool {
+	for _, day := range []time.Time{t.AddDate(0, 0, -1), t} {
+		from, ok, daylight := w.event(day, w.From)
+		if !ok {
+			if day.Equal(t) {
+				return daylight == (w.From == Sunrise)
+			}
+			continue
+		}
+		from = from.Add(w.FromOffset)
+		to, ok, _ := w.event(day, w.To)
+		if !ok {
+			continue
+		}
+		to = to.Add(w.ToOffset)
+		if !to.After(from) {
+			to, ok, _ = w.event(day.AddDate(0, 0, 1), w.To)
+			if !ok {
+				continue
+			}
+			to = to.Add(w.ToOffset)
+		}
+		if !t.Before(from) && t.Before(to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w SunWindow) event(day time.Time, event SunEvent) (time.Time, bool, bool) {
+	sunrise, sunset, daylight, ok := SunTimes(w.Position, day)
+	if event == Sunrise {
+		return sunrise, ok, daylight
+	}
+	return sunset, ok, daylight
+}
@@ -0,0 +1,85 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocatorPosition(t *testing.T) {
+	testCases := []struct {
+		locator  string
+		expected Position
+		invalid  bool
+	}{
+		{locator: "JO62", expected: Position{Latitude: 52.5, Longitude: 13}},
+		{locator: "jo62qm", expected: Position{Latitude: 52.520833, Longitude: 13.375}},
+		{locator: "AA00", expected: Position{Latitude: -89.5, Longitude: -179}},
+		{locator: "JO6", invalid: true},
+		{locator: "ZZ00", invalid: true},
+		{locator: "JO62ZZ", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.locator, func(t *testing.T) {
+			actual, err := LocatorPosition(tC.locator)
+			if tC.invalid {
+				assert.True(t, errors.Is(err, ErrInvalidLocator), "%v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tC.expected.Latitude, actual.Latitude, 0.0001)
+			assert.InDelta(t, tC.expected.Longitude, actual.Longitude, 0.0001)
+		})
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	berlin := Position{Latitude: 52.52, Longitude: 13.405}
+
+	sunrise, sunset, _, ok := SunTimes(berlin, at("2020-06-21T12:00:00Z"))
+	require.True(t, ok)
+	assert.WithinDuration(t, at("2020-06-21T02:43:00Z"), sunrise, 5*time.Minute)
+	assert.WithinDuration(t, at("2020-06-21T19:33:00Z"), sunset, 5*time.Minute)
+
+	sunrise, sunset, _, ok = SunTimes(berlin, at("2020-12-21T00:00:00Z"))
+	require.True(t, ok)
+	assert.WithinDuration(t, at("2020-12-21T07:15:00Z"), sunrise, 5*time.Minute)
+	assert.WithinDuration(t, at("2020-12-21T14:54:00Z"), sunset, 5*time.Minute)
+
+	tromso := Position{Latitude: 69.65, Longitude: 18.96}
+	_, _, daylight, ok := SunTimes(tromso, at("2020-06-21T12:00:00Z"))
+	assert.False(t, ok)
+	assert.True(t, daylight)
+	_, _, daylight, ok = SunTimes(tromso, at("2020-12-21T12:00:00Z"))
+	assert.False(t, ok)
+	assert.False(t, daylight)
+}
+
+func TestSunWindow(t *testing.T) {
+	berlin := Position{Latitude: 52.52, Longitude: 13.405}
+	greyline := SunWindow{Position: berlin, From: Sunset, FromOffset: -30 * time.Minute, To: Sunset, ToOffset: 30 * time.Minute}
+	testCases := []struct {
+		desc     string
+		window   SunWindow
+		value    string
+		expected bool
+	}{
+		{"night evening", Night(berlin), "2020-06-21T22:00:00Z", true},
+		{"night after midnight", Night(berlin), "2020-06-22T01:00:00Z", true},
+		{"night at noon", Night(berlin), "2020-06-21T12:00:00Z", false},
+		{"day at noon", Daylight(berlin), "2020-06-21T12:00:00Z", true},
+		{"day at midnight", Daylight(berlin), "2020-06-21T00:00:00Z", false},
+		{"greyline", greyline, "2020-06-21T19:20:00Z", true},
+		{"after greyline", greyline, "2020-06-21T20:30:00Z", false},
+		{"polar night", Night(Position{Latitude: 69.65, Longitude: 18.96}), "2020-12-21T12:00:00Z", true},
+		{"polar day", Night(Position{Latitude: 69.65, Longitude: 18.96}), "2020-06-21T00:00:00Z", false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tC.window.Active(at(tC.value)))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Package sdr connects the modulators of this package to software defined radios. The Upconverter turns the
+audio of a modulator into an SSB signal in complex baseband (IQ), which is written to an IQSink, e.g. a
+SoapySDR device.
+
+The SoapySDR device requires cgo and the SoapySDR development files. It is only built with the build tag
+"soapy", without it Open returns ErrNotSupported. Any device with a SoapySDR driver can be used, e.g. a HackRF
+with the args "driver=hackrf".
+*/
+package sdr
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/dsp"
+)
+
+var (
+	// ErrNotSupported is returned by Open if the package was built without SoapySDR support.
+	ErrNotSupported = errors.New("sdr: not supported, build with tag \"soapy\"")
+	// ErrClosed is returned when a closed device is used.
+	ErrClosed = errors.New("sdr: device closed")
+)
+
+// hilbertLength is the number of taps of the Hilbert transformer.
+const hilbertLength = 127
+
+// Config of a SoapySDR device.
+type Config struct {
+	// Args select the device, e.g. "driver=hackrf".
+	Args string
+	// Channel is the transmit channel of the device.
+	Channel int
+	// SampleRate of the IQ samples in Hz.
+	SampleRate int
+	// Frequency is the center frequency in Hz.
+	Frequency float64
+	// Gain is the overall transmit gain in dB.
+	Gain float64
+}
+
+// IQSink consumes complex baseband samples.
+type IQSink interface {
+	// SampleRate returns the sample rate of the sink in Hz.
+	SampleRate() int
+	// WriteIQ writes the given samples to the sink. It blocks until all samples are consumed or an error occurs.
+	WriteIQ(samples []complex128) (int, error)
+}
+
+// Upconverter is an audio sink that modulates the audio as single sideband signal into complex baseband and
+// writes it to an IQSink. The audio frequency becomes the offset from the center frequency of the sink, positive
+// for USB and negative for LSB. The sample rate of the sink must be a multiple of the audio sample rate.
+type Upconverter struct {
+	sink       IQSink
+	sampleRate int
+	sideband   bandplan.Sideband
+
+	hilbert []float64
+	history []float64
+	inPhase *dsp.Interpolator
+	quadr   *dsp.Interpolator
+	i, q    []float64
+	outI    []float64
+	outQ    []float64
+	iq      []complex128
+}
+
+// NewUpconverter returns a new Upconverter for audio with the given sample rate.
+func NewUpconverter(sink IQSink, sampleRate int, sideband bandplan.Sideband) (*Upconverter, error) {
+	if sampleRate <= 0 || sink.SampleRate()%sampleRate != 0 {
+		return nil, dsp.ErrInvalidRate
+	}
+	factor := sink.SampleRate() / sampleRate
+	return &Upconverter{
+		sink:       sink,
+		sampleRate: sampleRate,
+		sideband:   sideband,
+		hilbert:    hilbert(hilbertLength),
+		history:    make([]float64, hilbertLength),
+		inPhase:    dsp.NewInterpolator(interpolationFilter(sampleRate, factor), factor),
+		quadr:      dsp.NewInterpolator(interpolationFilter(sampleRate, factor), factor),
+	}, nil
+}
+
+// interpolationFilter returns the lowpass taps of the interpolators, scaled to compensate the inserted zeros.
+func interpolationFilter(sampleRate, factor int) []float64 {
+	if factor == 1 {
+		return []float64{1}
+	}
+	taps := dsp.LowPass(0.45*float64(sampleRate), sampleRate*factor, 16*factor+1)
+	for i := range taps {
+		taps[i] *= float64(factor)
+	}
+	return taps
+}
+
+// hilbert returns the taps of a Hamming windowed Hilbert transformer with the given odd length.
+func hilbert(length int) []float64 {
+	result := make([]float64, length)
+	center := length / 2
+	for i := range result {
+		n := i - center
+		if n%2 == 0 {
+			continue
+		}
+		window := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(length-1))
+		result[i] = 2 / (math.Pi * float64(n)) * window
+	}
+	return result
+}
+
+// SampleRate returns the audio sample rate in Hz.
+func (u *Upconverter) SampleRate() int {
+	return u.sampleRate
+}
+
+// WriteSamples modulates the given audio samples and writes the IQ samples to the sink. It returns the number of
+// audio samples that were written.
+func (u *Upconverter) WriteSamples(samples []float64) (int, error) {
+	if cap(u.i) < len(samples) {
+		u.i = make([]float64, len(samples))
+		u.q = make([]float64, len(samples))
+	}
+	u.i = u.i[:len(samples)]
+	u.q = u.q[:len(samples)]
+
+	center := len(u.history) / 2
+	for n, x := range samples {
+		copy(u.history[1:], u.history)
+		u.history[0] = x
+		var y float64
+		for k, tap := range u.hilbert {
+			y += tap * u.history[k]
+		}
+		// the in-phase component is delayed like the output of the Hilbert transformer
+		u.i[n] = u.history[center]
+		if u.sideband == bandplan.LSB {
+			u.q[n] = -y
+		} else {
+			u.q[n] = y
+		}
+	}
+
+	factor := u.inPhase.Factor()
+	size := len(samples) * factor
+	if cap(u.outI) < size {
+		u.outI = make([]float64, size)
+		u.outQ = make([]float64, size)
+		u.iq = make([]complex128, size)
+	}
+	u.inPhase.Interpolate(u.outI[:size], u.i)
+	u.quadr.Interpolate(u.outQ[:size], u.q)
+	iq := u.iq[:size]
+	for n := range iq {
+		iq[n] = complex(u.outI[n], u.outQ[n])
+	}
+
+	n, err := u.sink.WriteIQ(iq)
+	return n / factor, err
+}
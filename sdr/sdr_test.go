@@ -0,0 +1,86 @@
+package sdr
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/dsp"
+)
+
+type bufferSink struct {
+	sampleRate int
+	samples    []complex128
+}
+
+func (s *bufferSink) SampleRate() int {
+	return s.sampleRate
+}
+
+func (s *bufferSink) WriteIQ(samples []complex128) (int, error) {
+	s.samples = append(s.samples, samples...)
+	return len(samples), nil
+}
+
+// magnitude returns the magnitude of the given frequency in the IQ samples.
+func magnitude(samples []complex128, frequency float64, sampleRate int) float64 {
+	var sum complex128
+	for n, x := range samples {
+		sum += x * cmplx.Exp(complex(0, -2*math.Pi*frequency*float64(n)/float64(sampleRate)))
+	}
+	return cmplx.Abs(sum) / float64(len(samples))
+}
+
+func TestUpconverter(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		sideband bandplan.Sideband
+		expected float64
+	}{
+		{"usb", bandplan.USB, 1000},
+		{"lsb", bandplan.LSB, -1000},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			sink := &bufferSink{sampleRate: 48000}
+			upconverter, err := NewUpconverter(sink, 12000, tC.sideband)
+			require.NoError(t, err)
+			assert.Equal(t, 12000, upconverter.SampleRate())
+
+			audio := make([]float64, 12000)
+			for i := range audio {
+				audio[i] = 0.5 * math.Cos(2*math.Pi*1000*float64(i)/12000)
+			}
+			for i := 0; i < len(audio); i += 600 {
+				n, err := upconverter.WriteSamples(audio[i : i+600])
+				require.NoError(t, err)
+				assert.Equal(t, 600, n)
+			}
+			require.Len(t, sink.samples, 4*len(audio))
+
+			// skip the transient of the filters
+			samples := sink.samples[4000:]
+			wanted := magnitude(samples, tC.expected, 48000)
+			image := magnitude(samples, -tC.expected, 48000)
+			assert.InDelta(t, 0.5, wanted, 0.02)
+			assert.True(t, 20*math.Log10(wanted/image) > 40, "sideband suppression %.1fdB", 20*math.Log10(wanted/image))
+		})
+	}
+}
+
+func TestUpconverterInvalidRate(t *testing.T) {
+	_, err := NewUpconverter(&bufferSink{sampleRate: 48000}, 11025, bandplan.USB)
+	assert.Equal(t, dsp.ErrInvalidRate, err)
+}
+
+func TestOpenWithoutSoapySDR(t *testing.T) {
+	_, err := Open(Config{Args: "driver=hackrf"})
+	if err != ErrNotSupported {
+		t.Skip("built with SoapySDR support")
+	}
+	assert.Equal(t, ErrNotSupported, err)
+}
@@ -0,0 +1,199 @@
+//go:build soapy
+
+package sdr
+
+/*
+#cgo pkg-config: SoapySDR
+#include <stdlib.h>
+#include <SoapySDR/Device.h>
+#include <SoapySDR/Errors.h>
+#include <SoapySDR/Formats.h>
+
+static SoapySDRStream *dm_setup_tx_stream(SoapySDRDevice *device, size_t channel) {
+	size_t channels[] = {channel};
+	return SoapySDRDevice_setupStream(device, SOAPY_SDR_TX, SOAPY_SDR_CF32, channels, 1, NULL);
+}
+
+static int dm_write(SoapySDRDevice *device, SoapySDRStream *stream, void *samples, size_t count, int flags, long long timeNs) {
+	const void *buffs[] = {samples};
+	return SoapySDRDevice_writeStream(device, stream, buffs, count, &flags, timeNs, 1000000);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Device is a SoapySDR device that transmits IQ samples. It implements IQSink.
+type Device struct {
+	mu         sync.Mutex
+	device     *C.SoapySDRDevice
+	stream     *C.SoapySDRStream
+	channel    C.size_t
+	sampleRate int
+	buffer     []complex64
+}
+
+// Open opens the device with the given configuration and activates the transmit stream.
+func Open(config Config) (*Device, error) {
+	args := C.CString(config.Args)
+	defer C.free(unsafe.Pointer(args))
+	device := C.SoapySDRDevice_makeStrArgs(args)
+	if device == nil {
+		return nil, fmt.Errorf("sdr: cannot open device %q: %s", config.Args, lastError())
+	}
+	result := &Device{
+		device:  device,
+		channel: C.size_t(config.Channel),
+	}
+	err := result.configure(config)
+	if err != nil {
+		C.SoapySDRDevice_unmake(device)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *Device) configure(config Config) error {
+	if err := d.SetSampleRate(config.SampleRate); err != nil {
+		return err
+	}
+	if err := d.SetFrequency(config.Frequency); err != nil {
+		return err
+	}
+	if err := d.SetGain(config.Gain); err != nil {
+		return err
+	}
+	d.stream = C.dm_setup_tx_stream(d.device, d.channel)
+	if d.stream == nil {
+		return fmt.Errorf("sdr: cannot setup the transmit stream: %s", lastError())
+	}
+	if C.SoapySDRDevice_activateStream(d.device, d.stream, 0, 0, 0) != 0 {
+		C.SoapySDRDevice_closeStream(d.device, d.stream)
+		return fmt.Errorf("sdr: cannot activate the transmit stream: %s", lastError())
+	}
+	return nil
+}
+
+func lastError() string {
+	return C.GoString(C.SoapySDRDevice_lastError())
+}
+
+// SampleRate returns the sample rate of the IQ samples in Hz.
+func (d *Device) SampleRate() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sampleRate
+}
+
+// SetSampleRate sets the sample rate of the IQ samples in Hz.
+func (d *Device) SetSampleRate(sampleRate int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.device == nil {
+		return ErrClosed
+	}
+	if C.SoapySDRDevice_setSampleRate(d.device, C.SOAPY_SDR_TX, d.channel, C.double(sampleRate)) != 0 {
+		return fmt.Errorf("sdr: cannot set the sample rate to %d: %s", sampleRate, lastError())
+	}
+	d.sampleRate = sampleRate
+	return nil
+}
+
+// SetFrequency sets the center frequency in Hz.
+func (d *Device) SetFrequency(frequency float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.device == nil {
+		return ErrClosed
+	}
+	if C.SoapySDRDevice_setFrequency(d.device, C.SOAPY_SDR_TX, d.channel, C.double(frequency), nil) != 0 {
+		return fmt.Errorf("sdr: cannot set the frequency to %.0fHz: %s", frequency, lastError())
+	}
+	return nil
+}
+
+// SetGain sets the overall transmit gain in dB.
+func (d *Device) SetGain(gain float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.device == nil {
+		return ErrClosed
+	}
+	if C.SoapySDRDevice_setGain(d.device, C.SOAPY_SDR_TX, d.channel, C.double(gain)) != 0 {
+		return fmt.Errorf("sdr: cannot set the gain to %.1fdB: %s", gain, lastError())
+	}
+	return nil
+}
+
+// WriteIQ transmits the given samples immediately.
+func (d *Device) WriteIQ(samples []complex128) (int, error) {
+	return d.write(samples, 0, 0)
+}
+
+// WriteBurst transmits the given samples as burst that starts at the given time. The time is mapped to the
+// hardware time of the device, using the current system time as reference. A zero time starts immediately.
+func (d *Device) WriteBurst(samples []complex128, at time.Time) (int, error) {
+	flags := C.int(C.SOAPY_SDR_END_BURST)
+	var timeNs C.longlong
+	if !at.IsZero() {
+		d.mu.Lock()
+		if d.device == nil {
+			d.mu.Unlock()
+			return 0, ErrClosed
+		}
+		hardwareTime := C.SoapySDRDevice_getHardwareTime(d.device, nil)
+		d.mu.Unlock()
+		timeNs = hardwareTime + C.longlong(time.Until(at).Nanoseconds())
+		flags |= C.SOAPY_SDR_HAS_TIME
+	}
+	return d.write(samples, flags, timeNs)
+}
+
+func (d *Device) write(samples []complex128, flags C.int, timeNs C.longlong) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.device == nil {
+		return 0, ErrClosed
+	}
+	if cap(d.buffer) < len(samples) {
+		d.buffer = make([]complex64, len(samples))
+	}
+	buffer := d.buffer[:len(samples)]
+	for i, s := range samples {
+		buffer[i] = complex64(s)
+	}
+
+	written := 0
+	for written < len(buffer) {
+		// only the first call carries the start time of the burst
+		chunkFlags := flags
+		if written > 0 {
+			chunkFlags &^= C.SOAPY_SDR_HAS_TIME
+		}
+		n := C.dm_write(d.device, d.stream, unsafe.Pointer(&buffer[written]), C.size_t(len(buffer)-written), chunkFlags, timeNs)
+		if n < 0 {
+			return written, fmt.Errorf("sdr: cannot write samples: %s", C.GoString(C.SoapySDR_errToStr(n)))
+		}
+		written += int(n)
+	}
+	return written, nil
+}
+
+// Close deactivates the transmit stream and releases the device.
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.device == nil {
+		return nil
+	}
+	C.SoapySDRDevice_deactivateStream(d.device, d.stream, 0, 0)
+	C.SoapySDRDevice_closeStream(d.device, d.stream)
+	C.SoapySDRDevice_unmake(d.device)
+	d.device = nil
+	return nil
+}
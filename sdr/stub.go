@@ -0,0 +1,48 @@
+//go:build !soapy
+
+package sdr
+
+import "time"
+
+// Device is a SoapySDR device that transmits IQ samples. Without SoapySDR support, no device can be opened.
+type Device struct{}
+
+// Open returns ErrNotSupported, because the package was built without SoapySDR support.
+func Open(config Config) (*Device, error) {
+	return nil, ErrNotSupported
+}
+
+// SampleRate returns the sample rate of the IQ samples in Hz.
+func (d *Device) SampleRate() int {
+	return 0
+}
+
+// SetSampleRate sets the sample rate of the IQ samples in Hz.
+func (d *Device) SetSampleRate(sampleRate int) error {
+	return ErrNotSupported
+}
+
+// SetFrequency sets the center frequency in Hz.
+func (d *Device) SetFrequency(frequency float64) error {
+	return ErrNotSupported
+}
+
+// SetGain sets the overall transmit gain in dB.
+func (d *Device) SetGain(gain float64) error {
+	return ErrNotSupported
+}
+
+// WriteIQ transmits the given samples immediately.
+func (d *Device) WriteIQ(samples []complex128) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// WriteBurst transmits the given samples as burst that starts at the given time.
+func (d *Device) WriteBurst(samples []complex128, at time.Time) (int, error) {
+	return 0, ErrNotSupported
+}
+
+// Close releases the device.
+func (d *Device) Close() error {
+	return nil
+}
@@ -0,0 +1,151 @@
+package sequencer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMessage is returned when a text is not a standard FT8/FT4 QSO message.
+var ErrInvalidMessage = errors.New("sequencer: invalid message")
+
+// MessageKind is the kind of a standard FT8/FT4 QSO message.
+type MessageKind int
+
+// All message kinds.
+const (
+	// CQ calls: "CQ DL1ABC JO31", "CQ DX DL1ABC JO31", "CQ DL1ABC"
+	CQ MessageKind = iota
+	// Grid is the answer to a CQ: "DL1ABC W1AW FN31"
+	Grid
+	// Report: "W1AW DL1ABC -05"
+	Report
+	// RogerReport: "DL1ABC W1AW R-12"
+	RogerReport
+	// RRR: "W1AW DL1ABC RRR"
+	RRR
+	// RR73: "W1AW DL1ABC RR73"
+	RR73
+	// SeventyThree: "DL1ABC W1AW 73"
+	SeventyThree
+)
+
+var (
+	gridExpression   = regexp.MustCompile(`^[A-R]{2}[0-9]{2}$`)
+	reportExpression = regexp.MustCompile(`^(R)?([+-][0-9]{2})$`)
+)
+
+// Message is a standard FT8/FT4 QSO message.
+type Message struct {
+	Kind MessageKind
+	// To is the addressed callsign. It is empty for CQ messages.
+	To string
+	// From is the callsign of the sender.
+	From string
+	// Modifier of a CQ message, e.g. "DX" or "EU".
+	Modifier string
+	// Grid is the four character grid square.
+	Grid string
+	// Report is the signal report in dB.
+	Report int
+}
+
+// ParseMessage parses the given text as standard FT8/FT4 QSO message.
+func ParseMessage(text string) (Message, error) {
+	fields := strings.Fields(strings.ToUpper(text))
+	if len(fields) < 2 {
+		return Message{}, ErrInvalidMessage
+	}
+
+	if fields[0] == "CQ" {
+		result := Message{Kind: CQ}
+		fields = fields[1:]
+		if gridExpression.MatchString(fields[len(fields)-1]) {
+			result.Grid = fields[len(fields)-1]
+			fields = fields[:len(fields)-1]
+		}
+		switch len(fields) {
+		case 1:
+			result.From = fields[0]
+		case 2:
+			result.Modifier = fields[0]
+			result.From = fields[1]
+		default:
+			return Message{}, ErrInvalidMessage
+		}
+		return result, nil
+	}
+
+	if len(fields) != 3 {
+		return Message{}, ErrInvalidMessage
+	}
+	result := Message{To: fields[0], From: fields[1]}
+	last := fields[2]
+	switch {
+	case last == "RRR":
+		result.Kind = RRR
+	case last == "RR73":
+		result.Kind = RR73
+	case last == "73":
+		result.Kind = SeventyThree
+	case gridExpression.MatchString(last):
+		result.Kind = Grid
+		result.Grid = last
+	case reportExpression.MatchString(last):
+		groups := reportExpression.FindStringSubmatch(last)
+		result.Kind = Report
+		if groups[1] == "R" {
+			result.Kind = RogerReport
+		}
+		result.Report, _ = strconv.Atoi(groups[2])
+	default:
+		return Message{}, ErrInvalidMessage
+	}
+	return result, nil
+}
+
+// String returns the text of the message.
+func (m Message) String() string {
+	switch m.Kind {
+	case CQ:
+		return strings.Join(nonEmpty("CQ", m.Modifier, m.From, m.Grid), " ")
+	case Grid:
+		return strings.Join(nonEmpty(m.To, m.From, m.Grid), " ")
+	case Report:
+		return fmt.Sprintf("%s %s %s", m.To, m.From, FormatReport(m.Report))
+	case RogerReport:
+		return fmt.Sprintf("%s %s R%s", m.To, m.From, FormatReport(m.Report))
+	case RRR:
+		return fmt.Sprintf("%s %s RRR", m.To, m.From)
+	case RR73:
+		return fmt.Sprintf("%s %s RR73", m.To, m.From)
+	case SeventyThree:
+		return fmt.Sprintf("%s %s 73", m.To, m.From)
+	default:
+		return ""
+	}
+}
+
+// FormatReport formats the given signal report in dB with sign and two digits. The report is limited to the
+// range of the standard messages (-30dB to +49dB).
+func FormatReport(report int) string {
+	switch {
+	case report < -30:
+		report = -30
+	case report > 49:
+		report = 49
+	}
+	return fmt.Sprintf("%+03d", report)
+}
+
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package sequencer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessage(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected Message
+		invalid  bool
+	}{
+		{text: "CQ DL1ABC JO31", expected: Message{Kind: CQ, From: "DL1ABC", Grid: "JO31"}},
+		{text: "CQ DX DL1ABC JO31", expected: Message{Kind: CQ, Modifier: "DX", From: "DL1ABC", Grid: "JO31"}},
+		{text: "CQ DL1ABC", expected: Message{Kind: CQ, From: "DL1ABC"}},
+		{text: "DL1ABC W1AW FN31", expected: Message{Kind: Grid, To: "DL1ABC", From: "W1AW", Grid: "FN31"}},
+		{text: "W1AW DL1ABC -05", expected: Message{Kind: Report, To: "W1AW", From: "DL1ABC", Report: -5}},
+		{text: "DL1ABC W1AW R+12", expected: Message{Kind: RogerReport, To: "DL1ABC", From: "W1AW", Report: 12}},
+		{text: "W1AW DL1ABC RRR", expected: Message{Kind: RRR, To: "W1AW", From: "DL1ABC"}},
+		{text: "W1AW DL1ABC RR73", expected: Message{Kind: RR73, To: "W1AW", From: "DL1ABC"}},
+		{text: "DL1ABC W1AW 73", expected: Message{Kind: SeventyThree, To: "DL1ABC", From: "W1AW"}},
+		{text: "CQ", invalid: true},
+		{text: "CQ TEST EU DL1ABC JO31", invalid: true},
+		{text: "DL1ABC W1AW", invalid: true},
+		{text: "DL1ABC W1AW TNX", invalid: true},
+		{text: "TNX FER QSO 73", invalid: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.text, func(t *testing.T) {
+			actual, err := ParseMessage(tC.text)
+			if tC.invalid {
+				assert.Equal(t, ErrInvalidMessage, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+			assert.Equal(t, tC.text, actual.String())
+		})
+	}
+}
+
+func TestFormatReport(t *testing.T) {
+	assert.Equal(t, "-05", FormatReport(-5))
+	assert.Equal(t, "+00", FormatReport(0))
+	assert.Equal(t, "+12", FormatReport(12))
+	assert.Equal(t, "-30", FormatReport(-42))
+	assert.Equal(t, "+49", FormatReport(60))
+}
@@ -0,0 +1,360 @@
+/*
+Package sequencer implements the QSO sequence of the FT8 and FT4 modes. The sequencer answers a CQ or calls CQ
+itself and progresses through the exchange of grid, report, roger and 73. It handles timeouts with a limited
+number of retries. The selection of a caller and the logging of completed QSOs are left to hooks.
+
+The sequencer works slot by slot: after each receive slot, Next takes the decodes of that slot and returns the
+message to transmit in the following transmit slot.
+*/
+package sequencer
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// DefaultMaxRetries is the number of times a message is repeated without an answer before the QSO is abandoned.
+const DefaultMaxRetries = 3
+
+// ErrNotCQ is returned when Answer is called with a message that is not a CQ call.
+var ErrNotCQ = errors.New("sequencer: not a CQ call")
+
+// State of the sequencer.
+type State int
+
+// All states of the sequencer. The name tells what the sequencer transmits.
+const (
+	Idle State = iota
+	CallingCQ
+	SendingGrid
+	SendingReport
+	SendingRogerReport
+	SendingRoger
+	Sending73
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case CallingCQ:
+		return "calling CQ"
+	case SendingGrid:
+		return "sending grid"
+	case SendingReport:
+		return "sending report"
+	case SendingRogerReport:
+		return "sending roger report"
+	case SendingRoger:
+		return "sending roger"
+	case Sending73:
+		return "sending 73"
+	default:
+		return "unknown"
+	}
+}
+
+// QSO is the information exchanged with another station.
+type QSO struct {
+	Mode           string
+	Call           string
+	Grid           string
+	SentReport     int
+	ReceivedReport int
+	Start          time.Time
+	End            time.Time
+}
+
+// Candidate is a station that answered our CQ.
+type Candidate struct {
+	Message
+	Decode digimodes.DecodeRecord
+}
+
+// Hooks are called by the sequencer. All hooks are optional.
+type Hooks struct {
+	// SelectCall selects one of the stations that answered our CQ. It returns the index of the selected
+	// candidate, or -1 to ignore all candidates. By default, the first candidate is selected.
+	SelectCall func(candidates []Candidate) int
+	// Log is called when a QSO is complete.
+	Log func(QSO)
+	// Abandoned is called when a QSO is abandoned because the other station did not answer.
+	Abandoned func(QSO)
+}
+
+// Config of the sequencer.
+type Config struct {
+	// Mode is the name of the mode that is logged, e.g. "FT8".
+	Mode string
+	// Callsign is our callsign.
+	Callsign string
+	// Grid is our four character grid square.
+	Grid string
+	// MaxRetries is the number of times a message is repeated without an answer. 0 means DefaultMaxRetries.
+	MaxRetries int
+	// UseRRR sends RRR instead of RR73 and waits for the 73 of the other station.
+	UseRRR bool
+	// ResumeCQ continues calling CQ after a QSO that was started with a CQ call.
+	ResumeCQ bool
+}
+
+// Sequencer is the QSO state machine.
+type Sequencer struct {
+	config Config
+	hooks  Hooks
+	clock  timesource.Clock
+
+	mu       sync.Mutex
+	state    State
+	callerCQ bool
+	qso      QSO
+	logged   bool
+	retries  int
+}
+
+// New returns a new idle Sequencer. If clock is nil, the system clock is used.
+func New(config Config, hooks Hooks, clock timesource.Clock) *Sequencer {
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	config.Callsign = strings.ToUpper(config.Callsign)
+	config.Grid = strings.ToUpper(config.Grid)
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	return &Sequencer{
+		config: config,
+		hooks:  hooks,
+		clock:  clock,
+	}
+}
+
+// State returns the current state.
+func (s *Sequencer) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// QSO returns the current QSO.
+func (s *Sequencer) QSO() QSO {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.qso
+}
+
+// CallCQ starts calling CQ.
+func (s *Sequencer) CallCQ() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callerCQ = true
+	s.enter(CallingCQ)
+	s.qso = QSO{}
+	s.logged = false
+}
+
+// Answer starts a QSO with the station that sent the given decoded CQ call.
+func (s *Sequencer) Answer(decode digimodes.DecodeRecord) error {
+	message, err := ParseMessage(decode.Text)
+	if err != nil {
+		return err
+	}
+	if message.Kind != CQ {
+		return ErrNotCQ
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callerCQ = false
+	s.startQSO(message, decode)
+	s.enter(SendingGrid)
+	return nil
+}
+
+// Abort stops the sequencer immediately without logging the current QSO.
+func (s *Sequencer) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callerCQ = false
+	s.enter(Idle)
+}
+
+// Transmission returns the message for the next transmit slot in the current state.
+func (s *Sequencer) Transmission() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transmission()
+}
+
+// Next processes the decodes of a receive slot and returns the message for the next transmit slot.
+func (s *Sequencer) Next(decodes []digimodes.DecodeRecord) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []Candidate
+	var answer *Candidate
+	for _, decode := range decodes {
+		message, err := ParseMessage(decode.Text)
+		if err != nil || message.To != s.config.Callsign {
+			continue
+		}
+		candidate := Candidate{Message: message, Decode: decode}
+		switch {
+		case s.state == CallingCQ && (message.Kind == Grid || message.Kind == Report):
+			candidates = append(candidates, candidate)
+		case s.state != CallingCQ && s.state != Idle && message.From == s.qso.Call:
+			answer = &candidate
+		}
+	}
+
+	if len(candidates) > 0 {
+		answer = s.selectCall(candidates)
+		if answer != nil {
+			s.startQSO(answer.Message, answer.Decode)
+		}
+	}
+
+	if answer == nil {
+		s.timeout()
+	} else {
+		s.respond(*answer)
+	}
+	return s.transmission()
+}
+
+func (s *Sequencer) selectCall(candidates []Candidate) *Candidate {
+	index := 0
+	if s.hooks.SelectCall != nil {
+		index = s.hooks.SelectCall(candidates)
+	}
+	if index < 0 || index >= len(candidates) {
+		return nil
+	}
+	return &candidates[index]
+}
+
+func (s *Sequencer) startQSO(message Message, decode digimodes.DecodeRecord) {
+	s.qso = QSO{
+		Mode:       s.config.Mode,
+		Call:       message.From,
+		Grid:       message.Grid,
+		SentReport: int(decode.SNR),
+		Start:      s.clock.Now(),
+	}
+	s.logged = false
+}
+
+func (s *Sequencer) respond(answer Candidate) {
+	if answer.Grid != "" {
+		s.qso.Grid = answer.Grid
+	}
+	switch answer.Kind {
+	case Grid:
+		s.qso.SentReport = int(answer.Decode.SNR)
+		s.enter(SendingReport)
+	case Report:
+		s.qso.SentReport = int(answer.Decode.SNR)
+		s.qso.ReceivedReport = answer.Report
+		s.enter(SendingRogerReport)
+	case RogerReport:
+		s.qso.ReceivedReport = answer.Report
+		s.enter(SendingRoger)
+		if !s.config.UseRRR {
+			// RR73 does not need to be confirmed
+			s.log()
+		}
+	case RRR, RR73:
+		s.log()
+		s.enter(Sending73)
+	case SeventyThree:
+		if s.state == SendingRoger {
+			s.log()
+		}
+		s.finish()
+	default:
+		s.timeout()
+	}
+}
+
+func (s *Sequencer) timeout() {
+	switch s.state {
+	case Idle, CallingCQ:
+		return
+	case Sending73:
+		s.finish()
+		return
+	}
+
+	s.retries++
+	if s.retries <= s.config.MaxRetries {
+		return
+	}
+	switch {
+	case s.state == SendingRoger:
+		// both reports were exchanged, the QSO is valid without the final 73
+		s.log()
+	case s.hooks.Abandoned != nil:
+		s.hooks.Abandoned(s.qso)
+	}
+	s.finish()
+}
+
+// log reports the current QSO to the log hook, but only once.
+func (s *Sequencer) log() {
+	if s.logged {
+		return
+	}
+	s.logged = true
+	s.qso.End = s.clock.Now()
+	if s.hooks.Log != nil {
+		s.hooks.Log(s.qso)
+	}
+}
+
+func (s *Sequencer) finish() {
+	if s.callerCQ && s.config.ResumeCQ {
+		s.enter(CallingCQ)
+	} else {
+		s.callerCQ = false
+		s.enter(Idle)
+	}
+}
+
+func (s *Sequencer) enter(state State) {
+	if s.state != state {
+		s.retries = 0
+	}
+	s.state = state
+}
+
+func (s *Sequencer) transmission() (string, bool) {
+	message := Message{To: s.qso.Call, From: s.config.Callsign}
+	switch s.state {
+	case CallingCQ:
+		message = Message{Kind: CQ, From: s.config.Callsign, Grid: s.config.Grid}
+	case SendingGrid:
+		message.Kind = Grid
+		message.Grid = s.config.Grid
+	case SendingReport:
+		message.Kind = Report
+		message.Report = s.qso.SentReport
+	case SendingRogerReport:
+		message.Kind = RogerReport
+		message.Report = s.qso.SentReport
+	case SendingRoger:
+		message.Kind = RR73
+		if s.config.UseRRR {
+			message.Kind = RRR
+		}
+	case Sending73:
+		message.Kind = SeventyThree
+	default:
+		return "", false
+	}
+	return message.String(), true
+}
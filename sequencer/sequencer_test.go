@@ -0,0 +1,138 @@
+package sequencer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/timesource"
+)
+
+var testClock = timesource.ClockFunc(func() time.Time {
+	return time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+})
+
+func decode(text string, snr float64) digimodes.DecodeRecord {
+	return digimodes.DecodeRecord{Mode: "ft8", Text: text, SNR: snr}
+}
+
+type step struct {
+	decodes  []digimodes.DecodeRecord
+	expected string
+}
+
+func runSteps(t *testing.T, s *Sequencer, steps []step) {
+	for i, step := range steps {
+		text, transmit := s.Next(step.decodes)
+		if step.expected == "" {
+			assert.False(t, transmit, "step %d: %s", i, text)
+		} else {
+			assert.True(t, transmit, "step %d", i)
+			assert.Equal(t, step.expected, text, "step %d", i)
+		}
+	}
+}
+
+func TestAnswerCQ(t *testing.T) {
+	var logged []QSO
+	s := New(Config{Mode: "FT8", Callsign: "dl1abc", Grid: "jo31"}, Hooks{Log: func(qso QSO) { logged = append(logged, qso) }}, testClock)
+
+	err := s.Answer(decode("CQ W1AW FN31", -12))
+	require.NoError(t, err)
+	text, transmit := s.Transmission()
+	assert.True(t, transmit)
+	assert.Equal(t, "W1AW DL1ABC JO31", text)
+
+	runSteps(t, s, []step{
+		{[]digimodes.DecodeRecord{decode("K1A W1AW -01", -10)}, "W1AW DL1ABC JO31"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW -07", -10)}, "W1AW DL1ABC R-10"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW RR73", -9)}, "W1AW DL1ABC 73"},
+		{nil, ""},
+	})
+
+	require.Len(t, logged, 1)
+	assert.Equal(t, QSO{Mode: "FT8", Call: "W1AW", Grid: "FN31", SentReport: -10, ReceivedReport: -7, Start: testClock(), End: testClock()}, logged[0])
+	assert.Equal(t, Idle, s.State())
+}
+
+func TestCallCQ(t *testing.T) {
+	var logged []QSO
+	s := New(Config{Mode: "FT8", Callsign: "DL1ABC", Grid: "JO31", ResumeCQ: true}, Hooks{
+		SelectCall: func(candidates []Candidate) int {
+			best := 0
+			for i, c := range candidates {
+				if c.Decode.SNR > candidates[best].Decode.SNR {
+					best = i
+				}
+			}
+			return best
+		},
+		Log: func(qso QSO) { logged = append(logged, qso) },
+	}, testClock)
+
+	s.CallCQ()
+	runSteps(t, s, []step{
+		{nil, "CQ DL1ABC JO31"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC K1A FN42", -20), decode("DL1ABC W1AW FN31", -3), decode("DL2XYZ W1AW FN31", -3)}, "W1AW DL1ABC -03"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW R-11", -4)}, "W1AW DL1ABC RR73"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW R-11", -4)}, "W1AW DL1ABC RR73"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW 73", -4)}, "CQ DL1ABC JO31"},
+	})
+
+	require.Len(t, logged, 1, "logged once")
+	assert.Equal(t, "W1AW", logged[0].Call)
+	assert.Equal(t, -3, logged[0].SentReport)
+	assert.Equal(t, -11, logged[0].ReceivedReport)
+	assert.Equal(t, CallingCQ, s.State())
+}
+
+func TestRRR(t *testing.T) {
+	var logged []QSO
+	s := New(Config{Callsign: "DL1ABC", Grid: "JO31", UseRRR: true, MaxRetries: 1}, Hooks{Log: func(qso QSO) { logged = append(logged, qso) }}, testClock)
+
+	s.CallCQ()
+	runSteps(t, s, []step{
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW -15", -8)}, "W1AW DL1ABC R-08"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW RRR", -8)}, "W1AW DL1ABC 73"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC W1AW RRR", -8)}, "W1AW DL1ABC 73"},
+		{nil, ""},
+	})
+	require.Len(t, logged, 1)
+
+	s.CallCQ()
+	runSteps(t, s, []step{
+		{[]digimodes.DecodeRecord{decode("DL1ABC K1A R-15", -8)}, "CQ DL1ABC JO31"},
+	})
+	runSteps(t, s, []step{
+		{[]digimodes.DecodeRecord{decode("DL1ABC K1A FN42", -8)}, "K1A DL1ABC -08"},
+		{[]digimodes.DecodeRecord{decode("DL1ABC K1A R-15", -8)}, "K1A DL1ABC RRR"},
+		{nil, "K1A DL1ABC RRR"},
+		{nil, ""},
+	})
+	require.Len(t, logged, 2, "the QSO is valid without the final 73")
+	assert.Equal(t, "K1A", logged[1].Call)
+}
+
+func TestTimeout(t *testing.T) {
+	var abandoned []QSO
+	s := New(Config{Callsign: "DL1ABC", Grid: "JO31", MaxRetries: 2}, Hooks{Abandoned: func(qso QSO) { abandoned = append(abandoned, qso) }}, testClock)
+
+	require.NoError(t, s.Answer(decode("CQ W1AW FN31", -12)))
+	runSteps(t, s, []step{
+		{nil, "W1AW DL1ABC JO31"},
+		{nil, "W1AW DL1ABC JO31"},
+		{nil, ""},
+	})
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, "W1AW", abandoned[0].Call)
+
+	assert.Equal(t, ErrNotCQ, s.Answer(decode("DL1ABC W1AW FN31", 0)))
+	require.NoError(t, s.Answer(decode("CQ W1AW FN31", -12)))
+	s.Abort()
+	_, transmit := s.Transmission()
+	assert.False(t, transmit)
+	assert.Equal(t, Idle, s.State())
+}
@@ -0,0 +1,48 @@
+package simulate
+
+import "math"
+
+// hilbertHalfLength is the half length of the Hilbert transformer, the delay of the analytic signal in samples.
+const hilbertHalfLength = 64
+
+// analytic converts real samples into the analytic signal, which contains only the positive frequencies. This
+// allows to change the amplitude, phase and frequency of a real audio signal by complex multiplication.
+type analytic struct {
+	taps    []float64
+	history []float64
+	index   int
+}
+
+func newAnalytic() *analytic {
+	length := 2*hilbertHalfLength + 1
+	taps := make([]float64, length)
+	for i := range taps {
+		n := i - hilbertHalfLength
+		if n%2 == 0 {
+			continue
+		}
+		window := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(length-1))
+		taps[i] = 2 / (math.Pi * float64(n)) * window
+	}
+	return &analytic{
+		taps:    taps,
+		history: make([]float64, length),
+	}
+}
+
+// next returns the analytic signal of the sample that was passed hilbertHalfLength samples before.
+func (a *analytic) next(x float64) complex128 {
+	a.history[a.index] = x
+	a.index = (a.index + 1) % len(a.history)
+
+	var imag float64
+	for i, tap := range a.taps {
+		if tap == 0 {
+			continue
+		}
+		// taps[0] applies to the newest sample
+		imag += tap * a.history[(a.index+len(a.history)-1-i)%len(a.history)]
+	}
+	real := a.history[(a.index+hilbertHalfLength)%len(a.history)]
+	return complex(real, imag)
+}
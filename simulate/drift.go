@@ -0,0 +1,43 @@
+package simulate
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Drift shifts the frequency of the signal by an offset that changes linearly over time, like the drift of an
+// unstable oscillator. The output is delayed by 64 samples.
+type Drift struct {
+	sampleRate int
+	offset     float64
+	rate       float64
+	analytic   *analytic
+	phase      float64
+}
+
+// NewDrift returns a new Drift stage that starts with the given frequency offset in Hz and changes it by the given
+// rate in Hz per second.
+func NewDrift(sampleRate int, offset, rate float64) *Drift {
+	return &Drift{
+		sampleRate: sampleRate,
+		offset:     offset,
+		rate:       rate,
+		analytic:   newAnalytic(),
+	}
+}
+
+// Offset returns the current frequency offset in Hz.
+func (d *Drift) Offset() float64 {
+	return d.offset
+}
+
+// Process shifts the frequency of the given samples in place.
+func (d *Drift) Process(samples []float64) {
+	step := 1 / float64(d.sampleRate)
+	for i, x := range samples {
+		z := d.analytic.next(x)
+		samples[i] = real(z * cmplx.Rect(1, d.phase))
+		d.phase = math.Mod(d.phase+2*math.Pi*d.offset*step, 2*math.Pi)
+		d.offset += d.rate * step
+	}
+}
@@ -0,0 +1,188 @@
+package simulate
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+)
+
+// Path is a propagation path of a fading channel.
+type Path struct {
+	// Delay is the delay of the path in seconds.
+	Delay float64
+	// Gain is the mean amplitude of the path relative to the other paths.
+	Gain float64
+	// Spread is the Doppler spread of the path in Hz, two times the standard deviation of the Gaussian Doppler
+	// spectrum. 0 means the path does not fade.
+	Spread float64
+	// Shift is the Doppler shift of the path in Hz.
+	Shift float64
+}
+
+// The channel conditions of the CCIR recommendation 520 for the Watterson model, two paths with the same gain.
+var (
+	CCIRGood     = []Path{{Delay: 0, Gain: 1, Spread: 0.1}, {Delay: 0.0005, Gain: 1, Spread: 0.1}}
+	CCIRModerate = []Path{{Delay: 0, Gain: 1, Spread: 0.5}, {Delay: 0.001, Gain: 1, Spread: 0.5}}
+	CCIRPoor     = []Path{{Delay: 0, Gain: 1, Spread: 1}, {Delay: 0.002, Gain: 1, Spread: 1}}
+	CCIRFlutter  = []Path{{Delay: 0, Gain: 1, Spread: 10}, {Delay: 0.0005, Gain: 1, Spread: 10}}
+)
+
+// Fading simulates a fading channel with several propagation paths after the Watterson model. Each path has its own
+// delay and a complex gain that varies randomly with a Gaussian Doppler spectrum, so the amplitude of a single path
+// is Rayleigh distributed. The mean power of the signal is preserved. The output is delayed by 64 samples.
+type Fading struct {
+	sampleRate int
+	analytic   *analytic
+	paths      []*fadingPath
+	history    []complex128
+	index      int
+	n          int64
+}
+
+// NewFading returns a new Fading stage with the given paths at the given sample rate. If rng is nil, a source with
+// a fixed seed is used, so the results are reproducible.
+func NewFading(sampleRate int, paths []Path, rng *rand.Rand) *Fading {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+	totalPower := 0.0
+	for _, path := range paths {
+		totalPower += path.Gain * path.Gain
+	}
+	maxDelay := 0
+	result := &Fading{
+		sampleRate: sampleRate,
+		analytic:   newAnalytic(),
+	}
+	for _, path := range paths {
+		delay := int(math.Round(path.Delay * float64(sampleRate)))
+		if delay > maxDelay {
+			maxDelay = delay
+		}
+		gain := 0.0
+		if totalPower > 0 {
+			gain = path.Gain / math.Sqrt(totalPower)
+		}
+		result.paths = append(result.paths, newFadingPath(path, delay, gain, sampleRate, rng))
+	}
+	result.history = make([]complex128, maxDelay+1)
+	return result
+}
+
+// NewRayleigh returns a new Fading stage with a single path with Rayleigh fading and the given Doppler spread in Hz.
+func NewRayleigh(sampleRate int, spread float64, rng *rand.Rand) *Fading {
+	return NewFading(sampleRate, []Path{{Gain: 1, Spread: spread}}, rng)
+}
+
+// NewWatterson returns a new Fading stage with two equal paths with the given differential delay in seconds and
+// Doppler spread in Hz.
+func NewWatterson(sampleRate int, delay, spread float64, rng *rand.Rand) *Fading {
+	return NewFading(sampleRate, []Path{{Gain: 1, Spread: spread}, {Delay: delay, Gain: 1, Spread: spread}}, rng)
+}
+
+// Process applies the fading to the given samples in place.
+func (f *Fading) Process(samples []float64) {
+	for i, x := range samples {
+		f.history[f.index] = f.analytic.next(x)
+		t := float64(f.n) / float64(f.sampleRate)
+		var y complex128
+		for _, path := range f.paths {
+			delayed := f.history[(f.index+len(f.history)-path.delay)%len(f.history)]
+			y += delayed * path.gainAt(t)
+		}
+		samples[i] = real(y)
+		f.index = (f.index + 1) % len(f.history)
+		f.n++
+	}
+}
+
+// fadingPath generates the complex gain of a path. The gain is computed at a low update rate by filtering complex
+// white noise with a Gaussian filter and interpolated linearly in between.
+type fadingPath struct {
+	delay  int
+	gain   float64
+	shift  float64
+	fading bool
+	rng    *rand.Rand
+
+	filter   []float64
+	noise    []complex128
+	noiseIdx int
+
+	updatePeriod int
+	countdown    int
+	current      complex128
+	upcoming     complex128
+}
+
+// tapsPerSpread is the update rate of the path gain relative to the Doppler spread.
+const tapsPerSpread = 16
+
+func newFadingPath(path Path, delay int, gain float64, sampleRate int, rng *rand.Rand) *fadingPath {
+	result := &fadingPath{
+		delay:  delay,
+		gain:   gain,
+		shift:  path.Shift,
+		fading: path.Spread > 0,
+		rng:    rng,
+	}
+	if !result.fading {
+		return result
+	}
+
+	updateRate := tapsPerSpread * path.Spread
+	result.updatePeriod = int(math.Max(1, math.Round(float64(sampleRate)/updateRate)))
+	updateRate = float64(sampleRate) / float64(result.updatePeriod)
+
+	// the magnitude of the filter is the square root of the Gaussian Doppler spectrum with sigma = spread / 2
+	sigma := path.Spread / 2
+	halfLength := int(math.Ceil(3 / (2 * math.Pi * sigma) * updateRate))
+	sum := 0.0
+	for k := -halfLength; k <= halfLength; k++ {
+		t := float64(k) / updateRate
+		tap := math.Exp(-4 * math.Pi * math.Pi * sigma * sigma * t * t)
+		result.filter = append(result.filter, tap)
+		sum += tap * tap
+	}
+	for i := range result.filter {
+		result.filter[i] /= math.Sqrt(sum)
+	}
+	result.noise = make([]complex128, len(result.filter))
+	for range result.noise {
+		result.nextGain()
+	}
+	result.current = result.nextGain()
+	result.upcoming = result.nextGain()
+	result.countdown = result.updatePeriod
+	return result
+}
+
+// nextGain returns the next filtered gain at the update rate, with a mean power of 1.
+func (p *fadingPath) nextGain() complex128 {
+	p.noise[p.noiseIdx] = complex(p.rng.NormFloat64(), p.rng.NormFloat64()) / math.Sqrt2
+	p.noiseIdx = (p.noiseIdx + 1) % len(p.noise)
+	var result complex128
+	for i, tap := range p.filter {
+		result += complex(tap, 0) * p.noise[(p.noiseIdx+i)%len(p.noise)]
+	}
+	return result
+}
+
+// gainAt returns the complex gain of the path at the next sample at the given time in seconds.
+func (p *fadingPath) gainAt(t float64) complex128 {
+	result := complex(p.gain, 0)
+	if p.fading {
+		fraction := 1 - float64(p.countdown)/float64(p.updatePeriod)
+		result *= p.current + complex(fraction, 0)*(p.upcoming-p.current)
+		p.countdown--
+		if p.countdown == 0 {
+			p.current = p.upcoming
+			p.upcoming = p.nextGain()
+			p.countdown = p.updatePeriod
+		}
+	}
+	if p.shift != 0 {
+		result *= cmplx.Rect(1, 2*math.Pi*p.shift*t)
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+/*
+Package simulate applies the conditions of an HF channel to rendered audio samples: additive white Gaussian noise at
+a target SNR, Rayleigh and Watterson fading, and frequency drift. The stages implement dsp.Processor and can be
+chained, so decoders can be tested against realistic conditions.
+*/
+package simulate
+
+import (
+	"math"
+	"math/rand"
+)
+
+// ReferenceBandwidth is the common reference bandwidth of SNR figures in Hz, e.g. in WSJT-X.
+const ReferenceBandwidth = 2500.0
+
+// Power returns the mean power of the given samples.
+func Power(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, x := range samples {
+		sum += x * x
+	}
+	return sum / float64(len(samples))
+}
+
+// NoiseLevel returns the standard deviation of white noise that results in the given SNR in dB, measured in the
+// given bandwidth in Hz, for a signal with the given power at the given sample rate.
+func NoiseLevel(signalPower, snr, bandwidth float64, sampleRate int) float64 {
+	noisePower := signalPower / math.Pow(10, snr/10)
+	// the white noise is spread over the whole band up to the Nyquist frequency
+	return math.Sqrt(noisePower * float64(sampleRate) / 2 / bandwidth)
+}
+
+// AWGN adds white Gaussian noise with a fixed level to the samples.
+type AWGN struct {
+	level float64
+	rng   *rand.Rand
+}
+
+// NewAWGN returns a new AWGN stage that adds noise with the given standard deviation, see NoiseLevel. If rng is
+// nil, a source with a fixed seed is used, so the results are reproducible.
+func NewAWGN(level float64, rng *rand.Rand) *AWGN {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(1))
+	}
+	return &AWGN{
+		level: level,
+		rng:   rng,
+	}
+}
+
+// Process adds the noise to the given samples in place.
+func (n *AWGN) Process(samples []float64) {
+	for i := range samples {
+		samples[i] += n.level * n.rng.NormFloat64()
+	}
+}
+
+// AddNoise adds white Gaussian noise to the given samples, so that the SNR in the given bandwidth in Hz is the
+// given value in dB. The signal power is the mean power of the given samples. If rng is nil, a source with a fixed
+// seed is used.
+func AddNoise(samples []float64, snr, bandwidth float64, sampleRate int, rng *rand.Rand) {
+	NewAWGN(NoiseLevel(Power(samples), snr, bandwidth, sampleRate), rng).Process(samples)
+}
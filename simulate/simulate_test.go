@@ -0,0 +1,90 @@
+package simulate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/dsp"
+)
+
+const sampleRate = 8000
+
+func tone(frequency float64, seconds float64) []float64 {
+	result := make([]float64, int(seconds*sampleRate))
+	for i := range result {
+		result[i] = math.Sin(2 * math.Pi * frequency * float64(i) / sampleRate)
+	}
+	return result
+}
+
+// frequencyOf measures the frequency of the given samples by counting the zero crossings.
+func frequencyOf(samples []float64) float64 {
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / 2 / (float64(len(samples)) / sampleRate)
+}
+
+func TestAddNoise(t *testing.T) {
+	assert.InDelta(t, math.Sqrt(0.8), NoiseLevel(0.5, 0, ReferenceBandwidth, sampleRate), 1e-9)
+	assert.InDelta(t, math.Sqrt(0.08), NoiseLevel(0.5, 10, ReferenceBandwidth, sampleRate), 1e-9)
+
+	signal := tone(1000, 10)
+	samples := make([]float64, len(signal))
+	copy(samples, signal)
+	AddNoise(samples, 0, ReferenceBandwidth, sampleRate, nil)
+
+	for i := range samples {
+		samples[i] -= signal[i]
+	}
+	assert.InDelta(t, 0.8, Power(samples), 0.01)
+}
+
+func TestRayleighFading(t *testing.T) {
+	samples := tone(1000, 120)
+	NewRayleigh(sampleRate, 1, nil).Process(samples)
+
+	assert.InDelta(t, 0.5, Power(samples), 0.15, "the mean power is preserved")
+
+	const blockSize = sampleRate / 100
+	deepFades := 0
+	blocks := 0
+	for i := sampleRate; i+blockSize <= len(samples); i += blockSize {
+		if Power(samples[i:i+blockSize]) < 0.05 {
+			deepFades++
+		}
+		blocks++
+	}
+	// the power of a Rayleigh faded signal is below -10 dB for 1 - exp(-0.1) = 9.5% of the time
+	assert.InDelta(t, 0.095, float64(deepFades)/float64(blocks), 0.05)
+}
+
+func TestWattersonPresets(t *testing.T) {
+	for _, paths := range [][]Path{CCIRGood, CCIRModerate, CCIRPoor, CCIRFlutter} {
+		samples := tone(1000, 60)
+		NewFading(sampleRate, paths, nil).Process(samples)
+		assert.InDelta(t, 0.5, Power(samples), 0.2)
+	}
+}
+
+func TestDopplerShift(t *testing.T) {
+	samples := tone(1000, 2)
+	NewFading(sampleRate, []Path{{Gain: 1, Shift: 5}}, nil).Process(samples)
+
+	assert.InDelta(t, 1005, frequencyOf(samples[sampleRate:]), 1)
+}
+
+func TestDrift(t *testing.T) {
+	samples := tone(1000, 10)
+	drift := NewDrift(sampleRate, 10, 1)
+	dsp.Chain{drift}.Process(samples)
+
+	assert.InDelta(t, 20, drift.Offset(), 1e-6)
+	assert.InDelta(t, 1010.5, frequencyOf(samples[sampleRate:2*sampleRate]), 1)
+	assert.InDelta(t, 1019.5, frequencyOf(samples[9*sampleRate:]), 1)
+}
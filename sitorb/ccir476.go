@@ -0,0 +1,100 @@
+package sitorb
+
+import "unicode"
+
+// The CCIR 476 codes of the control signals. Each code has seven bits, four of them are marks (1) and three are
+// spaces (0), so the receiver can detect any single bit error.
+const (
+	CR     = 0x78
+	LF     = 0x6C
+	Space  = 0x5C
+	LTRS   = 0x5A
+	FIGS   = 0x36
+	Alpha  = 0x0F
+	Beta   = 0x33
+	RQ     = 0x66
+	Char32 = 0x6A
+)
+
+// Letters contains the characters of the letters shift, indexed by their CCIR 476 code.
+var Letters = map[uint8]rune{
+	0x47: 'A', 0x72: 'B', 0x1D: 'C', 0x53: 'D', 0x56: 'E', 0x1B: 'F', 0x35: 'G', 0x69: 'H', 0x4D: 'I',
+	0x17: 'J', 0x1E: 'K', 0x65: 'L', 0x39: 'M', 0x59: 'N', 0x71: 'O', 0x2D: 'P', 0x2E: 'Q', 0x55: 'R',
+	0x4B: 'S', 0x74: 'T', 0x4E: 'U', 0x3C: 'V', 0x27: 'W', 0x3A: 'X', 0x2B: 'Y', 0x63: 'Z',
+	CR: '\r', LF: '\n', Space: ' ',
+}
+
+// Figures contains the characters of the figures shift, indexed by their CCIR 476 code. It follows the
+// international layout of ITA2, the codes of D, F, G and H are not used.
+var Figures = map[uint8]rune{
+	0x47: '-', 0x72: '?', 0x1D: ':', 0x56: '3', 0x4D: '8', 0x17: '\a', 0x1E: '(', 0x65: ')', 0x39: '.',
+	0x59: ',', 0x71: '9', 0x2D: '0', 0x2E: '1', 0x55: '4', 0x4B: '\'', 0x74: '5', 0x4E: '7', 0x3C: '=',
+	0x27: '2', 0x3A: '/', 0x2B: '6', 0x63: '+',
+	CR: '\r', LF: '\n', Space: ' ',
+}
+
+// shiftState is the state of the CCIR 476 encoding.
+type shiftState uint8
+
+const (
+	noShift shiftState = iota
+	lettersShift
+	figuresShift
+)
+
+type ccirCode struct {
+	code  uint8
+	shift shiftState
+}
+
+// encodeTable maps the characters to their CCIR 476 code and the required shift.
+var encodeTable = func() map[rune]ccirCode {
+	result := make(map[rune]ccirCode, len(Letters)+len(Figures))
+	for code, letter := range Letters {
+		if figure, ok := Figures[code]; ok && figure == letter {
+			result[letter] = ccirCode{code, noShift}
+			continue
+		}
+		result[letter] = ccirCode{code, lettersShift}
+	}
+	for code, figure := range Figures {
+		if _, ok := result[figure]; !ok {
+			result[figure] = ccirCode{code, figuresShift}
+		}
+	}
+	return result
+}()
+
+// encoder converts text into CCIR 476 codes and inserts the shift codes as needed.
+type encoder struct {
+	shift shiftState
+}
+
+// Encode appends the CCIR 476 codes for the given character to the given codes. A newline is sent as CR LF,
+// characters that cannot be encoded are dropped.
+func (e *encoder) Encode(codes []uint8, r rune) []uint8 {
+	if r == '\r' {
+		return codes
+	}
+	c, ok := encodeTable[unicode.ToUpper(r)]
+	if !ok {
+		return codes
+	}
+	if r == '\n' {
+		codes = append(codes, CR)
+	}
+	switch {
+	case c.shift == lettersShift && e.shift != lettersShift:
+		codes = append(codes, LTRS)
+		e.shift = lettersShift
+	case c.shift == figuresShift && e.shift != figuresShift:
+		codes = append(codes, FIGS)
+		e.shift = figuresShift
+	}
+	return append(codes, c.code)
+}
+
+// Reset forgets the shift state, the next character gets a shift code in any case.
+func (e *encoder) Reset() {
+	e.shift = noShift
+}
@@ -0,0 +1,72 @@
+package sitorb
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodes(t *testing.T) {
+	codes := map[uint8]bool{LTRS: true, FIGS: true, Alpha: true, Beta: true, RQ: true, Char32: true}
+	for code := range Letters {
+		codes[code] = true
+	}
+	for code := range Figures {
+		assert.Contains(t, Letters, code, "figure %q", Figures[code])
+	}
+
+	// all 35 codes with four marks and three spaces are used
+	assert.Len(t, codes, 35)
+	for code := range codes {
+		assert.Less(t, code, uint8(1<<codeBits))
+		assert.Equal(t, 4, bits.OnesCount8(code), "code %#02x", code)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	const (
+		a   = 0x47
+		c   = 0x1D
+		h   = 0x69
+		i   = 0x4D
+		q   = 0x2E
+		one = 0x2E
+		two = 0x27
+		dot = 0x39
+	)
+	testCases := []struct {
+		desc     string
+		value    string
+		expected []uint8
+	}{
+		{"<empty>", "", nil},
+		{"letters", "CQ", []uint8{LTRS, c, q}},
+		{"lower case", "cq", []uint8{LTRS, c, q}},
+		{"figures", "1.2", []uint8{FIGS, one, dot, two}},
+		{"mixed", "A1A", []uint8{LTRS, a, FIGS, one, LTRS, a}},
+		{"space keeps the shift", "1 2", []uint8{FIGS, one, Space, two}},
+		{"newline", "HI\n", []uint8{LTRS, h, i, CR, LF}},
+		{"carriage return and newline", "HI\r\n", []uint8{LTRS, h, i, CR, LF}},
+		{"unknown characters", "A%~A", []uint8{LTRS, a, a}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			e := encoder{}
+			var actual []uint8
+			for _, r := range tC.value {
+				actual = e.Encode(actual, r)
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestEncodeTable(t *testing.T) {
+	for code, letter := range Letters {
+		assert.Equal(t, code, encodeTable[letter].code, "letter %q", letter)
+	}
+	for code, figure := range Figures {
+		assert.Equal(t, code, encodeTable[figure].code, "figure %q", figure)
+	}
+}
@@ -0,0 +1,18 @@
+package sitorb
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidNAVTEXHeader = errors.New("sitorb: invalid NAVTEX header")
+
+// NAVTEX returns the given text as NAVTEX message, framed by "ZCZC" with the header and "NNNN". The header consists
+// of the transmitter identity and the subject indicator, both a letter from A to Z, and the serial number from 0 to
+// 99. The message can be written to a Modulator, the newlines are sent as CR LF.
+func NAVTEX(station, subject rune, number int, text string) (string, error) {
+	if station < 'A' || station > 'Z' || subject < 'A' || subject > 'Z' || number < 0 || number > 99 {
+		return "", ErrInvalidNAVTEXHeader
+	}
+	return fmt.Sprintf("ZCZC %c%c%02d\n%s\nNNNN\n", station, subject, number, text), nil
+}
@@ -0,0 +1,26 @@
+package sitorb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNAVTEX(t *testing.T) {
+	message, err := NAVTEX('S', 'A', 7, "GALE WARNING")
+	assert.NoError(t, err)
+	assert.Equal(t, "ZCZC SA07\nGALE WARNING\nNNNN\n", message)
+
+	for _, header := range []struct {
+		station, subject rune
+		number           int
+	}{
+		{'a', 'A', 1},
+		{'A', '1', 1},
+		{'A', 'A', -1},
+		{'A', 'A', 100},
+	} {
+		_, err := NAVTEX(header.station, header.subject, header.number, "text")
+		assert.Equal(t, ErrInvalidNAVTEXHeader, err, "%c%c%d", header.station, header.subject, header.number)
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Package sitorb implements SITOR-B, the collective forward error correction mode of SITOR and AMTOR that is used
+for NAVTEX broadcasts: 100 baud FSK with 170 Hz shift and the seven bit codes of CCIR 476.
+
+Each character is sent twice. The first transmission (DX) is followed by four other characters, then the
+retransmission (RX) follows, so the receiver can take the correct one of both. A transmission starts with the
+phasing signals, alpha in the DX and RQ in the RX positions, and ends with three alphas in the DX positions.
+*/
+package sitorb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
+)
+
+const (
+	// Baud is the symbol rate of SITOR-B.
+	Baud = 100.0
+	// Shift is the distance between the mark and the space tone in Hz.
+	Shift = 170.0
+
+	// codeBits is the number of bits of a CCIR 476 code.
+	codeBits = 7
+	// slotTime is the duration of one DX or RX position in seconds.
+	slotTime = codeBits / Baud
+	// repeatDelay is the number of DX positions between the DX and the RX position of a character, minus one.
+	repeatDelay = 2
+	// phasingPairs is the number of phasing signal pairs before a transmission, about 10 s as NAVTEX requires.
+	phasingPairs = 72
+	// endPairs is the number of alphas in the DX positions at the end of a transmission.
+	endPairs = 3
+	// window is the time in seconds to ramp the amplitude up and down at the start and the end of a transmission.
+	window = 0.005
+)
+
+// codeBufferSize is the number of codes that can be buffered between Write and Modulate.
+const codeBufferSize = 64
+
+var ErrWriteAborted = errors.New("sitorb: write aborted")
+
+// Modulator generates a SITOR-B signal and provides the io.Writer interface. The mark tone is above the configured
+// frequency and the space tone below, like on USB. The bits of a code are sent with the most significant bit first.
+// While a transmission is active and there is nothing to send, the modulator sends alphas, the idle signal.
+type Modulator struct {
+	codes *stream.Stream[item]
+
+	writeLock sync.Mutex
+	encoder   encoder
+	writes    uint32
+	// sent counts the bytes of the latest write that were taken from the stream.
+	sent stream.Progress
+
+	mark      float64
+	space     float64
+	on        bool
+	onStart   float64
+	phasing   int
+	ending    int
+	endToken  chan struct{}
+	delay     [repeatDelay]uint8
+	rx        uint8
+	rxNext    bool
+	code      uint8
+	slotStart float64
+	slotEnd   float64
+
+	errLock sync.Mutex
+	err     error
+}
+
+// NewModulator returns a new Modulator for the given center frequency.
+func NewModulator(frequency float64) *Modulator {
+	return &Modulator{
+		codes: stream.New[item](codeBufferSize),
+		mark:  frequency + Shift/2,
+		space: frequency - Shift/2,
+	}
+}
+
+// bandwidthFactor is the factor K of the ITU formula for the necessary bandwidth of FSK, B = shift + K * baud.
+const bandwidthFactor = 1.2
+
+// Bandwidth returns the occupied bandwidth of the signal in Hz.
+func (m *Modulator) Bandwidth() float64 {
+	return Shift + bandwidthFactor*Baud
+}
+
+// SymbolRate returns the symbol rate of the signal in baud.
+func (m *Modulator) SymbolRate() float64 {
+	return Baud
+}
+
+// End ends the transmission after all written text is sent. It returns when the end of transmission signal is
+// sent.
+func (m *Modulator) End() error {
+	end := make(chan struct{})
+	err := m.codes.Send(context.Background(), item{kind: endItem, token: end})
+	if err != nil {
+		return m.abortError()
+	}
+	return m.waitFor(end)
+}
+
+func (m *Modulator) Close() error {
+	m.codes.Close()
+	return nil
+}
+
+// Err returns the internal error that made the modulator stop, or nil.
+func (m *Modulator) Err() error {
+	m.errLock.Lock()
+	defer m.errLock.Unlock()
+	return m.err
+}
+
+// fail stops the modulator because of the given internal error.
+func (m *Modulator) fail(err error) {
+	m.errLock.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.errLock.Unlock()
+	m.codes.Close()
+}
+
+func (m *Modulator) abortError() error {
+	if err := m.Err(); err != nil {
+		return err
+	}
+	return ErrWriteAborted
+}
+
+func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
+	go func() {
+		select {
+		case <-done:
+			m.Close()
+		case <-m.codes.Done():
+		}
+	}()
+}
+
+// Write sends the given text. If no transmission is active, the text is preceded by the phasing signals. It returns
+// when the text is sent completely. If the write is aborted, the returned count is the number of bytes whose
+// characters were actually transmitted, the dropped rest does not count.
+func (m *Modulator) Write(bytes []byte) (int, error) {
+	m.writeLock.Lock()
+	write, pending, err := m.writeText(bytes, false)
+	if err != nil {
+		m.writeLock.Unlock()
+		return m.sent.Count(write), m.abortError()
+	}
+
+	eot := make(chan struct{})
+	err = m.codes.Send(context.Background(), item{kind: endOfTransmissionItem, token: eot, write: write, bytes: pending})
+	m.writeLock.Unlock()
+	if err != nil {
+		return m.sent.Count(write), m.abortError()
+	}
+	err = m.waitFor(eot)
+	if err != nil {
+		return m.sent.Count(write), err
+	}
+	return len(bytes), nil
+}
+
+// Queue queues the given text like Write, followed by the end of the transmission like End, but it does not block
+// and does not wait until the text is sent. The returned channel is closed when the transmission is complete. Queue
+// implements audio.Queuer, e.g. to render a transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	write, pending, err := m.writeText([]byte(text), true)
+	if err != nil {
+		return nil, m.abortError()
+	}
+	end := make(chan struct{})
+	err = m.codes.Push(item{kind: endItem, token: end, write: write, bytes: pending})
+	if err != nil {
+		return nil, m.abortError()
+	}
+	return end, nil
+}
+
+// writeText sends the preamble and the codes of the given text as a new write and returns the number of the write
+// and the number of bytes that are not attached to a sent code yet. If queue is set, the codes are pushed without
+// blocking. It must be called while holding the write lock.
+func (m *Modulator) writeText(bytes []byte, queue bool) (uint32, int, error) {
+	m.writes++
+	write := m.writes
+	err := m.put(item{kind: preambleItem, write: write}, queue)
+	if err != nil {
+		return write, 0, err
+	}
+
+	// the receiver may have seen alphas in between, so the first character always gets a shift code
+	m.encoder.Reset()
+	var codes []uint8
+	// pending is the number of bytes that are not attached to a sent code yet
+	pending := 0
+	for text := string(bytes); len(text) > 0; {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		pending += size
+		codes = m.encoder.Encode(codes[:0], r)
+		for i, code := range codes {
+			next := item{kind: codeItem, code: code, write: write}
+			if i == len(codes)-1 {
+				next.bytes = pending
+			}
+			err := m.put(next, queue)
+			if err != nil {
+				return write, pending, err
+			}
+		}
+		if len(codes) > 0 {
+			pending = 0
+		}
+	}
+	return write, pending, nil
+}
+
+// put appends the given item to the stream of codes. It blocks while the stream is full, unless queue is set.
+func (m *Modulator) put(next item, queue bool) error {
+	if queue {
+		return m.codes.Push(next)
+	}
+	return m.codes.Send(context.Background(), next)
+}
+
+func (m *Modulator) waitFor(token chan struct{}) error {
+	select {
+	case <-token:
+		return nil
+	case <-m.codes.Done():
+		return m.abortError()
+	}
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.codes.Done())
+}
+
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if t >= m.slotEnd {
+		err := m.nextSlot(t)
+		if err != nil {
+			m.fail(err)
+			m.on = false
+		}
+	}
+	if !m.on {
+		return 0, m.mark, p
+	}
+
+	frequency = m.space
+	bit := int((t - m.slotStart) * Baud)
+	if bit < codeBits && (m.code>>uint(codeBits-1-bit))&1 == 1 {
+		frequency = m.mark
+	}
+
+	amplitude = 1
+	if t-m.onStart < window {
+		amplitude = (t - m.onStart) / window
+	}
+	if m.lastSlot() && m.slotEnd-t < window {
+		amplitude = (m.slotEnd - t) / window
+	}
+	return amplitude, frequency, p
+}
+
+// lastSlot indicates if the current slot is the last one of the transmission.
+func (m *Modulator) lastSlot() bool {
+	return m.endToken != nil && m.ending == 0 && !m.rxNext
+}
+
+func (m *Modulator) nextSlot(t float64) error {
+	if m.codes.Closed() {
+		m.on = false
+		return nil
+	}
+	if m.on && m.rxNext {
+		m.rxNext = false
+		m.startSlot(t, m.rx)
+		return nil
+	}
+	if m.lastSlot() {
+		m.on = false
+		close(m.endToken)
+		m.endToken = nil
+	}
+	for {
+		switch {
+		case m.phasing > 0:
+			m.phasing--
+			m.startPair(t, Alpha, RQ)
+			return nil
+		case m.ending > 0:
+			m.ending--
+			m.startPair(t, Alpha, m.repeat(Alpha))
+			return nil
+		}
+
+		next, ok := m.codes.TryReceive()
+		if !ok {
+			if m.on {
+				// idle with alphas, so the receiver stays synchronized
+				m.startPair(t, Alpha, m.repeat(Alpha))
+			}
+			return nil
+		}
+		m.sent.Add(next.write, next.bytes)
+		switch next.kind {
+		case codeItem:
+			if !m.on {
+				m.turnOn(t)
+			}
+			m.startPair(t, next.code, m.repeat(next.code))
+			return nil
+		case preambleItem:
+			if !m.on {
+				m.turnOn(t)
+				m.phasing = phasingPairs
+			}
+		case endOfTransmissionItem:
+			close(next.token)
+		case endItem:
+			if !m.on {
+				close(next.token)
+				continue
+			}
+			m.ending = endPairs
+			m.endToken = next.token
+		default:
+			return fmt.Errorf("sitorb: unknown item kind %d", next.kind)
+		}
+	}
+}
+
+func (m *Modulator) turnOn(t float64) {
+	m.on = true
+	m.onStart = t
+	m.slotEnd = t
+	// the first characters are repeated after the phasing, so their RX positions still carry the phasing signal
+	for i := range m.delay {
+		m.delay[i] = RQ
+	}
+}
+
+// repeat passes the given code of a DX position into the delay line and returns the code for the following RX
+// position.
+func (m *Modulator) repeat(code uint8) uint8 {
+	result := m.delay[0]
+	copy(m.delay[:], m.delay[1:])
+	m.delay[repeatDelay-1] = code
+	return result
+}
+
+// startPair starts a pair of a DX and an RX position with the given codes.
+func (m *Modulator) startPair(t float64, dx, rx uint8) {
+	m.rx = rx
+	m.rxNext = true
+	m.startSlot(t, dx)
+}
+
+func (m *Modulator) startSlot(t float64, code uint8) {
+	// keep the timing of continuous slots exact
+	if t-m.slotEnd < 1/Baud {
+		m.slotStart = m.slotEnd
+	} else {
+		m.slotStart = t
+	}
+	m.code = code
+	m.slotEnd = m.slotStart + slotTime
+}
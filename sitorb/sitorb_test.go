@@ -0,0 +1,197 @@
+package sitorb
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRate = 10000.0
+
+// receive samples the output of the modulator in the middle of the bits and returns the codes of the DX and RX
+// positions. It stops when the writer is done and the modulator is off for longer than a pair of positions.
+func receive(m *Modulator, done <-chan struct{}) []uint8 {
+	var codes []uint8
+	sample := func(at float64) (bool, bool) {
+		amplitude, frequency, _ := m.Modulate(at, 0, 0, 0)
+		return amplitude > 0, frequency == m.mark
+	}
+
+	n := 0
+	wasOn := false
+	for off := 0; off < int(2*slotTime*testRate) || !wasOn || !isDone(done); n++ {
+		on, _ := sample(float64(n) / testRate)
+		if !on {
+			off++
+			continue
+		}
+		off = 0
+		wasOn = true
+
+		// the positions follow each other without gaps until the end of the transmission
+		slotStart := float64(n) / testRate
+		for on {
+			var code uint8
+			for bit := 0; bit < codeBits; bit++ {
+				var mark bool
+				on, mark = sample(slotStart + (float64(bit)+0.5)/Baud)
+				if !on {
+					break
+				}
+				code <<= 1
+				if mark {
+					code |= 1
+				}
+			}
+			if on {
+				codes = append(codes, code)
+			}
+			slotStart += slotTime
+		}
+		n = int(slotStart * testRate)
+	}
+	return codes
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestModulate(t *testing.T) {
+	m := NewModulator(1000)
+	defer m.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := m.Write([]byte("CQ 73"))
+		assert.NoError(t, err)
+		assert.NoError(t, m.End())
+	}()
+
+	codes := receive(m, done)
+
+	var dx, rx []uint8
+	for i := 0; i+1 < len(codes); i += 2 {
+		dx = append(dx, codes[i])
+		rx = append(rx, codes[i+1])
+	}
+	require.Len(t, codes, 2*len(dx), "pairs of DX and RX positions")
+	text := []uint8{LTRS, 0x1D, 0x2E, Space, FIGS, 0x4E, 0x56}
+	require.True(t, len(dx) >= phasingPairs+len(text)+endPairs)
+
+	for i := 0; i < phasingPairs; i++ {
+		assert.Equal(t, uint8(Alpha), dx[i], "phasing DX %d", i)
+		assert.Equal(t, uint8(RQ), rx[i], "phasing RX %d", i)
+	}
+	assert.Equal(t, text, dx[phasingPairs:phasingPairs+len(text)])
+	for _, code := range dx[phasingPairs+len(text):] {
+		assert.Equal(t, uint8(Alpha), code, "idle and end of transmission")
+	}
+
+	// each character is repeated in the RX position after four other positions
+	assert.Equal(t, []uint8{RQ, RQ}, rx[phasingPairs:phasingPairs+repeatDelay])
+	assert.Equal(t, text, rx[phasingPairs+repeatDelay:phasingPairs+repeatDelay+len(text)])
+	for _, code := range rx[phasingPairs+repeatDelay+len(text):] {
+		assert.Equal(t, uint8(Alpha), code, "idle and end of transmission")
+	}
+}
+
+func TestModulateIdle(t *testing.T) {
+	m := NewModulator(1000)
+	defer m.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Write([]byte("A"))
+		m.Write([]byte("B"))
+		m.End()
+	}()
+
+	codes := receive(m, done)
+
+	// one transmission, both writes share the phasing
+	var dx []uint8
+	for i := 0; i < len(codes); i += 2 {
+		dx = append(dx, codes[i])
+	}
+	require.True(t, len(dx) > phasingPairs+4)
+	letters := 0
+	for _, code := range dx[phasingPairs:] {
+		switch code {
+		case Alpha:
+		case LTRS:
+		case 0x47, 0x72:
+			letters++
+		default:
+			assert.Fail(t, "unexpected code", "%#02x", code)
+		}
+	}
+	assert.Equal(t, 2, letters)
+}
+
+func TestFrequencies(t *testing.T) {
+	m := NewModulator(1000)
+	assert.Equal(t, 1085.0, m.mark)
+	assert.Equal(t, 915.0, m.space)
+	assert.InDelta(t, 290.0, m.Bandwidth(), 1e-9)
+	assert.Equal(t, 100.0, m.SymbolRate())
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(1000)
+	defer m.Close()
+	go m.Write([]byte("the quick brown fox jumps over the lazy dog"))
+
+	var a, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, _, p = m.Modulate(float64(n)/8000, a, 0, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func TestInvalidItemStopsModulator(t *testing.T) {
+	m := NewModulator(1000)
+	m.codes.Send(context.Background(), item{kind: itemKind(99)})
+
+	amplitude, _, _ := m.Modulate(0, 0, 0, 0)
+
+	assert.Equal(t, 0.0, amplitude)
+	assert.Error(t, m.Err())
+	_, err := m.Write([]byte("a"))
+	assert.Equal(t, m.Err(), err)
+}
+
+func TestWriteCountsTransmittedBytesOnAbort(t *testing.T) {
+	m := NewModulator(1000)
+	type result struct {
+		n   int
+		err error
+	}
+	written := make(chan result, 1)
+	go func() {
+		n, err := m.Write([]byte("RYRYRYRYRYRYRYRYRYRY"))
+		written <- result{n, err}
+	}()
+
+	// the phasing and the shift code before the text, each character takes 0.14s
+	duration := phasingPairs*2*slotTime + 2*2*slotTime + 0.01
+	for n := 0; n < int(duration*testRate); n++ {
+		m.Modulate(float64(n)/testRate, 0, 0, 0)
+		runtime.Gosched()
+	}
+	m.Close()
+
+	actual := <-written
+	assert.Equal(t, ErrWriteAborted, actual.err)
+	assert.InDelta(t, 1, actual.n, 1, "only the transmitted characters count")
+}
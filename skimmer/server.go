@@ -0,0 +1,181 @@
+package skimmer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/ftl/digimodes/dxcluster"
+)
+
+// clientQueueLength is the number of spots that are buffered for each client. Spots for slow clients are dropped.
+const clientQueueLength = 100
+
+// ErrServerClosed is returned by Serve after the server was closed.
+var ErrServerClosed = errors.New("skimmer: server closed")
+
+// Server is a telnet server that is compatible with DX cluster clients. It asks for the callsign of the user and
+// then sends all published spots in the common "DX de" format. Commands of the users are ignored, except "bye"
+// and "quit".
+type Server struct {
+	callsign string
+
+	mu        sync.Mutex
+	listeners map[net.Listener]bool
+	clients   map[*client]bool
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+type client struct {
+	conn  net.Conn
+	spots chan string
+}
+
+// NewServer returns a new Server for the skimmer with the given callsign.
+func NewServer(callsign string) *Server {
+	return &Server{
+		callsign:  strings.ToUpper(callsign),
+		listeners: make(map[net.Listener]bool),
+		clients:   make(map[*client]bool),
+	}
+}
+
+// ListenAndServe listens on the given TCP address and serves the clients.
+func (s *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts clients on the given listener until the server is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listeners[listener] = true
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, listener)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// Clients returns the number of logged in clients.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Publish sends the given spot to all logged in clients.
+func (s *Server) Publish(spot dxcluster.Spot) {
+	line := spot.String() + "\r\n"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c.spots <- line:
+		default:
+		}
+	}
+}
+
+// Close stops listening and disconnects all clients.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for listener := range s.listeners {
+		listener.Close()
+	}
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	_, err := fmt.Fprint(conn, "login: ")
+	if err != nil {
+		return
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	callsign := strings.ToUpper(strings.TrimSpace(line))
+	if callsign == "" {
+		return
+	}
+	_, err = fmt.Fprintf(conn, "Hello %s, this is %s\r\n%s de %s >\r\n", callsign, s.callsign, callsign, s.callsign)
+	if err != nil {
+		return
+	}
+
+	c := &client{conn: conn, spots: make(chan string, clientQueueLength)}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.clients[c] = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToLower(strings.TrimSpace(line))
+			if command == "bye" || command == "quit" {
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case line := <-c.spots:
+			_, err := conn.Write([]byte(line))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package skimmer
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/dxcluster"
+)
+
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer("dl1abc-#")
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	prompt := make([]byte, len("login: "))
+	_, err = reader.Read(prompt)
+	require.NoError(t, err)
+	assert.Equal(t, "login: ", string(prompt))
+	_, err = conn.Write([]byte("w1aw\r\n"))
+	require.NoError(t, err)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "Hello W1AW, this is DL1ABC-#\r\n", line)
+	_, err = reader.ReadString('\n')
+	require.NoError(t, err)
+
+	for server.Clients() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	spot := dxcluster.Spot{Spotter: "DL1ABC-#", Frequency: 14075000, DXCall: "K1A", Comment: "FT8 -5dB", Time: time.Date(2020, 5, 1, 12, 34, 0, 0, time.UTC)}
+	server.Publish(spot)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	parsed, err := dxcluster.ParseSpot(strings.TrimSpace(line), spot.Time)
+	require.NoError(t, err)
+	assert.Equal(t, "K1A", parsed.DXCall)
+	assert.Equal(t, 14075000.0, parsed.Frequency)
+
+	_, err = conn.Write([]byte("bye\r\n"))
+	require.NoError(t, err)
+	for server.Clients() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, server.Close())
+	assert.Equal(t, ErrServerClosed, <-served)
+}
@@ -0,0 +1,283 @@
+/*
+Package skimmer runs many decoders concurrently over a wide receive passband and aggregates their decodes into
+spots. The spots are delivered to a hook and can be served to DX cluster clients through a telnet server, which
+turns an SDR and this package into a standalone skimmer.
+*/
+package skimmer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/cmplx"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/dsp"
+	"github.com/ftl/digimodes/dxcluster"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// Default parameters of the Skimmer.
+const (
+	// DefaultQueueLength is the number of sample blocks that are buffered for each decoder.
+	DefaultQueueLength = 64
+	// DefaultRespotInterval is the time after which the same station on the same frequency is spotted again.
+	DefaultRespotInterval = 10 * time.Minute
+)
+
+// ErrNoChannels is returned when a skimmer is configured without channels.
+var ErrNoChannels = errors.New("skimmer: no channels configured")
+
+// NewDecoderFunc creates a decoder for audio with the given sample rate.
+type NewDecoderFunc func(sampleRate int) (digimodes.Decoder, error)
+
+// Channel is a part of the receive passband that is watched by one decoder.
+type Channel struct {
+	// Mode of the decoder.
+	Mode string
+	// Center is the audio frequency of the center of the channel within the receive passband in Hz.
+	Center float64
+	// Bandwidth of the channel in Hz.
+	Bandwidth float64
+	// SampleRate of the decoder audio. It must divide the sample rate of the receive passband and be at least
+	// twice the bandwidth.
+	SampleRate int
+	// NewDecoder creates the decoder of the channel.
+	NewDecoder NewDecoderFunc
+}
+
+// Config of a Skimmer.
+type Config struct {
+	// Callsign of the skimmer, used as spotter, e.g. "DL1ABC-#".
+	Callsign string
+	// Dial is the (USB) dial frequency of the receive passband in Hz. Without dial frequency, no spots are created.
+	Dial float64
+	// SampleRate of the receive passband.
+	SampleRate int
+	// Channels to decode.
+	Channels []Channel
+	// QueueLength is the number of sample blocks that are buffered for each decoder. 0 means DefaultQueueLength.
+	QueueLength int
+	// RespotInterval is the time after which the same station is spotted again. 0 means DefaultRespotInterval.
+	RespotInterval time.Duration
+	// Decode is called with every decode record. It is optional.
+	Decode func(digimodes.DecodeRecord)
+	// Spot is called with every new spot, e.g. with Server.Publish. It is optional.
+	Spot func(dxcluster.Spot)
+}
+
+// Skimmer feeds the channels of a shared receive passband to their decoders and turns the decodes into spots.
+// Each decoder runs in its own goroutine. If a decoder cannot keep up, blocks of its channel are dropped.
+type Skimmer struct {
+	config   Config
+	clock    timesource.Clock
+	frontEnd *dsp.FrontEnd
+	ctx      context.Context
+	cancel   context.CancelFunc
+	workers  []*worker
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	spotted  map[spotKey]time.Time
+	overruns int
+	closed   bool
+}
+
+type spotKey struct {
+	call string
+	kHz  int
+}
+
+// worker runs the decoder of one channel.
+type worker struct {
+	channel      Channel
+	decoder      digimodes.Decoder
+	blocks       chan []float64
+	intermediate float64
+	phasor       complex128
+	rotation     complex128
+}
+
+// New creates the decoders of all channels and starts them. If clock is nil, the system clock is used.
+func New(config Config, clock timesource.Clock) (*Skimmer, error) {
+	if len(config.Channels) == 0 {
+		return nil, ErrNoChannels
+	}
+	if config.QueueLength == 0 {
+		config.QueueLength = DefaultQueueLength
+	}
+	if config.RespotInterval == 0 {
+		config.RespotInterval = DefaultRespotInterval
+	}
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := &Skimmer{
+		config:   config,
+		clock:    clock,
+		frontEnd: dsp.NewFrontEnd(config.SampleRate),
+		ctx:      ctx,
+		cancel:   cancel,
+		spotted:  make(map[spotKey]time.Time),
+	}
+
+	for _, channel := range config.Channels {
+		w, err := result.addChannel(channel)
+		if err != nil {
+			result.closeDecoders()
+			cancel()
+			return nil, err
+		}
+		result.workers = append(result.workers, w)
+	}
+	for _, w := range result.workers {
+		result.wg.Add(2)
+		go result.decode(w)
+		go result.collect(w)
+	}
+	return result, nil
+}
+
+func (s *Skimmer) addChannel(channel Channel) (*worker, error) {
+	decoder, err := channel.NewDecoder(channel.SampleRate)
+	if err != nil {
+		return nil, err
+	}
+	// the baseband of the channel is shifted up to the center of the decoder audio
+	intermediate := float64(channel.SampleRate) / 4
+	w := &worker{
+		channel:      channel,
+		decoder:      decoder,
+		blocks:       make(chan []float64, s.config.QueueLength),
+		intermediate: intermediate,
+		phasor:       1,
+		rotation:     cmplx.Exp(complex(0, 2*math.Pi*intermediate/float64(channel.SampleRate))),
+	}
+	_, err = s.frontEnd.AddChannel(channel.Center, channel.Bandwidth, channel.SampleRate, s.handler(w))
+	if err != nil {
+		decoder.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// handler converts the baseband of the channel into real audio and passes it to the decoder goroutine.
+func (s *Skimmer) handler(w *worker) func([]complex128) {
+	return func(baseband []complex128) {
+		block := make([]float64, len(baseband))
+		for i, x := range baseband {
+			// the mixing of real input halves the amplitude
+			block[i] = 2 * real(x*w.phasor)
+			w.phasor *= w.rotation
+		}
+		w.phasor /= complex(cmplx.Abs(w.phasor), 0)
+
+		select {
+		case w.blocks <- block:
+		default:
+			s.mu.Lock()
+			s.overruns++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Feed passes the given samples of the receive passband to all channels.
+func (s *Skimmer) Feed(samples []float64) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return digimodes.ErrDecoderClosed
+	}
+	s.frontEnd.Process(samples)
+	return nil
+}
+
+// Overruns returns the number of sample blocks that were dropped because a decoder could not keep up.
+func (s *Skimmer) Overruns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overruns
+}
+
+// Close stops all decoders. The decodes of the already buffered samples are still delivered.
+func (s *Skimmer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	for _, w := range s.workers {
+		close(w.blocks)
+	}
+	s.wg.Wait()
+	s.cancel()
+	return nil
+}
+
+func (s *Skimmer) closeDecoders() {
+	for _, w := range s.workers {
+		w.decoder.Close()
+	}
+}
+
+func (s *Skimmer) decode(w *worker) {
+	defer s.wg.Done()
+	defer w.decoder.Close()
+	for block := range w.blocks {
+		if err := w.decoder.Feed(s.ctx, block); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Skimmer) collect(w *worker) {
+	defer s.wg.Done()
+	for record := range w.decoder.Records() {
+		// the audio frequency within the receive passband
+		record.AudioFrequency += w.channel.Center - w.intermediate
+		if record.Mode == "" {
+			record.Mode = strings.ToLower(w.channel.Mode)
+		}
+		if s.config.Dial != 0 {
+			record.RFFrequency = s.config.Dial + record.AudioFrequency
+		}
+		if s.config.Decode != nil {
+			s.config.Decode(record)
+		}
+
+		spot, ok := dxcluster.SpotFromDecode(record, s.config.Callsign)
+		if !ok || !s.isNew(spot) {
+			continue
+		}
+		if s.config.Spot != nil {
+			s.config.Spot(spot)
+		}
+	}
+}
+
+// isNew indicates if the station of the given spot was not spotted on the same frequency within the respot interval.
+func (s *Skimmer) isNew(spot dxcluster.Spot) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for key, last := range s.spotted {
+		if now.Sub(last) >= s.config.RespotInterval {
+			delete(s.spotted, key)
+		}
+	}
+	key := spotKey{call: spot.DXCall, kHz: int(math.Round(spot.Frequency / 1000))}
+	if _, ok := s.spotted[key]; ok {
+		return false
+	}
+	s.spotted[key] = now
+	return true
+}
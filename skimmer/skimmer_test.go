@@ -0,0 +1,134 @@
+package skimmer
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/dxcluster"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// toneDecoder "decodes" the given text when the audio of a block has a significant power.
+type toneDecoder struct {
+	*digimodes.RecordQueue
+	sampleRate int
+	text       string
+}
+
+func newToneDecoder(text string) NewDecoderFunc {
+	return func(sampleRate int) (digimodes.Decoder, error) {
+		return &toneDecoder{
+			RecordQueue: digimodes.NewRecordQueue(10),
+			sampleRate:  sampleRate,
+			text:        text,
+		}, nil
+	}
+}
+
+func (d *toneDecoder) Feed(ctx context.Context, samples []float64) error {
+	power := 0.0
+	for _, x := range samples {
+		power += x * x
+	}
+	power /= float64(len(samples))
+	if power < 0.1 {
+		return nil
+	}
+	return d.Emit(ctx, digimodes.DecodeRecord{
+		AudioFrequency: float64(d.sampleRate) / 4,
+		Text:           d.text,
+		SNR:            10 * math.Log10(power),
+	})
+}
+
+func tone(frequency float64, rate int, length int) []float64 {
+	result := make([]float64, length)
+	for i := range result {
+		result[i] = math.Sin(2 * math.Pi * frequency * float64(i) / float64(rate))
+	}
+	return result
+}
+
+func TestSkimmerSpots(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := timesource.ClockFunc(func() time.Time { return now })
+
+	var mu sync.Mutex
+	spots := make([]dxcluster.Spot, 0)
+	decodes := 0
+	skimmer, err := New(Config{
+		Callsign:   "DL1ABC-#",
+		Dial:       14074000,
+		SampleRate: 12000,
+		Channels: []Channel{
+			{Mode: "FT8", Center: 1000, Bandwidth: 500, SampleRate: 2000, NewDecoder: newToneDecoder("CQ W1AW FN31")},
+			{Mode: "FT8", Center: 2000, Bandwidth: 500, SampleRate: 2000, NewDecoder: newToneDecoder("CQ K1A FN42")},
+		},
+		Decode: func(record digimodes.DecodeRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			decodes++
+			assert.Equal(t, "ft8", record.Mode)
+			assert.Equal(t, 1000.0, record.AudioFrequency)
+			assert.Equal(t, 14075000.0, record.RFFrequency)
+		},
+		Spot: func(spot dxcluster.Spot) {
+			mu.Lock()
+			defer mu.Unlock()
+			spots = append(spots, spot)
+		},
+	}, clock)
+	require.NoError(t, err)
+
+	// only the first channel contains a signal
+	samples := tone(1000, 12000, 12000)
+	for i := 0; i < len(samples); i += 1200 {
+		require.NoError(t, skimmer.Feed(samples[i:i+1200]))
+	}
+	require.NoError(t, skimmer.Close())
+	assert.Equal(t, digimodes.ErrDecoderClosed, skimmer.Feed(samples))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, decodes > 1, "decodes: %d", decodes)
+	require.Len(t, spots, 1, "the same station is spotted only once")
+	assert.Equal(t, "W1AW", spots[0].DXCall)
+	assert.Equal(t, 14075000.0, spots[0].Frequency)
+	assert.Equal(t, "DL1ABC-#", spots[0].Spotter)
+	assert.Equal(t, 0, skimmer.Overruns())
+}
+
+func TestSkimmerRespot(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := &Skimmer{
+		config:  Config{RespotInterval: 10 * time.Minute},
+		clock:   timesource.ClockFunc(func() time.Time { return now }),
+		spotted: make(map[spotKey]time.Time),
+	}
+	spot := dxcluster.Spot{DXCall: "W1AW", Frequency: 14075000}
+
+	assert.True(t, s.isNew(spot))
+	assert.False(t, s.isNew(spot))
+	assert.True(t, s.isNew(dxcluster.Spot{DXCall: "W1AW", Frequency: 7074000}))
+
+	now = now.Add(10 * time.Minute)
+	assert.True(t, s.isNew(spot))
+}
+
+func TestSkimmerInvalidConfig(t *testing.T) {
+	_, err := New(Config{SampleRate: 12000}, nil)
+	assert.Equal(t, ErrNoChannels, err)
+
+	_, err = New(Config{
+		SampleRate: 12000,
+		Channels:   []Channel{{Center: 1000, Bandwidth: 500, SampleRate: 5000, NewDecoder: newToneDecoder("")}},
+	}, nil)
+	assert.Error(t, err)
+}
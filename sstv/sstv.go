@@ -0,0 +1,239 @@
+/*
+Package sstv implements the transmission of pictures with slow scan television in the Martin and Scottie modes.
+*/
+package sstv
+
+import (
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// The frequencies of SSTV in Hz.
+const (
+	SyncFrequency   = 1200.0
+	BlackFrequency  = 1500.0
+	WhiteFrequency  = 2300.0
+	LeaderFrequency = 1900.0
+
+	visOneFrequency  = 1100.0
+	visZeroFrequency = 1300.0
+)
+
+// The durations of the VIS header in seconds.
+const (
+	leaderDuration = 0.3
+	breakDuration  = 0.01
+	visBitDuration = 0.03
+)
+
+// window is the time in seconds to ramp the amplitude up and down at the start and the end of a transmission.
+const window = 0.005
+
+// Mode describes the timing of an SSTV mode. The durations are in seconds.
+type Mode struct {
+	Name   string
+	VIS    uint8
+	Width  int
+	Height int
+
+	Sync      float64
+	Porch     float64
+	Separator float64
+	Scan      float64
+
+	// Scottie modes send the sync pulse between the blue and the red scan instead of at the start of the line.
+	Scottie bool
+}
+
+// The supported modes.
+var (
+	MartinM1  = Mode{Name: "Martin M1", VIS: 44, Width: 320, Height: 256, Sync: 0.004862, Porch: 0.000572, Separator: 0.000572, Scan: 0.146432}
+	MartinM2  = Mode{Name: "Martin M2", VIS: 40, Width: 320, Height: 256, Sync: 0.004862, Porch: 0.000572, Separator: 0.000572, Scan: 0.073216}
+	ScottieS1 = Mode{Name: "Scottie S1", VIS: 60, Width: 320, Height: 256, Sync: 0.009, Porch: 0.0015, Separator: 0.0015, Scan: 0.138240, Scottie: true}
+	ScottieS2 = Mode{Name: "Scottie S2", VIS: 56, Width: 320, Height: 256, Sync: 0.009, Porch: 0.0015, Separator: 0.0015, Scan: 0.088064, Scottie: true}
+)
+
+// LineDuration returns the duration of one scan line in seconds.
+func (m Mode) LineDuration() float64 {
+	if m.Scottie {
+		return 2*m.Separator + m.Sync + m.Porch + 3*m.Scan
+	}
+	return m.Sync + m.Porch + 3*(m.Scan+m.Separator)
+}
+
+// segment is a part of the transmission with either a constant frequency or a scan of pixels.
+type segment struct {
+	start     float64
+	duration  float64
+	frequency float64
+	pixels    []float64
+}
+
+// Modulator generates the frequency sweeps of an SSTV transmission of a picture. The transmission starts with the
+// first call of Modulate.
+type Modulator struct {
+	mode     Mode
+	segments []segment
+	index    int
+	started  bool
+	start    float64
+	ended    bool
+	done     chan struct{}
+}
+
+// NewModulator returns a new Modulator that transmits the given image in the given mode. The image is scaled to
+// the size of the mode.
+func NewModulator(mode Mode, img image.Image) *Modulator {
+	result := &Modulator{
+		mode: mode,
+		done: make(chan struct{}),
+	}
+	result.addHeader()
+	red, green, blue := scanlines(mode, img)
+	if mode.Scottie {
+		// the first line starts with a sync pulse
+		result.add(mode.Sync, SyncFrequency, nil)
+	}
+	for y := 0; y < mode.Height; y++ {
+		if mode.Scottie {
+			result.add(mode.Separator, BlackFrequency, nil)
+			result.add(mode.Scan, 0, green[y])
+			result.add(mode.Separator, BlackFrequency, nil)
+			result.add(mode.Scan, 0, blue[y])
+			result.add(mode.Sync, SyncFrequency, nil)
+			result.add(mode.Porch, BlackFrequency, nil)
+			result.add(mode.Scan, 0, red[y])
+			continue
+		}
+		result.add(mode.Sync, SyncFrequency, nil)
+		result.add(mode.Porch, BlackFrequency, nil)
+		result.add(mode.Scan, 0, green[y])
+		result.add(mode.Separator, BlackFrequency, nil)
+		result.add(mode.Scan, 0, blue[y])
+		result.add(mode.Separator, BlackFrequency, nil)
+		result.add(mode.Scan, 0, red[y])
+		result.add(mode.Separator, BlackFrequency, nil)
+	}
+	return result
+}
+
+func (m *Modulator) addHeader() {
+	m.add(leaderDuration, LeaderFrequency, nil)
+	m.add(breakDuration, SyncFrequency, nil)
+	m.add(leaderDuration, LeaderFrequency, nil)
+	m.add(visBitDuration, SyncFrequency, nil)
+	parity := uint8(0)
+	for i := 0; i < 7; i++ {
+		bit := (m.mode.VIS >> uint(i)) & 1
+		parity ^= bit
+		m.add(visBitDuration, visFrequency(bit), nil)
+	}
+	m.add(visBitDuration, visFrequency(parity), nil)
+	m.add(visBitDuration, SyncFrequency, nil)
+}
+
+func visFrequency(bit uint8) float64 {
+	if bit == 1 {
+		return visOneFrequency
+	}
+	return visZeroFrequency
+}
+
+func (m *Modulator) add(duration float64, frequency float64, pixels []float64) {
+	var start float64
+	if len(m.segments) > 0 {
+		last := m.segments[len(m.segments)-1]
+		start = last.start + last.duration
+	}
+	m.segments = append(m.segments, segment{start: start, duration: duration, frequency: frequency, pixels: pixels})
+}
+
+// scanlines samples the image at the resolution of the mode and returns the frequencies of the color channels.
+func scanlines(mode Mode, img image.Image) (red, green, blue [][]float64) {
+	bounds := img.Bounds()
+	red = make([][]float64, mode.Height)
+	green = make([][]float64, mode.Height)
+	blue = make([][]float64, mode.Height)
+	for y := 0; y < mode.Height; y++ {
+		red[y] = make([]float64, mode.Width)
+		green[y] = make([]float64, mode.Width)
+		blue[y] = make([]float64, mode.Width)
+		sourceY := bounds.Min.Y + y*bounds.Dy()/mode.Height
+		for x := 0; x < mode.Width; x++ {
+			sourceX := bounds.Min.X + x*bounds.Dx()/mode.Width
+			c := color.RGBAModel.Convert(img.At(sourceX, sourceY)).(color.RGBA)
+			red[y][x] = PixelFrequency(float64(c.R) / 255)
+			green[y][x] = PixelFrequency(float64(c.G) / 255)
+			blue[y][x] = PixelFrequency(float64(c.B) / 255)
+		}
+	}
+	return red, green, blue
+}
+
+// PixelFrequency returns the frequency for the given brightness between 0 (black) and 1 (white).
+func PixelFrequency(brightness float64) float64 {
+	return BlackFrequency + brightness*(WhiteFrequency-BlackFrequency)
+}
+
+// Mode returns the mode of the transmission.
+func (m *Modulator) Mode() Mode {
+	return m.mode
+}
+
+// Duration returns the duration of the whole transmission in seconds.
+func (m *Modulator) Duration() float64 {
+	last := m.segments[len(m.segments)-1]
+	return last.start + last.duration
+}
+
+// Done is closed when the transmission is complete.
+func (m *Modulator) Done() <-chan struct{} {
+	return m.done
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends with the transmission of the image.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.Done())
+}
+
+// Modulate returns the amplitude and the frequency of the transmission at the given time in seconds.
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if !m.started {
+		m.started = true
+		m.start = t
+	}
+	elapsed := t - m.start
+	for m.index < len(m.segments) && elapsed >= m.segments[m.index].start+m.segments[m.index].duration {
+		m.index++
+	}
+	if m.index == len(m.segments) {
+		if !m.ended {
+			m.ended = true
+			close(m.done)
+		}
+		return 0, f, p
+	}
+
+	s := m.segments[m.index]
+	frequency = s.frequency
+	if s.pixels != nil {
+		x := int((elapsed - s.start) / s.duration * float64(len(s.pixels)))
+		if x >= len(s.pixels) {
+			x = len(s.pixels) - 1
+		}
+		frequency = s.pixels[x]
+	}
+
+	amplitude = 1
+	if elapsed < window {
+		amplitude = elapsed / window
+	}
+	if remaining := m.Duration() - elapsed; remaining < window {
+		amplitude = remaining / window
+	}
+	return amplitude, frequency, p
+}
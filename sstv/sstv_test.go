@@ -0,0 +1,132 @@
+package sstv
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineDuration(t *testing.T) {
+	testCases := []struct {
+		mode     Mode
+		expected float64
+	}{
+		{MartinM1, 0.446446},
+		{MartinM2, 0.226798},
+		{ScottieS1, 0.428220},
+		{ScottieS2, 0.277692},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.mode.Name, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, tC.mode.LineDuration(), 1e-9)
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	const header = 0.91
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	assert.InDelta(t, header+256*MartinM1.LineDuration(), NewModulator(MartinM1, img).Duration(), 1e-6)
+	assert.InDelta(t, header+ScottieS1.Sync+256*ScottieS1.LineDuration(), NewModulator(ScottieS1, img).Duration(), 1e-6)
+}
+
+func TestVISHeader(t *testing.T) {
+	testCases := []struct {
+		mode     Mode
+		expected []float64
+	}{
+		// start bit, 7 data bits LSB first, even parity, stop bit
+		{MartinM1, []float64{1200, 1300, 1300, 1100, 1100, 1300, 1100, 1300, 1100, 1200}},
+		{ScottieS1, []float64{1200, 1300, 1300, 1100, 1100, 1100, 1100, 1300, 1300, 1200}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.mode.Name, func(t *testing.T) {
+			m := NewModulator(tC.mode, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+			frequencyAt := func(at float64) float64 {
+				_, frequency, _ := m.Modulate(at, 0, 0, 0)
+				return frequency
+			}
+			frequencyAt(0)
+
+			assert.Equal(t, LeaderFrequency, frequencyAt(0.15))
+			assert.Equal(t, SyncFrequency, frequencyAt(0.305))
+			assert.Equal(t, LeaderFrequency, frequencyAt(0.46))
+			for i, expected := range tC.expected {
+				assert.Equal(t, expected, frequencyAt(0.61+float64(i)*0.03+0.015), "bit %d", i)
+			}
+		})
+	}
+}
+
+func TestScanline(t *testing.T) {
+	// left half red, right half white
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if x < 32 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	testCases := []struct {
+		mode  Mode
+		green float64
+		blue  float64
+		red   float64
+	}{
+		{MartinM1, 0.910 + MartinM1.Sync + MartinM1.Porch, 0.910 + MartinM1.Sync + MartinM1.Porch + MartinM1.Scan + MartinM1.Separator, 0.910 + MartinM1.Sync + MartinM1.Porch + 2*(MartinM1.Scan+MartinM1.Separator)},
+		{ScottieS1, 0.910 + ScottieS1.Sync + ScottieS1.Separator, 0.910 + ScottieS1.Sync + 2*ScottieS1.Separator + ScottieS1.Scan, 0.910 + 2*ScottieS1.Sync + 2*ScottieS1.Separator + ScottieS1.Porch + 2*ScottieS1.Scan},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.mode.Name, func(t *testing.T) {
+			m := NewModulator(tC.mode, img)
+			frequencyAt := func(at float64) float64 {
+				_, frequency, _ := m.Modulate(at, 0, 0, 0)
+				return frequency
+			}
+			frequencyAt(0)
+			left := tC.mode.Scan / 4
+			right := 3 * tC.mode.Scan / 4
+
+			assert.Equal(t, BlackFrequency, frequencyAt(tC.green+left))
+			assert.Equal(t, WhiteFrequency, frequencyAt(tC.green+right))
+			assert.Equal(t, BlackFrequency, frequencyAt(tC.blue+left))
+			assert.Equal(t, WhiteFrequency, frequencyAt(tC.blue+right))
+			assert.Equal(t, WhiteFrequency, frequencyAt(tC.red+left))
+			assert.Equal(t, WhiteFrequency, frequencyAt(tC.red+right))
+		})
+	}
+}
+
+func TestModulateEnds(t *testing.T) {
+	m := NewModulator(MartinM2, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	amplitude, _, _ := m.Modulate(10, 0, 0, 0)
+	assert.Equal(t, 0.0, amplitude)
+
+	amplitude, _, _ = m.Modulate(10+m.Duration()/2, 0, 0, 0)
+	assert.Equal(t, 1.0, amplitude)
+	select {
+	case <-m.Done():
+		assert.Fail(t, "done too early")
+	default:
+	}
+
+	amplitude, _, _ = m.Modulate(10+m.Duration(), 0, 0, 0)
+	assert.Equal(t, 0.0, amplitude)
+	<-m.Done()
+}
+
+func TestModulateDoesNotAllocate(t *testing.T) {
+	m := NewModulator(ScottieS2, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	var a, f, p float64
+	n := 0
+	allocs := testing.AllocsPerRun(10000, func() {
+		a, f, p = m.Modulate(float64(n)/8000, a, f, p)
+		n++
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -0,0 +1,114 @@
+package timesource
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultGpsdAddress is the default address of the gpsd service.
+const DefaultGpsdAddress = "localhost:2947"
+
+const gpsdWatch = `?WATCH={"enable":true,"json":true,"pps":true};` + "\n"
+
+type gpsdReport struct {
+	Class     string `json:"class"`
+	Time      string `json:"time"`
+	Mode      int    `json:"mode"`
+	RealSec   int64  `json:"real_sec"`
+	RealNsec  int64  `json:"real_nsec"`
+	ClockSec  int64  `json:"clock_sec"`
+	ClockNsec int64  `json:"clock_nsec"`
+}
+
+// GpsdReceiver disciplines a clock using the time information provided by gpsd.
+// If gpsd provides PPS reports, they are used exclusively, otherwise the time of TPV reports is used.
+type GpsdReceiver struct {
+	// SentenceDelay is the typical delay between the start of the second and the reception of the TPV report.
+	// It is only used without PPS.
+	SentenceDelay time.Duration
+
+	clock   *DisciplinedClock
+	havePPS bool
+}
+
+// NewGpsdReceiver returns a new GpsdReceiver that disciplines the given clock.
+func NewGpsdReceiver(clock *DisciplinedClock) *GpsdReceiver {
+	return &GpsdReceiver{
+		clock: clock,
+	}
+}
+
+// DialAndRun connects to gpsd at the given address and handles the received reports until the context is done.
+func (r *GpsdReceiver) DialAndRun(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	err = r.Run(ctx, conn)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Run enables the watcher mode on the given gpsd connection and handles the received reports until the connection is closed.
+func (r *GpsdReceiver) Run(ctx context.Context, conn io.ReadWriter) error {
+	_, err := io.WriteString(conn, gpsdWatch)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := r.HandleReport(scanner.Bytes(), r.clock.Local().Now())
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// HandleReport handles the given JSON report from gpsd that was received at the given local time.
+func (r *GpsdReceiver) HandleReport(report []byte, received time.Time) error {
+	var parsed gpsdReport
+	err := json.Unmarshal(report, &parsed)
+	if err != nil {
+		return fmt.Errorf("timesource: invalid gpsd report: %w", err)
+	}
+
+	switch parsed.Class {
+	case "PPS":
+		if !r.havePPS {
+			r.clock.Reset()
+			r.havePPS = true
+		}
+		reference := time.Unix(parsed.RealSec, parsed.RealNsec)
+		local := time.Unix(parsed.ClockSec, parsed.ClockNsec)
+		r.clock.AddSample(reference, local)
+	case "TPV":
+		if r.havePPS || parsed.Mode < 2 || parsed.Time == "" {
+			return nil
+		}
+		reference, err := time.Parse(time.RFC3339Nano, parsed.Time)
+		if err != nil {
+			return fmt.Errorf("timesource: invalid time in gpsd report: %w", err)
+		}
+		r.clock.AddSample(reference.Add(r.SentenceDelay), received)
+	}
+	return nil
+}
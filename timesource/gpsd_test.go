@@ -0,0 +1,30 @@
+package timesource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGpsdReceiver(t *testing.T) {
+	clock := NewDisciplinedClock(nil)
+	receiver := NewGpsdReceiver(clock)
+	received := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	err := receiver.HandleReport([]byte(`{"class":"TPV","mode":3,"time":"2020-05-01T12:00:00.250Z"}`), received)
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, clock.Offset())
+
+	err = receiver.HandleReport([]byte(`{"class":"PPS","real_sec":1588334401,"real_nsec":0,"clock_sec":1588334400,"clock_nsec":999000000}`), received)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond, clock.Offset())
+
+	err = receiver.HandleReport([]byte(`{"class":"TPV","mode":3,"time":"2020-05-01T12:00:00.250Z"}`), received)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond, clock.Offset(), "TPV is ignored when PPS is available")
+
+	err = receiver.HandleReport([]byte(`not json`), received)
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package timesource
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors while parsing NMEA sentences.
+var (
+	ErrNoTimeSentence  = errors.New("timesource: no NMEA time sentence")
+	ErrInvalidSentence = errors.New("timesource: invalid NMEA sentence")
+	ErrInvalidChecksum = errors.New("timesource: invalid NMEA checksum")
+	ErrNoFix           = errors.New("timesource: NMEA sentence without valid fix")
+)
+
+// ppsWindow is the maximum time between a PPS edge and the NMEA sentence that reports the time of this edge.
+const ppsWindow = 990 * time.Millisecond
+
+// NMEAReceiver disciplines a clock using the time information of a GPS receiver that provides NMEA sentences
+// (RMC or ZDA) and optionally a PPS (pulse per second) signal.
+//
+// Without PPS, the reception time of the sentence is used as local time, corrected by SentenceDelay. This is
+// only accurate within some 100 milliseconds. With PPS, the time of the PPS edge is used as local time for the
+// time that is reported in the following NMEA sentence.
+type NMEAReceiver struct {
+	// SentenceDelay is the typical delay between the start of the second and the reception of the time sentence.
+	// It is only used without PPS.
+	SentenceDelay time.Duration
+
+	clock *DisciplinedClock
+
+	mu            sync.Mutex
+	lastPPS       time.Time
+	lastReference time.Time
+}
+
+// NewNMEAReceiver returns a new NMEAReceiver that disciplines the given clock.
+func NewNMEAReceiver(clock *DisciplinedClock) *NMEAReceiver {
+	return &NMEAReceiver{
+		clock: clock,
+	}
+}
+
+// HandlePPS handles a PPS edge that was detected at the given local time.
+func (r *NMEAReceiver) HandlePPS(edge time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastPPS = edge
+}
+
+// HandleSentence handles the given NMEA sentence that was received at the given local time.
+// Sentences that do not contain time information are ignored.
+func (r *NMEAReceiver) HandleSentence(sentence string, received time.Time) error {
+	reference, err := ParseNMEATime(sentence)
+	if err == ErrNoTimeSentence {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reference.Equal(r.lastReference) {
+		return nil
+	}
+	r.lastReference = reference
+
+	sinceLastPPS := received.Sub(r.lastPPS)
+	if !r.lastPPS.IsZero() && sinceLastPPS >= 0 && sinceLastPPS < ppsWindow && reference.Nanosecond() == 0 {
+		r.clock.AddSample(reference, r.lastPPS)
+		return nil
+	}
+	r.clock.AddSample(reference.Add(r.SentenceDelay), received)
+	return nil
+}
+
+// Run reads NMEA sentences from the given reader and PPS edges from the given channel until the context is
+// done or the reader is exhausted. The pps channel may be nil if there is no PPS signal available. If the pps
+// channel is closed, Run continues without the PPS signal.
+// To read from a serial GPS receiver, open the device file (e.g. /dev/ttyACM0) and pass it as reader. The serial
+// port must be configured with the correct baudrate beforehand.
+func (r *NMEAReceiver) Run(ctx context.Context, in io.Reader, pps <-chan time.Time) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		readErr <- err
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case edge, ok := <-pps:
+			if !ok {
+				// the PPS signal is gone, continue with the sentences only
+				pps = nil
+				continue
+			}
+			r.HandlePPS(edge)
+		case line := <-lines:
+			err := r.HandleSentence(line, r.clock.Local().Now())
+			if errors.Is(err, ErrInvalidSentence) || errors.Is(err, ErrInvalidChecksum) || errors.Is(err, ErrNoFix) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ParseNMEATime returns the UTC time that is contained in the given NMEA sentence. Only RMC and ZDA sentences
+// are supported, all other sentences result in ErrNoTimeSentence.
+func ParseNMEATime(sentence string) (time.Time, error) {
+	fields, err := splitNMEA(sentence)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(fields[0]) < 5 {
+		return time.Time{}, ErrInvalidSentence
+	}
+
+	switch fields[0][len(fields[0])-3:] {
+	case "RMC":
+		if len(fields) < 10 {
+			return time.Time{}, ErrInvalidSentence
+		}
+		if fields[2] != "A" {
+			return time.Time{}, ErrNoFix
+		}
+		return parseNMEADateTime(fields[9], fields[1])
+	case "ZDA":
+		if len(fields) < 5 {
+			return time.Time{}, ErrInvalidSentence
+		}
+		if len(fields[2]) != 2 || len(fields[3]) != 2 || len(fields[4]) != 4 {
+			return time.Time{}, ErrInvalidSentence
+		}
+		return parseNMEADateTime(fields[2]+fields[3]+fields[4][2:], fields[1])
+	default:
+		return time.Time{}, ErrNoTimeSentence
+	}
+}
+
+func splitNMEA(sentence string) ([]string, error) {
+	sentence = strings.TrimSpace(sentence)
+	if len(sentence) < 1 || sentence[0] != '$' {
+		return nil, ErrInvalidSentence
+	}
+	sentence = sentence[1:]
+
+	if i := strings.IndexByte(sentence, '*'); i != -1 {
+		expected, err := strconv.ParseUint(sentence[i+1:], 16, 8)
+		if err != nil {
+			return nil, ErrInvalidChecksum
+		}
+		sentence = sentence[:i]
+		var checksum byte
+		for j := 0; j < len(sentence); j++ {
+			checksum ^= sentence[j]
+		}
+		if checksum != byte(expected) {
+			return nil, ErrInvalidChecksum
+		}
+	}
+
+	return strings.Split(sentence, ","), nil
+}
+
+func parseNMEADateTime(date, clock string) (time.Time, error) {
+	if len(date) != 6 || len(clock) < 6 {
+		return time.Time{}, ErrInvalidSentence
+	}
+	day, err1 := strconv.Atoi(date[0:2])
+	month, err2 := strconv.Atoi(date[2:4])
+	year, err3 := strconv.Atoi(date[4:6])
+	hour, err4 := strconv.Atoi(clock[0:2])
+	minute, err5 := strconv.Atoi(clock[2:4])
+	seconds, err6 := strconv.ParseFloat(clock[4:], 64)
+	for _, err := range []error{err1, err2, err3, err4, err5, err6} {
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSentence, err)
+		}
+	}
+
+	second := int(seconds)
+	nanosecond := int((seconds-float64(second))*1e9 + 0.5)
+	if year < 80 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, second, nanosecond, time.UTC), nil
+}
@@ -0,0 +1,100 @@
+package timesource
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNMEATime(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		sentence string
+		expected time.Time
+		err      error
+	}{
+		{"RMC", "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A", time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC), nil},
+		{"RMC with fraction", "$GNRMC,092751.50,A,5321.6802,N,00630.3372,W,0.06,31.66,280511,,,A", time.Date(2011, 5, 28, 9, 27, 51, 500000000, time.UTC), nil},
+		{"RMC without fix", "$GPRMC,123519,V,,,,,,,230394,,", time.Time{}, ErrNoFix},
+		{"ZDA", "$GPZDA,201530.00,04,07,2002,00,00*60", time.Date(2002, 7, 4, 20, 15, 30, 0, time.UTC), nil},
+		{"GGA", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", time.Time{}, ErrNoTimeSentence},
+		{"wrong checksum", "$GPZDA,201530.00,04,07,2002,00,00*61", time.Time{}, ErrInvalidChecksum},
+		{"no sentence", "GPZDA,201530.00,04,07,2002,00,00", time.Time{}, ErrInvalidSentence},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			actual, err := ParseNMEATime(tC.sentence)
+			if tC.err != nil {
+				assert.Equal(t, tC.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestNMEAReceiverWithPPS(t *testing.T) {
+	clock := NewDisciplinedClock(nil)
+	receiver := NewNMEAReceiver(clock)
+	edge := time.Date(2002, 7, 4, 20, 15, 29, 700000000, time.UTC)
+
+	receiver.HandlePPS(edge)
+	err := receiver.HandleSentence("$GPZDA,201530.00,04,07,2002,00,00*60", edge.Add(300*time.Millisecond))
+	require.NoError(t, err)
+
+	assert.Equal(t, 300*time.Millisecond, clock.Offset())
+}
+
+func TestNMEAReceiverWithoutPPS(t *testing.T) {
+	clock := NewDisciplinedClock(nil)
+	receiver := NewNMEAReceiver(clock)
+	receiver.SentenceDelay = 100 * time.Millisecond
+	received := time.Date(2002, 7, 4, 20, 15, 30, 0, time.UTC)
+
+	err := receiver.HandleSentence("$GPZDA,201530.00,04,07,2002,00,00*60", received)
+	require.NoError(t, err)
+
+	assert.Equal(t, 100*time.Millisecond, clock.Offset())
+}
+
+func TestNMEAReceiverRun(t *testing.T) {
+	local := time.Date(2002, 7, 4, 20, 15, 30, 0, time.UTC)
+	clock := NewDisciplinedClock(ClockFunc(func() time.Time { return local }))
+	receiver := NewNMEAReceiver(clock)
+	input := strings.NewReader("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n" +
+		"garbage\r\n" +
+		"$GPZDA,201531.00,04,07,2002,00,00*61\r\n")
+
+	err := receiver.Run(context.Background(), input, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, clock.Offset())
+	assert.Equal(t, 1, clock.Samples())
+}
+
+func TestNMEAReceiverRunClosedPPS(t *testing.T) {
+	local := time.Date(2002, 7, 4, 20, 15, 31, 0, time.UTC)
+	clock := NewDisciplinedClock(ClockFunc(func() time.Time { return local }))
+	receiver := NewNMEAReceiver(clock)
+	input, output := io.Pipe()
+	pps := make(chan time.Time)
+	go func() {
+		pps <- time.Date(2002, 7, 4, 20, 15, 30, 700000000, time.UTC)
+		close(pps)
+		io.WriteString(output, "$GPZDA,201531.00,04,07,2002,00,00*61\r\n")
+		output.Close()
+	}()
+
+	err := receiver.Run(context.Background(), input, pps)
+	require.NoError(t, err)
+
+	// the sentence is aligned to the last PPS edge
+	assert.Equal(t, 300*time.Millisecond, clock.Offset())
+	assert.Equal(t, 1, clock.Samples())
+}
@@ -0,0 +1,153 @@
+/*
+Package timesource provides clocks that can be disciplined by an external time reference like a GPS receiver.
+
+The clocks of this package can be used to schedule time-synchronized transmissions (e.g. WSPR) on machines without NTP,
+where the start of a transmission must be accurate within a fraction of a second.
+*/
+package timesource
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that uses the local system time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now returns the result of f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+const (
+	// offsetWeight is the weight of a new sample in the moving average of the offset.
+	offsetWeight = 1.0 / 8.0
+	// jitterWeight is the weight of a new sample in the moving average of the jitter.
+	jitterWeight = 1.0 / 4.0
+	// stepThreshold is the difference between a sample and the current offset that makes the clock step directly to the sample.
+	stepThreshold = 500 * time.Millisecond
+)
+
+// DisciplinedClock is a Clock that corrects a local clock by the measured offset to a time reference.
+// The offset is measured by adding samples that relate a reference time to the local time when the reference
+// time was valid. It is smoothed using a moving average, the jitter is the moving RMS of the differences between
+// successive samples.
+type DisciplinedClock struct {
+	local Clock
+
+	mu         sync.RWMutex
+	offset     float64
+	jitter     float64
+	lastSample float64
+	samples    int
+	lastUpdate time.Time
+}
+
+// NewDisciplinedClock returns a new DisciplinedClock based on the given local clock. If local is nil, the SystemClock is used.
+func NewDisciplinedClock(local Clock) *DisciplinedClock {
+	if local == nil {
+		local = SystemClock
+	}
+	return &DisciplinedClock{
+		local: local,
+	}
+}
+
+// Now returns the current time of the local clock corrected by the measured offset.
+func (c *DisciplinedClock) Now() time.Time {
+	return c.local.Now().Add(c.Offset())
+}
+
+// Local returns the underlying local clock.
+func (c *DisciplinedClock) Local() Clock {
+	return c.local
+}
+
+// AddSample adds a measurement to the clock: reference is the time of the reference at the local time local.
+func (c *DisciplinedClock) AddSample(reference, local time.Time) {
+	sample := float64(reference.Sub(local))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.samples == 0:
+		c.offset = sample
+		c.jitter = 0
+	case math.Abs(sample-c.offset) > float64(stepThreshold):
+		c.offset = sample
+		c.jitter = 0
+		c.samples = 0
+	default:
+		delta := sample - c.lastSample
+		c.offset += offsetWeight * (sample - c.offset)
+		c.jitter = math.Sqrt(c.jitter*c.jitter + jitterWeight*(delta*delta-c.jitter*c.jitter))
+	}
+	c.lastSample = sample
+	c.samples++
+	c.lastUpdate = local
+}
+
+// Reset discards all samples.
+func (c *DisciplinedClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = 0
+	c.jitter = 0
+	c.lastSample = 0
+	c.samples = 0
+	c.lastUpdate = time.Time{}
+}
+
+// Offset returns the measured offset between the reference and the local clock.
+func (c *DisciplinedClock) Offset() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Duration(c.offset)
+}
+
+// Jitter returns the measured jitter of the offset samples.
+func (c *DisciplinedClock) Jitter() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Duration(c.jitter)
+}
+
+// Samples returns the number of samples that were added since the last step of the clock.
+func (c *DisciplinedClock) Samples() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.samples
+}
+
+// LastUpdate returns the local time of the last sample.
+func (c *DisciplinedClock) LastUpdate() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastUpdate
+}
+
+// Synchronized indicates if the clock received at least minSamples samples and the last sample is not older than maxAge.
+func (c *DisciplinedClock) Synchronized(minSamples int, maxAge time.Duration) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.samples < minSamples || c.samples == 0 {
+		return false
+	}
+	return c.local.Now().Sub(c.lastUpdate) <= maxAge
+}
@@ -0,0 +1,50 @@
+package timesource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisciplinedClock(t *testing.T) {
+	local := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewDisciplinedClock(ClockFunc(func() time.Time { return local }))
+
+	assert.Equal(t, time.Duration(0), clock.Offset())
+	assert.False(t, clock.Synchronized(1, time.Minute))
+
+	clock.AddSample(local.Add(100*time.Millisecond), local)
+	assert.Equal(t, 100*time.Millisecond, clock.Offset())
+	assert.Equal(t, time.Duration(0), clock.Jitter())
+	assert.Equal(t, local.Add(100*time.Millisecond), clock.Now())
+	assert.True(t, clock.Synchronized(1, time.Minute))
+
+	for i := 0; i < 100; i++ {
+		clock.AddSample(local.Add(110*time.Millisecond), local)
+	}
+	assert.InDelta(t, float64(110*time.Millisecond), float64(clock.Offset()), float64(time.Millisecond))
+	assert.InDelta(t, 0, float64(clock.Jitter()), float64(time.Millisecond))
+
+	clock.AddSample(local.Add(-2*time.Second), local)
+	assert.Equal(t, -2*time.Second, clock.Offset())
+	assert.Equal(t, 1, clock.Samples())
+
+	local = local.Add(2 * time.Minute)
+	assert.False(t, clock.Synchronized(1, time.Minute))
+}
+
+func TestDisciplinedClockJitter(t *testing.T) {
+	local := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewDisciplinedClock(ClockFunc(func() time.Time { return local }))
+
+	for i := 0; i < 100; i++ {
+		delta := time.Millisecond
+		if i%2 == 0 {
+			delta = -delta
+		}
+		clock.AddSample(local.Add(delta), local)
+	}
+	assert.InDelta(t, 0, float64(clock.Offset()), float64(200*time.Microsecond))
+	assert.InDelta(t, float64(2*time.Millisecond), float64(clock.Jitter()), float64(100*time.Microsecond))
+}
@@ -0,0 +1,243 @@
+/*
+Package txlimit protects the transmitter during unattended operation. It wraps the function that keys the
+transmitter (e.g. the setKeyDown callback of cw.Send or the activateTransmitter callback of wspr.Send) and enforces
+a maximum continuous transmission time and a maximum duty cycle.
+*/
+package txlimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/timesource"
+)
+
+// Config of a Limiter. Zero values disable the corresponding limit.
+type Config struct {
+	// MaxContinuous is the maximum time the transmitter may be keyed continuously.
+	MaxContinuous time.Duration
+	// MaxDutyCycle is the maximum ratio (0.0-1.0) of transmit time within the duty cycle window.
+	MaxDutyCycle float64
+	// DutyCycleWindow is the time window over which the duty cycle is measured.
+	DutyCycleWindow time.Duration
+}
+
+// ViolationKind describes which limit was violated.
+type ViolationKind int
+
+// The kinds of violations.
+const (
+	ContinuousTimeout ViolationKind = iota
+	DutyCycleExceeded
+)
+
+func (k ViolationKind) String() string {
+	switch k {
+	case ContinuousTimeout:
+		return "continuous transmission timeout"
+	case DutyCycleExceeded:
+		return "duty cycle exceeded"
+	default:
+		return fmt.Sprintf("ViolationKind(%d)", int(k))
+	}
+}
+
+// Violation of a limit. The transmitter was forced to key up.
+type Violation struct {
+	Kind ViolationKind
+	Time time.Time
+	// Continuous is the time the transmitter was keyed continuously until the violation.
+	Continuous time.Duration
+	// DutyCycle is the duty cycle at the time of the violation.
+	DutyCycle float64
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("txlimit: %v after %v transmit time, duty cycle %.0f%%", v.Kind, v.Continuous, v.DutyCycle*100)
+}
+
+// Limiter enforces the configured limits on a transmit path. After a violation, the transmitter stays keyed up
+// until the transmit path releases the key. While the duty cycle is exceeded, the transmitter cannot be keyed.
+type Limiter struct {
+	config      Config
+	setTransmit func(bool)
+	onViolation func(Violation)
+	clock       timesource.Clock
+
+	mu           sync.Mutex
+	requested    bool
+	transmitting bool
+	lockedOut    bool
+	since        time.Time
+	intervals    []interval
+}
+
+type interval struct {
+	from, to time.Time
+}
+
+// New returns a new Limiter that keys the transmitter using the given setTransmit function. Violations are reported
+// to the given onViolation function, which may be nil. If clock is nil, the system clock is used.
+func New(config Config, setTransmit func(bool), onViolation func(Violation), clock timesource.Clock) *Limiter {
+	if clock == nil {
+		clock = timesource.SystemClock
+	}
+	if onViolation == nil {
+		onViolation = func(Violation) {}
+	}
+	return &Limiter{
+		config:      config,
+		setTransmit: setTransmit,
+		onViolation: onViolation,
+		clock:       clock,
+	}
+}
+
+// SetTransmit is the guarded replacement for the transmit function. Pass it to the transmit path instead of the
+// original function.
+func (l *Limiter) SetTransmit(on bool) {
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.requested = on
+	if !on {
+		l.lockedOut = false
+	}
+	violation, violated := l.check(now)
+	changed := l.update(now, on && !l.lockedOut)
+	transmitting := l.transmitting
+	l.mu.Unlock()
+
+	if changed {
+		l.setTransmit(transmitting)
+	}
+	if violated {
+		l.onViolation(violation)
+	}
+}
+
+// Transmitting indicates if the transmitter is currently keyed.
+func (l *Limiter) Transmitting() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.transmitting
+}
+
+// DutyCycle returns the current duty cycle.
+func (l *Limiter) DutyCycle() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dutyCycle(l.clock.Now())
+}
+
+// Check checks the limits and keys the transmitter up if a limit is violated.
+func (l *Limiter) Check() {
+	l.mu.Lock()
+	now := l.clock.Now()
+	violation, violated := l.check(now)
+	changed := l.update(now, l.requested && !l.lockedOut)
+	transmitting := l.transmitting
+	l.mu.Unlock()
+
+	if changed {
+		l.setTransmit(transmitting)
+	}
+	if violated {
+		l.onViolation(violation)
+	}
+}
+
+// Run checks the limits periodically with the given resolution until the context is done.
+// When the context is done, the transmitter is keyed up.
+func (l *Limiter) Run(ctx context.Context, resolution time.Duration) {
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.mu.Lock()
+			changed := l.update(l.clock.Now(), false)
+			l.requested = false
+			l.mu.Unlock()
+			if changed {
+				l.setTransmit(false)
+			}
+			return
+		case <-ticker.C:
+			l.Check()
+		}
+	}
+}
+
+// check must be called with the lock held.
+func (l *Limiter) check(now time.Time) (Violation, bool) {
+	if !l.transmitting {
+		return Violation{}, false
+	}
+
+	continuous := now.Sub(l.since)
+	if l.config.MaxContinuous > 0 && continuous >= l.config.MaxContinuous {
+		l.lockedOut = true
+		return Violation{Kind: ContinuousTimeout, Time: now, Continuous: continuous, DutyCycle: l.dutyCycle(now)}, true
+	}
+	if l.dutyCycleLimited() {
+		dutyCycle := l.dutyCycle(now)
+		if dutyCycle >= l.config.MaxDutyCycle {
+			l.lockedOut = true
+			return Violation{Kind: DutyCycleExceeded, Time: now, Continuous: continuous, DutyCycle: dutyCycle}, true
+		}
+	}
+	return Violation{}, false
+}
+
+func (l *Limiter) dutyCycleLimited() bool {
+	return l.config.MaxDutyCycle > 0 && l.config.DutyCycleWindow > 0
+}
+
+// update sets the transmit state and returns true if the state changed. It must be called with the lock held.
+func (l *Limiter) update(now time.Time, transmit bool) bool {
+	if transmit && l.dutyCycleLimited() && l.dutyCycle(now) >= l.config.MaxDutyCycle {
+		transmit = false
+	}
+	if transmit == l.transmitting {
+		return false
+	}
+	if transmit {
+		l.since = now
+	} else {
+		l.intervals = append(l.intervals, interval{l.since, now})
+	}
+	l.transmitting = transmit
+	return true
+}
+
+// dutyCycle must be called with the lock held.
+func (l *Limiter) dutyCycle(now time.Time) float64 {
+	if l.config.DutyCycleWindow <= 0 {
+		return 0
+	}
+	windowStart := now.Add(-l.config.DutyCycleWindow)
+
+	var onTime time.Duration
+	valid := l.intervals[:0]
+	for _, i := range l.intervals {
+		if !i.to.After(windowStart) {
+			continue
+		}
+		valid = append(valid, i)
+		onTime += i.to.Sub(maxTime(i.from, windowStart))
+	}
+	l.intervals = valid
+	if l.transmitting {
+		onTime += now.Sub(maxTime(l.since, windowStart))
+	}
+	return float64(onTime) / float64(l.config.DutyCycleWindow)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,101 @@
+package txlimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/digimodes/timesource"
+)
+
+type testTransmitter struct {
+	on         bool
+	violations []Violation
+}
+
+func (t *testTransmitter) SetTransmit(on bool) {
+	t.on = on
+}
+
+func (t *testTransmitter) OnViolation(v Violation) {
+	t.violations = append(t.violations, v)
+}
+
+func setup(config Config) (*Limiter, *testTransmitter, *time.Time) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	tx := new(testTransmitter)
+	limiter := New(config, tx.SetTransmit, tx.OnViolation, timesource.ClockFunc(func() time.Time { return now }))
+	return limiter, tx, &now
+}
+
+func TestContinuousTimeout(t *testing.T) {
+	limiter, tx, now := setup(Config{MaxContinuous: 2 * time.Minute})
+
+	limiter.SetTransmit(true)
+	assert.True(t, tx.on)
+
+	*now = now.Add(time.Minute)
+	limiter.Check()
+	assert.True(t, tx.on)
+	assert.Empty(t, tx.violations)
+
+	*now = now.Add(time.Minute)
+	limiter.Check()
+	assert.False(t, tx.on)
+	if assert.Len(t, tx.violations, 1) {
+		assert.Equal(t, ContinuousTimeout, tx.violations[0].Kind)
+		assert.Equal(t, 2*time.Minute, tx.violations[0].Continuous)
+	}
+
+	limiter.SetTransmit(true)
+	assert.False(t, tx.on, "locked out until the key is released")
+
+	limiter.SetTransmit(false)
+	limiter.SetTransmit(true)
+	assert.True(t, tx.on)
+}
+
+func TestDutyCycle(t *testing.T) {
+	limiter, tx, now := setup(Config{MaxDutyCycle: 0.5, DutyCycleWindow: 10 * time.Minute})
+
+	limiter.SetTransmit(true)
+	*now = now.Add(3 * time.Minute)
+	limiter.SetTransmit(false)
+	assert.InDelta(t, 0.3, limiter.DutyCycle(), 0.001)
+
+	*now = now.Add(time.Minute)
+	limiter.SetTransmit(true)
+	*now = now.Add(time.Minute)
+	limiter.Check()
+	assert.True(t, tx.on)
+	*now = now.Add(time.Minute)
+	limiter.Check()
+	assert.False(t, tx.on)
+	if assert.Len(t, tx.violations, 1) {
+		assert.Equal(t, DutyCycleExceeded, tx.violations[0].Kind)
+		assert.InDelta(t, 0.5, tx.violations[0].DutyCycle, 0.001)
+	}
+
+	limiter.SetTransmit(false)
+	limiter.SetTransmit(true)
+	assert.False(t, tx.on, "the duty cycle is still exceeded")
+
+	*now = now.Add(9 * time.Minute)
+	limiter.SetTransmit(false)
+	limiter.SetTransmit(true)
+	assert.True(t, tx.on)
+	assert.InDelta(t, 0.1, limiter.DutyCycle(), 0.001)
+}
+
+func TestPassThroughWithoutLimits(t *testing.T) {
+	limiter, tx, now := setup(Config{})
+
+	limiter.SetTransmit(true)
+	*now = now.Add(24 * time.Hour)
+	limiter.Check()
+	assert.True(t, tx.on)
+	limiter.SetTransmit(false)
+	assert.False(t, tx.on)
+	assert.Empty(t, tx.violations)
+}
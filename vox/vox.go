@@ -0,0 +1,151 @@
+/*
+Package vox implements a software VOX. It watches the transmit audio of a modulator or an external audio source
+and keys the transmitter when the level exceeds a threshold. It is meant for setups without a hardware keying line.
+
+The transmitter is keyed through a func(bool), like the setKeyDown callback of cw.Send, the activateTransmitter
+callback of wspr.Send or the SetTransmit method of a txlimit.Limiter.
+*/
+package vox
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+// Defaults of the configuration.
+const (
+	DefaultThresholdDBFS = -40.0
+	DefaultAttack        = 5 * time.Millisecond
+	DefaultHang          = 500 * time.Millisecond
+	// envelopeDecay is the time constant of the envelope follower.
+	envelopeDecay = 5 * time.Millisecond
+)
+
+// Config of a VOX.
+type Config struct {
+	// ThresholdDBFS is the level in dBFS from which on the audio counts as signal.
+	ThresholdDBFS float64
+	// Attack is the time the signal must be present before the transmitter is keyed.
+	Attack time.Duration
+	// Hang is the time the transmitter stays keyed after the signal is gone.
+	Hang time.Duration
+}
+
+// DefaultConfig returns the default configuration.
+func DefaultConfig() Config {
+	return Config{
+		ThresholdDBFS: DefaultThresholdDBFS,
+		Attack:        DefaultAttack,
+		Hang:          DefaultHang,
+	}
+}
+
+// VOX keys the transmitter depending on the audio level. It implements dsp.Processor without modifying the
+// samples.
+type VOX struct {
+	sampleRate  int
+	setTransmit func(bool)
+
+	mu           sync.Mutex
+	threshold    float64
+	attack       int
+	hang         int
+	decay        float64
+	envelope     float64
+	above        int
+	below        int
+	transmitting bool
+}
+
+// New returns a new VOX for the given sample rate that keys the transmitter with the given function.
+func New(sampleRate int, config Config, setTransmit func(bool)) *VOX {
+	result := &VOX{
+		sampleRate:  sampleRate,
+		setTransmit: setTransmit,
+		decay:       math.Exp(-1 / (envelopeDecay.Seconds() * float64(sampleRate))),
+	}
+	result.SetConfig(config)
+	return result
+}
+
+// SetConfig changes the configuration.
+func (v *VOX) SetConfig(config Config) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.threshold = math.Pow(10, config.ThresholdDBFS/20)
+	v.attack = v.samples(config.Attack)
+	v.hang = v.samples(config.Hang)
+}
+
+func (v *VOX) samples(d time.Duration) int {
+	result := int(d.Seconds() * float64(v.sampleRate))
+	if result < 1 {
+		result = 1
+	}
+	return result
+}
+
+// Transmitting indicates if the VOX currently keys the transmitter.
+func (v *VOX) Transmitting() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.transmitting
+}
+
+// Process watches the level of the given samples and keys the transmitter accordingly.
+func (v *VOX) Process(samples []float64) {
+	var transitions []bool
+
+	v.mu.Lock()
+	for _, x := range samples {
+		a := math.Abs(x)
+		if a > v.envelope {
+			v.envelope = a
+		} else {
+			v.envelope *= v.decay
+		}
+
+		if v.envelope >= v.threshold {
+			v.above++
+			v.below = 0
+		} else {
+			v.below++
+			v.above = 0
+		}
+
+		switch {
+		case !v.transmitting && v.above >= v.attack:
+			v.transmitting = true
+			transitions = append(transitions, true)
+		case v.transmitting && v.below >= v.hang:
+			v.transmitting = false
+			transitions = append(transitions, false)
+		}
+	}
+	v.mu.Unlock()
+
+	if v.setTransmit == nil {
+		return
+	}
+	for _, on := range transitions {
+		v.setTransmit(on)
+	}
+}
+
+// Sink returns an audio.Sink that passes the samples to the given sink and lets the VOX watch them.
+func (v *VOX) Sink(sink audio.Sink) audio.Sink {
+	return &voxSink{Sink: sink, vox: v}
+}
+
+type voxSink struct {
+	audio.Sink
+	vox *VOX
+}
+
+func (s *voxSink) WriteSamples(samples []float64) (int, error) {
+	s.vox.Process(samples)
+	return s.Sink.WriteSamples(samples)
+}
@@ -0,0 +1,73 @@
+package vox
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+type keying struct {
+	sample int
+	on     bool
+}
+
+type countingSink struct {
+	written int
+}
+
+func (s *countingSink) SampleRate() int {
+	return 1000
+}
+
+func (s *countingSink) WriteSamples(samples []float64) (int, error) {
+	s.written += len(samples)
+	return len(samples), nil
+}
+
+func TestVOX(t *testing.T) {
+	const rate = 1000
+	var keyings []keying
+	position := 0
+	v := New(rate, Config{ThresholdDBFS: -20, Attack: 10 * time.Millisecond, Hang: 100 * time.Millisecond}, func(on bool) {
+		keyings = append(keyings, keying{position, on})
+	})
+
+	sink := &countingSink{}
+	voxSink := v.Sink(sink)
+	var _ audio.Sink = voxSink
+
+	// 100ms silence, 300ms tone, 500ms silence, processed in blocks of 1ms
+	samples := make([]float64, 900)
+	for i := 100; i < 400; i++ {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*float64(i)/20)
+	}
+	for position = 0; position < len(samples); position++ {
+		_, err := voxSink.WriteSamples(samples[position : position+1])
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 900, sink.written)
+	require.Len(t, keyings, 2)
+	assert.True(t, keyings[0].on)
+	assert.InDelta(t, 110, keyings[0].sample, 5, "attack")
+	assert.False(t, keyings[1].on)
+	assert.InDelta(t, 500, keyings[1].sample, 20, "hang")
+	assert.False(t, v.Transmitting())
+}
+
+func TestVOXIgnoresNoise(t *testing.T) {
+	keyed := false
+	v := New(1000, DefaultConfig(), func(on bool) { keyed = keyed || on })
+
+	samples := make([]float64, 1000)
+	for i := range samples {
+		samples[i] = 0.001 * math.Sin(float64(i))
+	}
+	v.Process(samples)
+	assert.False(t, keyed)
+}
@@ -0,0 +1,134 @@
+package wspr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ftl/digimodes/bandplan"
+	"github.com/ftl/digimodes/sched"
+)
+
+// qsyLead is the time before the start of a slot when the radio is tuned to the band of the slot.
+const qsyLead = 2 * time.Second
+
+// ErrInvalidBeacon is returned for a beacon without bands, without transmissions or with an invalid band entry. A
+// band entry is invalid if the WSPR sub-band above its dial frequency is outside of the WSPR segments of the band
+// plan.
+var ErrInvalidBeacon = errors.New("wspr: invalid beacon")
+
+// BandEntry is an entry of the band schedule of a Beacon.
+type BandEntry struct {
+	// Band is the name of the band, e.g. "20m".
+	Band string
+	// Frequency is the dial frequency in Hz.
+	Frequency float64
+	// TxPercentage is the percentage of the slots on this band that are used to transmit, 0-100. In the other
+	// slots, the beacon stays on the band without transmitting, so a receiver can listen.
+	TxPercentage int
+}
+
+// BeaconHooks are called by the beacon. QSY and Progress are optional.
+type BeaconHooks struct {
+	// QSY tunes the radio to the given band entry shortly before a slot on this band starts.
+	QSY func(BandEntry)
+	// PTT activates or deactivates the transmitter.
+	PTT func(bool)
+	// TransmitSymbol transmits the given symbol.
+	TransmitSymbol func(Symbol)
+	// Progress is notified about the progress of the transmissions.
+	Progress Progress
+}
+
+// Beacon coordinates a WSPR beacon on multiple bands. The bands take turns in consecutive slots of the scheduler,
+// the band of a slot is derived from the start time of the slot, so several beacons with the same band schedule
+// hop in sync. In each slot, the beacon decides randomly according to the tx percentage of the band if it transmits.
+// Messages that need more than one transmission are sent in consecutive transmit slots.
+type Beacon struct {
+	scheduler     *Scheduler
+	bands         []BandEntry
+	transmissions []Transmission
+	hooks         BeaconHooks
+	rng           *rand.Rand
+
+	next int
+}
+
+// NewBeacon returns a new Beacon that sends the given transmissions on the given bands. The bands are checked against
+// the band plan of the given region. If rng is nil, a randomly seeded source is used.
+func NewBeacon(scheduler *Scheduler, region bandplan.Region, bands []BandEntry, transmissions []Transmission, hooks BeaconHooks, rng *rand.Rand) (*Beacon, error) {
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("%w: no bands", ErrInvalidBeacon)
+	}
+	for _, band := range bands {
+		if band.TxPercentage < 0 || band.TxPercentage > 100 {
+			return nil, fmt.Errorf("%w: %s: tx percentage %d", ErrInvalidBeacon, band.Band, band.TxPercentage)
+		}
+		if err := subBand(region, band.Frequency).Check(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBeacon, band.Band, err)
+		}
+	}
+	if len(transmissions) == 0 {
+		return nil, fmt.Errorf("%w: no transmissions", ErrInvalidBeacon)
+	}
+	if hooks.PTT == nil || hooks.TransmitSymbol == nil {
+		return nil, fmt.Errorf("%w: PTT and TransmitSymbol are required", ErrInvalidBeacon)
+	}
+	if hooks.QSY == nil {
+		hooks.QSY = func(BandEntry) {}
+	}
+	if hooks.Progress == nil {
+		hooks.Progress = noProgress{}
+	}
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &Beacon{
+		scheduler:     scheduler,
+		bands:         bands,
+		transmissions: transmissions,
+		hooks:         hooks,
+		rng:           rng,
+	}, nil
+}
+
+// subBand returns the channel of the WSPR sub-band above the given dial frequency.
+func subBand(region bandplan.Region, dial float64) sched.Channel {
+	return sched.Channel{
+		Region:    region,
+		Mode:      "wspr",
+		Frequency: bandplan.Frequency(dial + (SubBandLow+SubBandHigh)/2),
+		Bandwidth: bandplan.Frequency(SubBandHigh - SubBandLow),
+	}
+}
+
+// BandAt returns the band entry of the slot that starts at the given time.
+func (b *Beacon) BandAt(start time.Time) BandEntry {
+	slot := b.scheduler.slots.Index(start)
+	return b.bands[int(slot%int64(len(b.bands)))]
+}
+
+// Run operates the beacon until the given context is done. It returns the error of the context.
+func (b *Beacon) Run(ctx context.Context) error {
+	start := b.scheduler.NextStart(b.scheduler.slots.Now().Add(qsyLead))
+	for {
+		band := b.BandAt(start)
+		b.hooks.Progress.OnWait(start)
+		if !b.scheduler.slots.WaitUntil(ctx, start.Add(-qsyLead)) {
+			return ctx.Err()
+		}
+		b.hooks.QSY(band)
+
+		if b.rng.Intn(100) < band.TxPercentage {
+			transmission := b.transmissions[b.next]
+			if !b.scheduler.sendAt(ctx, start, b.hooks.Progress, b.hooks.PTT, b.hooks.TransmitSymbol, transmission) {
+				return ctx.Err()
+			}
+			b.next = (b.next + 1) % len(b.transmissions)
+		}
+
+		start = start.Add(b.scheduler.slots.Duration())
+	}
+}
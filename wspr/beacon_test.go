@@ -0,0 +1,97 @@
+package wspr
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/bandplan"
+)
+
+func TestNewBeaconInvalid(t *testing.T) {
+	scheduler := NewScheduler(&fakeClock{}, DefaultPeriod, 0)
+	hooks := BeaconHooks{PTT: func(bool) {}, TransmitSymbol: func(Symbol) {}}
+	transmissions := []Transmission{{}}
+	bands := []BandEntry{{Band: "20m", Frequency: 14095600, TxPercentage: 20}}
+
+	_, err := NewBeacon(scheduler, bandplan.Region1, nil, transmissions, hooks, nil)
+	assert.True(t, errors.Is(err, ErrInvalidBeacon))
+	_, err = NewBeacon(scheduler, bandplan.Region1, []BandEntry{{Band: "20m", TxPercentage: 101}}, transmissions, hooks, nil)
+	assert.True(t, errors.Is(err, ErrInvalidBeacon))
+	_, err = NewBeacon(scheduler, bandplan.Region1, []BandEntry{{Band: "20m", Frequency: 14074000, TxPercentage: 20}}, transmissions, hooks, nil)
+	assert.True(t, errors.Is(err, ErrInvalidBeacon), "outside of the WSPR segment")
+	_, err = NewBeacon(scheduler, bandplan.Region1, bands, nil, hooks, nil)
+	assert.True(t, errors.Is(err, ErrInvalidBeacon))
+	_, err = NewBeacon(scheduler, bandplan.Region1, bands, transmissions, BeaconHooks{}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidBeacon))
+	_, err = NewBeacon(scheduler, bandplan.Region1, bands, transmissions, hooks, nil)
+	assert.NoError(t, err)
+}
+
+func TestBeaconRun(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 3, 10, 0, time.UTC)}
+	scheduler := NewScheduler(clock, DefaultPeriod, 0)
+	bands := []BandEntry{
+		{Band: "40m", Frequency: 7038600, TxPercentage: 100},
+		{Band: "30m", Frequency: 10138700, TxPercentage: 0},
+		{Band: "20m", Frequency: 14095600, TxPercentage: 100},
+	}
+	first, err := ToTransmission("DB0ABC", "JN59", 12)
+	require.NoError(t, err)
+	second, err := ToTransmission("DB0ABC", "JN59", 13)
+	require.NoError(t, err)
+
+	type event struct {
+		time time.Time
+		band string
+	}
+	var qsys, transmissions []event
+	var symbols []Symbol
+	var band string
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hooks := BeaconHooks{
+		QSY: func(entry BandEntry) {
+			band = entry.Band
+			qsys = append(qsys, event{clock.Now(), band})
+			if len(qsys) == 7 {
+				cancel()
+			}
+		},
+		PTT: func(on bool) {
+			if on {
+				transmissions = append(transmissions, event{clock.Now(), band})
+			}
+		},
+		TransmitSymbol: func(symbol Symbol) {
+			symbols = append(symbols, symbol)
+		},
+	}
+	beacon, err := NewBeacon(scheduler, bandplan.Region1, bands, []Transmission{first, second}, hooks, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	err = beacon.Run(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	slot := func(minute int) time.Time {
+		return time.Date(2024, 3, 1, 12, minute, 0, 0, time.UTC)
+	}
+	require.Equal(t, 7, len(qsys))
+	for i, qsy := range qsys {
+		start := slot(4 + 2*i)
+		assert.Equal(t, start.Add(-qsyLead), qsy.time)
+		assert.Equal(t, beacon.BandAt(start).Band, qsy.band)
+	}
+	require.Equal(t, 4, len(transmissions), "two of three slots are used to transmit")
+	for _, tx := range transmissions {
+		assert.NotEqual(t, "30m", tx.band, "no transmissions on 30m")
+		assert.Equal(t, 0, tx.time.Second())
+	}
+	assert.Equal(t, first[:], symbols[:162])
+	assert.Equal(t, second[:], symbols[162:324], "the transmissions alternate")
+}
@@ -0,0 +1,235 @@
+package wspr
+
+import (
+	"errors"
+	"math/bits"
+	"strings"
+
+	"github.com/ftl/digimodes/locator"
+)
+
+// hashSeed is the initial value of the callsign hash used by WSPR.
+const hashSeed = 146
+
+// MessageType is the type of a WSPR message.
+type MessageType int
+
+// The WSPR message types.
+const (
+	// Type1 contains a callsign with up to 6 characters, a 4 character locator and the power.
+	Type1 MessageType = 1
+	// Type2 contains a compound callsign with a prefix or suffix and the power.
+	Type2 MessageType = 2
+	// Type3 contains the hash of a callsign, a 6 character locator and the power.
+	Type3 MessageType = 3
+)
+
+// Errors of the encoding of compound callsigns.
+var (
+	ErrInvalidCompoundCallsign = errors.New("wspr: invalid compound callsign")
+	ErrInvalidLocator          = errors.New("wspr: invalid locator")
+	ErrInvalidPower            = errors.New("wspr: power must be between 0 and 60 dBm and end with 0, 3 or 7")
+)
+
+// ToTransmissions converts the given data into one or two WSPR transmissions. A callsign with up to 6 characters
+// and a 4 character locator fit into a single type 1 message. A compound callsign like DL/PA3XYZ or PA3XYZ/P is sent
+// as type 2 message, a 6 character locator as type 1 message, both followed by a type 3 message with the hashed
+// callsign and the 6 character locator. Compound callsigns require a 6 character locator.
+func ToTransmissions(callsign string, locator string, dBm int) ([]Transmission, error) {
+	callsign = strings.ToUpper(callsign)
+	locator = strings.ToUpper(locator)
+	compound := strings.Contains(callsign, "/")
+	if !compound && len(locator) == 4 {
+		transmission, err := ToTransmission(callsign, locator, dBm)
+		if err != nil {
+			return nil, err
+		}
+		return []Transmission{transmission}, nil
+	}
+
+	if len(locator) != 6 {
+		return nil, ErrInvalidLocator
+	}
+	if !validPower(dBm) {
+		return nil, ErrInvalidPower
+	}
+
+	var first Transmission
+	if compound {
+		n, ng, nadd, err := packCompoundCallsign(callsign)
+		if err != nil {
+			return nil, err
+		}
+		ntype := uint32(dBm + 1 + nadd)
+		first = encode(n, ng<<7+ntype+64)
+	} else {
+		var err error
+		first, err = ToTransmission(callsign, locator[:4], dBm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := packLocator6(locator)
+	if err != nil {
+		return nil, err
+	}
+	ntype := -(dBm + 1)
+	second := encode(n, uint32(CallsignHash(callsign))<<7+uint32(ntype+64))
+
+	return []Transmission{first, second}, nil
+}
+
+func validPower(dBm int) bool {
+	if dBm < 0 || dBm > 60 {
+		return false
+	}
+	switch dBm % 10 {
+	case 0, 3, 7:
+		return true
+	default:
+		return false
+	}
+}
+
+// packCompoundCallsign packs the base callsign and the prefix or suffix of the given compound callsign.
+func packCompoundCallsign(callsign string) (n uint32, ng uint32, nadd int, err error) {
+	parts := strings.Split(callsign, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return 0, 0, 0, ErrInvalidCompoundCallsign
+	}
+	prefix, call, suffix := parts[0], parts[1], ""
+	if len(parts[1]) <= 2 {
+		prefix, call, suffix = "", parts[0], parts[1]
+	}
+
+	n, err = packCallsign(call)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var full uint32
+	switch {
+	case len(suffix) == 1 && (isNumber(suffix[0]) || isLetter(suffix[0])):
+		full = 60000 + charValue(suffix[0])
+	case len(suffix) == 2 && isNumber(suffix[0]) && isNumber(suffix[1]):
+		full = 60000 + 26 + charValue(suffix[0])*10 + charValue(suffix[1])
+	case len(suffix) > 0:
+		return 0, 0, 0, ErrInvalidCompoundCallsign
+	case len(prefix) > 3:
+		return 0, 0, 0, ErrInvalidCompoundCallsign
+	default:
+		aligned := strings.Repeat(" ", 3-len(prefix)) + prefix
+		for i := 0; i < len(aligned); i++ {
+			if !(isNumber(aligned[i]) || isLetter(aligned[i]) || isSpace(aligned[i])) {
+				return 0, 0, 0, ErrInvalidCompoundCallsign
+			}
+			full = full*37 + charValue(aligned[i])
+		}
+	}
+
+	if full >= 32768 {
+		return n, full - 32768, 1, nil
+	}
+	return n, full, 0, nil
+}
+
+func unpackPrefix(ng uint32, callsign string) string {
+	if ng < 60000 {
+		var prefix [3]byte
+		for i := 2; i >= 0; i-- {
+			prefix[i] = charOf(ng % 37)
+			ng /= 37
+		}
+		return strings.TrimSpace(string(prefix[:])) + "/" + callsign
+	}
+
+	suffix := ng - 60000
+	if suffix < 36 {
+		return callsign + "/" + string(charOf(suffix))
+	}
+	suffix -= 26
+	return callsign + "/" + string([]byte{charOf(suffix / 10), charOf(suffix % 10)})
+}
+
+// packLocator6 packs a 6 character locator like a callsign, with the first character moved to the end.
+func packLocator6(loc string) (uint32, error) {
+	parsed, err := locator.Parse(loc)
+	if err != nil || len(parsed) != 6 {
+		return 0, ErrInvalidLocator
+	}
+	rotated := string(parsed[1:] + parsed[:1])
+
+	packed := charValue(rotated[0])
+	packed = packed*36 + charValue(rotated[1])
+	packed = packed*10 + charValue(rotated[2])
+	packed = packed*27 + (charValue(rotated[3]) - 10)
+	packed = packed*27 + (charValue(rotated[4]) - 10)
+	packed = packed*27 + (charValue(rotated[5]) - 10)
+	return packed, nil
+}
+
+func unpackLocator6(aligned string) string {
+	return aligned[5:] + aligned[:5]
+}
+
+// CallsignHash returns the 15 bit hash of the given callsign that is sent in type 3 messages.
+func CallsignHash(callsign string) uint16 {
+	return uint16(hashLittle([]byte(strings.ToUpper(callsign)), hashSeed) & 0x7FFF)
+}
+
+// hashLittle is Bob Jenkins' lookup3 hash function for byte keys.
+func hashLittle(key []byte, seed uint32) uint32 {
+	a := 0xdeadbeef + uint32(len(key)) + seed
+	b, c := a, a
+
+	for len(key) > 12 {
+		a += uint32(key[0]) | uint32(key[1])<<8 | uint32(key[2])<<16 | uint32(key[3])<<24
+		b += uint32(key[4]) | uint32(key[5])<<8 | uint32(key[6])<<16 | uint32(key[7])<<24
+		c += uint32(key[8]) | uint32(key[9])<<8 | uint32(key[10])<<16 | uint32(key[11])<<24
+		a -= c
+		a ^= bits.RotateLeft32(c, 4)
+		c += b
+		b -= a
+		b ^= bits.RotateLeft32(a, 6)
+		a += c
+		c -= b
+		c ^= bits.RotateLeft32(b, 8)
+		b += a
+		a -= c
+		a ^= bits.RotateLeft32(c, 16)
+		c += b
+		b -= a
+		b ^= bits.RotateLeft32(a, 19)
+		a += c
+		c -= b
+		c ^= bits.RotateLeft32(b, 4)
+		b += a
+		key = key[12:]
+	}
+	if len(key) == 0 {
+		return c
+	}
+
+	var tail [12]byte
+	copy(tail[:], key)
+	a += uint32(tail[0]) | uint32(tail[1])<<8 | uint32(tail[2])<<16 | uint32(tail[3])<<24
+	b += uint32(tail[4]) | uint32(tail[5])<<8 | uint32(tail[6])<<16 | uint32(tail[7])<<24
+	c += uint32(tail[8]) | uint32(tail[9])<<8 | uint32(tail[10])<<16 | uint32(tail[11])<<24
+
+	c ^= b
+	c -= bits.RotateLeft32(b, 14)
+	a ^= c
+	a -= bits.RotateLeft32(c, 11)
+	b ^= a
+	b -= bits.RotateLeft32(a, 25)
+	c ^= b
+	c -= bits.RotateLeft32(b, 16)
+	a ^= c
+	a -= bits.RotateLeft32(c, 4)
+	b ^= a
+	b -= bits.RotateLeft32(a, 14)
+	c ^= b
+	c -= bits.RotateLeft32(b, 24)
+	return c
+}
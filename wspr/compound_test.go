@@ -0,0 +1,88 @@
+package wspr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashLittle(t *testing.T) {
+	assert.Equal(t, uint32(0xdeadbeef), hashLittle([]byte(""), 0))
+	assert.Equal(t, uint32(0x17770551), hashLittle([]byte("Four score and seven years ago"), 0))
+	assert.Equal(t, uint32(0xcd628161), hashLittle([]byte("Four score and seven years ago"), 1))
+}
+
+func TestToTransmissions(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		callsign string
+		locator  string
+		power    int
+		expected []Message
+	}{
+		{"simple", "DL1ABC", "JO62", 23, []Message{
+			{Type: Type1, Callsign: "DL1ABC", Locator: "JO62", Power: 23},
+		}},
+		{"6 character locator", "DL1ABC", "JO62qm", 23, []Message{
+			{Type: Type1, Callsign: "DL1ABC", Locator: "JO62", Power: 23},
+			{Type: Type3, Hash: CallsignHash("DL1ABC"), Locator: "JO62QM", Power: 23},
+		}},
+		{"2 character prefix", "DL/PA3XYZ", "JO62QM", 30, []Message{
+			{Type: Type2, Callsign: "DL/PA3XYZ", Power: 30},
+			{Type: Type3, Hash: CallsignHash("DL/PA3XYZ"), Locator: "JO62QM", Power: 30},
+		}},
+		{"3 character prefix", "VK9/G0ABC", "QH30AA", 37, []Message{
+			{Type: Type2, Callsign: "VK9/G0ABC", Power: 37},
+			{Type: Type3, Hash: CallsignHash("VK9/G0ABC"), Locator: "QH30AA", Power: 37},
+		}},
+		{"1 character prefix", "F/G0ABC", "JN18DU", 0, []Message{
+			{Type: Type2, Callsign: "F/G0ABC", Power: 0},
+			{Type: Type3, Hash: CallsignHash("F/G0ABC"), Locator: "JN18DU", Power: 0},
+		}},
+		{"letter suffix", "PA3XYZ/P", "JO22AB", 33, []Message{
+			{Type: Type2, Callsign: "PA3XYZ/P", Power: 33},
+			{Type: Type3, Hash: CallsignHash("PA3XYZ/P"), Locator: "JO22AB", Power: 33},
+		}},
+		{"two digit suffix", "K1ABC/12", "FN42XX", 60, []Message{
+			{Type: Type2, Callsign: "K1ABC/12", Power: 60},
+			{Type: Type3, Hash: CallsignHash("K1ABC/12"), Locator: "FN42XX", Power: 60},
+		}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			transmissions, err := ToTransmissions(tC.callsign, tC.locator, tC.power)
+			require.NoError(t, err)
+
+			actual := make([]Message, len(transmissions))
+			for i, transmission := range transmissions {
+				actual[i], err = Decode(transmission)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tC.expected, actual)
+		})
+	}
+}
+
+func TestToTransmissionsInvalid(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		callsign string
+		locator  string
+		power    int
+		expected error
+	}{
+		{"compound callsign with short locator", "DL/PA3XYZ", "JO22", 30, ErrInvalidLocator},
+		{"invalid 6 character locator", "DL/PA3XYZ", "JO22ZZ", 30, ErrInvalidLocator},
+		{"invalid power", "DL/PA3XYZ", "JO22AB", 31, ErrInvalidPower},
+		{"prefix too long", "DLXY/PA3XYZ", "JO22AB", 30, ErrInvalidCompoundCallsign},
+		{"two slashes", "DL/PA3XYZ/P", "JO22AB", 30, ErrInvalidCompoundCallsign},
+		{"letter in two character suffix", "PA3XYZ/QR", "JO22AB", 30, ErrInvalidCompoundCallsign},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := ToTransmissions(tC.callsign, tC.locator, tC.power)
+			assert.Equal(t, tC.expected, err)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package wspr
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/dsp"
+	"github.com/ftl/digimodes/fec"
+)
+
+// dataBits is the number of bits of a type 1 message: 28 bits callsign, 15 bits locator, 7 bits power.
+const dataBits = 50
+
+// Errors of the decoder.
+var (
+	ErrDecodeFailed       = errors.New("wspr: cannot decode the transmission")
+	ErrUnsupportedMessage = errors.New("wspr: unsupported message type")
+)
+
+// Message is the content of a WSPR message. Type 2 messages have no locator. Type 3 messages contain only the
+// hash of the callsign, it can be resolved from a previous message of the same station using CallsignHash.
+type Message struct {
+	Type     MessageType
+	Callsign string
+	Hash     uint16
+	Locator  string
+	Power    int
+}
+
+// Decode recovers the message from the given hard decided symbols.
+func Decode(transmission Transmission) (Message, error) {
+	var powers [162][4]float64
+	for i, symbol := range transmission {
+		tone := int(math.Round(float64(symbol) / symbolDelta))
+		if tone < 0 || tone >= len(Symbols) {
+			return Message{}, ErrDecodeFailed
+		}
+		powers[i][tone] = 1
+	}
+	return DecodeSoft(powers)
+}
+
+// DecodeSoft recovers the message from the received power of the four tones for each symbol. The power values
+// only need to be comparable within each symbol.
+func DecodeSoft(powers [162][4]float64) (Message, error) {
+	var interleaved [162]float64
+	for i, p := range powers {
+		p0 := p[syncWord[i]]
+		p1 := p[syncWord[i]+2]
+		if p0+p1 > 0 {
+			interleaved[i] = (p1 - p0) / (p1 + p0)
+		}
+	}
+
+	parity := deinterleave(interleaved)
+	decoded, err := fec.WSPR.DecodeFano(parity[:], fec.FanoConfig{})
+	if err != nil {
+		return Message{}, ErrDecodeFailed
+	}
+	var bits [dataBits]byte
+	copy(bits[:], decoded)
+	return unpack(bits)
+}
+
+// SignalMetrics returns the quality of a received transmission from the power of the four tones for each symbol,
+// as passed to DecodeSoft. The quality is the correlation of the received tones with the sync vector, from 0
+// (noise) to 1. The SNR compares the power of the strongest tone with the other tones, relative to the noise in
+// 2500 Hz bandwidth, the RecordDecoder measures it against the noise floor of the audio instead. The squelch is open
+// if the quality is at least the given minimum.
+func SignalMetrics(powers [162][4]float64, minQuality float64) digimodes.Metrics {
+	var correlation, signal, noise float64
+	for i, p := range powers {
+		// the sync bit selects the even or the odd tones
+		even := p[0] + p[2]
+		odd := p[1] + p[3]
+		if even+odd > 0 {
+			c := (odd - even) / (odd + even)
+			if syncWord[i] == 0 {
+				c = -c
+			}
+			correlation += c
+		}
+
+		strongest := 0
+		for tone := range p {
+			if p[tone] > p[strongest] {
+				strongest = tone
+			}
+		}
+		signal += p[strongest]
+		noise += (p[0] + p[1] + p[2] + p[3] - p[strongest]) / 3
+	}
+	quality := math.Max(0, correlation/float64(len(powers)))
+
+	snr := dsp.MinSNR
+	if noise > 0 && signal > noise {
+		// the noise of each tone is measured in the bandwidth of one tone spacing
+		snr = 10*math.Log10((signal-noise)/noise) - 10*math.Log10(dsp.ReferenceBandwidth/symbolDelta)
+		snr = math.Max(dsp.MinSNR, snr)
+	}
+	return digimodes.Metrics{
+		Open:    quality >= minQuality,
+		Quality: quality,
+		SNR:     snr,
+	}
+}
+
+func deinterleave(interleaved [162]float64) (parity [162]float64) {
+	p := 0
+	for k := 0; k <= 255; k++ {
+		i := uint8(k)
+		j := uint8(0)
+		for l := 7; l >= 0; l-- {
+			j |= (i & 0x01) << uint8(l)
+			i = i >> 1
+		}
+		if j < 162 {
+			parity[p] = interleaved[j]
+			p++
+		}
+	}
+	return
+}
+
+func unpack(bits [dataBits]byte) (Message, error) {
+	var n, m uint32
+	for _, bit := range bits[:28] {
+		n = n<<1 | uint32(bit)
+	}
+	for _, bit := range bits[28:] {
+		m = m<<1 | uint32(bit)
+	}
+
+	aligned, ok := unpackCallsign(n)
+	if !ok {
+		return Message{}, ErrUnsupportedMessage
+	}
+	callsign := strings.TrimSpace(aligned)
+
+	ntype := int(m&0x7F) - 64
+	switch {
+	case ntype > 62:
+		return Message{}, ErrUnsupportedMessage
+	case ntype < 0:
+		return Message{
+			Type:    Type3,
+			Hash:    uint16(m >> 7),
+			Locator: unpackLocator6(aligned),
+			Power:   -(ntype + 1),
+		}, nil
+	}
+
+	switch nu := ntype % 10; nu {
+	case 0, 3, 7:
+		locator, ok := unpackLocator(m >> 7)
+		if !ok {
+			return Message{}, ErrUnsupportedMessage
+		}
+		return Message{Type: Type1, Callsign: callsign, Locator: locator, Power: ntype}, nil
+	default:
+		nadd := nu
+		if nu > 3 {
+			nadd = nu - 3
+		}
+		if nu > 7 {
+			nadd = nu - 7
+		}
+		ng := m>>7 + 32768*uint32(nadd-1)
+		return Message{Type: Type2, Callsign: unpackPrefix(ng, callsign), Power: ntype - nadd}, nil
+	}
+}
+
+func unpackCallsign(n uint32) (string, bool) {
+	var aligned [6]uint32
+	aligned[5] = n%27 + 10
+	n /= 27
+	aligned[4] = n%27 + 10
+	n /= 27
+	aligned[3] = n%27 + 10
+	n /= 27
+	aligned[2] = n % 10
+	n /= 10
+	aligned[1] = n % 36
+	n /= 36
+	aligned[0] = n
+	if aligned[0] > 36 {
+		return "", false
+	}
+
+	result := make([]byte, len(aligned))
+	for i, v := range aligned {
+		result[i] = charOf(v)
+	}
+	return string(result), true
+}
+
+func unpackLocator(packed uint32) (string, bool) {
+	q := packed / 180
+	if q > 179 {
+		return "", false
+	}
+	v0 := (179 - q) / 10
+	v2 := (179 - q) % 10
+	v1 := (packed % 180) / 10
+	v3 := packed % 10
+	if v0 > 17 || v1 > 17 {
+		return "", false
+	}
+	return string([]byte{byte('A' + v0), byte('A' + v1), byte('0' + v2), byte('0' + v3)}), true
+}
+
+func charOf(v uint32) byte {
+	switch {
+	case v < 10:
+		return byte('0' + v)
+	case v == 36:
+		return ' '
+	default:
+		return byte('A' + v - 10)
+	}
+}
@@ -0,0 +1,105 @@
+package wspr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		callsign string
+		locator  string
+		power    int
+		errors   int
+	}{
+		{"2 prefix, 3 suffix", "DB0ABC", "JN59", 10, 0},
+		{"1 prefix, 2 suffix", "G1AB", "IO91", 37, 0},
+		{"numeric prefix", "9A1AB", "JN75", 0, 0},
+		{"corner locator", "K1A", "AA00", 60, 0},
+		{"other corner locator", "W1AW", "RR99", 30, 0},
+		{"symbol errors", "DL1ABC", "JO62", 23, 12},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			transmission, err := ToTransmission(tC.callsign, tC.locator, tC.power)
+			require.NoError(t, err)
+			rng := rand.New(rand.NewSource(1))
+			for _, i := range rng.Perm(len(transmission))[:tC.errors] {
+				transmission[i] = Symbols[(int(transmission[i]/Sym1+0.5)+2)%4]
+			}
+
+			message, err := Decode(transmission)
+
+			require.NoError(t, err)
+			assert.Equal(t, Message{Type: Type1, Callsign: tC.callsign, Locator: tC.locator, Power: tC.power}, message)
+		})
+	}
+}
+
+func TestDecodeSoft(t *testing.T) {
+	transmission, err := ToTransmission("DL1ABC", "JO62", 23)
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(1))
+	var powers [162][4]float64
+	for i, symbol := range transmission {
+		for tone := range powers[i] {
+			powers[i][tone] = rng.ExpFloat64()
+		}
+		powers[i][int(symbol/Sym1+0.5)] += 3
+	}
+
+	message, err := DecodeSoft(powers)
+
+	require.NoError(t, err)
+	assert.Equal(t, Message{Type: Type1, Callsign: "DL1ABC", Locator: "JO62", Power: 23}, message)
+}
+
+func TestDecodeNoise(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var transmission Transmission
+	for i := range transmission {
+		transmission[i] = Symbols[rng.Intn(len(Symbols))]
+	}
+
+	_, err := Decode(transmission)
+
+	assert.Error(t, err)
+}
+
+func TestUnpackLocator(t *testing.T) {
+	for _, locator := range []string{"AA00", "JN59", "IO91", "RR99"} {
+		packed, err := packLocator(locator)
+		require.NoError(t, err)
+		actual, ok := unpackLocator(packed)
+		assert.True(t, ok)
+		assert.Equal(t, locator, actual)
+	}
+}
+
+func TestSignalMetrics(t *testing.T) {
+	transmission, err := ToTransmission("DL1ABC", "JO62", 23)
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(1))
+	var signal, noise [162][4]float64
+	for i, symbol := range transmission {
+		for tone := range signal[i] {
+			signal[i][tone] = rng.ExpFloat64()
+			noise[i][tone] = rng.ExpFloat64()
+		}
+		signal[i][int(symbol/Sym1+0.5)] += 10
+	}
+
+	metrics := SignalMetrics(signal, 0.5)
+	assert.True(t, metrics.Open)
+	assert.True(t, metrics.Quality > 0.7, "quality %v", metrics.Quality)
+	assert.InDelta(t, 10*math.Log10(10)-32.3, metrics.SNR, 2)
+
+	metrics = SignalMetrics(noise, 0.5)
+	assert.False(t, metrics.Open)
+	assert.True(t, metrics.Quality < 0.2, "quality %v", metrics.Quality)
+}
@@ -0,0 +1,247 @@
+package wspr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/digimodes/audio"
+	"github.com/ftl/digimodes/internal/stream"
+)
+
+const (
+	// window is the time in seconds to ramp the amplitude up and down at the start and the end of a transmission.
+	window = 0.005
+)
+
+// The audio frequency range of the WSPR sub-band above the dial frequency in Hz.
+const (
+	SubBandLow  = 1400.0
+	SubBandHigh = 1600.0
+)
+
+// RandomFrequency returns a random frequency for the lowest tone of a transmission, so that the signal is within
+// the WSPR sub-band. Choosing a new frequency for each cycle avoids that stations collide on a single frequency.
+func RandomFrequency(rng *rand.Rand) float64 {
+	return SubBandLow + rng.Float64()*(SubBandHigh-SubBandLow-WSPR2.Bandwidth())
+}
+
+// transmissionBufferSize is the number of transmissions that can be buffered between Transmit and Modulate.
+const transmissionBufferSize = 2
+
+var (
+	ErrWriteAborted   = errors.New("wspr: write aborted")
+	ErrInvalidMessage = errors.New("wspr: message must be \"<callsign> <locator> <power in dBm>\"")
+)
+
+// item is an element of the pipeline between Transmit and Modulate.
+type item struct {
+	transmission Transmission
+	token        chan struct{}
+}
+
+// Modulator generates the continuous phase 4-FSK audio signal of WSPR transmissions. The given frequency is the
+// frequency of the lowest tone, the tones of the symbols are above. Modulator implements the io.Writer interface,
+// the written text is a WSPR message in the form "<callsign> <locator> <power in dBm>".
+type Modulator struct {
+	transmissions *stream.Stream[item]
+	writeLock     sync.Mutex
+
+	mode         Mode
+	frequency    float64
+	rng          *rand.Rand
+	on           bool
+	start        float64
+	transmission Transmission
+	token        chan struct{}
+}
+
+// NewModulator returns a new WSPR-2 Modulator with the lowest tone at the given audio frequency.
+func NewModulator(frequency float64) *Modulator {
+	return NewModeModulator(WSPR2, frequency)
+}
+
+// NewModeModulator returns a new Modulator for the given mode with the lowest tone at the given audio frequency.
+func NewModeModulator(mode Mode, frequency float64) *Modulator {
+	return &Modulator{
+		transmissions: stream.New[item](transmissionBufferSize),
+		mode:          mode,
+		frequency:     frequency,
+	}
+}
+
+// RandomizeFrequency makes the modulator pick a random frequency within the WSPR sub-band for each transmission,
+// using the given source of random numbers. If rng is nil, a randomly seeded source is used. RandomizeFrequency
+// must be called before the first transmission.
+func (m *Modulator) RandomizeFrequency(rng *rand.Rand) {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	m.rng = rng
+}
+
+// Bandwidth returns the occupied bandwidth of the signal in Hz, which is the spacing of the four tones.
+func (m *Modulator) Bandwidth() float64 {
+	return m.mode.Bandwidth()
+}
+
+// SymbolRate returns the symbol rate of the signal in baud.
+func (m *Modulator) SymbolRate() float64 {
+	return 1 / m.mode.symbolTime()
+}
+
+// Pending returns the number of transmissions that are queued, but not started yet, and the duration to send them.
+func (m *Modulator) Pending() (transmissions int, duration time.Duration) {
+	transmissions = m.transmissions.Len()
+	return transmissions, time.Duration(transmissions*len(Transmission{})) * m.mode.SymbolDuration()
+}
+
+func (m *Modulator) Close() error {
+	m.transmissions.Close()
+	return nil
+}
+
+func (m *Modulator) AbortWhenDone(done <-chan struct{}) {
+	go func() {
+		select {
+		case <-done:
+			m.Close()
+		case <-m.transmissions.Done():
+		}
+	}()
+}
+
+// Write parses the given WSPR message and sends it. Messages with compound callsigns or 6 character locators
+// are sent as two consecutive transmissions. It returns when the message is sent completely.
+func (m *Modulator) Write(bytes []byte) (int, error) {
+	transmissions, err := parseMessage(string(bytes))
+	if err != nil {
+		return 0, err
+	}
+
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	for _, transmission := range transmissions {
+		err := m.transmit(transmission)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(bytes), nil
+}
+
+// Queue parses the given WSPR message and queues it like Write, but it does not block and does not wait until the
+// message is sent. The returned channel is closed when the message is sent completely. Queue implements
+// audio.Queuer, e.g. to render a transmission offline.
+func (m *Modulator) Queue(text string) (<-chan struct{}, error) {
+	transmissions, err := parseMessage(text)
+	if err != nil {
+		return nil, err
+	}
+
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	var token chan struct{}
+	for _, transmission := range transmissions {
+		token = make(chan struct{})
+		err := m.transmissions.Push(item{transmission: transmission, token: token})
+		if err != nil {
+			return nil, ErrWriteAborted
+		}
+	}
+	return token, nil
+}
+
+// parseMessage parses the given WSPR message, the callsign, the locator and the power in dBm, into its
+// transmissions.
+func parseMessage(text string) ([]Transmission, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 3 {
+		return nil, ErrInvalidMessage
+	}
+	dBm, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid power %q", ErrInvalidMessage, fields[2])
+	}
+	return ToTransmissions(fields[0], fields[1], dBm)
+}
+
+// Transmit sends the given transmission. It returns when the transmission is sent completely.
+func (m *Modulator) Transmit(transmission Transmission) error {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	return m.transmit(transmission)
+}
+
+func (m *Modulator) transmit(transmission Transmission) error {
+	token := make(chan struct{})
+	err := m.transmissions.Send(context.Background(), item{transmission: transmission, token: token})
+	if err != nil {
+		return ErrWriteAborted
+	}
+	select {
+	case <-token:
+		return nil
+	case <-m.transmissions.Done():
+		return ErrWriteAborted
+	}
+}
+
+// SamplesReader returns a reader that renders the signal as 16 bit PCM (mono, signed, little endian) with the
+// given sample rate, e.g. to pipe it into aplay -f S16_LE. The reader ends when the modulator is closed.
+func (m *Modulator) SamplesReader(sampleRate int) io.Reader {
+	return audio.NewPCMReader(m, sampleRate, audio.Int16, m.transmissions.Done())
+}
+
+func (m *Modulator) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	if m.on && t >= m.end() {
+		m.on = false
+		close(m.token)
+	}
+	if !m.on {
+		m.nextTransmission(t)
+	}
+	if !m.on {
+		return 0, m.frequency, p
+	}
+	end := m.end()
+
+	i := int((t - m.start) / m.mode.symbolTime())
+	frequency = m.frequency + float64(m.mode.Tone(m.transmission[i]))
+
+	amplitude = 1
+	if t-m.start < window {
+		amplitude = (t - m.start) / window
+	}
+	if end-t < window {
+		amplitude = (end - t) / window
+	}
+	return amplitude, frequency, p
+}
+
+func (m *Modulator) end() float64 {
+	return m.start + float64(len(m.transmission))*m.mode.symbolTime()
+}
+
+func (m *Modulator) nextTransmission(t float64) {
+	if m.transmissions.Closed() {
+		return
+	}
+	next, ok := m.transmissions.TryReceive()
+	if !ok {
+		return
+	}
+	if m.rng != nil {
+		m.frequency = RandomFrequency(m.rng)
+	}
+	m.on = true
+	m.start = t
+	m.transmission = next.transmission
+	m.token = next.token
+}
@@ -0,0 +1,122 @@
+package wspr
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes/audio"
+)
+
+func TestModulator(t *testing.T) {
+	transmission, err := ToTransmission("DB0ABC", "JN59", 12)
+	require.NoError(t, err)
+	m := NewModulator(1500)
+	defer m.Close()
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- m.Transmit(transmission)
+	}()
+
+	var a, f, p float64
+	for !m.on {
+		a, f, p = m.Modulate(1, a, f, p)
+	}
+	assert.Equal(t, 0.0, a, "ramp up")
+	start := 1.0
+	for i, symbol := range transmission {
+		now := start + (float64(i)+0.5)*WSPR2.symbolTime()
+		a, f, p = m.Modulate(now, a, f, p)
+		assert.Equal(t, 1.0, a, "symbol %d", i)
+		assert.Equal(t, 1500+float64(symbol), f, "symbol %d", i)
+	}
+
+	end := start + 162*WSPR2.symbolTime()
+	a, _, _ = m.Modulate(end-window/2, a, f, p)
+	assert.InDelta(t, 0.5, a, 1e-6, "ramp down")
+	a, _, _ = m.Modulate(end, a, f, p)
+	assert.Equal(t, 0.0, a)
+	require.NoError(t, <-sent)
+}
+
+// startObserver passes the frequency of each started transmission of the wrapped modulator to a callback.
+type startObserver struct {
+	*Modulator
+	started func(frequency float64)
+}
+
+func (o startObserver) Modulate(t, a, f, p float64) (amplitude, frequency, phase float64) {
+	// consecutive transmissions follow each other without a gap, each one has its own token
+	token := o.token
+	amplitude, frequency, phase = o.Modulator.Modulate(t, a, f, p)
+	if o.on && o.token != token {
+		o.started(frequency)
+	}
+	return amplitude, frequency, phase
+}
+
+// renderText queues the given text in the given modulator and returns the frequencies of the started transmissions.
+func renderText(t *testing.T, m *Modulator, text string) []float64 {
+	var frequencies []float64
+	observer := startObserver{Modulator: m, started: func(frequency float64) {
+		frequencies = append(frequencies, frequency)
+	}}
+	// the modulator only changes the tone at the symbol boundaries, a low sample rate is sufficient
+	err := audio.NewRenderer(observer, 100).RenderText(text, 0, 1000*time.Second, func([]float64) error {
+		return nil
+	})
+	require.NoError(t, err)
+	return frequencies
+}
+
+func TestModulatorWrite(t *testing.T) {
+	m := NewModulator(1500)
+	defer m.Close()
+
+	_, err := m.Write([]byte("DB0ABC JN59"))
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+
+	frequencies := renderText(t, m, "PJ4/K1ABC FK52UD 37")
+	assert.Equal(t, 2, len(frequencies))
+}
+
+func TestModulatorAbort(t *testing.T) {
+	m := NewModulator(1500)
+	done := make(chan struct{})
+	m.AbortWhenDone(done)
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- m.Transmit(Transmission{})
+	}()
+	close(done)
+	assert.Equal(t, ErrWriteAborted, <-sent)
+}
+
+func TestRandomFrequency(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		frequency := RandomFrequency(rng)
+		assert.GreaterOrEqual(t, frequency, SubBandLow)
+		assert.LessOrEqual(t, frequency+WSPR2.Bandwidth(), SubBandHigh)
+	}
+}
+
+func TestModulatorRandomizeFrequency(t *testing.T) {
+	m := NewModulator(1500)
+	defer m.Close()
+	m.RandomizeFrequency(rand.New(rand.NewSource(1)))
+
+	frequencies := renderText(t, m, "PJ4/K1ABC FK52UD 37")
+
+	require.Equal(t, 2, len(frequencies))
+	assert.NotEqual(t, frequencies[0], frequencies[1])
+	for _, frequency := range frequencies {
+		assert.True(t, frequency >= SubBandLow && frequency <= SubBandHigh, "%f", frequency)
+	}
+}
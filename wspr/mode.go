@@ -0,0 +1,46 @@
+package wspr
+
+import "time"
+
+// Mode is a variant of WSPR. All variants use the same encoding, they differ in the length of the symbols and the
+// period of the transmission cycles. The tone spacing is the inverse of the symbol length.
+type Mode struct {
+	Name string
+	// SymbolLength is the length of one symbol in samples at 12000 Hz.
+	SymbolLength int
+	// Period is the period of the transmission cycles.
+	Period time.Duration
+}
+
+// The WSPR modes.
+var (
+	// WSPR2 is the common WSPR mode with two minute cycles.
+	WSPR2 = Mode{Name: "WSPR-2", SymbolLength: 8192, Period: DefaultPeriod}
+	// WSPR15 is the slow WSPR mode for LF and MF with quarter hour cycles.
+	WSPR15 = Mode{Name: "WSPR-15", SymbolLength: 8 * 8192, Period: 15 * time.Minute}
+)
+
+// symbolTime returns the duration of one symbol in seconds.
+func (m Mode) symbolTime() float64 {
+	return float64(m.SymbolLength) / 12000
+}
+
+// SymbolDuration returns the duration of one symbol.
+func (m Mode) SymbolDuration() time.Duration {
+	return time.Duration(m.symbolTime() * float64(time.Second))
+}
+
+// ToneSpacing returns the distance between two adjacent tones in Hz.
+func (m Mode) ToneSpacing() float64 {
+	return 12000 / float64(m.SymbolLength)
+}
+
+// Bandwidth returns the bandwidth of the signal in Hz.
+func (m Mode) Bandwidth() float64 {
+	return float64(len(Symbols)) * m.ToneSpacing()
+}
+
+// Tone returns the frequency offset of the given symbol to the base frequency in this mode.
+func (m Mode) Tone(symbol Symbol) Symbol {
+	return Symbol(float64(symbol) / symbolDelta * m.ToneSpacing())
+}
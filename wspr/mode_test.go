@@ -0,0 +1,25 @@
+package wspr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMode(t *testing.T) {
+	assert.Equal(t, 682666666*time.Nanosecond, WSPR2.SymbolDuration())
+	assert.Equal(t, symbolDelta, WSPR2.ToneSpacing())
+	assert.Equal(t, Sym3, WSPR2.Tone(Sym3))
+	assert.InDelta(t, 5.86, WSPR2.Bandwidth(), 0.01)
+
+	assert.Equal(t, 5461333333*time.Nanosecond, WSPR15.SymbolDuration())
+	assert.InDelta(t, 0.183, WSPR15.ToneSpacing(), 0.001)
+	assert.Equal(t, Symbol(3*WSPR15.ToneSpacing()), WSPR15.Tone(Sym3))
+	assert.InDelta(t, 0.73, WSPR15.Bandwidth(), 0.01)
+
+	m := NewModeModulator(WSPR15, 1500)
+	defer m.Close()
+	assert.InDelta(t, 0.183, m.SymbolRate(), 0.001)
+	assert.Equal(t, WSPR15.Bandwidth(), m.Bandwidth())
+}
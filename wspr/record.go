@@ -0,0 +1,197 @@
+package wspr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ftl/digimodes"
+	"github.com/ftl/digimodes/dsp"
+)
+
+const (
+	// recordQueueSize is the number of records that are buffered by a RecordDecoder.
+	recordQueueSize = 4
+	// maxStartOffset is the latest start of a transmission after the start of its cycle that is searched.
+	maxStartOffset = 3 * time.Second
+	// startSearchSteps is the number of steps per symbol of the search for the start of a transmission.
+	startSearchSteps = 4
+	// minSyncQuality is the minimum correlation with the sync vector to try to decode a transmission.
+	minSyncQuality = 0.2
+	// noiseSpan is the distance from the tones in Hz within which the noise floor is measured.
+	noiseSpan = 150.0
+	// noiseAveraging is the weight of a new analysis block in the averaged spectrum of the noise floor, it
+	// averages over about the last 15 seconds.
+	noiseAveraging = 0.01
+)
+
+// String returns the message in the usual notation "<callsign> <locator> <power>". An unresolved hash of a
+// type 3 message is written as "<...>".
+func (m Message) String() string {
+	switch m.Type {
+	case Type2:
+		return fmt.Sprintf("%s %d", m.Callsign, m.Power)
+	case Type3:
+		callsign := m.Callsign
+		if callsign == "" {
+			callsign = "..."
+		}
+		return fmt.Sprintf("<%s> %s %d", callsign, m.Locator, m.Power)
+	default:
+		return fmt.Sprintf("%s %s %d", m.Callsign, m.Locator, m.Power)
+	}
+}
+
+// RecordDecoder decodes WSPR transmissions with the lowest tone at a fixed audio frequency and delivers the
+// decoded messages as decode records. It buffers the samples of each transmission cycle and searches the start of
+// the transmission within the first three seconds of the cycle. Cycles that are not received from their start are
+// skipped. The time of a record is the start of the transmission. The SNR of a record is the power of the tones
+// relative to the noise floor around the transmission. It implements digimodes.Decoder.
+type RecordDecoder struct {
+	*digimodes.RecordQueue
+
+	mode       Mode
+	frequency  float64
+	sampleRate int
+	clock      *digimodes.SampleClock
+	snr        *dsp.SNREstimator
+
+	symbolLength int
+	cycle        time.Time
+	complete     bool
+	buffer       []float64
+}
+
+// NewRecordDecoder returns a new WSPR-2 RecordDecoder with the lowest tone at the given audio frequency. The first
+// sample that is fed into the decoder was taken at the given start time.
+func NewRecordDecoder(frequency float64, sampleRate int, start time.Time) *RecordDecoder {
+	return NewModeRecordDecoder(WSPR2, frequency, sampleRate, start)
+}
+
+// NewModeRecordDecoder returns a new RecordDecoder for the given mode with the lowest tone at the given audio
+// frequency.
+func NewModeRecordDecoder(mode Mode, frequency float64, sampleRate int, start time.Time) *RecordDecoder {
+	snr := dsp.NewSNREstimator(sampleRate, frequency-noiseSpan, frequency+mode.Bandwidth()+noiseSpan)
+	snr.SetAveraging(noiseAveraging)
+	return &RecordDecoder{
+		RecordQueue:  digimodes.NewRecordQueue(recordQueueSize),
+		mode:         mode,
+		frequency:    frequency,
+		sampleRate:   sampleRate,
+		clock:        digimodes.NewSampleClock(start, sampleRate),
+		snr:          snr,
+		symbolLength: int(math.Round(mode.symbolTime() * float64(sampleRate))),
+	}
+}
+
+// Feed buffers the given samples and decodes the transmission of a cycle as soon as it is received completely.
+func (d *RecordDecoder) Feed(ctx context.Context, samples []float64) error {
+	if d.Closed() {
+		return digimodes.ErrDecoderClosed
+	}
+	d.snr.Process(samples)
+	for len(samples) > 0 {
+		now := d.clock.Now()
+		cycle := now.Truncate(d.mode.Period)
+		if !cycle.Equal(d.cycle) {
+			d.cycle = cycle
+			d.buffer = d.buffer[:0]
+			// a transmission that started before the first sample cannot be decoded
+			d.complete = now.After(cycle)
+		}
+
+		n := int(math.Ceil(cycle.Add(d.mode.Period).Sub(now).Seconds() * float64(d.sampleRate)))
+		if n > len(samples) {
+			n = len(samples)
+		}
+		if !d.complete {
+			d.buffer = append(d.buffer, samples[:n]...)
+		}
+		d.clock.Advance(n)
+		samples = samples[n:]
+
+		if d.complete || len(d.buffer) < d.searchLength() {
+			continue
+		}
+		d.complete = true
+		record, ok := d.decode()
+		if !ok {
+			continue
+		}
+		if err := d.Emit(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchLength is the number of samples that are buffered to search the transmission.
+func (d *RecordDecoder) searchLength() int {
+	maxStart := int(maxStartOffset.Seconds() * float64(d.sampleRate))
+	return maxStart + len(Transmission{})*d.symbolLength
+}
+
+// decode searches the start of the transmission with the best correlation to the sync vector and decodes it.
+func (d *RecordDecoder) decode() (digimodes.DecodeRecord, bool) {
+	maxStart := int(maxStartOffset.Seconds() * float64(d.sampleRate))
+	step := d.symbolLength / startSearchSteps
+	if step < 1 {
+		step = 1
+	}
+
+	var best [162][4]float64
+	bestStart := 0
+	bestMetrics := digimodes.Metrics{}
+	for start := 0; start <= maxStart; start += step {
+		powers := d.tonePowers(start)
+		metrics := SignalMetrics(powers, minSyncQuality)
+		if metrics.Quality > bestMetrics.Quality {
+			best = powers
+			bestStart = start
+			bestMetrics = metrics
+		}
+	}
+	if !bestMetrics.Open {
+		return digimodes.DecodeRecord{}, false
+	}
+
+	message, err := DecodeSoft(best)
+	if err != nil {
+		return digimodes.DecodeRecord{}, false
+	}
+	return digimodes.DecodeRecord{
+		Time:           d.cycle.Add(time.Duration(bestStart) * time.Second / time.Duration(d.sampleRate)),
+		Mode:           "wspr",
+		AudioFrequency: d.frequency,
+		Text:           message.String(),
+		SNR:            d.signalSNR(best),
+	}, true
+}
+
+// signalSNR returns the SNR of the transmission with the given tone powers. The power of the strongest tone of each
+// symbol is corrected by the noise in the other tones.
+func (d *RecordDecoder) signalSNR(powers [162][4]float64) float64 {
+	signal := 0.0
+	for _, p := range powers {
+		strongest := math.Max(math.Max(p[0], p[1]), math.Max(p[2], p[3]))
+		signal += strongest - (p[0]+p[1]+p[2]+p[3]-strongest)/3
+	}
+	// the tone powers are squared amplitudes, the power of a sine wave is half of its squared amplitude
+	return d.snr.SignalSNR(signal / float64(len(powers)) / 2)
+}
+
+// tonePowers measures the power of the four tones of each symbol of a transmission that starts with the given
+// sample in the buffer.
+func (d *RecordDecoder) tonePowers(start int) [162][4]float64 {
+	var result [162][4]float64
+	for i := range result {
+		symbol := d.buffer[start+i*d.symbolLength : start+(i+1)*d.symbolLength]
+		for tone := range result[i] {
+			frequency := d.frequency + float64(d.mode.Tone(Symbols[tone]))
+			magnitude := dsp.GoertzelMagnitude(symbol, frequency, d.sampleRate)
+			result[i][tone] = magnitude * magnitude
+		}
+	}
+	return result
+}
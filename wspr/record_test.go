@@ -0,0 +1,86 @@
+package wspr
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/digimodes"
+)
+
+// render renders the given transmission as continuous phase 4-FSK with the lowest tone at the given frequency,
+// starting after the given delay, into a cycle of WSPR-2 with the given noise.
+func render(transmission Transmission, frequency float64, delay time.Duration, sampleRate int, noise float64) []float64 {
+	result := make([]float64, int(WSPR2.Period.Seconds())*sampleRate)
+	rng := rand.New(rand.NewSource(1))
+	for i := range result {
+		result[i] = noise * rng.NormFloat64()
+	}
+	start := int(delay.Seconds() * float64(sampleRate))
+	symbolLength := int(WSPR2.symbolTime() * float64(sampleRate))
+	phase := 0.0
+	for i, symbol := range transmission {
+		for j := 0; j < symbolLength; j++ {
+			result[start+i*symbolLength+j] += 0.5 * math.Sin(phase)
+			phase = math.Mod(phase+2*math.Pi*(frequency+float64(symbol))/float64(sampleRate), 2*math.Pi)
+		}
+	}
+	return result
+}
+
+func TestMessageString(t *testing.T) {
+	assert.Equal(t, "DL1ABC JO62 23", Message{Type: Type1, Callsign: "DL1ABC", Locator: "JO62", Power: 23}.String())
+	assert.Equal(t, "PJ4/K1ABC 37", Message{Type: Type2, Callsign: "PJ4/K1ABC", Power: 37}.String())
+	assert.Equal(t, "<...> FK52UD 37", Message{Type: Type3, Hash: 1234, Locator: "FK52UD", Power: 37}.String())
+}
+
+func TestRecordDecoder(t *testing.T) {
+	const sampleRate = 12000
+	cycle := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	transmission, err := ToTransmission("DL1ABC", "JO62", 23)
+	require.NoError(t, err)
+	samples := render(transmission, 1500, 1500*time.Millisecond, sampleRate, 0.5)
+
+	decoder := NewRecordDecoder(1500, sampleRate, cycle)
+	var _ digimodes.Decoder = decoder
+	for i := 0; i < len(samples); i += 4096 {
+		end := i + 4096
+		if end > len(samples) {
+			end = len(samples)
+		}
+		require.NoError(t, decoder.Feed(context.Background(), samples[i:end]))
+	}
+	require.NoError(t, decoder.Close())
+
+	records := make([]digimodes.DecodeRecord, 0)
+	for record := range decoder.Records() {
+		records = append(records, record)
+	}
+	require.Len(t, records, 1)
+	assert.Equal(t, "DL1ABC JO62 23", records[0].Text)
+	assert.Equal(t, "wspr", records[0].Mode)
+	assert.Equal(t, 1500.0, records[0].AudioFrequency)
+	assert.WithinDuration(t, cycle.Add(1500*time.Millisecond), records[0].Time, 200*time.Millisecond)
+	// a sine wave with the amplitude 0.5 in white noise with sigma 0.5 at 12000 Hz
+	assert.InDelta(t, 10*math.Log10(0.125/(0.25/6000*2500)), records[0].SNR, 1)
+}
+
+func TestRecordDecoderSkipsIncompleteCycle(t *testing.T) {
+	const sampleRate = 12000
+	cycle := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	transmission, err := ToTransmission("DL1ABC", "JO62", 23)
+	require.NoError(t, err)
+	samples := render(transmission, 1500, time.Second, sampleRate, 0)
+
+	decoder := NewRecordDecoder(1500, sampleRate, cycle.Add(500*time.Millisecond))
+	require.NoError(t, decoder.Feed(context.Background(), samples[sampleRate/2:]))
+	require.NoError(t, decoder.Close())
+
+	_, ok := <-decoder.Records()
+	assert.False(t, ok)
+}
@@ -0,0 +1,61 @@
+package wspr
+
+import (
+	"context"
+	"time"
+
+	"github.com/ftl/digimodes/sched"
+	"github.com/ftl/digimodes/timesource"
+)
+
+// DefaultPeriod is the period of the WSPR transmission cycles, the transmissions start at even minutes.
+const DefaultPeriod = sched.WSPR
+
+// Clock is the time base of a Scheduler.
+type Clock = sched.Clock
+
+// RealClock returns a Clock that waits in real time and reads the current time from the given clock, e.g. a
+// timesource.DisciplinedClock. If clock is nil, the system clock is used.
+func RealClock(clock timesource.Clock) Clock {
+	return sched.RealClock(clock)
+}
+
+// Scheduler aligns transmissions to the start of the transmission cycles. The cycles have a fixed period, counted
+// from midnight UTC, and the transmissions start with the given offset into a cycle.
+type Scheduler struct {
+	slots *sched.Scheduler
+	mode  Mode
+}
+
+// NewScheduler returns a new Scheduler for WSPR-2 transmissions with the given clock, period and offset. If clock
+// is nil, the system clock is used.
+func NewScheduler(clock Clock, period time.Duration, offset time.Duration) *Scheduler {
+	return &Scheduler{
+		slots: sched.New(clock, period, offset),
+		mode:  WSPR2,
+	}
+}
+
+// NewModeScheduler returns a new Scheduler for transmissions in the given mode with the given clock and offset.
+// The period of the cycles is the period of the mode. If clock is nil, the system clock is used.
+func NewModeScheduler(clock Clock, mode Mode, offset time.Duration) *Scheduler {
+	result := NewScheduler(clock, mode.Period, offset)
+	result.mode = mode
+	return result
+}
+
+// DefaultScheduler returns a new Scheduler that starts the transmissions at the even minutes of the system clock.
+func DefaultScheduler() *Scheduler {
+	return NewScheduler(nil, DefaultPeriod, 0)
+}
+
+// NextStart returns the start of the next transmission at or after the given time.
+func (s *Scheduler) NextStart(t time.Time) time.Time {
+	return s.slots.NextStart(t)
+}
+
+// WaitForStart waits for the start of the next transmission and returns its time. It returns false if the given
+// context is done before.
+func (s *Scheduler) WaitForStart(ctx context.Context) (time.Time, bool) {
+	return s.slots.WaitForStart(ctx)
+}
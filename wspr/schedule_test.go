@@ -0,0 +1,143 @@
+package wspr
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances instantly when waiting.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	result := make(chan time.Time, 1)
+	result <- c.now
+	return result, func() bool { return false }
+}
+
+type recordingProgress struct {
+	events  []string
+	symbols int
+}
+
+func (p *recordingProgress) OnWait(start time.Time) {
+	p.events = append(p.events, "wait "+start.Format("15:04:05"))
+}
+
+func (p *recordingProgress) OnStart() {
+	p.events = append(p.events, "start")
+}
+
+func (p *recordingProgress) OnSymbol(i int) {
+	p.symbols++
+}
+
+func (p *recordingProgress) OnEnd() {
+	p.events = append(p.events, "end")
+}
+
+func TestSchedulerNextStart(t *testing.T) {
+	at := func(hour, min, sec int) time.Time {
+		return time.Date(2024, 3, 1, hour, min, sec, 0, time.UTC)
+	}
+	testCases := []struct {
+		desc     string
+		period   time.Duration
+		offset   time.Duration
+		now      time.Time
+		expected time.Time
+	}{
+		{"exact", DefaultPeriod, 0, at(12, 2, 0), at(12, 2, 0)},
+		{"odd minute", DefaultPeriod, 0, at(12, 3, 0), at(12, 4, 0)},
+		{"just after the start", DefaultPeriod, 0, at(12, 2, 1), at(12, 4, 0)},
+		{"offset", DefaultPeriod, time.Second, at(12, 2, 0), at(12, 2, 1)},
+		{"offset passed", DefaultPeriod, time.Second, at(12, 2, 2), at(12, 4, 1)},
+		{"quarter hour", 15 * time.Minute, 0, at(12, 1, 0), at(12, 15, 0)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			scheduler := NewScheduler(&fakeClock{}, tC.period, tC.offset)
+			assert.Equal(t, tC.expected, scheduler.NextStart(tC.now))
+		})
+	}
+}
+
+func TestSchedulerSend(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 3, 10, 0, time.UTC)}
+	scheduler := NewScheduler(clock, DefaultPeriod, 0)
+	transmission, err := ToTransmission("DB0ABC", "JN59", 12)
+	assert.NoError(t, err)
+
+	progress := &recordingProgress{}
+	var active []bool
+	var symbols []Symbol
+	var startTime time.Time
+	ok := scheduler.Send(context.Background(), progress, func(on bool) {
+		active = append(active, on)
+		if on {
+			startTime = clock.Now()
+		}
+	}, func(symbol Symbol) {
+		symbols = append(symbols, symbol)
+	}, transmission)
+
+	assert.True(t, ok)
+	assert.Equal(t, []bool{true, false}, active)
+	assert.Equal(t, transmission[:], symbols)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 4, 0, 0, time.UTC), startTime)
+	assert.Equal(t, startTime.Add(110592*time.Millisecond), clock.Now(), "162 symbols of 8192 samples at 12 kHz")
+	assert.Equal(t, []string{"wait 12:04:00", "start"}, progress.events[:2])
+	assert.Equal(t, 162, progress.symbols)
+	assert.Equal(t, "end", progress.events[len(progress.events)-1])
+}
+
+func TestSchedulerSendCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduler := NewScheduler(&fakeClock{now: time.Date(2024, 3, 1, 12, 3, 10, 0, time.UTC)}, DefaultPeriod, 0)
+
+	var active []bool
+	ok := scheduler.Send(ctx, nil, func(on bool) { active = append(active, on) }, func(Symbol) {}, Transmission{})
+
+	assert.False(t, ok)
+	assert.Equal(t, []bool{false}, active)
+}
+
+func TestModeSchedulerSend(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 3, 10, 0, time.UTC)}
+	scheduler := NewModeScheduler(clock, WSPR15, 0)
+	transmission, err := ToTransmission("DB0ABC", "JN59", 12)
+	assert.NoError(t, err)
+
+	var symbols []Symbol
+	var startTime time.Time
+	ok := scheduler.Send(context.Background(), nil, func(on bool) {
+		if on {
+			startTime = clock.Now()
+		}
+	}, func(symbol Symbol) {
+		symbols = append(symbols, symbol)
+	}, transmission)
+
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 15, 0, 0, time.UTC), startTime)
+	assert.Equal(t, startTime.Add(8*110592*time.Millisecond), clock.Now())
+	for i, symbol := range symbols {
+		assert.Equal(t, transmission[i]/8, symbol)
+	}
+}
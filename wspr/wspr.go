@@ -13,55 +13,95 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"github.com/ftl/digimodes/fec"
+	"github.com/ftl/digimodes/locator"
 )
 
-// Send transmits the given transmission using the given functions to activate the transmitter and to transmit the symbol.
+// Progress is notified about the progress of a transmission.
+type Progress interface {
+	// OnWait is called when the transmission waits for the cycle that starts at the given time.
+	OnWait(start time.Time)
+	// OnStart is called when the transmission starts.
+	OnStart()
+	// OnSymbol is called when the symbol with the given index is transmitted.
+	OnSymbol(i int)
+	// OnEnd is called when the transmission ends completely.
+	OnEnd()
+}
+
+// LogProgress reports the progress of a transmission with the standard logger and a dot per symbol on stdout.
+type LogProgress struct{}
+
+func (LogProgress) OnWait(start time.Time) {
+	log.Printf("waiting for next transmission cycle at %s", start.Format("15:04:05"))
+}
+
+func (LogProgress) OnStart() {
+	log.Print("transmission start")
+}
+
+func (LogProgress) OnSymbol(int) {
+	fmt.Print(".")
+}
+
+func (LogProgress) OnEnd() {
+	fmt.Println()
+	log.Print("transmission end")
+}
+
+type noProgress struct{}
+
+func (noProgress) OnWait(time.Time) {}
+func (noProgress) OnStart()         {}
+func (noProgress) OnSymbol(int)     {}
+func (noProgress) OnEnd()           {}
+
+// Send transmits the given transmission at the next even minute of the system clock using the given functions to
+// activate the transmitter and to transmit the symbol.
 func Send(ctx context.Context, activateTransmitter func(bool), transmitSymbol func(Symbol), transmission Transmission) bool {
+	return DefaultScheduler().Send(ctx, nil, activateTransmitter, transmitSymbol, transmission)
+}
+
+// Send transmits the given transmission at the start of the next transmission cycle using the given functions to
+// activate the transmitter and to transmit the symbol. The symbols are converted to the tones of the mode of the
+// scheduler. The given progress is notified about the transmission, it
+// may be nil.
+func (s *Scheduler) Send(ctx context.Context, progress Progress, activateTransmitter func(bool), transmitSymbol func(Symbol), transmission Transmission) bool {
+	if progress == nil {
+		progress = noProgress{}
+	}
+	start := s.slots.NextStart(s.slots.Now())
+	progress.OnWait(start)
+	return s.sendAt(ctx, start, progress, activateTransmitter, transmitSymbol, transmission)
+}
+
+func (s *Scheduler) sendAt(ctx context.Context, start time.Time, progress Progress, activateTransmitter func(bool), transmitSymbol func(Symbol), transmission Transmission) bool {
 	defer activateTransmitter(false)
-	if !waitForTransmitStart(ctx) {
+	if !s.slots.WaitUntil(ctx, start) {
 		return false
 	}
 
-	log.Print("transmission start")
+	progress.OnStart()
 
 	for i, symbol := range transmission {
-		fmt.Print(".")
+		progress.OnSymbol(i)
 
-		transmitSymbol(symbol)
+		transmitSymbol(s.mode.Tone(symbol))
 		if i == 0 {
 			activateTransmitter(true)
 		}
 
-		select {
-		case <-time.After(SymbolDuration):
-		case <-ctx.Done():
+		// the symbols are timed relative to the start to avoid accumulating delays
+		if !s.slots.WaitUntil(ctx, start.Add(time.Duration(float64(i+1)*s.mode.symbolTime()*float64(time.Second)))) {
 			return false
 		}
 	}
 
-	fmt.Println()
-	log.Print("transmission end")
+	progress.OnEnd()
 	return true
 }
 
-func waitForTransmitStart(ctx context.Context) bool {
-	for {
-		log.Print("waiting for next transmission cycle")
-		select {
-		case <-ctx.Done():
-			return false
-		case now := <-time.After(1 * time.Second):
-			if isTransmitStart(now) {
-				return true
-			}
-		}
-	}
-}
-
-func isTransmitStart(t time.Time) bool {
-	return t.Minute()%2 == 0 && t.Second() == 0
-}
-
 // Symbol in WSPR. The value represents the delta to the base frequency.
 type Symbol float64
 
@@ -96,12 +136,14 @@ func ToTransmission(callsign string, locator string, dBm int) (Transmission, err
 	}
 	m = packPower(m, dBm)
 
+	return encode(n, m), nil
+}
+
+func encode(n, m uint32) Transmission {
 	c := compress(n, m)
 	parity := calcParity(c)
 	interleaved := interleave(parity)
-	transmission := synchronize(interleaved)
-
-	return transmission, nil
+	return synchronize(interleaved)
 }
 
 func packCallsign(callsign string) (uint32, error) {
@@ -157,22 +199,18 @@ func alignCallsign(callsign string) ([]byte, error) {
 
 func packLocator(loc string) (uint32, error) {
 	if len(loc) < 4 {
-		return 0, errors.New("locator must have at least four characters")
+		return 0, ErrInvalidLocator
 	}
-
-	normalized := strings.ToUpper(string(loc[0:4]))
-	if !(isLocatorLetter(normalized[0]) && isLocatorLetter(normalized[1])) {
-		return 0, errors.New("locator must have letters a the 1st and the 2nd position")
-	}
-	if !(isNumber(normalized[2]) && isNumber(normalized[3])) {
-		return 0, errors.New("locator must have numbers at the 3rd and 4th position")
+	square, err := locator.Parse(loc[0:4])
+	if err != nil {
+		return 0, ErrInvalidLocator
 	}
 
 	v := func(i int) uint32 {
 		if i < 2 {
-			return charValue(normalized[i]) - 10
+			return charValue(square[i]) - 10
 		}
-		return charValue(normalized[i])
+		return charValue(square[i])
 	}
 
 	packed := (179-10*v(0)-v(2))*180 + 10*v(1) + v(3)
@@ -193,10 +231,6 @@ func isLetter(b byte) bool {
 	return b >= 'A' && b <= 'Z'
 }
 
-func isLocatorLetter(b byte) bool {
-	return b >= 'A' && b <= 'R'
-}
-
 func isSpace(b byte) bool {
 	return b == ' '
 }
@@ -228,43 +262,11 @@ func compress(n, m uint32) (c [11]byte) {
 }
 
 func calcParity(c [11]byte) (parity [162]byte) {
-	const (
-		polynom1 = uint32(0xf2d05351)
-		polynom2 = uint32(0xe4613c47)
-	)
-
-	var (
-		reg0, reg1 uint32
-	)
-
-	parityIndex := 0
-	for i := 0; i < len(c); i++ {
-		for j := 7; j >= 0; j-- {
-			reg0 = (reg0 << 1) | uint32((c[i]>>uint8(j))&0x01)
-			reg1 = reg0
-
-			result0 := reg0 & polynom1
-			result1 := reg1 & polynom2
-			count0 := 0
-			count1 := 0
-			for k := 0; k < 32; k++ {
-				if ((result0 >> uint8(k)) & 0x01) == 1 {
-					count0++
-				}
-				if ((result1 >> uint8(k)) & 0x01) == 1 {
-					count1++
-				}
-			}
-			if count0%2 == 1 {
-				parity[parityIndex] = 1
-			}
-			parityIndex++
-			if count1%2 == 1 {
-				parity[parityIndex] = 1
-			}
-			parityIndex++
-		}
+	bits := make([]byte, dataBits)
+	for i := range bits {
+		bits[i] = (c[i/8] >> (7 - i%8)) & 0x01
 	}
+	copy(parity[:], fec.WSPR.Encode(bits))
 	return
 }
 
@@ -287,13 +289,15 @@ func interleave(parity [162]byte) (interleaved [162]byte) {
 	return
 }
 
+// syncWord is the sequence of synchronization bits, one bit per symbol.
+var syncWord = [162]byte{
+	1, 1, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 1, 1, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 0, 0,
+	0, 0, 0, 0, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1, 0, 0, 0, 1, 1, 0, 1, 0, 0, 0, 0, 1, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 0, 1, 0, 0, 1, 0,
+	1, 1, 0, 0, 0, 1, 1, 0, 1, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 1, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1, 0, 0, 0, 1,
+	1, 1, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 0, 1, 1, 0, 0, 0, 1, 1, 0, 0, 0,
+}
+
 func synchronize(interleaved [162]byte) (transmission Transmission) {
-	syncWord := []byte{
-		1, 1, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 1, 1, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 0, 0,
-		0, 0, 0, 0, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1, 0, 0, 0, 1, 1, 0, 1, 0, 0, 0, 0, 1, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 0, 1, 0, 0, 1, 0,
-		1, 1, 0, 0, 0, 1, 1, 0, 1, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 1, 1, 0, 1, 1, 0, 0, 1, 1, 0, 1, 0, 0, 0, 1,
-		1, 1, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 0, 1, 1, 0, 0, 0, 1, 1, 0, 0, 0,
-	}
 	for i := 0; i < len(interleaved); i++ {
 		transmission[i] = Symbols[syncWord[i]+2*interleaved[i]]
 	}